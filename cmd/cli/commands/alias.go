@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	"github.com/spf13/cobra"
+)
+
+func newAliasCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage short names that resolve to full model references",
+	}
+	c.AddCommand(
+		withStandaloneRunner(newAliasSetCmd()),
+		withStandaloneRunner(newAliasListCmd()),
+		withStandaloneRunner(newAliasRemoveCmd()),
+	)
+	return c
+}
+
+func newAliasSetCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "set ALIAS MODEL",
+		Short: "Map an alias to a local model",
+		Args:  requireExactArgs(2, "alias set", "ALIAS MODEL"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := desktopClient.SetAlias(args[0], args[1]); err != nil {
+				return handleClientError(err, "Failed to set alias")
+			}
+			cmd.Printf("Alias %q now resolves to %q\n", args[0], args[1])
+			return nil
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	return c
+}
+
+func newAliasListCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List model aliases",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases, err := desktopClient.ListAliases()
+			if err != nil {
+				return handleClientError(err, "Failed to list aliases")
+			}
+			cmd.Print(aliasTable(aliases))
+			return nil
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	return c
+}
+
+func newAliasRemoveCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "rm ALIAS",
+		Short: "Remove a model alias",
+		Args:  requireExactArgs(1, "alias rm", "ALIAS"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := desktopClient.RemoveAlias(args[0]); err != nil {
+				return handleClientError(err, "Failed to remove alias")
+			}
+			cmd.Printf("Alias %q removed\n", args[0])
+			return nil
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	return c
+}
+
+func aliasTable(aliases map[string]string) string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	table := newTable(&buf)
+	table.Header([]string{"ALIAS", "MODEL"})
+	for _, name := range names {
+		table.Append([]string{name, aliases[name]})
+	}
+	table.Render()
+	return buf.String()
+}
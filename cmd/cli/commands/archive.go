@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/docker/go-units"
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	"github.com/docker/model-runner/cmd/cli/desktop"
+	"github.com/spf13/cobra"
+)
+
+func newArchiveCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "archive MODEL [MODEL...]",
+		Short: "Remove the weights of local models while keeping them listed",
+		Long: "Remove the weights of local models to free disk space. Archived models keep their tags and " +
+			"configuration, so they are still listed and can be inspected, but must be restored with " +
+			"'docker model unarchive' or pulled again before they can be run.",
+		Args: requireMinArgs(1, "archive", "MODEL [MODEL...]"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, model := range args {
+				response, err := desktopClient.Archive(model)
+				if err != nil {
+					return handleClientError(err, "Failed to archive model")
+				}
+				cmd.Printf("Archived %s, reclaiming %s\n", model, units.HumanSize(float64(response.Reclaimed)))
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.ModelNames(getDesktopClient, -1),
+	}
+	return c
+}
+
+func newUnarchiveCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "unarchive MODEL [MODEL...]",
+		Short: "Restore archived models, pulling their weights again",
+		Args:  requireMinArgs(1, "unarchive", "MODEL [MODEL...]"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, model := range args {
+				if err := unarchiveModel(cmd, desktopClient, model); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.ModelNames(getDesktopClient, -1),
+	}
+	return c
+}
+
+// unarchiveModel restores an archived model, pulling it again if its weights
+// aren't in the store.
+func unarchiveModel(cmd *cobra.Command, desktopClient *desktop.Client, model string) error {
+	err := desktopClient.Unarchive(model)
+	if err == nil {
+		cmd.Printf("Unarchived %s\n", model)
+		return nil
+	}
+	if !errors.Is(err, desktop.ErrModelArchived) {
+		return handleClientError(err, "Failed to unarchive model")
+	}
+	cmd.Printf("Pulling %s to restore its weights\n", model)
+	return pullModel(cmd, desktopClient, model)
+}
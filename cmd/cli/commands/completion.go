@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli-plugins/plugin"
+	"github.com/spf13/cobra"
+)
+
+func newCompletionCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "Generate a shell completion script",
+		Long: `Generate a shell completion script, including completion of local model names.
+
+When run as "docker model", the script completes the docker command, whose
+completion delegates to this plugin for "docker model" arguments, so it can
+replace an existing docker completion script. When run as a standalone
+docker-model binary, the script completes that binary.
+
+Bash (requires the bash-completion package):
+
+  # Load completions in the current shell:
+  source <(docker model completion bash)
+
+  # Load completions for every new shell, on Linux:
+  docker model completion bash > /etc/bash_completion.d/docker-model
+
+  # On macOS with Homebrew:
+  docker model completion bash > $(brew --prefix)/etc/bash_completion.d/docker-model
+
+Zsh:
+
+  # Enable completion if it isn't already, by adding this to ~/.zshrc:
+  autoload -U compinit; compinit
+
+  # Load completions for every new shell:
+  docker model completion zsh > "${fpath[1]}/_docker-model"
+
+Fish:
+
+  # Load completions in the current shell:
+  docker model completion fish | source
+
+  # Load completions for every new shell:
+  docker model completion fish > ~/.config/fish/completions/docker-model.fish
+
+PowerShell:
+
+  # Load completions in the current shell:
+  docker model completion powershell | Out-String | Invoke-Expression
+
+  # Load completions for every new shell, by adding the output of the above
+  # command to your PowerShell profile.
+
+Start a new shell for the changes to take effect.`,
+		Args:      requireExactArgs(1, "completion", "bash|zsh|fish|powershell"),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		// Generating a script doesn't need a model runner, so skip the root
+		// command's context detection.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			// Generate the script for the name the shell sees rather than the
+			// root command's "model".
+			defer func(use string) { root.Use = use }(root.Use)
+			root.Use = completionName(root)
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			case "powershell":
+				return root.GenPowerShellCompletionWithDesc(out)
+			default:
+				return fmt.Errorf("unsupported shell %q: must be one of bash, zsh, fish, powershell", args[0])
+			}
+		},
+	}
+	return c
+}
+
+// completionName returns the name of the command that shell completion is
+// registered for: the binary as invoked when running standalone, such as
+// docker-model, or the root command otherwise, which is docker itself when
+// running as a CLI plugin.
+func completionName(root *cobra.Command) string {
+	if plugin.RunningStandalone() {
+		return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	}
+	return root.Name()
+}
@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/spf13/cobra"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	origArgs := os.Args
+	t.Cleanup(func() { os.Args = origArgs })
+
+	tests := []struct {
+		name   string
+		plugin bool
+		// registered is the fragment of each script that registers the
+		// completion function for the command, by shell.
+		registered map[string]string
+	}{
+		{
+			name: "standalone",
+			registered: map[string]string{
+				"bash":       "complete -o default -F __start_docker-model docker-model",
+				"zsh":        "#compdef docker-model",
+				"fish":       "complete -c docker-model ",
+				"powershell": "Register-ArgumentCompleter -CommandName 'docker-model'",
+			},
+		},
+		{
+			name:   "plugin",
+			plugin: true,
+			registered: map[string]string{
+				"bash":       "complete -o default -F __start_docker docker",
+				"zsh":        "#compdef docker",
+				"fish":       "complete -c docker ",
+				"powershell": "Register-ArgumentCompleter -CommandName 'docker'",
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+			t.Run(tt.name+"/"+shell, func(t *testing.T) {
+				os.Args = []string{"/usr/local/bin/docker-model"}
+				root := &cobra.Command{Use: "model"}
+				root.AddCommand(newCompletionCmd())
+				args := []string{"completion", shell}
+				if tt.plugin {
+					// A plugin's root command is docker, with the plugin as a child.
+					t.Setenv(metadata.ReexecEnvvar, "docker")
+					docker := &cobra.Command{Use: "docker [OPTIONS] model [ARG...]"}
+					docker.AddCommand(root)
+					root = docker
+					args = append([]string{"model"}, args...)
+				}
+				var out bytes.Buffer
+				root.SetOut(&out)
+				root.SetArgs(args)
+				if err := root.Execute(); err != nil {
+					t.Fatalf("completion %s failed: %v", shell, err)
+				}
+				script := out.String()
+				if !strings.Contains(script, tt.registered[shell]) {
+					t.Errorf("Expected %s script to register %q, got:\n%s", shell, tt.registered[shell], script)
+				}
+				if strings.Contains(script, "__start_model") || strings.Contains(script, "#compdef model") {
+					t.Errorf("Expected %s script not to register the bare model command", shell)
+				}
+				// Scripts must defer to the hidden __complete command so that
+				// dynamic completions such as model names are offered.
+				if !strings.Contains(script, "__complete") {
+					t.Errorf("Expected %s script to use dynamic completion", shell)
+				}
+			})
+		}
+	}
+}
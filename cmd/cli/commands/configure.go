@@ -11,7 +11,7 @@ func newConfigureCmd() *cobra.Command {
 	var flags ConfigureFlags
 
 	c := &cobra.Command{
-		Use:     "configure [--context-size=<n>] [--speculative-draft-model=<model>] [--hf_overrides=<json>] [--gpu-memory-utilization=<float>] [--mode=<mode>] [--think] MODEL [-- <runtime-flags...>]",
+		Use:     "configure [--context-size=<n>] [--speculative-draft-model=<model>] [--hf_overrides=<json>] [--gpu-memory-utilization=<float>] [--mode=<mode>] [--think] [--default-stop=<stop>...] [--clear-default-stop] [--env=<key=value>...] [--system=<prompt>] [--system-policy=<policy>] MODEL [-- <runtime-flags...>]",
 		Aliases: []string{"config"},
 		Short:   "Manage model runtime configurations",
 		Hidden:  true,
@@ -50,5 +50,6 @@ func newConfigureCmd() *cobra.Command {
 
 	flags.RegisterFlags(c)
 	c.AddCommand(newConfigureShowCmd())
+	c.AddCommand(newConfigureResetCmd())
 	return c
 }
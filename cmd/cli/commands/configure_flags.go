@@ -115,6 +115,33 @@ func (v *Float64PtrValue) Type() string {
 	return "float64"
 }
 
+// StringPtrValue implements pflag.Value interface for *string pointers
+// This allows flags to have a nil default value to detect if explicitly set
+type StringPtrValue struct {
+	ptr **string
+}
+
+// NewStringPtrValue creates a new StringPtrValue for the given pointer
+func NewStringPtrValue(p **string) *StringPtrValue {
+	return &StringPtrValue{ptr: p}
+}
+
+func (v *StringPtrValue) String() string {
+	if v.ptr == nil || *v.ptr == nil {
+		return ""
+	}
+	return **v.ptr
+}
+
+func (v *StringPtrValue) Set(s string) error {
+	*v.ptr = &s
+	return nil
+}
+
+func (v *StringPtrValue) Type() string {
+	return "string"
+}
+
 // ptr is a helper function to create a pointer to int32
 func ptr(v int32) *int32 {
 	return &v
@@ -135,6 +162,17 @@ type ConfigureFlags struct {
 	GPUMemoryUtilization *float64
 	// Think parameter for reasoning models
 	Think *bool
+	// DefaultStop holds stop sequences merged into every request
+	DefaultStop []string
+	// ClearDefaultStop removes the default stop sequences
+	ClearDefaultStop bool
+	// Env holds KEY=VALUE environment variables set on the backend
+	Env []string
+	// System is the default system prompt applied to chat requests
+	System *string
+	// SystemPolicy is how the default system prompt combines with a
+	// request's own (if-missing, merge)
+	SystemPolicy string
 }
 
 // RegisterFlags registers all configuration flags on the given cobra command.
@@ -147,6 +185,11 @@ func (f *ConfigureFlags) RegisterFlags(cmd *cobra.Command) {
 	cmd.Flags().Var(NewFloat64PtrValue(&f.GPUMemoryUtilization), "gpu-memory-utilization", "fraction of GPU memory to use for the model executor (0.0-1.0) - vLLM only")
 	cmd.Flags().Var(NewBoolPtrValue(&f.Think), "think", "enable reasoning mode for thinking models")
 	cmd.Flags().StringVar(&f.Mode, "mode", "", "backend operation mode (completion, embedding, reranking, image-generation)")
+	cmd.Flags().StringArrayVar(&f.DefaultStop, "default-stop", nil, "stop sequence merged into every request for the model (repeatable)")
+	cmd.Flags().BoolVar(&f.ClearDefaultStop, "clear-default-stop", false, "remove the stop sequences merged into every request for the model")
+	cmd.Flags().StringArrayVar(&f.Env, "env", nil, "KEY=VALUE environment variable set on the model's backend process (repeatable)")
+	cmd.Flags().Var(NewStringPtrValue(&f.System), "system", "default system prompt applied to chat requests for the model (empty to remove)")
+	cmd.Flags().StringVar(&f.SystemPolicy, "system-policy", "", "how the default system prompt combines with a request's own (if-missing, merge)")
 }
 
 // BuildConfigureRequest builds a scheduling.ConfigureRequest from the flags.
@@ -205,6 +248,42 @@ func (f *ConfigureFlags) BuildConfigureRequest(model string) (scheduling.Configu
 		req.LlamaCpp.ReasoningBudget = reasoningBudget
 	}
 
+	// Set default stop sequences if provided, or an empty list to remove them
+	if f.ClearDefaultStop {
+		if len(f.DefaultStop) > 0 {
+			return req, fmt.Errorf("--clear-default-stop cannot be combined with --default-stop")
+		}
+		req.DefaultStop = []string{}
+	} else {
+		req.DefaultStop = f.DefaultStop
+	}
+
+	// Set the default system prompt and its policy if provided
+	if f.SystemPolicy != "" {
+		policy := scheduling.SystemPromptPolicy(strings.ToLower(f.SystemPolicy))
+		if policy != scheduling.SystemPromptIfMissing && policy != scheduling.SystemPromptMerge {
+			return req, fmt.Errorf("invalid --system-policy %q: must be %s or %s", f.SystemPolicy,
+				scheduling.SystemPromptIfMissing, scheduling.SystemPromptMerge)
+		}
+		if f.System == nil {
+			return req, fmt.Errorf("--system-policy requires --system")
+		}
+		req.SystemPromptPolicy = policy
+	}
+	req.DefaultSystemPrompt = f.System
+
+	// Parse backend environment variables if provided
+	for _, variable := range f.Env {
+		name, value, ok := strings.Cut(variable, "=")
+		if !ok || name == "" {
+			return req, fmt.Errorf("invalid --env value %q: must be KEY=VALUE", variable)
+		}
+		if req.Env == nil {
+			req.Env = make(map[string]string)
+		}
+		req.Env[name] = value
+	}
+
 	// Parse mode if provided
 	if f.Mode != "" {
 		parsedMode, err := parseBackendMode(f.Mode)
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	"github.com/spf13/cobra"
+)
+
+func newConfigureResetCmd() *cobra.Command {
+	var all bool
+	c := &cobra.Command{
+		Use:   "reset [MODEL]",
+		Short: "Reset model configurations to their defaults",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all == (len(args) > 0) {
+				return fmt.Errorf("Either a model or --all must be specified\n\n" +
+					"See 'docker model configure reset --help' for more information")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var model string
+			if len(args) > 0 {
+				model = args[0]
+			}
+			reset, err := desktopClient.ResetConfig(model)
+			if err != nil {
+				return err
+			}
+			if reset == 0 {
+				cmd.Println("No configurations to reset")
+				return nil
+			}
+			cmd.Printf("Reset %d configuration(s)\n", reset)
+			return nil
+		},
+		ValidArgsFunction: completion.ModelNames(getDesktopClient, 1),
+	}
+	c.Flags().BoolVar(&all, "all", false, "Reset the configurations of all models")
+	return c
+}
@@ -1,7 +1,11 @@
 package commands
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
+
+	"github.com/docker/model-runner/pkg/inference/scheduling"
 )
 
 func TestConfigureCmdHfOverridesFlag(t *testing.T) {
@@ -108,6 +112,59 @@ func TestConfigureCmdModeFlag(t *testing.T) {
 	}
 }
 
+func TestConfigureCmdDefaultStopFlag(t *testing.T) {
+	// Create the configure command
+	cmd := newConfigureCmd()
+
+	// Verify the --default-stop flag is repeatable
+	if err := cmd.Flags().Parse([]string{"--default-stop", "</s>", "--default-stop", "<|im_end|>"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	stops, err := cmd.Flags().GetStringArray("default-stop")
+	if err != nil {
+		t.Fatalf("--default-stop flag not found: %v", err)
+	}
+	if len(stops) != 2 || stops[0] != "</s>" || stops[1] != "<|im_end|>" {
+		t.Errorf("Expected both stop sequences, got %v", stops)
+	}
+}
+
+func TestConfigureCmdClearDefaultStopFlag(t *testing.T) {
+	flags := ConfigureFlags{ClearDefaultStop: true}
+	req, err := flags.BuildConfigureRequest("ai/smollm2")
+	if err != nil {
+		t.Fatalf("BuildConfigureRequest failed: %v", err)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Failed to marshal the request: %v", err)
+	}
+	if !strings.Contains(string(body), `"default-stop":[]`) {
+		t.Errorf("Expected an empty default stop list to be sent, got %s", body)
+	}
+
+	flags = ConfigureFlags{ClearDefaultStop: true, DefaultStop: []string{"</s>"}}
+	if _, err := flags.BuildConfigureRequest("ai/smollm2"); err == nil {
+		t.Error("Expected an error for --clear-default-stop with --default-stop")
+	}
+}
+
+func TestConfigureCmdEnvFlag(t *testing.T) {
+	flags := ConfigureFlags{Env: []string{"OMP_NUM_THREADS=8", "CUDA_VISIBLE_DEVICES=0,1"}}
+	req, err := flags.BuildConfigureRequest("ai/smollm2")
+	if err != nil {
+		t.Fatalf("BuildConfigureRequest failed: %v", err)
+	}
+	if req.Env["OMP_NUM_THREADS"] != "8" || req.Env["CUDA_VISIBLE_DEVICES"] != "0,1" {
+		t.Errorf("Expected both environment variables, got %v", req.Env)
+	}
+
+	flags = ConfigureFlags{Env: []string{"OMP_NUM_THREADS"}}
+	if _, err := flags.BuildConfigureRequest("ai/smollm2"); err == nil {
+		t.Error("Expected an error for --env without a value")
+	}
+}
+
 func TestConfigureCmdThinkFlag(t *testing.T) {
 	// Create the configure command
 	cmd := newConfigureCmd()
@@ -412,3 +469,35 @@ func equalStringSlices(a, b []string) bool {
 	}
 	return true
 }
+
+func TestConfigureCmdSystemFlag(t *testing.T) {
+	prompt := "You are a support agent."
+	flags := ConfigureFlags{System: &prompt, SystemPolicy: "merge"}
+	req, err := flags.BuildConfigureRequest("ai/smollm2")
+	if err != nil {
+		t.Fatalf("BuildConfigureRequest failed: %v", err)
+	}
+	if req.DefaultSystemPrompt == nil || *req.DefaultSystemPrompt != prompt || req.SystemPromptPolicy != scheduling.SystemPromptMerge {
+		t.Errorf("Expected the system prompt with the merge policy, got %v and %q", req.DefaultSystemPrompt, req.SystemPromptPolicy)
+	}
+
+	// An unset --system leaves the system prompt unchanged, while an empty
+	// one removes it.
+	if req, err = (&ConfigureFlags{}).BuildConfigureRequest("ai/smollm2"); err != nil || req.DefaultSystemPrompt != nil {
+		t.Errorf("Expected no system prompt, got %v (%v)", req.DefaultSystemPrompt, err)
+	}
+	cmd := newConfigureCmd()
+	if err := cmd.Flags().Parse([]string{"--system", ""}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	if !cmd.Flags().Changed("system") {
+		t.Error("Expected an empty --system to be recorded")
+	}
+
+	if _, err := (&ConfigureFlags{System: &prompt, SystemPolicy: "append"}).BuildConfigureRequest("ai/smollm2"); err == nil {
+		t.Error("Expected an error for an unknown --system-policy")
+	}
+	if _, err := (&ConfigureFlags{SystemPolicy: "merge"}).BuildConfigureRequest("ai/smollm2"); err == nil {
+		t.Error("Expected an error for --system-policy without --system")
+	}
+}
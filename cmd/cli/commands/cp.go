@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	"github.com/spf13/cobra"
+)
+
+func newCpCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "cp MODEL DEST",
+		Short: "Copy a model's GGUF weights to a local path",
+		Long: "Copy a model's GGUF weight file(s) to a local path. If DEST is an existing directory " +
+			"(or the model is sharded), the file(s) are written into it; otherwise the weights are written to DEST.",
+		Args: requireExactArgs(2, "cp", "MODEL DEST"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return copyModelGGUF(cmd, args[0], args[1])
+		},
+		ValidArgsFunction: completion.ModelNames(getDesktopClient, 1),
+	}
+	return c
+}
+
+func copyModelGGUF(cmd *cobra.Command, model, dest string) error {
+	body, err := desktopClient.ModelGGUF(cmd.Context(), model)
+	if err != nil {
+		return handleClientError(err, "Failed to copy model")
+	}
+	defer body.Close()
+
+	intoDir := strings.HasSuffix(dest, string(os.PathSeparator)) || strings.HasSuffix(dest, "/")
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		intoDir = true
+	}
+
+	tr := tar.NewReader(body)
+	var copied []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading GGUF stream: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Shards must keep their split names to be loadable as a set, so a
+		// sharded model is always copied into a directory.
+		if len(copied) == 0 && strings.Contains(hdr.Name, "-of-") {
+			intoDir = true
+		}
+		target := dest
+		if intoDir {
+			target = filepath.Join(dest, filepath.Base(hdr.Name))
+		} else if len(copied) > 0 {
+			return fmt.Errorf("model %q has multiple GGUF files: DEST must be a directory", model)
+		}
+		if err := writeGGUFFile(target, tr); err != nil {
+			return err
+		}
+		copied = append(copied, target)
+	}
+	if len(copied) == 0 {
+		return fmt.Errorf("model %q contains no GGUF files", model)
+	}
+
+	for _, path := range copied {
+		cmd.Printf("Copied %s\n", path)
+	}
+	return nil
+}
+
+// writeGGUFFile writes r to path, creating parent directories as needed. A
+// partially written file is removed on failure.
+func writeGGUFFile(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", path, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
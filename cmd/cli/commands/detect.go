@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/distribution/format"
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
+	"github.com/spf13/cobra"
+)
+
+func newDetectCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "detect PATH",
+		Short: "Detect the format of a model file",
+		Long: `Detect the format of a model file and whether the model runner can serve it.
+
+The format is determined from the file's leading bytes, falling back to its
+extension. Only the leading bytes are sent to the model runner.`,
+		Args: requireExactArgs(1, "detect", "PATH"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open model file: %w", err)
+			}
+			defer f.Close()
+			header := io.LimitReader(f, format.ContentSniffLength)
+			detection, err := desktopClient.Detect(filepath.Base(args[0]), header)
+			if err != nil {
+				return handleClientError(err, "Failed to detect model format")
+			}
+			cmd.Print(formatDetection(detection))
+			return nil
+		},
+	}
+	return c
+}
+
+// formatDetection renders a format detection result as text.
+func formatDetection(detection dmrm.FormatDetection) string {
+	var sb strings.Builder
+	if detection.Format == "" {
+		sb.WriteString("Format:    unknown\n")
+	} else {
+		fmt.Fprintf(&sb, "Format:    %s (detected by %s)\n", detection.Format, detection.DetectedBy)
+		supported := "no"
+		if detection.Supported {
+			supported = "yes"
+		}
+		fmt.Fprintf(&sb, "Supported: %s\n", supported)
+		if detection.Backend != "" {
+			fmt.Fprintf(&sb, "Backend:   %s\n", detection.Backend)
+		}
+	}
+	if detection.Warning != "" {
+		fmt.Fprintf(&sb, "Warning:   %s\n", detection.Warning)
+	}
+	return sb.String()
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"testing"
+
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
+)
+
+func TestFormatDetection(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection dmrm.FormatDetection
+		expected  string
+	}{
+		{
+			name:      "supported",
+			detection: dmrm.FormatDetection{Format: "gguf", DetectedBy: "content", Supported: true, Backend: "llama.cpp"},
+			expected:  "Format:    gguf (detected by content)\nSupported: yes\nBackend:   llama.cpp\n",
+		},
+		{
+			name:      "unsupported",
+			detection: dmrm.FormatDetection{Format: "safetensors", DetectedBy: "extension"},
+			expected:  "Format:    safetensors (detected by extension)\nSupported: no\n",
+		},
+		{
+			name:      "unknown",
+			detection: dmrm.FormatDetection{Warning: "file name suggests gguf, but its content is not gguf"},
+			expected:  "Format:    unknown\nWarning:   file name suggests gguf, but its content is not gguf\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDetection(tt.detection); got != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
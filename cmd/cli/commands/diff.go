@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
+	"github.com/spf13/cobra"
+)
+
+func newDiffCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "diff MODEL1 MODEL2",
+		Short: "Show the layer and config differences between two models",
+		Long: `Show the layer and config differences between two local models.
+
+Layers are matched by their file path annotation. Layers only in MODEL2 are
+shown as added (+), layers only in MODEL1 as removed (-), and layers whose
+content differs as changed (~).`,
+		Args: requireExactArgs(2, "diff", "MODEL1 MODEL2"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			diff, err := desktopClient.Diff(args[0], args[1])
+			if err != nil {
+				return handleClientError(err, "Failed to compare models")
+			}
+			cmd.Print(formatModelDiff(args[0], args[1], diff))
+			return nil
+		},
+		ValidArgsFunction: completion.ModelNames(getDesktopClient, 2),
+	}
+	return c
+}
+
+// formatModelDiff renders a model diff as text.
+func formatModelDiff(a, b string, diff dmrm.ModelDiff) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s (%s)\n+++ %s (%s)\n", a, shortDigest(diff.A), b, shortDigest(diff.B))
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed)+len(diff.Config) == 0 {
+		sb.WriteString("Models are identical\n")
+		return sb.String()
+	}
+
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+		sb.WriteString("\nLayers:\n")
+		for _, layer := range diff.Removed {
+			fmt.Fprintf(&sb, "  - %s  %s\n", layerName(layer), shortDigest(layer.DigestA))
+		}
+		for _, layer := range diff.Added {
+			fmt.Fprintf(&sb, "  + %s  %s\n", layerName(layer), shortDigest(layer.DigestB))
+		}
+		for _, layer := range diff.Changed {
+			fmt.Fprintf(&sb, "  ~ %s  %s -> %s\n", layerName(layer), shortDigest(layer.DigestA), shortDigest(layer.DigestB))
+		}
+	}
+
+	if len(diff.Config) > 0 {
+		sb.WriteString("\nConfig:\n")
+		for _, field := range diff.Config {
+			fmt.Fprintf(&sb, "  ~ %s: %s -> %s\n", field.Field, configValue(field.A), configValue(field.B))
+		}
+	}
+	return sb.String()
+}
+
+// layerName describes a layer by its file path, if it has one, and media
+// type.
+func layerName(layer dmrm.LayerDiff) string {
+	if layer.Path == "" {
+		return layer.MediaType
+	}
+	return fmt.Sprintf("%s (%s)", layer.Path, layer.MediaType)
+}
+
+// shortDigest abbreviates a sha256 digest to the 12 characters shown by
+// docker model list.
+func shortDigest(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		return hex[:12]
+	}
+	return hex
+}
+
+// configValue renders a config field value, abbreviating long values.
+func configValue(value []byte) string {
+	if len(value) == 0 {
+		return "<unset>"
+	}
+	const maxLen = 60
+	if s := string(value); len(s) > maxLen {
+		return s[:maxLen] + "..."
+	}
+	return string(value)
+}
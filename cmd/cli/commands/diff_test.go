@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
+)
+
+func TestFormatModelDiff(t *testing.T) {
+	diff := dmrm.ModelDiff{
+		A:       "sha256:aaaaaaaaaaaa1111",
+		B:       "sha256:bbbbbbbbbbbb2222",
+		Added:   []dmrm.LayerDiff{{Path: "LICENSE", MediaType: "application/vnd.docker.ai.license", DigestB: "sha256:cccccccccccc3333"}},
+		Removed: []dmrm.LayerDiff{},
+		Changed: []dmrm.LayerDiff{{Path: "model.gguf", MediaType: "application/vnd.docker.ai.gguf.v3", DigestA: "sha256:dddddddddddd4444", DigestB: "sha256:eeeeeeeeeeee5555"}},
+		Config:  []dmrm.ConfigFieldDiff{{Field: "config.context_size", B: json.RawMessage("4096")}},
+	}
+	out := formatModelDiff("ai/model:a", "ai/model:b", diff)
+	for _, want := range []string{
+		"--- ai/model:a (aaaaaaaaaaaa)",
+		"+ LICENSE (application/vnd.docker.ai.license)  cccccccccccc",
+		"~ model.gguf (application/vnd.docker.ai.gguf.v3)  dddddddddddd -> eeeeeeeeeeee",
+		"~ config.context_size: <unset> -> 4096",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	identical := formatModelDiff("ai/model:a", "ai/model:a", dmrm.ModelDiff{A: diff.A, B: diff.A})
+	if !strings.Contains(identical, "Models are identical") {
+		t.Errorf("Expected identical models to be reported, got:\n%s", identical)
+	}
+}
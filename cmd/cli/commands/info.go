@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/spf13/cobra"
+)
+
+func newInfoCmd() *cobra.Command {
+	var formatJson bool
+	c := &cobra.Command{
+		Use:   "info",
+		Short: "Show the effective configuration of the Docker Model Runner",
+		Long: "Show how the Docker Model Runner interpreted its environment: where it stores models, " +
+			"where it listens, its backends and defaults, which features are enabled and the " +
+			"configuration environment variables it was started with. Secrets are redacted.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := desktopClient.Info()
+			if err != nil {
+				return handleClientError(err, "Failed to get Docker Model Runner info")
+			}
+			if formatJson {
+				data, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal info: %w", err)
+				}
+				cmd.Println(string(data))
+				return nil
+			}
+			return textInfo(cmd.OutOrStdout(), info)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	c.Flags().BoolVar(&formatJson, "json", false, "Format output in JSON")
+	return c
+}
+
+func textInfo(out io.Writer, info inference.RunnerInfo) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Models path:\t%s\n", info.ModelsPath)
+	fmt.Fprintf(w, "Listen:\t%s\n", info.Listen)
+	if info.TLSListen != "" {
+		fmt.Fprintf(w, "TLS listen:\t%s\n", info.TLSListen)
+	}
+	fmt.Fprintf(w, "Allowed origins:\t%s\n", orNone(strings.Join(info.AllowedOrigins, ", ")))
+	fmt.Fprintf(w, "Default backend:\t%s\n", info.DefaultBackend)
+	fmt.Fprintf(w, "llama.cpp version:\t%s\n", info.LlamaServerVersion)
+	if len(info.LlamaArgs) > 0 {
+		fmt.Fprintf(w, "llama.cpp args:\t%s\n", strings.Join(info.LlamaArgs, " "))
+	}
+	fmt.Fprintf(w, "Default context size:\t%s\n", orNone(contextSizeString(info.DefaultContextSize)))
+	fmt.Fprintf(w, "Max context size:\t%s\n", orNone(contextSizeString(info.MaxContextSize)))
+	fmt.Fprintf(w, "Idle timeout:\t%s\n", info.IdleTimeout)
+	fmt.Fprintf(w, "Backend start timeout:\t%s\n", info.BackendStartTimeout)
+
+	fmt.Fprintln(w, "\nBackends:")
+	for _, backend := range info.Backends {
+		status := backend.Status
+		if backend.Path != "" {
+			status += " (" + backend.Path + ")"
+		}
+		fmt.Fprintf(w, "  %s:\t%s\n", backend.Name, status)
+	}
+
+	fmt.Fprintln(w, "\nFeatures:")
+	for _, name := range sortedKeys(info.Features) {
+		state := "disabled"
+		if info.Features[name] {
+			state = "enabled"
+		}
+		fmt.Fprintf(w, "  %s:\t%s\n", name, state)
+	}
+
+	if len(info.Environment) > 0 {
+		fmt.Fprintln(w, "\nEnvironment:")
+		for _, name := range sortedKeys(info.Environment) {
+			fmt.Fprintf(w, "  %s=%s\n", name, info.Environment[name])
+		}
+	}
+	return w.Flush()
+}
+
+func contextSizeString(size *int32) string {
+	if size == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *size)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/docker/model-runner/cmd/cli/desktop"
+	"github.com/spf13/cobra"
+)
+
+// jsonSchemaTypes are the type names a JSON schema may use.
+var jsonSchemaTypes = []string{"string", "number", "integer", "boolean", "object", "array", "null"}
+
+// jsonSchemaKeywords maps the JSON schema keywords checked by
+// validateJSONSchema to the kind of value they take.
+var jsonSchemaKeywords = map[string]string{
+	"properties":           "schema map",
+	"patternProperties":    "schema map",
+	"$defs":                "schema map",
+	"definitions":          "schema map",
+	"additionalProperties": "schema",
+	"items":                "schema",
+	"not":                  "schema",
+	"if":                   "schema",
+	"then":                 "schema",
+	"else":                 "schema",
+	"prefixItems":          "schema list",
+	"allOf":                "schema list",
+	"anyOf":                "schema list",
+	"oneOf":                "schema list",
+	"required":             "string list",
+	"enum":                 "list",
+	"minimum":              "number",
+	"maximum":              "number",
+	"exclusiveMinimum":     "number",
+	"exclusiveMaximum":     "number",
+	"multipleOf":           "number",
+	"minLength":            "count",
+	"maxLength":            "count",
+	"minItems":             "count",
+	"maxItems":             "count",
+	"minProperties":        "count",
+	"maxProperties":        "count",
+	"pattern":              "string",
+	"format":               "string",
+	"$ref":                 "string",
+}
+
+// loadJSONSchema reads the JSON schema in path, checking that it is well
+// formed, and returns it compacted.
+func loadJSONSchema(path string) (json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON schema: %w", err)
+	}
+	var schema any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema %s: %w", path, err)
+	}
+	if _, ok := schema.(map[string]any); !ok {
+		return nil, fmt.Errorf("invalid JSON schema %s: the schema must be an object", path)
+	}
+	if err := validateJSONSchema(schema, "#"); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema %s: %w", path, err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema %s: %w", path, err)
+	}
+	return compact.Bytes(), nil
+}
+
+// validateJSONSchema checks that schema, found at the JSON pointer at, is a
+// boolean or an object whose keywords have values of the right kind.
+func validateJSONSchema(schema any, at string) error {
+	if _, ok := schema.(bool); ok {
+		return nil
+	}
+	object, ok := schema.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: a schema must be an object or a boolean", at)
+	}
+	if err := validateJSONSchemaType(object["type"], at+"/type"); err != nil {
+		return err
+	}
+	for _, keyword := range slices.Sorted(maps.Keys(jsonSchemaKeywords)) {
+		kind := jsonSchemaKeywords[keyword]
+		value, ok := object[keyword]
+		if !ok {
+			continue
+		}
+		path := at + "/" + keyword
+		switch kind {
+		case "schema":
+			if err := validateJSONSchema(value, path); err != nil {
+				return err
+			}
+		case "schema map":
+			schemas, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: must be an object", path)
+			}
+			for name, s := range schemas {
+				if err := validateJSONSchema(s, path+"/"+name); err != nil {
+					return err
+				}
+			}
+		case "schema list":
+			schemas, ok := value.([]any)
+			if !ok || len(schemas) == 0 {
+				return fmt.Errorf("%s: must be a non-empty array", path)
+			}
+			for i, s := range schemas {
+				if err := validateJSONSchema(s, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		case "string list":
+			names, ok := value.([]any)
+			if !ok {
+				return fmt.Errorf("%s: must be an array of strings", path)
+			}
+			for _, name := range names {
+				if _, ok := name.(string); !ok {
+					return fmt.Errorf("%s: must be an array of strings", path)
+				}
+			}
+		case "list":
+			if _, ok := value.([]any); !ok {
+				return fmt.Errorf("%s: must be an array", path)
+			}
+		case "number":
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("%s: must be a number", path)
+			}
+		case "count":
+			if n, ok := value.(float64); !ok || n < 0 || n != float64(int64(n)) {
+				return fmt.Errorf("%s: must be a non-negative integer", path)
+			}
+		case "string":
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("%s: must be a string", path)
+			}
+		}
+	}
+	return nil
+}
+
+// validateJSONSchemaType checks the value of a schema's type keyword, which
+// is either a type name or an array of them.
+func validateJSONSchemaType(value any, at string) error {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		if !slices.Contains(jsonSchemaTypes, value) {
+			return fmt.Errorf("%s: unknown type %q", at, value)
+		}
+		return nil
+	case []any:
+		for _, t := range value {
+			name, ok := t.(string)
+			if !ok || !slices.Contains(jsonSchemaTypes, name) {
+				return fmt.Errorf("%s: unknown type %v", at, t)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: must be a string or an array of strings", at)
+	}
+}
+
+// chatStructured sends prompt to model with the output constrained to schema,
+// printing the JSON response, indented if pretty is set, and recording the
+// exchange.
+func chatStructured(cmd *cobra.Command, client *desktop.Client, model, prompt string, schema json.RawMessage, pretty bool, record *transcript) error {
+	sentAt := time.Now()
+	response, err := client.ChatStructured(cmd.Context(), model, prompt, schema)
+	if err != nil {
+		return handleClientError(err, "Failed to generate a structured response")
+	}
+	record.add(buildUserMessage(prompt, nil), sentAt)
+	record.add(desktop.OpenAIChatMessage{Role: "assistant", Content: response}, time.Now())
+	if pretty {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(response), "", "  "); err == nil {
+			response = indented.String()
+		}
+	}
+	cmd.Println(response)
+	return nil
+}
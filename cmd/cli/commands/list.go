@@ -241,7 +241,7 @@ func prettyPrintModels(models []dmrm.Model) string {
 
 	var buf bytes.Buffer
 	table := newTable(&buf)
-	table.Header([]string{"MODEL NAME", "PARAMETERS", "QUANTIZATION", "ARCHITECTURE", "MODEL ID", "CREATED", "CONTEXT", "SIZE"})
+	table.Header([]string{"MODEL NAME", "PARAMETERS", "QUANTIZATION", "ARCHITECTURE", "MODEL ID", "CREATED", "LAST USED", "CONTEXT", "SIZE"})
 
 	for _, row := range rows {
 		appendRow(table, row.tag, row.model)
@@ -258,6 +258,9 @@ func appendRow(table *tablewriter.Table, tag string, model dmrm.Model) {
 	}
 	// Strip default "ai/" prefix and ":latest" tag for display
 	displayTag := stripDefaultsFromModelName(tag)
+	if model.Archived {
+		displayTag += " (archived)"
+	}
 	contextSize := ""
 	if model.Config.GetContextSize() != nil {
 		contextSize = fmt.Sprintf("%d", *model.Config.GetContextSize())
@@ -271,6 +274,11 @@ func appendRow(table *tablewriter.Table, tag string, model dmrm.Model) {
 		}
 	}
 
+	lastUsed := "never"
+	if model.LastUsed != 0 {
+		lastUsed = units.HumanDuration(time.Since(time.Unix(model.LastUsed, 0))) + " ago"
+	}
+
 	table.Append([]string{
 		displayTag,
 		model.Config.GetParameters(),
@@ -278,6 +286,7 @@ func appendRow(table *tablewriter.Table, tag string, model dmrm.Model) {
 		model.Config.GetArchitecture(),
 		model.ID[7:19],
 		units.HumanDuration(time.Since(time.Unix(model.Created, 0))) + " ago",
+		lastUsed,
 		contextSize,
 		model.Config.GetSize(),
 	})
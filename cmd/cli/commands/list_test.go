@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/types"
 	dmrm "github.com/docker/model-runner/pkg/inference/models"
@@ -316,3 +317,38 @@ func TestPrettyPrintModelsWithMultipleTags(t *testing.T) {
 		t.Error("'qwen3:0.6B-F16' should appear before 'qwen3:8B-Q4_K_M'")
 	}
 }
+
+func TestPrettyPrintModelsShowsLastUsed(t *testing.T) {
+	models := []dmrm.Model{
+		{
+			ID:       "sha256:123456789012345678901234567890123456789012345678901234567890abcd",
+			Tags:     []string{"ai/used:latest"},
+			Created:  1000,
+			LastUsed: time.Now().Add(-3 * time.Hour).Unix(),
+			Config:   &types.Config{Parameters: "7B"},
+		},
+		{
+			ID:      "sha256:223456789012345678901234567890123456789012345678901234567890abcd",
+			Tags:    []string{"ai/unused:latest"},
+			Created: 1000,
+			Config:  &types.Config{Parameters: "7B"},
+		},
+	}
+
+	output := prettyPrintModels(models)
+	if !strings.Contains(output, "LAST USED") {
+		t.Fatalf("Expected a LAST USED column, got:\n%s", output)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "unused"):
+			if !strings.Contains(line, "never") {
+				t.Errorf("Expected unused model to show never, got %q", line)
+			}
+		case strings.HasPrefix(line, "used"):
+			if !strings.Contains(line, "3 hours ago") {
+				t.Errorf("Expected used model to show 3 hours ago, got %q", line)
+			}
+		}
+	}
+}
@@ -154,6 +154,18 @@ func newPackagedCmd() *cobra.Command {
 					return err
 				}
 			}
+			if opts.quantization != "" && (opts.fromModel == "" || opts.push) {
+				return fmt.Errorf(
+					"--quantization requires --from and cannot be combined with --push\n\n" +
+						"See 'docker model package --help' for more information",
+				)
+			}
+			if opts.ddufComponents && opts.ddufPath == "" {
+				return fmt.Errorf(
+					"--dduf-components requires --dduf\n\n" +
+						"See 'docker model package --help' for more information",
+				)
+			}
 
 			// Validate dir-tar paths are relative (not absolute)
 			for _, dirPath := range opts.dirTarPaths {
@@ -182,6 +194,7 @@ func newPackagedCmd() *cobra.Command {
 	c.Flags().StringVar(&opts.ggufPath, "gguf", "", "absolute path to gguf file")
 	c.Flags().StringVar(&opts.safetensorsDir, "safetensors-dir", "", "absolute path to directory containing safetensors files and config")
 	c.Flags().StringVar(&opts.ddufPath, "dduf", "", "absolute path to DDUF archive file (Diffusers Unified Format)")
+	c.Flags().BoolVar(&opts.ddufComponents, "dduf-components", false, "package the DDUF archive's component files as individual layers instead of a single file")
 	c.Flags().StringVar(&opts.fromModel, "from", "", "reference to an existing model to repackage")
 	c.Flags().StringVar(&opts.chatTemplatePath, "chat-template", "", "absolute path to chat template file (must be Jinja format)")
 	c.Flags().StringArrayVarP(&opts.licensePaths, "license", "l", nil, "absolute path to a license file")
@@ -189,6 +202,7 @@ func newPackagedCmd() *cobra.Command {
 	c.Flags().StringVar(&opts.mmprojPath, "mmproj", "", "absolute path to multimodal projector file")
 	c.Flags().BoolVar(&opts.push, "push", false, "push to registry (if not set, the model is loaded into the Model Runner content store)")
 	c.Flags().Uint64Var(&opts.contextSize, "context-size", 0, "context size in tokens")
+	c.Flags().StringVar(&opts.quantization, "quantization", "", "requantize the GGUF weights of the --from model to the given type (e.g. Q4_K_M)")
 	return c
 }
 
@@ -198,11 +212,13 @@ type packageOptions struct {
 	ggufPath         string
 	safetensorsDir   string
 	ddufPath         string
+	ddufComponents   bool
 	fromModel        string
 	licensePaths     []string
 	dirTarPaths      []string
 	mmprojPath       string
 	push             bool
+	quantization     string
 	tag              string
 }
 
@@ -266,6 +282,21 @@ func initializeBuilder(ctx context.Context, cmd *cobra.Command, client *desktop.
 			return nil, fmt.Errorf("add gguf file: %w", err)
 		}
 		result.builder = pkg
+	} else if opts.ddufPath != "" && opts.ddufComponents {
+		cmd.PrintErrf("Unpacking DDUF components from %q\n", opts.ddufPath)
+		tempDir, err := os.MkdirTemp("", "dduf-components-*")
+		if err != nil {
+			return nil, fmt.Errorf("create temp directory: %w", err)
+		}
+		result.cleanupFunc = func() {
+			os.RemoveAll(tempDir)
+		}
+		pkg, err := builder.FromDDUFComponents(opts.ddufPath, tempDir)
+		if err != nil {
+			result.cleanupFunc()
+			return nil, fmt.Errorf("add dduf components: %w", err)
+		}
+		result.builder = pkg
 	} else if opts.ddufPath != "" {
 		cmd.PrintErrf("Adding DDUF file from %q\n", opts.ddufPath)
 		pkg, err := builder.FromPath(opts.ddufPath)
@@ -355,23 +386,29 @@ func packageModel(ctx context.Context, cmd *cobra.Command, client *desktop.Clien
 		opts.chatTemplatePath == "" &&
 		opts.mmprojPath == "" &&
 		len(opts.dirTarPaths) == 0 &&
-		cmd.Flags().Changed("context-size")
+		(cmd.Flags().Changed("context-size") || opts.quantization != "")
 
 	if canUseDaemonRepackage {
 		cmd.PrintErrf("Reading model from daemon: %q\n", opts.fromModel)
-		cmd.PrintErrf("Setting context size %d\n", opts.contextSize)
-		cmd.PrintErrln("Creating lightweight model variant...")
+		repackageOpts := desktop.RepackageOptions{}
+		if cmd.Flags().Changed("context-size") {
+			cmd.PrintErrf("Setting context size %d\n", opts.contextSize)
+			repackageOpts.ContextSize = &opts.contextSize
+		}
+		if opts.quantization != "" {
+			cmd.PrintErrf("Requantizing to %s\n", opts.quantization)
+			repackageOpts.Quantization = &opts.quantization
+		} else {
+			cmd.PrintErrln("Creating lightweight model variant...")
+		}
 
 		// Ensure standalone runner is available
 		if _, err := ensureStandaloneRunnerAvailable(ctx, asPrinter(cmd), false); err != nil {
 			return fmt.Errorf("unable to initialize standalone model runner: %w", err)
 		}
 
-		repackageOpts := desktop.RepackageOptions{
-			ContextSize: &opts.contextSize,
-		}
-		if err := client.RepackageModel(ctx, opts.fromModel, opts.tag, repackageOpts); err != nil {
-			return fmt.Errorf("failed to create lightweight model: %w", err)
+		if err := client.RepackageModel(ctx, opts.fromModel, opts.tag, repackageOpts, asPrinter(cmd)); err != nil {
+			return fmt.Errorf("failed to create model variant: %w", err)
 		}
 
 		cmd.PrintErrln("Model variant created successfully")
@@ -578,7 +615,7 @@ func (t *modelRunnerTarget) Write(ctx context.Context, mdl types.ModelArtifact,
 		return fmt.Errorf("get model ID: %w", err)
 	}
 	if t.tag != nil {
-		if err := t.client.Tag(id, parseRepo(t.tag), t.tag.TagStr()); err != nil {
+		if err := t.client.Tag(id, parseRepo(t.tag), t.tag.TagStr(), true); err != nil {
 			return fmt.Errorf("tag model: %w", err)
 		}
 	}
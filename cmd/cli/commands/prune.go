@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
+	"github.com/spf13/cobra"
+)
+
+func newPruneCmd() *cobra.Command {
+	var request dmrm.ModelPruneRequest
+	var filters []string
+	var force bool
+
+	c := &cobra.Command{
+		Use:   "prune [OPTIONS]",
+		Short: "Remove unused local models",
+		Long: "Remove unused local models.\n\n" +
+			"By default only dangling models, which no tag references, are removed. " +
+			"--until and --filter select the models to remove instead; a model must match every filter. " +
+			"Supported filters are until=<duration> and label=<key>[=<value>], matched against the model's manifest annotations.",
+		Example: "  docker model prune\n" +
+			"  docker model prune --until 720h\n" +
+			"  docker model prune --filter label=org.opencontainers.image.vendor=acme --dry-run",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			for _, filter := range filters {
+				key, value, ok := strings.Cut(filter, "=")
+				switch {
+				case ok && key == "until":
+					if cmd.Flags().Changed("until") {
+						return fmt.Errorf("--until and --filter until cannot be used together")
+					}
+					request.Until = value
+				case ok && key == "label" && value != "":
+					request.Labels = append(request.Labels, value)
+				default:
+					return fmt.Errorf("invalid filter %q: expected until=<duration> or label=<key>[=<value>]", filter)
+				}
+			}
+			if request.Until != "" {
+				if until, err := time.ParseDuration(request.Until); err != nil || until <= 0 {
+					return fmt.Errorf("invalid until duration %q", request.Until)
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !force && !request.DryRun {
+				cmd.Println("WARNING! This will remove the models matching the filters.")
+				cmd.Print("Are you sure you want to continue? [y/N] ")
+
+				var input string
+				_, err := fmt.Scanln(&input)
+				if err != nil && err.Error() != "unexpected newline" {
+					return err
+				}
+
+				if input != "y" && input != "Y" {
+					cmd.Println("Operation cancelled.")
+					return nil
+				}
+			}
+
+			response, err := desktopClient.Prune(request)
+			for _, model := range response.Models {
+				name := shortDigest(model.ID)
+				if len(model.Tags) > 0 {
+					name = strings.Join(model.Tags, ", ")
+				}
+				if response.DryRun {
+					cmd.Printf("Would delete: %s\n", name)
+				} else {
+					cmd.Printf("Deleted: %s\n", name)
+				}
+			}
+			if err != nil {
+				return handleClientError(err, "Failed to prune models")
+			}
+			if response.DryRun {
+				cmd.Printf("Total reclaimable space: %s\n", units.HumanSize(float64(response.Reclaimed)))
+			} else {
+				cmd.Printf("Total reclaimed space: %s\n", units.HumanSize(float64(response.Reclaimed)))
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	c.Flags().StringVar(&request.Until, "until", "", "Remove models not used or pulled within this duration (e.g. 720h)")
+	c.Flags().BoolVar(&request.Dangling, "dangling", false, "Remove only untagged models")
+	c.Flags().StringArrayVar(&filters, "filter", nil, "Filter the models to remove (until=<duration>, label=<key>[=<value>])")
+	c.Flags().BoolVar(&request.DryRun, "dry-run", false, "List the models that would be removed without removing them")
+	c.Flags().BoolVarP(&force, "force", "f", false, "Do not prompt for confirmation")
+	return c
+}
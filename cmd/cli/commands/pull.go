@@ -1,33 +1,128 @@
 package commands
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
 	"github.com/docker/model-runner/cmd/cli/commands/completion"
 	"github.com/docker/model-runner/cmd/cli/desktop"
+	"github.com/docker/model-runner/pkg/distribution/distribution"
+	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/spf13/cobra"
 )
 
 func newPullCmd() *cobra.Command {
+	var opts desktop.PullOptions
+	var progress progressOptions
+	var listPartial, purgePartial bool
 	c := &cobra.Command{
 		Use:   "pull MODEL",
 		Short: "Pull a model from Docker Hub or HuggingFace to your local environment",
-		Args:  requireExactArgs(1, "pull", "MODEL"),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if listPartial || purgePartial {
+				return cobra.NoArgs(cmd, args)
+			}
+			return requireExactArgs(1, "pull", "MODEL")(cmd, args)
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if listPartial && purgePartial {
+				return fmt.Errorf("--list-partial and --purge-partial cannot be used together")
+			}
+			if opts.ExpectedDigest != "" {
+				if _, err := oci.NewHash(opts.ExpectedDigest); err != nil {
+					return fmt.Errorf("invalid --digest %q: %w", opts.ExpectedDigest, err)
+				}
+			}
+			return progress.validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return pullModel(cmd, desktopClient, args[0])
+			if listPartial {
+				downloads, err := desktopClient.IncompleteDownloads()
+				if err != nil {
+					return handleClientError(err, "Failed to list partial downloads")
+				}
+				cmd.Print(partialDownloadsTable(downloads))
+				return nil
+			}
+			if purgePartial {
+				purged, err := desktopClient.PurgeIncompleteDownloads()
+				if err != nil {
+					return handleClientError(err, "Failed to purge partial downloads")
+				}
+				var reclaimed int64
+				for _, download := range purged {
+					reclaimed += download.Size
+				}
+				cmd.Printf("Removed %d partial download(s), reclaiming %s\n", len(purged), units.HumanSize(float64(reclaimed)))
+				return nil
+			}
+			return pullModelWithOptions(cmd, desktopClient, args[0], opts, progress)
 		},
 		ValidArgsFunction: completion.NoComplete,
 	}
+	c.Flags().StringVar(&opts.Platform, "platform", "",
+		"Pull the variant for this platform (os/arch[/variant]) when MODEL is a multi-platform tag")
+	c.Flags().StringVar(&opts.ExpectedDigest, "digest", "",
+		"Fail before downloading unless the model's manifest or index has this digest (sha256:...)")
+	c.Flags().BoolVar(&listPartial, "list-partial", false,
+		"List the partially downloaded files left by interrupted pulls instead of pulling")
+	c.Flags().BoolVar(&purgePartial, "purge-partial", false,
+		"Remove the partially downloaded files left by interrupted pulls instead of pulling")
+	progress.addFlags(c)
 
 	return c
 }
 
 func pullModel(cmd *cobra.Command, desktopClient *desktop.Client, model string) error {
-	printer := asPrinter(cmd)
-	response, _, err := desktopClient.Pull(model, printer)
+	return pullModelWithOptions(cmd, desktopClient, model, desktop.PullOptions{}, progressOptions{})
+}
+
+func pullModelWithOptions(cmd *cobra.Command, desktopClient *desktop.Client, model string, opts desktop.PullOptions, progress progressOptions) error {
+	response, _, err := desktopClient.PullWithOptions(model, opts, progress.printer(cmd))
 
 	if err != nil {
 		return handleClientError(err, "Failed to pull model")
 	}
 
-	cmd.Println(response)
+	progress.printResult(cmd, response)
 	return nil
 }
+
+// partialDownloadsTable renders partial downloads as a table.
+func partialDownloadsTable(downloads []distribution.IncompleteDownload) string {
+	var buf bytes.Buffer
+	table := newTable(&buf)
+	table.Header([]string{"DIFF ID", "DOWNLOADED", "MODEL", "MODIFIED", "STATUS"})
+
+	for _, download := range downloads {
+		id := strings.TrimPrefix(download.DiffID, "sha256:")
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		downloaded := units.HumanSize(float64(download.Size))
+		if download.ExpectedSize > 0 {
+			downloaded += " / " + units.HumanSize(float64(download.ExpectedSize))
+		}
+		model := strings.Join(download.References, ", ")
+		if model == "" {
+			model = "<unknown>"
+		}
+		status := "interrupted"
+		if download.Active {
+			status = "downloading"
+		}
+		table.Append([]string{
+			id,
+			downloaded,
+			model,
+			units.HumanDuration(time.Since(download.ModifiedAt)) + " ago",
+			status,
+		})
+	}
+
+	table.Render()
+	return buf.String()
+}
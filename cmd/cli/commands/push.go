@@ -1,32 +1,77 @@
 package commands
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/docker/model-runner/cmd/cli/commands/completion"
 	"github.com/docker/model-runner/cmd/cli/desktop"
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
 	"github.com/spf13/cobra"
 )
 
 func newPushCmd() *cobra.Command {
+	var variants []string
+	var progress progressOptions
 	c := &cobra.Command{
 		Use:   "push MODEL",
 		Short: "Push a model to Docker Hub",
-		Args:  requireExactArgs(1, "push", "MODEL"),
+		Long: "Push a model to Docker Hub. With --variant, the given local models are pushed as " +
+			"platform variants of a single multi-platform tag MODEL.",
+		Example: "  docker model push myorg/model:latest\n" +
+			"  docker model push --variant linux/amd64=myorg/model:cpu --variant linux/amd64/cuda=myorg/model:cuda myorg/model:latest",
+		Args: requireExactArgs(1, "push", "MODEL"),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return progress.validate()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return pushModel(cmd, desktopClient, args[0])
+			if len(variants) > 0 {
+				parsed, err := parsePushVariants(variants)
+				if err != nil {
+					return err
+				}
+				return pushModelIndex(cmd, desktopClient, args[0], parsed, progress)
+			}
+			return pushModel(cmd, desktopClient, args[0], progress)
 		},
 		ValidArgsFunction: completion.NoComplete,
 	}
+	c.Flags().StringArrayVar(&variants, "variant", nil,
+		"Push a local model as a platform variant, as PLATFORM=MODEL (e.g. linux/amd64/cuda=myorg/model:cuda); repeatable")
+	progress.addFlags(c)
 	return c
 }
 
-func pushModel(cmd *cobra.Command, desktopClient *desktop.Client, model string) error {
-	printer := asPrinter(cmd)
-	response, _, err := desktopClient.Push(model, printer)
+func pushModel(cmd *cobra.Command, desktopClient *desktop.Client, model string, progress progressOptions) error {
+	response, _, err := desktopClient.Push(model, progress.printer(cmd))
+
+	if err != nil {
+		return handleClientError(err, "Failed to push model")
+	}
+
+	progress.printResult(cmd, response)
+	return nil
+}
 
+func pushModelIndex(cmd *cobra.Command, desktopClient *desktop.Client, tag string, variants []dmrm.PushVariant, progress progressOptions) error {
+	response, _, err := desktopClient.PushIndex(tag, variants, progress.printer(cmd))
 	if err != nil {
 		return handleClientError(err, "Failed to push model")
 	}
 
-	cmd.Println(response)
+	progress.printResult(cmd, response)
 	return nil
 }
+
+// parsePushVariants parses --variant values of the form PLATFORM=MODEL.
+func parsePushVariants(values []string) ([]dmrm.PushVariant, error) {
+	variants := make([]dmrm.PushVariant, 0, len(values))
+	for _, value := range values {
+		platform, model, ok := strings.Cut(value, "=")
+		if !ok || platform == "" || model == "" {
+			return nil, fmt.Errorf("invalid --variant %q: expected PLATFORM=MODEL", value)
+		}
+		variants = append(variants, dmrm.PushVariant{Platform: platform, Model: model})
+	}
+	return variants, nil
+}
@@ -73,5 +73,23 @@ func newRequestsCmd() *cobra.Command {
 	c.Flags().StringVar(&model, "model", "", "Specify the model to filter requests")
 	// Enable completion for the --model flag.
 	_ = c.RegisterFlagCompletionFunc("model", completion.ModelNames(getDesktopClient, 1))
+	c.AddCommand(withStandaloneRunner(newRequestsCancelCmd()))
+	return c
+}
+
+func newRequestsCancelCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "cancel REQUEST_ID",
+		Short: "Cancel an in-flight request",
+		Args:  requireExactArgs(1, "requests cancel", "REQUEST_ID"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := desktopClient.CancelRequest(args[0]); err != nil {
+				return handleClientError(err, "Failed to cancel request")
+			}
+			cmd.Printf("Cancelled request %s\n", args[0])
+			return nil
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
 	return c
 }
@@ -87,6 +87,7 @@ func NewRootCmd(cli *command.DockerCli) *cobra.Command {
 	// Runner management commands - these manage the runner itself and don't need automatic runner initialization.
 	rootCmd.AddCommand(
 		newVersionCmd(),
+		newCompletionCmd(),
 		newInstallRunner(),
 		newUninstallRunner(),
 		newStartRunner(),
@@ -100,6 +101,7 @@ func NewRootCmd(cli *command.DockerCli) *cobra.Command {
 	// Commands that require a running model runner. These are wrapped to ensure the standalone runner is available.
 	for _, cmd := range []*cobra.Command{
 		newStatusCmd(),
+		newInfoCmd(),
 		newPullCmd(),
 		newPushCmd(),
 		newListCmd(),
@@ -110,12 +112,21 @@ func NewRootCmd(cli *command.DockerCli) *cobra.Command {
 		newComposeCmd(),
 		newLaunchCmd(),
 		newTagCmd(),
+		newDiffCmd(),
+		newDetectCmd(),
+		newCpCmd(),
+		newAliasCmd(),
 		newConfigureCmd(),
 		newPSCmd(),
 		newDFCmd(),
 		newUnloadCmd(),
+		newWarmupCmd(),
 		newRequestsCmd(),
 		newPurgeCmd(),
+		newPruneCmd(),
+		newStoreCmd(),
+		newArchiveCmd(),
+		newUnarchiveCmd(),
 		newBenchCmd(),
 	} {
 		rootCmd.AddCommand(withStandaloneRunner(cmd))
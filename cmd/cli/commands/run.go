@@ -3,6 +3,7 @@ package commands
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/docker/model-runner/cmd/cli/commands/completion"
@@ -90,7 +92,7 @@ func readMultilineInput(cmd *cobra.Command, scanner *bufio.Scanner) (string, err
 }
 
 // generateInteractiveWithReadline provides an enhanced interactive mode with readline support
-func generateInteractiveWithReadline(cmd *cobra.Command, desktopClient *desktop.Client, model string) error {
+func generateInteractiveWithReadline(cmd *cobra.Command, desktopClient *desktop.Client, model string, record *transcript) error {
 	usage := func() {
 		fmt.Fprintln(os.Stderr, "Available Commands:")
 		fmt.Fprintln(os.Stderr, "  /bye            Exit")
@@ -349,6 +351,7 @@ func generateInteractiveWithReadline(cmd *cobra.Command, desktopClient *desktop.
 				messagesWithSystem = append(messagesWithSystem, conversationHistory...)
 			}
 
+			sentAt := time.Now()
 			assistantResponse, processedUserMessage, err := chatWithMarkdownContext(chatCtx, cmd, desktopClient, model, userInput, messagesWithSystem)
 
 			// Clean up signal handler
@@ -370,11 +373,19 @@ func generateInteractiveWithReadline(cmd *cobra.Command, desktopClient *desktop.
 			// Add the processed user message and assistant response to conversation history.
 			// Using the processed message ensures the history reflects exactly what the model
 			// received (after file inclusions and image processing), not the raw user input.
-			conversationHistory = append(conversationHistory, processedUserMessage)
-			conversationHistory = append(conversationHistory, desktop.OpenAIChatMessage{
+			assistantMessage := desktop.OpenAIChatMessage{
 				Role:    "assistant",
 				Content: assistantResponse,
-			})
+			}
+			conversationHistory = append(conversationHistory, processedUserMessage, assistantMessage)
+
+			// Record the turn as soon as it completes, so that the transcript
+			// survives the session being killed.
+			record.add(processedUserMessage, sentAt)
+			record.add(assistantMessage, time.Now())
+			if err := record.write(); err != nil {
+				cmd.PrintErrln(err)
+			}
 
 			cmd.Println()
 			sb.Reset()
@@ -600,10 +611,35 @@ func buildUserMessage(prompt string, imageURLs []string) desktop.OpenAIChatMessa
 	}
 }
 
-// chatWithMarkdown performs chat and streams the response with selective markdown rendering.
-func chatWithMarkdown(cmd *cobra.Command, client *desktop.Client, model, prompt string) error {
-	_, _, err := chatWithMarkdownContext(cmd.Context(), cmd, client, model, prompt, nil)
-	return err
+// printChatStats prints the timing breakdown of a chat turn to stderr.
+func printChatStats(cmd *cobra.Command, stats desktop.ChatStats) {
+	cmd.PrintErrln()
+	cmd.PrintErrln(formatChatStats(stats))
+}
+
+// formatChatStats describes the timing and token usage of a chat turn,
+// leaving out what is unknown.
+func formatChatStats(stats desktop.ChatStats) string {
+	var parts []string
+	if stats.LoadTime > 0 {
+		parts = append(parts, "load "+formatStatDuration(stats.LoadTime))
+	}
+	parts = append(parts, "first token "+formatStatDuration(stats.TimeToFirstToken))
+	if stats.GenerationTime > 0 {
+		parts = append(parts, "generation "+formatStatDuration(stats.GenerationTime))
+	}
+	if stats.PromptTokens > 0 || stats.CompletionTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%d prompt + %d completion tokens", stats.PromptTokens, stats.CompletionTokens))
+	}
+	if speed := stats.TokensPerSecond(); speed > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f tokens/s", speed))
+	}
+	return "Stats: " + strings.Join(parts, ", ")
+}
+
+// formatStatDuration formats d in seconds with millisecond precision.
+func formatStatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
 }
 
 // chatWithMarkdownContext performs chat with context support and streams the response with selective markdown rendering.
@@ -613,6 +649,15 @@ func chatWithMarkdownContext(ctx context.Context, cmd *cobra.Command, client *de
 	colorMode, _ := cmd.Flags().GetString("color")
 	useMarkdown := shouldUseMarkdown(colorMode)
 	debug, _ := cmd.Flags().GetBool("debug")
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		var stats desktop.ChatStats
+		ctx = desktop.WithChatStats(ctx, &stats)
+		defer func() {
+			if err == nil {
+				printChatStats(cmd, stats)
+			}
+		}()
+	}
 
 	// Process file inclusions first (files referenced with @ symbol)
 	prompt, err = processFileInclusions(prompt)
@@ -670,9 +715,15 @@ func chatWithMarkdownContext(ctx context.Context, cmd *cobra.Command, client *de
 
 func newRunCmd() *cobra.Command {
 	var debug bool
+	var verbose bool
 	var colorMode string
 	var detach bool
 	var openaiURL string
+	var keepAlive string
+	var outputFile string
+	var outputFormat string
+	var jsonSchemaFile string
+	var pretty bool
 
 	const cmdArgs = "MODEL [PROMPT]"
 	c := &cobra.Command{
@@ -681,10 +732,37 @@ func newRunCmd() *cobra.Command {
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			switch colorMode {
 			case "auto", "yes", "no":
-				return nil
 			default:
 				return fmt.Errorf("--color must be one of: auto, yes, no (got %q)", colorMode)
 			}
+			if err := validateTranscriptFormat(outputFormat); err != nil {
+				return err
+			}
+			if outputFile != "" && detach {
+				return fmt.Errorf("--output-file flag cannot be used with --detach flag")
+			}
+			if jsonSchemaFile != "" && detach {
+				return fmt.Errorf("--json-schema flag cannot be used with --detach flag")
+			}
+			if pretty && jsonSchemaFile == "" {
+				return fmt.Errorf("--pretty flag requires --json-schema")
+			}
+			if verbose && (detach || jsonSchemaFile != "") {
+				return fmt.Errorf("--verbose flag cannot be used with --detach or --json-schema flags")
+			}
+			if keepAlive != "" {
+				if openaiURL != "" {
+					return fmt.Errorf("--keepalive flag cannot be used with --openaiurl flag")
+				}
+				d, err := time.ParseDuration(keepAlive)
+				if err != nil {
+					return fmt.Errorf("invalid --keepalive value %q: must be a duration such as 10m, or 0 to unload on exit", keepAlive)
+				}
+				if d == 0 && detach {
+					return fmt.Errorf("--keepalive 0 cannot be used with --detach flag")
+				}
+			}
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			model := args[0]
@@ -719,6 +797,27 @@ func newRunCmd() *cobra.Command {
 				}
 			}
 
+			// Structured output is generated for a single prompt.
+			var schema json.RawMessage
+			if jsonSchemaFile != "" {
+				if prompt == "" {
+					return fmt.Errorf("--json-schema flag requires a prompt")
+				}
+				var err error
+				if schema, err = loadJSONSchema(jsonSchemaFile); err != nil {
+					return err
+				}
+			}
+
+			// Record the conversation if requested, writing it once more on exit
+			// so that the file exists even if no turn completed.
+			record := newTranscript(outputFile, outputFormat, model)
+			defer func() {
+				if err := record.write(); err != nil {
+					cmd.PrintErrln(err)
+				}
+			}()
+
 			// Handle --openaiurl flag for external OpenAI endpoints
 			if openaiURL != "" {
 				if detach {
@@ -730,22 +829,34 @@ func newRunCmd() *cobra.Command {
 				}
 				openaiClient := desktop.New(ctx)
 
+				if schema != nil {
+					return chatStructured(cmd, openaiClient, model, prompt, schema, pretty, record)
+				}
+
 				if prompt != "" {
 					// Single prompt mode
 					useMarkdown := shouldUseMarkdown(colorMode)
-					if err := openaiClient.ChatWithContext(cmd.Context(), model, prompt, nil, func(content string) {
+					sentAt := time.Now()
+					var stats desktop.ChatStats
+					response, err := openaiClient.ChatWithMessagesContext(desktop.WithChatStats(cmd.Context(), &stats), model, nil, prompt, nil, func(content string) {
 						cmd.Print(content)
-					}, useMarkdown); err != nil {
+					}, useMarkdown)
+					if err != nil {
 						return handleClientError(err, "Failed to generate a response")
 					}
+					record.add(buildUserMessage(prompt, nil), sentAt)
+					record.add(desktop.OpenAIChatMessage{Role: "assistant", Content: response}, time.Now())
 					cmd.Println()
+					if verbose {
+						printChatStats(cmd, stats)
+					}
 					return nil
 				}
 
 				termenv.SetDefaultOutput(
 					termenv.NewOutput(asPrinter(cmd), termenv.WithColorCache(true)),
 				)
-				return generateInteractiveWithReadline(cmd, openaiClient, model)
+				return generateInteractiveWithReadline(cmd, openaiClient, model, record)
 			}
 
 			if _, err := ensureStandaloneRunnerAvailable(cmd.Context(), asPrinter(cmd), debug); err != nil {
@@ -754,6 +865,12 @@ func newRunCmd() *cobra.Command {
 
 			// Check if this is an NVIDIA NIM image
 			if isNIMImage(model) {
+				if outputFile != "" {
+					return fmt.Errorf("--output-file flag cannot be used with NIM images")
+				}
+				if schema != nil {
+					return fmt.Errorf("--json-schema flag cannot be used with NIM images")
+				}
 				// NIM images are handled differently - they run as Docker containers
 				// Create a Docker client
 				cli := getDockerCLI()
@@ -820,6 +937,29 @@ func newRunCmd() *cobra.Command {
 				}
 			}
 
+			if d, _ := time.ParseDuration(keepAlive); keepAlive != "" && d == 0 {
+				// A zero keep-alive on the server would unload the model after
+				// every response, so only unload it once the run finishes.
+				defer unloadOnExit(cmd, model)
+			} else if keepAlive != "" {
+				previous, err := keepAliveOverride(model)
+				if err != nil {
+					return handleClientError(err, "Failed to read keep-alive")
+				}
+				if err := desktopClient.ConfigureBackend(scheduling.ConfigureRequest{
+					Model:     model,
+					KeepAlive: &keepAlive,
+				}); err != nil {
+					return handleClientError(err, "Failed to set keep-alive")
+				}
+				// A detached model is meant to stay loaded for the requested
+				// time, so only interactive and one-shot runs restore the
+				// previous keep-alive.
+				if !detach {
+					defer restoreKeepAlive(cmd, model, previous)
+				}
+			}
+
 			// Handle --detach flag: just load the model without interaction
 			if detach {
 				if err := desktopClient.Preload(cmd.Context(), model); err != nil {
@@ -831,10 +971,18 @@ func newRunCmd() *cobra.Command {
 				return nil
 			}
 
+			if schema != nil {
+				return chatStructured(cmd, desktopClient, model, prompt, schema, pretty, record)
+			}
+
 			if prompt != "" {
-				if err := chatWithMarkdown(cmd, desktopClient, model, prompt); err != nil {
+				sentAt := time.Now()
+				response, userMessage, err := chatWithMarkdownContext(cmd.Context(), cmd, desktopClient, model, prompt, nil)
+				if err != nil {
 					return handleClientError(err, "Failed to generate a response")
 				}
+				record.add(userMessage, sentAt)
+				record.add(desktop.OpenAIChatMessage{Role: "assistant", Content: response}, time.Now())
 				cmd.Println()
 				return nil
 			}
@@ -854,7 +1002,7 @@ func newRunCmd() *cobra.Command {
 				termenv.NewOutput(asPrinter(cmd), termenv.WithColorCache(true)),
 			)
 
-			return generateInteractiveWithReadline(cmd, desktopClient, model)
+			return generateInteractiveWithReadline(cmd, desktopClient, model, record)
 
 		},
 		ValidArgsFunction: completion.ModelNames(getDesktopClient, 1),
@@ -862,9 +1010,48 @@ func newRunCmd() *cobra.Command {
 	c.Args = requireMinArgs(1, "run", cmdArgs)
 
 	c.Flags().BoolVar(&debug, "debug", false, "Enable debug logging")
+	c.Flags().BoolVar(&verbose, "verbose", false, "Print load time, time to first token, token counts and speed after each response")
 	c.Flags().StringVar(&colorMode, "color", "no", "Use colored output (auto|yes|no)")
 	c.Flags().BoolVarP(&detach, "detach", "d", false, "Load the model in the background without interaction")
 	c.Flags().StringVar(&openaiURL, "openaiurl", "", "OpenAI-compatible API endpoint URL to chat with")
+	c.Flags().StringVar(&keepAlive, "keepalive", "", "How long to keep the model loaded once idle (e.g. 10m, or 0 to unload on exit)")
+	c.Flags().StringVar(&outputFile, "output-file", "", "Write the conversation, with timestamps, to a file")
+	c.Flags().StringVar(&outputFormat, "output-format", "json", "Format of the --output-file transcript (json|md)")
+	c.Flags().StringVar(&jsonSchemaFile, "json-schema", "", "Constrain the response to JSON conforming to the schema in this file")
+	c.Flags().BoolVar(&pretty, "pretty", false, "Pretty-print the --json-schema response")
 
 	return c
 }
+
+// keepAliveOverride returns the keep-alive configured for model, or an empty
+// string if it uses the server default.
+func keepAliveOverride(model string) (string, error) {
+	configs, err := desktopClient.ShowConfigs(model)
+	if err != nil {
+		return "", err
+	}
+	for _, config := range configs {
+		if config.Mode == inference.BackendModeCompletion {
+			return config.KeepAlive, nil
+		}
+	}
+	return "", nil
+}
+
+// restoreKeepAlive restores the keep-alive model had before a run started with
+// --keepalive, where an empty keep-alive restores the server default.
+func restoreKeepAlive(cmd *cobra.Command, model, keepAlive string) {
+	if err := desktopClient.ConfigureBackend(scheduling.ConfigureRequest{
+		Model:     model,
+		KeepAlive: &keepAlive,
+	}); err != nil {
+		cmd.PrintErrf("Failed to restore the keep-alive of model %s: %v\n", model, err)
+	}
+}
+
+// unloadOnExit unloads model once a run started with --keepalive 0 finishes.
+func unloadOnExit(cmd *cobra.Command, model string) {
+	if _, err := desktopClient.Unload(desktop.UnloadRequest{Models: []string{model}}); err != nil {
+		cmd.PrintErrf("Failed to unload model %s: %v\n", model, err)
+	}
+}
@@ -2,10 +2,19 @@ package commands
 
 import (
 	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/model-runner/cmd/cli/desktop"
+	mockdesktop "github.com/docker/model-runner/cmd/cli/mocks"
 	"github.com/spf13/cobra"
+	"go.uber.org/mock/gomock"
 )
 
 func TestReadMultilineInput(t *testing.T) {
@@ -160,3 +169,213 @@ func TestRunCmdDetachFlag(t *testing.T) {
 		t.Errorf("Expected detach flag value to be true, got false")
 	}
 }
+
+func TestRunCmdKeepAliveValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		wantErr string
+	}{
+		{name: "duration", flags: map[string]string{"keepalive": "10m"}},
+		{name: "zero", flags: map[string]string{"keepalive": "0"}},
+		{name: "invalid", flags: map[string]string{"keepalive": "soon"}, wantErr: "invalid --keepalive"},
+		{name: "zero with detach", flags: map[string]string{"keepalive": "0", "detach": "true"}, wantErr: "--detach"},
+		{name: "with openaiurl", flags: map[string]string{"keepalive": "5m", "openaiurl": "http://localhost:1234/v1"}, wantErr: "--openaiurl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newRunCmd()
+			for name, value := range tt.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatalf("Failed to set --%s: %v", name, err)
+				}
+			}
+			err := cmd.PreRunE(cmd, []string{"ai/smollm2"})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRestoreKeepAlive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mockdesktop.NewMockDockerHttpClient(ctrl)
+	modelRunner = desktop.NewContextForMock(client)
+	desktopClient = desktop.New(modelRunner)
+
+	configs := `[{"Mode":"embedding","KeepAlive":"1h0m0s"},{"Mode":"completion","KeepAlive":"10m0s"}]`
+	client.EXPECT().Do(gomock.Cond(func(req any) bool {
+		r, ok := req.(*http.Request)
+		return ok && r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/_configure")
+	})).Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(configs))}, nil)
+	previous, err := keepAliveOverride("ai/smollm2")
+	if err != nil {
+		t.Fatalf("keepAliveOverride failed: %v", err)
+	}
+	if previous != "10m0s" {
+		t.Errorf("Expected the completion keep-alive, got %q", previous)
+	}
+
+	var restored map[string]any
+	client.EXPECT().Do(gomock.Cond(func(req any) bool {
+		r, ok := req.(*http.Request)
+		return ok && r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/_configure")
+	})).DoAndReturn(func(r *http.Request) (*http.Response, error) {
+		if err := json.NewDecoder(r.Body).Decode(&restored); err != nil {
+			t.Errorf("Failed to decode the configure request: %v", err)
+		}
+		return &http.Response{StatusCode: http.StatusAccepted, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	restoreKeepAlive(newRunCmd(), "ai/smollm2", "")
+	if keepAlive, ok := restored["keep-alive"]; !ok || keepAlive != "" {
+		t.Errorf("Expected an empty keep-alive restoring the server default, got %v", restored)
+	}
+}
+
+func TestRunCmdOutputFileValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		wantErr string
+	}{
+		{name: "json", flags: map[string]string{"output-file": "chat.json"}},
+		{name: "markdown", flags: map[string]string{"output-file": "chat.md", "output-format": "md"}},
+		{name: "invalid format", flags: map[string]string{"output-file": "chat.txt", "output-format": "txt"}, wantErr: "--output-format"},
+		{name: "with detach", flags: map[string]string{"output-file": "chat.json", "detach": "true"}, wantErr: "--detach"},
+		{name: "json schema with detach", flags: map[string]string{"json-schema": "schema.json", "detach": "true"}, wantErr: "--detach"},
+		{name: "pretty without json schema", flags: map[string]string{"pretty": "true"}, wantErr: "--json-schema"},
+		{name: "verbose", flags: map[string]string{"verbose": "true"}},
+		{name: "verbose with detach", flags: map[string]string{"verbose": "true", "detach": "true"}, wantErr: "--detach"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newRunCmd()
+			for name, value := range tt.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatalf("Failed to set --%s: %v", name, err)
+				}
+			}
+			err := cmd.PreRunE(cmd, []string{"ai/smollm2"})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFormatChatStats(t *testing.T) {
+	stats := desktop.ChatStats{
+		LoadTime:         1500 * time.Millisecond,
+		TimeToFirstToken: 1750 * time.Millisecond,
+		GenerationTime:   2 * time.Second,
+		PromptTokens:     24,
+		CompletionTokens: 100,
+	}
+	want := "Stats: load 1.500s, first token 1.750s, generation 2.000s, 24 prompt + 100 completion tokens, 50.0 tokens/s"
+	if got := formatChatStats(stats); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// Warm, non-streamed turns without usage only have a response time.
+	if got := formatChatStats(desktop.ChatStats{TimeToFirstToken: 250 * time.Millisecond}); got != "Stats: first token 0.250s" {
+		t.Errorf("Expected only the first token time, got %q", got)
+	}
+}
+
+func TestLoadJSONSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "object", schema: `{"type": "object", "properties": {"name": {"type": "string"}, "tags": {"type": "array", "items": {"type": "string"}}}, "required": ["name"], "additionalProperties": false}`},
+		{name: "combinators", schema: `{"anyOf": [{"type": ["string", "null"]}, {"$ref": "#/$defs/n"}], "$defs": {"n": {"type": "integer", "minimum": 0}}}`},
+		{name: "not json", schema: `{"type": "object"`, wantErr: "invalid JSON schema"},
+		{name: "not an object", schema: `["string"]`, wantErr: "must be an object"},
+		{name: "unknown type", schema: `{"type": "text"}`, wantErr: `#/type: unknown type "text"`},
+		{name: "nested unknown type", schema: `{"properties": {"age": {"type": "int"}}}`, wantErr: "#/properties/age/type"},
+		{name: "required not strings", schema: `{"required": [1]}`, wantErr: "#/required"},
+		{name: "empty anyOf", schema: `{"anyOf": []}`, wantErr: "#/anyOf"},
+		{name: "negative maxLength", schema: `{"maxLength": -1}`, wantErr: "#/maxLength"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema.json")
+			if err := os.WriteFile(path, []byte(tt.schema), 0o644); err != nil {
+				t.Fatalf("Failed to write schema: %v", err)
+			}
+			schema, err := loadJSONSchema(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if strings.ContainsAny(string(schema), " \n") {
+					t.Errorf("Expected a compacted schema, got %s", schema)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTranscriptWrite(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, format := range []string{"json", "md"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "chat."+format)
+			record := newTranscript(path, format, "ai/smollm2")
+			record.add(desktop.OpenAIChatMessage{Role: "user", Content: []desktop.ContentPart{
+				{Type: "image_url"},
+				{Type: "text", Text: "What is this?"},
+			}}, at)
+			record.add(desktop.OpenAIChatMessage{Role: "assistant", Content: "A cat."}, at)
+			if err := record.write(); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read transcript: %v", err)
+			}
+
+			if format == "md" {
+				for _, want := range []string{"# Conversation with ai/smollm2", "## User (2025-01-02T03:04:05Z)\n\n[image_url]\nWhat is this?", "## Assistant (2025-01-02T03:04:05Z)\n\nA cat."} {
+					if !strings.Contains(string(data), want) {
+						t.Errorf("Expected transcript to contain %q, got:\n%s", want, data)
+					}
+				}
+				return
+			}
+			var decoded transcript
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Failed to decode transcript: %v", err)
+			}
+			if decoded.Model != "ai/smollm2" || len(decoded.Entries) != 2 ||
+				decoded.Entries[0].Content != "[image_url]\nWhat is this?" || decoded.Entries[1].Role != "assistant" {
+				t.Errorf("Unexpected transcript: %+v", decoded)
+			}
+		})
+	}
+
+	// A nil transcript, used when --output-file isn't set, records nothing.
+	var record *transcript
+	record.add(desktop.OpenAIChatMessage{Role: "user", Content: "hi"}, at)
+	if err := record.write(); err != nil {
+		t.Errorf("Expected no error from a nil transcript, got %v", err)
+	}
+}
@@ -2,10 +2,15 @@ package commands
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"net/url"
 
+	"github.com/docker/go-units"
 	"github.com/docker/model-runner/cmd/cli/commands/formatter"
+	"github.com/docker/model-runner/cmd/cli/desktop"
 	"github.com/docker/model-runner/cmd/cli/search"
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
 	"github.com/spf13/cobra"
 )
 
@@ -18,8 +23,9 @@ func newSearchCmd() *cobra.Command {
 
 	c := &cobra.Command{
 		Use:   "search [OPTIONS] [TERM]",
-		Short: "Search for models on Docker Hub and HuggingFace",
-		Long: `Search for models from Docker Hub (ai/ namespace) and HuggingFace.
+		Short: "Search for models on Docker Hub, HuggingFace and the model catalog",
+		Long: `Search for models from Docker Hub (ai/ namespace), HuggingFace and the model
+catalog configured on the Model Runner, if any.
 
 When no search term is provided, lists all available models.
 When a search term is provided, filters models by name/description.
@@ -29,6 +35,7 @@ Examples:
   docker model search llama                 # Search for models containing "llama"
   docker model search --source=all          # Search both Docker Hub and HuggingFace
   docker model search --source=huggingface  # Only search HuggingFace
+  docker model search --source=catalog      # Only search the configured catalog
   docker model search --limit=50 phi        # Search with custom limit
   docker model search --json llama          # Output as JSON`,
 		Args: cobra.MaximumNArgs(1),
@@ -46,7 +53,7 @@ Examples:
 			}
 
 			// Create the search client
-			client := search.NewAggregatedClient(sourceType, cmd.ErrOrStderr())
+			client := search.NewAggregatedClient(sourceType, cmd.ErrOrStderr(), catalogLister{})
 
 			// Perform the search
 			opts := search.SearchOptions{
@@ -84,31 +91,68 @@ Examples:
 	}
 
 	c.Flags().IntVarP(&limit, "limit", "n", 32, "Maximum number of results to show")
-	c.Flags().StringVar(&source, "source", "all", "Source to search: all, dockerhub, huggingface")
+	c.Flags().StringVar(&source, "source", "all", "Source to search: all, catalog, dockerhub, huggingface")
 	c.Flags().BoolVar(&jsonFormat, "json", false, "Output results as JSON")
 
 	return c
 }
 
-// prettyPrintSearchResults formats search results as a table
+// catalogLister lists the model runner's configured catalog, reporting an
+// unreachable runner or a missing catalog as search.ErrCatalogUnavailable.
+type catalogLister struct{}
+
+func (catalogLister) Catalog(query string) ([]dmrm.CatalogEntry, error) {
+	if desktopClient == nil {
+		return nil, search.ErrCatalogUnavailable
+	}
+	entries, err := desktopClient.Catalog(query)
+	var urlErr *url.Error
+	if errors.Is(err, desktop.ErrCatalogNotConfigured) || errors.Is(err, desktop.ErrServiceUnavailable) || errors.As(err, &urlErr) {
+		return nil, fmt.Errorf("%w: %w", search.ErrCatalogUnavailable, err)
+	}
+	return entries, err
+}
+
+// prettyPrintSearchResults formats search results as a table. A size column
+// is included if any result has a known size.
 func prettyPrintSearchResults(results []search.SearchResult) string {
+	withSize := false
+	for _, r := range results {
+		if r.Size > 0 {
+			withSize = true
+			break
+		}
+	}
+
 	var buf bytes.Buffer
 	table := newTable(&buf)
-	table.Header([]string{"NAME", "DESCRIPTION", "BACKEND", "DOWNLOADS", "STARS", "SOURCE"})
+	header := []string{"NAME", "DESCRIPTION", "BACKEND", "DOWNLOADS", "STARS", "SOURCE"}
+	if withSize {
+		header = append(header, "SIZE")
+	}
+	table.Header(header)
 
 	for _, r := range results {
 		name := r.Name
 		if r.Source == search.HuggingFaceSourceName {
 			name = "hf.co/" + r.Name
 		}
-		table.Append([]string{
+		row := []string{
 			name,
 			r.Description,
 			r.Backend,
 			formatCount(r.Downloads),
 			formatCount(r.Stars),
 			r.Source,
-		})
+		}
+		if withSize {
+			size := ""
+			if r.Size > 0 {
+				size = units.HumanSize(float64(r.Size))
+			}
+			row = append(row, size)
+		}
+		table.Append(row)
 	}
 
 	table.Render()
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"github.com/docker/go-units"
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	"github.com/spf13/cobra"
+)
+
+func newStoreCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "store",
+		Short: "Manage the local model store",
+	}
+	c.AddCommand(withStandaloneRunner(newStoreCompactCmd()))
+	return c
+}
+
+func newStoreCompactCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "compact",
+		Short: "Reclaim space from empty directories and bloated index files in the model store",
+		Long: "Reclaim space from empty directories and bloated index files in the model store.\n\n" +
+			"Duplicate entries of the models index are merged. Models and their blobs are left intact, " +
+			"and the store remains usable while it is compacted.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := desktopClient.Compact()
+			if err != nil {
+				return handleClientError(err, "Failed to compact the model store")
+			}
+			if result.MergedEntries > 0 {
+				cmd.Printf("Merged %d duplicate index entries\n", result.MergedEntries)
+			}
+			cmd.Printf("Removed %d empty directories\n", result.Inodes)
+			cmd.Printf("Total reclaimed space: %s\n", units.HumanSize(float64(result.Bytes)))
+			return nil
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	return c
+}
@@ -12,26 +12,28 @@ import (
 )
 
 func newTagCmd() *cobra.Command {
+	var force bool
 	c := &cobra.Command{
 		Use:   "tag SOURCE TARGET",
 		Short: "Tag a model",
 		Args:  requireExactArgs(2, "tag", "SOURCE TARGET"),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tagModel(cmd, desktopClient, args[0], args[1])
+			return tagModel(cmd, desktopClient, args[0], args[1], force)
 		},
 		ValidArgsFunction: completion.ModelNames(getDesktopClient, 1),
 	}
+	c.Flags().BoolVarP(&force, "force", "f", false, "Move the tag if it already refers to a different model")
 	return c
 }
 
-func tagModel(cmd *cobra.Command, desktopClient *desktop.Client, source, target string) error {
+func tagModel(cmd *cobra.Command, desktopClient *desktop.Client, source, target string, force bool) error {
 	// Ensure tag is valid
 	tag, err := reference.NewTag(target, registry.GetDefaultRegistryOptions()...)
 	if err != nil {
 		return fmt.Errorf("invalid tag: %w", err)
 	}
 	// Make tag request with model runner client
-	if err := desktopClient.Tag(source, parseRepo(tag), tag.TagStr()); err != nil {
+	if err := desktopClient.Tag(source, parseRepo(tag), tag.TagStr(), force); err != nil {
 		return fmt.Errorf("failed to tag model: %w", err)
 	}
 	cmd.Printf("Model %q tagged successfully with %q\n", source, target)
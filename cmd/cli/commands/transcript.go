@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/model-runner/cmd/cli/desktop"
+)
+
+// transcriptEntry is a turn of a recorded conversation.
+type transcriptEntry struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// transcript records a run session's conversation to a file.
+type transcript struct {
+	path    string
+	format  string
+	Model   string            `json:"model"`
+	Started time.Time         `json:"started_at"`
+	Entries []transcriptEntry `json:"messages"`
+}
+
+// newTranscript returns a transcript of a conversation with model written to
+// path in format ("json" or "md"), or nil if path is empty.
+func newTranscript(path, format, model string) *transcript {
+	if path == "" {
+		return nil
+	}
+	return &transcript{path: path, format: format, Model: model, Started: time.Now()}
+}
+
+// validateTranscriptFormat checks the --output-format flag.
+func validateTranscriptFormat(format string) error {
+	switch format {
+	case "json", "md":
+		return nil
+	default:
+		return fmt.Errorf("--output-format must be one of: json, md (got %q)", format)
+	}
+}
+
+// add records a message sent at the given time. Images are noted by a
+// placeholder.
+func (t *transcript) add(message desktop.OpenAIChatMessage, at time.Time) {
+	if t == nil {
+		return
+	}
+	t.Entries = append(t.Entries, transcriptEntry{
+		Role:      message.Role,
+		Content:   messageText(message.Content),
+		Timestamp: at,
+	})
+}
+
+// write writes the conversation recorded so far, replacing the file.
+func (t *transcript) write() error {
+	if t == nil {
+		return nil
+	}
+	var data []byte
+	if t.format == "md" {
+		data = []byte(t.markdown())
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(t, "", "  "); err != nil {
+			return fmt.Errorf("failed to marshal transcript: %w", err)
+		}
+		data = append(data, '\n')
+	}
+	if err := os.WriteFile(t.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// markdown renders the conversation as a markdown document.
+func (t *transcript) markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Conversation with %s\n\nStarted %s\n", t.Model, t.Started.Format(time.RFC3339))
+	for _, entry := range t.Entries {
+		role := entry.Role
+		if role != "" {
+			role = strings.ToUpper(role[:1]) + role[1:]
+		}
+		fmt.Fprintf(&sb, "\n## %s (%s)\n\n%s\n", role, entry.Timestamp.Format(time.RFC3339), entry.Content)
+	}
+	return sb.String()
+}
+
+// messageText returns the text of a chat message's content, which is either a
+// string or a list of content parts.
+func messageText(content interface{}) string {
+	switch content := content.(type) {
+	case string:
+		return content
+	case []desktop.ContentPart:
+		var parts []string
+		for _, part := range content {
+			if part.Type == "text" {
+				parts = append(parts, part.Text)
+			} else {
+				parts = append(parts, "["+part.Type+"]")
+			}
+		}
+		return strings.Join(parts, "\n")
+	default:
+		return fmt.Sprint(content)
+	}
+}
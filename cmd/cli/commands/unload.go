@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/docker/model-runner/cmd/cli/commands/completion"
 	"github.com/docker/model-runner/cmd/cli/desktop"
@@ -9,15 +10,20 @@ import (
 )
 
 func newUnloadCmd() *cobra.Command {
-	var all bool
+	var all, wait bool
 	var backend string
+	var waitTimeout time.Duration
 
-	const cmdArgs = "(MODEL [MODEL ...] [--backend BACKEND] | --all)"
+	const cmdArgs = "(MODEL [MODEL ...] [--backend BACKEND] | --all [--wait])"
 	c := &cobra.Command{
 		Use:   "unload " + cmdArgs,
 		Short: "Unload running models",
 		RunE: func(cmd *cobra.Command, modelArgs []string) error {
-			unloadResp, err := desktopClient.Unload(desktop.UnloadRequest{All: all, Backend: backend, Models: modelArgs})
+			req := desktop.UnloadRequest{All: all, Backend: backend, Models: modelArgs}
+			if wait || cmd.Flags().Changed("wait-timeout") {
+				req.Wait, req.WaitTimeout = true, waitTimeout.String()
+			}
+			unloadResp, err := desktopClient.Unload(req)
 			if err != nil {
 				return handleClientError(err, "Failed to unload models")
 			}
@@ -36,6 +42,16 @@ func newUnloadCmd() *cobra.Command {
 		ValidArgsFunction: completion.NoComplete,
 	}
 	c.Args = func(cmd *cobra.Command, args []string) error {
+		if (wait || cmd.Flags().Changed("wait-timeout")) && !all {
+			return fmt.Errorf(
+				"'docker model unload' only supports --wait with --all.\n\n" +
+					"Usage:  docker model unload " + cmdArgs + "\n\n" +
+					"See 'docker model unload --help' for more information.",
+			)
+		}
+		if waitTimeout <= 0 {
+			return fmt.Errorf("--wait-timeout must be positive")
+		}
 		if all {
 			if len(args) > 0 {
 				return fmt.Errorf(
@@ -57,5 +73,7 @@ func newUnloadCmd() *cobra.Command {
 	}
 	c.Flags().BoolVar(&all, "all", false, "Unload all running models")
 	c.Flags().StringVar(&backend, "backend", "", "Optional backend to target")
+	c.Flags().BoolVar(&wait, "wait", false, "With --all, wait for in-flight requests to finish before unloading models in use")
+	c.Flags().DurationVar(&waitTimeout, "wait-timeout", 2*time.Minute, "Maximum time to wait for in-flight requests, implies --wait")
 	return c
 }
@@ -102,6 +102,54 @@ func asPrinter(cmd *cobra.Command) standalone.StatusPrinter {
 	return &commandPrinter{cmd: cmd}
 }
 
+// progressOptions holds the flags controlling how transfer progress is shown.
+type progressOptions struct {
+	// quiet suppresses progress output.
+	quiet bool
+	// mode is the progress output mode: "auto" or "json".
+	mode string
+}
+
+// addFlags registers the progress flags on cmd.
+func (o *progressOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&o.quiet, "quiet", "q", false, "Suppress progress output")
+	cmd.Flags().StringVar(&o.mode, "progress", "auto",
+		"Progress output mode (auto|json); json writes each progress message to stdout as a line of JSON")
+}
+
+// validate checks that the progress flags are consistent.
+func (o *progressOptions) validate() error {
+	switch o.mode {
+	case "", "auto":
+	case "json":
+		if o.quiet {
+			return fmt.Errorf("--quiet cannot be used with --progress json")
+		}
+	default:
+		return fmt.Errorf("--progress must be one of: auto, json (got %q)", o.mode)
+	}
+	return nil
+}
+
+// printer returns the printer to render transfer progress with.
+func (o *progressOptions) printer(cmd *cobra.Command) standalone.StatusPrinter {
+	if o.quiet {
+		return standalone.QuietPrinter(asPrinter(cmd))
+	}
+	if o.mode == "json" {
+		return desktop.NewJSONProgressPrinter(asPrinter(cmd))
+	}
+	return asPrinter(cmd)
+}
+
+// printResult prints the final message of a transfer. In JSON mode the
+// message was already written as part of the progress stream.
+func (o *progressOptions) printResult(cmd *cobra.Command, message string) {
+	if o.mode != "json" {
+		cmd.Println(message)
+	}
+}
+
 // stripDefaultsFromModelName removes the default "ai/" prefix, default registry, and ":latest" tag for display.
 // Examples:
 //   - "ai/gemma3:latest" -> "gemma3"
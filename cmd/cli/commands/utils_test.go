@@ -112,3 +112,24 @@ func TestHandleClientErrorFormat(t *testing.T) {
 		}
 	})
 }
+
+func TestProgressOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    progressOptions
+		wantErr bool
+	}{
+		{name: "default", opts: progressOptions{mode: "auto"}},
+		{name: "quiet", opts: progressOptions{quiet: true, mode: "auto"}},
+		{name: "json", opts: progressOptions{mode: "json"}},
+		{name: "quiet json", opts: progressOptions{quiet: true, mode: "json"}, wantErr: true},
+		{name: "unknown mode", opts: progressOptions{mode: "tty"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
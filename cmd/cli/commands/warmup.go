@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/docker/model-runner/cmd/cli/commands/completion"
+	"github.com/docker/model-runner/cmd/cli/desktop"
+	"github.com/spf13/cobra"
+)
+
+func newWarmupCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "warmup MODEL [MODEL...]",
+		Short: "Preload models into memory to avoid first-request latency",
+		Args:  requireMinArgs(1, "warmup", "MODEL [MODEL...]"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := desktopClient.Warmup(cmd.Context(), args)
+			if err != nil {
+				return handleClientError(err, "Failed to warm up models")
+			}
+			cmd.Print(warmupTable(results))
+
+			failed := 0
+			for _, result := range results {
+				if result.Status == desktop.WarmupFailed {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("failed to warm up %d of %d model(s)", failed, len(args))
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.ModelNames(getDesktopClient, -1),
+	}
+	return c
+}
+
+func warmupTable(results []desktop.WarmupResult) string {
+	var buf bytes.Buffer
+	table := newTable(&buf)
+	table.Header([]string{"MODEL", "STATUS", "DETAILS"})
+	for _, result := range results {
+		details := ""
+		if result.Err != nil {
+			details = result.Err.Error()
+		}
+		table.Append([]string{result.Model, result.Status, details})
+	}
+	table.Render()
+	return buf.String()
+}
@@ -1,5 +1,7 @@
 package desktop
 
+import "encoding/json"
+
 type OpenAIChatMessage struct {
 	Role    string      `json:"role"`
 	Content interface{} `json:"content"` // Can be string or []ContentPart for multimodal
@@ -18,9 +20,34 @@ type ImageURL struct {
 }
 
 type OpenAIChatRequest struct {
-	Model    string              `json:"model"`
-	Messages []OpenAIChatMessage `json:"messages"`
-	Stream   bool                `json:"stream"`
+	Model          string              `json:"model"`
+	Messages       []OpenAIChatMessage `json:"messages"`
+	Stream         bool                `json:"stream"`
+	ResponseFormat *ResponseFormat     `json:"response_format,omitempty"`
+}
+
+// ResponseFormat constrains the format of a chat response.
+type ResponseFormat struct {
+	Type       string            `json:"type"` // "json_schema"
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat is the JSON schema a structured chat response conforms to.
+type JSONSchemaFormat struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+// TokenizeRequest is the body of a tokenize request.
+type TokenizeRequest struct {
+	Model   string `json:"model"`
+	Content string `json:"content"`
+}
+
+// TokenizeResponse is the body of a tokenize response.
+type TokenizeResponse struct {
+	Tokens []int `json:"tokens"`
 }
 
 type OpenAIChatResponse struct {
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
@@ -27,6 +28,18 @@ import (
 var (
 	ErrNotFound           = errors.New("model not found")
 	ErrServiceUnavailable = errors.New("service unavailable")
+	// ErrCatalogNotConfigured is returned when the model runner has no model
+	// catalog configured.
+	ErrCatalogNotConfigured = errors.New("no model catalog configured")
+	// ErrModelArchived is returned when an archived model must be pulled
+	// again before it can be used.
+	ErrModelArchived = errors.New("model archived, re-pull required")
+	// ErrStructuredOutputUnsupported is returned when the model's backend
+	// can't constrain a chat response to a schema.
+	ErrStructuredOutputUnsupported = errors.New("the model's backend does not support grammar-constrained generation")
+	// ErrInvalidStructuredOutput is returned when a structured chat response
+	// isn't valid JSON.
+	ErrInvalidStructuredOutput = errors.New("structured output is not valid JSON")
 )
 
 type otelErrorSilencer struct{}
@@ -98,6 +111,28 @@ func (c *Client) Status() Status {
 }
 
 func (c *Client) Pull(model string, printer standalone.StatusPrinter) (string, bool, error) {
+	return c.PullWithOptions(model, PullOptions{}, printer)
+}
+
+// PullPlatform pulls model, selecting the variant for platform
+// ("os/arch[/variant]") if model refers to a multi-platform image index. An
+// empty platform lets the server choose.
+func (c *Client) PullPlatform(model, platform string, printer standalone.StatusPrinter) (string, bool, error) {
+	return c.PullWithOptions(model, PullOptions{Platform: platform}, printer)
+}
+
+// PullOptions are the optional parameters of a pull.
+type PullOptions struct {
+	// Platform selects the variant ("os/arch[/variant]") of a multi-platform
+	// image index. Empty lets the server choose.
+	Platform string
+	// ExpectedDigest, if set, makes the pull fail before downloading unless
+	// the model's manifest has this digest ("sha256:...").
+	ExpectedDigest string
+}
+
+// PullWithOptions pulls model with opts.
+func (c *Client) PullWithOptions(model string, opts PullOptions, printer standalone.StatusPrinter) (string, bool, error) {
 	// Check if this is a Hugging Face model and if HF_TOKEN is set
 	var hfToken string
 	if strings.HasPrefix(strings.ToLower(model), "hf.co/") {
@@ -106,8 +141,10 @@ func (c *Client) Pull(model string, printer standalone.StatusPrinter) (string, b
 
 	return c.withRetries("download", 3, printer, func(attempt int) (string, bool, error, bool) {
 		jsonData, err := json.Marshal(dmrm.ModelCreateRequest{
-			From:        model,
-			BearerToken: hfToken,
+			From:           model,
+			BearerToken:    hfToken,
+			Platform:       opts.Platform,
+			ExpectedDigest: opts.ExpectedDigest,
 		})
 		if err != nil {
 			// Marshaling errors are not retryable
@@ -133,16 +170,16 @@ func (c *Client) Pull(model string, printer standalone.StatusPrinter) (string, b
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			err := fmt.Errorf("pulling %s failed with status %s: %s", model, resp.Status, string(body))
-			// Only retry on server errors (5xx), not client errors (4xx)
-			shouldRetry := resp.StatusCode >= 500 && resp.StatusCode < 600
-			return "", false, err, shouldRetry
+			// Only retry on server errors (5xx) and rate limits, not other
+			// client errors (4xx)
+			shouldRetry := resp.StatusCode >= 500 && resp.StatusCode < 600 || resp.StatusCode == http.StatusTooManyRequests
+			return "", false, newRateLimitedError(resp, err), shouldRetry
 		}
 
 		// Use Docker-style progress display
 		message, shown, err := DisplayProgress(resp.Body, printer)
 		if err != nil {
-			// Retry on progress display errors (likely network interruption)
-			shouldRetry := isRetryableError(err)
+			err, shouldRetry := classifyProgressError(err)
 			return "", shown, err, shouldRetry
 		}
 
@@ -188,7 +225,62 @@ func isRetryableError(err error) bool {
 	return false
 }
 
-// withRetries executes an operation with automatic retry logic for transient failures
+// maxRateLimitWait bounds how long withRetries waits before retrying an
+// operation the server rate limited, whatever its Retry-After header says.
+const maxRateLimitWait = time.Minute
+
+// rateLimitedError is returned by an operation attempt that the server
+// rejected with 429 Too Many Requests.
+type rateLimitedError struct {
+	err error
+	// retryAfter is how long the server asked to wait before retrying.
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rateLimitedError) Unwrap() error {
+	return e.err
+}
+
+// newRateLimitedError returns err as a rateLimitedError if resp is a 429
+// response, defaulting to a one second wait if it has no valid Retry-After
+// header, and err otherwise.
+func newRateLimitedError(resp *http.Response, err error) error {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return err
+	}
+	retryAfter := time.Second
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return &rateLimitedError{err: err, retryAfter: retryAfter}
+}
+
+// classifyProgressError returns err from DisplayProgress as a
+// rateLimitedError if the server reported a rate limit in the progress
+// stream, and whether the operation should be retried. Errors reported with
+// a status are retried like responses with that status, and others if they
+// look like a network interruption.
+func classifyProgressError(err error) (error, bool) {
+	var failure *progressError
+	if !errors.As(err, &failure) || failure.status == 0 {
+		return err, isRetryableError(err)
+	}
+	if failure.status == http.StatusTooManyRequests {
+		retryAfter := failure.retryAfter
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		return &rateLimitedError{err: err, retryAfter: retryAfter}, true
+	}
+	return err, failure.status >= 500 && failure.status < 600
+}
+
+// withRetries executes an operation with automatic retry logic for transient
+// failures, honoring the Retry-After header of rate limited attempts.
 func (c *Client) withRetries(
 	operationName string,
 	maxRetries int,
@@ -200,10 +292,18 @@ func (c *Client) withRetries(
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			// Calculate exponential backoff: 2^(attempt-1) seconds (1s, 2s, 4s)
-			backoffDuration := time.Duration(1<<uint(attempt-1)) * time.Second
-			printer.PrintErrf("Retrying %s (attempt %d/%d) in %v...\n", operationName, attempt, maxRetries, backoffDuration)
-			time.Sleep(backoffDuration)
+			var rateLimited *rateLimitedError
+			if errors.As(lastErr, &rateLimited) {
+				// Wait as long as the server asked, within reason.
+				wait := min(rateLimited.retryAfter, maxRateLimitWait)
+				printer.PrintErrf("Rate limited, retrying %s (attempt %d/%d) in %v...\n", operationName, attempt, maxRetries, wait)
+				time.Sleep(wait)
+			} else {
+				// Calculate exponential backoff: 2^(attempt-1) seconds (1s, 2s, 4s)
+				backoffDuration := time.Duration(1<<uint(attempt-1)) * time.Second
+				printer.PrintErrf("Retrying %s (attempt %d/%d) in %v...\n", operationName, attempt, maxRetries, backoffDuration)
+				time.Sleep(backoffDuration)
+			}
 		}
 
 		message, shown, err, shouldRetry := operation(attempt)
@@ -223,12 +323,30 @@ func (c *Client) withRetries(
 }
 
 func (c *Client) Push(model string, printer standalone.StatusPrinter) (string, bool, error) {
+	return c.push(model, nil, printer)
+}
+
+// PushIndex pushes the given local models as platform variants of an image
+// index tagged as tag.
+func (c *Client) PushIndex(tag string, variants []dmrm.PushVariant, printer standalone.StatusPrinter) (string, bool, error) {
+	body, err := json.Marshal(dmrm.PushRequest{Variants: variants})
+	if err != nil {
+		return "", false, fmt.Errorf("error marshaling request: %w", err)
+	}
+	return c.push(tag, body, printer)
+}
+
+func (c *Client) push(model string, body []byte, printer standalone.StatusPrinter) (string, bool, error) {
 	return c.withRetries("push", 3, printer, func(attempt int) (string, bool, error, bool) {
 		pushPath := inference.ModelsPrefix + "/" + model + "/push"
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
 		resp, err := c.doRequest(
 			http.MethodPost,
 			pushPath,
-			nil, // Assuming no body is needed for the push request
+			reqBody,
 		)
 		if err != nil {
 			// Only retry on network errors, not on client errors
@@ -243,16 +361,16 @@ func (c *Client) Push(model string, printer standalone.StatusPrinter) (string, b
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			err := fmt.Errorf("pushing %s failed with status %s: %s", model, resp.Status, string(body))
-			// Only retry on server errors (5xx), not client errors (4xx)
-			shouldRetry := resp.StatusCode >= 500 && resp.StatusCode < 600
-			return "", false, err, shouldRetry
+			// Only retry on server errors (5xx) and rate limits, not other
+			// client errors (4xx)
+			shouldRetry := resp.StatusCode >= 500 && resp.StatusCode < 600 || resp.StatusCode == http.StatusTooManyRequests
+			return "", false, newRateLimitedError(resp, err), shouldRetry
 		}
 
 		// Use Docker-style progress display
 		message, shown, err := DisplayProgress(resp.Body, printer)
 		if err != nil {
-			// Retry on progress display errors (likely network interruption)
-			shouldRetry := isRetryableError(err)
+			err, shouldRetry := classifyProgressError(err)
 			return "", shown, err, shouldRetry
 		}
 
@@ -303,6 +421,20 @@ func (c *Client) Inspect(model string, remote bool) (dmrm.Model, error) {
 	return modelInspect, nil
 }
 
+// Config returns the full parsed configuration of a local model. Its Config is
+// *types.Config or *modelpack.Model, depending on the model's format.
+func (c *Client) Config(model string) (dmrm.ModelConfigResponse, error) {
+	rawResponse, err := c.listRaw(fmt.Sprintf("%s/%s/config", inference.ModelsPrefix, model), model)
+	if err != nil {
+		return dmrm.ModelConfigResponse{}, err
+	}
+	var cfg dmrm.ModelConfigResponse
+	if err := json.Unmarshal(rawResponse, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return cfg, nil
+}
+
 func (c *Client) InspectOpenAI(model string) (dmrm.OpenAIModel, error) {
 	modelsRoute := c.modelRunner.OpenAIPathPrefix() + "/models"
 	rawResponse, err := c.listRaw(fmt.Sprintf("%s/%s", modelsRoute, model), model)
@@ -389,6 +521,127 @@ func (c *Client) Preload(ctx context.Context, model string) error {
 	return nil
 }
 
+// Tokenize tokenizes text with the given model, loading it if necessary, and
+// returns the number of tokens.
+func (c *Client) Tokenize(model, text string) (int, error) {
+	jsonData, err := json.Marshal(TokenizeRequest{Model: model, Content: text})
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	tokenizePath := c.modelRunner.OpenAIPathPrefix() + "/tokenize"
+	resp, err := c.doRequest(http.MethodPost, tokenizePath, bytes.NewReader(jsonData))
+	if err != nil {
+		return 0, c.handleQueryError(err, tokenizePath)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("tokenize failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var tokenizeResp TokenizeResponse
+	if err := json.Unmarshal(body, &tokenizeResp); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return len(tokenizeResp.Tokens), nil
+}
+
+// Warmup status values reported in WarmupResult.
+const (
+	WarmupLoaded  = scheduling.WarmupLoaded
+	WarmupSkipped = scheduling.WarmupSkipped
+	WarmupFailed  = scheduling.WarmupFailed
+)
+
+// WarmupResult reports the outcome of preloading a single model.
+type WarmupResult struct {
+	Model  string
+	Status string
+	Err    error
+}
+
+// Warmup asks the model runner to preload each of models in turn. The runner
+// reports each model as loaded, skipped (it couldn't be loaded without
+// waiting, for example because all runner slots are in use) or failed, and
+// warmup continues with the next model either way.
+func (c *Client) Warmup(ctx context.Context, models []string) ([]WarmupResult, error) {
+	warmupPath := inference.InferencePrefix + "/warmup"
+	jsonData, err := json.Marshal(scheduling.WarmupRequest{Models: models})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	resp, err := c.doRequestWithAuthContext(ctx, http.MethodPost, warmupPath, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, c.handleQueryError(err, warmupPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("warmup failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var warmupResp scheduling.WarmupResponse
+	if err := json.NewDecoder(resp.Body).Decode(&warmupResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+
+	results := make([]WarmupResult, 0, len(warmupResp.Results))
+	for _, r := range warmupResp.Results {
+		result := WarmupResult{Model: r.Model, Status: r.Status}
+		switch r.Status {
+		case WarmupLoaded:
+		case WarmupSkipped, WarmupFailed:
+			result.Err = errors.New(r.Error)
+		default:
+			result.Status = WarmupFailed
+			result.Err = fmt.Errorf("unknown warmup status %q: %s", r.Status, r.Error)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ChatStats holds the timing and token usage of a chat turn.
+type ChatStats struct {
+	// LoadTime is how long the model took to load, if the turn waited for a
+	// cold start.
+	LoadTime time.Duration
+	// TimeToFirstToken is the time from sending the request to receiving the
+	// first generated token, including any load time.
+	TimeToFirstToken time.Duration
+	// GenerationTime is the time from the first generated token to the end of
+	// the response. It is zero for responses that weren't streamed.
+	GenerationTime time.Duration
+	// PromptTokens and CompletionTokens are the token counts the server
+	// reported, if any.
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// TokensPerSecond returns the generation speed, or zero if it is unknown.
+func (s ChatStats) TokensPerSecond() float64 {
+	if s.GenerationTime <= 0 || s.CompletionTokens == 0 {
+		return 0
+	}
+	return float64(s.CompletionTokens) / s.GenerationTime.Seconds()
+}
+
+// chatStatsKey is the context key holding a *ChatStats.
+type chatStatsKey struct{}
+
+// WithChatStats returns a context that makes ChatWithMessagesContext record
+// the statistics of its turn in stats.
+func WithChatStats(ctx context.Context, stats *ChatStats) context.Context {
+	return context.WithValue(ctx, chatStatsKey{}, stats)
+}
+
 // ChatWithMessagesContext performs a chat request with conversation history and returns the assistant's response.
 // This allows maintaining conversation context across multiple exchanges.
 func (c *Client) ChatWithMessagesContext(ctx context.Context, model string, conversationHistory []OpenAIChatMessage, prompt string, imageURLs []string, outputFunc func(string), shouldUseMarkdown bool) (string, error) {
@@ -443,6 +696,9 @@ func (c *Client) ChatWithMessagesContext(ctx context.Context, model string, conv
 
 	completionsPath := c.modelRunner.OpenAIPathPrefix() + "/chat/completions"
 
+	stats, _ := ctx.Value(chatStatsKey{}).(*ChatStats)
+	sentAt := time.Now()
+	var firstTokenAt time.Time
 	resp, err := c.doRequestWithAuthContext(
 		ctx,
 		http.MethodPost,
@@ -458,6 +714,9 @@ func (c *Client) ChatWithMessagesContext(ctx context.Context, model string, conv
 		body, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("error response: status=%d body=%s", resp.StatusCode, body)
 	}
+	if stats != nil {
+		stats.LoadTime, _ = time.ParseDuration(resp.Header.Get(inference.ModelLoadTimeHeader))
+	}
 
 	type chatPrinterState int
 	const (
@@ -541,6 +800,9 @@ func (c *Client) ChatWithMessagesContext(ctx context.Context, model string, conv
 			}
 
 			if len(streamResp.Choices) > 0 {
+				if firstTokenAt.IsZero() && (streamResp.Choices[0].Delta.ReasoningContent != "" || streamResp.Choices[0].Delta.Content != "") {
+					firstTokenAt = time.Now()
+				}
 				if streamResp.Choices[0].Delta.ReasoningContent != "" {
 					chunk := streamResp.Choices[0].Delta.ReasoningContent
 					if printerState == chatPrinterContent {
@@ -578,6 +840,18 @@ func (c *Client) ChatWithMessagesContext(ctx context.Context, model string, conv
 		}
 	}
 
+	if stats != nil {
+		if firstTokenAt.IsZero() {
+			stats.TimeToFirstToken = time.Since(sentAt)
+		} else {
+			stats.TimeToFirstToken = firstTokenAt.Sub(sentAt)
+			stats.GenerationTime = time.Since(firstTokenAt)
+		}
+		if finalUsage != nil {
+			stats.PromptTokens, stats.CompletionTokens = finalUsage.PromptTokens, finalUsage.CompletionTokens
+		}
+	}
+
 	if finalUsage != nil {
 		usageInfo := fmt.Sprintf("\n\nToken usage: %d prompt + %d completion = %d total",
 			finalUsage.PromptTokens,
@@ -594,6 +868,55 @@ func (c *Client) ChatWithMessagesContext(ctx context.Context, model string, conv
 	return assistantResponse.String(), nil
 }
 
+// ChatStructured sends prompt to model, constraining the response to JSON that
+// conforms to schema, and returns the response. It returns an error wrapping
+// ErrStructuredOutputUnsupported if the model's backend can't constrain the
+// response, and one wrapping ErrInvalidStructuredOutput if the response isn't
+// JSON.
+func (c *Client) ChatStructured(ctx context.Context, model, prompt string, schema json.RawMessage) (string, error) {
+	reqBody := OpenAIChatRequest{
+		Model:    model,
+		Messages: []OpenAIChatMessage{{Role: "user", Content: prompt}},
+		ResponseFormat: &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchemaFormat{Name: "response", Schema: schema, Strict: true},
+		},
+	}
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	completionsPath := c.modelRunner.OpenAIPathPrefix() + "/chat/completions"
+	resp, err := c.doRequestWithAuthContext(ctx, http.MethodPost, completionsPath, bytes.NewReader(jsonData))
+	if err != nil {
+		return "", c.handleQueryError(err, completionsPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotImplemented {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("%w: %s", ErrStructuredOutputUnsupported, strings.TrimSpace(string(body)))
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("structured output request failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var chatResp OpenAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("structured output request returned no choices")
+	}
+	content := strings.TrimSpace(chatResp.Choices[0].Message.Content)
+	if !json.Valid([]byte(content)) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidStructuredOutput, content)
+	}
+	return content, nil
+}
+
 // ChatWithContext performs a chat request with context support for cancellation and streams the response content with selective markdown rendering.
 func (c *Client) ChatWithContext(ctx context.Context, model, prompt string, imageURLs []string, outputFunc func(string), shouldUseMarkdown bool) error {
 	_, err := c.ChatWithMessagesContext(ctx, model, nil, prompt, imageURLs, outputFunc, shouldUseMarkdown)
@@ -710,11 +1033,39 @@ func (c *Client) DF() (DiskUsage, error) {
 	return df, nil
 }
 
+// Usage returns the token usage recorded by the model runner since the given
+// time, which is an RFC 3339 time or a duration before now, or all retained
+// usage if since is empty.
+func (c *Client) Usage(since string) (scheduling.UsageReport, error) {
+	usagePath := inference.InferencePrefix + "/usage"
+	if since != "" {
+		usagePath += "?since=" + url.QueryEscape(since)
+	}
+	resp, err := c.doRequest(http.MethodGet, usagePath, nil)
+	if err != nil {
+		return scheduling.UsageReport{}, c.handleQueryError(err, usagePath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return scheduling.UsageReport{}, fmt.Errorf("failed to get usage: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var report scheduling.UsageReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return scheduling.UsageReport{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return report, nil
+}
+
 // UnloadRequest to be imported from docker/model-runner when https://github.com/docker/model-runner/pull/46 is merged.
 type UnloadRequest struct {
-	All     bool     `json:"all"`
-	Backend string   `json:"backend"`
-	Models  []string `json:"models"`
+	All         bool     `json:"all"`
+	Backend     string   `json:"backend"`
+	Models      []string `json:"models"`
+	Wait        bool     `json:"wait,omitempty"`
+	WaitTimeout string   `json:"wait-timeout,omitempty"`
 }
 
 // UnloadResponse to be imported from docker/model-runner when https://github.com/docker/model-runner/pull/46 is merged.
@@ -753,6 +1104,199 @@ func (c *Client) Unload(req UnloadRequest) (UnloadResponse, error) {
 	return unloadResp, nil
 }
 
+// SetAlias maps alias to the target model reference.
+func (c *Client) SetAlias(alias, target string) error {
+	aliasPath := inference.ModelsPrefix + "/_alias"
+	jsonData, err := json.Marshal(dmrm.ModelAliasRequest{Alias: alias, Target: target})
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	resp, err := c.doRequest(http.MethodPost, aliasPath, bytes.NewReader(jsonData))
+	if err != nil {
+		return c.handleQueryError(err, aliasPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("no such model: %s", target)
+		}
+		return fmt.Errorf("setting alias failed with status %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// ListAliases returns the alias table, mapping alias names to model references.
+func (c *Client) ListAliases() (map[string]string, error) {
+	aliasPath := inference.ModelsPrefix + "/_alias"
+	resp, err := c.doRequest(http.MethodGet, aliasPath, nil)
+	if err != nil {
+		return nil, c.handleQueryError(err, aliasPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("listing aliases failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var aliases map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&aliases); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return aliases, nil
+}
+
+// RemoveAlias removes the alias with the given name.
+func (c *Client) RemoveAlias(alias string) error {
+	aliasPath := inference.ModelsPrefix + "/_alias/" + url.PathEscape(alias)
+	resp, err := c.doRequest(http.MethodDelete, aliasPath, nil)
+	if err != nil {
+		return c.handleQueryError(err, aliasPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("no such alias: %s", alias)
+		}
+		return fmt.Errorf("removing alias failed with status %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// Diff compares two local models, reporting the layers and config fields
+// that differ between them.
+func (c *Client) Diff(a, b string) (dmrm.ModelDiff, error) {
+	diffPath := inference.ModelsPrefix + "/_diff"
+	jsonData, err := json.Marshal(dmrm.ModelDiffRequest{A: a, B: b})
+	if err != nil {
+		return dmrm.ModelDiff{}, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	resp, err := c.doRequest(http.MethodPost, diffPath, bytes.NewReader(jsonData))
+	if err != nil {
+		return dmrm.ModelDiff{}, c.handleQueryError(err, diffPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return dmrm.ModelDiff{}, fmt.Errorf("comparing models failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var diff dmrm.ModelDiff
+	if err := json.NewDecoder(resp.Body).Decode(&diff); err != nil {
+		return dmrm.ModelDiff{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return diff, nil
+}
+
+// Detect asks the model runner to detect the format of a model file from its
+// name and leading bytes, read from header.
+func (c *Client) Detect(filename string, header io.Reader) (dmrm.FormatDetection, error) {
+	detectPath := inference.ModelsPrefix + "/_detect"
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		return dmrm.FormatDetection{}, fmt.Errorf("error creating request: %w", err)
+	}
+	if _, err := io.Copy(fw, header); err != nil {
+		return dmrm.FormatDetection{}, fmt.Errorf("error reading file: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return dmrm.FormatDetection{}, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.modelRunner.URL(detectPath), &body)
+	if err != nil {
+		return dmrm.FormatDetection{}, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("User-Agent", "docker-model-cli/"+Version)
+
+	resp, err := c.modelRunner.Client().Do(req)
+	if err != nil {
+		return dmrm.FormatDetection{}, c.handleQueryError(err, detectPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return dmrm.FormatDetection{}, fmt.Errorf("detecting format failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var detection dmrm.FormatDetection
+	if err := json.NewDecoder(resp.Body).Decode(&detection); err != nil {
+		return dmrm.FormatDetection{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return detection, nil
+}
+
+// Catalog lists the models available to pull from the model runner's
+// configured catalog whose name or description contains query.
+func (c *Client) Catalog(query string) ([]dmrm.CatalogEntry, error) {
+	catalogPath := inference.ModelsPrefix + "/catalog"
+	if query != "" {
+		catalogPath += "?q=" + url.QueryEscape(query)
+	}
+
+	resp, err := c.doRequest(http.MethodGet, catalogPath, nil)
+	if err != nil {
+		return nil, c.handleQueryError(err, catalogPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrCatalogNotConfigured
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("listing catalog failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var entries []dmrm.CatalogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return entries, nil
+}
+
+// IncompleteDownloads lists the partially downloaded blobs in the model store.
+func (c *Client) IncompleteDownloads() ([]distribution.IncompleteDownload, error) {
+	return c.incompleteDownloads(http.MethodGet, "listing incomplete downloads")
+}
+
+// PurgeIncompleteDownloads removes the partially downloaded blobs that aren't
+// being downloaded, returning those removed.
+func (c *Client) PurgeIncompleteDownloads() ([]distribution.IncompleteDownload, error) {
+	return c.incompleteDownloads(http.MethodDelete, "purging incomplete downloads")
+}
+
+func (c *Client) incompleteDownloads(method, action string) ([]distribution.IncompleteDownload, error) {
+	incompletePath := inference.ModelsPrefix + "/incomplete"
+	resp, err := c.doRequest(method, incompletePath, nil)
+	if err != nil {
+		return nil, c.handleQueryError(err, incompletePath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s failed with status %s: %s", action, resp.Status, string(body))
+	}
+
+	var downloads []distribution.IncompleteDownload
+	if err := json.NewDecoder(resp.Body).Decode(&downloads); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return downloads, nil
+}
+
 func (c *Client) ShowConfigs(modelFilter string) ([]scheduling.ModelConfigEntry, error) {
 	configureBackendPath := inference.InferencePrefix + "/_configure"
 	if modelFilter != "" {
@@ -782,6 +1326,86 @@ func (c *Client) ShowConfigs(modelFilter string) ([]scheduling.ModelConfigEntry,
 	return configs, nil
 }
 
+// RenderTemplate returns the prompt that model's chat template renders for
+// messages, without generating a response.
+func (c *Client) RenderTemplate(model string, messages []OpenAIChatMessage) (string, error) {
+	renderPath := inference.InferencePrefix + "/_render-template"
+	rawMessages := make([]json.RawMessage, 0, len(messages))
+	for _, message := range messages {
+		raw, err := json.Marshal(message)
+		if err != nil {
+			return "", fmt.Errorf("error marshaling message: %w", err)
+		}
+		rawMessages = append(rawMessages, raw)
+	}
+	jsonData, err := json.Marshal(scheduling.RenderTemplateRequest{Model: model, Messages: rawMessages})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	resp, err := c.doRequest(http.MethodPost, renderPath, bytes.NewReader(jsonData))
+	if err != nil {
+		return "", c.handleQueryError(err, renderPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("rendering template failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var rendered scheduling.RenderTemplateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rendered); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return rendered.Prompt, nil
+}
+
+// Info returns the effective configuration of the model runner.
+func (c *Client) Info() (inference.RunnerInfo, error) {
+	resp, err := c.doRequest(http.MethodGet, inference.InfoPath, nil)
+	if err != nil {
+		return inference.RunnerInfo{}, c.handleQueryError(err, inference.InfoPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return inference.RunnerInfo{}, fmt.Errorf("getting runner info failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var info inference.RunnerInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return inference.RunnerInfo{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return info, nil
+}
+
+// ResetConfig restores the default runner configuration of model, or of every
+// model if model is empty, returning the number of configurations reset.
+func (c *Client) ResetConfig(model string) (int, error) {
+	configureBackendPath := inference.InferencePrefix + "/_configure?all=true"
+	if model != "" {
+		configureBackendPath = inference.InferencePrefix + "/_configure?model=" + url.QueryEscape(model)
+	}
+	resp, err := c.doRequest(http.MethodDelete, configureBackendPath, nil)
+	if err != nil {
+		return 0, c.handleQueryError(err, configureBackendPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("resetting config failed with status %s: %s", resp.Status, string(body))
+	}
+
+	var resetResp scheduling.ResetConfigResponse
+	if err := json.NewDecoder(resp.Body).Decode(&resetResp); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return resetResp.ResetConfigs, nil
+}
+
 func (c *Client) ConfigureBackend(request scheduling.ConfigureRequest) error {
 	configureBackendPath := inference.InferencePrefix + "/_configure"
 	jsonData, err := json.Marshal(request)
@@ -863,6 +1487,131 @@ func (c *Client) Requests(modelFilter string, streaming bool, includeExisting bo
 	return resp.Body, cancel, nil
 }
 
+// Events streams runner lifecycle events (loads, unloads, evictions, and load
+// failures), calling handle for each until ctx is cancelled, the stream ends,
+// or handle returns an error.
+func (c *Client) Events(ctx context.Context, handle func(scheduling.LifecycleEvent) error) error {
+	eventsPath := inference.InferencePrefix + "/events"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.modelRunner.URL(eventsPath), http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("User-Agent", "docker-model-cli/"+Version)
+
+	resp, err := c.modelRunner.Client().Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return c.handleQueryError(fmt.Errorf("failed to connect to stream: %w", err), eventsPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("event stream failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var eventName string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			eventName = name
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || eventName == "connected" {
+			continue
+		}
+		var event scheduling.LifecycleEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", eventName, err)
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("reading event stream: %w", err)
+	}
+	return nil
+}
+
+// Batch runs requests against model as a single batch, calling onItem (if
+// non-nil) as each request completes, and returns the results in request
+// order. The runner executes the requests concurrently, up to its slot count.
+func (c *Client) Batch(ctx context.Context, model string, requests []OpenAIChatRequest, onItem func(scheduling.BatchItemResult)) ([]scheduling.BatchItemResult, error) {
+	batch := scheduling.BatchRequest{Model: model, Stream: true}
+	for _, request := range requests {
+		raw, err := json.Marshal(request)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling request: %w", err)
+		}
+		batch.Requests = append(batch.Requests, raw)
+	}
+	jsonData, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	batchPath := c.modelRunner.OpenAIPathPrefix() + "/batch"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.modelRunner.URL(batchPath), bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("User-Agent", "docker-model-cli/"+Version)
+
+	resp, err := c.modelRunner.Client().Do(req)
+	if err != nil {
+		return nil, c.handleQueryError(err, batchPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("batch failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// Read line by line rather than with a bufio.Scanner, since the final
+	// event holds every response and can exceed any fixed token size.
+	results := make([]scheduling.BatchItemResult, len(requests))
+	var eventName string
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			eventName = name
+		} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+			switch eventName {
+			case "item":
+				var result scheduling.BatchItemResult
+				if err := json.Unmarshal([]byte(data), &result); err != nil {
+					return nil, fmt.Errorf("failed to decode batch item: %w", err)
+				}
+				if result.Index >= 0 && result.Index < len(results) {
+					results[result.Index] = result
+				}
+				if onItem != nil {
+					onItem(result)
+				}
+			case "done":
+				return results, nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("batch stream ended before completion")
+		} else if err != nil {
+			return nil, fmt.Errorf("reading batch stream: %w", err)
+		}
+	}
+}
+
 func (c *Client) Purge() error {
 	purgePath := inference.ModelsPrefix + "/purge"
 	resp, err := c.doRequest(http.MethodDelete, purgePath, nil)
@@ -879,6 +1628,74 @@ func (c *Client) Purge() error {
 	return nil
 }
 
+// Prune removes the local models matching the request's filters, or lists
+// them if the request is a dry run.
+func (c *Client) Prune(request dmrm.ModelPruneRequest) (distribution.PruneResponse, error) {
+	prunePath := inference.ModelsPrefix + "/prune"
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return distribution.PruneResponse{}, fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	resp, err := c.doRequest(http.MethodPost, prunePath, bytes.NewReader(jsonData))
+	if err != nil {
+		return distribution.PruneResponse{}, c.handleQueryError(err, prunePath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return distribution.PruneResponse{}, fmt.Errorf("pruning failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var response distribution.PruneResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return distribution.PruneResponse{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return response, nil
+}
+
+// Compact reclaims the space taken by the model store's empty directories and
+// bloated index files.
+func (c *Client) Compact() (distribution.CompactResult, error) {
+	compactPath := inference.ModelsPrefix + "/compact"
+	resp, err := c.doRequest(http.MethodPost, compactPath, nil)
+	if err != nil {
+		return distribution.CompactResult{}, c.handleQueryError(err, compactPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return distribution.CompactResult{}, fmt.Errorf("compacting failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var result distribution.CompactResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return distribution.CompactResult{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return result, nil
+}
+
+// CancelRequest cancels the in-flight inference request with the given ID.
+func (c *Client) CancelRequest(id string) error {
+	cancelPath := inference.InferencePrefix + "/requests/" + url.PathEscape(id)
+	resp, err := c.doRequest(http.MethodDelete, cancelPath, nil)
+	if err != nil {
+		return c.handleQueryError(err, cancelPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("no in-flight request with ID %s", id)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cancelling request failed with status %s: %s", resp.Status, string(body))
+	}
+	return nil
+}
+
 // doRequest is a helper function that performs HTTP requests and handles 503 responses
 func (c *Client) doRequest(method, path string, body io.Reader) (*http.Response, error) {
 	return c.doRequestWithAuth(method, path, body)
@@ -920,13 +1737,17 @@ func (c *Client) handleQueryError(err error, path string) error {
 	return fmt.Errorf("error querying %s: %w", path, err)
 }
 
-func (c *Client) Tag(source, targetRepo, targetTag string) error {
+// Tag tags the source model as targetRepo:targetTag. Re-applying a tag the
+// model already has succeeds; a tag that refers to a different model is only
+// moved if force is set.
+func (c *Client) Tag(source, targetRepo, targetTag string, force bool) error {
 	// Construct the URL with query parameters using the normalized source
-	tagPath := fmt.Sprintf("%s/%s/tag?repo=%s&tag=%s",
+	tagPath := fmt.Sprintf("%s/%s/tag?repo=%s&tag=%s&force=%s",
 		inference.ModelsPrefix,
 		source,
 		targetRepo,
 		targetTag,
+		strconv.FormatBool(force),
 	)
 
 	resp, err := c.doRequest(http.MethodPost, tagPath, nil)
@@ -939,13 +1760,60 @@ func (c *Client) Tag(source, targetRepo, targetTag string) error {
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("tagging failed with status %s: %s", resp.Status, string(body))
 	}
 
 	return nil
 }
 
+// Archive removes the weights of a local model while keeping it listed.
+func (c *Client) Archive(model string) (distribution.ArchiveResponse, error) {
+	archivePath := fmt.Sprintf("%s/%s/archive", inference.ModelsPrefix, model)
+	resp, err := c.doRequest(http.MethodPost, archivePath, nil)
+	if err != nil {
+		return distribution.ArchiveResponse{}, c.handleQueryError(err, archivePath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return distribution.ArchiveResponse{}, errors.Wrap(ErrNotFound, model)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return distribution.ArchiveResponse{}, fmt.Errorf("archiving failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var response distribution.ArchiveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return distribution.ArchiveResponse{}, fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return response, nil
+}
+
+// Unarchive marks an archived model as usable again. It returns
+// ErrModelArchived if the model's weights are missing and it must be pulled.
+func (c *Client) Unarchive(model string) error {
+	unarchivePath := fmt.Sprintf("%s/%s/unarchive", inference.ModelsPrefix, model)
+	resp, err := c.doRequest(http.MethodPost, unarchivePath, nil)
+	if err != nil {
+		return c.handleQueryError(err, unarchivePath)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errors.Wrap(ErrNotFound, model)
+	case http.StatusConflict:
+		return errors.Wrap(ErrModelArchived, model)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unarchiving failed with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
 func (c *Client) LoadModel(ctx context.Context, r io.Reader) error {
 	loadPath := fmt.Sprintf("%s/load", inference.ModelsPrefix)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.modelRunner.URL(loadPath), r)
@@ -994,19 +1862,51 @@ func (c *Client) ExportModel(ctx context.Context, model string) (io.ReadCloser,
 	return resp.Body, nil
 }
 
+// ModelGGUF returns a tar stream of the model's GGUF weight file(s).
+func (c *Client) ModelGGUF(ctx context.Context, model string) (io.ReadCloser, error) {
+	ggufPath := fmt.Sprintf("%s/%s/gguf", inference.ModelsPrefix, model)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.modelRunner.URL(ggufPath), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "docker-model-cli/"+Version)
+
+	resp, err := c.modelRunner.Client().Do(req)
+	if err != nil {
+		return nil, c.handleQueryError(err, ggufPath)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errors.Wrap(ErrNotFound, model)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+
+	return resp.Body, nil
+}
+
 type RepackageOptions struct {
-	ContextSize *uint64 `json:"context_size,omitempty"`
+	ContextSize  *uint64 `json:"context_size,omitempty"`
+	Quantization *string `json:"quantization,omitempty"`
 }
 
-func (c *Client) RepackageModel(ctx context.Context, source, target string, opts RepackageOptions) error {
+// RepackageModel creates target as a variant of source. Requantization
+// progress, if requested, is displayed with printer.
+func (c *Client) RepackageModel(ctx context.Context, source, target string, opts RepackageOptions, printer standalone.StatusPrinter) error {
 	repackagePath := fmt.Sprintf("%s/%s/repackage", inference.ModelsPrefix, source)
 
 	reqBody := struct {
-		Target      string  `json:"target"`
-		ContextSize *uint64 `json:"context_size,omitempty"`
+		Target       string  `json:"target"`
+		ContextSize  *uint64 `json:"context_size,omitempty"`
+		Quantization *string `json:"quantization,omitempty"`
 	}{
-		Target:      target,
-		ContextSize: opts.ContextSize,
+		Target:       target,
+		ContextSize:  opts.ContextSize,
+		Quantization: opts.Quantization,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -1028,5 +1928,10 @@ func (c *Client) RepackageModel(ctx context.Context, source, target string, opts
 		return fmt.Errorf("repackage failed with status %s: %s", resp.Status, string(body))
 	}
 
+	if opts.Quantization != nil {
+		if _, _, err := DisplayProgress(resp.Body, printer); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -3,12 +3,18 @@ package desktop
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	mockdesktop "github.com/docker/model-runner/cmd/cli/mocks"
+	"github.com/docker/model-runner/cmd/cli/pkg/standalone"
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/scheduling"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
@@ -111,6 +117,87 @@ func TestPullRetryOnServiceUnavailable(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPullRetryOnRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	modelName := "test-model"
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	// First attempt is rate limited, second succeeds after the requested wait
+	gomock.InOrder(
+		mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Body:       io.NopCloser(bytes.NewBufferString("rate limited by registry")),
+		}, nil),
+		mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"type":"success","message":"Model pulled successfully"}`)),
+		}, nil),
+	)
+
+	var output strings.Builder
+	printer := NewSimplePrinter(func(s string) { output.WriteString(s) })
+	_, _, err := client.Pull(modelName, printer)
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), "Rate limited, retrying download (attempt 1/3) in 0s")
+}
+
+func TestPullRetryOnRateLimitedProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	modelName := "test-model"
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	// The first attempt reports the rate limit in its progress stream, as its
+	// status was already sent, and the second succeeds after the wait
+	gomock.InOrder(
+		mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body: io.NopCloser(bytes.NewBufferString(
+				`{"type":"warning","message":"Rate limited by the registry, retrying in 1s"}` + "\n" +
+					`{"type":"error","message":"rate limited by registry","status":429,"retry_after":1}` + "\n")),
+		}, nil),
+		mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"type":"success","message":"Model pulled successfully"}`)),
+		}, nil),
+	)
+
+	var output strings.Builder
+	printer := NewSimplePrinter(func(s string) { output.WriteString(s) })
+	_, _, err := client.Pull(modelName, printer)
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), "Rate limited, retrying download (attempt 1/3) in 1s")
+}
+
+func TestPullNoRetryOnProgressError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	modelName := "test-model"
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	// Should not retry a client error reported after the status was sent
+	mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"type":"error","message":"Model not found","status":404}`)),
+	}, nil).Times(1)
+
+	printer := NewSimplePrinter(func(s string) {})
+	_, _, err := client.Pull(modelName, printer)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Model not found")
+}
+
 func TestPullMaxRetriesExhausted(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -152,6 +239,262 @@ func TestPushRetryOnNetworkError(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestWarmupReportsEachModel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.True(t, strings.HasSuffix(req.URL.Path, inference.InferencePrefix+"/warmup"))
+		var warmupReq scheduling.WarmupRequest
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&warmupReq))
+		assert.Equal(t, []string{"m1", "m2", "m3", "m4"}, warmupReq.Models)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: io.NopCloser(bytes.NewBufferString(`{"results":[` +
+				`{"model":"m1","status":"loaded"},` +
+				`{"model":"m2","status":"skipped","error":"no runner slot available"},` +
+				`{"model":"m3","status":"failed","error":"out of memory"},` +
+				`{"model":"m4","status":"evicted"}]}`)),
+		}, nil
+	})
+
+	results, err := client.Warmup(t.Context(), []string{"m1", "m2", "m3", "m4"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 4)
+	assert.Equal(t, WarmupLoaded, results[0].Status)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, WarmupSkipped, results[1].Status)
+	assert.EqualError(t, results[1].Err, "no runner slot available")
+	// The status decides the outcome, not the error message.
+	assert.Equal(t, WarmupFailed, results[2].Status)
+	assert.EqualError(t, results[2].Err, "out of memory")
+	assert.Equal(t, WarmupFailed, results[3].Status)
+	assert.Error(t, results[3].Err)
+}
+
+func TestTokenize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.True(t, strings.HasSuffix(req.URL.Path, inference.InferencePrefix+"/v1/tokenize"))
+		var body TokenizeRequest
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "ai/smollm2", body.Model)
+		assert.Equal(t, "hello world", body.Content)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"tokens":[15339,1917]}`)),
+		}, nil
+	})
+
+	count, err := client.Tokenize("ai/smollm2", "hello world")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.True(t, strings.HasSuffix(req.URL.Path, inference.InferencePrefix+"/_render-template"))
+		var body scheduling.RenderTemplateRequest
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "ai/smollm2", body.Model)
+		assert.Len(t, body.Messages, 1)
+		assert.JSONEq(t, `{"role":"user","content":"hi"}`, string(body.Messages[0]))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"prompt":"<|im_start|>user\nhi<|im_end|>\n"}`)),
+		}, nil
+	})
+
+	prompt, err := client.RenderTemplate("ai/smollm2", []OpenAIChatMessage{{Role: "user", Content: "hi"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "<|im_start|>user\nhi<|im_end|>\n", prompt)
+}
+
+func TestChatStructured(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	schema := json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}}}`)
+	for _, content := range []string{`{\"name\":\"Ada\"}`, "Sure! Her name is Ada."} {
+		mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+			var body OpenAIChatRequest
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+			assert.False(t, body.Stream)
+			assert.Equal(t, "json_schema", body.ResponseFormat.Type)
+			assert.JSONEq(t, string(schema), string(body.ResponseFormat.JSONSchema.Schema))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"choices":[{"message":{"role":"assistant","content":"` + content + `"}}]}`)),
+			}, nil
+		})
+	}
+
+	response, err := client.ChatStructured(t.Context(), "ai/smollm2", "Who wrote the first program?", schema)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"Ada"}`, response)
+
+	_, err = client.ChatStructured(t.Context(), "ai/smollm2", "Who wrote the first program?", schema)
+	assert.ErrorIs(t, err, ErrInvalidStructuredOutput)
+	assert.NotErrorIs(t, err, ErrStructuredOutputUnsupported)
+
+	mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: http.StatusNotImplemented,
+		Body:       io.NopCloser(bytes.NewBufferString("response_format is not supported by the mlx backend\n")),
+	}, nil)
+	_, err = client.ChatStructured(t.Context(), "ai/smollm2", "Who wrote the first program?", schema)
+	assert.ErrorIs(t, err, ErrStructuredOutputUnsupported)
+	assert.ErrorContains(t, err, "mlx backend")
+}
+
+func TestChatStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	stream := "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\n" +
+		"data: [DONE]\n\n"
+	mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type":                []string{"text/event-stream"},
+			inference.ModelLoadTimeHeader: []string{"1.5s"},
+		},
+		Body: io.NopCloser(bytes.NewBufferString(stream)),
+	}, nil)
+
+	var stats ChatStats
+	response, err := client.ChatWithMessagesContext(WithChatStats(t.Context(), &stats), "ai/smollm2", nil, "Hello", nil, func(string) {}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi", response)
+	assert.Equal(t, 1500*time.Millisecond, stats.LoadTime)
+	assert.Equal(t, 5, stats.PromptTokens)
+	assert.Equal(t, 1, stats.CompletionTokens)
+	assert.Positive(t, stats.TimeToFirstToken)
+}
+
+func TestUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.True(t, strings.HasSuffix(req.URL.Path, inference.InferencePrefix+"/usage"))
+		assert.Equal(t, "24h", req.URL.Query().Get("since"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body: io.NopCloser(bytes.NewBufferString(`{"since":"2025-06-01T00:00:00Z","usage":[` +
+				`{"model":"ai/smollm2","tenant":"a","requests":2,"prompt_tokens":8,"completion_tokens":5,"total_tokens":13}]}`)),
+		}, nil
+	})
+
+	report, err := client.Usage("24h")
+	assert.NoError(t, err)
+	assert.Equal(t, []scheduling.TokenUsage{{
+		Model: "ai/smollm2", Tenant: "a", Requests: 2, PromptTokens: 8, CompletionTokens: 5, TotalTokens: 13,
+	}}, report.Usage)
+}
+
+func TestEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	stream := "event: connected\ndata: {\"status\": \"connected\"}\n\n" +
+		"event: model_loaded\ndata: {\"type\":\"model_loaded\",\"backend\":\"llama.cpp\",\"model\":\"ai/smollm2\",\"memory_bytes\":1024}\n\n" +
+		"event: model_evicted\ndata: {\"type\":\"model_evicted\",\"backend\":\"llama.cpp\",\"model\":\"ai/smollm2\",\"reason\":\"idle\"}\n\n"
+	mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.True(t, strings.HasSuffix(req.URL.Path, inference.InferencePrefix+"/events"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(stream)),
+		}, nil
+	})
+
+	var events []scheduling.LifecycleEvent
+	err := client.Events(t.Context(), func(event scheduling.LifecycleEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, scheduling.EventModelLoaded, events[0].Type)
+	assert.Equal(t, uint64(1024), events[0].MemoryBytes)
+	assert.Equal(t, scheduling.EventModelEvicted, events[1].Type)
+	assert.Equal(t, "idle", events[1].Reason)
+}
+
+func TestBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	mockContext := NewContextForMock(mockClient)
+	client := New(mockContext)
+
+	stream := "event: item\ndata: {\"index\":1,\"status\":500,\"error\":\"boom\"}\n\n" +
+		"event: item\ndata: {\"index\":0,\"status\":200,\"response\":{\"id\":\"a\"}}\n\n" +
+		"event: done\ndata: {\"model\":\"ai/smollm2\",\"results\":[]}\n\n"
+	mockClient.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.True(t, strings.HasSuffix(req.URL.Path, inference.InferencePrefix+"/v1/batch"))
+		var body scheduling.BatchRequest
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "ai/smollm2", body.Model)
+		assert.True(t, body.Stream)
+		assert.Len(t, body.Requests, 2)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(stream)),
+		}, nil
+	})
+
+	requests := []OpenAIChatRequest{
+		{Messages: []OpenAIChatMessage{{Role: "user", Content: "one"}}},
+		{Messages: []OpenAIChatMessage{{Role: "user", Content: "two"}}},
+	}
+	var completed []int
+	results, err := client.Batch(t.Context(), "ai/smollm2", requests, func(result scheduling.BatchItemResult) {
+		completed = append(completed, result.Index)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 0}, completed)
+	assert.Len(t, results, 2)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.JSONEq(t, `{"id":"a"}`, string(results[0].Response))
+	assert.Equal(t, "boom", results[1].Error)
+}
+
 func TestIsRetryableError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -180,3 +523,49 @@ func TestIsRetryableError(t *testing.T) {
 		})
 	}
 }
+
+func TestPullQuietSuppressesProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	client := New(NewContextForMock(mockClient))
+
+	body := `{"type":"progress","message":"Downloaded 1 MB","total":2097152,"layer":{"id":"sha256:abc","size":2097152,"current":1048576}}
+{"type":"warning","message":"low disk space"}
+{"type":"progress","message":"Downloaded 2 MB","total":2097152,"layer":{"id":"sha256:abc","size":2097152,"current":2097152}}
+{"type":"success","message":"Model pulled successfully"}
+`
+	mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil)
+
+	var output strings.Builder
+	printer := standalone.QuietPrinter(NewSimplePrinter(func(s string) { output.WriteString(s) }))
+	message, _, err := client.Pull("test-model", printer)
+	assert.NoError(t, err)
+	assert.Equal(t, "Model pulled successfully", message)
+	assert.Equal(t, "Warning: low disk space\n", output.String())
+}
+
+func TestPullJSONProgressForwardsMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mockdesktop.NewMockDockerHttpClient(ctrl)
+	client := New(NewContextForMock(mockClient))
+
+	progressLine := `{"type":"progress","message":"","total":2048,"layer":{"id":"sha256:abc","size":2048,"current":1024}}`
+	errorLine := `{"type":"error","message":"layer verification failed"}`
+	mockClient.EXPECT().Do(gomock.Any()).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(progressLine + "\n" + errorLine + "\n")),
+	}, nil)
+
+	var output strings.Builder
+	printer := NewJSONProgressPrinter(NewSimplePrinter(func(s string) { output.WriteString(s) }))
+	_, _, err := client.Pull("test-model", printer)
+	assert.EqualError(t, err, "layer verification failed")
+	assert.Equal(t, progressLine+"\n"+errorLine+"\n", output.String())
+}
@@ -8,6 +8,7 @@ import (
 	"html"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/go-units"
@@ -19,6 +20,11 @@ import (
 // using Docker-style multi-line progress bars.
 // Returns the final message, whether progress was actually shown, and any error.
 func DisplayProgress(body io.Reader, printer standalone.StatusPrinter) (string, bool, error) {
+	// Forward the raw progress stream if JSON output was requested.
+	if _, ok := printer.(*jsonProgressPrinter); ok {
+		return displayProgressJSON(body, printer)
+	}
+
 	fd, isTerminal := printer.GetFdInfo()
 
 	// If not a terminal, fall back to simple line-by-line output
@@ -75,7 +81,7 @@ func DisplayProgress(body io.Reader, printer standalone.StatusPrinter) (string,
 
 		case oci.TypeError:
 			pw.Close()
-			return "", false, fmt.Errorf("%s", progressMsg.Message)
+			return "", false, newProgressError(&progressMsg)
 		}
 	}
 
@@ -94,6 +100,78 @@ func DisplayProgress(body io.Reader, printer standalone.StatusPrinter) (string,
 	return finalMessage, progressShown, nil
 }
 
+// progressError is an error reported in a progress stream, after the
+// response status was sent.
+type progressError struct {
+	message string
+	// status is the HTTP status the error stands in for, or 0 if unknown.
+	status int
+	// retryAfter is how long a rate limited operation was asked to wait
+	// before retrying, or zero if it wasn't.
+	retryAfter time.Duration
+}
+
+func newProgressError(msg *oci.ProgressMessage) *progressError {
+	return &progressError{
+		message:    msg.Message,
+		status:     msg.Status,
+		retryAfter: time.Duration(msg.RetryAfter) * time.Second,
+	}
+}
+
+func (e *progressError) Error() string {
+	return e.message
+}
+
+// jsonProgressPrinter marks a printer to which DisplayProgress forwards the
+// progress stream as JSON lines instead of rendering it.
+type jsonProgressPrinter struct {
+	standalone.StatusPrinter
+}
+
+// NewJSONProgressPrinter returns a StatusPrinter that makes DisplayProgress
+// write each oci.ProgressMessage to printer as a line of JSON.
+func NewJSONProgressPrinter(printer standalone.StatusPrinter) standalone.StatusPrinter {
+	return &jsonProgressPrinter{StatusPrinter: printer}
+}
+
+// displayProgressJSON forwards each progress message line to printer as-is,
+// while still tracking the outcome of the operation.
+func displayProgressJSON(body io.Reader, printer standalone.StatusPrinter) (string, bool, error) {
+	scanner := bufio.NewScanner(body)
+	var finalMessage string
+	progressShown := false
+
+	for scanner.Scan() {
+		progressLine := html.UnescapeString(scanner.Text())
+		if progressLine == "" {
+			continue
+		}
+
+		var progressMsg oci.ProgressMessage
+		if err := json.Unmarshal([]byte(progressLine), &progressMsg); err != nil {
+			continue
+		}
+		if _, err := printer.Write([]byte(progressLine + "\n")); err != nil {
+			return "", false, err
+		}
+
+		switch progressMsg.Type {
+		case oci.TypeProgress:
+			progressShown = true
+		case oci.TypeSuccess:
+			finalMessage = progressMsg.Message
+		case oci.TypeError:
+			return "", false, newProgressError(&progressMsg)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+	return finalMessage, progressShown, nil
+}
+
 // displayProgressSimple displays progress messages in simple line-by-line format
 func displayProgressSimple(body io.Reader, printer standalone.StatusPrinter) (string, bool, error) {
 	scanner := bufio.NewScanner(body)
@@ -137,7 +215,7 @@ func displayProgressSimple(body io.Reader, printer standalone.StatusPrinter) (st
 			printer.PrintErrf("Warning: %s\n", progressMsg.Message)
 
 		case oci.TypeError:
-			return "", false, fmt.Errorf("%s", progressMsg.Message)
+			return "", false, newProgressError(&progressMsg)
 		}
 	}
 
@@ -162,7 +240,9 @@ func writeDockerProgress(w io.Writer, msg *oci.ProgressMessage) error {
 	var status string
 	var progressDetail *jsonmessage.JSONProgress
 
-	if msg.Layer.Current == 0 {
+	if isPush && msg.Layer.Size > 0 && msg.Layer.Skipped >= msg.Layer.Size {
+		status = "Layer already exists"
+	} else if msg.Layer.Current == 0 {
 		status = "Waiting"
 	} else if msg.Layer.Current < msg.Layer.Size {
 		if isPush {
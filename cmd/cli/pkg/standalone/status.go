@@ -40,3 +40,38 @@ func (*noopPrinter) GetFdInfo() (fd uintptr, isTerminal bool) {
 func NoopPrinter() StatusPrinter {
 	return &noopPrinter{}
 }
+
+// quietPrinter discards progress output while still reporting errors and
+// warnings to stderr.
+type quietPrinter struct {
+	printer StatusPrinter
+}
+
+// Printf implements StatusPrinter.Printf.
+func (*quietPrinter) Printf(format string, args ...any) {}
+
+// Println implements StatusPrinter.Println.
+func (*quietPrinter) Println(args ...any) {}
+
+// PrintErrf implements StatusPrinter.PrintErrf.
+func (p *quietPrinter) PrintErrf(format string, args ...any) {
+	p.printer.PrintErrf(format, args...)
+}
+
+// Write implements StatusPrinter.Write.
+func (*quietPrinter) Write(p []byte) (n int, err error) {
+	return len(p), nil
+}
+
+// GetFdInfo implements StatusPrinter.GetFdInfo. It never reports a terminal,
+// so that no interactive progress is rendered.
+func (p *quietPrinter) GetFdInfo() (fd uintptr, isTerminal bool) {
+	fd, _ = p.printer.GetFdInfo()
+	return fd, false
+}
+
+// QuietPrinter returns a StatusPrinter that suppresses progress output from
+// printer, forwarding only stderr output such as warnings.
+func QuietPrinter(printer StatusPrinter) StatusPrinter {
+	return &quietPrinter{printer: printer}
+}
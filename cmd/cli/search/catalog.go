@@ -0,0 +1,61 @@
+package search
+
+import (
+	"context"
+	"errors"
+
+	dmrm "github.com/docker/model-runner/pkg/inference/models"
+)
+
+// ErrCatalogUnavailable indicates that the model catalog can't be searched,
+// because the model runner is unreachable or has no catalog configured.
+var ErrCatalogUnavailable = errors.New("model catalog unavailable")
+
+// CatalogLister lists the models in the model runner's configured catalog.
+type CatalogLister interface {
+	Catalog(query string) ([]dmrm.CatalogEntry, error)
+}
+
+// CatalogClient searches the model catalog configured on the model runner
+type CatalogClient struct {
+	lister CatalogLister
+	// optional causes an unavailable catalog to yield no results rather than
+	// an error.
+	optional bool
+}
+
+// NewCatalogClient creates a new catalog search client. If optional is set,
+// an unavailable catalog is skipped silently.
+func NewCatalogClient(lister CatalogLister, optional bool) *CatalogClient {
+	return &CatalogClient{lister: lister, optional: optional}
+}
+
+// Name returns the name of this search source
+func (c *CatalogClient) Name() string {
+	return CatalogSourceName
+}
+
+// Search searches the model catalog for models matching the query
+func (c *CatalogClient) Search(_ context.Context, opts SearchOptions) ([]SearchResult, error) {
+	entries, err := c.lister.Catalog(opts.Query)
+	if err != nil {
+		if c.optional && errors.Is(err, ErrCatalogUnavailable) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, entry := range entries {
+		if opts.Limit > 0 && len(results) >= opts.Limit {
+			break
+		}
+		results = append(results, SearchResult{
+			Name:        entry.Name,
+			Description: truncateString(entry.Description, 50),
+			Size:        entry.Size,
+			Source:      CatalogSourceName,
+		})
+	}
+	return results, nil
+}
@@ -15,16 +15,26 @@ const (
 	SourceAll         SourceType = "all"
 	SourceDockerHub   SourceType = "dockerhub"
 	SourceHuggingFace SourceType = "huggingface"
+	SourceCatalog     SourceType = "catalog"
 )
 
+// sourceRank orders results by source, with the configured catalog first
+var sourceRank = map[string]int{
+	CatalogSourceName:     0,
+	DockerHubSourceName:   1,
+	HuggingFaceSourceName: 2,
+}
+
 // AggregatedClient searches multiple sources and merges results
 type AggregatedClient struct {
 	clients []SearchClient
 	errOut  io.Writer
 }
 
-// NewAggregatedClient creates a client that searches the specified sources
-func NewAggregatedClient(source SourceType, errOut io.Writer) *AggregatedClient {
+// NewAggregatedClient creates a client that searches the specified sources.
+// The model catalog is searched through catalog, if not nil; when searching
+// all sources, it is skipped if unavailable.
+func NewAggregatedClient(source SourceType, errOut io.Writer, catalog CatalogLister) *AggregatedClient {
 	var clients []SearchClient
 
 	switch source {
@@ -32,11 +42,18 @@ func NewAggregatedClient(source SourceType, errOut io.Writer) *AggregatedClient
 		clients = []SearchClient{NewDockerHubClient()}
 	case SourceHuggingFace:
 		clients = []SearchClient{NewHuggingFaceClient()}
+	case SourceCatalog:
+		if catalog != nil {
+			clients = []SearchClient{NewCatalogClient(catalog, false)}
+		}
 	case SourceAll:
 		clients = []SearchClient{
 			NewDockerHubClient(),
 			NewHuggingFaceClient(),
 		}
+		if catalog != nil {
+			clients = append(clients, NewCatalogClient(catalog, true))
+		}
 	default: // This handles any unexpected values
 		clients = []SearchClient{
 			NewDockerHubClient(),
@@ -102,11 +119,11 @@ func (c *AggregatedClient) Search(ctx context.Context, opts SearchOptions) ([]Se
 		return nil, fmt.Errorf("all search sources failed: %v", errors)
 	}
 
-	// Sort by source (Docker Hub first), then by downloads within each source
-	sort.Slice(allResults, func(i, j int) bool {
-		// Docker Hub comes before HuggingFace
+	// Sort by source (catalog, then Docker Hub, then HuggingFace), then by
+	// downloads within each source
+	sort.SliceStable(allResults, func(i, j int) bool {
 		if allResults[i].Source != allResults[j].Source {
-			return allResults[i].Source == DockerHubSourceName
+			return sourceRank[allResults[i].Source] < sourceRank[allResults[j].Source]
 		}
 		// Within same source, sort by downloads (popularity)
 		return allResults[i].Downloads > allResults[j].Downloads
@@ -129,7 +146,9 @@ func ParseSource(s string) (SourceType, error) {
 		return SourceDockerHub, nil
 	case "huggingface", "hf":
 		return SourceHuggingFace, nil
+	case "catalog":
+		return SourceCatalog, nil
 	default:
-		return "", fmt.Errorf("unknown source %q: valid options are 'all', 'dockerhub', 'docker', 'hub', 'huggingface', 'hf'", s)
+		return "", fmt.Errorf("unknown source %q: valid options are 'all', 'catalog', 'dockerhub', 'docker', 'hub', 'huggingface', 'hf'", s)
 	}
 }
@@ -6,6 +6,7 @@ import "context"
 const (
 	DockerHubSourceName   = "Docker Hub"
 	HuggingFaceSourceName = "HuggingFace"
+	CatalogSourceName     = "Catalog"
 )
 
 // SearchResult represents a model found during search
@@ -14,7 +15,8 @@ type SearchResult struct {
 	Description string // Short description
 	Downloads   int64  // Download/pull count
 	Stars       int64  // Star/like count
-	Source      string // "Docker Hub" or "HuggingFace"
+	Size        int64  // Model size in bytes, if known
+	Source      string // "Catalog", "Docker Hub" or "HuggingFace"
 	Official    bool   // Whether this is an official model
 	UpdatedAt   string // Last update timestamp
 	Backend     string // Backend type: "llama.cpp", "vllm", or "llama.cpp, vllm" if both
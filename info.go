@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/backends/llamacpp"
+	"github.com/docker/model-runner/pkg/inference/scheduling"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+// configEnvPrefixes are the prefixes of the environment variables reported in
+// the runner info.
+var configEnvPrefixes = []string{"MODEL_RUNNER_", "DMR_", "LLAMA_", "MODELS_PATH", "DISABLE_", "HF_", "OTEL_"}
+
+// configEnvVars are other environment variables reported in the runner info.
+var configEnvVars = []string{
+	"VLLM_SERVER_PATH", "SGLANG_SERVER_PATH", "MLX_SERVER_PATH", "DIFFUSERS_SERVER_PATH", "VLLM_METAL_SERVER_PATH",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy", "NVIDIA_VISIBLE_DEVICES",
+}
+
+// runnerInfo gathers the parts of the runner info that main resolves from the
+// environment.
+type runnerInfo struct {
+	modelsPath   string
+	listen       string
+	tlsListen    string
+	backendPaths map[string]string
+	tracing      bool
+}
+
+// info returns the runner's effective configuration, with secrets redacted.
+func (i runnerInfo) info(scheduler *scheduling.Scheduler, backends map[string]inference.Backend, defaultBackend inference.Backend) inference.RunnerInfo {
+	settings := scheduler.LoaderSettings()
+	info := inference.RunnerInfo{
+		ModelsPath:          i.modelsPath,
+		Listen:              i.listen,
+		TLSListen:           i.tlsListen,
+		AllowedOrigins:      splitList(os.Getenv("DMR_ORIGINS")),
+		DefaultBackend:      defaultBackend.Name(),
+		LlamaServerVersion:  llamacpp.GetDesiredServerVersion(),
+		LlamaArgs:           splitArgs(logging.RedactEnv("LLAMA_ARGS", os.Getenv("LLAMA_ARGS"))),
+		DefaultContextSize:  settings.DefaultContextSize,
+		MaxContextSize:      settings.MaxContextSize,
+		IdleTimeout:         settings.IdleTimeout.String(),
+		BackendStartTimeout: settings.BackendStartTimeout.String(),
+		Features: map[string]bool{
+			"metrics":             os.Getenv("DISABLE_METRICS") != "1",
+			"metrics_auth":        os.Getenv("MODEL_RUNNER_METRICS_TOKEN") != "",
+			"tls":                 i.tlsListen != "",
+			"tracing":             i.tracing,
+			"auto_prune":          os.Getenv("MODEL_RUNNER_AUTO_PRUNE") == "1",
+			"llama_server_update": os.Getenv("DISABLE_SERVER_UPDATE") == "",
+		},
+		Environment: configEnv(),
+	}
+	if info.AllowedOrigins == nil {
+		info.AllowedOrigins = []string{}
+	}
+	for name, backend := range backends {
+		info.Backends = append(info.Backends, inference.BackendInfo{
+			Name:   name,
+			Path:   i.backendPaths[name],
+			Status: backend.Status(),
+		})
+	}
+	sort.Slice(info.Backends, func(a, b int) bool {
+		return info.Backends[a].Name < info.Backends[b].Name
+	})
+	return info
+}
+
+// configEnv returns the runner's configuration environment variables, with
+// secrets redacted.
+func configEnv() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		relevant := false
+		for _, prefix := range configEnvPrefixes {
+			relevant = relevant || strings.HasPrefix(name, prefix)
+		}
+		for _, other := range configEnvVars {
+			relevant = relevant || name == other
+		}
+		if relevant {
+			env[name] = logging.RedactEnv(name, value)
+		}
+	}
+	return env
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// newInfoHandler serves the runner info at inference.InfoPath.
+func newInfoHandler(info func() inference.RunnerInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(info()); err != nil {
+			http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
@@ -1,18 +1,24 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/docker/model-runner/pkg/anthropic"
+	"github.com/docker/model-runner/pkg/distribution/distribution"
+	"github.com/docker/model-runner/pkg/distribution/registry"
 	"github.com/docker/model-runner/pkg/inference"
 	"github.com/docker/model-runner/pkg/inference/backends/diffusers"
 	"github.com/docker/model-runner/pkg/inference/backends/llamacpp"
@@ -24,12 +30,14 @@ import (
 	"github.com/docker/model-runner/pkg/inference/models"
 	"github.com/docker/model-runner/pkg/inference/platform"
 	"github.com/docker/model-runner/pkg/inference/scheduling"
+	"github.com/docker/model-runner/pkg/logging"
 	"github.com/docker/model-runner/pkg/metrics"
 	"github.com/docker/model-runner/pkg/middleware"
 	"github.com/docker/model-runner/pkg/ollama"
 	"github.com/docker/model-runner/pkg/responses"
 	"github.com/docker/model-runner/pkg/routing"
 	modeltls "github.com/docker/model-runner/pkg/tls"
+	"github.com/docker/model-runner/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
@@ -50,6 +58,24 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// Scrub secrets from every log line before it is formatted.
+	log.AddHook(logging.NewRedactHook())
+
+	if err := configureLoggerFromEnv(log); err != nil {
+		log.Warnf("Ignoring invalid log configuration: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, "model-runner")
+	if err != nil {
+		log.Warnf("Tracing disabled: %v", err)
+	} else {
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				log.Warnf("Failed to shut down tracing: %v", err)
+			}
+		}()
+	}
+
 	sockName := os.Getenv("MODEL_RUNNER_SOCK")
 	if sockName == "" {
 		sockName = "model-runner.sock"
@@ -99,10 +125,56 @@ func main() {
 	}
 	baseTransport.Proxy = http.ProxyFromEnvironment
 
+	// Apply dial, TLS handshake, response header and read timeouts to registry
+	// and HuggingFace operations so a stalled connection can't hang a pull.
+	registryTimeouts, err := registry.TransportTimeoutsFromEnv()
+	if err != nil {
+		log.Warnf("Ignoring invalid registry timeout configuration: %v", err)
+	}
+
 	clientConfig := models.ClientConfig{
 		StoreRootPath: modelPath,
 		Logger:        log.WithFields(logrus.Fields{"component": "model-manager"}),
-		Transport:     baseTransport,
+		Transport:     registry.NewTimeoutTransport(baseTransport, registryTimeouts),
+		AutoPrune:     os.Getenv("MODEL_RUNNER_AUTO_PRUNE") == "1",
+		// Tags registry and HuggingFace traffic from this deployment.
+		UserAgentSuffix: os.Getenv("MODEL_RUNNER_UA_SUFFIX"),
+		// Mirrors are listed comma-separated; empty entries are ignored.
+		RegistryMirrors: strings.Split(os.Getenv("MODEL_RUNNER_REGISTRY_MIRRORS"), ","),
+		// A JSON catalog manifest URL or a registry namespace to list.
+		Catalog: os.Getenv("MODEL_RUNNER_CATALOG"),
+	}
+	// Applied to bare model names, e.g. "mycompany" and "stable" resolve "foo"
+	// to "mycompany/foo:stable".
+	defaultOrg, defaultTag, err := modelDefaultsFromEnv()
+	if err != nil {
+		log.Warnf("Ignoring invalid model name defaults: %v", err)
+	}
+	clientConfig.DefaultOrg, clientConfig.DefaultTag = defaultOrg, defaultTag
+	if quota, err := storeQuotaFromEnv(); err != nil {
+		log.Warnf("Ignoring invalid store quota: %v", err)
+	} else {
+		clientConfig.StoreQuotaBytes = quota
+	}
+	fileMode, dirMode, err := storeModesFromEnv()
+	if err != nil {
+		log.Warnf("Ignoring invalid store permissions: %v", err)
+	}
+	clientConfig.StoreFileMode, clientConfig.StoreDirMode = fileMode, dirMode
+	if maxPulls, err := maxConcurrentPullsFromEnv(); err != nil {
+		log.Warnf("Ignoring invalid pull concurrency: %v", err)
+	} else {
+		clientConfig.MaxConcurrentPulls = maxPulls
+	}
+	if rateLimit, err := pullRateLimitFromEnv(); err != nil {
+		log.Warnf("Ignoring invalid pull rate limit: %v", err)
+	} else {
+		clientConfig.PullRateLimit = rateLimit
+	}
+	if maxModelBytes, err := maxModelBytesFromEnv(); err != nil {
+		log.Warnf("Ignoring invalid maximum model size: %v", err)
+	} else {
+		clientConfig.MaxModelBytes = maxModelBytes
 	}
 	modelManager := models.NewManager(log.WithFields(logrus.Fields{"component": "model-manager"}), clientConfig)
 	modelHandler := models.NewHTTPHandler(
@@ -126,6 +198,16 @@ func main() {
 
 	// Create llama.cpp configuration from environment variables
 	llamaCppConfig := createLlamaCppConfigFromEnv()
+	if templates, err := chatTemplatesFromEnv(); err != nil {
+		log.Warnf("Ignoring invalid chat templates: %v", err)
+	} else if templates != nil {
+		cfg, ok := llamaCppConfig.(*llamacpp.Config)
+		if !ok {
+			cfg = llamacpp.NewDefaultLlamaCppConfig()
+			llamaCppConfig = cfg
+		}
+		cfg.ChatTemplates = templates
+	}
 
 	llamaCppBackend, err := llamacpp.New(
 		log,
@@ -222,6 +304,9 @@ func main() {
 		),
 	)
 
+	modelHandler.SetBackendSelector(scheduler.BackendForFormat)
+	modelManager.SetInUseModels(scheduler.LoadedModels)
+
 	// Create the HTTP handler for the scheduler
 	schedulerHTTP := scheduling.NewHTTPHandler(scheduler, modelHandler, nil)
 
@@ -257,6 +342,30 @@ func main() {
 	anthropicHandler := anthropic.NewHandler(log, schedulerHTTP, nil, modelManager)
 	router.Handle(anthropic.APIPrefix+"/", anthropicHandler)
 
+	// Report the runner's effective configuration
+	runnerConfig := runnerInfo{
+		modelsPath: modelPath,
+		listen:     "unix:" + sockName,
+		backendPaths: map[string]string{
+			llamacpp.Name:  llamaServerPath,
+			vllm.Name:      vllmServerPath,
+			sglang.Name:    sglangServerPath,
+			mlx.Name:       mlxServerPath,
+			diffusers.Name: diffusersServerPath,
+			vllmmetal.Name: vllmMetalServerPath,
+		},
+		tracing: shutdownTracing != nil && tracing.Enabled(),
+	}
+	if port := os.Getenv("MODEL_RUNNER_PORT"); port != "" {
+		runnerConfig.listen = "tcp::" + port
+	}
+	if os.Getenv("MODEL_RUNNER_TLS_ENABLED") == "true" {
+		runnerConfig.tlsListen = "tcp::" + cmp.Or(os.Getenv("MODEL_RUNNER_TLS_PORT"), DefaultTLSPort)
+	}
+	router.Handle(inference.InfoPath, newInfoHandler(func() inference.RunnerInfo {
+		return runnerConfig.info(scheduler, backends, llamaCppBackend)
+	}))
+
 	// Register root handler LAST - it will only catch exact "/" requests that don't match other patterns
 	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// Only respond to exact root path
@@ -274,14 +383,21 @@ func main() {
 			log.WithField("component", "metrics"),
 			schedulerHTTP,
 		)
-		router.Handle("/metrics", metricsHandler)
+		// Require a bearer token for network scrapes if one is configured.
+		router.Handle("/metrics", &middleware.TokenAuthHandler{
+			Handler: metricsHandler,
+			Token:   os.Getenv("MODEL_RUNNER_METRICS_TOKEN"),
+		})
 		log.Info("Metrics endpoint enabled at /metrics")
 	} else {
 		log.Info("Metrics endpoint disabled")
 	}
 
+	// Extract trace context and wrap every request in a server span.
+	handler := &middleware.TracingHandler{Handler: router}
+
 	server := &http.Server{
-		Handler:           router,
+		Handler:           handler,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 	serverErrors := make(chan error, 1)
@@ -351,7 +467,7 @@ func main() {
 
 		tlsServer = &http.Server{
 			Addr:              ":" + tlsPort,
-			Handler:           router,
+			Handler:           handler,
 			TLSConfig:         tlsConfig,
 			ReadHeaderTimeout: 10 * time.Second,
 		}
@@ -373,6 +489,13 @@ func main() {
 		schedulerErrors <- scheduler.Run(ctx)
 	}()
 
+	// Preload any configured models in the background so that startup isn't
+	// blocked on them.
+	if preload := preloadModelsFromEnv(); len(preload) > 0 {
+		log.Infof("Preloading models: %s", strings.Join(preload, ", "))
+		go schedulerHTTP.PreloadModels(ctx, preload)
+	}
+
 	var tlsServerErrorsChan <-chan error
 	if os.Getenv("MODEL_RUNNER_TLS_ENABLED") == "true" {
 		tlsServerErrorsChan = tlsServerErrors
@@ -410,6 +533,172 @@ func main() {
 	log.Infoln("Docker Model Runner stopped")
 }
 
+// preloadModelsFromEnv returns the model references listed, comma-separated,
+// in MODEL_RUNNER_PRELOAD.
+func preloadModelsFromEnv() []string {
+	var models []string
+	for _, model := range strings.Split(os.Getenv("MODEL_RUNNER_PRELOAD"), ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// storeQuotaFromEnv returns the model store quota in bytes set by
+// MODEL_RUNNER_STORE_QUOTA_BYTES, or zero (no limit) if it is unset.
+func storeQuotaFromEnv() (int64, error) {
+	raw := os.Getenv("MODEL_RUNNER_STORE_QUOTA_BYTES")
+	if raw == "" {
+		return 0, nil
+	}
+	quota, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || quota < 0 {
+		return 0, fmt.Errorf("invalid MODEL_RUNNER_STORE_QUOTA_BYTES value %q", raw)
+	}
+	return quota, nil
+}
+
+// pullRateLimitFromEnv returns the aggregate pull bandwidth cap in bytes per
+// second set by MODEL_RUNNER_PULL_RATE_LIMIT, or zero (no limit) if it is
+// unset.
+func pullRateLimitFromEnv() (int64, error) {
+	raw := os.Getenv("MODEL_RUNNER_PULL_RATE_LIMIT")
+	if raw == "" {
+		return 0, nil
+	}
+	rateLimit, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || rateLimit < 0 {
+		return 0, fmt.Errorf("invalid MODEL_RUNNER_PULL_RATE_LIMIT value %q", raw)
+	}
+	return rateLimit, nil
+}
+
+// maxModelBytesFromEnv returns the largest declared model size in bytes that
+// may be pulled set by MODEL_RUNNER_MAX_MODEL_BYTES, or zero (no limit) if it
+// is unset.
+func maxModelBytesFromEnv() (int64, error) {
+	raw := os.Getenv("MODEL_RUNNER_MAX_MODEL_BYTES")
+	if raw == "" {
+		return 0, nil
+	}
+	maxModelBytes, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || maxModelBytes < 0 {
+		return 0, fmt.Errorf("invalid MODEL_RUNNER_MAX_MODEL_BYTES value %q", raw)
+	}
+	return maxModelBytes, nil
+}
+
+// maxConcurrentPullsFromEnv returns the maximum number of concurrent model
+// pulls set by MODEL_RUNNER_MAX_CONCURRENT_PULLS, or zero (the default) if it
+// is unset.
+func maxConcurrentPullsFromEnv() (int, error) {
+	raw := os.Getenv("MODEL_RUNNER_MAX_CONCURRENT_PULLS")
+	if raw == "" {
+		return 0, nil
+	}
+	maxPulls, err := strconv.Atoi(raw)
+	if err != nil || maxPulls <= 0 {
+		return 0, fmt.Errorf("invalid MODEL_RUNNER_MAX_CONCURRENT_PULLS value %q", raw)
+	}
+	return maxPulls, nil
+}
+
+// storeModesFromEnv returns the permissions of the files and directories
+// written to the model store, as octal modes in MODEL_RUNNER_STORE_FILE_MODE
+// and MODEL_RUNNER_STORE_DIR_MODE (e.g. "0640" and "0750"). Unset variables
+// yield zero, keeping the default permissions. Invalid values are reported
+// in the returned error and yield zero. Modes must leave the store readable
+// and writable by its owner.
+func storeModesFromEnv() (os.FileMode, os.FileMode, error) {
+	var errs []error
+	parse := func(env string, required os.FileMode) os.FileMode {
+		raw := os.Getenv(env)
+		if raw == "" {
+			return 0
+		}
+		mode, err := strconv.ParseUint(raw, 8, 32)
+		if err != nil || mode > 0o777 || os.FileMode(mode)&required != required {
+			errs = append(errs, fmt.Errorf("invalid %s value %q", env, raw))
+			return 0
+		}
+		return os.FileMode(mode)
+	}
+	fileMode := parse("MODEL_RUNNER_STORE_FILE_MODE", 0o600)
+	dirMode := parse("MODEL_RUNNER_STORE_DIR_MODE", 0o700)
+	return fileMode, dirMode, errors.Join(errs...)
+}
+
+// modelDefaultsFromEnv returns the organization and tag applied to model
+// names without one, set by MODEL_RUNNER_DEFAULT_ORG and
+// MODEL_RUNNER_DEFAULT_TAG. Unset variables yield empty strings, keeping the
+// defaults. Invalid values are reported in the returned error and yield empty
+// strings.
+func modelDefaultsFromEnv() (string, string, error) {
+	var errs []error
+	org := os.Getenv("MODEL_RUNNER_DEFAULT_ORG")
+	if org != "" && distribution.ValidateDefaultOrg(org) != nil {
+		errs = append(errs, fmt.Errorf("invalid MODEL_RUNNER_DEFAULT_ORG value %q", org))
+		org = ""
+	}
+	tag := os.Getenv("MODEL_RUNNER_DEFAULT_TAG")
+	if tag != "" && distribution.ValidateDefaultTag(tag) != nil {
+		errs = append(errs, fmt.Errorf("invalid MODEL_RUNNER_DEFAULT_TAG value %q", tag))
+		tag = ""
+	}
+	return org, tag, errors.Join(errs...)
+}
+
+// chatTemplatesFromEnv returns the llama.cpp chat templates to use for models
+// without one of their own, as comma-separated architecture=template pairs in
+// MODEL_RUNNER_CHAT_TEMPLATES (e.g. "llama=llama3,qwen2=chatml"). An empty
+// template disables the default for that architecture. Invalid values are
+// reported in the returned error and yield nil.
+func chatTemplatesFromEnv() (map[string]string, error) {
+	raw := os.Getenv("MODEL_RUNNER_CHAT_TEMPLATES")
+	if raw == "" {
+		return nil, nil
+	}
+	valid := func(s string) bool {
+		return !strings.ContainsFunc(s, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+		})
+	}
+	templates := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		architecture, template, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || architecture == "" || !valid(architecture) || !valid(template) {
+			return nil, fmt.Errorf("invalid MODEL_RUNNER_CHAT_TEMPLATES value %q", raw)
+		}
+		templates[architecture] = template
+	}
+	return templates, nil
+}
+
+// configureLoggerFromEnv applies MODEL_RUNNER_LOG_FORMAT ("text" or "json")
+// and MODEL_RUNNER_LOG_LEVEL (e.g. "debug", "info", "warn", "error") to logger.
+// Invalid values are reported in the returned error and leave the logger's
+// current setting in place.
+func configureLoggerFromEnv(logger *logrus.Logger) error {
+	var errs []error
+	switch format := os.Getenv("MODEL_RUNNER_LOG_FORMAT"); format {
+	case "", "text":
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		errs = append(errs, fmt.Errorf("invalid MODEL_RUNNER_LOG_FORMAT value %q", format))
+	}
+	if raw := os.Getenv("MODEL_RUNNER_LOG_LEVEL"); raw != "" {
+		level, err := logrus.ParseLevel(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid MODEL_RUNNER_LOG_LEVEL value %q", raw))
+		} else {
+			logger.SetLevel(level)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // createLlamaCppConfigFromEnv creates a LlamaCppConfig from environment variables
 func createLlamaCppConfigFromEnv() config.BackendConfig {
 	// Check if any configuration environment variables are set
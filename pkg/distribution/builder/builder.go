@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/format"
@@ -59,22 +62,26 @@ func FromPaths(paths []string) (*Builder, error) {
 // fromFormat creates a Builder using the unified format abstraction.
 // This is the internal implementation that creates layers and config.
 func fromFormat(f format.Format, paths []string) (*Builder, error) {
+	// Order the paths as their layers will be ordered, so that the extracted
+	// config doesn't depend on the order in which they were given either.
+	paths = slices.Clone(paths)
+	slices.SortStableFunc(paths, func(a, b string) int {
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
+
 	// Create layers from paths
 	layers := make([]oci.Layer, len(paths))
-	diffIDs := make([]oci.Hash, len(paths))
-
 	mediaType := f.MediaType()
 	for i, path := range paths {
 		layer, err := partial.NewLayer(path, mediaType)
 		if err != nil {
 			return nil, fmt.Errorf("create layer from %q: %w", path, err)
 		}
-		diffID, err := layer.DiffID()
-		if err != nil {
-			return nil, fmt.Errorf("get diffID for %q: %w", path, err)
-		}
 		layers[i] = layer
-		diffIDs[i] = diffID
+	}
+	diffIDs, err := sortLayers(layers)
+	if err != nil {
+		return nil, err
 	}
 
 	// Extract config metadata using format-specific logic
@@ -123,10 +130,41 @@ func (b *Builder) WithLicense(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("license layer from %q: %w", path, err)
 	}
+	return b.appendLayer(licenseLayer), nil
+}
+
+// appendLayer returns a Builder whose model has layer added, keeping the
+// layers in canonical order so that the model's ID doesn't depend on the
+// order in which files were added.
+func (b *Builder) appendLayer(layer oci.Layer) *Builder {
 	return &Builder{
-		model:          mutate.AppendLayers(b.model, licenseLayer),
+		model:          mutate.SortLayers(mutate.AppendLayers(b.model, layer)),
 		originalLayers: b.originalLayers,
-	}, nil
+	}
+}
+
+// sortLayers orders layers canonically (see partial.CompareLayers) and returns
+// their diff IDs in the same order.
+func sortLayers(layers []oci.Layer) ([]oci.Hash, error) {
+	slices.SortStableFunc(layers, partial.CompareLayers)
+	diffIDs := make([]oci.Hash, len(layers))
+	for i, layer := range layers {
+		diffID, err := layer.DiffID()
+		if err != nil {
+			return nil, fmt.Errorf("get diffID for layer %d: %w", i, err)
+		}
+		diffIDs[i] = diffID
+	}
+	return diffIDs, nil
+}
+
+// WithCreated sets the creation time recorded in the model's config. Models
+// built from identical files with the same creation time have the same ID.
+func (b *Builder) WithCreated(created time.Time) *Builder {
+	return &Builder{
+		model:          mutate.Created(b.model, created),
+		originalLayers: b.originalLayers,
+	}
 }
 
 func (b *Builder) WithContextSize(size int32) *Builder {
@@ -142,10 +180,7 @@ func (b *Builder) WithMultimodalProjector(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("mmproj layer from %q: %w", path, err)
 	}
-	return &Builder{
-		model:          mutate.AppendLayers(b.model, mmprojLayer),
-		originalLayers: b.originalLayers,
-	}, nil
+	return b.appendLayer(mmprojLayer), nil
 }
 
 // WithChatTemplateFile adds a Jinja chat template file to the artifact which takes precedence over template from GGUF.
@@ -154,10 +189,7 @@ func (b *Builder) WithChatTemplateFile(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("chat template layer from %q: %w", path, err)
 	}
-	return &Builder{
-		model:          mutate.AppendLayers(b.model, templateLayer),
-		originalLayers: b.originalLayers,
-	}, nil
+	return b.appendLayer(templateLayer), nil
 }
 
 // WithConfigArchive adds a config archive (tar) file to the artifact
@@ -179,10 +211,7 @@ func (b *Builder) WithConfigArchive(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("config archive layer from %q: %w", path, err)
 	}
-	return &Builder{
-		model:          mutate.AppendLayers(b.model, configLayer),
-		originalLayers: b.originalLayers,
-	}, nil
+	return b.appendLayer(configLayer), nil
 }
 
 // WithDirTar adds a directory tar archive to the artifact.
@@ -192,10 +221,7 @@ func (b *Builder) WithDirTar(path string) (*Builder, error) {
 	if err != nil {
 		return nil, fmt.Errorf("dir tar layer from %q: %w", path, err)
 	}
-	return &Builder{
-		model:          mutate.AppendLayers(b.model, dirTarLayer),
-		originalLayers: b.originalLayers,
-	}, nil
+	return b.appendLayer(dirTarLayer), nil
 }
 
 // Target represents a build target
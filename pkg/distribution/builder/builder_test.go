@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/builder"
 	"github.com/docker/model-runner/pkg/distribution/oci"
@@ -49,15 +50,56 @@ func TestBuilder(t *testing.T) {
 		t.Fatalf("Expected 2 layers, got %d", len(manifest.Layers))
 	}
 
-	// Check that each layer has the expected
-	if manifest.Layers[0].MediaType != types.MediaTypeGGUF {
-		t.Fatalf("Expected first layer with media type %s, got %s", types.MediaTypeGGUF, manifest.Layers[0].MediaType)
+	// Check that each layer has the expected media type, in canonical order
+	if manifest.Layers[0].MediaType != types.MediaTypeChatTemplate {
+		t.Fatalf("Expected first layer with media type %s, got %s", types.MediaTypeChatTemplate, manifest.Layers[0].MediaType)
 	}
-	if manifest.Layers[1].MediaType != types.MediaTypeMultimodalProjector {
-		t.Fatalf("Expected first layer with media type %s, got %s", types.MediaTypeMultimodalProjector, manifest.Layers[1].MediaType)
+	if manifest.Layers[1].MediaType != types.MediaTypeGGUF {
+		t.Fatalf("Expected second layer with media type %s, got %s", types.MediaTypeGGUF, manifest.Layers[1].MediaType)
 	}
-	if manifest.Layers[2].MediaType != types.MediaTypeChatTemplate {
-		t.Fatalf("Expected first layer with media type %s, got %s", types.MediaTypeChatTemplate, manifest.Layers[2].MediaType)
+	if manifest.Layers[2].MediaType != types.MediaTypeMultimodalProjector {
+		t.Fatalf("Expected third layer with media type %s, got %s", types.MediaTypeMultimodalProjector, manifest.Layers[2].MediaType)
+	}
+}
+
+func TestBuilderIDIndependentOfOrder(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	gguf := filepath.Join("..", "assets", "dummy.gguf")
+	mmproj := filepath.Join("..", "assets", "dummy.mmproj")
+	template := filepath.Join("..", "assets", "template.jinja")
+
+	b1, err := builder.FromPath(gguf)
+	if err != nil {
+		t.Fatalf("Failed to create builder from GGUF: %v", err)
+	}
+	if b1, err = b1.WithMultimodalProjector(mmproj); err != nil {
+		t.Fatalf("Failed to add multimodal projector: %v", err)
+	}
+	if b1, err = b1.WithChatTemplateFile(template); err != nil {
+		t.Fatalf("Failed to add chat template: %v", err)
+	}
+
+	b2, err := builder.FromPath(gguf)
+	if err != nil {
+		t.Fatalf("Failed to create builder from GGUF: %v", err)
+	}
+	if b2, err = b2.WithChatTemplateFile(template); err != nil {
+		t.Fatalf("Failed to add chat template: %v", err)
+	}
+	if b2, err = b2.WithMultimodalProjector(mmproj); err != nil {
+		t.Fatalf("Failed to add multimodal projector: %v", err)
+	}
+
+	id1, err := b1.WithCreated(created).Model().ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+	id2, err := b2.WithCreated(created).Model().ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+	if id1 != id2 {
+		t.Errorf("Expected equal IDs regardless of packaging order, got %s and %s", id1, id2)
 	}
 }
 
@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/distribution/internal/partial"
+	"github.com/docker/model-runner/pkg/distribution/types"
+)
+
+// FromDDUFComponents creates a Builder that packages the component files of a
+// DDUF archive (model_index.json, scheduler and tokenizer configs, weights,
+// ...) as individual layers annotated with their path inside the archive,
+// rather than as a single opaque DDUF layer.
+//
+// Because unchanged components keep their layer digests, a variant of the
+// model that only edits a config file shares every weight layer with the
+// original and can be written or pushed without transferring them again.
+//
+// The archive is extracted into destDir, which must remain in place until the
+// built model has been written; the caller is responsible for removing it.
+func FromDDUFComponents(ddufPath, destDir string) (*Builder, error) {
+	if err := extractDDUF(ddufPath, destDir); err != nil {
+		return nil, fmt.Errorf("extract DDUF archive: %w", err)
+	}
+
+	b, err := FromDirectory(destDir)
+	if err != nil {
+		return nil, err
+	}
+
+	mdl, ok := b.model.(*partial.BaseModel)
+	if !ok {
+		return nil, fmt.Errorf("unexpected model type: %T", b.model)
+	}
+	mdl.ModelConfigFile.Config.Format = types.FormatDiffusers
+	mdl.ModelConfigFile.Config.Architecture = "diffusers"
+	mdl.ModelConfigFile.Config.Diffusers = map[string]string{
+		"layout":    "components",
+		"dduf_file": filepath.Base(ddufPath),
+	}
+	return b, nil
+}
+
+// extractDDUF extracts the regular files of the DDUF (zip) archive at path
+// into destDir, rejecting entries that would escape it.
+func extractDDUF(path, destDir string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	root, err := filepath.Abs(destDir)
+	if err != nil {
+		return fmt.Errorf("resolve destination: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return fmt.Errorf("unsupported entry %q in DDUF archive", f.Name)
+		}
+		target := filepath.Join(root, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(target, root+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid entry %q in DDUF archive: path escapes destination", f.Name)
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("create directory for %s: %w", f.Name, err)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s in archive: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", target, err)
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	return out.Close()
+}
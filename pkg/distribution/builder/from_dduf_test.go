@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+	"github.com/docker/model-runner/pkg/distribution/types"
+)
+
+func TestFromDDUFComponents(t *testing.T) {
+	ddufPath := createTestDDUF(t, map[string]string{
+		"model_index.json":                         `{"_class_name": "StableDiffusionPipeline"}`,
+		"scheduler/scheduler_config.json":          "{}",
+		"unet/config.json":                         "{}",
+		"unet/diffusion_pytorch_model.safetensors": "unet weights",
+	})
+
+	b, err := FromDDUFComponents(ddufPath, t.TempDir())
+	if err != nil {
+		t.Fatalf("FromDDUFComponents failed: %v", err)
+	}
+
+	layers, err := b.Model().Layers()
+	if err != nil {
+		t.Fatalf("Failed to get layers: %v", err)
+	}
+	var paths []string
+	for _, layer := range layers {
+		dp, ok := layer.(interface{ GetDescriptor() oci.Descriptor })
+		if !ok {
+			t.Fatalf("Layer does not expose its descriptor")
+		}
+		paths = append(paths, dp.GetDescriptor().Annotations[types.AnnotationFilePath])
+	}
+	sort.Strings(paths)
+	want := []string{
+		"model_index.json",
+		"scheduler/scheduler_config.json",
+		"unet/config.json",
+		"unet/diffusion_pytorch_model.safetensors",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("Expected layers for %v, got %v", want, paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("Expected layer path %q, got %q", want[i], paths[i])
+		}
+	}
+
+	cfg, err := b.Model().Config()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+	if cfg.GetFormat() != types.FormatDiffusers {
+		t.Errorf("Expected format %q, got %q", types.FormatDiffusers, cfg.GetFormat())
+	}
+}
+
+func TestFromDDUFComponents_RejectsPathTraversal(t *testing.T) {
+	ddufPath := createTestDDUF(t, map[string]string{
+		"model_index.json": "{}",
+		"../escape.json":   "{}",
+	})
+
+	if _, err := FromDDUFComponents(ddufPath, t.TempDir()); err == nil {
+		t.Fatal("Expected error for entry escaping the destination")
+	}
+}
+
+// createTestDDUF writes a DDUF (zip) archive holding the given files.
+func createTestDDUF(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "model.dduf")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create DDUF file: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatalf("Failed to add %s to DDUF: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write %s to DDUF: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to finalize DDUF: %v", err)
+	}
+	return path
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/files"
-	"github.com/docker/model-runner/pkg/distribution/internal/mutate"
 	"github.com/docker/model-runner/pkg/distribution/internal/partial"
 	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/docker/model-runner/pkg/distribution/types"
@@ -81,7 +80,6 @@ func FromDirectory(dirPath string, opts ...DirectoryOption) (*Builder, error) {
 	}
 
 	var layers []oci.Layer
-	var diffIDs []oci.Hash
 	var detectedFormat types.Format
 	var weightFiles []string
 
@@ -159,14 +157,7 @@ func FromDirectory(dirPath string, opts ...DirectoryOption) (*Builder, error) {
 			return fmt.Errorf("create layer for %q: %w", relPath, err)
 		}
 
-		diffID, err := layer.DiffID()
-		if err != nil {
-			return fmt.Errorf("get diffID for %q: %w", relPath, err)
-		}
-
 		layers = append(layers, layer)
-		diffIDs = append(diffIDs, diffID)
-
 		return nil
 	})
 
@@ -177,6 +168,10 @@ func FromDirectory(dirPath string, opts ...DirectoryOption) (*Builder, error) {
 	if len(layers) == 0 {
 		return nil, fmt.Errorf("no files found in directory: %s", dirPath)
 	}
+	diffIDs, err := sortLayers(layers)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(weightFiles) == 0 {
 		return nil, fmt.Errorf("no weight files (safetensors, GGUF, or DDUF) found in directory: %s", dirPath)
@@ -311,8 +306,5 @@ func (b *Builder) WithFileLayer(absPath, relPath string) (*Builder, error) {
 		return nil, fmt.Errorf("file layer from %q: %w", absPath, err)
 	}
 
-	return &Builder{
-		model:          mutate.AppendLayers(b.model, layer),
-		originalLayers: b.originalLayers,
-	}, nil
+	return b.appendLayer(layer), nil
 }
@@ -0,0 +1,47 @@
+package distribution
+
+import (
+	"fmt"
+
+	"github.com/docker/model-runner/pkg/internal/utils"
+)
+
+// ArchiveResponse describes a model archived by ArchiveModel.
+type ArchiveResponse struct {
+	// ID is the ID of the archived model.
+	ID string `json:"id"`
+	// Reclaimed is the number of bytes freed by removing the model's blobs.
+	Reclaimed int64 `json:"reclaimed"`
+}
+
+// ArchiveModel removes the weights of a local model while keeping its
+// manifest, config and tags, so that it is still listed but must be pulled
+// again before it can be used.
+func (c *Client) ArchiveModel(reference string) (*ArchiveResponse, error) {
+	normalizedRef := c.resolveModelName(reference)
+	mdl, err := c.store.Read(normalizedRef)
+	if err != nil {
+		return nil, fmt.Errorf("get model '%q': %w", utils.SanitizeForLog(reference), err)
+	}
+	id, err := mdl.ID()
+	if err != nil {
+		return nil, fmt.Errorf("getting model ID: %w", err)
+	}
+	c.log.Infoln("Archiving model:", id)
+	reclaimed, err := c.store.Archive(id)
+	if err != nil {
+		return nil, fmt.Errorf("archiving model: %w", err)
+	}
+	return &ArchiveResponse{ID: id, Reclaimed: reclaimed}, nil
+}
+
+// UnarchiveModel marks an archived model as usable again once its weights are
+// back in the store, e.g. because another model shares them. It returns an
+// error wrapping ErrModelArchived if the model must be pulled again first.
+func (c *Client) UnarchiveModel(reference string) error {
+	normalizedRef := c.resolveModelName(reference)
+	if err := c.store.Unarchive(normalizedRef); err != nil {
+		return fmt.Errorf("unarchiving model '%q': %w", utils.SanitizeForLog(reference), err)
+	}
+	return nil
+}
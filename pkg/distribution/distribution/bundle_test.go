@@ -2,8 +2,10 @@ package distribution
 
 import (
 	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/docker/model-runner/pkg/distribution/builder"
@@ -149,3 +151,115 @@ func TestBundle(t *testing.T) {
 		})
 	}
 }
+
+func TestBundleIncompleteShards(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	b, err := builder.FromPath(filepath.Join("..", "assets", "dummy-00001-of-00002.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(b.Model(), []string{"sharded-model"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	bundle, err := client.GetBundle("sharded-model")
+	if err != nil {
+		t.Fatalf("Failed to get bundle: %v", err)
+	}
+	secondShard := filepath.Join(bundle.RootDir(), "model", "model-00002-of-00002.gguf")
+
+	// A shard missing from the bundle is restored from the store.
+	if err := os.Remove(secondShard); err != nil {
+		t.Fatalf("Failed to remove shard: %v", err)
+	}
+	if _, err := client.GetBundle("sharded-model"); err != nil {
+		t.Fatalf("Expected the bundle to be recreated, got: %v", err)
+	}
+	if _, err := os.Stat(secondShard); err != nil {
+		t.Fatalf("Expected the shard to be restored: %v", err)
+	}
+
+	// A truncated shard, linked to its blob, can only be fixed by a re-pull.
+	if err := os.Truncate(secondShard, 1); err != nil {
+		t.Fatalf("Failed to truncate shard: %v", err)
+	}
+	if _, err := client.GetBundle("sharded-model"); !errors.Is(err, ErrIncompleteModel) {
+		t.Errorf("Expected ErrIncompleteModel, got: %v", err)
+	}
+}
+
+func TestBundleStoreModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions are not supported on Windows")
+	}
+	storeDir := filepath.Join(t.TempDir(), "store")
+	client, err := NewClient(WithStoreRootPath(storeDir), WithStoreModes(0o640, 0o750))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	b, err := builder.FromPath(filepath.Join("..", "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(b.Model(), []string{"some-model"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	bundle, err := client.GetBundle("some-model")
+	if err != nil {
+		t.Fatalf("Failed to get bundle: %v", err)
+	}
+
+	for _, root := range []string{filepath.Join(storeDir, "blobs"), bundle.RootDir()} {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			want := os.FileMode(0o640)
+			if d.IsDir() {
+				want = 0o750
+			}
+			if info.Mode().Perm() != want {
+				t.Errorf("Expected %s to have mode %o, got %o", path, want, info.Mode().Perm())
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to walk %s: %v", root, err)
+		}
+	}
+}
+
+func TestBundleMissingShardLayers(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Package the first of two shards on its own, so that the model has one
+	// GGUF layer while the shard's metadata declares two.
+	firstShard := filepath.Join(t.TempDir(), "model.gguf")
+	data, err := os.ReadFile(filepath.Join("..", "assets", "dummy-00001-of-00002.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to read shard: %v", err)
+	}
+	if err := os.WriteFile(firstShard, data, 0644); err != nil {
+		t.Fatalf("Failed to write shard: %v", err)
+	}
+	b, err := builder.FromPath(firstShard)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(b.Model(), []string{"partial-model"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	if _, err := client.GetBundle("partial-model"); !errors.Is(err, ErrIncompleteModel) {
+		t.Errorf("Expected ErrIncompleteModel, got: %v", err)
+	}
+}
@@ -1,27 +1,39 @@
 package distribution
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/docker/model-runner/pkg/distribution/builder"
 	"github.com/docker/model-runner/pkg/distribution/huggingface"
 	"github.com/docker/model-runner/pkg/distribution/internal/mutate"
 	"github.com/docker/model-runner/pkg/distribution/internal/progress"
 	"github.com/docker/model-runner/pkg/distribution/internal/store"
 	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/docker/model-runner/pkg/distribution/oci/authn"
+	"github.com/docker/model-runner/pkg/distribution/oci/reference"
 	"github.com/docker/model-runner/pkg/distribution/oci/remote"
 	"github.com/docker/model-runner/pkg/distribution/registry"
 	"github.com/docker/model-runner/pkg/distribution/tarball"
 	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/inference/platform"
 	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client provides model distribution functionality
@@ -29,6 +41,31 @@ type Client struct {
 	store    *store.LocalStore
 	log      *logrus.Entry
 	registry *registry.Client
+	// autoPrune removes a tag's previous model after a pull moves the tag to
+	// a new digest, if nothing else references it.
+	autoPrune bool
+	// storeQuota is the maximum total size in bytes of models in the store.
+	// Zero means no limit.
+	storeQuota int64
+	// inUseModels, if set, reports the IDs of the models that the store quota
+	// must not evict.
+	inUseModels atomic.Pointer[func() []string]
+	// pendingUses holds the references whose use is being recorded, so that
+	// requests don't queue up behind the index update.
+	pendingUses sync.Map
+	// mirrors are registries that pulls retry against, in order, when the
+	// primary registry fails.
+	mirrors []string
+	// pullLimiter caps the aggregate download rate of pulls. Nil means no
+	// limit.
+	pullLimiter *store.RateLimiter
+	// maxModelSize is the largest declared size in bytes of a model that may
+	// be pulled. Zero means no limit.
+	maxModelSize int64
+	// defaultOrg and defaultTag are applied to model names that lack an
+	// organization or a tag.
+	defaultOrg string
+	defaultTag string
 }
 
 // GetStorePath returns the root path where models are stored
@@ -44,6 +81,15 @@ type options struct {
 	storeRootPath  string
 	logger         *logrus.Entry
 	registryClient *registry.Client
+	autoPrune      bool
+	storeQuota     int64
+	mirrors        []string
+	storeFileMode  os.FileMode
+	storeDirMode   os.FileMode
+	pullRateLimit  int64
+	maxModelSize   int64
+	defaultOrg     string
+	defaultTag     string
 }
 
 // WithStoreRootPath sets the store root path
@@ -73,9 +119,94 @@ func WithRegistryClient(client *registry.Client) Option {
 	}
 }
 
+// WithAutoPrune enables removal of a tag's previous, now-untagged model after
+// a pull updates the tag to a new digest.
+func WithAutoPrune(enabled bool) Option {
+	return func(o *options) {
+		o.autoPrune = enabled
+	}
+}
+
+// WithStoreQuota limits the total size in bytes of models in the store. Pulls
+// and imports that would exceed it first evict least recently used models.
+// A non-positive value means no limit.
+func WithStoreQuota(bytes int64) Option {
+	return func(o *options) {
+		o.storeQuota = max(bytes, 0)
+	}
+}
+
+// WithStoreModes sets the permissions applied to the files and directories
+// written to the store, regardless of the umask. A zero mode keeps the
+// default permissions.
+func WithStoreModes(fileMode, dirMode os.FileMode) Option {
+	return func(o *options) {
+		o.storeFileMode = fileMode
+		o.storeDirMode = dirMode
+	}
+}
+
+// WithPullRateLimit caps the aggregate download rate of pulls, across
+// concurrent layers and pulls, in bytes per second. A non-positive value means
+// no limit.
+func WithPullRateLimit(bytesPerSecond int64) Option {
+	return func(o *options) {
+		o.pullRateLimit = max(bytesPerSecond, 0)
+	}
+}
+
+// WithMaxModelSize refuses pulls of models whose layers' declared sizes sum
+// to more than bytes. A non-positive value means no limit.
+func WithMaxModelSize(bytes int64) Option {
+	return func(o *options) {
+		o.maxModelSize = max(bytes, 0)
+	}
+}
+
+// WithDefaultOrg sets the organization applied to model names without one,
+// such as "mycompany" or "registry.example.com/mycompany". An empty org keeps
+// the default of "ai".
+func WithDefaultOrg(org string) Option {
+	return func(o *options) {
+		if org != "" {
+			o.defaultOrg = org
+		}
+	}
+}
+
+// WithDefaultTag sets the tag applied to model names without one. An empty
+// tag keeps the default of "latest".
+func WithDefaultTag(tag string) Option {
+	return func(o *options) {
+		if tag != "" {
+			o.defaultTag = tag
+		}
+	}
+}
+
+// ValidateDefaultOrg returns an error if org can't be applied to model names
+// as their organization.
+func ValidateDefaultOrg(org string) error {
+	if _, err := reference.ParseReference(org + "/model:latest"); err != nil || org != strings.ToLower(org) {
+		return fmt.Errorf("invalid default organization %q", org)
+	}
+	return nil
+}
+
+// ValidateDefaultTag returns an error if tag can't be applied to model names
+// as their tag.
+func ValidateDefaultTag(tag string) error {
+	if _, err := reference.ParseReference("ai/model:" + tag); err != nil || strings.ContainsAny(tag, "@:") {
+		return fmt.Errorf("invalid default tag %q", tag)
+	}
+	return nil
+}
+
 func defaultOptions() *options {
 	return &options{
-		logger: logrus.NewEntry(logrus.StandardLogger()),
+		logger:     logrus.NewEntry(logrus.StandardLogger()),
+		defaultOrg: "ai",
+		defaultTag: "latest",
 	}
 }
 
@@ -89,9 +220,14 @@ func NewClient(opts ...Option) (*Client, error) {
 	if options.storeRootPath == "" {
 		return nil, fmt.Errorf("store root path is required")
 	}
+	if err := errors.Join(ValidateDefaultOrg(options.defaultOrg), ValidateDefaultTag(options.defaultTag)); err != nil {
+		return nil, err
+	}
 
 	s, err := store.New(store.Options{
 		RootPath: options.storeRootPath,
+		FileMode: options.storeFileMode,
+		DirMode:  options.storeDirMode,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("initializing store: %w", err)
@@ -104,9 +240,17 @@ func NewClient(opts ...Option) (*Client, error) {
 
 	options.logger.Infoln("Successfully initialized store")
 	c := &Client{
-		store:    s,
-		log:      options.logger,
-		registry: registryClient,
+		store:       s,
+		log:         options.logger,
+		registry:    registryClient,
+		autoPrune:   options.autoPrune,
+		storeQuota:  options.storeQuota,
+		mirrors:     options.mirrors,
+		pullLimiter: store.NewRateLimiter(options.pullRateLimit),
+
+		maxModelSize: options.maxModelSize,
+		defaultOrg:   options.defaultOrg,
+		defaultTag:   options.defaultTag,
 	}
 
 	// Migrate any legacy hf.co tags to huggingface.co
@@ -136,14 +280,13 @@ func (c *Client) migrateHFTags() error {
 	return nil
 }
 
-// normalizeModelName adds the default organization prefix (ai/) and tag (:latest) if missing.
-// It also resolves IDs to full IDs.
+// normalizeModelName adds the default organization prefix (ai/ unless
+// configured) and tag (:latest unless configured) if missing. HuggingFace
+// references always default to :latest, which selects their default
+// quantization. It also resolves IDs to full IDs.
 // This is a private method used internally by the Client.
 func (c *Client) normalizeModelName(model string) string {
-	const (
-		defaultOrg = "ai"
-		defaultTag = "latest"
-	)
+	defaultOrg, defaultTag := c.defaultOrg, c.defaultTag
 
 	model = strings.TrimSpace(model)
 	if model == "" {
@@ -155,6 +298,9 @@ func (c *Client) normalizeModelName(model string) string {
 	if rest, found := strings.CutPrefix(model, "hf.co/"); found {
 		model = "huggingface.co/" + rest
 	}
+	if isHuggingFaceReference(model) {
+		defaultTag = "latest"
+	}
 
 	// If it looks like an ID or digest, try to resolve it to full ID
 	if c.looksLikeID(model) || c.looksLikeDigest(model) {
@@ -164,6 +310,11 @@ func (c *Client) normalizeModelName(model string) string {
 		return model
 	}
 
+	// A digest reference (name@sha256:...) pins the manifest, so it gets no tag.
+	if name, digest, ok := strings.Cut(model, "@"); ok {
+		return normalizeRepository(name, defaultOrg) + "@" + digest
+	}
+
 	// Split name vs tag, where ':' is a tag separator only if it's after the last '/'
 	lastSlash := strings.LastIndex(model, "/")
 	lastColon := strings.LastIndex(model, ":")
@@ -180,6 +331,13 @@ func (c *Client) normalizeModelName(model string) string {
 		}
 	}
 
+	// Lowercase ONLY the name part (registry/org/repo). Tag stays unchanged.
+	return normalizeRepository(name, defaultOrg) + ":" + tag
+}
+
+// normalizeRepository applies the default organization to a repository name
+// without a registry or organization, and lowercases it.
+func normalizeRepository(name, defaultOrg string) string {
 	// If name has no registry (domain with dot before first slash), apply default org if missing slash
 	firstSlash := strings.Index(name, "/")
 	hasRegistry := firstSlash > 0 && strings.Contains(name[:firstSlash], ".")
@@ -187,11 +345,57 @@ func (c *Client) normalizeModelName(model string) string {
 	if !hasRegistry && !strings.Contains(name, "/") {
 		name = defaultOrg + "/" + name
 	}
+	return strings.ToLower(name)
+}
 
-	// Lowercase ONLY the name part (registry/org/repo). Tag stays unchanged.
-	name = strings.ToLower(name)
+// resolveModelName resolves a user-supplied model name for lookup in the store.
+// Aliases are consulted first; an alias whose target is no longer in the store
+// is ignored and the name falls back to normalizeModelName.
+func (c *Client) resolveModelName(model string) string {
+	name := strings.TrimSpace(model)
+	aliases, err := c.store.Aliases()
+	if err != nil {
+		c.log.Warnf("Failed to read model aliases: %v", err)
+		return c.normalizeModelName(model)
+	}
+	if target, ok := aliases[name]; ok {
+		if _, err := c.store.Read(target); err == nil {
+			return target
+		}
+		c.log.Debugf("Ignoring alias %s: target %s not in store",
+			utils.SanitizeForLog(name), utils.SanitizeForLog(target))
+	}
+	return c.normalizeModelName(model)
+}
+
+// SetAlias maps the alias name to the target model reference, which must be in
+// the local store.
+func (c *Client) SetAlias(name, target string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid alias name %q", utils.SanitizeForLog(name))
+	}
+	normalizedTarget := c.normalizeModelName(target)
+	if _, err := c.store.Read(normalizedTarget); err != nil {
+		return fmt.Errorf("alias target '%q': %w", utils.SanitizeForLog(target), err)
+	}
+	if err := c.store.SetAlias(name, normalizedTarget); err != nil {
+		return fmt.Errorf("set alias: %w", err)
+	}
+	return nil
+}
+
+// ListAliases returns the alias table, mapping alias names to model references.
+func (c *Client) ListAliases() (map[string]string, error) {
+	return c.store.Aliases()
+}
 
-	return name + ":" + tag
+// RemoveAlias removes the alias with the given name.
+func (c *Client) RemoveAlias(name string) error {
+	if err := c.store.RemoveAlias(strings.TrimSpace(name)); err != nil {
+		return fmt.Errorf("remove alias '%q': %w", utils.SanitizeForLog(name), err)
+	}
+	return nil
 }
 
 // looksLikeID returns true for short & long hex IDs (12 or 64 chars)
@@ -259,27 +463,78 @@ func (c *Client) resolveID(id string) string {
 	return ""
 }
 
+// PullOption configures a model pull.
+type PullOption func(*pullOptions)
+
+type pullOptions struct {
+	bearerToken    string
+	variant        *oci.Platform
+	expectedDigest string
+}
+
+// WithBearerToken authenticates the pull with the given bearer token.
+func WithBearerToken(token string) PullOption {
+	return func(o *pullOptions) {
+		o.bearerToken = token
+	}
+}
+
+// WithVariant selects the variant for platform when the reference resolves to
+// an image index.
+func WithVariant(platform oci.Platform) PullOption {
+	return func(o *pullOptions) {
+		o.variant = &platform
+	}
+}
+
+// WithExpectedDigest makes the pull fail with ErrDigestMismatch, before
+// anything is downloaded, if the manifest the reference resolves to doesn't
+// have the given digest. For an image index, either the index or the manifest
+// of the selected variant may have it. Pulls from Hugging Face, which has no
+// manifest to check, fail with ErrDigestUnsupported.
+func WithExpectedDigest(digest string) PullOption {
+	return func(o *pullOptions) {
+		o.expectedDigest = digest
+	}
+}
+
 // PullModel pulls a model from a registry and returns the local file path
-func (c *Client) PullModel(ctx context.Context, reference string, progressWriter io.Writer, bearerToken ...string) error {
+func (c *Client) PullModel(ctx context.Context, reference string, progressWriter io.Writer, opts ...PullOption) (err error) {
 	// Store original reference before normalization (needed for case-sensitive HuggingFace API)
 	originalReference := reference
 	// Normalize the model reference
 	reference = c.normalizeModelName(reference)
 	c.log.Infoln("Starting model pull:", utils.SanitizeForLog(reference))
 
-	// Handle bearer token for registry authentication
-	var token string
-	if len(bearerToken) > 0 && bearerToken[0] != "" {
-		token = bearerToken[0]
+	ctx, span := tracing.Tracer().Start(ctx, "pull", trace.WithAttributes(attribute.String("model", reference)))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+	ctx = c.withRateLimitProgress(ctx, progressWriter, oci.ModePull)
+
+	var o pullOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	token := o.bearerToken
+	if o.expectedDigest != "" {
+		if _, err := oci.NewHash(o.expectedDigest); err != nil {
+			return fmt.Errorf("invalid expected digest %q: %w", utils.SanitizeForLog(o.expectedDigest), err)
+		}
 	}
 
 	// HuggingFace references always use native pull (download raw files from HF Hub)
 	if isHuggingFaceReference(originalReference) {
+		if o.expectedDigest != "" {
+			// Hugging Face repositories have no manifest to check up front.
+			return fmt.Errorf("%w: Hugging Face model %s has no manifest digest to verify", ErrDigestUnsupported, utils.SanitizeForLog(reference))
+		}
 		c.log.Infoln("Using native HuggingFace pull for:", utils.SanitizeForLog(reference))
 
 		// Check if model already exists in local store (reference is already normalized)
 		localModel, err := c.store.Read(reference)
-		if err == nil {
+		if err == nil && !localModel.Archived() {
 			c.log.Infoln("HuggingFace model found in local store:", utils.SanitizeForLog(reference))
 			cfg, err := localModel.Config()
 			if err != nil {
@@ -290,7 +545,7 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 			}
 			return nil
 		}
-		if !errors.Is(err, ErrModelNotFound) {
+		if err != nil && !errors.Is(err, ErrModelNotFound) {
 			return fmt.Errorf("checking for cached HuggingFace model: %w", err)
 		}
 
@@ -303,11 +558,37 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 	if token != "" {
 		// Create a temporary registry client with bearer token authentication
 		auth := authn.NewBearer(token)
-		registryClient = registry.FromClient(c.registry, registry.WithAuth(auth))
+		registryClient = registry.FromClient(registryClient, registry.WithAuth(auth))
+	}
+	if o.variant != nil {
+		registryClient = registry.FromClient(registryClient, registry.WithPlatform(o.variant))
 	}
 
-	// Fetch the remote model to get the manifest
+	// Fetch the remote model to get the manifest, falling back to the mirrors
+	// if the primary registry fails. mirror is the index of the mirror
+	// serving the model, or -1 for the primary registry.
+	mirror := -1
 	remoteModel, err := registryClient.Model(ctx, reference)
+	if err == nil && len(c.mirrors) > 0 {
+		// The manifest and config are fetched lazily; fetch them now so
+		// that failures can still fall back to a mirror.
+		err = prefetchMetadata(remoteModel)
+	}
+	if err != nil && len(c.mirrors) > 0 && isRetryablePullError(ctx, err) {
+		// Mirrors are only trusted to serve the manifest with a known digest,
+		// so a tag that the primary registry couldn't resolve isn't looked
+		// up on them.
+		if digest, ok := knownDigest(reference, remoteModel, o.expectedDigest); !ok {
+			c.log.Warnf("Failed to read model %s from registry, not trying mirrors without a known manifest digest: %v", utils.SanitizeForLog(reference), err)
+		} else {
+			c.log.Warnf("Failed to read model %s from registry, trying mirrors: %v", utils.SanitizeForLog(reference), err)
+			if mirrored, idx, mirrorErr := c.modelFromMirrors(ctx, registryClient, reference, digest, 0, progressWriter); mirrorErr == nil {
+				remoteModel, mirror, err = mirrored, idx, nil
+			} else {
+				err = errors.Join(err, mirrorErr)
+			}
+		}
+	}
 	if err != nil {
 		// Check if the error should be converted to registry.ErrModelNotFound for API compatibility
 		// If the error already matches ErrModelNotFound, return it directly to preserve errors.Is compatibility
@@ -316,6 +597,9 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 		}
 		return fmt.Errorf("reading model from registry: %w", err)
 	}
+	if o.variant != nil && !registry.FromIndex(remoteModel) {
+		c.log.Debugf("Ignoring variant %s: %s is not an image index", o.variant.String(), utils.SanitizeForLog(reference))
+	}
 
 	// Get the remote image digest immediately to ensure we work with a consistent manifest
 	// This prevents race conditions if the tag is updated during the pull
@@ -325,6 +609,21 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 		return fmt.Errorf("getting remote image digest: %w", err)
 	}
 	c.log.Infoln("Remote model digest:", remoteDigest.String())
+	if o.expectedDigest != "" && remoteDigest.String() != o.expectedDigest {
+		if indexDigest, ok := registry.IndexDigest(remoteModel); !ok || indexDigest.String() != o.expectedDigest {
+			return fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, utils.SanitizeForLog(o.expectedDigest), remoteDigest)
+		}
+	}
+
+	// A digest reference names the manifest itself, so it isn't stored as a
+	// tag. Unless it names an image index, it must match the manifest served.
+	tags := []string{reference}
+	if _, pinned, ok := strings.Cut(reference, "@"); ok {
+		tags = nil
+		if !registry.FromIndex(remoteModel) && remoteDigest.String() != pinned {
+			return fmt.Errorf("reading model from registry: requested manifest %s, got %s", utils.SanitizeForLog(pinned), remoteDigest)
+		}
+	}
 
 	// Check for incomplete downloads and prepare resume offsets
 	layers, err := remoteModel.Layers()
@@ -361,9 +660,11 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 	}
 
 	// If we have any incomplete downloads, create a new context with resume offsets
-	// and re-fetch using the original reference to ensure compatibility with all registries
+	// and re-fetch using the original reference to ensure compatibility with all registries.
+	// Downloads from a mirror always start over so that each blob is verified in full.
+	fetchCtx := ctx
 	var rangeSuccess *remote.RangeSuccess
-	if len(resumeOffsets) > 0 {
+	if len(resumeOffsets) > 0 && mirror < 0 {
 		c.log.Infof("Resuming %d interrupted layer download(s)", len(resumeOffsets))
 		// Create a RangeSuccess tracker to record which Range requests succeed
 		rangeSuccess = &remote.RangeSuccess{}
@@ -384,8 +685,9 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 	}
 
 	// Check if model exists in local store
+	// Archived models are pulled again, which restores their weights.
 	localModel, err := c.store.Read(remoteDigest.String())
-	if err == nil {
+	if err == nil && !localModel.Archived() {
 		c.log.Infoln("Model found in local store:", utils.SanitizeForLog(reference))
 		cfg, err := localModel.Config()
 		if err != nil {
@@ -398,9 +700,12 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 		}
 
 		// Ensure model has the correct tag
-		if err := c.store.AddTags(remoteDigest.String(), []string{reference}); err != nil {
+		if err := c.store.AddTags(remoteDigest.String(), tags); err != nil {
 			return fmt.Errorf("tagging model: %w", err)
 		}
+		if err := c.store.MarkPulled(remoteDigest.String(), time.Now()); err != nil {
+			c.log.Warnf("Failed to record pull of model %s: %v", utils.SanitizeForLog(reference), err)
+		}
 		return nil
 	} else {
 		c.log.Infoln("Model not found in local store, pulling from remote:", utils.SanitizeForLog(reference))
@@ -408,19 +713,71 @@ func (c *Client) PullModel(ctx context.Context, reference string, progressWriter
 
 	// Model doesn't exist in local store or digests don't match, pull from remote
 
+	// Remember which model the tag currently points at so it can be pruned
+	// once the tag moves to the new digest.
+	var previousID string
+	if c.autoPrune && tags != nil {
+		if previous, err := c.store.Read(reference); err == nil {
+			previousID, _ = previous.ID()
+		}
+	}
+
+	total, need, err := pullSizes(remoteModel, c.store, resumeOffsets)
+	if err != nil {
+		return err
+	}
+	if c.maxModelSize > 0 && total > c.maxModelSize {
+		return c.refusePull(progressWriter, fmt.Errorf("%w: model is %d bytes, the maximum is %d bytes",
+			ErrModelTooLarge, total, c.maxModelSize))
+	}
+	releaseRoom, err := c.makeRoomFor(remoteModel, progressWriter)
+	if err != nil {
+		return err
+	}
+	defer releaseRoom()
+	if available, err := c.store.AvailableSpace(); err != nil {
+		c.log.Debugf("Not checking available space for pull: %v", err)
+	} else if uint64(need) > available {
+		return c.refusePull(progressWriter, fmt.Errorf("%w: pull needs %d bytes, %d bytes are available",
+			ErrInsufficientSpace, need, available))
+	}
+
 	// Pass rangeSuccess to store.Write for resume detection
-	var writeOpts []store.WriteOption
+	writeOpts := []store.WriteOption{store.WithContext(ctx), store.WithRateLimiter(c.pullLimiter)}
 	if rangeSuccess != nil {
 		writeOpts = append(writeOpts, store.WithRangeSuccess(rangeSuccess))
 	}
-	if err = c.store.Write(remoteModel, []string{reference}, progressWriter, writeOpts...); err != nil {
+	err = c.store.Write(remoteModel, tags, progressWriter, writeOpts...)
+	// If fetching fails, retry the pinned digest against each remaining mirror.
+	for err != nil && mirror+1 < len(c.mirrors) && isRetryablePullError(fetchCtx, err) {
+		c.log.Warnf("Failed to fetch model %s, trying mirrors: %v", utils.SanitizeForLog(reference), err)
+		mirrored, idx, mirrorErr := c.modelFromMirrors(fetchCtx, registryClient, reference, remoteDigest, mirror+1, progressWriter)
+		if mirrorErr != nil {
+			err = errors.Join(err, mirrorErr)
+			break
+		}
+		mirror = idx
+		err = c.store.Write(mirrored, tags, progressWriter, store.WithContext(fetchCtx), store.WithRateLimiter(c.pullLimiter))
+	}
+	if err != nil {
 		if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error()), oci.ModePull); writeErr != nil {
 			c.log.Warnf("Failed to write error message: %v", writeErr)
 		}
 		return fmt.Errorf("writing image to store: %w", err)
 	}
 
-	if err := progress.WriteSuccess(progressWriter, "Model pulled successfully", oci.ModePull); err != nil {
+	successMsg := "Model pulled successfully"
+	if previousID != "" && previousID != remoteDigest.String() {
+		reclaimed, err := c.store.PruneUntagged(previousID)
+		if err != nil {
+			c.log.Warnf("Failed to prune previous model %s: %v", previousID, err)
+		} else if reclaimed > 0 {
+			c.log.Infof("Pruned previous model %s, reclaimed %d bytes", previousID, reclaimed)
+			successMsg = fmt.Sprintf("%s (reclaimed %.2f MB from previous version)", successMsg, float64(reclaimed)/1024/1024)
+		}
+	}
+
+	if err := progress.WriteSuccess(progressWriter, successMsg, oci.ModePull); err != nil {
 		c.log.Warnf("Failed to write success message: %v", err)
 	}
 
@@ -432,6 +789,9 @@ func (c *Client) LoadModel(r io.Reader, progressWriter io.Writer) (string, error
 	c.log.Infoln("Starting model load")
 
 	tr := tarball.NewReader(r)
+	// loaded tracks the blobs this load adds to the store, which count
+	// towards the store quota.
+	var loaded []oci.Hash
 	for {
 		diffID, err := tr.Next()
 		if errors.Is(err, io.EOF) {
@@ -445,18 +805,47 @@ func (c *Client) LoadModel(r io.Reader, progressWriter io.Writer) (string, error
 			return "", fmt.Errorf("reading blob from stream: %w", err)
 		}
 		c.log.Infoln("Loading blob:", diffID)
-		if err := c.store.WriteBlob(diffID, tr); err != nil {
+		exists, err := c.store.HasBlob(diffID)
+		if err == nil && exists {
+			// The stored blob was verified when it was written.
+			continue
+		}
+		// The store hashes the blob as it streams and only keeps it if its
+		// content matches the claimed diffID.
+		reporter := progress.NewBlobProgressReporter(progressWriter, progress.VerifyMsg, diffID, tr.Size(), oci.ModePull)
+		updates := reporter.Updates()
+		err = c.store.WriteVerifiedBlob(diffID, progress.NewReader(tr, updates))
+		close(updates)
+		if waitErr := reporter.Wait(); waitErr != nil {
+			c.log.Warnf("Failed to write progress for blob %s: %v", diffID, waitErr)
+		}
+		if err != nil {
+			c.removeLoadedBlobs(loaded)
+			if errors.Is(err, ErrBlobDigestMismatch) {
+				c.log.Warnf("Rejected blob %s from archive: %v", diffID, err)
+				return "", fmt.Errorf("verifying blob %s: %w", diffID, err)
+			}
 			return "", fmt.Errorf("writing blob: %w", err)
 		}
+		loaded = append(loaded, diffID)
 		c.log.Infoln("Loaded blob:", diffID)
 	}
 
 	manifest, digest, err := tr.Manifest()
 	if err != nil {
+		c.removeLoadedBlobs(loaded)
 		return "", fmt.Errorf("read manifest: %w", err)
 	}
+	releaseRoom, err := c.makeRoomForLoaded(manifest, loaded, progressWriter)
+	if err != nil {
+		c.removeLoadedBlobs(loaded)
+		return "", err
+	}
+	defer releaseRoom()
 	c.log.Infoln("Loading manifest:", digest.String())
 	if err := c.store.WriteManifest(digest, manifest); err != nil {
+		// Don't leave the blobs of a rejected model behind.
+		c.removeLoadedBlobs(loaded)
 		return "", fmt.Errorf("write manifest: %w", err)
 	}
 	c.log.Infoln("Loaded model with ID:", digest.String())
@@ -468,6 +857,168 @@ func (c *Client) LoadModel(r io.Reader, progressWriter io.Writer) (string, error
 	return digest.String(), nil
 }
 
+// removeLoadedBlobs removes the blobs an aborted load added to the store.
+func (c *Client) removeLoadedBlobs(loaded []oci.Hash) {
+	for _, hash := range loaded {
+		if removeErr := c.store.RemoveBlob(hash); removeErr != nil {
+			c.log.Warnf("Failed to remove loaded blob %s: %v", hash, removeErr)
+		}
+	}
+}
+
+// pullSizes returns the sum of the declared sizes of mdl's layers, and how many
+// of those bytes a pull still has to write to st, given the offsets at which
+// interrupted layer downloads resume.
+func pullSizes(mdl oci.Image, st *store.LocalStore, resumeOffsets map[string]int64) (total, need int64, err error) {
+	layers, err := mdl.Layers()
+	if err != nil {
+		return 0, 0, fmt.Errorf("getting layers: %w", err)
+	}
+	for _, layer := range layers {
+		size, err := layer.Size()
+		if err != nil {
+			return 0, 0, fmt.Errorf("getting layer size: %w", err)
+		}
+		total += size
+		digest, err := layer.Digest()
+		if err != nil {
+			return 0, 0, fmt.Errorf("getting layer digest: %w", err)
+		}
+		if exists, err := st.HasBlob(digest); err == nil && exists {
+			continue
+		}
+		need += max(size-resumeOffsets[digest.String()], 0)
+	}
+	return total, need, nil
+}
+
+// refusePull reports err, the reason a pull is refused before downloading, to
+// progressWriter and returns it.
+func (c *Client) refusePull(progressWriter io.Writer, err error) error {
+	c.log.Warnf("Refusing pull: %v", err)
+	if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error()), oci.ModePull); writeErr != nil {
+		c.log.Warnf("Failed to write error message: %v", writeErr)
+	}
+	return err
+}
+
+// makeRoomFor enforces the store quota before mdl is written, evicting least
+// recently used models until the blobs it adds to the store fit. The room is
+// reserved until the returned function is called, once mdl has been written.
+// It is a no-op if no quota is configured.
+func (c *Client) makeRoomFor(mdl oci.Image, progressWriter io.Writer) (func(), error) {
+	if c.storeQuota == 0 {
+		return func() {}, nil
+	}
+	layers, err := mdl.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("getting layers: %w", err)
+	}
+	var need int64
+	var keep []string
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("getting layer digest: %w", err)
+		}
+		keep = append(keep, digest.String())
+		if exists, err := c.store.HasBlob(digest); err == nil && exists {
+			continue
+		}
+		size, err := layer.Size()
+		if err != nil {
+			return nil, fmt.Errorf("getting layer size: %w", err)
+		}
+		need += size
+	}
+	if cfgName, err := mdl.ConfigName(); err == nil {
+		keep = append(keep, cfgName.String())
+	}
+	return c.makeRoom(need, keep, progressWriter)
+}
+
+// makeRoomForLoaded enforces the store quota for a model whose blobs have
+// been loaded but whose manifest has not yet been written. Only the newly
+// loaded blobs count towards the quota. The room is reserved until the
+// returned function is called, once the manifest has been written.
+func (c *Client) makeRoomForLoaded(rawManifest []byte, loaded []oci.Hash, progressWriter io.Writer) (func(), error) {
+	if c.storeQuota == 0 {
+		return func() {}, nil
+	}
+	manifest, err := oci.ParseManifest(bytes.NewReader(rawManifest))
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	isLoaded := make(map[string]bool, len(loaded))
+	for _, hash := range loaded {
+		isLoaded[hash.String()] = true
+	}
+	var need int64
+	keep := []string{manifest.Config.Digest.String()}
+	for _, layer := range manifest.Layers {
+		keep = append(keep, layer.Digest.String())
+		if isLoaded[layer.Digest.String()] {
+			need += layer.Size
+		}
+	}
+	return c.makeRoom(need, keep, progressWriter)
+}
+
+// makeRoom evicts least recently used models until need more bytes fit within
+// the store quota, logging and reporting each eviction. Blobs in keep and
+// models loaded for inference are never removed. The room is reserved until
+// the returned function is called.
+func (c *Client) makeRoom(need int64, keep []string, progressWriter io.Writer) (func(), error) {
+	var inUse []string
+	if fn := c.inUseModels.Load(); fn != nil {
+		inUse = (*fn)()
+	}
+	evicted, release, err := c.store.MakeRoom(need, c.storeQuota, keep, inUse)
+	for _, m := range evicted {
+		name := m.ID
+		if len(m.Tags) > 0 {
+			name = m.Tags[0]
+		}
+		msg := fmt.Sprintf("Evicted model %s to stay within the store quota (freed %.2f MB)", name, float64(m.Freed)/1024/1024)
+		c.log.Infoln(msg)
+		if err := progress.WriteWarning(progressWriter, msg, oci.ModePull); err != nil {
+			c.log.Warnf("Writing progress: %v", err)
+		}
+	}
+	if err != nil {
+		if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error()), oci.ModePull); writeErr != nil {
+			c.log.Warnf("Failed to write error message: %v", writeErr)
+		}
+		return release, fmt.Errorf("enforcing store quota: %w", err)
+	}
+	return release, nil
+}
+
+// SetInUseModels sets the function reporting the IDs of the models that are
+// in use, such as those loaded for inference, which the store quota never
+// evicts.
+func (c *Client) SetInUseModels(inUse func() []string) {
+	c.inUseModels.Store(&inUse)
+}
+
+// MarkUsed records that the model matching reference was just used, making
+// it the least likely candidate for eviction under the store quota. The use
+// is recorded in the background, so that inference requests don't wait for
+// the index to be updated, and uses made while one is being recorded are
+// dropped.
+func (c *Client) MarkUsed(reference string) {
+	if _, pending := c.pendingUses.LoadOrStore(reference, struct{}{}); pending {
+		return
+	}
+	now := time.Now()
+	go func() {
+		defer c.pendingUses.Delete(reference)
+		if err := c.store.Touch(c.resolveModelName(reference), now); err != nil && !errors.Is(err, ErrModelNotFound) {
+			c.log.Warnf("Failed to record use of model %s: %v", utils.SanitizeForLog(reference), err)
+		}
+	}()
+}
+
 // ListModels returns all available models
 func (c *Client) ListModels() ([]types.Model, error) {
 	c.log.Infoln("Listing available models")
@@ -495,7 +1046,7 @@ func (c *Client) ListModels() ([]types.Model, error) {
 // GetModel returns a model by reference
 func (c *Client) GetModel(reference string) (types.Model, error) {
 	c.log.Infoln("Getting model by reference:", utils.SanitizeForLog(reference))
-	normalizedRef := c.normalizeModelName(reference)
+	normalizedRef := c.resolveModelName(reference)
 	model, err := c.store.Read(normalizedRef)
 	if err != nil {
 		c.log.Errorln("Failed to get model:", err, "reference:", utils.SanitizeForLog(reference))
@@ -508,7 +1059,7 @@ func (c *Client) GetModel(reference string) (types.Model, error) {
 // IsModelInStore checks if a model with the given reference is in the local store
 func (c *Client) IsModelInStore(reference string) (bool, error) {
 	c.log.Infoln("Checking model by reference:", utils.SanitizeForLog(reference))
-	normalizedRef := c.normalizeModelName(reference)
+	normalizedRef := c.resolveModelName(reference)
 	if _, err := c.store.Read(normalizedRef); errors.Is(err, ErrModelNotFound) {
 		return false, nil
 	} else if err != nil {
@@ -517,6 +1068,28 @@ func (c *Client) IsModelInStore(reference string) (bool, error) {
 	return true, nil
 }
 
+// HasBlob reports whether the blob with the given digest is in the local store
+func (c *Client) HasBlob(digest string) (bool, error) {
+	hash, err := oci.NewHash(digest)
+	if err != nil {
+		return false, fmt.Errorf("invalid digest %q: %w", utils.SanitizeForLog(digest), err)
+	}
+	return c.store.HasBlob(hash)
+}
+
+// WriteBlob writes a blob to the local store under the given digest. The
+// content must hash to the digest, otherwise ErrBlobDigestMismatch is returned.
+func (c *Client) WriteBlob(digest string, r io.Reader) error {
+	hash, err := oci.NewHash(digest)
+	if err != nil {
+		return fmt.Errorf("invalid digest %q: %w", utils.SanitizeForLog(digest), err)
+	}
+	if err := c.store.WriteVerifiedBlob(hash, r); err != nil {
+		return fmt.Errorf("write blob: %w", err)
+	}
+	return nil
+}
+
 type DeleteModelAction struct {
 	Untagged *string `json:"Untagged,omitempty"`
 	Deleted  *string `json:"Deleted,omitempty"`
@@ -580,12 +1153,43 @@ func (c *Client) DeleteModel(reference string, force bool) (*DeleteModelResponse
 	return &resp, nil
 }
 
-// Tag adds a tag to a model
-func (c *Client) Tag(source string, target string) error {
+// Tag adds a tag to a model and reports whether the tag was created. Tagging
+// a model with a tag it already has is a no-op; a tag that refers to a
+// different model is only moved if force is set, otherwise ErrConflict is
+// returned.
+func (c *Client) Tag(source string, target string, force bool) (bool, error) {
 	c.log.Infoln("Tagging model, source:", source, "target:", utils.SanitizeForLog(target))
 	normalizedSource := c.normalizeModelName(source)
 	normalizedTarget := c.normalizeModelName(target)
-	return c.store.AddTags(normalizedSource, []string{normalizedTarget})
+
+	mdl, err := c.store.Read(normalizedSource)
+	if err != nil {
+		return false, err
+	}
+	id, err := mdl.ID()
+	if err != nil {
+		return false, fmt.Errorf("getting model ID: %w", err)
+	}
+	existing, err := c.store.Read(normalizedTarget)
+	if err == nil {
+		existingID, err := existing.ID()
+		if err != nil {
+			return false, fmt.Errorf("getting model ID: %w", err)
+		}
+		if existingID == id {
+			return false, nil
+		}
+		if !force {
+			return false, fmt.Errorf("tag %q already refers to model %s, use force to move it: %w",
+				utils.SanitizeForLog(target), existingID, ErrConflict)
+		}
+	} else if !errors.Is(err, ErrModelNotFound) {
+		return false, fmt.Errorf("reading tag target: %w", err)
+	}
+	if err := c.store.AddTags(id, []string{normalizedTarget}); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // PushModel pushes a tagged model from the content store to the registry.
@@ -605,6 +1209,7 @@ func (c *Client) PushModel(ctx context.Context, tag string, progressWriter io.Wr
 
 	// Push the model
 	c.log.Infoln("Pushing model:", utils.SanitizeForLog(tag, -1))
+	ctx = c.withRateLimitProgress(ctx, progressWriter, oci.ModePush)
 	if err := target.Write(ctx, mdl, progressWriter); err != nil {
 		c.log.Errorln("Failed to push image:", err, "reference:", tag)
 		if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error()), oci.ModePush); writeErr != nil {
@@ -621,6 +1226,63 @@ func (c *Client) PushModel(ctx context.Context, tag string, progressWriter io.Wr
 	return nil
 }
 
+// withRateLimitProgress returns ctx with the waits of its rate limited
+// registry requests reported to progressWriter.
+func (c *Client) withRateLimitProgress(ctx context.Context, progressWriter io.Writer, mode oci.Mode) context.Context {
+	return registry.WithRateLimitNotifier(ctx, func(wait time.Duration) {
+		msg := fmt.Sprintf("Rate limited by the registry, retrying in %s", wait.Round(time.Second))
+		c.log.Warnln(msg)
+		if err := progress.WriteWarning(progressWriter, msg, mode); err != nil {
+			c.log.Warnf("Failed to write warning message: %v", err)
+		}
+	})
+}
+
+// IndexVariant is a local model pushed as the child of an image index for
+// the given platform.
+type IndexVariant struct {
+	Platform oci.Platform
+	Model    string
+}
+
+// PushModelIndex pushes each variant's model and an image index referencing
+// them to tag, so that a single tag serves a platform-specific variant.
+func (c *Client) PushModelIndex(ctx context.Context, tag string, variants []IndexVariant, progressWriter io.Writer) error {
+	target, err := c.registry.NewTarget(tag)
+	if err != nil {
+		return fmt.Errorf("new tag: %w", err)
+	}
+
+	entries := make([]oci.IndexEntry, 0, len(variants))
+	for _, variant := range variants {
+		mdl, err := c.store.Read(c.normalizeModelName(variant.Model))
+		if err != nil {
+			return fmt.Errorf("reading model %q for %s: %w", utils.SanitizeForLog(variant.Model), variant.Platform.String(), err)
+		}
+		entries = append(entries, oci.IndexEntry{Image: mdl, Platform: variant.Platform})
+	}
+	idx, err := oci.NewImageIndex(entries)
+	if err != nil {
+		return fmt.Errorf("creating image index: %w", err)
+	}
+
+	c.log.Infoln("Pushing model index:", utils.SanitizeForLog(tag, -1), "with", len(entries), "variants")
+	ctx = c.withRateLimitProgress(ctx, progressWriter, oci.ModePush)
+	if err := target.WriteIndex(ctx, idx, progressWriter); err != nil {
+		c.log.Errorln("Failed to push model index:", err, "reference:", utils.SanitizeForLog(tag, -1))
+		if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error()), oci.ModePush); writeErr != nil {
+			c.log.Warnf("Failed to write error message: %v", writeErr)
+		}
+		return fmt.Errorf("pushing image index: %w", err)
+	}
+
+	c.log.Infoln("Successfully pushed model index:", utils.SanitizeForLog(tag, -1))
+	if err := progress.WriteSuccess(progressWriter, "Model index pushed successfully", oci.ModePush); err != nil {
+		c.log.Warnf("Failed to write success message: %v", err)
+	}
+	return nil
+}
+
 // WriteLightweightModel writes a model to the store without transferring layer data.
 // This is used for config-only modifications where the layer data hasn't changed.
 // The layers must already exist in the store.
@@ -642,9 +1304,46 @@ func (c *Client) ResetStore() error {
 	return nil
 }
 
+// CompactResult reports what Compact reclaimed.
+type CompactResult = store.CompactResult
+
+// Compact reclaims the space taken by the store's empty directories and
+// bloated index files. It never removes blobs.
+func (c *Client) Compact() (CompactResult, error) {
+	result, err := c.store.Compact()
+	if err != nil {
+		return result, fmt.Errorf("compacting store: %w", err)
+	}
+	c.log.Infof("Compacted store, reclaiming %d bytes and %d inodes", result.Bytes, result.Inodes)
+	return result, nil
+}
+
+// IncompleteDownload describes a partially downloaded blob in the store.
+type IncompleteDownload = store.IncompleteDownload
+
+// IncompleteDownloads lists the partially downloaded blobs in the store.
+func (c *Client) IncompleteDownloads() ([]IncompleteDownload, error) {
+	downloads, err := c.store.IncompleteDownloads()
+	if err != nil {
+		return nil, fmt.Errorf("listing incomplete downloads: %w", err)
+	}
+	return downloads, nil
+}
+
+// PurgeIncompleteDownloads removes the partially downloaded blobs that aren't
+// being downloaded, returning those removed.
+func (c *Client) PurgeIncompleteDownloads() ([]IncompleteDownload, error) {
+	c.log.Infoln("Purging incomplete downloads")
+	purged, err := c.store.PurgeIncompleteDownloads()
+	if err != nil {
+		return purged, fmt.Errorf("purging incomplete downloads: %w", err)
+	}
+	return purged, nil
+}
+
 func (c *Client) ExportModel(reference string, w io.Writer) error {
 	c.log.Infoln("Exporting model:", utils.SanitizeForLog(reference))
-	normalizedRef := c.normalizeModelName(reference)
+	normalizedRef := c.resolveModelName(reference)
 	mdl, err := c.store.Read(normalizedRef)
 	if err != nil {
 		c.log.Errorln("Failed to get model for export:", err, "reference:", utils.SanitizeForLog(reference))
@@ -665,11 +1364,21 @@ func (c *Client) ExportModel(reference string, w io.Writer) error {
 	return nil
 }
 
+// Quantizer converts the GGUF file at src into dst using the given
+// quantization type (e.g. "Q4_K_M"), writing diagnostic output to log.
+type Quantizer func(ctx context.Context, src, dst, quantization string, log io.Writer) error
+
 type RepackageOptions struct {
 	ContextSize *uint64
+	// Quantization requests the GGUF weights be requantized to the given type
+	// using Quantizer.
+	Quantization *string
+	Quantizer    Quantizer
+	// ProgressWriter, if set, receives progress messages while requantizing.
+	ProgressWriter io.Writer
 }
 
-func (c *Client) RepackageModel(sourceRef string, targetRef string, opts RepackageOptions) error {
+func (c *Client) RepackageModel(ctx context.Context, sourceRef string, targetRef string, opts RepackageOptions) error {
 	c.log.Infoln("Repackaging model:", utils.SanitizeForLog(sourceRef), "->", utils.SanitizeForLog(targetRef))
 
 	normalizedSource := c.normalizeModelName(sourceRef)
@@ -682,22 +1391,144 @@ func (c *Client) RepackageModel(sourceRef string, targetRef string, opts Repacka
 	}
 
 	var modifiedModel types.ModelArtifact = mdl
+	if opts.Quantization != nil {
+		requantized, cleanup, err := c.requantize(ctx, mdl, *opts.Quantization, opts.Quantizer, opts.ProgressWriter)
+		if err != nil {
+			c.log.Errorln("Failed to requantize model:", err, "reference:", utils.SanitizeForLog(sourceRef))
+			return err
+		}
+		defer cleanup()
+		modifiedModel = requantized
+	}
 	if opts.ContextSize != nil {
 		modifiedModel = mutate.ContextSize(modifiedModel, int32(*opts.ContextSize))
 	}
 
-	if err := c.store.WriteLightweight(modifiedModel, []string{normalizedTarget}); err != nil {
+	if opts.Quantization != nil {
+		// The requantized weights are a new layer, so a full write is required.
+		err = c.store.Write(modifiedModel, []string{normalizedTarget}, opts.ProgressWriter, store.WithContext(ctx))
+	} else {
+		err = c.store.WriteLightweight(modifiedModel, []string{normalizedTarget})
+	}
+	if err != nil {
 		c.log.Errorln("Failed to write repackaged model:", err, "target:", utils.SanitizeForLog(targetRef))
 		return fmt.Errorf("write repackaged model: %w", err)
 	}
 
+	if opts.Quantization != nil {
+		msg := fmt.Sprintf("Model requantized to %s successfully", *opts.Quantization)
+		if err := progress.WriteSuccess(opts.ProgressWriter, msg, oci.ModePull); err != nil {
+			c.log.Warnf("Failed to write success message: %v", err)
+		}
+	}
+
 	c.log.Infoln("Successfully repackaged model:", utils.SanitizeForLog(sourceRef), "->", utils.SanitizeForLog(targetRef))
 	return nil
 }
 
+// requantize converts the GGUF weights of mdl to the given quantization and
+// returns a model carrying the new weights along with the source's other
+// layers (license, projector, chat template, ...) and context size. The
+// returned cleanup function removes the temporary weight file and must be
+// called once the model has been written.
+func (c *Client) requantize(ctx context.Context, mdl *store.Model, quantization string, quantizer Quantizer, pw io.Writer) (types.ModelArtifact, func(), error) {
+	if quantizer == nil {
+		return nil, nil, fmt.Errorf("%w: no quantizer available", ErrRequantizationUnsupported)
+	}
+	ggufPaths, err := mdl.GGUFPaths()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get GGUF paths: %w", err)
+	}
+	switch {
+	case len(ggufPaths) == 0:
+		return nil, nil, fmt.Errorf("%w: model is not in GGUF format", ErrRequantizationUnsupported)
+	case len(ggufPaths) > 1:
+		return nil, nil, fmt.Errorf("%w: sharded GGUF models are not supported", ErrRequantizationUnsupported)
+	}
+
+	// Keep the (potentially multi-GB) output on the same filesystem as the store.
+	tempDir, err := os.MkdirTemp(c.store.RootPath(), ".requantize-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			c.log.Warnf("Failed to remove temporary directory %s: %v", tempDir, err)
+		}
+	}
+
+	if err := progress.WriteProgress(pw, fmt.Sprintf("Requantizing model to %s...", quantization), 0, 0, 0, "", oci.ModePull); err != nil {
+		c.log.Warnf("Failed to write progress message: %v", err)
+	}
+	dst := filepath.Join(tempDir, "model.gguf")
+	if err := quantizer(ctx, ggufPaths[0], dst, quantization, &quantizerLogWriter{w: pw}); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("requantize model: %w", err)
+	}
+
+	b, err := builder.FromPath(dst)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("create model from requantized weights: %w", err)
+	}
+	requantized := b.Model()
+
+	layers, err := mdl.Layers()
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("get model layers: %w", err)
+	}
+	var extra []oci.Layer
+	for _, layer := range layers {
+		mt, err := layer.MediaType()
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("get layer media type: %w", err)
+		}
+		if mt != types.MediaTypeGGUF {
+			extra = append(extra, layer)
+		}
+	}
+	if len(extra) > 0 {
+		requantized = mutate.AppendLayers(requantized, extra...)
+	}
+
+	cfg, err := mdl.Config()
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("get model config: %w", err)
+	}
+	if cs := cfg.GetContextSize(); cs != nil {
+		requantized = mutate.ContextSize(requantized, *cs)
+	}
+	return requantized, cleanup, nil
+}
+
+// quantizerLogWriter forwards quantizer output to a progress stream, one
+// progress message per line.
+type quantizerLogWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (q *quantizerLogWriter) Write(p []byte) (int, error) {
+	q.buf = append(q.buf, p...)
+	for {
+		i := bytes.IndexByte(q.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(q.buf[:i])); line != "" {
+			_ = progress.WriteProgress(q.w, line, 0, 0, 0, "", oci.ModePull)
+		}
+		q.buf = q.buf[i+1:]
+	}
+	return len(p), nil
+}
+
 // GetBundle returns a types.Bundle containing the model, creating one as necessary
 func (c *Client) GetBundle(ref string) (types.ModelBundle, error) {
-	normalizedRef := c.normalizeModelName(ref)
+	normalizedRef := c.resolveModelName(ref)
 	return c.store.BundleForModel(normalizedRef)
 }
 
@@ -713,8 +1544,8 @@ func checkCompat(image types.ModelArtifact, log *logrus.Entry, reference string,
 	if err != nil {
 		return err
 	}
-	if manifest.Config.MediaType != types.MediaTypeModelConfigV01 {
-		return fmt.Errorf("config type %q is unsupported: %w", manifest.Config.MediaType, ErrUnsupportedMediaType)
+	if err := checkConfigMediaType(manifest.Config.MediaType); err != nil {
+		return err
 	}
 
 	// Check if the model format is supported
@@ -738,6 +1569,58 @@ func checkCompat(image types.ModelArtifact, log *logrus.Entry, reference string,
 	return nil
 }
 
+// supportedConfigMediaTypes lists the model config media types this client can pull.
+var supportedConfigMediaTypes = []types.MediaType{
+	types.MediaTypeModelConfigV01,
+	types.MediaTypeModelConfigV02,
+}
+
+// modelConfigMediaTypeRegexp matches versioned Docker model config media types
+// and captures the major and minor version numbers.
+var modelConfigMediaTypeRegexp = regexp.MustCompile(`^application/vnd\.docker\.ai\.model\.config\.v(\d+)\.(\d+)\+json$`)
+
+// parseConfigMediaTypeVersion extracts the major and minor version from a Docker
+// model config media type. It returns false if the media type is not one.
+func parseConfigMediaTypeVersion(mt types.MediaType) (major, minor int, ok bool) {
+	m := modelConfigMediaTypeRegexp.FindStringSubmatch(string(mt))
+	if m == nil {
+		return 0, 0, false
+	}
+	major, errMajor := strconv.Atoi(m[1])
+	minor, errMinor := strconv.Atoi(m[2])
+	if errMajor != nil || errMinor != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// checkConfigMediaType verifies that the config media type is one this client
+// supports. A recognized model config type with a version newer than every
+// supported one yields an *ErrNewerModelFormat; anything else that isn't
+// supported yields ErrUnsupportedMediaType.
+func checkConfigMediaType(mt types.MediaType) error {
+	if slices.Contains(supportedConfigMediaTypes, mt) {
+		return nil
+	}
+	if major, minor, ok := parseConfigMediaTypeVersion(mt); ok {
+		newer := true
+		for _, supported := range supportedConfigMediaTypes {
+			sMajor, sMinor, _ := parseConfigMediaTypeVersion(supported)
+			if major < sMajor || (major == sMajor && minor <= sMinor) {
+				newer = false
+				break
+			}
+		}
+		if newer {
+			return &ErrNewerModelFormat{
+				Got:       mt,
+				Supported: slices.Clone(supportedConfigMediaTypes),
+			}
+		}
+	}
+	return fmt.Errorf("config type %q is unsupported: %w", mt, ErrUnsupportedMediaType)
+}
+
 // isHuggingFaceReference checks if a reference is a HuggingFace model reference
 func isHuggingFaceReference(reference string) bool {
 	return strings.HasPrefix(reference, "huggingface.co/") ||
@@ -779,19 +1662,31 @@ func (c *Client) pullNativeHuggingFace(ctx context.Context, reference string, pr
 
 	// Create HuggingFace client
 	hfOpts := []huggingface.ClientOption{
-		huggingface.WithUserAgent(registry.DefaultUserAgent),
+		huggingface.WithUserAgent(c.registry.UserAgent()),
+		huggingface.WithTransport(c.registry.Transport()),
+		huggingface.WithDownloadLimiter(c.pullLimiter.Reader),
 	}
 	if token != "" {
 		hfOpts = append(hfOpts, huggingface.WithToken(token))
 	}
 	hfClient := huggingface.NewClient(hfOpts...)
 
-	// Create temp directory for downloads
-	tempDir, err := os.MkdirTemp("", "hf-model-*")
+	// Stage downloads in a directory of the store derived from the reference,
+	// so that partial files from an interrupted pull of the same reference can
+	// be resumed. The directory stays locked for the duration of the pull.
+	tempDir, release, err := c.store.Staging("huggingface:" + repo + "@" + revision + ":" + tag)
 	if err != nil {
-		return fmt.Errorf("create temp dir: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
+		return fmt.Errorf("create staging dir: %w", err)
+	}
+	defer release()
+	var pulled, interrupted bool
+	defer func() {
+		// Keep partial downloads around only if the pull was interrupted, so
+		// the next attempt can resume
+		if !pulled && !interrupted {
+			os.RemoveAll(tempDir)
+		}
+	}()
 
 	// Build model from HuggingFace repository
 	// The tag is used for GGUF quantization selection (e.g., "Q4_K_M", "Q8_0")
@@ -800,6 +1695,8 @@ func (c *Client) pullNativeHuggingFace(ctx context.Context, reference string, pr
 		// Convert HuggingFace errors to registry errors for consistent handling
 		var authErr *huggingface.AuthError
 		var notFoundErr *huggingface.NotFoundError
+		var interruptedErr *huggingface.InterruptedError
+		interrupted = errors.As(err, &interruptedErr) || ctx.Err() != nil
 		if errors.As(err, &authErr) {
 			return registry.ErrUnauthorized
 		}
@@ -814,6 +1711,11 @@ func (c *Client) pullNativeHuggingFace(ctx context.Context, reference string, pr
 
 	// Write model to store with normalized tag
 	storageTag := c.normalizeModelName(reference)
+	releaseRoom, err := c.makeRoomFor(model, progressWriter)
+	if err != nil {
+		return err
+	}
+	defer releaseRoom()
 	c.log.Infof("Writing model to store with tag: %s", utils.SanitizeForLog(storageTag))
 	if err := c.store.Write(model, []string{storageTag}, progressWriter); err != nil {
 		if writeErr := progress.WriteError(progressWriter, fmt.Sprintf("Error: %s", err.Error()), oci.ModePull); writeErr != nil {
@@ -821,6 +1723,7 @@ func (c *Client) pullNativeHuggingFace(ctx context.Context, reference string, pr
 		}
 		return fmt.Errorf("writing model to store: %w", err)
 	}
+	pulled = true
 
 	if err := progress.WriteSuccess(progressWriter, "Model pulled successfully", oci.ModePull); err != nil {
 		c.log.Warnf("Failed to write success message: %v", err)
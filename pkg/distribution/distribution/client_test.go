@@ -3,18 +3,25 @@ package distribution
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/docker/model-runner/pkg/distribution/builder"
+	"github.com/docker/model-runner/pkg/distribution/internal/bundle"
 	"github.com/docker/model-runner/pkg/distribution/internal/gguf"
 	"github.com/docker/model-runner/pkg/distribution/internal/mutate"
 	"github.com/docker/model-runner/pkg/distribution/internal/progress"
@@ -24,6 +31,7 @@ import (
 	"github.com/docker/model-runner/pkg/distribution/oci/remote"
 	mdregistry "github.com/docker/model-runner/pkg/distribution/registry"
 	"github.com/docker/model-runner/pkg/distribution/registry/testregistry"
+	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/inference/platform"
 	"github.com/sirupsen/logrus"
 )
@@ -394,8 +402,79 @@ func TestClientPullModel(t *testing.T) {
 		}
 	})
 
+	t.Run("auto prune previous version on tag update", func(t *testing.T) {
+		tempDir := t.TempDir()
+		testClient, err := NewClient(
+			WithStoreRootPath(tempDir),
+			WithRegistryClient(mdregistry.NewClient(mdregistry.WithPlainHTTP(true))),
+			WithAutoPrune(true),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		testTag := registryHost + "/prune-test:latest"
+		if err := writeToRegistry(testGGUFFile, testTag, remote.WithPlainHTTP(true)); err != nil {
+			t.Fatalf("Failed to push first version of model: %v", err)
+		}
+		if err := testClient.PullModel(t.Context(), testTag, nil); err != nil {
+			t.Fatalf("Failed to pull first version of model: %v", err)
+		}
+
+		content, err := os.ReadFile(testGGUFFile)
+		if err != nil {
+			t.Fatalf("Failed to read test model file: %v", err)
+		}
+		updatedModelFile := filepath.Join(tempDir, "updated-dummy.gguf")
+		if err := os.WriteFile(updatedModelFile, append(content, []byte("PRUNE")...), 0644); err != nil {
+			t.Fatalf("Failed to create updated model file: %v", err)
+		}
+		if err := writeToRegistry(updatedModelFile, testTag, remote.WithPlainHTTP(true)); err != nil {
+			t.Fatalf("Failed to push updated model: %v", err)
+		}
+
+		var progressBuffer bytes.Buffer
+		if err := testClient.PullModel(t.Context(), testTag, &progressBuffer); err != nil {
+			t.Fatalf("Failed to pull updated model: %v", err)
+		}
+		if !strings.Contains(progressBuffer.String(), "reclaimed") {
+			t.Errorf("Expected reclaimed bytes in progress output, got %q", progressBuffer.String())
+		}
+
+		models, err := testClient.ListModels()
+		if err != nil {
+			t.Fatalf("Failed to list models: %v", err)
+		}
+		if len(models) != 1 {
+			t.Errorf("Expected previous version to be pruned, got %d models", len(models))
+		}
+	})
+
+	t.Run("refuse model above maximum size", func(t *testing.T) {
+		testClient, err := NewClient(
+			WithStoreRootPath(t.TempDir()),
+			WithRegistryClient(mdregistry.NewClient(mdregistry.WithPlainHTTP(true))),
+			WithMaxModelSize(1),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		var progressBuffer bytes.Buffer
+		err = testClient.PullModel(t.Context(), tag, &progressBuffer)
+		if !errors.Is(err, ErrModelTooLarge) {
+			t.Fatalf("Expected ErrModelTooLarge, got %v", err)
+		}
+		if !strings.Contains(progressBuffer.String(), "maximum model size") {
+			t.Errorf("Expected the refusal in progress output, got %q", progressBuffer.String())
+		}
+		if models, err := testClient.ListModels(); err != nil || len(models) != 0 {
+			t.Errorf("Expected nothing to be pulled, got %d models, %v", len(models), err)
+		}
+	})
+
 	t.Run("pull unsupported (newer) version", func(t *testing.T) {
-		newMdl := mutate.ConfigMediaType(model, "application/vnd.docker.ai.model.config.v0.2+json")
+		newMdl := mutate.ConfigMediaType(model, "application/vnd.docker.ai.model.config.v0.3+json")
 		// Push model to local store
 		testTag := registryHost + "/unsupported-test/model:v1.0.0"
 		ref, err := reference.ParseReference(testTag)
@@ -405,17 +484,74 @@ func TestClientPullModel(t *testing.T) {
 		if err := remote.Write(ref, newMdl, nil, remote.WithPlainHTTP(true)); err != nil {
 			t.Fatalf("Failed to push model: %v", err)
 		}
-		if err := client.PullModel(t.Context(), testTag, nil); err == nil || !errors.Is(err, ErrUnsupportedMediaType) {
+		err = client.PullModel(t.Context(), testTag, nil)
+		if err == nil || !errors.Is(err, ErrUnsupportedMediaType) {
 			t.Fatalf("Expected artifact version error, got %v", err)
 		}
+		var newerErr *ErrNewerModelFormat
+		if !errors.As(err, &newerErr) {
+			t.Fatalf("Expected ErrNewerModelFormat, got %T: %v", err, err)
+		}
+	})
+
+	t.Run("pull V0.2 layer-per-file model", func(t *testing.T) {
+		// Build a V0.2 model from a directory with a nested file
+		srcDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(srcDir, "model.gguf"), modelContent, 0644); err != nil {
+			t.Fatalf("Failed to write model file: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Join(srcDir, "tokenizer"), 0755); err != nil {
+			t.Fatalf("Failed to create nested directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, "tokenizer", "config.json"), []byte("{}"), 0644); err != nil {
+			t.Fatalf("Failed to write nested config file: %v", err)
+		}
+		b, err := builder.FromDirectory(srcDir)
+		if err != nil {
+			t.Fatalf("Failed to create V0.2 model: %v", err)
+		}
+		v02Model := b.Model()
+		manifest, err := v02Model.Manifest()
+		if err != nil {
+			t.Fatalf("Failed to get manifest: %v", err)
+		}
+		if manifest.Config.MediaType != types.MediaTypeModelConfigV02 {
+			t.Fatalf("Expected config media type %q, got %q", types.MediaTypeModelConfigV02, manifest.Config.MediaType)
+		}
+
+		testTag := registryHost + "/v02-test/model:v1.0.0"
+		ref, err := reference.ParseReference(testTag)
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, v02Model, nil, remote.WithPlainHTTP(true)); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+
+		if err := client.PullModel(t.Context(), testTag, nil); err != nil {
+			t.Fatalf("Failed to pull V0.2 model: %v", err)
+		}
+
+		// The bundle should be unpacked via UnpackFromLayers, preserving nested paths
+		bndl, err := client.GetBundle(testTag)
+		if err != nil {
+			t.Fatalf("Failed to get bundle: %v", err)
+		}
+		if filepath.Base(bndl.GGUFPath()) != "model.gguf" {
+			t.Errorf("Expected GGUF path to end with model.gguf, got %q", bndl.GGUFPath())
+		}
+		nestedPath := filepath.Join(bndl.RootDir(), bundle.ModelSubdir, "tokenizer", "config.json")
+		if _, err := os.Stat(nestedPath); err != nil {
+			t.Errorf("Expected nested file %s to be unpacked: %v", nestedPath, err)
+		}
 	})
 
 	t.Run("pull safetensors model returns error on unsupported platforms", func(t *testing.T) {
 		safetensorsTempDir := t.TempDir()
 
-		// Create a minimal safetensors file (just needs to exist for this test)
+		// Create a minimal safetensors file: an empty JSON header and no tensors
 		safetensorsPath := filepath.Join(safetensorsTempDir, "model.safetensors")
-		safetensorsContent := []byte("fake safetensors content for testing")
+		safetensorsContent := []byte("\x02\x00\x00\x00\x00\x00\x00\x00{}")
 		if err := os.WriteFile(safetensorsPath, safetensorsContent, 0644); err != nil {
 			t.Fatalf("Failed to create safetensors file: %v", err)
 		}
@@ -632,7 +768,7 @@ func TestClientListModels(t *testing.T) {
 	}
 
 	// Create test model file
-	modelContent := []byte("test model content")
+	modelContent := ggufContent("test model content")
 	modelFile := filepath.Join(tempDir, "test-model.gguf")
 	if err := os.WriteFile(modelFile, modelContent, 0644); err != nil {
 		t.Fatalf("Failed to write test model file: %v", err)
@@ -651,7 +787,7 @@ func TestClientListModels(t *testing.T) {
 	}
 
 	// Create a slightly different model file for the second model
-	modelContent2 := []byte("test model content 2")
+	modelContent2 := ggufContent("test model content 2")
 	modelFile2 := filepath.Join(tempDir, "test-model2.gguf")
 	if err := os.WriteFile(modelFile2, modelContent2, 0644); err != nil {
 		t.Fatalf("Failed to write test model file: %v", err)
@@ -886,6 +1022,181 @@ func TestPush(t *testing.T) {
 	}
 }
 
+func TestPushModelIndex(t *testing.T) {
+	tempDir := t.TempDir()
+
+	client, err := newTestClient(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	tag := uri.Host + "/index-test/model:latest"
+
+	cpuModel, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	gpuModel := mutate.ContextSize(cpuModel, 8192)
+	if err := client.store.Write(cpuModel, []string{"index-test/model:cpu"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := client.store.Write(gpuModel, []string{"index-test/model:gpu"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	variants := []IndexVariant{
+		{Platform: oci.Platform{OS: "linux", Architecture: "amd64"}, Model: "index-test/model:cpu"},
+		{Platform: oci.Platform{OS: "linux", Architecture: "amd64", Variant: "cuda"}, Model: "index-test/model:gpu"},
+	}
+	if err := client.PushModelIndex(t.Context(), tag, variants, nil); err != nil {
+		t.Fatalf("Failed to push model index: %v", err)
+	}
+
+	resp, err := http.Get(server.URL + "/v2/index-test/model/manifests/latest")
+	if err != nil {
+		t.Fatalf("Failed to fetch index: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != string(oci.OCIImageIndex) {
+		t.Errorf("Expected index media type, got %q", ct)
+	}
+	var index oci.IndexManifest
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		t.Fatalf("Failed to decode index: %v", err)
+	}
+	if len(index.Manifests) != 2 {
+		t.Fatalf("Expected 2 manifests in index, got %d", len(index.Manifests))
+	}
+	for i, desc := range index.Manifests {
+		if desc.Platform == nil || desc.Platform.String() != variants[i].Platform.String() {
+			t.Errorf("Manifest %d: expected platform %s, got %+v", i, variants[i].Platform.String(), desc.Platform)
+		}
+		child, err := http.Get(server.URL + "/v2/index-test/model/manifests/" + desc.Digest.String())
+		if err != nil {
+			t.Fatalf("Failed to fetch child manifest: %v", err)
+		}
+		child.Body.Close()
+		if child.StatusCode != http.StatusOK {
+			t.Errorf("Manifest %d: expected child %s to be pushed, got status %d", i, desc.Digest, child.StatusCode)
+		}
+	}
+
+	// Duplicate platforms are rejected
+	variants[1].Platform = variants[0].Platform
+	if err := client.PushModelIndex(t.Context(), tag, variants, nil); err == nil {
+		t.Error("Expected error for duplicate platforms")
+	}
+}
+
+func TestPullModelVariant(t *testing.T) {
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	indexTag := uri.Host + "/variant-test/model:latest"
+	plainTag := uri.Host + "/variant-test/model:plain"
+
+	pusher, err := newTestClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	cpuModel, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	gpuModel := mutate.ContextSize(cpuModel, 8192)
+	if err := pusher.store.Write(cpuModel, []string{plainTag}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := pusher.store.Write(gpuModel, []string{"variant-test/model:gpu"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := pusher.PushModel(t.Context(), plainTag, nil); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	cudaPlatform := oci.Platform{OS: "linux", Architecture: "amd64", Variant: "cuda"}
+	if err := pusher.PushModelIndex(t.Context(), indexTag, []IndexVariant{
+		{Platform: oci.Platform{OS: "linux", Architecture: "amd64"}, Model: plainTag},
+		{Platform: cudaPlatform, Model: "variant-test/model:gpu"},
+	}, nil); err != nil {
+		t.Fatalf("Failed to push model index: %v", err)
+	}
+	gpuID, err := gpuModel.ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+
+	t.Run("selects requested variant", func(t *testing.T) {
+		client, err := newTestClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), indexTag, nil, WithVariant(cudaPlatform)); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		mdl, err := client.GetModel(indexTag)
+		if err != nil {
+			t.Fatalf("Failed to get pulled model: %v", err)
+		}
+		if id, _ := mdl.ID(); id != gpuID {
+			t.Errorf("Expected the cuda variant %s, got %s", gpuID, id)
+		}
+	})
+
+	t.Run("unknown variant", func(t *testing.T) {
+		client, err := newTestClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		err = client.PullModel(t.Context(), indexTag, nil, WithVariant(oci.Platform{OS: "linux", Architecture: "arm64"}))
+		var variantErr *ErrVariantNotFound
+		if !errors.As(err, &variantErr) {
+			t.Fatalf("Expected ErrVariantNotFound, got: %v", err)
+		}
+		if len(variantErr.Available) != 2 || variantErr.Available[1] != cudaPlatform.String() {
+			t.Errorf("Expected available variants to be listed, got %v", variantErr.Available)
+		}
+	})
+
+	t.Run("ignored for single image", func(t *testing.T) {
+		client, err := newTestClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.PullModel(t.Context(), plainTag, nil, WithVariant(cudaPlatform)); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+	})
+
+	t.Run("expected index or variant digest", func(t *testing.T) {
+		client, err := newTestClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		remoteModel, err := client.registry.Model(t.Context(), indexTag)
+		if err != nil {
+			t.Fatalf("Failed to read model index: %v", err)
+		}
+		indexDigest, ok := mdregistry.IndexDigest(remoteModel)
+		if !ok {
+			t.Fatal("Expected the model to be selected from an index")
+		}
+		for _, expected := range []string{indexDigest.String(), gpuID} {
+			if err := client.PullModel(t.Context(), indexTag, nil, WithVariant(cudaPlatform), WithExpectedDigest(expected)); err != nil {
+				t.Errorf("Failed to pull model with expected digest %s: %v", expected, err)
+			}
+		}
+	})
+}
+
 func TestPushProgress(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -971,6 +1282,202 @@ func TestPushProgress(t *testing.T) {
 	}
 }
 
+// interruptingRegistry wraps a registry, interrupting chunked uploads after
+// the registry has received half of a chunk while interrupt is set.
+type interruptingRegistry struct {
+	http.Handler
+	mu        sync.Mutex
+	interrupt bool
+	// patched counts the bytes received in chunks.
+	patched int64
+}
+
+func (r *interruptingRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	interrupt := r.interrupt
+	r.mu.Unlock()
+	switch {
+	case req.Method == http.MethodPatch:
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if interrupt {
+			body = body[:len(body)/2]
+		}
+		r.mu.Lock()
+		r.patched += int64(len(body))
+		r.mu.Unlock()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if !interrupt {
+			r.Handler.ServeHTTP(w, req)
+			return
+		}
+		r.Handler.ServeHTTP(httptest.NewRecorder(), req)
+		http.Error(w, "connection lost", http.StatusBadGateway)
+	case req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/blobs/uploads/") && interrupt:
+		http.Error(w, "connection lost", http.StatusBadGateway)
+	default:
+		r.Handler.ServeHTTP(w, req)
+	}
+}
+
+func (r *interruptingRegistry) patchedBytes() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.patched
+}
+
+func TestPushResume(t *testing.T) {
+	client, err := newTestClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	registry := &interruptingRegistry{Handler: testregistry.New(testregistry.WithChunkedUploads(1024)), interrupt: true}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	tag := uri.Host + "/resume/model:latest"
+
+	sz := int64(progress.MinBytesForUpdate)
+	path, err := randomFile(sz)
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(path)
+	mdl, err := gguf.NewModel(path)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(mdl, []string{tag}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	// layerProgress pushes the model, returning the last progress reported
+	// for its layer.
+	layerProgress := func() (oci.ProgressLayer, error) {
+		var out bytes.Buffer
+		err := client.PushModel(t.Context(), tag, &out)
+		var last oci.ProgressLayer
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			var msg oci.ProgressMessage
+			if json.Unmarshal([]byte(line), &msg) == nil && msg.Type == oci.TypeProgress {
+				last = msg.Layer
+			}
+		}
+		return last, err
+	}
+
+	if _, err := layerProgress(); err == nil {
+		t.Fatal("Expected the interrupted push to fail")
+	}
+	if registry.patchedBytes() != sz/2 {
+		t.Fatalf("Expected the registry to have received %d bytes, got %d", sz/2, registry.patchedBytes())
+	}
+
+	// Pushing again resumes the upload where it stopped.
+	registry.mu.Lock()
+	registry.interrupt = false
+	registry.mu.Unlock()
+	layer, err := layerProgress()
+	if err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	if registry.patchedBytes() != sz {
+		t.Fatalf("Expected %d bytes to be uploaded in total, got %d", sz, registry.patchedBytes())
+	}
+	if layer.Current != uint64(sz) || layer.Skipped != uint64(sz/2) {
+		t.Fatalf("Expected progress of %d bytes with %d skipped, got %+v", sz, sz/2, layer)
+	}
+
+	// Pushing once more skips the layer the registry has.
+	layer, err = layerProgress()
+	if err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	if registry.patchedBytes() != sz {
+		t.Fatalf("Expected no more bytes to be uploaded, got %d in total", registry.patchedBytes())
+	}
+	if layer.Skipped != uint64(sz) {
+		t.Fatalf("Expected the whole layer to be skipped, got %+v", layer)
+	}
+
+	// The pushed model is intact.
+	if _, err := client.DeleteModel(tag, false); err != nil {
+		t.Fatalf("Failed to delete model: %v", err)
+	}
+	if err := client.PullModel(t.Context(), tag, nil); err != nil {
+		t.Fatalf("Failed to pull model: %v", err)
+	}
+}
+
+// stallingRegistry wraps a registry, accepting every chunk of an upload but
+// acknowledging only the first received bytes of the blob.
+type stallingRegistry struct {
+	http.Handler
+	received int64
+	// patches counts the chunks sent.
+	patches atomic.Int64
+}
+
+func (r *stallingRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPatch {
+		r.Handler.ServeHTTP(w, req)
+		return
+	}
+	r.patches.Add(1)
+	_, _ = io.Copy(io.Discard, req.Body)
+	w.Header().Set("Location", req.URL.Path)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", r.received-1))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func TestPushFailsWhenRegistryStalls(t *testing.T) {
+	client, err := newTestClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	registry := &stallingRegistry{Handler: testregistry.New(testregistry.WithChunkedUploads(1024)), received: 100}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	tag := uri.Host + "/stall/model:latest"
+
+	mdl, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(mdl, []string{tag}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 30*time.Second)
+	defer cancel()
+	err = client.PushModel(ctx, tag, io.Discard)
+	if err == nil {
+		t.Fatal("Expected the stalled push to fail")
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("Expected the push to give up before timing out, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "registry received none of the last") {
+		t.Errorf("Expected a stalled upload error, got %v", err)
+	}
+	// One chunk advances the upload, then the stalled ones are retried.
+	if patches := registry.patches.Load(); patches > 1+5 {
+		t.Errorf("Expected at most 6 chunks to be sent, got %d", patches)
+	}
+}
+
 func TestTag(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -999,12 +1506,12 @@ func TestTag(t *testing.T) {
 	}
 
 	// Tag the model by ID
-	if err := client.Tag(id, "other-repo:tag1"); err != nil {
+	if _, err := client.Tag(id, "other-repo:tag1", false); err != nil {
 		t.Fatalf("Failed to tag model %q: %v", id, err)
 	}
 
 	// Tag the model by tag
-	if err := client.Tag(id, "other-repo:tag2"); err != nil {
+	if _, err := client.Tag("some-repo:some-tag", "other-repo:tag2", false); err != nil {
 		t.Fatalf("Failed to tag model %q: %v", id, err)
 	}
 
@@ -1037,11 +1544,149 @@ func TestTagNotFound(t *testing.T) {
 	}
 
 	// Tag the model by ID
-	if err := client.Tag("non-existent-model:latest", "other-repo:tag1"); !errors.Is(err, ErrModelNotFound) {
+	if _, err := client.Tag("non-existent-model:latest", "other-repo:tag1", false); !errors.Is(err, ErrModelNotFound) {
 		t.Fatalf("Expected ErrModelNotFound, got: %v", err)
 	}
 }
 
+func TestTagExisting(t *testing.T) {
+	client, err := newTestClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	model, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	other := mutate.ContextSize(model, 8192)
+	if err := client.store.Write(model, []string{client.normalizeModelName("first:latest")}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := client.store.Write(other, []string{client.normalizeModelName("second:latest")}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	otherID, err := other.ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+
+	t.Run("same model is a no-op", func(t *testing.T) {
+		created, err := client.Tag("first:latest", "first:latest", false)
+		if err != nil {
+			t.Fatalf("Expected re-tagging to succeed, got: %v", err)
+		}
+		if created {
+			t.Error("Expected re-tagging to report no new tag")
+		}
+		mdl, err := client.GetModel("first:latest")
+		if err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+		if len(mdl.Tags()) != 1 {
+			t.Errorf("Expected 1 tag, got %v", mdl.Tags())
+		}
+	})
+
+	t.Run("different model conflicts", func(t *testing.T) {
+		if _, err := client.Tag("second:latest", "first:latest", false); !errors.Is(err, ErrConflict) {
+			t.Fatalf("Expected ErrConflict, got: %v", err)
+		}
+		mdl, err := client.GetModel("first:latest")
+		if err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+		if id, _ := mdl.ID(); id == otherID {
+			t.Error("Expected the conflicting tag not to move")
+		}
+	})
+
+	t.Run("force moves the tag", func(t *testing.T) {
+		created, err := client.Tag("second:latest", "first:latest", true)
+		if err != nil {
+			t.Fatalf("Expected forced tagging to succeed, got: %v", err)
+		}
+		if !created {
+			t.Error("Expected forced tagging to report a new tag")
+		}
+		mdl, err := client.GetModel("first:latest")
+		if err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+		if id, _ := mdl.ID(); id != otherID {
+			t.Errorf("Expected tag to refer to %s, got %s", otherID, id)
+		}
+	})
+}
+
+func TestRepackageModelRequantize(t *testing.T) {
+	tempDir := t.TempDir()
+
+	client, err := newTestClient(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	model, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(model, []string{"test/model:f16"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	quantization := "Q4_K_M"
+	var gotQuantization string
+	quantizer := func(_ context.Context, src, dst, q string, log io.Writer) error {
+		gotQuantization = q
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(log, "quantizing tensors")
+		return os.WriteFile(dst, append(data, 0), 0o644)
+	}
+
+	t.Run("unsupported without quantizer", func(t *testing.T) {
+		err := client.RepackageModel(t.Context(), "test/model:f16", "test/model:q4", RepackageOptions{
+			Quantization: &quantization,
+		})
+		if !errors.Is(err, ErrRequantizationUnsupported) {
+			t.Fatalf("Expected ErrRequantizationUnsupported, got: %v", err)
+		}
+	})
+
+	t.Run("requantizes weights", func(t *testing.T) {
+		var pw bytes.Buffer
+		err := client.RepackageModel(t.Context(), "test/model:f16", "test/model:q4", RepackageOptions{
+			Quantization:   &quantization,
+			Quantizer:      quantizer,
+			ProgressWriter: &pw,
+		})
+		if err != nil {
+			t.Fatalf("Failed to repackage model: %v", err)
+		}
+		if gotQuantization != quantization {
+			t.Errorf("Expected quantizer to receive %q, got %q", quantization, gotQuantization)
+		}
+		if !strings.Contains(pw.String(), "quantizing tensors") {
+			t.Errorf("Expected quantizer output in progress stream, got %q", pw.String())
+		}
+
+		source, err := client.GetModel("test/model:f16")
+		if err != nil {
+			t.Fatalf("Failed to get source model: %v", err)
+		}
+		target, err := client.GetModel("test/model:q4")
+		if err != nil {
+			t.Fatalf("Failed to get requantized model: %v", err)
+		}
+		sourceID, _ := source.ID()
+		targetID, _ := target.ID()
+		if sourceID == targetID {
+			t.Errorf("Expected requantized model to have a new ID, got %s for both", sourceID)
+		}
+	})
+}
+
 func TestClientPushModelNotFound(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -1102,6 +1747,59 @@ func TestIsModelInStoreFound(t *testing.T) {
 	}
 }
 
+func TestAliases(t *testing.T) {
+	tempDir := t.TempDir()
+
+	client, err := newTestClient(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	model, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(model, []string{client.normalizeModelName("llama3.2:latest")}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	// Alias targets must exist in the store
+	if err := client.SetAlias("missing", "non-existent-model"); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("Expected ErrModelNotFound, got: %v", err)
+	}
+
+	if err := client.SetAlias("llama", "llama3.2"); err != nil {
+		t.Fatalf("Failed to set alias: %v", err)
+	}
+	aliases, err := client.ListAliases()
+	if err != nil {
+		t.Fatalf("Failed to list aliases: %v", err)
+	}
+	if aliases["llama"] != "ai/llama3.2:latest" {
+		t.Fatalf("Expected alias to resolve to ai/llama3.2:latest, got %q", aliases["llama"])
+	}
+
+	// The alias is consulted before normalization defaults apply
+	if _, err := client.GetModel("llama"); err != nil {
+		t.Fatalf("Failed to get model by alias: %v", err)
+	}
+
+	// An alias whose target no longer exists falls back to normalization
+	if _, err := client.DeleteModel("llama3.2", true); err != nil {
+		t.Fatalf("Failed to delete model: %v", err)
+	}
+	if _, err := client.GetModel("llama"); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("Expected ErrModelNotFound for dangling alias, got: %v", err)
+	}
+
+	if err := client.RemoveAlias("llama"); err != nil {
+		t.Fatalf("Failed to remove alias: %v", err)
+	}
+	if err := client.RemoveAlias("llama"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("Expected ErrAliasNotFound, got: %v", err)
+	}
+}
+
 // writeToRegistry writes a GGUF model to a registry.
 func writeToRegistry(source, refStr string, opts ...remote.Option) error {
 
@@ -1133,8 +1831,12 @@ func randomFile(size int64) (string, error) {
 	}
 	defer f.Close()
 
-	// Fill with random data
-	if _, err := io.Copy(f, io.LimitReader(rand.Reader, size)); err != nil {
+	// Fill with random data after a minimal GGUF header
+	header := ggufContent("")
+	if _, err := f.Write(header); err != nil {
+		return "", fmt.Errorf("Failed to write GGUF header: %w", err)
+	}
+	if _, err := io.Copy(f, io.LimitReader(rand.Reader, size-int64(len(header)))); err != nil {
 		return "", fmt.Errorf("Failed to write random data: %w", err)
 	}
 
@@ -1281,3 +1983,428 @@ func TestPullHuggingFaceModelFromCache(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckConfigMediaType(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType oci.MediaType
+		wantErr   bool
+		wantNewer bool
+	}{
+		{
+			name:      "supported v0.1",
+			mediaType: "application/vnd.docker.ai.model.config.v0.1+json",
+		},
+		{
+			name:      "supported v0.2",
+			mediaType: "application/vnd.docker.ai.model.config.v0.2+json",
+		},
+		{
+			name:      "newer minor version",
+			mediaType: "application/vnd.docker.ai.model.config.v0.3+json",
+			wantErr:   true,
+			wantNewer: true,
+		},
+		{
+			name:      "newer major version",
+			mediaType: "application/vnd.docker.ai.model.config.v1.0+json",
+			wantErr:   true,
+			wantNewer: true,
+		},
+		{
+			name:      "not a model config",
+			mediaType: "application/vnd.oci.image.config.v1+json",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkConfigMediaType(tt.mediaType)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnsupportedMediaType) {
+				t.Fatalf("Expected ErrUnsupportedMediaType, got %v", err)
+			}
+			var newerErr *ErrNewerModelFormat
+			if got := errors.As(err, &newerErr); got != tt.wantNewer {
+				t.Fatalf("errors.As(ErrNewerModelFormat) = %v, want %v (err: %v)", got, tt.wantNewer, err)
+			}
+			if tt.wantNewer && newerErr.Got != tt.mediaType {
+				t.Errorf("Expected Got %q, got %q", tt.mediaType, newerErr.Got)
+			}
+		})
+	}
+}
+
+// ggufContent returns a minimal GGUF file (header with no tensors or
+// metadata) followed by payload, which keeps blobs unique per test.
+func ggufContent(payload string) []byte {
+	header := []byte("GGUF\x03\x00\x00\x00" + strings.Repeat("\x00", 16))
+	return append(header, payload...)
+}
+
+func TestPullModelMirrors(t *testing.T) {
+	modelContent, err := os.ReadFile(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to read test model file: %v", err)
+	}
+	model, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	// newRegistry starts a registry holding the test model at testmodel:v1,
+	// serving requests through wrap.
+	newRegistry := func(t *testing.T, wrap func(http.Handler) http.Handler) string {
+		registry := testregistry.New()
+		server := httptest.NewServer(wrap(registry))
+		t.Cleanup(server.Close)
+		uri, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to parse registry URL: %v", err)
+		}
+		ref, err := reference.ParseReference(uri.Host + "/testmodel:v1")
+		if err != nil {
+			t.Fatalf("Failed to parse reference: %v", err)
+		}
+		if err := remote.Write(ref, model, nil, remote.WithPlainHTTP(true)); err != nil {
+			t.Fatalf("Failed to push model: %v", err)
+		}
+		return uri.Host
+	}
+	layers, err := model.Layers()
+	if err != nil {
+		t.Fatalf("Failed to get layers: %v", err)
+	}
+	layerDigest, err := layers[0].Digest()
+	if err != nil {
+		t.Fatalf("Failed to get layer digest: %v", err)
+	}
+	modelDigest, err := model.Digest()
+	if err != nil {
+		t.Fatalf("Failed to get model digest: %v", err)
+	}
+	passthrough := func(h http.Handler) http.Handler { return h }
+	// failing fails GET requests for paths containing part.
+	failing := func(part string) func(http.Handler) http.Handler {
+		return func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && strings.Contains(r.URL.Path, part) {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				h.ServeHTTP(w, r)
+			})
+		}
+	}
+	newMirroredClient := func(t *testing.T, mirrors ...string) *Client {
+		client, err := NewClient(
+			WithStoreRootPath(t.TempDir()),
+			WithRegistryClient(mdregistry.NewClient(mdregistry.WithPlainHTTP(true))),
+			WithMirrors(mirrors),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client
+	}
+	checkPulled := func(t *testing.T, client *Client, tag string) {
+		t.Helper()
+		mdl, err := client.GetModel(tag)
+		if err != nil {
+			t.Fatalf("Failed to get model: %v", err)
+		}
+		paths, err := mdl.GGUFPaths()
+		if err != nil || len(paths) != 1 {
+			t.Fatalf("Unexpected model paths %v: %v", paths, err)
+		}
+		if pulled, err := os.ReadFile(paths[0]); err != nil || !bytes.Equal(pulled, modelContent) {
+			t.Errorf("Pulled model content doesn't match original: %v", err)
+		}
+	}
+
+	t.Run("primary unavailable", func(t *testing.T) {
+		primary := newRegistry(t, failing("/v2/"))
+		mirror := newRegistry(t, passthrough)
+		client := newMirroredClient(t, "127.0.0.1:1", mirror)
+		tag := primary + "/testmodel:v1"
+		if err := client.PullModel(t.Context(), tag, nil, WithExpectedDigest(modelDigest.String())); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		checkPulled(t, client, tag)
+	})
+
+	t.Run("primary unavailable without digest", func(t *testing.T) {
+		var mirrorRequests atomic.Int32
+		mirror := newRegistry(t, func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					mirrorRequests.Add(1)
+				}
+				h.ServeHTTP(w, r)
+			})
+		})
+		client := newMirroredClient(t, mirror)
+		// The primary registry can't even resolve the tag to a digest.
+		tag := "127.0.0.1:1/testmodel:v1"
+		if err := client.PullModel(t.Context(), tag, nil); err == nil {
+			t.Fatal("Expected the pull to fail without a known manifest digest")
+		}
+		if n := mirrorRequests.Load(); n != 0 {
+			t.Errorf("Expected the mirror not to be asked to resolve the tag, got %d requests", n)
+		}
+	})
+
+	t.Run("mirror serves another manifest", func(t *testing.T) {
+		primary := newRegistry(t, failing("/v2/"))
+		mirror := newRegistry(t, passthrough)
+		client := newMirroredClient(t, mirror)
+		tag := primary + "/testmodel:v1"
+		other := oci.Hash{Algorithm: "sha256", Hex: strings.Repeat("0", 64)}
+		if err := client.PullModel(t.Context(), tag, nil, WithExpectedDigest(other.String())); err == nil {
+			t.Fatal("Expected the pull to fail when the mirror lacks the expected manifest")
+		}
+		if _, err := client.GetModel(tag); !errors.Is(err, ErrModelNotFound) {
+			t.Errorf("Expected no model to be stored, got: %v", err)
+		}
+	})
+
+	t.Run("blob fetch fails", func(t *testing.T) {
+		primary := newRegistry(t, failing("/blobs/"+layerDigest.String()))
+		mirror := newRegistry(t, passthrough)
+		client := newMirroredClient(t, mirror)
+		tag := primary + "/testmodel:v1"
+		if err := client.PullModel(t.Context(), tag, nil); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+		checkPulled(t, client, tag)
+	})
+
+	t.Run("tampered blob", func(t *testing.T) {
+		primary := newRegistry(t, failing("/blobs/"+layerDigest.String()))
+		mirror := newRegistry(t, func(h http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, "/blobs/"+layerDigest.String()) {
+					h.ServeHTTP(w, r)
+					return
+				}
+				tampered := bytes.Clone(modelContent)
+				tampered[len(tampered)-1] ^= 0xff
+				w.Header().Set("Content-Length", fmt.Sprint(len(tampered)))
+				_, _ = w.Write(tampered)
+			})
+		})
+		client := newMirroredClient(t, mirror)
+		tag := primary + "/testmodel:v1"
+		if err := client.PullModel(t.Context(), tag, nil); !errors.Is(err, ErrBlobDigestMismatch) {
+			t.Fatalf("Expected ErrBlobDigestMismatch, got: %v", err)
+		}
+		if _, err := client.GetModel(tag); !errors.Is(err, ErrModelNotFound) {
+			t.Errorf("Expected the tampered model not to be stored, got: %v", err)
+		}
+	})
+
+	t.Run("model not found is not retried", func(t *testing.T) {
+		primary := httptest.NewServer(testregistry.New())
+		defer primary.Close()
+		uri, err := url.Parse(primary.URL)
+		if err != nil {
+			t.Fatalf("Failed to parse registry URL: %v", err)
+		}
+		mirror := newRegistry(t, passthrough)
+		client := newMirroredClient(t, mirror)
+		if err := client.PullModel(t.Context(), uri.Host+"/testmodel:v1", nil); !errors.Is(err, mdregistry.ErrModelNotFound) {
+			t.Fatalf("Expected ErrModelNotFound, got: %v", err)
+		}
+	})
+}
+
+func TestPullModelByDigest(t *testing.T) {
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	model, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	ref, err := reference.ParseReference(uri.Host + "/testmodel:v1")
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+	if err := remote.Write(ref, model, nil, remote.WithPlainHTTP(true)); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	id, err := model.ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+	digestRef := uri.Host + "/testmodel@" + id
+
+	client, err := newTestClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.PullModel(t.Context(), digestRef, nil); err != nil {
+		t.Fatalf("Failed to pull model by digest: %v", err)
+	}
+	mdl, err := client.GetModel(digestRef)
+	if err != nil {
+		t.Fatalf("Failed to get model by digest: %v", err)
+	}
+	if pulledID, _ := mdl.ID(); pulledID != id {
+		t.Errorf("Expected model %s, got %s", id, pulledID)
+	}
+	if tags := mdl.Tags(); len(tags) != 0 {
+		t.Errorf("Expected no tags for a model pulled by digest, got %v", tags)
+	}
+
+	missing := uri.Host + "/testmodel@sha256:" + strings.Repeat("0", 64)
+	if err := client.PullModel(t.Context(), missing, nil); !errors.Is(err, mdregistry.ErrModelNotFound) {
+		t.Errorf("Expected ErrModelNotFound for an unknown digest, got %v", err)
+	}
+}
+
+func TestPullModelExpectedDigest(t *testing.T) {
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	model, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	tag := uri.Host + "/testmodel:v1"
+	ref, err := reference.ParseReference(tag)
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+	if err := remote.Write(ref, model, nil, remote.WithPlainHTTP(true)); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+	id, err := model.ID()
+	if err != nil {
+		t.Fatalf("Failed to get model ID: %v", err)
+	}
+
+	client, err := newTestClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	wrong := "sha256:" + strings.Repeat("0", 64)
+	if err := client.PullModel(t.Context(), tag, nil, WithExpectedDigest(wrong)); !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("Expected ErrDigestMismatch, got %v", err)
+	}
+	if _, err := client.GetModel(tag); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("Expected nothing to be stored after a digest mismatch, got %v", err)
+	}
+	if err := client.PullModel(t.Context(), tag, nil, WithExpectedDigest("sha256:bogus")); err == nil || errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("Expected an invalid digest error, got %v", err)
+	}
+	if err := client.PullModel(t.Context(), "hf.co/org/model", nil, WithExpectedDigest(id)); !errors.Is(err, ErrDigestUnsupported) {
+		t.Errorf("Expected ErrDigestUnsupported for a Hugging Face model, got %v", err)
+	}
+
+	if err := client.PullModel(t.Context(), tag, nil, WithExpectedDigest(id)); err != nil {
+		t.Fatalf("Failed to pull model with its digest: %v", err)
+	}
+	if _, err := client.GetModel(tag); err != nil {
+		t.Errorf("Failed to get pulled model: %v", err)
+	}
+}
+
+// rateLimitingRegistry wraps a registry, rate limiting its first limited
+// requests with the given Retry-After header.
+type rateLimitingRegistry struct {
+	http.Handler
+	retryAfter string
+	mu         sync.Mutex
+	limited    int
+}
+
+func (r *rateLimitingRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	limit := r.limited > 0
+	if limit {
+		r.limited--
+	}
+	r.mu.Unlock()
+	if limit {
+		w.Header().Set("Retry-After", r.retryAfter)
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+	r.Handler.ServeHTTP(w, req)
+}
+
+// newRateLimitedTestClient returns a test client whose registry requests
+// wait for rate limits to clear, for up to a minute.
+func newRateLimitedTestClient(storeRootPath string) (*Client, error) {
+	transport := mdregistry.NewRateLimitTransport(http.DefaultTransport, time.Minute)
+	return NewClient(
+		WithStoreRootPath(storeRootPath),
+		WithRegistryClient(mdregistry.NewClient(mdregistry.WithPlainHTTP(true), mdregistry.WithTransport(transport))),
+	)
+}
+
+func TestPullModelRateLimited(t *testing.T) {
+	reg := &rateLimitingRegistry{Handler: testregistry.New()}
+	server := httptest.NewServer(reg)
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+	model, err := gguf.NewModel(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	tag := uri.Host + "/testmodel:v1"
+	ref, err := reference.ParseReference(tag)
+	if err != nil {
+		t.Fatalf("Failed to parse reference: %v", err)
+	}
+	if err := remote.Write(ref, model, nil, remote.WithPlainHTTP(true)); err != nil {
+		t.Fatalf("Failed to push model: %v", err)
+	}
+
+	t.Run("retried", func(t *testing.T) {
+		reg.mu.Lock()
+		reg.limited, reg.retryAfter = 2, "0"
+		reg.mu.Unlock()
+		client, err := newRateLimitedTestClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		var progress bytes.Buffer
+		if err := client.PullModel(t.Context(), tag, &progress); err != nil {
+			t.Fatalf("Failed to pull rate limited model: %v", err)
+		}
+		if got := strings.Count(progress.String(), "Rate limited by the registry, retrying in 0s"); got != 2 {
+			t.Errorf("Expected 2 rate limit messages, got %d in %q", got, progress.String())
+		}
+	})
+
+	t.Run("wait too long", func(t *testing.T) {
+		reg.mu.Lock()
+		reg.limited, reg.retryAfter = 1, "3600"
+		reg.mu.Unlock()
+		client, err := newRateLimitedTestClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		err = client.PullModel(t.Context(), tag, nil)
+		var rateLimited *mdregistry.RateLimitError
+		if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != time.Hour {
+			t.Fatalf("Expected a RateLimitError asking to retry after an hour, got %v", err)
+		}
+	})
+}
@@ -3,7 +3,9 @@ package distribution
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/builder"
 )
@@ -112,7 +114,7 @@ func TestDeleteModel(t *testing.T) {
 				t.Fatalf("Failed to write model to store: %v", err)
 			}
 			for _, tag := range tc.tags {
-				if err := client.Tag(id, tag); err != nil {
+				if _, err := client.Tag(id, tag, false); err != nil {
 					t.Fatalf("Failed to tag model: %v", err)
 				}
 			}
@@ -151,3 +153,168 @@ func TestDeleteModel(t *testing.T) {
 		})
 	}
 }
+
+func TestPrune(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	write := func(path string, tags []string, pulled time.Time) string {
+		b, err := builder.FromPath(path)
+		if err != nil {
+			t.Fatalf("Failed to create model: %v", err)
+		}
+		id, err := b.Model().ID()
+		if err != nil {
+			t.Fatalf("Failed to get model ID: %v", err)
+		}
+		if err := client.store.Write(b.Model(), tags, nil); err != nil {
+			t.Fatalf("Failed to write model to store: %v", err)
+		}
+		if err := client.store.MarkPulled(id, pulled); err != nil {
+			t.Fatalf("Failed to mark model pulled: %v", err)
+		}
+		return id
+	}
+	dangling := write(testGGUFFile, nil, time.Now())
+	stale := write(filepath.Join("..", "assets", "dummy-00001-of-00002.gguf"), []string{"stale-model"}, time.Now().Add(-60*24*time.Hour))
+
+	// A dry run lists the dangling model, which is pruned by default.
+	response, err := client.Prune(PruneOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if len(response.Models) != 1 || response.Models[0].ID != dangling || response.Reclaimed == 0 || !response.DryRun {
+		t.Fatalf("Expected the dangling model to be listed, got %+v", response)
+	}
+	if _, err := client.GetModel(dangling); err != nil {
+		t.Fatalf("Expected a dry run to keep the model: %v", err)
+	}
+
+	// Only the model unused for longer than the cutoff is pruned.
+	response, err = client.Prune(PruneOptions{Until: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	if len(response.Models) != 1 || response.Models[0].ID != stale || response.DryRun {
+		t.Fatalf("Expected the stale model to be pruned, got %+v", response)
+	}
+	if _, err := client.GetModel("stale-model"); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("Expected the stale model to be removed, got: %v", err)
+	}
+	if _, err := client.GetModel(dangling); err != nil {
+		t.Errorf("Expected the recent model to be kept: %v", err)
+	}
+
+	// Models must have every label filtered on.
+	response, err = client.Prune(PruneOptions{Labels: []string{"org.example.missing"}})
+	if err != nil || len(response.Models) != 0 {
+		t.Errorf("Expected no models to match the label filter, got %+v, %v", response, err)
+	}
+}
+
+func TestArchiveModel(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	b, err := builder.FromPath(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := client.store.Write(b.Model(), []string{"archived-model"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+
+	response, err := client.ArchiveModel("archived-model")
+	if err != nil {
+		t.Fatalf("Failed to archive model: %v", err)
+	}
+	if response.Reclaimed == 0 {
+		t.Errorf("Expected archiving to reclaim space")
+	}
+
+	// The model is still listed, but can't be used.
+	mdl, err := client.GetModel("archived-model")
+	if err != nil {
+		t.Fatalf("Expected the archived model to be listed: %v", err)
+	}
+	if !mdl.Archived() {
+		t.Errorf("Expected the model to be marked archived")
+	}
+	if _, err := mdl.Config(); err != nil {
+		t.Errorf("Expected the archived model's config to be kept: %v", err)
+	}
+	if _, err := client.GetBundle("archived-model"); !errors.Is(err, ErrModelArchived) {
+		t.Errorf("Expected ErrModelArchived for a bundle, got: %v", err)
+	}
+	if err := client.UnarchiveModel("archived-model"); !errors.Is(err, ErrModelArchived) {
+		t.Errorf("Expected unarchiving without the weights to fail, got: %v", err)
+	}
+
+	// Writing the model again restores it.
+	if err := client.store.Write(b.Model(), []string{"archived-model"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if mdl, err := client.GetModel("archived-model"); err != nil || mdl.Archived() {
+		t.Fatalf("Expected the model to be restored, got archived %v, %v", mdl != nil && mdl.Archived(), err)
+	}
+	if _, err := client.GetBundle("archived-model"); err != nil {
+		t.Errorf("Expected a bundle for the restored model: %v", err)
+	}
+	if err := client.UnarchiveModel("archived-model"); err != nil {
+		t.Errorf("Expected unarchiving a restored model to be a no-op: %v", err)
+	}
+}
+
+func TestDeleteFreesBlobsOfArchivedModel(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	b, err := builder.FromPath(testGGUFFile)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	licensed, err := b.WithLicense(filepath.Join("..", "assets", "license.txt"))
+	if err != nil {
+		t.Fatalf("Failed to add license: %v", err)
+	}
+	if err := client.store.Write(b.Model(), []string{"archived-model"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	if err := client.store.Write(licensed.Model(), []string{"licensed-model"}, nil); err != nil {
+		t.Fatalf("Failed to write model to store: %v", err)
+	}
+	layers, err := b.Model().Layers()
+	if err != nil {
+		t.Fatalf("Failed to get layers: %v", err)
+	}
+	weights, err := layers[0].Digest()
+	if err != nil {
+		t.Fatalf("Failed to get layer digest: %v", err)
+	}
+
+	// Archiving keeps the weights, which the other model still uses.
+	if _, err := client.ArchiveModel("archived-model"); err != nil {
+		t.Fatalf("Failed to archive model: %v", err)
+	}
+	if has, err := client.store.HasBlob(weights); err != nil || !has {
+		t.Fatalf("Expected the shared weights to be kept, got %v, %v", has, err)
+	}
+
+	// Deleting the other model frees them, as the archived model gave them up.
+	if _, err := client.DeleteModel("licensed-model", false); err != nil {
+		t.Fatalf("Failed to delete model: %v", err)
+	}
+	if has, err := client.store.HasBlob(weights); err != nil || has {
+		t.Errorf("Expected the weights to be freed, got %v, %v", has, err)
+	}
+	mdl, err := client.GetModel("archived-model")
+	if err != nil {
+		t.Fatalf("Expected the archived model to be listed: %v", err)
+	}
+	if _, err := mdl.Config(); err != nil {
+		t.Errorf("Expected the archived model's config to be kept: %v", err)
+	}
+}
@@ -3,8 +3,10 @@ package distribution
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/docker/model-runner/pkg/distribution/internal/store"
+	"github.com/docker/model-runner/pkg/distribution/oci/remote"
 	"github.com/docker/model-runner/pkg/distribution/registry"
 	"github.com/docker/model-runner/pkg/distribution/types"
 )
@@ -14,9 +16,63 @@ var (
 	ErrModelNotFound        = store.ErrModelNotFound // model not found in store
 	ErrUnsupportedMediaType = fmt.Errorf(
 		"client supports only models of type %q and older - try upgrading",
-		types.MediaTypeModelConfigV01,
+		types.MediaTypeModelConfigV02,
 	)
-	ErrConflict = errors.New("resource conflict")
+	ErrConflict           = errors.New("resource conflict")
+	ErrBlobDigestMismatch = store.ErrBlobDigestMismatch // uploaded blob does not hash to its digest
+	ErrAliasNotFound      = store.ErrAliasNotFound      // alias not in alias table
+	ErrNotAModel          = store.ErrNotAModel          // weight layer content does not match its format
+	ErrQuotaExceeded      = store.ErrQuotaExceeded      // model does not fit within the store quota
+	ErrIncompleteModel    = store.ErrIncompleteModel    // model files are missing or truncated on disk
+	ErrModelArchived      = store.ErrModelArchived      // model weights were archived and must be pulled again
+
+	// ErrRequantizationUnsupported is returned when a repackage requests a
+	// quantization change that cannot be performed.
+	ErrRequantizationUnsupported = errors.New("requantization is not supported")
+
+	// ErrModelTooLarge is returned when a pull is refused because the
+	// model's declared size exceeds the configured maximum model size.
+	ErrModelTooLarge = errors.New("model exceeds the maximum model size")
+	// ErrInsufficientSpace is returned when a pull is refused because the
+	// model's declared size exceeds the space available to the store.
+	ErrInsufficientSpace = errors.New("not enough space available for the model")
+	// ErrDigestMismatch is returned when a pull is refused because the
+	// model's manifest doesn't have the digest the caller expected.
+	ErrDigestMismatch = errors.New("model digest does not match the expected digest")
+	// ErrDigestUnsupported is returned when a pull expects a digest from a
+	// source that has no manifest digest to check, such as Hugging Face.
+	ErrDigestUnsupported = errors.New("expected digests are not supported for this source")
 )
 
+// ErrVariantNotFound is returned when a pull requests a variant that the
+// reference's image index does not contain. Available lists the variants it
+// does contain.
+type ErrVariantNotFound = remote.ErrPlatformNotFound
+
+// ErrNewerModelFormat is returned when a model's config media type is a
+// recognized Docker model config type, but a newer version than this client
+// understands. It matches ErrUnsupportedMediaType via errors.Is.
+type ErrNewerModelFormat struct {
+	// Got is the config media type of the model.
+	Got types.MediaType
+	// Supported lists the config media types this client understands.
+	Supported []types.MediaType
+}
+
+func (e *ErrNewerModelFormat) Error() string {
+	supported := make([]string, len(e.Supported))
+	for i, mt := range e.Supported {
+		supported[i] = string(mt)
+	}
+	return fmt.Sprintf(
+		"model config type %q is a newer format than this client supports (supported: %s) - please upgrade Docker Model Runner",
+		e.Got, strings.Join(supported, ", "),
+	)
+}
+
+// Is implements error matching for ErrNewerModelFormat
+func (e *ErrNewerModelFormat) Is(target error) bool {
+	return target == ErrUnsupportedMediaType
+}
+
 const warnUnsupportedFormat = "vLLM backend currently only implemented for x86_64 NVIDIA platforms"
@@ -1,11 +1,18 @@
 package distribution
 
 import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/docker/model-runner/pkg/distribution/builder"
+	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/docker/model-runner/pkg/distribution/tarball"
+	"github.com/docker/model-runner/pkg/distribution/types"
 )
 
 func TestLoadModel(t *testing.T) {
@@ -47,3 +54,108 @@ func TestLoadModel(t *testing.T) {
 		t.Fatalf("Failed to get model: %v", err)
 	}
 }
+
+func TestLoadModelCorruptedBlob(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	intact, _, err := oci.SHA256(strings.NewReader("intact"))
+	if err != nil {
+		t.Fatalf("Failed to hash blob: %v", err)
+	}
+	claimed, _, err := oci.SHA256(strings.NewReader("original"))
+	if err != nil {
+		t.Fatalf("Failed to hash blob: %v", err)
+	}
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, blob := range []struct {
+		diffID  oci.Hash
+		content string
+	}{
+		{intact, "intact"},
+		// The blob's content was tampered with after its diffID was computed.
+		{claimed, "tampered"},
+	} {
+		name := "blobs/" + blob.diffID.Algorithm + "/" + blob.diffID.Hex
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(blob.content))}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(blob.content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+
+	var progressOutput bytes.Buffer
+	_, err = client.LoadModel(&archive, &progressOutput)
+	if !errors.Is(err, ErrBlobDigestMismatch) {
+		t.Fatalf("Expected ErrBlobDigestMismatch, got: %v", err)
+	}
+	for _, diffID := range []oci.Hash{intact, claimed} {
+		if has, err := client.store.HasBlob(diffID); err != nil || has {
+			t.Errorf("Expected blob %s not to be kept after the load failed", diffID)
+		}
+	}
+	if !strings.Contains(progressOutput.String(), intact.String()) {
+		t.Errorf("Expected verification progress for blob %s, got: %s", intact, progressOutput.String())
+	}
+}
+
+func TestLoadModelRejectsNonModelContent(t *testing.T) {
+	client, err := NewClient(WithStoreRootPath(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	const weights, config = "this is just a text file\n", "{}"
+	weightsHash, _, err := oci.SHA256(strings.NewReader(weights))
+	if err != nil {
+		t.Fatalf("Failed to hash blob: %v", err)
+	}
+	configHash, _, err := oci.SHA256(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("Failed to hash blob: %v", err)
+	}
+	manifest := fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,`+
+		`"config":{"mediaType":%q,"size":%d,"digest":%q},`+
+		`"layers":[{"mediaType":%q,"size":%d,"digest":%q}]}`,
+		oci.OCIManifestSchema1,
+		types.MediaTypeModelConfigV01, len(config), configHash,
+		types.MediaTypeGGUF, len(weights), weightsHash)
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, entry := range []struct {
+		name    string
+		content string
+	}{
+		{"blobs/" + weightsHash.Algorithm + "/" + weightsHash.Hex, weights},
+		{"blobs/" + configHash.Algorithm + "/" + configHash.Hex, config},
+		{"manifest.json", manifest},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: entry.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(entry.content))}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+
+	_, err = client.LoadModel(&archive, nil)
+	if !errors.Is(err, ErrNotAModel) {
+		t.Fatalf("Expected ErrNotAModel, got: %v", err)
+	}
+	for _, hash := range []oci.Hash{weightsHash, configHash} {
+		if has, err := client.store.HasBlob(hash); err != nil || has {
+			t.Errorf("Expected blob %s not to be kept after the model was rejected", hash)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package distribution
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/distribution/internal/progress"
+	"github.com/docker/model-runner/pkg/distribution/oci"
+	"github.com/docker/model-runner/pkg/distribution/oci/reference"
+	"github.com/docker/model-runner/pkg/distribution/oci/remote"
+	"github.com/docker/model-runner/pkg/distribution/registry"
+	"github.com/docker/model-runner/pkg/distribution/types"
+	"github.com/docker/model-runner/pkg/internal/utils"
+)
+
+// WithMirrors sets registries that a pull retries against, in order, when
+// the primary registry fails with a retryable error. Each mirror is a
+// registry host, optionally followed by a path prefix, that serves the same
+// repositories as the primary registry. Mirrors aren't trusted to resolve
+// tags: they are only used once the manifest digest is known, from the
+// primary registry, a digest reference or WithExpectedDigest, and everything
+// they serve is verified against it.
+func WithMirrors(mirrors []string) Option {
+	return func(o *options) {
+		o.mirrors = nil
+		for _, mirror := range mirrors {
+			if mirror = strings.Trim(strings.TrimSpace(mirror), "/"); mirror != "" {
+				o.mirrors = append(o.mirrors, mirror)
+			}
+		}
+	}
+}
+
+// isRetryablePullError reports whether a pull that failed with err may
+// succeed against a mirror. Errors describing the model or the request
+// itself, rather than the registry serving it, are not retried.
+func isRetryablePullError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var platformErr *remote.ErrPlatformNotFound
+	return !errors.Is(err, registry.ErrModelNotFound) &&
+		!errors.Is(err, registry.ErrUnauthorized) &&
+		!errors.Is(err, registry.ErrInvalidReference) &&
+		!errors.Is(err, ErrQuotaExceeded) &&
+		!errors.As(err, &platformErr)
+}
+
+// prefetchMetadata fetches the manifest and config of mdl, returning any
+// error from the registry.
+func prefetchMetadata(mdl types.ModelArtifact) error {
+	if _, err := mdl.RawManifest(); err != nil {
+		return err
+	}
+	_, err := mdl.RawConfigFile()
+	return err
+}
+
+// knownDigest returns the manifest digest that a pull of ref must match, if
+// known without trusting a mirror: the digest served by the primary registry,
+// if it got as far as resolving the manifest, the digest ref is pinned to, or
+// the digest the caller expects.
+func knownDigest(ref string, primary types.ModelArtifact, expected string) (oci.Hash, bool) {
+	if primary != nil {
+		if digest, err := primary.Digest(); err == nil {
+			return digest, true
+		}
+	}
+	if _, pinned, ok := strings.Cut(ref, "@"); ok {
+		if digest, err := oci.NewHash(pinned); err == nil {
+			return digest, true
+		}
+	}
+	if expected != "" {
+		if digest, err := oci.NewHash(expected); err == nil {
+			return digest, true
+		}
+	}
+	return oci.Hash{}, false
+}
+
+// mirrorReference returns reference rewritten to point at the same repository
+// on mirror, pinned to digest.
+func mirrorReference(ref, mirror string, digest oci.Hash) (string, error) {
+	parsed, err := reference.ParseReference(ref, registry.GetDefaultRegistryOptions()...)
+	if err != nil {
+		return "", registry.NewReferenceError(ref, err)
+	}
+	return mirror + "/" + parsed.Context().RepositoryStr() + "@" + digest.String(), nil
+}
+
+// modelFromMirrors resolves reference against the configured mirrors,
+// starting with the mirror at index start. Mirrors are not trusted to resolve
+// tags: they must serve exactly the manifest with the given digest, or an
+// image index with that digest. It returns the model, wrapped so that its
+// config and layers are verified against their digests as they are read,
+// along with the index of the mirror that served it.
+func (c *Client) modelFromMirrors(ctx context.Context, registryClient *registry.Client, reference string, digest oci.Hash, start int, progressWriter io.Writer) (types.ModelArtifact, int, error) {
+	if digest.Hex == "" {
+		return nil, -1, errors.New("mirrors require a known manifest digest")
+	}
+	var errs []error
+	for i := start; i < len(c.mirrors); i++ {
+		mirror := c.mirrors[i]
+		mdl, err := c.modelFromMirror(ctx, registryClient, reference, mirror, digest)
+		if err != nil {
+			c.log.Warnf("Failed to read model %s from mirror %s: %v", utils.SanitizeForLog(reference), mirror, err)
+			errs = append(errs, fmt.Errorf("mirror %s: %w", mirror, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		msg := fmt.Sprintf("Pulling %s from mirror %s", reference, mirror)
+		c.log.Infoln(utils.SanitizeForLog(msg))
+		if err := progress.WriteWarning(progressWriter, msg, oci.ModePull); err != nil {
+			c.log.Warnf("Writing progress: %v", err)
+		}
+		return mdl, i, nil
+	}
+	if len(errs) == 0 {
+		return nil, -1, errors.New("no mirrors configured")
+	}
+	return nil, -1, errors.Join(errs...)
+}
+
+// modelFromMirror resolves reference against a single mirror and checks that
+// the manifest it serves matches its digest.
+func (c *Client) modelFromMirror(ctx context.Context, registryClient *registry.Client, reference, mirror string, digest oci.Hash) (types.ModelArtifact, error) {
+	ref, err := mirrorReference(reference, mirror, digest)
+	if err != nil {
+		return nil, err
+	}
+	mdl, err := registryClient.Model(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	served, err := mdl.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("getting manifest digest: %w", err)
+	}
+	// An image index fetched by digest is verified against it, and the
+	// manifest selected from it against the index, so the model may have
+	// another digest.
+	if served != digest && !registry.FromIndex(mdl) {
+		return nil, fmt.Errorf("%w: expected manifest %s, got %s", ErrBlobDigestMismatch, digest, served)
+	}
+	rawManifest, err := mdl.RawManifest()
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := verifyContent(rawManifest, served); err != nil {
+		return nil, fmt.Errorf("verifying manifest: %w", err)
+	}
+	return &verifiedArtifact{ModelArtifact: mdl}, nil
+}
+
+// verifyContent checks that data hashes to want.
+func verifyContent(data []byte, want oci.Hash) error {
+	if want.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm %q", want.Algorithm)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want.Hex {
+		return fmt.Errorf("%w: expected %s, got sha256:%s", ErrBlobDigestMismatch, want, got)
+	}
+	return nil
+}
+
+// verifiedArtifact wraps a model served by a mirror, verifying its config and
+// layer contents against the digests in its manifest.
+type verifiedArtifact struct {
+	types.ModelArtifact
+}
+
+func (a *verifiedArtifact) RawConfigFile() ([]byte, error) {
+	raw, err := a.ModelArtifact.RawConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	name, err := a.ConfigName()
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyContent(raw, name); err != nil {
+		return nil, fmt.Errorf("verifying config: %w", err)
+	}
+	return raw, nil
+}
+
+func (a *verifiedArtifact) ConfigFile() (*oci.ConfigFile, error) {
+	raw, err := a.RawConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	var cfg oci.ConfigFile
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (a *verifiedArtifact) Layers() ([]oci.Layer, error) {
+	layers, err := a.ModelArtifact.Layers()
+	if err != nil {
+		return nil, err
+	}
+	verified := make([]oci.Layer, len(layers))
+	for i, layer := range layers {
+		verified[i] = &verifiedLayer{Layer: layer}
+	}
+	return verified, nil
+}
+
+// verifiedLayer wraps a layer served by a mirror, verifying its contents as
+// they are read.
+type verifiedLayer struct {
+	oci.Layer
+}
+
+func (l *verifiedLayer) Compressed() (io.ReadCloser, error) {
+	digest, err := l.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return newVerifyingReader(l.Layer.Compressed, digest)
+}
+
+func (l *verifiedLayer) Uncompressed() (io.ReadCloser, error) {
+	diffID, err := l.DiffID()
+	if err != nil {
+		return nil, err
+	}
+	return newVerifyingReader(l.Layer.Uncompressed, diffID)
+}
+
+// verifyingReader returns an error wrapping ErrBlobDigestMismatch at the end
+// of its content if that content doesn't hash to want.
+type verifyingReader struct {
+	io.ReadCloser
+	want   oci.Hash
+	hasher hash.Hash
+}
+
+func newVerifyingReader(open func() (io.ReadCloser, error), want oci.Hash) (io.ReadCloser, error) {
+	if want.Algorithm != "sha256" {
+		return nil, fmt.Errorf("unsupported digest algorithm %q", want.Algorithm)
+	}
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	return &verifyingReader{ReadCloser: rc, want: want, hasher: sha256.New()}, nil
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hasher.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if got := hex.EncodeToString(r.hasher.Sum(nil)); got != r.want.Hex {
+			return n, fmt.Errorf("%w: expected %s, got sha256:%s", ErrBlobDigestMismatch, r.want, got)
+		}
+	}
+	return n, err
+}
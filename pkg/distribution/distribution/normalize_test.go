@@ -37,6 +37,16 @@ func TestNormalizeModelName(t *testing.T) {
 			input:    "myorg/model",
 			expected: "myorg/model:latest",
 		},
+		{
+			name:     "digest reference",
+			input:    "Gemma3@sha256:" + strings.Repeat("a", 64),
+			expected: "ai/gemma3@sha256:" + strings.Repeat("a", 64),
+		},
+		{
+			name:     "registry digest reference",
+			input:    "registry.example.com/myorg/model@sha256:" + strings.Repeat("a", 64),
+			expected: "registry.example.com/myorg/model@sha256:" + strings.Repeat("a", 64),
+		},
 		{
 			name:     "org and name with tag",
 			input:    "myorg/model:v2",
@@ -281,6 +291,53 @@ func TestLooksLikeDigest(t *testing.T) {
 	}
 }
 
+func TestNormalizeModelNameCustomDefaults(t *testing.T) {
+	client, err := NewClient(
+		WithStoreRootPath(t.TempDir()),
+		WithDefaultOrg("mycompany"),
+		WithDefaultTag("stable"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"foo", "mycompany/foo:stable"},
+		{"foo:v1", "mycompany/foo:v1"},
+		{"foo:", "mycompany/foo:stable"},
+		{"ai/foo", "ai/foo:stable"},
+		{"foo@" + digest, "mycompany/foo@" + digest},
+		{"registry.example.com/foo", "registry.example.com/foo:stable"},
+		{"hf.co/org/model", "huggingface.co/org/model:latest"},
+		{"huggingface.co/org/model:Q4_K_M", "huggingface.co/org/model:Q4_K_M"},
+		{strings.Repeat("a", 12), strings.Repeat("a", 12)},
+		{digest, digest},
+	}
+	for _, tt := range tests {
+		if result := client.normalizeModelName(tt.input); result != tt.expected {
+			t.Errorf("normalizeModelName(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+
+	client, err = NewClient(WithStoreRootPath(t.TempDir()), WithDefaultOrg("registry.example.com:5000/team"))
+	if err != nil {
+		t.Fatalf("Failed to create client with a registry org: %v", err)
+	}
+	if result := client.normalizeModelName("foo"); result != "registry.example.com:5000/team/foo:latest" {
+		t.Errorf("normalizeModelName(%q) = %q with a registry org", "foo", result)
+	}
+
+	for _, opt := range []Option{WithDefaultOrg("My Company"), WithDefaultOrg("MyCompany"), WithDefaultTag("not a tag")} {
+		if _, err := NewClient(WithStoreRootPath(t.TempDir()), opt); err == nil {
+			t.Error("Expected an error for an invalid default")
+		}
+	}
+}
+
 func TestNormalizeModelNameWithIDResolution(t *testing.T) {
 	// Create a client with a temporary store
 	client, cleanup := createTestClient(t)
@@ -0,0 +1,100 @@
+package distribution
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/model-runner/pkg/distribution/internal/store"
+)
+
+// PruneOptions selects the models removed by Prune. A model must match every
+// criterion set. If neither Until nor Labels is set, only untagged models are
+// removed, as if Dangling were set.
+type PruneOptions struct {
+	// Until removes only models that haven't been used or pulled for at
+	// least this long. Models predating usage tracking are always old enough.
+	Until time.Duration
+	// Dangling removes only models that no tag references.
+	Dangling bool
+	// Labels removes only models whose manifest has all of these
+	// annotations, each given as "key" or "key=value".
+	Labels []string
+	// DryRun reports the models that would be removed without removing them.
+	DryRun bool
+}
+
+// PrunedModel describes a model removed, or that would be removed, by Prune.
+type PrunedModel = store.PrunedModel
+
+// PruneResponse lists the models removed by Prune.
+type PruneResponse struct {
+	// Models are the models removed, or that would be removed.
+	Models []PrunedModel `json:"models"`
+	// Reclaimed is the total number of bytes freed.
+	Reclaimed int64 `json:"reclaimed"`
+	// DryRun indicates that nothing was removed.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Prune removes the local models matching opts.
+func (c *Client) Prune(opts PruneOptions) (*PruneResponse, error) {
+	if opts.Until < 0 {
+		return nil, fmt.Errorf("invalid prune age %s", opts.Until)
+	}
+	labels := make(map[string]*string, len(opts.Labels))
+	for _, label := range opts.Labels {
+		key, value, hasValue := strings.Cut(label, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			return nil, fmt.Errorf("invalid label filter %q", label)
+		}
+		if hasValue {
+			labels[key] = &value
+		} else {
+			labels[key] = nil
+		}
+	}
+	dangling := opts.Dangling || (opts.Until == 0 && len(labels) == 0)
+	cutoff := time.Now().Add(-opts.Until)
+
+	pruned, err := c.store.Prune(func(entry store.IndexEntry) bool {
+		if dangling && len(entry.Tags) > 0 {
+			return false
+		}
+		if opts.Until > 0 && entry.LastActive().After(cutoff) {
+			return false
+		}
+		return len(labels) == 0 || c.hasLabels(entry.ID, labels)
+	}, opts.DryRun)
+	response := &PruneResponse{Models: pruned, DryRun: opts.DryRun}
+	for _, model := range pruned {
+		response.Reclaimed += model.Reclaimed
+	}
+	if err != nil {
+		return response, fmt.Errorf("pruning models: %w", err)
+	}
+	if !opts.DryRun && len(pruned) > 0 {
+		c.log.Infof("Pruned %d model(s), reclaiming %d bytes", len(pruned), response.Reclaimed)
+	}
+	return response, nil
+}
+
+// hasLabels reports whether the manifest of the model with the given ID has
+// all of labels as annotations. A nil value matches any value.
+func (c *Client) hasLabels(id string, labels map[string]*string) bool {
+	mdl, err := c.store.Read(id)
+	if err != nil {
+		return false
+	}
+	manifest, err := mdl.Manifest()
+	if err != nil {
+		return false
+	}
+	for key, value := range labels {
+		actual, ok := manifest.Annotations[key]
+		if !ok || (value != nil && actual != *value) {
+			return false
+		}
+	}
+	return true
+}
@@ -4,6 +4,8 @@
 package format
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 
 	"github.com/docker/go-units"
@@ -97,6 +99,33 @@ func DetectFromPaths(paths []string) (Format, error) {
 	return format, nil
 }
 
+// ContentSniffLength is the number of leading bytes of a weight file that
+// DetectFromContent needs to identify its format.
+const ContentSniffLength = 9
+
+// maxSafetensorsHeaderSize bounds the header length prefix accepted when
+// sniffing safetensors content. Real headers are a few megabytes at most.
+const maxSafetensorsHeaderSize = 100 << 20
+
+// DetectFromContent determines the model format from the leading bytes of a
+// weight file, which should be at least ContentSniffLength long.
+// Returns an error if the content doesn't match a known format.
+func DetectFromContent(header []byte) (Format, error) {
+	switch {
+	case bytes.HasPrefix(header, []byte("GGUF")):
+		return Get(types.FormatGGUF)
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		// DDUF files are ZIP archives.
+		return Get(types.FormatDiffusers)
+	case len(header) >= ContentSniffLength && header[8] == '{':
+		// A little-endian header length followed by the JSON header.
+		if size := binary.LittleEndian.Uint64(header[:8]); size > 0 && size <= maxSafetensorsHeaderSize {
+			return Get(types.FormatSafetensors)
+		}
+	}
+	return nil, fmt.Errorf("unable to detect format from content")
+}
+
 // formatParameters converts parameter count to human-readable format
 // Returns format like "361.82M" or "1.5B" (no space before unit, base 1000, where B = Billion)
 func formatParameters(params int64) string {
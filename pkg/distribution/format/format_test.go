@@ -1,6 +1,10 @@
 package format
 
 import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/docker/model-runner/pkg/distribution/types"
@@ -174,6 +178,78 @@ func TestSafetensorsFormat_MediaType(t *testing.T) {
 	}
 }
 
+func TestSafetensorsFormat_ExtractConfigValidatesHeader(t *testing.T) {
+	// writeSafetensors writes a file with the given JSON header and dataSize
+	// bytes of tensor data.
+	writeSafetensors := func(t *testing.T, header string, dataSize int) string {
+		t.Helper()
+		buf := binary.LittleEndian.AppendUint64(nil, uint64(len(header)))
+		buf = append(buf, header...)
+		buf = append(buf, make([]byte, dataSize)...)
+		path := filepath.Join(t.TempDir(), "model.safetensors")
+		if err := os.WriteFile(path, buf, 0644); err != nil {
+			t.Fatalf("Failed to write safetensors file: %v", err)
+		}
+		return path
+	}
+
+	const tensorHeader = `{"weight":{"dtype":"F32","shape":[2,2],"data_offsets":[0,16]}}`
+	tests := []struct {
+		name    string
+		path    func(t *testing.T) string
+		wantErr string
+	}{
+		{
+			name: "valid file",
+			path: func(t *testing.T) string { return writeSafetensors(t, tensorHeader, 16) },
+		},
+		{
+			name:    "truncated tensor data",
+			path:    func(t *testing.T) string { return writeSafetensors(t, tensorHeader, 8) },
+			wantErr: "file truncated",
+		},
+		{
+			name:    "malformed JSON header",
+			path:    func(t *testing.T) string { return writeSafetensors(t, `{"weight":`, 0) },
+			wantErr: "parse JSON header",
+		},
+		{
+			name: "inverted data offsets",
+			path: func(t *testing.T) string {
+				return writeSafetensors(t, `{"w":{"dtype":"F32","shape":[1],"data_offsets":[8,4]}}`, 8)
+			},
+			wantErr: "invalid data_offsets",
+		},
+		{
+			name: "too short for header length",
+			path: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "model.safetensors")
+				if err := os.WriteFile(path, []byte("abc"), 0644); err != nil {
+					t.Fatalf("Failed to write safetensors file: %v", err)
+				}
+				return path
+			},
+			wantErr: "too short",
+		},
+	}
+
+	f := &SafetensorsFormat{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.ExtractConfig([]string{tt.path(t)})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestNormalizeUnitString(t *testing.T) {
 	tests := []struct {
 		input string
@@ -238,3 +314,38 @@ func TestFormatSize(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectFromContent(t *testing.T) {
+	safetensors := []byte{2, 0, 0, 0, 0, 0, 0, 0, '{', '}'}
+	tests := []struct {
+		name       string
+		header     []byte
+		wantFormat types.Format
+		wantError  bool
+	}{
+		{"gguf", []byte("GGUF\x03\x00\x00\x00\x00"), types.FormatGGUF, false},
+		{"safetensors", safetensors, types.FormatSafetensors, false},
+		{"dduf", []byte("PK\x03\x04\x14\x00\x00\x00\x00"), types.FormatDiffusers, false},
+		{"oversized safetensors header", []byte{0, 0, 0, 0, 0, 0, 0, 1, '{'}, types.Format(""), true},
+		{"text", []byte("hello world"), types.Format(""), true},
+		{"too short", []byte("GG"), types.Format(""), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := DetectFromContent(tt.header)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error, got format %s", f.Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if f.Name() != tt.wantFormat {
+				t.Errorf("Got format %s, want %s", f.Name(), tt.wantFormat)
+			}
+		})
+	}
+}
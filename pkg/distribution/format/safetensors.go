@@ -89,6 +89,13 @@ func (s *SafetensorsFormat) ExtractConfig(paths []string) (types.Config, error)
 		return types.Config{Format: types.FormatSafetensors}, nil
 	}
 
+	// Reject truncated or malformed files before they are packaged
+	for _, path := range paths {
+		if err := validateSafetensorsFile(path); err != nil {
+			return types.Config{}, err
+		}
+	}
+
 	// Parse the first safetensors file to extract metadata
 	header, err := parseSafetensorsHeader(paths[0])
 	if err != nil {
@@ -145,33 +152,40 @@ type tensorInfo struct {
 
 // parseSafetensorsHeader reads only the header from a safetensors file without loading the entire file.
 func parseSafetensorsHeader(path string) (*safetensorsHeader, error) {
+	header, _, err := readSafetensorsHeader(path)
+	return header, err
+}
+
+// readSafetensorsHeader parses the header of a safetensors file and also
+// returns the length of its JSON portion.
+func readSafetensorsHeader(path string) (*safetensorsHeader, uint64, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("open file: %w", err)
+		return nil, 0, fmt.Errorf("open file: %w", err)
 	}
 	defer file.Close()
 
 	// Read the first 8 bytes to get the header length
 	var headerLen uint64
 	if err := binary.Read(file, binary.LittleEndian, &headerLen); err != nil {
-		return nil, fmt.Errorf("read header length: %w", err)
+		return nil, 0, fmt.Errorf("read header length: %w", err)
 	}
 
 	// Sanity check: header shouldn't be larger than 100MB
 	if headerLen > 100*1024*1024 {
-		return nil, fmt.Errorf("header length too large: %d bytes", headerLen)
+		return nil, 0, fmt.Errorf("header length too large: %d bytes", headerLen)
 	}
 
 	// Read only the header JSON (not the entire file!)
 	headerBytes := make([]byte, headerLen)
 	if _, err := io.ReadFull(file, headerBytes); err != nil {
-		return nil, fmt.Errorf("read header: %w", err)
+		return nil, 0, fmt.Errorf("read header: %w", err)
 	}
 
 	// Parse the JSON header
 	var rawHeader map[string]interface{}
 	if err := json.Unmarshal(headerBytes, &rawHeader); err != nil {
-		return nil, fmt.Errorf("parse JSON header: %w", err)
+		return nil, 0, fmt.Errorf("parse JSON header: %w", err)
 	}
 
 	// Extract metadata (stored under "__metadata__" key)
@@ -198,7 +212,7 @@ func parseSafetensorsHeader(path string) (*safetensorsHeader, error) {
 			for index, v := range shapeArray {
 				floatVal, ok := v.(float64)
 				if !ok {
-					return nil, fmt.Errorf("invalid shape value for tensor %q at index %d: expected number, got %T", name, index, v)
+					return nil, 0, fmt.Errorf("invalid shape value for tensor %q at index %d: expected number, got %T", name, index, v)
 				}
 				shape = append(shape, int64(floatVal))
 			}
@@ -208,12 +222,12 @@ func parseSafetensorsHeader(path string) (*safetensorsHeader, error) {
 		var dataOffsets [2]int64
 		if offsetsArray, ok := tensorMap["data_offsets"].([]interface{}); ok {
 			if len(offsetsArray) != 2 {
-				return nil, fmt.Errorf("invalid data_offsets for tensor %q: expected 2 elements, got %d", name, len(offsetsArray))
+				return nil, 0, fmt.Errorf("invalid data_offsets for tensor %q: expected 2 elements, got %d", name, len(offsetsArray))
 			}
 			for index, offset := range offsetsArray {
 				floatVal, ok := offset.(float64)
 				if !ok {
-					return nil, fmt.Errorf("invalid data_offsets value for tensor %q at index %d: expected number, got %T", name, index, offset)
+					return nil, 0, fmt.Errorf("invalid data_offsets value for tensor %q at index %d: expected number, got %T", name, index, offset)
 				}
 				dataOffsets[index] = int64(floatVal)
 			}
@@ -229,7 +243,36 @@ func parseSafetensorsHeader(path string) (*safetensorsHeader, error) {
 	return &safetensorsHeader{
 		Metadata: metadata,
 		Tensors:  tensors,
-	}, nil
+	}, headerLen, nil
+}
+
+// validateSafetensorsFile checks that the file at path has a well-formed
+// header and that every tensor's declared byte range lies within the file.
+func validateSafetensorsFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat safetensors file %s: %w", path, err)
+	}
+	if info.Size() < 8 {
+		return fmt.Errorf("invalid safetensors file %s: file is %d bytes, too short to hold a header length", path, info.Size())
+	}
+	header, headerLen, err := readSafetensorsHeader(path)
+	if err != nil {
+		return fmt.Errorf("invalid safetensors file %s: %w", path, err)
+	}
+
+	dataSize := info.Size() - 8 - int64(headerLen)
+	for name, tensor := range header.Tensors {
+		begin, end := tensor.DataOffsets[0], tensor.DataOffsets[1]
+		if begin < 0 || end < begin {
+			return fmt.Errorf("invalid safetensors file %s: tensor %q has invalid data_offsets [%d, %d]", path, name, begin, end)
+		}
+		if end > dataSize {
+			return fmt.Errorf("invalid safetensors file %s: tensor %q data ends at byte %d but the data section is only %d bytes (file truncated?)",
+				path, name, end, dataSize)
+		}
+	}
+	return nil
 }
 
 // calculateParameters sums up all tensor parameters
@@ -21,6 +21,9 @@ type Client struct {
 	userAgent  string
 	token      string
 	baseURL    string
+	// limitDownload, if set, wraps the bodies of file downloads, for
+	// example to cap their bandwidth.
+	limitDownload func(context.Context, io.Reader) io.Reader
 }
 
 // ClientOption configures a Client
@@ -62,6 +65,14 @@ func WithBaseURL(baseURL string) ClientOption {
 	}
 }
 
+// WithDownloadLimiter wraps the bodies of file downloads with limit, for
+// example to cap their aggregate bandwidth.
+func WithDownloadLimiter(limit func(context.Context, io.Reader) io.Reader) ClientOption {
+	return func(c *Client) {
+		c.limitDownload = limit
+	}
+}
+
 // NewClient creates a new HuggingFace Hub API client
 func NewClient(opts ...ClientOption) *Client {
 	c := &Client{
@@ -147,6 +158,17 @@ func (c *Client) ListFilesInPath(ctx context.Context, repo, revision, filePath s
 // DownloadFile streams a file from the repository
 // Returns the reader, content length (-1 if unknown), and any error
 func (c *Client) DownloadFile(ctx context.Context, repo, revision, filename string) (io.ReadCloser, int64, error) {
+	reader, length, _, err := c.DownloadFileRange(ctx, repo, revision, filename, 0)
+	return reader, length, err
+}
+
+// DownloadFileRange streams a file from the repository starting at the given byte offset.
+// When offset is greater than zero a "Range: bytes=offset-" header is sent. The returned
+// boolean reports whether the server honored the range with a 206 Partial Content response;
+// if it is false, including when the range is not satisfiable, the reader yields the whole
+// file from the beginning.
+// Returns the reader, content length of the body (-1 if unknown), whether the range was honored, and any error
+func (c *Client) DownloadFileRange(ctx context.Context, repo, revision, filename string, offset int64) (io.ReadCloser, int64, bool, error) {
 	if revision == "" {
 		revision = "main"
 	}
@@ -156,22 +178,47 @@ func (c *Client) DownloadFile(ctx context.Context, repo, revision, filename stri
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
 	if err != nil {
-		return nil, 0, fmt.Errorf("create request: %w", err)
+		return nil, 0, false, fmt.Errorf("create request: %w", err)
 	}
 
 	c.setHeaders(req)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("download file: %w", err)
+		return nil, 0, false, fmt.Errorf("download file: %w", err)
+	}
+
+	if offset > 0 && resp.StatusCode == http.StatusPartialContent {
+		return c.downloadBody(ctx, resp.Body), resp.ContentLength, true, nil
+	}
+
+	// The partial file is at least as long as the file on the hub, which
+	// means it is stale; fetch the whole file so the caller starts over.
+	if offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		resp.Body.Close()
+		return c.DownloadFileRange(ctx, repo, revision, filename, 0)
 	}
 
 	if err := c.checkResponse(resp, repo); err != nil {
 		resp.Body.Close()
-		return nil, 0, err
+		return nil, 0, false, err
 	}
 
-	return resp.Body, resp.ContentLength, nil
+	return c.downloadBody(ctx, resp.Body), resp.ContentLength, false, nil
+}
+
+// downloadBody applies the client's download limiter, if any, to body.
+func (c *Client) downloadBody(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	if c.limitDownload == nil {
+		return body
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{c.limitDownload(ctx, body), body}
 }
 
 // setHeaders sets common headers for HuggingFace API requests
@@ -218,6 +265,21 @@ func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("repository %q not found", e.Repo)
 }
 
+// InterruptedError indicates that a file download stopped partway through,
+// leaving a partial file that a later download can resume from
+type InterruptedError struct {
+	Path string
+	Err  error
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("download of %q interrupted: %v", e.Path, e.Err)
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
 // RateLimitError indicates rate limiting
 type RateLimitError struct {
 	Repo string
@@ -1,13 +1,19 @@
 package huggingface
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestClientListFiles(t *testing.T) {
@@ -288,3 +294,191 @@ func TestClientListFilesRecursive(t *testing.T) {
 		}
 	}
 }
+
+func TestDownloaderResumesPartialFile(t *testing.T) {
+	content := "0123456789abcdefghij"
+	var receivedRange string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRange = r.Header.Get("Range")
+		http.ServeContent(w, r, "model.safetensors", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	// Simulate an interrupted download that left the first 10 bytes behind
+	if err := os.WriteFile(filepath.Join(tempDir, "model.safetensors"), []byte(content[:10]), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	client := NewClient(WithBaseURL(server.URL))
+	downloader := NewDownloader(client, "test-org/test-model", "main", tempDir)
+	file := RepoFile{Type: "file", Path: "model.safetensors", Size: int64(len(content))}
+
+	localPath, err := downloader.DownloadSingleFile(t.Context(), file)
+	if err != nil {
+		t.Fatalf("DownloadSingleFile failed: %v", err)
+	}
+
+	if receivedRange != "bytes=10-" {
+		t.Errorf("Expected Range header 'bytes=10-', got %q", receivedRange)
+	}
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Expected content %q, got %q", content, string(got))
+	}
+}
+
+func TestDownloaderRestartsWhenRangeIgnored(t *testing.T) {
+	content := "0123456789abcdefghij"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignore the Range header and always return the full file
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "model.safetensors"), []byte("stale"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	client := NewClient(WithBaseURL(server.URL))
+	downloader := NewDownloader(client, "test-org/test-model", "main", tempDir)
+	file := RepoFile{Type: "file", Path: "model.safetensors", Size: int64(len(content))}
+
+	localPath, err := downloader.DownloadSingleFile(t.Context(), file)
+	if err != nil {
+		t.Fatalf("DownloadSingleFile failed: %v", err)
+	}
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Expected content %q, got %q", content, string(got))
+	}
+}
+
+func TestDownloaderRestartsWhenRangeNotSatisfiable(t *testing.T) {
+	content := "0123456789"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	// A partial file shorter than the declared size, but longer than the
+	// file actually served by the hub
+	if err := os.WriteFile(filepath.Join(tempDir, "model.safetensors"), []byte("0123456789abc"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	client := NewClient(WithBaseURL(server.URL))
+	downloader := NewDownloader(client, "test-org/test-model", "main", tempDir)
+	file := RepoFile{Type: "file", Path: "model.safetensors", Size: 20}
+
+	localPath, err := downloader.DownloadSingleFile(t.Context(), file)
+	if err != nil {
+		t.Fatalf("DownloadSingleFile failed: %v", err)
+	}
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Expected content %q, got %q", content, string(got))
+	}
+}
+
+func TestDownloaderVerifiesDigest(t *testing.T) {
+	content := "0123456789abcdefghij"
+	sum := sha256.Sum256([]byte(content))
+	lfs := &LFSInfo{OID: hex.EncodeToString(sum[:]), Size: int64(len(content))}
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.ServeContent(w, r, "model.safetensors", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	// A complete file of the right size, left by a pull of an older version
+	if err := os.WriteFile(filepath.Join(tempDir, "model.safetensors"), []byte("abcdefghij0123456789"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	client := NewClient(WithBaseURL(server.URL))
+	downloader := NewDownloader(client, "test-org/test-model", "main", tempDir)
+	file := RepoFile{Type: "file", Path: "model.safetensors", Size: 134, LFS: lfs}
+
+	localPath, err := downloader.DownloadSingleFile(t.Context(), file)
+	if err != nil {
+		t.Fatalf("DownloadSingleFile failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the stale file to be downloaded again, got %d requests", requests)
+	}
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Expected content %q, got %q", content, string(got))
+	}
+
+	// The verified file is reused without downloading it again
+	if _, err := downloader.DownloadSingleFile(t.Context(), file); err != nil {
+		t.Fatalf("DownloadSingleFile failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the verified file to be reused, got %d requests", requests)
+	}
+
+	// Content that doesn't match the digest is rejected
+	file.LFS = &LFSInfo{OID: strings.Repeat("0", 64), Size: int64(len(content))}
+	if _, err := downloader.DownloadSingleFile(t.Context(), file); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("Expected digest mismatch error, got %v", err)
+	}
+}
+
+func TestClientDownloadLimiter(t *testing.T) {
+	content := "0123456789"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	var limited int
+	client := NewClient(WithBaseURL(server.URL), WithDownloadLimiter(func(ctx context.Context, r io.Reader) io.Reader {
+		limited++
+		return r
+	}))
+	reader, _, err := client.DownloadFile(t.Context(), "test-org/test-model", "main", "model.safetensors")
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Expected content %q, got %q", content, string(got))
+	}
+	if limited != 1 {
+		t.Errorf("Expected the download body to be limited once, got %d", limited)
+	}
+}
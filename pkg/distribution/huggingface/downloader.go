@@ -2,12 +2,16 @@ package huggingface
 
 import (
 	"context"
+	"crypto/sha1" //nolint:gosec // git blob IDs are sha1
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/docker/model-runner/pkg/distribution/internal/progress"
@@ -125,7 +129,7 @@ func (d *Downloader) DownloadAll(ctx context.Context, files []RepoFile, progress
 	}
 
 	if len(errs) > 0 {
-		return nil, fmt.Errorf("download errors: %v", errs)
+		return nil, fmt.Errorf("download errors: %w", errors.Join(errs...))
 	}
 
 	// Calculate total downloaded
@@ -140,7 +144,12 @@ func (d *Downloader) DownloadAll(ctx context.Context, files []RepoFile, progress
 	}, nil
 }
 
-// downloadFileWithProgress downloads a single file with progress reporting
+// downloadFileWithProgress downloads a single file with progress reporting.
+// If a partial file from a previous interrupted download of the same content
+// exists in the temp directory, the download is resumed from its current size
+// using a Range request. The file is checked against the digest the hub
+// reports for it, so that a partial or complete file left by a download of a
+// different version of the file is never trusted.
 func (d *Downloader) downloadFileWithProgress(ctx context.Context, file RepoFile, totalImageSize uint64, progressWriter io.Writer) (string, error) {
 	// Create local file path (preserve directory structure)
 	localPath := filepath.Join(d.tempDir, file.Path)
@@ -150,36 +159,104 @@ func (d *Downloader) downloadFileWithProgress(ctx context.Context, file RepoFile
 		return "", fmt.Errorf("create directory: %w", err)
 	}
 
-	// Download from HuggingFace
-	reader, _, err := d.client.DownloadFile(ctx, d.repo, d.revision, file.Path)
+	// Generate unique ID for this file (for progress tracking)
+	fileID := fileIDFromPath(file.Path)
+	fileSize := uint64(file.ActualSize())
+	digest, newHash := fileDigest(file)
+	digestPath := d.digestPath(fileID)
+
+	// Check for a partial download left behind by a previous attempt
+	var offset int64
+	if stat, err := os.Stat(localPath); err == nil && stat.Mode().IsRegular() {
+		offset = stat.Size()
+	}
+	if offset > 0 && digest != "" {
+		// Only resume from a file downloaded for the same content
+		if recorded, err := os.ReadFile(digestPath); err != nil || string(recorded) != digest {
+			offset = 0
+		}
+	}
+	if offset > 0 && fileSize > 0 && uint64(offset) >= fileSize {
+		if uint64(offset) == fileSize && verifyFile(localPath, digest, newHash) == nil {
+			// Already fully downloaded by a previous attempt
+			if progressWriter != nil {
+				_ = progress.WriteProgress(progressWriter, "", totalImageSize, fileSize, fileSize, fileID, "pull")
+			}
+			return localPath, nil
+		}
+		// Larger than expected or corrupt, the file is stale; start over
+		offset = 0
+	}
+
+	// Record what is being downloaded before writing any of it
+	if err := os.MkdirAll(filepath.Dir(digestPath), 0o755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
+	}
+	if err := os.WriteFile(digestPath, []byte(digest), 0o644); err != nil {
+		return "", fmt.Errorf("record file digest: %w", err)
+	}
+
+	// Download from HuggingFace, resuming if possible
+	reader, _, resumed, err := d.client.DownloadFileRange(ctx, d.repo, d.revision, file.Path, offset)
 	if err != nil {
 		return "", err
 	}
 	defer reader.Close()
 
-	// Create local file
-	f, err := os.Create(localPath)
+	// Open local file, keeping its content if the server honored the Range request
+	flags := os.O_CREATE | os.O_RDWR | os.O_TRUNC
+	if resumed {
+		flags = os.O_CREATE | os.O_RDWR
+	} else {
+		offset = 0
+	}
+	f, err := os.OpenFile(localPath, flags, 0o644)
 	if err != nil {
 		return "", fmt.Errorf("create file: %w", err)
 	}
 	defer f.Close()
 
-	// Generate unique ID for this file (for progress tracking)
-	fileID := fileIDFromPath(file.Path)
-	fileSize := uint64(file.ActualSize())
+	// Hash the already downloaded bytes, which leaves the file positioned
+	// at their end, ready to append to
+	var h hash.Hash
+	w := io.Writer(f)
+	if newHash != nil {
+		h = newHash()
+		if _, err := io.Copy(h, io.LimitReader(f, offset)); err != nil {
+			return "", fmt.Errorf("read partial file: %w", err)
+		}
+		w = io.MultiWriter(f, h)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", fmt.Errorf("seek partial file: %w", err)
+	}
 
-	// Copy with progress tracking
+	// Copy with progress tracking, accounting for already downloaded bytes
 	pr := &progressReader{
 		reader:         reader,
 		progressWriter: progressWriter,
 		totalImageSize: totalImageSize,
 		fileSize:       fileSize,
 		fileID:         fileID,
+		bytesRead:      uint64(offset),
+		lastReported:   uint64(offset),
+	}
+	if resumed && progressWriter != nil {
+		_ = progress.WriteProgress(progressWriter, "", totalImageSize, fileSize, uint64(offset), fileID, "pull")
+	}
+
+	if _, err := io.Copy(w, pr); err != nil {
+		// Keep the partial file so a subsequent pull can resume from it
+		return "", &InterruptedError{Path: file.Path, Err: err}
 	}
 
-	if _, err := io.Copy(f, pr); err != nil {
-		os.Remove(localPath) // Clean up on error
-		return "", fmt.Errorf("write file: %w", err)
+	if h != nil {
+		if got := digestPrefix(digest) + hex.EncodeToString(h.Sum(nil)); got != digest {
+			f.Close()
+			os.Remove(localPath)
+			os.Remove(digestPath)
+			return "", fmt.Errorf("digest mismatch for %q: expected %s, got %s", file.Path, digest, got)
+		}
 	}
 
 	// Write final progress for this file (100% complete)
@@ -190,6 +267,58 @@ func (d *Downloader) downloadFileWithProgress(ctx context.Context, file RepoFile
 	return localPath, nil
 }
 
+// digestPath returns the path of the file recording the digest of the content
+// being downloaded for the file with the given progress ID.
+func (d *Downloader) digestPath(fileID string) string {
+	return filepath.Join(d.tempDir, ".digests", strings.TrimPrefix(fileID, "sha256:"))
+}
+
+// fileDigest returns the digest the hub reports for a file's content, along
+// with a constructor for the hash that computes it. LFS files are identified
+// by the sha256 of their content, other files by their git blob ID. It returns
+// an empty digest and a nil constructor if the hub reports neither.
+func fileDigest(file RepoFile) (string, func() hash.Hash) {
+	if file.LFS != nil && file.LFS.OID != "" {
+		return "sha256:" + strings.ToLower(file.LFS.OID), sha256.New
+	}
+	if file.OID != "" {
+		size := file.Size
+		return "git-sha1:" + strings.ToLower(file.OID), func() hash.Hash {
+			h := sha1.New()
+			fmt.Fprintf(h, "blob %d\x00", size)
+			return h
+		}
+	}
+	return "", nil
+}
+
+// digestPrefix returns the algorithm prefix of digest, including the colon.
+func digestPrefix(digest string) string {
+	algorithm, _, _ := strings.Cut(digest, ":")
+	return algorithm + ":"
+}
+
+// verifyFile checks that the file at path has the given digest. A file with
+// no known digest is accepted.
+func verifyFile(path, digest string, newHash func() hash.Hash) error {
+	if newHash == nil {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := digestPrefix(digest) + hex.EncodeToString(h.Sum(nil)); got != digest {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", digest, got)
+	}
+	return nil
+}
+
 // progressReader wraps a reader and reports per-file progress
 type progressReader struct {
 	reader         io.Reader
@@ -3,6 +3,8 @@ package mutate
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/internal/partial"
 	"github.com/docker/model-runner/pkg/distribution/oci"
@@ -14,6 +16,10 @@ type model struct {
 	appended        []oci.Layer
 	configMediaType oci.MediaType
 	contextSize     *int32
+	created         *time.Time
+	// sortLayers orders the layers canonically, with the config's diff IDs
+	// following the same order.
+	sortLayers bool
 }
 
 func (m *model) Descriptor() (types.Descriptor, error) {
@@ -95,7 +101,12 @@ func (m *model) Layers() ([]oci.Layer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return append(ls, m.appended...), nil
+	ls = append(ls, m.appended...)
+	if m.sortLayers {
+		ls = slices.Clone(ls)
+		slices.SortStableFunc(ls, partial.CompareLayers)
+	}
+	return ls, nil
 }
 
 func (m *model) Manifest() (*oci.Manifest, error) {
@@ -121,9 +132,24 @@ func (m *model) RawConfigFile() ([]byte, error) {
 		}
 		cf.RootFS.DiffIDs = append(cf.RootFS.DiffIDs, diffID)
 	}
+	if m.sortLayers {
+		ls, err := m.Layers()
+		if err != nil {
+			return nil, err
+		}
+		cf.RootFS.DiffIDs = make([]oci.Hash, len(ls))
+		for i, l := range ls {
+			if cf.RootFS.DiffIDs[i], err = l.DiffID(); err != nil {
+				return nil, err
+			}
+		}
+	}
 	if m.contextSize != nil {
 		cf.Config.ContextSize = m.contextSize
 	}
+	if m.created != nil {
+		cf.Descriptor.Created = m.created
+	}
 	raw, err := json.Marshal(cf)
 	if err != nil {
 		return nil, err
@@ -1,6 +1,8 @@
 package mutate
 
 import (
+	"time"
+
 	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/docker/model-runner/pkg/distribution/types"
 )
@@ -25,3 +27,20 @@ func ContextSize(mdl types.ModelArtifact, cs int32) types.ModelArtifact {
 		contextSize: &cs,
 	}
 }
+
+// SortLayers orders the layers of mdl canonically (see partial.CompareLayers),
+// so that its ID doesn't depend on the order in which layers were added.
+func SortLayers(mdl types.ModelArtifact) types.ModelArtifact {
+	return &model{
+		base:       mdl,
+		sortLayers: true,
+	}
+}
+
+// Created sets the creation time recorded in the config of mdl.
+func Created(mdl types.ModelArtifact, created time.Time) types.ModelArtifact {
+	return &model{
+		base:    mdl,
+		created: &created,
+	}
+}
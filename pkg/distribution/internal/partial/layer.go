@@ -1,6 +1,7 @@
 package partial
 
 import (
+	"cmp"
 	"encoding/json"
 	"io"
 	"os"
@@ -116,3 +117,29 @@ func (l Layer) GetDescriptor() oci.Descriptor {
 func (l Layer) GetPath() string {
 	return l.Path
 }
+
+// CompareLayers orders layers canonically, by media type and then by file
+// path annotation, so that a model's manifest doesn't depend on the order in
+// which its files were added. It is suitable for slices.SortStableFunc.
+func CompareLayers(a, b oci.Layer) int {
+	if c := cmp.Compare(layerMediaType(a), layerMediaType(b)); c != 0 {
+		return c
+	}
+	return cmp.Compare(layerFilePath(a), layerFilePath(b))
+}
+
+func layerMediaType(l oci.Layer) oci.MediaType {
+	mt, err := l.MediaType()
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+func layerFilePath(l oci.Layer) string {
+	dp, ok := l.(interface{ GetDescriptor() oci.Descriptor })
+	if !ok {
+		return ""
+	}
+	return dp.GetDescriptor().Annotations[types.AnnotationFilePath]
+}
@@ -90,6 +90,28 @@ func GGUFPaths(i WithLayers) ([]string, error) {
 	return layerPathsByMediaType(i, types.MediaTypeGGUF)
 }
 
+// GGUFSizes returns the sizes, recorded in the manifest, of a model's GGUF
+// layers, in the same order as GGUFPaths.
+func GGUFSizes(i WithLayers) ([]int64, error) {
+	layers, err := i.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("get layers: %w", err)
+	}
+	var sizes []int64
+	for _, l := range layers {
+		mt, err := l.MediaType()
+		if err != nil || !matchesMediaType(mt, types.MediaTypeGGUF) {
+			continue
+		}
+		size, err := l.Size()
+		if err != nil {
+			return nil, fmt.Errorf("get layer size: %w", err)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
 func MMPROJPath(i WithLayers) (string, error) {
 	paths, err := layerPathsByMediaType(i, types.MediaTypeMultimodalProjector)
 	if err != nil {
@@ -25,6 +25,10 @@ type Reporter struct {
 	layer     oci.Layer
 	imageSize uint64
 	mode      oci.Mode
+	// blobID and blobSize identify the blob reported on when there is no
+	// layer, such as a blob streamed from a model archive.
+	blobID   string
+	blobSize uint64
 }
 
 type progressF func(update oci.Update) string
@@ -34,9 +38,17 @@ func PullMsg(update oci.Update) string {
 }
 
 func PushMsg(update oci.Update) string {
+	if update.Skipped > 0 {
+		return fmt.Sprintf("Uploaded: %.2f MB (%.2f MB already present)",
+			float64(update.Complete-update.Skipped)/1024/1024, float64(update.Skipped)/1024/1024)
+	}
 	return fmt.Sprintf("Uploaded: %.2f MB", float64(update.Complete)/1024/1024)
 }
 
+func VerifyMsg(update oci.Update) string {
+	return fmt.Sprintf("Verified: %.2f MB", float64(update.Complete)/1024/1024)
+}
+
 func NewProgressReporter(w io.Writer, msgF progressF, imageSize int64, layer oci.Layer, mode oci.Mode) *Reporter {
 	return &Reporter{
 		out:       w,
@@ -49,6 +61,15 @@ func NewProgressReporter(w io.Writer, msgF progressF, imageSize int64, layer oci
 	}
 }
 
+// NewBlobProgressReporter returns a Reporter for a blob known only by its
+// digest and size, rather than by a layer.
+func NewBlobProgressReporter(w io.Writer, msgF progressF, digest oci.Hash, size int64, mode oci.Mode) *Reporter {
+	r := NewProgressReporter(w, msgF, size, nil, mode)
+	r.blobID = digest.String()
+	r.blobSize = safeUint64(size)
+	return r
+}
+
 // safeUint64 converts an int64 to uint64, ensuring the value is non-negative
 func safeUint64(n int64) uint64 {
 	if n < 0 {
@@ -63,14 +84,18 @@ func (r *Reporter) Updates() chan<- oci.Update {
 	go func() {
 		var lastComplete int64
 		var lastUpdate time.Time
+		// skipped is kept from the update that reports it, as updates from
+		// a progress Reader don't carry it.
+		var skipped int64
 
 		for p := range r.progress {
+			skipped = max(skipped, p.Skipped)
+			p.Skipped = skipped
 			if r.out == nil || r.err != nil {
 				continue // If we fail to write progress, don't try again
 			}
 			now := time.Now()
-			var layerSize uint64
-			var layerID string
+			layerSize, layerID := r.blobSize, r.blobID
 			if r.layer != nil {
 				id, err := r.layer.DiffID()
 				if err != nil {
@@ -91,7 +116,18 @@ func (r *Reporter) Updates() chan<- oci.Update {
 			if now.Sub(lastUpdate) >= UpdateInterval ||
 				incrementalBytes >= MinBytesForUpdate ||
 				safeUint64(p.Complete) == layerSize {
-				if err := WriteProgress(r.out, r.format(p), r.imageSize, layerSize, safeUint64(p.Complete), layerID, r.mode); err != nil {
+				if err := write(r.out, oci.ProgressMessage{
+					Type:    oci.TypeProgress,
+					Message: r.format(p),
+					Total:   r.imageSize,
+					Layer: oci.ProgressLayer{
+						ID:      layerID,
+						Size:    layerSize,
+						Current: safeUint64(p.Complete),
+						Skipped: safeUint64(p.Skipped),
+					},
+					Mode: r.mode,
+				}); err != nil {
 					r.err = err
 				}
 				lastUpdate = now
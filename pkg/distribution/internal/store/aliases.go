@@ -0,0 +1,81 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrAliasNotFound is returned when removing an alias that does not exist.
+var ErrAliasNotFound = errors.New("alias not found")
+
+// aliasesPath returns the path to the aliases file
+func (s *LocalStore) aliasesPath() string {
+	return filepath.Join(s.rootPath, "aliases.json")
+}
+
+// readAliases reads the alias table. A missing file yields an empty table.
+func (s *LocalStore) readAliases() (map[string]string, error) {
+	data, err := os.ReadFile(s.aliasesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read aliases file %q: %w", s.aliasesPath(), err)
+	}
+
+	aliases := map[string]string{}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("unmarshal aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// writeAliases writes the alias table
+func (s *LocalStore) writeAliases(aliases map[string]string) error {
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling aliases: %w", err)
+	}
+	if err := s.writeFile(s.aliasesPath(), data); err != nil {
+		return fmt.Errorf("writing aliases file: %w", err)
+	}
+	return nil
+}
+
+// Aliases returns the alias table, mapping alias names to model references.
+func (s *LocalStore) Aliases() (map[string]string, error) {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+	return s.readAliases()
+}
+
+// SetAlias maps name to the target reference, replacing any existing alias.
+func (s *LocalStore) SetAlias(name, target string) error {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+
+	aliases, err := s.readAliases()
+	if err != nil {
+		return err
+	}
+	aliases[name] = target
+	return s.writeAliases(aliases)
+}
+
+// RemoveAlias removes the alias with the given name.
+func (s *LocalStore) RemoveAlias(name string) error {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+
+	aliases, err := s.readAliases()
+	if err != nil {
+		return err
+	}
+	if _, ok := aliases[name]; !ok {
+		return ErrAliasNotFound
+	}
+	delete(aliases, name)
+	return s.writeAliases(aliases)
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+)
+
+// Archive removes the layer blobs and bundle of the model matching ref,
+// keeping its manifest, config and tags so that it is still listed and can be
+// inspected, and marks it as archived until it is pulled again. Blobs that
+// other unarchived models use are kept. It returns the number of bytes freed.
+func (s *LocalStore) Archive(ref string) (int64, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.readIndex()
+	if err != nil {
+		return 0, fmt.Errorf("reading models index: %w", err)
+	}
+	model, n, ok := idx.Find(ref)
+	if !ok {
+		return 0, ErrModelNotFound
+	}
+	if model.Archived {
+		return 0, nil
+	}
+	digest, manifest, err := s.readManifest(model.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Mark the model first, so that an interrupted archive isn't mistaken
+	// for a usable model.
+	idx.Models[n].Archived = true
+	if err := s.writeIndex(idx); err != nil {
+		return 0, fmt.Errorf("writing models index: %w", err)
+	}
+	if err := s.removeBundle(digest); err != nil {
+		fmt.Printf("Warning: failed to remove bundle %q: %v\n", digest, err)
+	}
+
+	inUse := make(map[string]bool)
+	for _, m := range idx.Models {
+		if m.ID == model.ID || m.Archived {
+			continue
+		}
+		for _, file := range m.Files {
+			inUse[file] = true
+		}
+	}
+	var freed int64
+	for _, layer := range manifest.Layers {
+		if inUse[layer.Digest.String()] {
+			continue
+		}
+		size := s.blobSize(layer.Digest)
+		if err := s.removeBlob(layer.Digest); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Warning: failed to remove blob %q from store: %v\n", layer.Digest, err)
+			continue
+		}
+		freed += size
+	}
+	return freed, nil
+}
+
+// Unarchive clears the archived mark of the model matching ref once all of
+// its layer blobs are back in the store. It returns an error wrapping
+// ErrModelArchived if any are still missing.
+func (s *LocalStore) Unarchive(ref string) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.readIndex()
+	if err != nil {
+		return fmt.Errorf("reading models index: %w", err)
+	}
+	model, n, ok := idx.Find(ref)
+	if !ok {
+		return ErrModelNotFound
+	}
+	if !model.Archived {
+		return nil
+	}
+	_, manifest, err := s.readManifest(model.ID)
+	if err != nil {
+		return err
+	}
+	missing := 0
+	for _, layer := range manifest.Layers {
+		if has, err := s.hasBlob(layer.Digest); err != nil || !has {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%w: %d of %d blobs missing", ErrModelArchived, missing, len(manifest.Layers))
+	}
+	idx.Models[n].Archived = false
+	return s.writeIndex(idx)
+}
+
+// readManifest reads the manifest of the model with the given ID.
+func (s *LocalStore) readManifest(id string) (oci.Hash, *oci.Manifest, error) {
+	digest, err := oci.NewHash(id)
+	if err != nil {
+		return oci.Hash{}, nil, fmt.Errorf("parse manifest digest %q: %w", id, err)
+	}
+	raw, err := os.ReadFile(s.manifestPath(digest))
+	if err != nil {
+		return oci.Hash{}, nil, fmt.Errorf("read manifest: %w", err)
+	}
+	manifest, err := oci.ParseManifest(bytes.NewReader(raw))
+	if err != nil {
+		return oci.Hash{}, nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return digest, manifest, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -84,7 +85,7 @@ type blob interface {
 
 // writeLayer writes the layer blob to the store.
 // It returns true when a new blob was created and the blob's DiffID.
-func (s *LocalStore) writeLayer(layer blob, updates chan<- oci.Update, rangeSuccess *remote.RangeSuccess) (bool, oci.Hash, error) {
+func (s *LocalStore) writeLayer(layer blob, updates chan<- oci.Update, options writeOptions) (bool, oci.Hash, error) {
 	hash, err := layer.DiffID()
 	if err != nil {
 		return false, oci.Hash{}, fmt.Errorf("get file hash: %w", err)
@@ -129,17 +130,20 @@ func (s *LocalStore) writeLayer(layer blob, updates chan<- oci.Update, rangeSucc
 		}
 	}
 
-	// Wrap the reader with progress reporting, accounting for already downloaded bytes
+	// Wrap the reader with progress reporting, accounting for already
+	// downloaded bytes. Progress is measured after throttling, so that it
+	// reports the limited rate.
+	limited := options.rateLimiter.Reader(options.ctx, lr)
 	var r io.Reader
 	if incompleteSize > 0 {
-		r = progress.NewReaderWithOffset(lr, updates, incompleteSize)
+		r = progress.NewReaderWithOffset(limited, updates, incompleteSize)
 	} else {
-		r = progress.NewReader(lr, updates)
+		r = progress.NewReader(limited, updates)
 	}
 
 	// WriteBlob will handle appending to incomplete files
 	// The HTTP layer will handle resuming via Range headers
-	if err := s.WriteBlobWithResume(hash, r, layerDigestStr, rangeSuccess); err != nil {
+	if err := s.WriteBlobWithResume(hash, r, layerDigestStr, options.rangeSuccess); err != nil {
 		return false, hash, err
 	}
 	return true, hash, nil
@@ -198,8 +202,10 @@ func (s *LocalStore) WriteBlobWithResume(diffID oci.Hash, r io.Reader, digestStr
 		n, readErr := r.Read(buf)
 		if readErr != nil && readErr != io.EOF {
 			// Clean up the incomplete file on read error (unless it's a context cancellation
-			// which should preserve the file for future resume attempts)
-			if !errors.Is(readErr, context.Canceled) && !errors.Is(readErr, context.DeadlineExceeded) {
+			// or network timeout which should preserve the file for future resume attempts)
+			var netErr net.Error
+			isTimeout := errors.As(readErr, &netErr) && netErr.Timeout()
+			if !errors.Is(readErr, context.Canceled) && !errors.Is(readErr, context.DeadlineExceeded) && !isTimeout {
 				_ = os.Remove(incompletePath)
 			}
 			return fmt.Errorf("read first byte: %w", readErr)
@@ -226,7 +232,7 @@ func (s *LocalStore) WriteBlobWithResume(diffID oci.Hash, r io.Reader, digestStr
 				return fmt.Errorf("remove incomplete file: %w", removeErr)
 			}
 			var createErr error
-			f, createErr = createFile(incompletePath)
+			f, createErr = s.createFile(incompletePath)
 			if createErr != nil {
 				return fmt.Errorf("create blob file: %w", createErr)
 			}
@@ -250,7 +256,7 @@ func (s *LocalStore) WriteBlobWithResume(diffID oci.Hash, r io.Reader, digestStr
 		}
 	} else {
 		// No incomplete file exists - create new file
-		f, err = createFile(incompletePath)
+		f, err = s.createFile(incompletePath)
 		if err != nil {
 			return fmt.Errorf("create blob file: %w", err)
 		}
@@ -258,6 +264,12 @@ func (s *LocalStore) WriteBlobWithResume(diffID oci.Hash, r io.Reader, digestStr
 	defer f.Close()
 
 	if _, err := io.Copy(f, r); err != nil {
+		// Content that doesn't match its digest must not be resumed from.
+		if errors.Is(err, ErrBlobDigestMismatch) {
+			f.Close()
+			_ = os.Remove(incompletePath)
+			return fmt.Errorf("copy blob %q to store: %w", diffID.String(), err)
+		}
 		// Preserve incomplete file for all errors to allow resume attempts.
 		// Transient network errors (HTTP/2 stream errors, connection resets, etc.)
 		// should not cause the downloaded data to be discarded.
@@ -277,6 +289,57 @@ func (s *LocalStore) WriteBlobWithResume(diffID oci.Hash, r io.Reader, digestStr
 	return nil
 }
 
+// WriteVerifiedBlob writes the blob to the store, verifying that its content
+// hashes to the given sha256 digest. On mismatch, nothing is stored and
+// ErrBlobDigestMismatch is returned.
+func (s *LocalStore) WriteVerifiedBlob(hash oci.Hash, r io.Reader) error {
+	if hash.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm %q", hash.Algorithm)
+	}
+	hasBlob, err := s.hasBlob(hash)
+	if err != nil {
+		return fmt.Errorf("check blob existence: %w", err)
+	}
+	if hasBlob {
+		return nil
+	}
+
+	path, err := s.blobPath(hash)
+	if err != nil {
+		return fmt.Errorf("get blob path: %w", err)
+	}
+	tmpPath := incompletePath(path)
+	f, err := s.createFile(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create blob file: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	computed, _, err := oci.SHA256(io.TeeReader(r, f))
+	f.Close() // Rename will fail on Windows if the file is still open.
+	if err != nil {
+		return fmt.Errorf("copy blob %q to store: %w", hash.String(), err)
+	}
+	if computed != hash {
+		return fmt.Errorf("%w: expected %s, got %s", ErrBlobDigestMismatch, hash.String(), computed.String())
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename blob file: %w", err)
+	}
+	return nil
+}
+
+// HasBlob reports whether the blob with the given hash is in the store.
+func (s *LocalStore) HasBlob(hash oci.Hash) (bool, error) {
+	return s.hasBlob(hash)
+}
+
+// RemoveBlob removes the blob with the given hash from the store.
+func (s *LocalStore) RemoveBlob(hash oci.Hash) error {
+	return s.removeBlob(hash)
+}
+
 // removeBlob removes the blob with the given hash from the store.
 func (s *LocalStore) removeBlob(hash oci.Hash) error {
 	path, err := s.blobPath(hash)
@@ -317,11 +380,23 @@ func (s *LocalStore) GetIncompleteSize(hash oci.Hash) (int64, error) {
 }
 
 // createFile is a wrapper around os.Create that creates any parent directories as needed.
-func createFile(path string) (*os.File, error) {
-	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+func (s *LocalStore) createFile(path string) (*os.File, error) {
+	s.layoutMu.RLock()
+	defer s.layoutMu.RUnlock()
+	if err := s.mkdirAll(filepath.Dir(path), 0777); err != nil {
 		return nil, fmt.Errorf("create parent directory %q: %w", filepath.Dir(path), err)
 	}
-	return os.Create(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if s.fileMode != 0 {
+		if err := f.Chmod(s.fileMode); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("chmod %q: %w", path, err)
+		}
+	}
+	return f, nil
 }
 
 // incompletePath returns the path to the incomplete file for the given path.
@@ -352,7 +427,7 @@ func (s *LocalStore) writeConfigFile(mdl oci.Image) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("get raw manifest: %w", err)
 	}
-	if err := writeFile(path, rcf); err != nil {
+	if err := s.writeFile(path, rcf); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -2,11 +2,14 @@ package store
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/oci"
 )
@@ -153,6 +156,37 @@ func TestBlobs(t *testing.T) {
 			t.Fatalf("unexpected blob content: got %v expected %s", string(content), "some-data")
 		}
 	})
+
+	t.Run("WriteVerifiedBlob", func(t *testing.T) {
+		hash, _, err := oci.SHA256(bytes.NewBufferString("verified-data"))
+		if err != nil {
+			t.Fatalf("error calculating hash: %v", err)
+		}
+
+		// content that does not match the digest is rejected
+		err = store.WriteVerifiedBlob(hash, bytes.NewBufferString("other-data"))
+		if !errors.Is(err, ErrBlobDigestMismatch) {
+			t.Fatalf("expected ErrBlobDigestMismatch, got %v", err)
+		}
+		if has, err := store.HasBlob(hash); err != nil || has {
+			t.Fatalf("expected blob to be absent after mismatch, got has=%v err=%v", has, err)
+		}
+		blobPath, err := store.blobPath(hash)
+		if err != nil {
+			t.Fatalf("error getting blob path: %v", err)
+		}
+		if _, err := os.Stat(incompletePath(blobPath)); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected incomplete blob file to be removed after mismatch")
+		}
+
+		// matching content is stored
+		if err := store.WriteVerifiedBlob(hash, bytes.NewBufferString("verified-data")); err != nil {
+			t.Fatalf("error writing blob: %v", err)
+		}
+		if has, err := store.HasBlob(hash); err != nil || !has {
+			t.Fatalf("expected blob to be present, got has=%v err=%v", has, err)
+		}
+	})
 }
 
 var _ io.Reader = &errorReader{}
@@ -167,3 +201,107 @@ func (e errorReader) Read(p []byte) (n int, err error) {
 func (e errorReader) Close() error {
 	return nil
 }
+
+func TestIncompleteDownloads(t *testing.T) {
+	store, err := New(Options{RootPath: filepath.Join(t.TempDir(), "store")})
+	if err != nil {
+		t.Fatalf("error creating store: %v", err)
+	}
+
+	interrupted := oci.Hash{
+		Algorithm: "sha256",
+		Hex:       "deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+	}
+	completed, _, err := oci.SHA256(bytes.NewBufferString("some data"))
+	if err != nil {
+		t.Fatalf("error calculating hash: %v", err)
+	}
+	hashes := []oci.Hash{interrupted, completed}
+	if err := store.beginDownloads(hashes, []int64{100, 9}, []string{"ai/model:latest"}); err != nil {
+		t.Fatalf("error recording downloads: %v", err)
+	}
+	if err := store.WriteBlob(interrupted, &errorReader{}); err == nil {
+		t.Fatalf("expected error writing blob")
+	}
+	if err := store.WriteBlob(completed, bytes.NewBufferString("some data")); err != nil {
+		t.Fatalf("error writing blob: %v", err)
+	}
+
+	downloads, err := store.IncompleteDownloads()
+	if err != nil {
+		t.Fatalf("error listing incomplete downloads: %v", err)
+	}
+	if len(downloads) != 1 || downloads[0].DiffID != interrupted.String() || !downloads[0].Active {
+		t.Fatalf("expected the interrupted download to be listed as active, got %+v", downloads)
+	}
+	if purged, err := store.PurgeIncompleteDownloads(); err != nil || len(purged) != 0 {
+		t.Fatalf("expected active downloads not to be purged, got %+v, %v", purged, err)
+	}
+
+	if err := store.endDownloads(hashes); err != nil {
+		t.Fatalf("error recording finished downloads: %v", err)
+	}
+	downloads, err = store.IncompleteDownloads()
+	if err != nil {
+		t.Fatalf("error listing incomplete downloads: %v", err)
+	}
+	if len(downloads) != 1 || downloads[0].Active || downloads[0].ExpectedSize != 100 ||
+		len(downloads[0].References) != 1 || downloads[0].References[0] != "ai/model:latest" {
+		t.Fatalf("expected the interrupted download to be attributed, got %+v", downloads)
+	}
+
+	purged, err := store.PurgeIncompleteDownloads()
+	if err != nil {
+		t.Fatalf("error purging incomplete downloads: %v", err)
+	}
+	if len(purged) != 1 || purged[0].DiffID != interrupted.String() {
+		t.Fatalf("expected the interrupted download to be purged, got %+v", purged)
+	}
+	if downloads, err := store.IncompleteDownloads(); err != nil || len(downloads) != 0 {
+		t.Fatalf("expected no incomplete downloads after purging, got %+v, %v", downloads, err)
+	}
+	if _, err := os.Stat(store.downloadsPath()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected downloads file to be removed")
+	}
+	if has, err := store.hasBlob(completed); err != nil || !has {
+		t.Fatalf("expected completed blob to be kept")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	if NewRateLimiter(0) != nil {
+		t.Error("Expected no limiter for a zero rate")
+	}
+	var unlimited *RateLimiter
+	r := bytes.NewReader(nil)
+	if unlimited.Reader(context.Background(), r) != r {
+		t.Error("Expected a nil limiter to return the reader unchanged")
+	}
+
+	// Two concurrent readers share the limit.
+	limiter := NewRateLimiter(64 << 10)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := io.Copy(io.Discard, limiter.Reader(context.Background(), bytes.NewReader(make([]byte, 16<<10))))
+			if err != nil || n != 16<<10 {
+				t.Errorf("Expected to read 16KiB, got %d, %v", n, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Expected 32KiB at 64KiB/s to take about 500ms, took %v", elapsed)
+	}
+
+	// Cancelling the context interrupts a wait.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := NewRateLimiter(1)
+	if _, err := io.Copy(io.Discard, slow.Reader(ctx, bytes.NewReader(make([]byte, 1024)))); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the read to be cancelled, got %v", err)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 
 	"github.com/docker/model-runner/pkg/distribution/internal/bundle"
+	mdpartial "github.com/docker/model-runner/pkg/distribution/internal/partial"
 	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/docker/model-runner/pkg/distribution/types"
 )
@@ -14,6 +17,10 @@ const (
 	bundlesDir = "bundles"
 )
 
+// ggufShardPattern matches GGUF shard file names like
+// "model-00001-of-00003.gguf".
+var ggufShardPattern = regexp.MustCompile(`^(.+)-(\d{5})-of-(\d{5})\.gguf$`)
+
 // bundlePath returns the path to the bundle directory for the given hash.
 func (s *LocalStore) bundlePath(hash oci.Hash) string {
 	return filepath.Join(s.rootPath, bundlesDir, hash.Algorithm, hash.Hex)
@@ -25,32 +32,136 @@ func (s *LocalStore) BundleForModel(ref string) (types.ModelBundle, error) {
 	if err != nil {
 		return nil, fmt.Errorf("find model content: %w", err)
 	}
+	if mdl.Archived() {
+		return nil, ErrModelArchived
+	}
 	dgst, err := mdl.Digest()
 	if err != nil {
 		return nil, fmt.Errorf("get model ID: %w", err)
 	}
+	// Check that the model's weights were stored in full before unpacking or
+	// launching anything with them.
+	if err := verifyGGUFBlobs(mdl); err != nil {
+		return nil, err
+	}
 	path := s.bundlePath(dgst)
 	bdl, err := bundle.Parse(path)
+	if err == nil && s.verifyGGUFShards(bdl) == nil {
+		return bdl, nil
+	}
+
+	// create for first time or replace bad/corrupted/incomplete bundle
+	created, err := s.createBundle(path, mdl)
 	if err != nil {
-		// create for first time or replace bad/corrupted bundle
-		return s.createBundle(path, mdl)
+		return nil, err
+	}
+	if err := s.verifyGGUFShards(created); err != nil {
+		return nil, err
 	}
+	return created, nil
+}
 
-	return bdl, nil
+// verifyGGUFBlobs checks that the stored blob of each GGUF layer of mdl is
+// present with the size recorded in its manifest, returning an error wrapping
+// ErrIncompleteModel for the first one that isn't.
+func verifyGGUFBlobs(mdl *Model) error {
+	paths, err := mdl.GGUFPaths()
+	if err != nil {
+		return fmt.Errorf("get GGUF files for model: %w", err)
+	}
+	sizes, err := mdpartial.GGUFSizes(mdl)
+	if err != nil {
+		return fmt.Errorf("get GGUF sizes for model: %w", err)
+	}
+	if len(sizes) != len(paths) {
+		return fmt.Errorf("%w: found %d GGUF files for %d layers", ErrIncompleteModel, len(paths), len(sizes))
+	}
+	for i, path := range paths {
+		shard := fmt.Sprintf("GGUF file %d of %d (%s)", i+1, len(paths), filepath.Base(path))
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: missing %s, re-pull the model", ErrIncompleteModel, shard)
+		} else if err != nil {
+			return fmt.Errorf("stat %s: %w", shard, err)
+		}
+		if info.Size() != sizes[i] {
+			return fmt.Errorf("%w: %s has %d of %d bytes, re-pull the model", ErrIncompleteModel, shard, info.Size(), sizes[i])
+		}
+	}
+	return nil
+}
+
+// verifyGGUFShards checks that a bundle with GGUF weights holds every shard
+// declared by their split.count metadata, returning an error wrapping
+// ErrIncompleteModel naming the first missing one. Bundles without GGUF
+// weights are always complete.
+func (s *LocalStore) verifyGGUFShards(bdl types.ModelBundle) error {
+	path := bdl.GGUFPath()
+	if path == "" {
+		return nil
+	}
+	count, err := s.ggufSplitCount(path)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return nil
+	}
+
+	// The bundle names shards after the number of GGUF layers, which is
+	// fewer than declared if the model was packaged without some of them.
+	found := 1
+	prefix := ""
+	if matches := ggufShardPattern.FindStringSubmatch(filepath.Base(path)); matches != nil {
+		found, _ = strconv.Atoi(matches[3])
+		prefix = matches[1]
+	}
+	if found != count {
+		return fmt.Errorf("%w: model has %d of the %d GGUF shards its metadata declares", ErrIncompleteModel, found, count)
+	}
+	dir := filepath.Dir(path)
+	for i := 1; i <= count; i++ {
+		name := fmt.Sprintf("%s-%05d-of-%05d.gguf", prefix, i, count)
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() == 0 {
+			return fmt.Errorf("%w: missing shard %s, re-pull the model", ErrIncompleteModel, name)
+		}
+	}
+	return nil
+}
+
+// ggufSplitCount returns the split.count of the bundled GGUF file at path,
+// reading it only the first time, since the metadata can be large.
+func (s *LocalStore) ggufSplitCount(path string) (int, error) {
+	if count, ok := s.splitCounts.Load(path); ok {
+		return count.(int), nil
+	}
+	count, err := readGGUFSplitCount(path)
+	if err != nil {
+		return 0, fmt.Errorf("read GGUF metadata of %s: %w", filepath.Base(path), err)
+	}
+	s.splitCounts.Store(path, count)
+	return count, nil
 }
 
 // createBundle unpacks the bundle to path, replacing existing bundle if one is found
 func (s *LocalStore) createBundle(path string, mdl *Model) (types.ModelBundle, error) {
+	s.layoutMu.RLock()
+	defer s.layoutMu.RUnlock()
 	if err := os.RemoveAll(path); err != nil {
 		return nil, fmt.Errorf("remove %s: %w", path, err)
 	}
-	if err := os.MkdirAll(path, 0755); err != nil {
+	if err := s.mkdirAll(path, 0755); err != nil {
 		return nil, fmt.Errorf("create bundle directory: %w", err)
 	}
 	bdl, err := bundle.Unpack(path, mdl)
 	if err != nil {
 		return nil, fmt.Errorf("unpack bundle: %w", err)
 	}
+	// Unpacking links blobs and extracts archives with their own
+	// permissions; bring the whole bundle in line with the store's modes.
+	if err := s.applyModes(path); err != nil {
+		return nil, fmt.Errorf("set bundle permissions: %w", err)
+	}
 	return bdl, nil
 }
 
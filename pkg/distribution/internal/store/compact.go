@@ -0,0 +1,205 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/docker/model-runner/pkg/distribution/oci/reference"
+	"github.com/docker/model-runner/pkg/distribution/registry"
+)
+
+// CompactResult reports what Compact reclaimed.
+type CompactResult struct {
+	// Bytes is the number of bytes reclaimed by rewriting index files.
+	Bytes int64 `json:"bytes"`
+	// Inodes is the number of empty directories removed.
+	Inodes int64 `json:"inodes"`
+	// MergedEntries is the number of duplicate model entries merged into
+	// others in the models index.
+	MergedEntries int `json:"merged_entries"`
+}
+
+// Compact removes the empty directories left under the blob, manifest and
+// bundle directories by deletions, merges duplicate entries of the models
+// index and rewrites the index files larger than their canonical encoding.
+// Blobs and manifests are never removed, so models stay intact. Compact can
+// run concurrently with other store operations: reads never see a partially
+// written index, and writes wait for it to finish.
+func (s *LocalStore) Compact() (CompactResult, error) {
+	// Take the table locks before layoutMu, as their holders do when writing
+	// the tables.
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+	s.downloadsMu.Lock()
+	defer s.downloadsMu.Unlock()
+	s.layoutMu.Lock()
+	defer s.layoutMu.Unlock()
+
+	var result CompactResult
+	for _, dir := range []string{blobsDir, manifestsDir, bundlesDir} {
+		removed, _, err := removeEmptyDirs(filepath.Join(s.rootPath, dir), true)
+		result.Inodes += removed
+		if err != nil {
+			return result, fmt.Errorf("removing empty directories: %w", err)
+		}
+	}
+
+	idx, err := s.readIndex()
+	if err != nil {
+		return result, fmt.Errorf("reading models index: %w", err)
+	}
+	idx, result.MergedEntries = idx.consolidate()
+	aliases, err := s.readAliases()
+	if err != nil {
+		return result, err
+	}
+	downloads, err := s.readDownloads()
+	if err != nil {
+		return result, err
+	}
+
+	files := []struct {
+		path string
+		data any
+	}{
+		{s.indexPath(), idx},
+		{s.aliasesPath(), aliases},
+		{s.downloadsPath(), downloads},
+	}
+	for _, file := range files {
+		reclaimed, err := s.rewriteIfSmaller(file.path, file.data)
+		if err != nil {
+			return result, err
+		}
+		result.Bytes += reclaimed
+	}
+	return result, nil
+}
+
+// rewriteIfSmaller rewrites the JSON file at path with the indented encoding
+// of data if that is smaller than the file, returning the number of bytes
+// reclaimed. Dropping duplicates from a table always shrinks its encoding.
+// Missing files are left alone.
+func (s *LocalStore) rewriteIfSmaller(path string, data any) (int64, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("stat %q: %w", path, err)
+	}
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("marshaling %q: %w", filepath.Base(path), err)
+	}
+	if int64(len(encoded)) >= info.Size() {
+		return 0, nil
+	}
+	if err := s.writeFileLocked(path, encoded); err != nil {
+		return 0, fmt.Errorf("rewriting %q: %w", filepath.Base(path), err)
+	}
+	return info.Size() - int64(len(encoded)), nil
+}
+
+// removeEmptyDirs removes the directories under dir that are empty, or
+// contain only empty directories, and dir itself unless root is set. It
+// returns the number of directories removed and whether dir is now empty. A
+// missing dir is empty.
+func removeEmptyDirs(dir string, root bool) (int64, bool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, true, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	var removed int64
+	empty := true
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			empty = false
+			continue
+		}
+		n, subEmpty, err := removeEmptyDirs(filepath.Join(dir, entry.Name()), false)
+		removed += n
+		if err != nil {
+			return removed, false, err
+		}
+		empty = empty && subEmpty
+	}
+	if !empty || root {
+		return removed, empty, nil
+	}
+	if err := os.Remove(dir); err != nil {
+		return removed, false, err
+	}
+	return removed + 1, true, nil
+}
+
+// consolidate returns the index with the entries sharing a model ID merged
+// into the first of them, and duplicate tags and files dropped, along with
+// the number of entries merged. A merged model is archived if any of its
+// entries is, so that it is checked before use.
+func (i Index) consolidate() (Index, int) {
+	result := Index{Models: make([]IndexEntry, 0, len(i.Models))}
+	positions := make(map[string]int, len(i.Models))
+	merged := 0
+	for _, entry := range i.Models {
+		n, ok := positions[entry.ID]
+		if !ok {
+			positions[entry.ID] = len(result.Models)
+			entry.Tags = appendUniqueTags(make([]string, 0, len(entry.Tags)), entry.Tags)
+			entry.Files = appendUnique(make([]string, 0, len(entry.Files)), entry.Files)
+			result.Models = append(result.Models, entry)
+			continue
+		}
+		merged++
+		existing := &result.Models[n]
+		existing.Tags = appendUniqueTags(existing.Tags, entry.Tags)
+		existing.Files = appendUnique(existing.Files, entry.Files)
+		if entry.LastUsed.After(existing.LastUsed) {
+			existing.LastUsed = entry.LastUsed
+		}
+		if entry.LastPulled.After(existing.LastPulled) {
+			existing.LastPulled = entry.LastPulled
+		}
+		existing.Archived = existing.Archived || entry.Archived
+	}
+	return result, merged
+}
+
+// appendUniqueTags appends the tags not already in dst to it, comparing them
+// as references so that differently spelled duplicates are dropped too.
+func appendUniqueTags(dst, tags []string) []string {
+	seen := make(map[string]bool, len(dst)+len(tags))
+	normalize := func(tag string) string {
+		if ref, err := reference.ParseReference(tag, registry.GetDefaultRegistryOptions()...); err == nil {
+			return ref.String()
+		}
+		return tag
+	}
+	for _, tag := range dst {
+		seen[normalize(tag)] = true
+	}
+	for _, tag := range tags {
+		if key := normalize(tag); !seen[key] {
+			seen[key] = true
+			dst = append(dst, tag)
+		}
+	}
+	return dst
+}
+
+// appendUnique appends the values not already in dst to it.
+func appendUnique(dst, values []string) []string {
+	for _, v := range values {
+		if !slices.Contains(dst, v) {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+)
+
+// pendingDownload records what a blob being downloaded belongs to, so that
+// the download can be attributed if it is interrupted.
+type pendingDownload struct {
+	// References are the references of the models being pulled.
+	References []string `json:"references"`
+	// Size is the expected size of the blob, in bytes.
+	Size int64 `json:"size"`
+}
+
+// IncompleteDownload describes a partially downloaded blob.
+type IncompleteDownload struct {
+	// DiffID is the digest of the blob's uncompressed content.
+	DiffID string `json:"diff_id"`
+	// Size is the number of bytes downloaded so far.
+	Size int64 `json:"size"`
+	// ExpectedSize is the size of the complete blob, or zero if unknown.
+	ExpectedSize int64 `json:"expected_size,omitempty"`
+	// References are the references of the models the blob was pulled for,
+	// if known.
+	References []string `json:"references,omitempty"`
+	// ModifiedAt is when the download last wrote to the blob.
+	ModifiedAt time.Time `json:"modified_at"`
+	// Active indicates that the blob is still being downloaded.
+	Active bool `json:"active"`
+}
+
+// downloadsPath returns the path to the pending downloads file.
+func (s *LocalStore) downloadsPath() string {
+	return filepath.Join(s.rootPath, "downloads.json")
+}
+
+// readDownloads reads the pending downloads table, keyed by diffID. A
+// missing file yields an empty table.
+func (s *LocalStore) readDownloads() (map[string]pendingDownload, error) {
+	data, err := os.ReadFile(s.downloadsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]pendingDownload{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read downloads file %q: %w", s.downloadsPath(), err)
+	}
+
+	downloads := map[string]pendingDownload{}
+	if err := json.Unmarshal(data, &downloads); err != nil {
+		return nil, fmt.Errorf("unmarshal downloads: %w", err)
+	}
+	return downloads, nil
+}
+
+// writeDownloads writes the pending downloads table, removing the file once
+// it is empty.
+func (s *LocalStore) writeDownloads(downloads map[string]pendingDownload) error {
+	if len(downloads) == 0 {
+		if err := os.Remove(s.downloadsPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing downloads file: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(downloads, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling downloads: %w", err)
+	}
+	if err := s.writeFile(s.downloadsPath(), data); err != nil {
+		return fmt.Errorf("writing downloads file: %w", err)
+	}
+	return nil
+}
+
+// beginDownloads records that the blobs with the given diffIDs and sizes are
+// being downloaded for the models with the given references.
+func (s *LocalStore) beginDownloads(diffIDs []oci.Hash, sizes []int64, references []string) error {
+	s.downloadsMu.Lock()
+	defer s.downloadsMu.Unlock()
+	downloads, err := s.readDownloads()
+	if err != nil {
+		return err
+	}
+	for i, diffID := range diffIDs {
+		download := downloads[diffID.String()]
+		download.Size = sizes[i]
+		for _, reference := range references {
+			if !slices.Contains(download.References, reference) {
+				download.References = append(download.References, reference)
+			}
+		}
+		downloads[diffID.String()] = download
+		s.activeDownloads[diffID.String()]++
+	}
+	return s.writeDownloads(downloads)
+}
+
+// endDownloads records that the downloads of the blobs with the given diffIDs
+// have stopped. Blobs left incomplete stay recorded, so that they can be
+// attributed; the others are forgotten.
+func (s *LocalStore) endDownloads(diffIDs []oci.Hash) error {
+	s.downloadsMu.Lock()
+	defer s.downloadsMu.Unlock()
+	downloads, err := s.readDownloads()
+	if err != nil {
+		return err
+	}
+	for _, diffID := range diffIDs {
+		key := diffID.String()
+		if s.activeDownloads[key]--; s.activeDownloads[key] > 0 {
+			continue
+		}
+		delete(s.activeDownloads, key)
+		if path, err := s.blobPath(diffID); err == nil {
+			if _, err := os.Stat(incompletePath(path)); err == nil {
+				continue
+			}
+		}
+		delete(downloads, key)
+	}
+	return s.writeDownloads(downloads)
+}
+
+// IncompleteDownloads lists the partially downloaded blobs in the store,
+// oldest first, along with the models they were pulled for, if known.
+func (s *LocalStore) IncompleteDownloads() ([]IncompleteDownload, error) {
+	s.downloadsMu.Lock()
+	defer s.downloadsMu.Unlock()
+	downloads, err := s.readDownloads()
+	if err != nil {
+		return nil, err
+	}
+	incomplete := []IncompleteDownload{}
+	err = s.walkIncomplete(func(diffID string, info os.FileInfo) {
+		download := downloads[diffID]
+		incomplete = append(incomplete, IncompleteDownload{
+			DiffID:       diffID,
+			Size:         info.Size(),
+			ExpectedSize: download.Size,
+			References:   download.References,
+			ModifiedAt:   info.ModTime(),
+			Active:       s.activeDownloads[diffID] > 0,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(incomplete, func(i, j int) bool {
+		return incomplete[i].ModifiedAt.Before(incomplete[j].ModifiedAt)
+	})
+	return incomplete, nil
+}
+
+// PurgeIncompleteDownloads removes the partially downloaded blobs that aren't
+// being downloaded, returning those removed.
+func (s *LocalStore) PurgeIncompleteDownloads() ([]IncompleteDownload, error) {
+	s.downloadsMu.Lock()
+	defer s.downloadsMu.Unlock()
+	downloads, err := s.readDownloads()
+	if err != nil {
+		return nil, err
+	}
+	purged := []IncompleteDownload{}
+	var errs []error
+	err = s.walkIncomplete(func(diffID string, info os.FileInfo) {
+		if s.activeDownloads[diffID] > 0 {
+			return
+		}
+		hash, err := oci.NewHash(diffID)
+		if err != nil {
+			return
+		}
+		path, err := s.blobPath(hash)
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		if err := os.Remove(incompletePath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove incomplete file for %s: %w", diffID, err))
+			return
+		}
+		download := downloads[diffID]
+		purged = append(purged, IncompleteDownload{
+			DiffID:       diffID,
+			Size:         info.Size(),
+			ExpectedSize: download.Size,
+			References:   download.References,
+			ModifiedAt:   info.ModTime(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Forget every download that is no longer in progress, including those
+	// whose incomplete file was already discarded.
+	for diffID := range downloads {
+		if s.activeDownloads[diffID] == 0 {
+			delete(downloads, diffID)
+		}
+	}
+	if err := s.writeDownloads(downloads); err != nil {
+		errs = append(errs, err)
+	}
+	return purged, errors.Join(errs...)
+}
+
+// walkIncomplete calls fn for each incomplete file in the blobs directory,
+// with the diffID of the blob it is downloading.
+func (s *LocalStore) walkIncomplete(fn func(diffID string, info os.FileInfo)) error {
+	blobsPath := s.blobsDir()
+	if _, err := os.Stat(blobsPath); os.IsNotExist(err) {
+		return nil
+	}
+	err := filepath.Walk(blobsPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".incomplete") {
+			return nil
+		}
+		rel, err := filepath.Rel(blobsPath, strings.TrimSuffix(path, ".incomplete"))
+		if err != nil {
+			return nil
+		}
+		algorithm, hex, ok := strings.Cut(filepath.ToSlash(rel), "/")
+		if !ok || strings.Contains(hex, "/") {
+			return nil
+		}
+		fn(algorithm+":"+hex, info)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walking blobs directory: %w", err)
+	}
+	return nil
+}
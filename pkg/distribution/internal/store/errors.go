@@ -4,4 +4,11 @@ import (
 	"errors"
 )
 
-var ErrModelNotFound = errors.New("model not found")
+var (
+	ErrModelNotFound      = errors.New("model not found")
+	ErrBlobDigestMismatch = errors.New("blob content does not match digest")
+	ErrNotAModel          = errors.New("content does not match the declared model format")
+	ErrQuotaExceeded      = errors.New("model exceeds the store quota")
+	ErrIncompleteModel    = errors.New("model files are incomplete")
+	ErrModelArchived      = errors.New("model archived, re-pull required")
+)
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/oci/reference"
 	"github.com/docker/model-runner/pkg/distribution/registry"
@@ -112,7 +113,7 @@ func (s *LocalStore) writeIndex(index Index) error {
 	}
 
 	// Write the models index
-	if err := writeFile(s.indexPath(), modelsData); err != nil {
+	if err := s.writeFile(s.indexPath(), modelsData); err != nil {
 		return fmt.Errorf("writing models file: %w", err)
 	}
 
@@ -150,6 +151,24 @@ type IndexEntry struct {
 	Tags []string `json:"tags"`
 	// Files are the files associated with the model.
 	Files []string `json:"files"`
+	// LastUsed is when the model was last used for inference. It is zero if
+	// the model has never been used.
+	LastUsed time.Time `json:"last_used,omitzero"`
+	// LastPulled is when the model was last pulled or imported. It is zero for
+	// models written before pull tracking was added.
+	LastPulled time.Time `json:"last_pulled,omitzero"`
+	// Archived indicates that the model's layer blobs were removed to save
+	// space, so it must be pulled again before it can be used.
+	Archived bool `json:"archived,omitempty"`
+}
+
+// LastActive returns when the model was last pulled or used, whichever is
+// later.
+func (e IndexEntry) LastActive() time.Time {
+	if e.LastUsed.After(e.LastPulled) {
+		return e.LastUsed
+	}
+	return e.LastPulled
 }
 
 func (e IndexEntry) HasTag(tag string) bool {
@@ -203,9 +222,12 @@ func (e IndexEntry) Tag(tag *reference.Tag) IndexEntry {
 		return e
 	}
 	return IndexEntry{
-		ID:    e.ID,
-		Tags:  append(e.Tags, tag.String()),
-		Files: e.Files,
+		ID:         e.ID,
+		Tags:       append(e.Tags, tag.String()),
+		Files:      e.Files,
+		LastUsed:   e.LastUsed,
+		LastPulled: e.LastPulled,
+		Archived:   e.Archived,
 	}
 }
 
@@ -222,8 +244,11 @@ func (e IndexEntry) UnTag(tag *reference.Tag) IndexEntry {
 		tags = append(tags, e.Tags[i])
 	}
 	return IndexEntry{
-		ID:    e.ID,
-		Tags:  tags,
-		Files: e.Files,
+		ID:         e.ID,
+		Tags:       tags,
+		Files:      e.Files,
+		LastUsed:   e.LastUsed,
+		LastPulled: e.LastPulled,
+		Archived:   e.Archived,
 	}
 }
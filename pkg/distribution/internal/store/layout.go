@@ -54,7 +54,7 @@ func (s *LocalStore) writeLayout(layout Layout) error {
 	if err != nil {
 		return fmt.Errorf("marshaling layout: %w", err)
 	}
-	if err := writeFile(s.layoutPath(), layoutData); err != nil {
+	if err := s.writeFile(s.layoutPath(), layoutData); err != nil {
 		return fmt.Errorf("writing layout file: %w", err)
 	}
 	return nil
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/oci"
 )
@@ -34,16 +35,25 @@ func (s *LocalStore) WriteManifest(hash oci.Hash, raw []byte) error {
 			return fmt.Errorf("missing blob %q for manifest - refusing to write unless all blobs exist", layer.Digest)
 		}
 	}
-	if err := writeFile(s.manifestPath(hash), raw); err != nil {
+	if err := s.validateWeights(manifest); err != nil {
+		return err
+	}
+	if err := s.writeFile(s.manifestPath(hash), raw); err != nil {
 		return fmt.Errorf("write manifest: %w", err)
 	}
 
 	// Add the manifest to the index
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
 	idx, err := s.readIndex()
 	if err != nil {
 		return fmt.Errorf("reading models: %w", err)
 	}
 
+	// Writing the manifest of an archived model means its blobs are back.
+	if _, n, ok := idx.Find(hash.String()); ok {
+		idx.Models[n].Archived = false
+	}
 	if err := s.writeIndex(idx.Add(newEntryForManifest(hash, manifest))); err != nil {
 		// Best effort rollback to avoid leaving an orphaned manifest on disk.
 		if removeErr := s.removeManifest(hash); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
@@ -65,8 +75,9 @@ func newEntryForManifest(digest oci.Hash, manifest *oci.Manifest) IndexEntry {
 	files[len(manifest.Layers)] = manifest.Config.Digest.String()
 
 	return IndexEntry{
-		ID:    digest.String(),
-		Files: files,
+		ID:         digest.String(),
+		Files:      files,
+		LastPulled: time.Now(),
 	}
 }
 
@@ -76,9 +87,16 @@ func (s *LocalStore) removeManifest(hash oci.Hash) error {
 }
 
 // writeFile is a wrapper around os.WriteFile that creates any parent directories as needed.
-func writeFile(path string, data []byte) error {
+func (s *LocalStore) writeFile(path string, data []byte) error {
+	s.layoutMu.RLock()
+	defer s.layoutMu.RUnlock()
+	return s.writeFileLocked(path, data)
+}
+
+// writeFileLocked is writeFile for callers holding layoutMu.
+func (s *LocalStore) writeFileLocked(path string, data []byte) error {
 	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	if err := s.mkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("create parent directory %q: %w", dir, err)
 	}
 
@@ -105,7 +123,7 @@ func writeFile(path string, data []byte) error {
 		cleanup()
 		return fmt.Errorf("close temporary file %q: %w", tmpName, err)
 	}
-	if err := os.Chmod(tmpName, 0o644); err != nil {
+	if err := os.Chmod(tmpName, s.fileModeOr(0o644)); err != nil {
 		cleanup()
 		return fmt.Errorf("chmod temporary file %q: %w", tmpName, err)
 	}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	mdpartial "github.com/docker/model-runner/pkg/distribution/internal/partial"
 	"github.com/docker/model-runner/pkg/distribution/oci"
@@ -19,6 +20,9 @@ type Model struct {
 	rawConfigFile []byte
 	layers        []oci.Layer
 	tags          []string
+	lastUsed      time.Time
+	lastPulled    time.Time
+	archived      bool
 }
 
 func (s *LocalStore) newModel(digest oci.Hash, tags []string) (*Model, error) {
@@ -169,6 +173,18 @@ func (m *Model) Tags() []string {
 	return m.tags
 }
 
+func (m *Model) LastUsed() time.Time {
+	return m.lastUsed
+}
+
+func (m *Model) LastPulled() time.Time {
+	return m.lastPulled
+}
+
+func (m *Model) Archived() bool {
+	return m.archived
+}
+
 func (m *Model) ID() (string, error) {
 	return mdpartial.ID(m)
 }
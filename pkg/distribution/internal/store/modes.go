@@ -0,0 +1,72 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// fileModeOr returns the store's file mode, or def if none is configured.
+func (s *LocalStore) fileModeOr(def os.FileMode) os.FileMode {
+	if s.fileMode != 0 {
+		return s.fileMode
+	}
+	return def
+}
+
+// mkdirAll creates path and any missing parents. The directories it creates
+// get the store's directory mode, regardless of the umask, or def, subject to
+// the umask, if none is configured.
+func (s *LocalStore) mkdirAll(path string, def os.FileMode) error {
+	if s.dirMode == 0 {
+		return os.MkdirAll(path, def)
+	}
+	// Find the directories that don't exist yet, innermost first.
+	var missing []string
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil || !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		missing = append(missing, dir)
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	if err := os.MkdirAll(path, s.dirMode); err != nil {
+		return err
+	}
+	for _, dir := range missing {
+		if err := os.Chmod(dir, s.dirMode); err != nil {
+			return fmt.Errorf("chmod %q: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// applyModes applies the store's file and directory modes to root and
+// everything under it. Symlinks are left untouched.
+func (s *LocalStore) applyModes(root string) error {
+	if s.fileMode == 0 && s.dirMode == 0 {
+		return nil
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		mode := s.fileMode
+		if d.IsDir() {
+			mode = s.dirMode
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+		if mode == 0 {
+			return nil
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return fmt.Errorf("chmod %q: %w", path, err)
+		}
+		return nil
+	})
+}
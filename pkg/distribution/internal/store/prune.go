@@ -0,0 +1,76 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+)
+
+// PrunedModel describes a model removed, or that would be removed, by Prune.
+type PrunedModel struct {
+	// ID is the ID of the model.
+	ID string `json:"id"`
+	// Tags are the tags the model had.
+	Tags []string `json:"tags,omitempty"`
+	// Reclaimed is the number of bytes freed by removing the model's blobs.
+	Reclaimed int64 `json:"reclaimed"`
+}
+
+// Prune removes the models for which match returns true, returning them with
+// the bytes reclaimed by removing the blobs no remaining model uses. If dryRun
+// is set, nothing is removed and the models that would be are returned.
+func (s *LocalStore) Prune(match func(IndexEntry) bool, dryRun bool) ([]PrunedModel, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.readIndex()
+	if err != nil {
+		return nil, fmt.Errorf("reading models index: %w", err)
+	}
+
+	pruned := []PrunedModel{}
+	for _, model := range append([]IndexEntry(nil), idx.Models...) {
+		if !match(model) {
+			continue
+		}
+		var reclaimed int64
+		if dryRun {
+			reclaimed = s.unsharedSize(idx, model)
+		} else {
+			if reclaimed, err = s.removeModelFiles(idx, model, nil); err != nil {
+				return pruned, err
+			}
+		}
+		idx = idx.Remove(model.ID)
+		if !dryRun {
+			if err := s.writeIndex(idx); err != nil {
+				return pruned, fmt.Errorf("writing models index: %w", err)
+			}
+		}
+		pruned = append(pruned, PrunedModel{ID: model.ID, Tags: model.Tags, Reclaimed: reclaimed})
+	}
+	return pruned, nil
+}
+
+// unsharedSize returns the total size of the blobs of model that no other
+// model in idx uses.
+func (s *LocalStore) unsharedSize(idx Index, model IndexEntry) int64 {
+	shared := make(map[string]bool)
+	for _, m := range idx.Models {
+		if m.ID == model.ID {
+			continue
+		}
+		for _, file := range s.retainedFiles(m) {
+			shared[file] = true
+		}
+	}
+	var size int64
+	for _, file := range model.Files {
+		if shared[file] {
+			continue
+		}
+		if hash, err := oci.NewHash(file); err == nil {
+			size += s.blobSize(hash)
+		}
+	}
+	return size
+}
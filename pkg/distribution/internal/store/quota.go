@@ -0,0 +1,187 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+)
+
+// touchInterval is the minimum time between persisted updates of a model's
+// last-use timestamp, so that frequent inference requests don't rewrite the
+// index each time.
+const touchInterval = time.Minute
+
+// EvictedModel describes a model removed from the store to stay within its
+// quota.
+type EvictedModel struct {
+	// ID is the ID of the evicted model.
+	ID string
+	// Tags are the tags the model had.
+	Tags []string
+	// Freed is the number of bytes freed by removing the model's blobs.
+	Freed int64
+}
+
+// Touch records that the model matching ref was used for inference at t. Updates within
+// touchInterval of the recorded last use are skipped.
+func (s *LocalStore) Touch(ref string, t time.Time) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.readIndex()
+	if err != nil {
+		return fmt.Errorf("reading models index: %w", err)
+	}
+	_, n, ok := idx.Find(ref)
+	if !ok {
+		return ErrModelNotFound
+	}
+	if t.Sub(idx.Models[n].LastUsed) < touchInterval {
+		return nil
+	}
+	idx.Models[n].LastUsed = t
+	return s.writeIndex(idx)
+}
+
+// MarkPulled records that the model matching ref was pulled at t.
+func (s *LocalStore) MarkPulled(ref string, t time.Time) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.readIndex()
+	if err != nil {
+		return fmt.Errorf("reading models index: %w", err)
+	}
+	_, n, ok := idx.Find(ref)
+	if !ok {
+		return ErrModelNotFound
+	}
+	idx.Models[n].LastPulled = t
+	return s.writeIndex(idx)
+}
+
+// AvailableSpace returns the number of bytes free on the filesystem holding
+// the store.
+func (s *LocalStore) AvailableSpace() (uint64, error) {
+	return availableSpace(s.rootPath)
+}
+
+// Usage returns the total size in bytes of the blobs referenced by models in
+// the store. Blobs shared between models are counted once.
+func (s *LocalStore) Usage() (int64, error) {
+	idx, err := s.readIndex()
+	if err != nil {
+		return 0, fmt.Errorf("reading models index: %w", err)
+	}
+	seen := make(map[string]bool)
+	var usage int64
+	for _, m := range idx.Models {
+		for _, file := range m.Files {
+			if seen[file] {
+				continue
+			}
+			seen[file] = true
+			if hash, err := oci.NewHash(file); err == nil {
+				usage += s.blobSize(hash)
+			}
+		}
+	}
+	return usage, nil
+}
+
+// MakeRoom evicts least recently used models until need more bytes fit within
+// quota, returning the models it evicted. Blobs in keep, such as those of the
+// model about to be written, are never removed, nor are the models whose IDs
+// are in inUse, such as those loaded for inference. It returns
+// ErrQuotaExceeded without evicting anything if need alone exceeds quota.
+//
+// The room made is reserved until release is called, once the model has been
+// written or its write has failed, so that concurrent writes can't together
+// exceed the quota. release is never nil.
+func (s *LocalStore) MakeRoom(need, quota int64, keep, inUse []string) (evicted []EvictedModel, release func(), err error) {
+	release = func() {}
+	if need > quota {
+		return nil, release, fmt.Errorf("%w: needs %d bytes, quota is %d bytes", ErrQuotaExceeded, need, quota)
+	}
+
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	usage, err := s.Usage()
+	if err != nil {
+		return nil, release, err
+	}
+	usage += s.reserved
+
+	if usage+need > quota {
+		s.indexMu.Lock()
+		defer s.indexMu.Unlock()
+		idx, err := s.readIndex()
+		if err != nil {
+			return nil, release, fmt.Errorf("reading models index: %w", err)
+		}
+		kept := make(map[string]bool, len(keep))
+		for _, file := range keep {
+			kept[file] = true
+		}
+		loaded := make(map[string]bool, len(inUse))
+		for _, id := range inUse {
+			loaded[id] = true
+		}
+		candidates := make([]IndexEntry, 0, len(idx.Models))
+		for _, model := range idx.Models {
+			if !loaded[model.ID] {
+				candidates = append(candidates, model)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].LastActive().Before(candidates[j].LastActive())
+		})
+
+		for _, model := range candidates {
+			if usage+need <= quota {
+				break
+			}
+			freed, err := s.removeModelFiles(idx, model, kept)
+			if err != nil {
+				return evicted, release, err
+			}
+			idx = idx.Remove(model.ID)
+			if err := s.writeIndex(idx); err != nil {
+				return evicted, release, fmt.Errorf("writing models index: %w", err)
+			}
+			usage -= freed
+			evicted = append(evicted, EvictedModel{ID: model.ID, Tags: model.Tags, Freed: freed})
+		}
+		if usage+need > quota {
+			return evicted, release, fmt.Errorf("%w: needs %d bytes, %d of %d bytes still in use or reserved after eviction",
+				ErrQuotaExceeded, need, usage, quota)
+		}
+	}
+
+	s.reserved += need
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			s.quotaMu.Lock()
+			defer s.quotaMu.Unlock()
+			s.reserved -= need
+		})
+	}
+	return evicted, release, nil
+}
+
+// blobSize returns the size of the blob with the given hash, or zero if it is
+// missing.
+func (s *LocalStore) blobSize(hash oci.Hash) int64 {
+	path, err := s.blobPath(hash)
+	if err != nil {
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
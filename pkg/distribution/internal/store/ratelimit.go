@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// minRateLimitChunk and maxRateLimitChunk bound the size of the reads a
+	// rate limited reader makes, so that each read waits for roughly a tenth
+	// of a second.
+	minRateLimitChunk = 512
+	maxRateLimitChunk = 256 << 10
+)
+
+// RateLimiter is a token bucket capping the aggregate rate of the readers it
+// wraps. It is safe for concurrent use.
+type RateLimiter struct {
+	// rate is the number of bytes allowed per second.
+	rate float64
+	// chunk is the largest read the wrapped readers make.
+	chunk int
+	// mu guards the fields below.
+	mu sync.Mutex
+	// tokens is the number of bytes that may be read without waiting. It is
+	// negative while readers are waiting.
+	tokens float64
+	// last is when tokens was last updated.
+	last time.Time
+}
+
+// NewRateLimiter returns a limiter allowing bytesPerSecond bytes per second,
+// or nil, meaning no limit, if bytesPerSecond isn't positive.
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		rate:  float64(bytesPerSecond),
+		chunk: int(min(max(bytesPerSecond/10, minRateLimitChunk), maxRateLimitChunk)),
+		last:  time.Now(),
+	}
+}
+
+// reserve takes n bytes from the bucket, returning how long to wait before
+// they may be used. The bucket holds at most one second of tokens.
+func (l *RateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Reader returns r limited by l until ctx is done. A nil limiter returns r
+// unchanged.
+func (l *RateLimiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &rateLimitedReader{ctx: ctx, r: r, limiter: l}
+}
+
+// rateLimitedReader is a reader whose reads wait for a RateLimiter.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *RateLimiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.chunk {
+		p = p[:r.limiter.chunk]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if wait := r.limiter.reserve(n); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-r.ctx.Done():
+				return n, r.ctx.Err()
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+	"github.com/docker/model-runner/pkg/distribution/types"
+)
+
+// maxSafetensorsHeaderSize bounds the header length prefix accepted when
+// sniffing safetensors files. Real headers are a few megabytes at most.
+const maxSafetensorsHeaderSize = 100 << 20
+
+var (
+	ggufMagic = []byte("GGUF")
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// validateWeights checks that the primary weight layer of manifest holds
+// content matching its declared format. Manifests without a weight layer
+// are accepted.
+func (s *LocalStore) validateWeights(manifest *oci.Manifest) error {
+	for _, layer := range manifest.Layers {
+		switch layer.MediaType {
+		case types.MediaTypeGGUF, types.MediaTypeSafetensors, types.MediaTypeDDUF:
+		default:
+			continue
+		}
+		path, err := s.blobPath(layer.Digest)
+		if err != nil {
+			return fmt.Errorf("get blob path: %w", err)
+		}
+		header, err := readHeader(path, 9)
+		if err != nil {
+			return fmt.Errorf("read blob %s: %w", layer.Digest, err)
+		}
+		if !matchesFormat(layer.MediaType, header) {
+			return fmt.Errorf("%w: layer %s does not look like %s content", ErrNotAModel, layer.Digest, layer.MediaType)
+		}
+		return nil
+	}
+	return nil
+}
+
+// readHeader returns up to n leading bytes of the file at path.
+func readHeader(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// matchesFormat reports whether header starts with the magic bytes of the
+// weight format identified by mediaType.
+func matchesFormat(mediaType oci.MediaType, header []byte) bool {
+	switch mediaType {
+	case types.MediaTypeGGUF:
+		return bytes.HasPrefix(header, ggufMagic)
+	case types.MediaTypeSafetensors:
+		// A little-endian header length followed by the JSON header.
+		if len(header) < 9 {
+			return false
+		}
+		size := binary.LittleEndian.Uint64(header[:8])
+		return size > 0 && size <= maxSafetensorsHeaderSize && header[8] == '{'
+	case types.MediaTypeDDUF:
+		return bytes.HasPrefix(header, zipMagic)
+	}
+	return true
+}
+
+// GGUF metadata value types, as defined by the GGUF specification.
+const (
+	ggufTypeUint8   = 0
+	ggufTypeInt8    = 1
+	ggufTypeUint16  = 2
+	ggufTypeInt16   = 3
+	ggufTypeUint32  = 4
+	ggufTypeInt32   = 5
+	ggufTypeFloat32 = 6
+	ggufTypeBool    = 7
+	ggufTypeString  = 8
+	ggufTypeArray   = 9
+	ggufTypeUint64  = 10
+	ggufTypeInt64   = 11
+	ggufTypeFloat64 = 12
+)
+
+// maxGGUFStringSize bounds the length of the GGUF metadata strings read when
+// looking for split.count, to reject corrupt headers.
+const maxGGUFStringSize = 1 << 30
+
+// ggufValueSizes holds the sizes of the fixed-size GGUF metadata value types.
+var ggufValueSizes = map[uint32]int{
+	ggufTypeUint8: 1, ggufTypeInt8: 1, ggufTypeBool: 1,
+	ggufTypeUint16: 2, ggufTypeInt16: 2,
+	ggufTypeUint32: 4, ggufTypeInt32: 4, ggufTypeFloat32: 4,
+	ggufTypeUint64: 8, ggufTypeInt64: 8, ggufTypeFloat64: 8,
+}
+
+// readGGUFSplitCount returns the number of files that the GGUF weights
+// including the file at path are split across, from its split.count
+// metadata, or 1 if they aren't split.
+func readGGUFSplitCount(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	magic := make([]byte, len(ggufMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return 0, fmt.Errorf("read magic: %w", err)
+	}
+	if !bytes.Equal(magic, ggufMagic) {
+		return 0, errors.New("not a GGUF file")
+	}
+	var version uint32
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return 0, fmt.Errorf("read version: %w", err)
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if version&0xffff == 0 {
+		// Big-endian files have their version's bytes swapped.
+		order = binary.BigEndian
+		version = order.Uint32(binary.LittleEndian.AppendUint32(nil, version))
+	}
+	if version < 2 {
+		// Version 1 predates split files.
+		return 1, nil
+	}
+	var header struct {
+		TensorCount uint64
+		KVCount     uint64
+	}
+	if err := binary.Read(r, order, &header); err != nil {
+		return 0, fmt.Errorf("read header: %w", err)
+	}
+
+	for range header.KVCount {
+		key, err := readGGUFString(r, order)
+		if err != nil {
+			return 0, fmt.Errorf("read metadata key: %w", err)
+		}
+		var valueType uint32
+		if err := binary.Read(r, order, &valueType); err != nil {
+			return 0, fmt.Errorf("read type of %s: %w", key, err)
+		}
+		if key != "split.count" {
+			if err := skipGGUFValue(r, order, valueType); err != nil {
+				return 0, fmt.Errorf("read %s: %w", key, err)
+			}
+			continue
+		}
+		size, ok := ggufValueSizes[valueType]
+		if !ok || valueType == ggufTypeBool || valueType == ggufTypeFloat32 || valueType == ggufTypeFloat64 {
+			return 0, fmt.Errorf("split.count has non-integer type %d", valueType)
+		}
+		value := make([]byte, 8)
+		if _, err := io.ReadFull(r, value[:size]); err != nil {
+			return 0, fmt.Errorf("read split.count: %w", err)
+		}
+		if order == binary.BigEndian {
+			// Right-align the value so that it reads the same at any width.
+			copy(value[8-size:], value[:size])
+			clear(value[:8-size])
+		}
+		count := order.Uint64(value)
+		if count > math.MaxUint16 {
+			return 0, fmt.Errorf("invalid split.count %d", count)
+		}
+		return max(int(count), 1), nil
+	}
+	return 1, nil
+}
+
+// readGGUFString reads a length-prefixed GGUF string from r.
+func readGGUFString(r *bufio.Reader, order binary.ByteOrder) (string, error) {
+	var size uint64
+	if err := binary.Read(r, order, &size); err != nil {
+		return "", err
+	}
+	if size > maxGGUFStringSize {
+		return "", fmt.Errorf("string of %d bytes is too long", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// skipGGUFValue discards a GGUF metadata value of the given type from r.
+func skipGGUFValue(r *bufio.Reader, order binary.ByteOrder, valueType uint32) error {
+	if size, ok := ggufValueSizes[valueType]; ok {
+		_, err := r.Discard(size)
+		return err
+	}
+	switch valueType {
+	case ggufTypeString:
+		var size uint64
+		if err := binary.Read(r, order, &size); err != nil {
+			return err
+		}
+		if size > maxGGUFStringSize {
+			return fmt.Errorf("string of %d bytes is too long", size)
+		}
+		_, err := r.Discard(int(size))
+		return err
+	case ggufTypeArray:
+		var array struct {
+			Type  uint32
+			Count uint64
+		}
+		if err := binary.Read(r, order, &array); err != nil {
+			return err
+		}
+		if size, ok := ggufValueSizes[array.Type]; ok {
+			if array.Count > maxGGUFStringSize/uint64(size) {
+				return fmt.Errorf("array of %d values is too long", array.Count)
+			}
+			_, err := r.Discard(int(array.Count) * size)
+			return err
+		}
+		for range array.Count {
+			if err := skipGGUFValue(r, order, array.Type); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown value type %d", valueType)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package store
+
+import "errors"
+
+// availableSpace is not implemented on this platform.
+func availableSpace(string) (uint64, error) {
+	return 0, errors.New("available space is unknown on this platform")
+}
@@ -0,0 +1,17 @@
+package store
+
+import "golang.org/x/sys/windows"
+
+// availableSpace returns the number of bytes available to the current user on
+// the volume holding path.
+func availableSpace(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &available, nil, nil); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	stagingDir = "staging"
+)
+
+// stagingLock serializes the pulls that stage files in the same directory.
+type stagingLock struct {
+	mu sync.Mutex
+	// refs counts the holders and waiters of the lock, so that it can be
+	// forgotten once unused.
+	refs int
+}
+
+// stagingPath returns the path of the staging directory for key.
+func (s *LocalStore) stagingPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.rootPath, stagingDir, hex.EncodeToString(sum[:16]))
+}
+
+// Staging returns a directory inside the store in which a pull identified by
+// key can stage the files it downloads, so that an interrupted pull of the
+// same key can resume from them. The directory is locked until release is
+// called: concurrent pulls of the same key wait for each other rather than
+// writing the same files.
+func (s *LocalStore) Staging(key string) (dir string, release func(), err error) {
+	s.stagingMu.Lock()
+	lock, ok := s.stagingLocks[key]
+	if !ok {
+		lock = &stagingLock{}
+		s.stagingLocks[key] = lock
+	}
+	lock.refs++
+	s.stagingMu.Unlock()
+
+	lock.mu.Lock()
+	release = func() {
+		lock.mu.Unlock()
+		s.stagingMu.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(s.stagingLocks, key)
+		}
+		s.stagingMu.Unlock()
+	}
+
+	s.layoutMu.RLock()
+	defer s.layoutMu.RUnlock()
+	dir = s.stagingPath(key)
+	if err := s.mkdirAll(dir, 0o755); err != nil {
+		release()
+		return "", nil, fmt.Errorf("create staging directory: %w", err)
+	}
+	// Touch the directory so that stale staging cleanup measures its age
+	// from the last pull that used it.
+	now := time.Now()
+	_ = os.Chtimes(dir, now, now)
+	return dir, release, nil
+}
+
+// CleanupStaleStaging removes the staging directories that no pull has used
+// for more than maxAge.
+func (s *LocalStore) CleanupStaleStaging(maxAge time.Duration) error {
+	root := filepath.Join(s.rootPath, stagingDir)
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading staging directory: %w", err)
+	}
+
+	s.stagingMu.Lock()
+	defer s.stagingMu.Unlock()
+	inUse := make(map[string]bool, len(s.stagingLocks))
+	for key := range s.stagingLocks {
+		inUse[filepath.Base(s.stagingPath(key))] = true
+	}
+
+	var cleanedCount int
+	var cleanupErrors []error
+	for _, entry := range entries {
+		if inUse[entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			cleanupErrors = append(cleanupErrors, fmt.Errorf("failed to remove stale staging directory %s: %w", entry.Name(), err))
+		} else {
+			cleanedCount++
+		}
+	}
+	if len(cleanupErrors) > 0 {
+		return fmt.Errorf("encountered %d errors during cleanup (cleaned %d directories): %w", len(cleanupErrors), cleanedCount, cleanupErrors[0])
+	}
+	return nil
+}
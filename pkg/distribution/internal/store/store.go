@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,8 @@ import (
 	"github.com/docker/model-runner/pkg/distribution/internal/progress"
 	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/docker/model-runner/pkg/distribution/oci/remote"
+	"github.com/docker/model-runner/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -22,6 +25,35 @@ const (
 // LocalStore implements the Store interface for local storage
 type LocalStore struct {
 	rootPath string
+	// fileMode and dirMode are the permissions applied to the files and
+	// directories the store creates, regardless of the umask. Zero keeps
+	// the default permissions.
+	fileMode os.FileMode
+	dirMode  os.FileMode
+	// indexMu serializes read-modify-write updates of the models index.
+	indexMu sync.Mutex
+	// aliasMu serializes read-modify-write updates of the alias table.
+	aliasMu sync.Mutex
+	// downloadsMu serializes updates of the pending downloads table and
+	// guards activeDownloads.
+	downloadsMu sync.Mutex
+	// activeDownloads counts the writes in progress of each blob, by diffID.
+	activeDownloads map[string]int
+	// layoutMu is held for writing by Compact and for reading while files
+	// are created, so that compaction neither removes a directory that is
+	// about to be written to nor overwrites a newer version of a file.
+	layoutMu sync.RWMutex
+	// stagingMu guards stagingLocks, the locks of the staging directories
+	// in use, by key.
+	stagingMu    sync.Mutex
+	stagingLocks map[string]*stagingLock
+	// quotaMu serializes quota enforcement and guards reserved, the number
+	// of bytes set aside for models being written.
+	quotaMu  sync.Mutex
+	reserved int64
+	// splitCounts caches the GGUF split.count of bundled weights, by path.
+	// Bundle files are never modified in place, so entries don't go stale.
+	splitCounts sync.Map
 }
 
 // RootPath returns the root path of the store
@@ -32,12 +64,22 @@ func (s *LocalStore) RootPath() string {
 // Options represents options for creating a store
 type Options struct {
 	RootPath string
+	// FileMode is the permission applied to files written to the store,
+	// such as blobs and unpacked bundle files. Zero keeps the default.
+	FileMode os.FileMode
+	// DirMode is the permission applied to directories created in the
+	// store. Zero keeps the default.
+	DirMode os.FileMode
 }
 
 // New creates a new LocalStore
 func New(opts Options) (*LocalStore, error) {
 	store := &LocalStore{
-		rootPath: opts.RootPath,
+		rootPath:        opts.RootPath,
+		fileMode:        opts.FileMode.Perm(),
+		dirMode:         opts.DirMode.Perm(),
+		activeDownloads: make(map[string]int),
+		stagingLocks:    make(map[string]*stagingLock),
 	}
 
 	// Initialize store if it doesn't exist
@@ -52,6 +94,8 @@ func New(opts Options) (*LocalStore, error) {
 // It removes all files and subdirectories within the store's root path, but preserves the root directory itself.
 // This allows the method to work correctly when the store directory is a mounted volume (e.g., in Docker Engine).
 func (s *LocalStore) Reset() error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
 	entries, err := os.ReadDir(s.rootPath)
 	if err != nil {
 		return fmt.Errorf("reading store directory: %w", err)
@@ -89,6 +133,9 @@ func (s *LocalStore) initialize() error {
 		// Log the error but don't fail initialization
 		fmt.Printf("Warning: failed to clean up stale incomplete files: %v\n", err)
 	}
+	if err := s.CleanupStaleStaging(7 * 24 * time.Hour); err != nil {
+		fmt.Printf("Warning: failed to clean up stale staging directories: %v\n", err)
+	}
 
 	return nil
 }
@@ -104,6 +151,8 @@ func (s *LocalStore) List() ([]IndexEntry, error) {
 
 // Delete deletes a model by reference
 func (s *LocalStore) Delete(ref string) (string, []string, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
 	idx, err := s.readIndex()
 	if err != nil {
 		return "", nil, fmt.Errorf("reading models file: %w", err)
@@ -113,9 +162,23 @@ func (s *LocalStore) Delete(ref string) (string, []string, error) {
 		return "", nil, ErrModelNotFound
 	}
 
+	if _, err := s.removeModelFiles(idx, model, nil); err != nil {
+		return "", nil, err
+	}
+
+	idx = idx.Remove(model.ID)
+
+	return model.ID, model.Tags, s.writeIndex(idx)
+}
+
+// removeModelFiles removes the manifest, bundle and blobs of model from disk,
+// leaving blobs that other models in idx or the keep set still reference. It
+// returns the number of bytes freed by removing blobs. The index itself is not
+// updated.
+func (s *LocalStore) removeModelFiles(idx Index, model IndexEntry, keep map[string]bool) (int64, error) {
 	digest, err := oci.NewHash(model.ID)
 	if err != nil {
-		return "", nil, fmt.Errorf("parse manifest digest %q: %w", model.ID, err)
+		return 0, fmt.Errorf("parse manifest digest %q: %w", model.ID, err)
 	}
 
 	// Remove manifest file
@@ -134,13 +197,14 @@ func (s *LocalStore) Delete(ref string) (string, []string, error) {
 		if m.ID == model.ID {
 			continue // Skip the model being deleted
 		}
-		for _, file := range m.Files {
+		for _, file := range s.retainedFiles(m) {
 			blobRefs[file]++
 		}
 	}
 	// Only delete blobs that are not referenced by other models
+	var freed int64
 	for _, blobFile := range model.Files {
-		if blobRefs[blobFile] > 0 {
+		if blobRefs[blobFile] > 0 || keep[blobFile] {
 			// Skip deletion if blob is referenced by other models
 			continue
 		}
@@ -149,19 +213,64 @@ func (s *LocalStore) Delete(ref string) (string, []string, error) {
 			fmt.Printf("Warning: failed to parse blob hash %s: %v\n", blobFile, err)
 			continue
 		}
-		if err := s.removeBlob(hash); err != nil {
+		size := s.blobSize(hash)
+		if err := s.removeBlob(hash); errors.Is(err, os.ErrNotExist) {
+			// The blob was already removed, e.g. by archiving the model.
+			continue
+		} else if err != nil {
 			// Just log the error but don't fail the operation
 			fmt.Printf("Warning: failed to remove blob %q from store: %v\n", hash.String(), err)
+			continue
 		}
+		freed += size
 	}
+	return freed, nil
+}
 
-	idx = idx.Remove(model.ID)
+// retainedFiles returns the blobs of model m that must stay in the store: all
+// of its files, or only its config once it is archived, as archiving gives up
+// its layers.
+func (s *LocalStore) retainedFiles(m IndexEntry) []string {
+	if !m.Archived {
+		return m.Files
+	}
+	_, manifest, err := s.readManifest(m.ID)
+	if err != nil {
+		// Keep everything if unsure what the model needs.
+		return m.Files
+	}
+	return []string{manifest.Config.Digest.String()}
+}
 
-	return model.ID, model.Tags, s.writeIndex(idx)
+// PruneUntagged deletes the model with the given ID if no tags reference it,
+// returning the number of bytes freed by removing blobs that no other model
+// uses. It is a no-op if the model is missing or still tagged.
+func (s *LocalStore) PruneUntagged(id string) (int64, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.readIndex()
+	if err != nil {
+		return 0, fmt.Errorf("reading models index: %w", err)
+	}
+	model, _, ok := idx.Find(id)
+	if !ok || len(model.Tags) > 0 {
+		return 0, nil
+	}
+
+	reclaimed := s.unsharedSize(idx, model)
+	if _, err := s.removeModelFiles(idx, model, nil); err != nil {
+		return 0, err
+	}
+	if err := s.writeIndex(idx.Remove(model.ID)); err != nil {
+		return 0, fmt.Errorf("writing models index: %w", err)
+	}
+	return reclaimed, nil
 }
 
 // AddTags adds tags to an existing model
 func (s *LocalStore) AddTags(ref string, newTags []string) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
 	index, err := s.readIndex()
 	if err != nil {
 		return fmt.Errorf("reading models file: %w", err)
@@ -178,6 +287,8 @@ func (s *LocalStore) AddTags(ref string, newTags []string) error {
 
 // RemoveTags removes tags from models
 func (s *LocalStore) RemoveTags(tags []string) ([]string, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
 	index, err := s.readIndex()
 	if err != nil {
 		return nil, fmt.Errorf("reading modelss index: %w", err)
@@ -198,6 +309,17 @@ func (s *LocalStore) RemoveTags(tags []string) ([]string, error) {
 	return tagRefs, s.writeIndex(index)
 }
 
+// removeIndexEntry removes the model with the given ID from the index.
+func (s *LocalStore) removeIndexEntry(id string) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.readIndex()
+	if err != nil {
+		return fmt.Errorf("reading models index: %w", err)
+	}
+	return s.writeIndex(idx.Remove(id))
+}
+
 // Version returns the store version
 func (s *LocalStore) Version() string {
 	layout, err := s.readLayout()
@@ -226,6 +348,23 @@ type WriteOption func(*writeOptions)
 
 type writeOptions struct {
 	rangeSuccess *remote.RangeSuccess
+	ctx          context.Context
+	rateLimiter  *RateLimiter
+}
+
+// WithContext sets the context used as the parent of per-layer trace spans.
+func WithContext(ctx context.Context) WriteOption {
+	return func(o *writeOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithRateLimiter caps the rate at which layer contents are read, shared
+// with every other write using the same limiter.
+func WithRateLimiter(limiter *RateLimiter) WriteOption {
+	return func(o *writeOptions) {
+		o.rateLimiter = limiter
+	}
 }
 
 // WithRangeSuccess passes a RangeSuccess tracker for resume detection.
@@ -237,15 +376,10 @@ func WithRangeSuccess(rs *remote.RangeSuccess) WriteOption {
 
 // Write writes a model to the store
 func (s *LocalStore) Write(mdl oci.Image, tags []string, w io.Writer, opts ...WriteOption) (err error) {
-	var options writeOptions
+	options := writeOptions{ctx: context.Background()}
 	for _, opt := range opts {
 		opt(&options)
 	}
-	initialIndex, err := s.readIndex()
-	if err != nil {
-		return fmt.Errorf("reading models index: %w", err)
-	}
-
 	type cleanupFunc func() error
 	var cleanups []cleanupFunc
 	success := false
@@ -301,6 +435,36 @@ func (s *LocalStore) Write(mdl oci.Image, tags []string, w io.Writer, opts ...Wr
 		imageSize += size
 	}
 
+	// Record the blobs to download, so that they can be attributed to the
+	// model if the pull is interrupted.
+	var downloads []oci.Hash
+	var downloadSizes []int64
+	for _, layer := range layers {
+		diffID, err := layer.DiffID()
+		if err != nil {
+			return fmt.Errorf("getting layer diffID: %w", err)
+		}
+		if has, err := s.hasBlob(diffID); err != nil || has {
+			continue
+		}
+		size, err := layer.Size()
+		if err != nil {
+			return fmt.Errorf("getting layer size: %w", err)
+		}
+		downloads = append(downloads, diffID)
+		downloadSizes = append(downloadSizes, size)
+	}
+	if len(downloads) > 0 {
+		if err := s.beginDownloads(downloads, downloadSizes, tags); err != nil {
+			return fmt.Errorf("recording downloads: %w", err)
+		}
+		defer func() {
+			if endErr := s.endDownloads(downloads); endErr != nil {
+				fmt.Printf("Warning: failed to record finished downloads: %v\n", endErr)
+			}
+		}()
+	}
+
 	// Create a thread-safe writer wrapper for concurrent progress reporting
 	var safeWriter io.Writer
 	if w != nil {
@@ -329,7 +493,13 @@ func (s *LocalStore) Write(mdl oci.Image, tags []string, w io.Writer, opts ...Wr
 				progressChan = pr.Updates()
 			}
 
-			created, diffID, err := s.writeLayer(l, progressChan, options.rangeSuccess)
+			_, span := tracing.Tracer().Start(options.ctx, "pull.layer")
+			if digest, err := l.Digest(); err == nil {
+				span.SetAttributes(attribute.String("layer.digest", digest.String()))
+			}
+			created, diffID, err := s.writeLayer(l, progressChan, options)
+			tracing.RecordError(span, err)
+			span.End()
 
 			if progressChan != nil {
 				close(progressChan)
@@ -411,13 +581,15 @@ func (s *LocalStore) Write(mdl oci.Image, tags []string, w io.Writer, opts ...Wr
 			}
 			return nil
 		})
+		// Remove the index entry that writing the manifest added, leaving
+		// the entries that other writes have added meanwhile.
+		cleanups = append(cleanups, func() error {
+			if err := s.removeIndexEntry(digest.String()); err != nil {
+				return fmt.Errorf("restore models index: %w", err)
+			}
+			return nil
+		})
 	}
-	cleanups = append(cleanups, func() error {
-		if err := s.writeIndex(initialIndex); err != nil {
-			return fmt.Errorf("restore models index: %w", err)
-		}
-		return nil
-	})
 	if err := s.AddTags(digest.String(), tags); err != nil {
 		return fmt.Errorf("adding tags: %w", err)
 	}
@@ -428,11 +600,6 @@ func (s *LocalStore) Write(mdl oci.Image, tags []string, w io.Writer, opts ...Wr
 // WriteLightweight writes only the manifest and config for a model, assuming layers already exist in the store.
 // This is used for config-only modifications where the layer data hasn't changed.
 func (s *LocalStore) WriteLightweight(mdl oci.Image, tags []string) (err error) {
-	initialIndex, err := s.readIndex()
-	if err != nil {
-		return fmt.Errorf("reading models index: %w", err)
-	}
-
 	type cleanupFunc func() error
 	var cleanups []cleanupFunc
 	success := false
@@ -520,13 +687,15 @@ func (s *LocalStore) WriteLightweight(mdl oci.Image, tags []string) (err error)
 			}
 			return nil
 		})
+		// Remove the index entry that writing the manifest added, leaving
+		// the entries that other writes have added meanwhile.
+		cleanups = append(cleanups, func() error {
+			if err := s.removeIndexEntry(digest.String()); err != nil {
+				return fmt.Errorf("restore models index: %w", err)
+			}
+			return nil
+		})
 	}
-	cleanups = append(cleanups, func() error {
-		if err := s.writeIndex(initialIndex); err != nil {
-			return fmt.Errorf("restore models index: %w", err)
-		}
-		return nil
-	})
 	if err := s.AddTags(digest.String(), tags); err != nil {
 		return fmt.Errorf("adding tags: %w", err)
 	}
@@ -538,6 +707,8 @@ func (s *LocalStore) WriteLightweight(mdl oci.Image, tags []string) (err error)
 // If the function returns a different string, the tag is updated.
 // Returns the number of tags that were migrated.
 func (s *LocalStore) MigrateTags(transform func(string) string) (int, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
 	index, err := s.readIndex()
 	if err != nil {
 		return 0, fmt.Errorf("reading index for migration: %w", err)
@@ -581,7 +752,14 @@ func (s *LocalStore) Read(reference string) (*Model, error) {
 			if err != nil {
 				return nil, fmt.Errorf("parsing hash: %w", err)
 			}
-			return s.newModel(hash, model.Tags)
+			mdl, err := s.newModel(hash, model.Tags)
+			if err != nil {
+				return nil, err
+			}
+			mdl.lastUsed = model.LastUsed
+			mdl.lastPulled = model.LastPulled
+			mdl.archived = model.Archived
+			return mdl, nil
 		}
 	}
 
@@ -3,13 +3,16 @@ package store_test
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/internal/gguf"
 	"github.com/docker/model-runner/pkg/distribution/internal/mutate"
@@ -204,7 +207,7 @@ func TestStoreAPI(t *testing.T) {
 	// Test that Delete removes the blob files
 	t.Run("DeleteRemovesBlobs", func(t *testing.T) {
 		// Create a new model with unique content
-		modelContent := []byte("unique content for blob deletion test")
+		modelContent := ggufContent("unique content for blob deletion test")
 		modelPath := filepath.Join(tempDir, "blob-deletion-test.gguf")
 		if err := os.WriteFile(modelPath, modelContent, 0644); err != nil {
 			t.Fatalf("Failed to create test model file: %v", err)
@@ -263,7 +266,7 @@ func TestStoreAPI(t *testing.T) {
 	// Test that shared blobs between different models are not deleted
 	t.Run("SharedBlobsPreservation", func(t *testing.T) {
 		// Create a model file with content that will be shared
-		sharedContent := []byte("shared content for multiple models test")
+		sharedContent := ggufContent("shared content for multiple models test")
 		sharedModelPath := filepath.Join(tempDir, "shared-model.gguf")
 		if err := os.WriteFile(sharedModelPath, sharedContent, 0644); err != nil {
 			t.Fatalf("Failed to create shared model file: %v", err)
@@ -567,7 +570,7 @@ func TestIncompleteFileHandling(t *testing.T) {
 	tempDir := t.TempDir()
 
 	// Create a temporary model file with known content
-	modelContent := []byte("test model content for incomplete file test")
+	modelContent := ggufContent("test model content for incomplete file test")
 	modelPath := filepath.Join(tempDir, "incomplete-test-model.gguf")
 	if err := os.WriteFile(modelPath, modelContent, 0644); err != nil {
 		t.Fatalf("Failed to create test model file: %v", err)
@@ -854,7 +857,7 @@ func TestResetStore(t *testing.T) {
 			if tt.setupModels > 0 {
 				for i := 0; i < tt.setupModels; i++ {
 					// Create a unique model file for each iteration
-					modelContent := []byte(fmt.Sprintf("unique model content %d", i))
+					modelContent := ggufContent(fmt.Sprintf("unique model content %d", i))
 					modelPath := filepath.Join(tempDir, fmt.Sprintf("model-%d.gguf", i))
 					if err := os.WriteFile(modelPath, modelContent, 0644); err != nil {
 						t.Fatalf("Failed to create model file: %v", err)
@@ -1009,7 +1012,7 @@ func TestMigrateTags(t *testing.T) {
 	}
 
 	// Write another model with a non-HF tag (should be unaffected)
-	mdl2Content := []byte("another model content")
+	mdl2Content := ggufContent("another model content")
 	mdl2Path := filepath.Join(tempDir, "other-model.gguf")
 	if err := os.WriteFile(mdl2Path, mdl2Content, 0644); err != nil {
 		t.Fatalf("Failed to write model file: %v", err)
@@ -1415,3 +1418,347 @@ func TestWriteLightweight(t *testing.T) {
 		}
 	})
 }
+
+// TestWriteRejectsNonModelContent verifies that weight layers whose content
+// does not match their declared format are rejected.
+func TestWriteRejectsNonModelContent(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := store.New(store.Options{
+		RootPath: filepath.Join(tempDir, "model-store"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	textPath := filepath.Join(tempDir, "notes.gguf")
+	if err := os.WriteFile(textPath, []byte("this is just a text file\n"), 0644); err != nil {
+		t.Fatalf("Failed to write text file: %v", err)
+	}
+	mdl, err := gguf.NewModel(textPath)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+
+	err = s.Write(mdl, []string{"ai/not-a-model:latest"}, nil)
+	if !errors.Is(err, store.ErrNotAModel) {
+		t.Fatalf("Expected ErrNotAModel, got %v", err)
+	}
+	models, err := s.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(models) != 0 {
+		t.Errorf("Expected rejected model not to be stored, got %d models", len(models))
+	}
+
+	// The blobs written for the rejected model are removed.
+	layers, err := mdl.Layers()
+	if err != nil {
+		t.Fatalf("Failed to get layers: %v", err)
+	}
+	diffID, err := layers[0].DiffID()
+	if err != nil {
+		t.Fatalf("Failed to get layer diffID: %v", err)
+	}
+	configName, err := mdl.ConfigName()
+	if err != nil {
+		t.Fatalf("Failed to get config name: %v", err)
+	}
+	for _, hash := range []oci.Hash{diffID, configName} {
+		if has, err := s.HasBlob(hash); err != nil || has {
+			t.Errorf("Expected blob %s of the rejected model to be removed", hash)
+		}
+	}
+}
+
+func TestTouchDoesNotLoseConcurrentIndexUpdates(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := store.New(store.Options{RootPath: filepath.Join(tempDir, "touch-model-store")})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	modelPath := filepath.Join(tempDir, "model.gguf")
+	if err := os.WriteFile(modelPath, ggufContent("touch model content"), 0644); err != nil {
+		t.Fatalf("Failed to create model file: %v", err)
+	}
+	mdl, err := gguf.NewModel(modelPath)
+	if err != nil {
+		t.Fatalf("Failed to create model: %v", err)
+	}
+	if err := s.Write(mdl, []string{"touch-model:latest"}, nil); err != nil {
+		t.Fatalf("Failed to write model: %v", err)
+	}
+
+	const tags = 20
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < tags; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := s.AddTags("touch-model:latest", []string{fmt.Sprintf("touch-model:tag-%d", i)}); err != nil {
+				t.Errorf("AddTags failed: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := s.Touch("touch-model:latest", now.Add(time.Duration(i)*time.Hour)); err != nil {
+				t.Errorf("Touch failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	model, err := s.Read("touch-model:latest")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := len(model.Tags()); got != tags+1 {
+		t.Errorf("Expected %d tags after concurrent updates, got %d", tags+1, got)
+	}
+}
+
+func TestMakeRoomEvictsLeastRecentlyUsed(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := store.New(store.Options{RootPath: filepath.Join(tempDir, "quota-model-store")})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		modelPath := filepath.Join(tempDir, fmt.Sprintf("model-%d.gguf", i))
+		if err := os.WriteFile(modelPath, ggufContent(fmt.Sprintf("quota model content %d", i)), 0644); err != nil {
+			t.Fatalf("Failed to create model file: %v", err)
+		}
+		mdl, err := gguf.NewModel(modelPath)
+		if err != nil {
+			t.Fatalf("Failed to create model: %v", err)
+		}
+		if err := s.Write(mdl, []string{fmt.Sprintf("quota-model-%d:latest", i)}, nil); err != nil {
+			t.Fatalf("Failed to write model %d: %v", i, err)
+		}
+	}
+
+	// Use model 1 after model 0, leaving model 2 as the least recently used.
+	now := time.Now()
+	if err := s.Touch("quota-model-0:latest", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	if err := s.Touch("quota-model-1:latest", now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Touch failed: %v", err)
+	}
+	used, err := s.Read("quota-model-0:latest")
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !used.LastUsed().Equal(now.Add(time.Hour)) || used.LastPulled().IsZero() {
+		t.Fatalf("Unexpected timestamps: last used %v, last pulled %v", used.LastUsed(), used.LastPulled())
+	}
+	if err := s.Touch("missing:latest", now); !errors.Is(err, store.ErrModelNotFound) {
+		t.Fatalf("Expected ErrModelNotFound touching a missing model, got %v", err)
+	}
+
+	quota, err := s.Usage()
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+
+	if _, _, err := s.MakeRoom(quota+1, quota, nil, nil); !errors.Is(err, store.ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded for a model larger than the quota, got %v", err)
+	}
+	evicted, release, err := s.MakeRoom(0, quota, nil, nil)
+	if err != nil || len(evicted) != 0 {
+		t.Fatalf("Expected no evictions when the model fits, got %v, %v", evicted, err)
+	}
+	release()
+
+	// quota-model-2 is the least recently used, but in use, so it is kept.
+	modelID := func(tag string) string {
+		mdl, err := s.Read(tag)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		id, err := mdl.ID()
+		if err != nil {
+			t.Fatalf("ID failed: %v", err)
+		}
+		return id
+	}
+	model1, model2 := modelID("quota-model-1:latest"), modelID("quota-model-2:latest")
+	evicted, release, err = s.MakeRoom(1, quota, nil, []string{model2})
+	if err != nil {
+		t.Fatalf("MakeRoom failed: %v", err)
+	}
+	if len(evicted) != 1 || !strings.HasSuffix(evicted[0].Tags[0], "quota-model-0:latest") || evicted[0].Freed == 0 {
+		t.Fatalf("Expected only quota-model-0 to be evicted, got %+v", evicted)
+	}
+	if _, err := s.Read("quota-model-0:latest"); !errors.Is(err, store.ErrModelNotFound) {
+		t.Fatalf("Expected evicted model to be gone, got %v", err)
+	}
+	for _, tag := range []string{"quota-model-1:latest", "quota-model-2:latest"} {
+		if _, err := s.Read(tag); err != nil {
+			t.Fatalf("Expected %s to be kept: %v", tag, err)
+		}
+	}
+
+	// The freed bytes would fit, but one of them is reserved until the first
+	// write completes.
+	freed := evicted[0].Freed
+	inUse := []string{model1, model2}
+	if _, _, err := s.MakeRoom(freed, quota, nil, inUse); !errors.Is(err, store.ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded while room is reserved, got %v", err)
+	}
+	release()
+	evicted, release, err = s.MakeRoom(freed, quota, nil, inUse)
+	if err != nil || len(evicted) != 0 {
+		t.Fatalf("Expected the freed room to fit once released, got %v, %v", evicted, err)
+	}
+	release()
+}
+
+// ggufContent returns a minimal GGUF file (header with no tensors or
+// metadata) followed by payload, which keeps blobs unique per test.
+func ggufContent(payload string) []byte {
+	header := []byte("GGUF\x03\x00\x00\x00" + strings.Repeat("\x00", 16))
+	return append(header, payload...)
+}
+
+func TestCompact(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "compact-store")
+	s, err := store.New(store.Options{RootPath: storePath})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := s.Write(newTestModel(t), []string{"ai/compact:latest"}, nil); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Duplicate the model's entry, as an interrupted rewrite might, and pad
+	// the index with whitespace.
+	indexPath := filepath.Join(storePath, "models.json")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	var idx store.Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		t.Fatalf("Failed to parse index: %v", err)
+	}
+	duplicate := idx.Models[0]
+	duplicate.Tags = []string{"ai/compact:latest", "ai/compact:v2", "ai/compact:v2"}
+	idx.Models = append(idx.Models, duplicate)
+	data, err = json.MarshalIndent(idx, "", "        ")
+	if err != nil {
+		t.Fatalf("Failed to marshal index: %v", err)
+	}
+	if err := os.WriteFile(indexPath, data, 0644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+	for _, dir := range []string{"blobs/sha512", "bundles/sha256/stale"} {
+		if err := os.MkdirAll(filepath.Join(storePath, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+
+	result, err := s.Compact()
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if result.MergedEntries != 1 {
+		t.Errorf("Expected 1 merged entry, got %d", result.MergedEntries)
+	}
+	if result.Inodes != 3 {
+		t.Errorf("Expected 3 directories removed, got %d", result.Inodes)
+	}
+	if result.Bytes <= 0 {
+		t.Errorf("Expected index bytes to be reclaimed, got %d", result.Bytes)
+	}
+	for _, dir := range []string{"blobs/sha512", "bundles/sha256"} {
+		if _, err := os.Stat(filepath.Join(storePath, dir)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected %s to be removed, got %v", dir, err)
+		}
+	}
+
+	models, err := s.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(models) != 1 || len(models[0].Tags) != 2 {
+		t.Fatalf("Expected one model with both tags, got %+v", models)
+	}
+	if _, err := s.BundleForModel("ai/compact:v2"); err != nil {
+		t.Errorf("Failed to use model after compaction: %v", err)
+	}
+
+	result, err = s.Compact()
+	if err != nil {
+		t.Fatalf("Second Compact failed: %v", err)
+	}
+	if result != (store.CompactResult{}) {
+		t.Errorf("Expected nothing to reclaim on a second run, got %+v", result)
+	}
+}
+
+// TestStaging tests that staging directories live in the store and are locked per key
+func TestStaging(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "staging-model-store")
+	s, err := store.New(store.Options{RootPath: storePath})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	dir, release, err := s.Staging("huggingface:org/model@main:latest")
+	if err != nil {
+		t.Fatalf("Staging failed: %v", err)
+	}
+	if !strings.HasPrefix(dir, storePath+string(filepath.Separator)) {
+		t.Errorf("Expected staging directory inside the store, got %q", dir)
+	}
+
+	acquired := make(chan string)
+	go func() {
+		dir, release, err := s.Staging("huggingface:org/model@main:latest")
+		if err != nil {
+			t.Errorf("Staging failed: %v", err)
+			close(acquired)
+			return
+		}
+		defer release()
+		acquired <- dir
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected a second pull of the same key to wait for the first")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Stale staging directories in use are kept
+	if err := s.CleanupStaleStaging(0); err != nil {
+		t.Fatalf("CleanupStaleStaging failed: %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("Expected staging directory in use to be kept: %v", err)
+	}
+
+	release()
+	if got := <-acquired; got != dir {
+		t.Errorf("Expected the same staging directory %q, got %q", dir, got)
+	}
+
+	other, releaseOther, err := s.Staging("huggingface:org/model@main:Q4_K_M")
+	if err != nil {
+		t.Fatalf("Staging failed: %v", err)
+	}
+	releaseOther()
+	if other == dir {
+		t.Errorf("Expected a different staging directory for a different key")
+	}
+
+	// Unused stale staging directories are removed
+	if err := s.CleanupStaleStaging(0); err != nil {
+		t.Fatalf("CleanupStaleStaging failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected stale staging directory to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// IndexEntry is an image together with the platform it targets within an
+// image index.
+type IndexEntry struct {
+	Image    Image
+	Platform Platform
+}
+
+// ImageIndex is an OCI image index referencing a set of platform-specific
+// images.
+type ImageIndex struct {
+	entries     []IndexEntry
+	manifest    IndexManifest
+	rawManifest []byte
+}
+
+// NewImageIndex assembles an image index from entries. Each entry must target
+// a distinct platform.
+func NewImageIndex(entries []IndexEntry) (*ImageIndex, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("image index requires at least one image")
+	}
+
+	manifest := IndexManifest{
+		SchemaVersion: 2,
+		MediaType:     OCIImageIndex,
+		Manifests:     make([]Descriptor, 0, len(entries)),
+	}
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		platform := entry.Platform
+		if seen[platform.String()] {
+			return nil, fmt.Errorf("duplicate platform %q in image index", platform.String())
+		}
+		seen[platform.String()] = true
+
+		mediaType, err := entry.Image.MediaType()
+		if err != nil {
+			return nil, fmt.Errorf("getting media type for %s: %w", platform.String(), err)
+		}
+		digest, err := entry.Image.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("getting digest for %s: %w", platform.String(), err)
+		}
+		size, err := entry.Image.Size()
+		if err != nil {
+			return nil, fmt.Errorf("getting size for %s: %w", platform.String(), err)
+		}
+		manifest.Manifests = append(manifest.Manifests, Descriptor{
+			MediaType: mediaType,
+			Size:      size,
+			Digest:    digest,
+			Platform:  &platform,
+		})
+	}
+
+	raw, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling image index: %w", err)
+	}
+	return &ImageIndex{
+		entries:     entries,
+		manifest:    manifest,
+		rawManifest: raw,
+	}, nil
+}
+
+// Entries returns the images referenced by the index.
+func (i *ImageIndex) Entries() []IndexEntry {
+	return i.entries
+}
+
+// MediaType returns the media type of the index manifest.
+func (i *ImageIndex) MediaType() MediaType {
+	return i.manifest.MediaType
+}
+
+// IndexManifest returns the index manifest.
+func (i *ImageIndex) IndexManifest() *IndexManifest {
+	return &i.manifest
+}
+
+// RawManifest returns the serialized bytes of the index manifest.
+func (i *ImageIndex) RawManifest() []byte {
+	return i.rawManifest
+}
+
+// Digest returns the digest of the index manifest.
+func (i *ImageIndex) Digest() (Hash, error) {
+	h, _, err := SHA256(bytes.NewReader(i.rawManifest))
+	return h, err
+}
+
+// ParsePlatform parses a platform of the form "os/arch[/variant]", e.g.
+// "linux/amd64" or "linux/amd64/cuda".
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Platform{}, fmt.Errorf("invalid platform %q: expected os/arch[/variant]", s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return Platform{}, fmt.Errorf("invalid platform %q: expected os/arch[/variant]", s)
+		}
+	}
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
+
+// String returns the platform in "os/arch[/variant]" form.
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
@@ -9,7 +9,10 @@ const UploadingLayerID = "uploading"
 type Update struct {
 	Complete int64
 	Total    int64
-	Error    error
+	// Skipped is how much of Complete didn't need transferring, such as
+	// bytes a registry already had when pushing.
+	Skipped int64
+	Error   error
 }
 
 // MessageType represents the type of progress message
@@ -38,9 +41,10 @@ const (
 
 // ProgressLayer represents layer information in a progress message
 type ProgressLayer struct {
-	ID      string `json:"id,omitempty"` // Layer ID
-	Size    uint64 `json:"size"`         // Layer size
-	Current uint64 `json:"current"`      // Current bytes transferred
+	ID      string `json:"id,omitempty"`      // Layer ID
+	Size    uint64 `json:"size"`              // Layer size
+	Current uint64 `json:"current"`           // Current bytes transferred
+	Skipped uint64 `json:"skipped,omitempty"` // Bytes of Current that the destination already had
 }
 
 // ProgressMessage represents a structured message for progress reporting
@@ -50,4 +54,10 @@ type ProgressMessage struct {
 	Total   uint64        `json:"total"`
 	Layer   ProgressLayer `json:"layer"` // Current layer information
 	Mode    Mode          `json:"mode"`  // Operation mode: push or pull
+	// Status is the HTTP status an error would have been reported with had
+	// the response status not already been sent, or 0 if unknown.
+	Status int `json:"status,omitempty"`
+	// RetryAfter is how many seconds a rate limited operation was asked to
+	// wait before retrying, or 0 if it wasn't.
+	RetryAfter int `json:"retry_after,omitempty"`
 }
@@ -49,6 +49,15 @@ func (r Repository) RepositoryStr() string {
 	return r.Repository
 }
 
+// Digest returns a reference to the given digest within the repository.
+func (r Repository) Digest(digest string) *Digest {
+	return &Digest{
+		registry:   r.Registry,
+		repository: r.Repository,
+		digest:     digest,
+	}
+}
+
 // Registry represents a registry.
 type Registry struct {
 	registry string
@@ -10,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"runtime"
 	"strings"
 	"sync"
 
@@ -48,6 +49,8 @@ type options struct {
 	keychain  authn.Keychain
 	progress  chan<- oci.Update
 	plainHTTP bool
+	platform  *oci.Platform
+	uploads   *UploadSessions
 }
 
 // WithContext sets the context for remote operations.
@@ -92,6 +95,14 @@ func WithProgress(ch chan<- oci.Update) Option {
 	}
 }
 
+// WithPlatform selects the image for platform when a reference resolves to an
+// image index. It has no effect on references to a single image.
+func WithPlatform(platform *oci.Platform) Option {
+	return func(o *options) {
+		o.platform = platform
+	}
+}
+
 // WithPlainHTTP allows connecting to registries using plain HTTP instead of HTTPS.
 func WithPlainHTTP(plain bool) Option {
 	return func(o *options) {
@@ -99,6 +110,14 @@ func WithPlainHTTP(plain bool) Option {
 	}
 }
 
+// WithUploadSessions sets where interrupted blob uploads are remembered, so
+// that pushing the blobs again resumes their uploads.
+func WithUploadSessions(s *UploadSessions) Option {
+	return func(o *options) {
+		o.uploads = s
+	}
+}
+
 // WithResumeOffsets is a context key for storing resume offsets.
 type resumeOffsetsKey struct{}
 
@@ -301,6 +320,8 @@ type remoteImage struct {
 	ref         reference.Reference
 	resolver    remotes.Resolver
 	desc        v1.Descriptor
+	fromIndex   bool
+	indexDigest string
 	manifest    *oci.Manifest
 	rawManifest []byte
 	store       content.Store
@@ -383,7 +404,8 @@ func createResolverWithPushScope(o *options, ref reference.Reference) (resolverC
 	}
 
 	// Pre-authorize with push scope
-	pr, err := Ping(o.ctx, ref.Context().Registry, o.transport)
+	transport := &rangeTransport{base: o.transport, userAgent: o.userAgent}
+	pr, err := Ping(o.ctx, ref.Context().Registry, transport)
 	if err != nil {
 		// Ping failed, fall back to standard resolver
 		return createResolver(o, ref), nil
@@ -396,7 +418,7 @@ func createResolverWithPushScope(o *options, ref reference.Reference) (resolverC
 
 	// Exchange credentials for a token with push scope
 	scope := ref.Scope(PushScope)
-	tok, err := Exchange(o.ctx, ref.Context().Registry, auth, o.transport,
+	tok, err := Exchange(o.ctx, ref.Context().Registry, auth, transport,
 		[]string{scope}, pr)
 	if err != nil {
 		// Token exchange failed, fall back to standard resolver
@@ -405,7 +427,7 @@ func createResolverWithPushScope(o *options, ref reference.Reference) (resolverC
 
 	// Create transport with the bearer token
 	bearerTransport := &BearerTransport{
-		Transport: &rangeTransport{base: o.transport, userAgent: o.userAgent},
+		Transport: transport,
 		Token:     tok.Token,
 	}
 	client := &http.Client{Transport: bearerTransport}
@@ -455,6 +477,20 @@ func Image(ref reference.Reference, opts ...Option) (oci.Image, error) {
 	}
 	_ = name // we use the original ref
 
+	if d, ok := ref.(*reference.Digest); ok && desc.Digest.String() != d.DigestStr() {
+		return nil, fmt.Errorf("resolving %s: registry returned manifest %s", ref.String(), desc.Digest)
+	}
+
+	fromIndex := oci.MediaType(desc.MediaType).IsIndex()
+	var indexDigest string
+	if fromIndex {
+		indexDigest = desc.Digest.String()
+		desc, err = selectFromIndex(o.ctx, components.resolver, ref, desc, o.platform)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create a temporary content store
 	tmpDir, err := os.MkdirTemp("", "model-runner-remote")
 	if err != nil {
@@ -468,14 +504,104 @@ func Image(ref reference.Reference, opts ...Option) (oci.Image, error) {
 	}
 
 	return &remoteImage{
-		ref:      ref,
-		resolver: components.resolver,
-		desc:     desc,
-		store:    store,
-		ctx:      o.ctx,
+		ref:         ref,
+		resolver:    components.resolver,
+		desc:        desc,
+		fromIndex:   fromIndex,
+		indexDigest: indexDigest,
+		store:       store,
+		ctx:         o.ctx,
 	}, nil
 }
 
+// ErrPlatformNotFound is returned when an image index has no image for the
+// requested platform.
+type ErrPlatformNotFound struct {
+	// Platform is the requested platform.
+	Platform string
+	// Available lists the platforms present in the index.
+	Available []string
+}
+
+func (e *ErrPlatformNotFound) Error() string {
+	return fmt.Sprintf("no variant for platform %q in image index (available: %s)",
+		e.Platform, strings.Join(e.Available, ", "))
+}
+
+// selectFromIndex fetches the image index described by desc and returns the
+// descriptor of the image for platform. Without a platform, the image for the
+// host OS and architecture is preferred, falling back to the first image.
+func selectFromIndex(ctx context.Context, resolver remotes.Resolver, ref reference.Reference, desc v1.Descriptor, platform *oci.Platform) (v1.Descriptor, error) {
+	fetcher, err := resolver.Fetcher(ctx, ref.String())
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("getting fetcher: %w", err)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("fetching image index: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return v1.Descriptor{}, fmt.Errorf("reading image index: %w", err)
+	}
+	if err := verifyDescriptor(desc, data); err != nil {
+		return v1.Descriptor{}, fmt.Errorf("verifying image index: %w", err)
+	}
+
+	var index v1.Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return v1.Descriptor{}, fmt.Errorf("parsing image index: %w", err)
+	}
+	if len(index.Manifests) == 0 {
+		return v1.Descriptor{}, fmt.Errorf("image index for %s is empty", ref.String())
+	}
+
+	platformOf := func(d v1.Descriptor) oci.Platform {
+		if d.Platform == nil {
+			return oci.Platform{}
+		}
+		return oci.Platform{OS: d.Platform.OS, Architecture: d.Platform.Architecture, Variant: d.Platform.Variant}
+	}
+
+	if platform != nil {
+		available := make([]string, 0, len(index.Manifests))
+		for _, m := range index.Manifests {
+			p := platformOf(m)
+			if p.String() == platform.String() {
+				return m, nil
+			}
+			available = append(available, p.String())
+		}
+		return v1.Descriptor{}, &ErrPlatformNotFound{Platform: platform.String(), Available: available}
+	}
+
+	host := oci.Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}
+	for _, m := range index.Manifests {
+		if platformOf(m).String() == host.String() {
+			return m, nil
+		}
+	}
+	for _, m := range index.Manifests {
+		if p := platformOf(m); p.OS == host.OS && p.Architecture == host.Architecture {
+			return m, nil
+		}
+	}
+	return index.Manifests[0], nil
+}
+
+// verifyDescriptor checks that data is the content described by desc.
+func verifyDescriptor(desc v1.Descriptor, data []byte) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return err
+	}
+	if got := desc.Digest.Algorithm().FromBytes(data); got != desc.Digest {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", desc.Digest, got)
+	}
+	return nil
+}
+
 // fetchManifest fetches and caches the manifest.
 func (i *remoteImage) fetchManifest() error {
 	i.mu.Lock()
@@ -501,6 +627,9 @@ func (i *remoteImage) fetchManifest() error {
 	if err != nil {
 		return fmt.Errorf("reading manifest: %w", err)
 	}
+	if err := verifyDescriptor(i.desc, data); err != nil {
+		return fmt.Errorf("verifying manifest: %w", err)
+	}
 
 	i.rawManifest = data
 
@@ -591,6 +720,21 @@ func (i *remoteImage) RawConfigFile() ([]byte, error) {
 	return io.ReadAll(rc)
 }
 
+// FromIndex reports whether the image was selected from an image index.
+func (i *remoteImage) FromIndex() bool {
+	return i.fromIndex
+}
+
+// IndexDigest returns the digest of the image index the image was selected
+// from, or false if it wasn't selected from one.
+func (i *remoteImage) IndexDigest() (oci.Hash, bool) {
+	if !i.fromIndex {
+		return oci.Hash{}, false
+	}
+	digest, err := oci.NewHash(i.indexDigest)
+	return digest, err == nil
+}
+
 // Digest returns the manifest digest.
 func (i *remoteImage) Digest() (oci.Hash, error) {
 	return oci.FromDigest(i.desc.Digest), nil
@@ -735,6 +879,11 @@ func Write(ref reference.Reference, img oci.Image, w io.Writer, opts ...Option)
 		return fmt.Errorf("getting layers: %w", err)
 	}
 
+	// Layers are uploaded directly, skipping those the registry already has
+	// and resuming interrupted uploads where the registry supports it
+	uploader := newBlobUploader(o, components, ref)
+	uploadCtx := docker.WithScope(o.ctx, ref.Scope(PushScope))
+
 	// Create a thread-safe writer wrapper for concurrent progress reporting
 	var safeWriter io.Writer
 	if w != nil {
@@ -754,7 +903,6 @@ func Write(ref reference.Reference, img oci.Image, w io.Writer, opts ...Option)
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			var completed int64
 			digest, err := l.Digest()
 			if err != nil {
 				results[idx] = fmt.Errorf("getting layer digest: %w", err)
@@ -789,66 +937,29 @@ func Write(ref reference.Reference, img oci.Image, w io.Writer, opts ...Option)
 				progressChan = pr.Updates()
 			}
 
-			rc, err := l.Compressed()
-			if err != nil {
-				closeProgress(progressChan)
-				closeReporter(pr)
-				results[idx] = fmt.Errorf("layer %s: getting content: %w", digestStr, err)
-				return
-			}
-			defer rc.Close()
-
-			// Create content writer for push
-			cw, err := pusher.Push(o.ctx, desc)
-			if err != nil {
-				// If already exists, mark as success
-				if errdefs.IsAlreadyExists(err) || strings.Contains(err.Error(), "already exists") {
-					completed += size
-					if progressChan != nil {
-						progressChan <- oci.Update{
-							Complete: completed,
-							Total:    size,
-						}
-					}
-					closeProgress(progressChan)
-					closeReporter(pr)
-					return
+			// A failed existence check isn't fatal, as the upload reports any
+			// real problem with the registry
+			exists, err := uploader.exists(uploadCtx, desc)
+			if err == nil && exists {
+				if progressChan != nil {
+					progressChan <- oci.Update{Complete: size, Total: size, Skipped: size}
 				}
 				closeProgress(progressChan)
 				closeReporter(pr)
-				results[idx] = fmt.Errorf("layer %s: pushing: %w", digestStr, err)
 				return
 			}
-			defer cw.Close()
 
-			// Wrap the reader with progress tracking to report incremental upload progress
-			// Uses the shared progress.Reader from internal/progress package
-			var reader io.Reader = rc
-			if progressChan != nil {
-				reader = progress.NewReaderWithOffset(rc, progressChan, completed)
-			}
-
-			if _, err := io.Copy(cw, reader); err != nil {
+			if err := uploader.upload(uploadCtx, desc, l.Compressed, progressChan); err != nil {
 				closeProgress(progressChan)
 				closeReporter(pr)
-				results[idx] = fmt.Errorf("layer %s: writing: %w", digestStr, err)
+				results[idx] = fmt.Errorf("layer %s: pushing: %w", digestStr, err)
 				return
 			}
 
-			if err := cw.Commit(o.ctx, size, desc.Digest); err != nil {
-				if !errdefs.IsAlreadyExists(err) && !strings.Contains(err.Error(), "already exists") {
-					closeProgress(progressChan)
-					closeReporter(pr)
-					results[idx] = fmt.Errorf("layer %s: committing: %w", digestStr, err)
-					return
-				}
-			}
-
-			// On success or "already exists", update progress to 100%
-			completed += size
+			// On success, update progress to 100%
 			if progressChan != nil {
 				progressChan <- oci.Update{
-					Complete: completed,
+					Complete: size,
 					Total:    size,
 				}
 			}
@@ -927,30 +1038,66 @@ func Write(ref reference.Reference, img oci.Image, w io.Writer, opts ...Option)
 		Digest:    godigest.Digest(manifestDigest.String()),
 		Size:      int64(len(rawManifest)),
 	}
+	return pushManifest(o.ctx, pusher, manifestDesc, rawManifest)
+}
+
+// WriteIndex pushes each image in idx by digest and then the index itself to
+// ref, so that ref resolves to the index.
+func WriteIndex(ref reference.Reference, idx *oci.ImageIndex, w io.Writer, opts ...Option) error {
+	o := makeOptions(opts...)
 
-	cw, err = pusher.Push(o.ctx, manifestDesc)
+	for _, entry := range idx.Entries() {
+		digest, err := entry.Image.Digest()
+		if err != nil {
+			return fmt.Errorf("getting digest for %s: %w", entry.Platform.String(), err)
+		}
+		if err := Write(ref.Context().Digest(digest.String()), entry.Image, w, opts...); err != nil {
+			return fmt.Errorf("pushing %s image: %w", entry.Platform.String(), err)
+		}
+	}
+
+	components, err := createResolverWithPushScope(o, ref)
+	if err != nil {
+		return fmt.Errorf("creating resolver with push scope: %w", err)
+	}
+	pusher, err := components.resolver.Pusher(o.ctx, ref.String())
+	if err != nil {
+		return fmt.Errorf("getting pusher: %w", err)
+	}
+
+	digest, err := idx.Digest()
+	if err != nil {
+		return fmt.Errorf("getting index digest: %w", err)
+	}
+	raw := idx.RawManifest()
+	desc := v1.Descriptor{
+		MediaType: string(idx.MediaType()),
+		Digest:    godigest.Digest(digest.String()),
+		Size:      int64(len(raw)),
+	}
+	return pushManifest(o.ctx, pusher, desc, raw)
+}
+
+// pushManifest pushes a manifest or index, treating one that already exists
+// as success.
+func pushManifest(ctx context.Context, pusher remotes.Pusher, desc v1.Descriptor, raw []byte) error {
+	cw, err := pusher.Push(ctx, desc)
 	if err != nil {
 		if !errdefs.IsAlreadyExists(err) && !strings.Contains(err.Error(), "already exists") {
 			return fmt.Errorf("pushing manifest: %w", err)
 		}
 		return nil
 	}
+	defer cw.Close()
 
-	if _, err := cw.Write(rawManifest); err != nil {
-		cw.Close()
+	if _, err := cw.Write(raw); err != nil {
 		return fmt.Errorf("writing manifest: %w", err)
 	}
-
-	if err := cw.Commit(o.ctx, int64(len(rawManifest)), manifestDesc.Digest); err != nil {
-		cw.Close()
+	if err := cw.Commit(ctx, int64(len(raw)), desc.Digest); err != nil {
 		if !errdefs.IsAlreadyExists(err) && !strings.Contains(err.Error(), "already exists") {
 			return fmt.Errorf("committing manifest: %w", err)
 		}
-		// If it already exists, we still want to close the writer
-		cw.Close()
 	}
-	cw.Close()
-
 	return nil
 }
 
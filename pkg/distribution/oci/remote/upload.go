@@ -0,0 +1,397 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/docker/model-runner/pkg/distribution/internal/progress"
+	"github.com/docker/model-runner/pkg/distribution/oci"
+	"github.com/docker/model-runner/pkg/distribution/oci/reference"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// chunkMinLengthHeader is the header with which a registry advertises
+	// support for chunked uploads, giving the smallest chunk it accepts.
+	chunkMinLengthHeader = "OCI-Chunk-Min-Length"
+	// defaultUploadChunkSize is the size of the chunks blobs are uploaded in
+	// when the registry supports chunked uploads.
+	defaultUploadChunkSize = 64 * 1024 * 1024
+	// maxUploadAttempts is how many times a chunked upload is attempted
+	// before giving up, resuming from what the registry received each time.
+	// It also bounds how many chunks in a row the registry may accept
+	// without receiving any more of the blob.
+	maxUploadAttempts = 5
+)
+
+// UploadSessions remembers the registry upload sessions of blobs whose upload
+// was interrupted, so that pushing them again resumes the upload rather than
+// starting over. It is safe for concurrent use.
+type UploadSessions struct {
+	mu        sync.Mutex
+	locations map[string]string // blob URL -> upload location
+}
+
+// NewUploadSessions returns an empty UploadSessions.
+func NewUploadSessions() *UploadSessions {
+	return &UploadSessions{locations: make(map[string]string)}
+}
+
+func (s *UploadSessions) get(blob string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	location, ok := s.locations[blob]
+	return location, ok
+}
+
+func (s *UploadSessions) set(blob, location string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locations[blob] = location
+}
+
+func (s *UploadSessions) remove(blob string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.locations, blob)
+}
+
+// blobUploader uploads blobs to a repository using the registry API directly,
+// which lets it upload in resumable chunks.
+type blobUploader struct {
+	client     *http.Client
+	authorizer docker.Authorizer
+	// base is the URL of the repository, such as https://host/v2/org/name.
+	base     *url.URL
+	sessions *UploadSessions
+}
+
+func newBlobUploader(o *options, components resolverComponents, ref reference.Reference) *blobUploader {
+	scheme := ref.Context().Registry.Scheme()
+	if components.plainHTTP {
+		scheme = "http"
+	}
+	sessions := o.uploads
+	if sessions == nil {
+		sessions = NewUploadSessions()
+	}
+	return &blobUploader{
+		client:     components.httpClient,
+		authorizer: components.authorizer,
+		base: &url.URL{
+			Scheme: scheme,
+			Host:   ref.Context().Registry.RegistryStr(),
+			Path:   "/v2/" + ref.Context().RepositoryStr(),
+		},
+		sessions: sessions,
+	}
+}
+
+// exists reports whether the repository already has the blob.
+func (u *blobUploader) exists(ctx context.Context, desc v1.Descriptor) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.base.JoinPath("blobs", desc.Digest.String()).String(), http.NoBody)
+	if err != nil {
+		return false, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := u.do(ctx, req)
+	if err != nil {
+		return false, fmt.Errorf("checking blob existence: %w", err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("checking blob existence: unexpected status %s", resp.Status)
+	}
+}
+
+// upload uploads the blob read from open, resuming an earlier upload of it if
+// the registry still has one. Registries advertising chunked uploads are sent
+// the blob in chunks, so that an interrupted chunk can be resent from what the
+// registry received, and others in a single request. Progress is sent to
+// updates, if not nil, with bytes the registry had before this push reported
+// as skipped.
+func (u *blobUploader) upload(ctx context.Context, desc v1.Descriptor, open func() (io.ReadCloser, error), updates chan<- oci.Update) error {
+	blob := u.base.JoinPath("blobs", desc.Digest.String()).String()
+	location, offset, chunkSize, err := u.resume(ctx, blob, desc.Size)
+	if err != nil {
+		return err
+	}
+	if offset > 0 && updates != nil {
+		updates <- oci.Update{Complete: offset, Total: desc.Size, Skipped: offset}
+	}
+
+	if chunkSize == 0 {
+		rc, err := open()
+		if err != nil {
+			return fmt.Errorf("getting content: %w", err)
+		}
+		defer rc.Close()
+		return u.finish(ctx, location, desc, progress.NewReader(rc, updates), desc.Size)
+	}
+
+	var rc io.ReadCloser
+	defer func() {
+		if rc != nil {
+			rc.Close()
+		}
+	}()
+	var reader io.Reader
+	stalled := 0
+	for attempt := 1; offset < desc.Size; {
+		if reader == nil {
+			if rc, err = openAt(open, offset); err != nil {
+				return fmt.Errorf("getting content: %w", err)
+			}
+			reader = progress.NewReaderWithOffset(rc, updates, offset)
+		}
+		n := min(chunkSize, desc.Size-offset)
+		next, received, err := u.patch(ctx, location, offset, n, reader)
+		if err == nil {
+			location = next
+			u.sessions.set(blob, location)
+			if received == offset+n {
+				offset = received
+				continue
+			}
+			// The registry kept a different amount than was sent, so the
+			// content has to be reread from where it stopped.
+			if received > offset {
+				stalled = 0
+			} else if stalled++; stalled >= maxUploadAttempts {
+				return fmt.Errorf("uploading chunk at offset %d: registry received none of the last %d chunks", offset, stalled)
+			}
+			offset = received
+		} else {
+			if ctx.Err() != nil || attempt >= maxUploadAttempts {
+				return fmt.Errorf("uploading chunk at offset %d: %w", offset, err)
+			}
+			attempt++
+			next, received, statusErr := u.status(ctx, location)
+			if statusErr != nil {
+				// The session is kept, as the next push may yet resume it.
+				return fmt.Errorf("uploading chunk at offset %d: %w", offset, errors.Join(err, statusErr))
+			}
+			location, offset = next, received
+		}
+		rc.Close()
+		rc, reader = nil, nil
+	}
+	if err := u.finish(ctx, location, desc, http.NoBody, 0); err != nil {
+		return err
+	}
+	u.sessions.remove(blob)
+	return nil
+}
+
+// resume returns the location of an upload of blob and how much of it the
+// registry has received, continuing a remembered upload if there is one and
+// starting one otherwise. The chunk size is zero if the upload is to be made
+// in a single request.
+func (u *blobUploader) resume(ctx context.Context, blob string, size int64) (string, int64, int64, error) {
+	if location, ok := u.sessions.get(blob); ok {
+		location, offset, err := u.status(ctx, location)
+		if err == nil && offset <= size {
+			return location, offset, defaultUploadChunkSize, nil
+		}
+		u.sessions.remove(blob)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.base.JoinPath("blobs", "uploads").String()+"/", http.NoBody)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := u.do(ctx, req)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("starting upload: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return "", 0, 0, fmt.Errorf("starting upload: %w", unexpectedStatus(resp))
+	}
+	location, err := u.location(resp)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("starting upload: %w", err)
+	}
+	raw := resp.Header.Get(chunkMinLengthHeader)
+	if raw == "" {
+		return location, 0, 0, nil
+	}
+	chunkSize := int64(defaultUploadChunkSize)
+	if minLength, err := strconv.ParseInt(raw, 10, 64); err == nil && minLength > chunkSize {
+		chunkSize = minLength
+	}
+	u.sessions.set(blob, location)
+	return location, 0, chunkSize, nil
+}
+
+// status asks the registry how much of the upload at location it has
+// received, returning the location to continue the upload at.
+func (u *blobUploader) status(ctx context.Context, location string) (string, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, http.NoBody)
+	if err != nil {
+		return "", 0, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := u.do(ctx, req)
+	if err != nil {
+		return "", 0, fmt.Errorf("getting upload status: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return "", 0, fmt.Errorf("getting upload status: %w", unexpectedStatus(resp))
+	}
+	next, err := u.location(resp)
+	if err != nil {
+		next = location
+	}
+	return next, receivedBytes(resp), nil
+}
+
+// patch uploads the next n bytes of reader, which start at offset, returning
+// the location to continue the upload at and how much the registry now has.
+func (u *blobUploader) patch(ctx context.Context, location string, offset, n int64, reader io.Reader) (string, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, location, io.LimitReader(reader, n))
+	if err != nil {
+		return "", 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.ContentLength = n
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+n-1))
+	resp, err := u.do(ctx, req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return "", 0, unexpectedStatus(resp)
+	}
+	next, err := u.location(resp)
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.Header.Get("Range") == "" {
+		return next, offset + n, nil
+	}
+	return next, receivedBytes(resp), nil
+}
+
+// finish completes the upload at location, sending the last n bytes of the
+// blob from body.
+func (u *blobUploader) finish(ctx context.Context, location string, desc v1.Descriptor, body io.Reader, n int64) error {
+	target, err := url.Parse(location)
+	if err != nil {
+		return fmt.Errorf("parsing upload location: %w", err)
+	}
+	query := target.Query()
+	query.Set("digest", desc.Digest.String())
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), body)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.ContentLength = n
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := u.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("completing upload: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("completing upload: %w", unexpectedStatus(resp))
+	}
+	return nil
+}
+
+// do sends req, authorizing it for the repository. Requests without a body
+// are retried once if the registry challenges for credentials.
+func (u *blobUploader) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	for retried := false; ; retried = true {
+		if u.authorizer != nil {
+			if err := u.authorizer.Authorize(ctx, req); err != nil {
+				return nil, fmt.Errorf("authorizing request: %w", err)
+			}
+		}
+		resp, err := u.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || retried || u.authorizer == nil ||
+			(req.Body != nil && req.Body != http.NoBody) {
+			return resp, nil
+		}
+		if err := u.authorizer.AddResponses(ctx, []*http.Response{resp}); err != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		req = req.Clone(ctx)
+	}
+}
+
+// location resolves the Location header of resp against the request URL.
+func (u *blobUploader) location(resp *http.Response) (string, error) {
+	raw := resp.Header.Get("Location")
+	if raw == "" {
+		return "", fmt.Errorf("registry returned no upload location")
+	}
+	location, err := resp.Request.URL.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("parsing upload location: %w", err)
+	}
+	return location.String(), nil
+}
+
+// receivedBytes returns how many bytes of an upload the registry has
+// received, from the Range header of resp, of the form 0-<last offset>.
+func receivedBytes(resp *http.Response) int64 {
+	raw := strings.TrimPrefix(resp.Header.Get("Range"), "bytes=")
+	_, end, ok := strings.Cut(raw, "-")
+	if !ok {
+		return 0
+	}
+	last, err := strconv.ParseInt(end, 10, 64)
+	if err != nil || last < 0 {
+		return 0
+	}
+	return last + 1
+}
+
+// openAt opens the content with open, positioned at offset.
+func openAt(open func() (io.ReadCloser, error), offset int64) (io.ReadCloser, error) {
+	rc, err := open()
+	if err != nil || offset == 0 {
+		return rc, err
+	}
+	var skipErr error
+	if seeker, ok := rc.(io.Seeker); ok {
+		_, skipErr = seeker.Seek(offset, io.SeekStart)
+	} else {
+		_, skipErr = io.CopyN(io.Discard, rc, offset)
+	}
+	if skipErr != nil {
+		rc.Close()
+		return nil, fmt.Errorf("skipping to offset %d: %w", offset, skipErr)
+	}
+	return rc, nil
+}
+
+// unexpectedStatus describes a response with an unexpected status, including
+// the start of its body, which registries use for error details.
+func unexpectedStatus(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, msg)
+	}
+	return fmt.Errorf("unexpected status %s", resp.Status)
+}
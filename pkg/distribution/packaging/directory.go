@@ -12,7 +12,9 @@ import (
 )
 
 // PackageFromDirectory scans a directory for safetensors files and config files,
-// creating a temporary tar archive of the config files.
+// creating a temporary tar archive of the config files. For sharded models
+// without a model.safetensors.index.json, an index is generated from the shard
+// headers and added to the archive; an existing index is preserved as-is.
 // It returns the paths to safetensors files, path to temporary config archive (if created),
 // and any error encountered.
 func PackageFromDirectory(dirPath string) (safetensorsPaths []string, tempConfigArchive string, err error) {
@@ -23,6 +25,7 @@ func PackageFromDirectory(dirPath string) (safetensorsPaths []string, tempConfig
 	}
 
 	var configFiles []string
+	hasIndex := false
 
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -40,6 +43,7 @@ func PackageFromDirectory(dirPath string) (safetensorsPaths []string, tempConfig
 			safetensorsPaths = append(safetensorsPaths, fullPath)
 		case files.FileTypeConfig, files.FileTypeChatTemplate:
 			configFiles = append(configFiles, fullPath)
+			hasIndex = hasIndex || isSafetensorsIndex(name)
 		case files.FileTypeUnknown, files.FileTypeGGUF, files.FileTypeLicense, files.FileTypeDDUF:
 			// Skip these file types
 		}
@@ -52,10 +56,22 @@ func PackageFromDirectory(dirPath string) (safetensorsPaths []string, tempConfig
 	// Sort to ensure reproducible artifacts
 	sort.Strings(safetensorsPaths)
 
+	if len(safetensorsPaths) > 1 && !hasIndex {
+		indexPath, err := writeSafetensorsIndex(safetensorsPaths)
+		if err != nil {
+			return nil, "", fmt.Errorf("generate safetensors index: %w", err)
+		}
+		defer os.RemoveAll(filepath.Dir(indexPath))
+		configFiles = append(configFiles, indexPath)
+	}
+
 	// Create temporary tar archive with config files if any exist
 	if len(configFiles) > 0 {
-		// Sort config files for reproducible tar archive
-		sort.Strings(configFiles)
+		// Sort config files by name for reproducible tar archive (a generated
+		// index lives in a temporary directory)
+		sort.Slice(configFiles, func(i, j int) bool {
+			return filepath.Base(configFiles[i]) < filepath.Base(configFiles[j])
+		})
 
 		tempConfigArchive, err = CreateTempConfigArchive(configFiles)
 		if err != nil {
@@ -2,6 +2,9 @@ package packaging
 
 import (
 	"archive/tar"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -78,8 +81,8 @@ func TestPackageFromDirectory_BasicFunctionality(t *testing.T) {
 
 	// Create test files
 	files := map[string]string{
-		"model-00001-of-00002.safetensors": "safetensors content 1",
-		"model-00002-of-00002.safetensors": "safetensors content 2",
+		"model-00001-of-00002.safetensors": safetensorsShard("layer.0.weight", 8),
+		"model-00002-of-00002.safetensors": safetensorsShard("layer.1.weight", 8),
 		"config.json":                      `{"model_type": "test"}`,
 		"merges.txt":                       "merge1 merge2",
 		"tokenizer.model":                  "tokenizer content",
@@ -132,6 +135,7 @@ func TestPackageFromDirectory_BasicFunctionality(t *testing.T) {
 		"merges.txt",
 		"tokenizer.model",
 		"special_tokens_map.json",
+		SafetensorsIndexFile,
 	}
 	sort.Strings(expectedConfigFiles)
 	sort.Strings(archiveFiles)
@@ -272,6 +276,108 @@ func TestPackageFromDirectory_SkipsSubdirectories(t *testing.T) {
 }
 
 // Helper function to read tar archive and return list of file names
+func TestPackageFromDirectory_SafetensorsIndex(t *testing.T) {
+	t.Run("generated from shard headers", func(t *testing.T) {
+		tempDir := t.TempDir()
+		writeTestFiles(t, tempDir, map[string]string{
+			"model-00001-of-00002.safetensors": safetensorsShard("layer.0.weight", 16),
+			"model-00002-of-00002.safetensors": safetensorsShard("layer.1.weight", 8),
+		})
+
+		_, tempConfigArchive, err := PackageFromDirectory(tempDir)
+		if err != nil {
+			t.Fatalf("PackageFromDirectory failed: %v", err)
+		}
+		defer os.Remove(tempConfigArchive)
+
+		var index safetensorsIndex
+		if err := json.Unmarshal(readTarFile(t, tempConfigArchive, SafetensorsIndexFile), &index); err != nil {
+			t.Fatalf("Failed to parse generated index: %v", err)
+		}
+		if index.Metadata.TotalSize != 24 {
+			t.Errorf("Expected total_size 24, got %d", index.Metadata.TotalSize)
+		}
+		want := map[string]string{
+			"layer.0.weight": "model-00001-of-00002.safetensors",
+			"layer.1.weight": "model-00002-of-00002.safetensors",
+		}
+		if len(index.WeightMap) != len(want) {
+			t.Errorf("Expected weight_map %v, got %v", want, index.WeightMap)
+		}
+		for name, shard := range want {
+			if index.WeightMap[name] != shard {
+				t.Errorf("Expected %s in %s, got %q", name, shard, index.WeightMap[name])
+			}
+		}
+	})
+
+	t.Run("existing index preserved", func(t *testing.T) {
+		tempDir := t.TempDir()
+		const existing = `{"metadata": {"total_size": 1}, "weight_map": {"a": "b"}}`
+		writeTestFiles(t, tempDir, map[string]string{
+			"model-00001-of-00002.safetensors": safetensorsShard("layer.0.weight", 8),
+			"model-00002-of-00002.safetensors": safetensorsShard("layer.1.weight", 8),
+			SafetensorsIndexFile:               existing,
+		})
+
+		_, tempConfigArchive, err := PackageFromDirectory(tempDir)
+		if err != nil {
+			t.Fatalf("PackageFromDirectory failed: %v", err)
+		}
+		defer os.Remove(tempConfigArchive)
+
+		if got := string(readTarFile(t, tempConfigArchive, SafetensorsIndexFile)); got != existing {
+			t.Errorf("Expected index to be preserved verbatim, got %q", got)
+		}
+	})
+}
+
+// safetensorsShard returns a safetensors file holding a single tensor with
+// size bytes of data.
+func safetensorsShard(tensor string, size int) string {
+	header := fmt.Sprintf(`{%q:{"dtype":"U8","shape":[%d],"data_offsets":[0,%d]}}`, tensor, size, size)
+	buf := binary.LittleEndian.AppendUint64(nil, uint64(len(header)))
+	buf = append(buf, header...)
+	buf = append(buf, make([]byte, size)...)
+	return string(buf)
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+}
+
+// readTarFile returns the contents of the named entry in a tar archive.
+func readTarFile(t *testing.T, archivePath, name string) []byte {
+	t.Helper()
+	f, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			t.Fatalf("Entry %s not found in archive", name)
+		}
+		if err != nil {
+			t.Fatalf("Failed to read archive: %v", err)
+		}
+		if hdr.Name == name {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatalf("Failed to read entry %s: %v", name, err)
+			}
+			return data
+		}
+	}
+}
+
 func readTarArchive(archivePath string) ([]string, error) {
 	file, err := os.Open(archivePath)
 	if err != nil {
@@ -0,0 +1,75 @@
+package packaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/distribution/internal/safetensors"
+)
+
+// SafetensorsIndexFile is the index file name backends such as vLLM expect
+// alongside sharded safetensors weights.
+const SafetensorsIndexFile = "model.safetensors.index.json"
+
+// safetensorsIndex is the layout of a model.safetensors.index.json file.
+type safetensorsIndex struct {
+	Metadata  safetensorsIndexMetadata `json:"metadata"`
+	WeightMap map[string]string        `json:"weight_map"`
+}
+
+type safetensorsIndexMetadata struct {
+	TotalSize int64 `json:"total_size"`
+}
+
+// isSafetensorsIndex reports whether name is a safetensors shard index file.
+func isSafetensorsIndex(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".safetensors.index.json")
+}
+
+// BuildSafetensorsIndex builds a model.safetensors.index.json document mapping
+// every tensor in the given shards to the file name of the shard holding it.
+func BuildSafetensorsIndex(shardPaths []string) ([]byte, error) {
+	index := safetensorsIndex{WeightMap: make(map[string]string)}
+	for _, path := range shardPaths {
+		header, err := safetensors.ParseSafetensorsHeader(path)
+		if err != nil {
+			return nil, fmt.Errorf("parse safetensors header of %s: %w", filepath.Base(path), err)
+		}
+		shard := filepath.Base(path)
+		for name, tensor := range header.Tensors {
+			if existing, ok := index.WeightMap[name]; ok {
+				return nil, fmt.Errorf("tensor %q appears in both %s and %s", name, existing, shard)
+			}
+			index.WeightMap[name] = shard
+			index.Metadata.TotalSize += tensor.DataOffsets[1] - tensor.DataOffsets[0]
+		}
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal safetensors index: %w", err)
+	}
+	return data, nil
+}
+
+// writeSafetensorsIndex writes a generated index for shardPaths into a new
+// temporary directory and returns the index path. The caller is responsible
+// for removing the directory.
+func writeSafetensorsIndex(shardPaths []string) (string, error) {
+	data, err := BuildSafetensorsIndex(shardPaths)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "safetensors-index-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp directory: %w", err)
+	}
+	path := filepath.Join(dir, SafetensorsIndexFile)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("write safetensors index: %w", err)
+	}
+	return path, nil
+}
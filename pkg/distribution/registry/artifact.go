@@ -23,3 +23,28 @@ func (a *artifact) Config() (types.ModelConfig, error) {
 func (a *artifact) Descriptor() (types.Descriptor, error) {
 	return partial.Descriptor(a)
 }
+
+// FromIndex reports whether mdl is a remote model that was selected from an
+// image index.
+func FromIndex(mdl types.ModelArtifact) bool {
+	a, ok := mdl.(*artifact)
+	if !ok {
+		return false
+	}
+	fi, ok := a.Image.(interface{ FromIndex() bool })
+	return ok && fi.FromIndex()
+}
+
+// IndexDigest returns the digest of the image index that mdl was selected
+// from, or false if mdl isn't a remote model selected from an index.
+func IndexDigest(mdl types.ModelArtifact) (oci.Hash, bool) {
+	a, ok := mdl.(*artifact)
+	if !ok {
+		return oci.Hash{}, false
+	}
+	id, ok := a.Image.(interface{ IndexDigest() (oci.Hash, bool) })
+	if !ok {
+		return oci.Hash{}, false
+	}
+	return id.IndexDigest()
+}
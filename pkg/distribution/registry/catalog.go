@@ -0,0 +1,161 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/distribution/oci/authn"
+	"github.com/docker/model-runner/pkg/distribution/oci/reference"
+	"github.com/docker/model-runner/pkg/distribution/oci/remote"
+)
+
+const (
+	// catalogPageSize is the number of repositories requested per page of a
+	// registry catalog listing.
+	catalogPageSize = 100
+	// catalogScope is the token scope granting access to the catalog API.
+	catalogScope = "registry:catalog:*"
+)
+
+// Repositories lists the repositories under namespace, a registry host
+// optionally followed by a path, using the registry's catalog API. Each
+// repository is returned as a full name, prefixed by the registry host.
+func (c *Client) Repositories(ctx context.Context, namespace string) ([]string, error) {
+	namespace = strings.Trim(namespace, "/")
+	// Parse a repository under the namespace to resolve its registry.
+	parsedRef, err := reference.ParseReference(namespace+"/catalog", GetDefaultRegistryOptions()...)
+	if err != nil {
+		return nil, NewReferenceError(namespace, err)
+	}
+	reg := parsedRef.Context().Registry
+	prefix := strings.TrimSuffix(parsedRef.Context().RepositoryStr(), "catalog")
+
+	scheme := reg.Scheme()
+	if c.plainHTTP {
+		scheme = "http"
+	}
+	next := fmt.Sprintf("%s://%s/v2/_catalog?n=%d", scheme, reg.RegistryStr(), catalogPageSize)
+	if prefix != "" {
+		// Start after the entries sorting before the namespace.
+		next += "&last=" + url.QueryEscape(strings.TrimSuffix(prefix, "/"))
+	}
+
+	transport, authorized := c.transport, false
+	var repositories []string
+	for next != "" {
+		resp, err := c.getCatalogPage(ctx, transport, next)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && !authorized {
+			resp.Body.Close()
+			if transport, err = c.catalogTransport(ctx, parsedRef); err != nil {
+				return nil, err
+			}
+			authorized = true
+			continue
+		}
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		err = decodeCatalogPage(resp, &page)
+		link := resp.Header.Get("Link")
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		done := false
+		for _, repository := range page.Repositories {
+			if strings.HasPrefix(repository, prefix) {
+				repositories = append(repositories, reg.RegistryStr()+"/"+repository)
+			} else if repository > prefix {
+				// The catalog is sorted, so no later entry is in the namespace.
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+		if next, err = nextCatalogPage(resp.Request.URL, link); err != nil {
+			return nil, err
+		}
+	}
+	return repositories, nil
+}
+
+// getCatalogPage requests a page of a registry catalog.
+func (c *Client) getCatalogPage(ctx context.Context, transport http.RoundTripper, pageURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating catalog request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.UserAgent())
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("listing registry catalog: %w", err)
+	}
+	return resp, nil
+}
+
+// catalogTransport returns a transport authorized to list the catalog of the
+// registry hosting ref.
+func (c *Client) catalogTransport(ctx context.Context, ref reference.Reference) (http.RoundTripper, error) {
+	var auth authn.Authenticator
+	if c.auth != nil {
+		auth = c.auth
+	} else {
+		var err error
+		if auth, err = c.keychain.Resolve(authn.NewResource(ref)); err != nil {
+			return nil, fmt.Errorf("resolving credentials: %w", err)
+		}
+	}
+	pr, err := remote.Ping(ctx, ref.Context().Registry, c.transport)
+	if err != nil {
+		return nil, fmt.Errorf("pinging registry: %w", err)
+	}
+	tok, err := remote.Exchange(ctx, ref.Context().Registry, auth, c.transport, []string{catalogScope}, pr)
+	if err != nil {
+		return nil, fmt.Errorf("getting registry token: %w", err)
+	}
+	return &remote.BearerTransport{Transport: c.transport, Token: tok.Token}, nil
+}
+
+// decodeCatalogPage decodes a catalog page response into page.
+func decodeCatalogPage(resp *http.Response, page any) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: listing registry catalog: %s", ErrUnauthorized, resp.Status)
+	default:
+		return fmt.Errorf("listing registry catalog: unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(page); err != nil {
+		return fmt.Errorf("decoding registry catalog: %w", err)
+	}
+	return nil
+}
+
+// nextCatalogPage returns the URL of the next catalog page from a Link
+// header, resolved against the URL of the current page, or an empty string
+// if there is none.
+func nextCatalogPage(current *url.URL, link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+	target, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(link), "<"), ">")
+	if !ok || !strings.Contains(link, `rel="next"`) {
+		return "", nil
+	}
+	next, err := current.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("parsing registry catalog link: %w", err)
+	}
+	return next.String(), nil
+}
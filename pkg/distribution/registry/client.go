@@ -2,6 +2,7 @@ package registry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -51,9 +52,14 @@ func GetDefaultRegistryOptions() []reference.Option {
 type Client struct {
 	transport http.RoundTripper
 	userAgent string
+	uaSuffix  string
 	keychain  authn.Keychain
 	auth      authn.Authenticator
 	plainHTTP bool
+	platform  *oci.Platform
+	// uploads remembers interrupted blob uploads, so that pushing again
+	// resumes them.
+	uploads *remote.UploadSessions
 }
 
 type ClientOption func(*Client)
@@ -74,6 +80,14 @@ func WithUserAgent(userAgent string) ClientOption {
 	}
 }
 
+// WithUserAgentSuffix appends suffix, in parentheses, to the user agent of
+// every request, e.g. to identify the deployment a request comes from.
+func WithUserAgentSuffix(suffix string) ClientOption {
+	return func(c *Client) {
+		c.uaSuffix = strings.TrimSpace(suffix)
+	}
+}
+
 func WithAuthConfig(username, password string) ClientOption {
 	return func(c *Client) {
 		if username != "" && password != "" {
@@ -101,11 +115,20 @@ func WithPlainHTTP(plain bool) ClientOption {
 	}
 }
 
+// WithPlatform selects the variant for platform when a model reference
+// resolves to an image index.
+func WithPlatform(platform *oci.Platform) ClientOption {
+	return func(c *Client) {
+		c.platform = platform
+	}
+}
+
 func NewClient(opts ...ClientOption) *Client {
 	client := &Client{
 		transport: remote.DefaultTransport,
 		userAgent: DefaultUserAgent,
 		keychain:  authn.DefaultKeychain,
+		uploads:   remote.NewUploadSessions(),
 	}
 	for _, opt := range opts {
 		opt(client)
@@ -119,9 +142,12 @@ func FromClient(base *Client, opts ...ClientOption) *Client {
 	client := &Client{
 		transport: base.transport,
 		userAgent: base.userAgent,
+		uaSuffix:  base.uaSuffix,
 		keychain:  base.keychain,
 		auth:      base.auth,
 		plainHTTP: base.plainHTTP,
+		platform:  base.platform,
+		uploads:   base.uploads,
 	}
 	for _, opt := range opts {
 		opt(client)
@@ -129,6 +155,20 @@ func FromClient(base *Client, opts ...ClientOption) *Client {
 	return client
 }
 
+// Transport returns the HTTP transport used by the client.
+func (c *Client) Transport() http.RoundTripper {
+	return c.transport
+}
+
+// UserAgent returns the user agent sent with registry requests, including
+// any configured suffix.
+func (c *Client) UserAgent() string {
+	if c.uaSuffix == "" {
+		return c.userAgent
+	}
+	return c.userAgent + " (" + c.uaSuffix + ")"
+}
+
 func (c *Client) Model(ctx context.Context, ref string) (types.ModelArtifact, error) {
 	// Parse the reference
 	parsedRef, err := reference.ParseReference(ref, GetDefaultRegistryOptions()...)
@@ -140,7 +180,7 @@ func (c *Client) Model(ctx context.Context, ref string) (types.ModelArtifact, er
 	authOpts := []remote.Option{
 		remote.WithContext(ctx),
 		remote.WithTransport(c.transport),
-		remote.WithUserAgent(c.userAgent),
+		remote.WithUserAgent(c.UserAgent()),
 		remote.WithPlainHTTP(c.plainHTTP),
 	}
 
@@ -151,9 +191,17 @@ func (c *Client) Model(ctx context.Context, ref string) (types.ModelArtifact, er
 		authOpts = append(authOpts, remote.WithAuthFromKeychain(c.keychain))
 	}
 
+	if c.platform != nil {
+		authOpts = append(authOpts, remote.WithPlatform(c.platform))
+	}
+
 	// Return the artifact at the given reference
 	remoteImg, err := remote.Image(parsedRef, authOpts...)
 	if err != nil {
+		var platformErr *remote.ErrPlatformNotFound
+		if errors.As(err, &platformErr) {
+			return nil, err
+		}
 		errStr := err.Error()
 		errStrLower := strings.ToLower(errStr)
 		if strings.Contains(errStr, "UNAUTHORIZED") || strings.Contains(errStrLower, "unauthorized") {
@@ -232,6 +280,7 @@ type Target struct {
 	keychain  authn.Keychain
 	auth      authn.Authenticator
 	plainHTTP bool
+	uploads   *remote.UploadSessions
 }
 
 func (c *Client) NewTarget(tag string) (*Target, error) {
@@ -242,10 +291,11 @@ func (c *Client) NewTarget(tag string) (*Target, error) {
 	return &Target{
 		reference: ref,
 		transport: c.transport,
-		userAgent: c.userAgent,
+		userAgent: c.UserAgent(),
 		keychain:  c.keychain,
 		auth:      c.auth,
 		plainHTTP: c.plainHTTP,
+		uploads:   c.uploads,
 	}, nil
 }
 
@@ -264,12 +314,29 @@ func (t *Target) Write(ctx context.Context, model types.ModelArtifact, progressW
 		imageSize += size
 	}
 
-	// Set up authentication options
+	if err := remote.Write(t.reference, model, progressWriter, t.remoteOptions(ctx)...); err != nil {
+		return fmt.Errorf("write to registry %q: %w", t.reference.String(), err)
+	}
+	return nil
+}
+
+// WriteIndex pushes the images in idx followed by the index itself, so that
+// the target tag resolves to the index.
+func (t *Target) WriteIndex(ctx context.Context, idx *oci.ImageIndex, progressWriter io.Writer) error {
+	if err := remote.WriteIndex(t.reference, idx, progressWriter, t.remoteOptions(ctx)...); err != nil {
+		return fmt.Errorf("write index to registry %q: %w", t.reference.String(), err)
+	}
+	return nil
+}
+
+// remoteOptions returns the options used to talk to the target registry.
+func (t *Target) remoteOptions(ctx context.Context) []remote.Option {
 	authOpts := []remote.Option{
 		remote.WithContext(ctx),
 		remote.WithTransport(t.transport),
 		remote.WithUserAgent(t.userAgent),
 		remote.WithPlainHTTP(t.plainHTTP),
+		remote.WithUploadSessions(t.uploads),
 	}
 
 	// Use direct auth if provided, otherwise fall back to keychain
@@ -278,9 +345,5 @@ func (t *Target) Write(ctx context.Context, model types.ModelArtifact, progressW
 	} else {
 		authOpts = append(authOpts, remote.WithAuthFromKeychain(t.keychain))
 	}
-
-	if err := remote.Write(t.reference, model, progressWriter, authOpts...); err != nil {
-		return fmt.Errorf("write to registry %q: %w", t.reference.String(), err)
-	}
-	return nil
+	return authOpts
 }
@@ -1,7 +1,10 @@
 package registry
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 
@@ -161,3 +164,40 @@ func TestWithUserAgentEmpty(t *testing.T) {
 			client.userAgent, DefaultUserAgent)
 	}
 }
+
+func TestWithUserAgentSuffix(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = append(got, r.UserAgent())
+		mu.Unlock()
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithUserAgentSuffix(" cluster-east "),
+		WithUserAgent("docker-model-runner/1.2"),
+		WithPlainHTTP(true),
+	)
+	want := "docker-model-runner/1.2 (cluster-east)"
+	if ua := client.UserAgent(); ua != want {
+		t.Errorf("UserAgent() = %q, want %q", ua, want)
+	}
+	if ua := FromClient(client).UserAgent(); ua != want {
+		t.Errorf("FromClient UserAgent() = %q, want %q", ua, want)
+	}
+
+	_, _ = client.Model(t.Context(), strings.TrimPrefix(server.URL, "http://")+"/ai/model:latest")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) == 0 {
+		t.Fatal("Expected a request to the registry")
+	}
+	for _, ua := range got {
+		if ua != want {
+			t.Errorf("Registry request User-Agent = %q, want %q", ua, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRateLimitWait is the default longest time a registry request
+	// waits, in total, for a rate limit to clear before failing.
+	DefaultRateLimitWait = 2 * time.Minute
+	// defaultRateLimitDelay is the first delay before retrying a rate
+	// limited request whose response has no Retry-After header. It doubles
+	// with each retry.
+	defaultRateLimitDelay = 5 * time.Second
+	// maxRateLimitRetries bounds the number of times a request is retried.
+	maxRateLimitRetries = 5
+)
+
+// ErrRateLimited is matched by errors caused by a registry rate limit.
+var ErrRateLimited = errors.New("rate limited by registry")
+
+// RateLimitError is returned when a registry keeps rate limiting a request
+// for longer than the transport is willing to wait.
+type RateLimitError struct {
+	// Host is the host that rate limited the request.
+	Host string
+	// RetryAfter is how long the registry asked the client to wait before its
+	// last response, or zero if it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited by registry %s, retry after %s", e.Host, e.RetryAfter)
+	}
+	return fmt.Sprintf("rate limited by registry %s", e.Host)
+}
+
+// Is implements error matching for RateLimitError.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+type rateLimitNotifierKey struct{}
+
+// WithRateLimitNotifier returns a context whose rate limited registry requests
+// call notify with the delay before each retry.
+func WithRateLimitNotifier(ctx context.Context, notify func(wait time.Duration)) context.Context {
+	return context.WithValue(ctx, rateLimitNotifierKey{}, notify)
+}
+
+// NewRateLimitTransport returns a transport that retries requests rate limited
+// by base with 429 Too Many Requests once the delay from the response's
+// Retry-After header has passed, waiting at most maxWait in total for each
+// request. Requests whose body can't be replayed aren't retried. A request
+// still rate limited fails with a RateLimitError.
+func NewRateLimitTransport(base http.RoundTripper, maxWait time.Duration) http.RoundTripper {
+	return &rateLimitTransport{base: base, maxWait: maxWait}
+}
+
+// rateLimitTransport retries rate limited requests.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	maxWait time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		wait := retryAfter
+		if !ok {
+			wait = defaultRateLimitDelay << attempt
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		rateLimited := &RateLimitError{Host: req.URL.Host, RetryAfter: retryAfter}
+		if !replayable || attempt >= maxRateLimitRetries || waited+wait > t.maxWait {
+			return nil, rateLimited
+		}
+
+		if notify, ok := req.Context().Value(rateLimitNotifierKey{}).(func(time.Duration)); ok {
+			notify(wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		waited += wait
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("replaying request body: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, given either as a number of
+// seconds or as an HTTP date, returning the delay it asks for from now.
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(header, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(min(seconds, int64(time.Hour/time.Second))) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -28,13 +31,34 @@ type Registry struct {
 	mu        sync.RWMutex
 	blobs     map[string][]byte            // digest -> content
 	manifests map[string]map[string][]byte // repo -> tag/digest -> manifest
+	uploads   map[string][]byte            // upload ID -> content received
+	// nextUpload numbers upload sessions.
+	nextUpload int
+	// chunkMinLength, if positive, is advertised as the smallest chunk
+	// accepted by chunked uploads.
+	chunkMinLength int64
+}
+
+// Option configures a test registry.
+type Option func(*Registry)
+
+// WithChunkedUploads makes the registry advertise support for chunked
+// uploads, with chunks of at least minLength bytes.
+func WithChunkedUploads(minLength int64) Option {
+	return func(r *Registry) {
+		r.chunkMinLength = minLength
+	}
 }
 
 // New creates a new test registry handler.
-func New() http.Handler {
+func New(opts ...Option) http.Handler {
 	r := &Registry{
 		blobs:     make(map[string][]byte),
 		manifests: make(map[string]map[string][]byte),
+		uploads:   make(map[string][]byte),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
 	return r
 }
@@ -50,6 +74,8 @@ func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	// Route requests
 	switch {
+	case path == "_catalog":
+		r.handleCatalog(w, req)
 	case strings.Contains(path, "/blobs/uploads/"):
 		r.handleBlobUpload(w, req, path)
 	case strings.Contains(path, "/blobs/"):
@@ -62,19 +88,39 @@ func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Registry) handleBlobUpload(w http.ResponseWriter, req *http.Request, path string) {
-	// Parse repo from path
+	// Parse repo and upload ID from path
 	parts := strings.SplitN(path, "/blobs/uploads/", 2)
-	repo := parts[0]
+	repo, uploadID := parts[0], parts[1]
+	location := fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, uploadID)
 
 	switch req.Method {
 	case http.MethodPost:
 		// Start upload
-		uploadID := fmt.Sprintf("upload-%d", len(r.blobs))
-		location := fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, uploadID)
-		w.Header().Set("Location", location)
+		r.mu.Lock()
+		uploadID = fmt.Sprintf("upload-%d", r.nextUpload)
+		r.nextUpload++
+		r.uploads[uploadID] = nil
+		r.mu.Unlock()
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", repo, uploadID))
 		w.Header().Set("Docker-Upload-UUID", uploadID)
+		if r.chunkMinLength > 0 {
+			w.Header().Set("OCI-Chunk-Min-Length", strconv.FormatInt(r.chunkMinLength, 10))
+		}
 		w.WriteHeader(http.StatusAccepted)
 
+	case http.MethodGet:
+		// Report upload status
+		r.mu.RLock()
+		content, ok := r.uploads[uploadID]
+		r.mu.RUnlock()
+		if !ok {
+			http.Error(w, "blob upload unknown", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Location", location)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(content)-1))
+		w.WriteHeader(http.StatusNoContent)
+
 	case http.MethodPut:
 		// Complete upload
 		dgst := req.URL.Query().Get("digest")
@@ -83,14 +129,21 @@ func (r *Registry) handleBlobUpload(w http.ResponseWriter, req *http.Request, pa
 			return
 		}
 
-		content, err := io.ReadAll(req.Body)
+		body, err := io.ReadAll(req.Body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		r.mu.Lock()
+		content := append(r.uploads[uploadID], body...)
+		if digest.FromBytes(content).String() != dgst {
+			r.mu.Unlock()
+			http.Error(w, "digest invalid", http.StatusBadRequest)
+			return
+		}
 		r.blobs[dgst] = content
+		delete(r.uploads, uploadID)
 		r.mu.Unlock()
 
 		w.Header().Set("Docker-Content-Digest", dgst)
@@ -98,19 +151,29 @@ func (r *Registry) handleBlobUpload(w http.ResponseWriter, req *http.Request, pa
 
 	case http.MethodPatch:
 		// Chunked upload - accumulate data
-		content, err := io.ReadAll(req.Body)
+		body, err := io.ReadAll(req.Body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		// For simplicity, compute digest and store directly
-		dgst := digest.FromBytes(content)
 		r.mu.Lock()
-		r.blobs[dgst.String()] = content
+		content, ok := r.uploads[uploadID]
+		if !ok {
+			r.mu.Unlock()
+			http.Error(w, "blob upload unknown", http.StatusNotFound)
+			return
+		}
+		var start, end int
+		if n, _ := fmt.Sscanf(req.Header.Get("Content-Range"), "%d-%d", &start, &end); n == 2 && start != len(content) {
+			r.mu.Unlock()
+			http.Error(w, "range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		content = append(content, body...)
+		r.uploads[uploadID] = content
 		r.mu.Unlock()
 
-		location := req.URL.Path
 		w.Header().Set("Location", location)
 		w.Header().Set("Range", fmt.Sprintf("0-%d", len(content)-1))
 		w.WriteHeader(http.StatusAccepted)
@@ -235,7 +298,7 @@ func (r *Registry) handleManifest(w http.ResponseWriter, req *http.Request, path
 		dgst := digest.FromBytes(manifest)
 		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(manifest)))
 		w.Header().Set("Docker-Content-Digest", dgst.String())
-		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		w.Header().Set("Content-Type", manifestMediaType(manifest))
 
 		if req.Method == http.MethodGet {
 			w.WriteHeader(http.StatusOK)
@@ -269,3 +332,47 @@ func (r *Registry) handleManifest(w http.ResponseWriter, req *http.Request, path
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// manifestMediaType returns the mediaType declared by manifest, defaulting to
+// an OCI image manifest.
+func manifestMediaType(manifest []byte) string {
+	var m struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(manifest, &m); err != nil || m.MediaType == "" {
+		return "application/vnd.oci.image.manifest.v1+json"
+	}
+	return m.MediaType
+}
+
+// handleCatalog lists repositories in lexical order, paginated by the n and
+// last query parameters.
+func (r *Registry) handleCatalog(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.mu.RLock()
+	repositories := make([]string, 0, len(r.manifests))
+	for repo := range r.manifests {
+		repositories = append(repositories, repo)
+	}
+	r.mu.RUnlock()
+	sort.Strings(repositories)
+
+	last := req.URL.Query().Get("last")
+	start := sort.SearchStrings(repositories, last)
+	for start < len(repositories) && repositories[start] <= last {
+		start++
+	}
+	repositories = repositories[start:]
+	if n, err := strconv.Atoi(req.URL.Query().Get("n")); err == nil && n > 0 && n < len(repositories) {
+		repositories = repositories[:n]
+		next := url.Values{"n": {strconv.Itoa(n)}, "last": {repositories[n-1]}}
+		w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?%s>; rel="next"`, next.Encode()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": repositories})
+}
@@ -0,0 +1,191 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultDialTimeout is the default timeout for establishing a TCP connection.
+	DefaultDialTimeout = 30 * time.Second
+	// DefaultTLSHandshakeTimeout is the default timeout for the TLS handshake.
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+	// DefaultResponseHeaderTimeout is the default time to wait for response headers
+	// after the request has been fully written.
+	DefaultResponseHeaderTimeout = 60 * time.Second
+	// DefaultReadTimeout is the default maximum time a response body read may
+	// block without receiving any data.
+	DefaultReadTimeout = 60 * time.Second
+)
+
+// Environment variables that override the transport timeouts. Values use Go
+// duration syntax (e.g. "30s", "2m"); "0" disables the corresponding timeout.
+const (
+	EnvDialTimeout           = "MODEL_RUNNER_REGISTRY_DIAL_TIMEOUT"
+	EnvTLSHandshakeTimeout   = "MODEL_RUNNER_REGISTRY_TLS_HANDSHAKE_TIMEOUT"
+	EnvResponseHeaderTimeout = "MODEL_RUNNER_REGISTRY_RESPONSE_HEADER_TIMEOUT"
+	EnvReadTimeout           = "MODEL_RUNNER_REGISTRY_READ_TIMEOUT"
+	EnvRateLimitWait         = "MODEL_RUNNER_REGISTRY_RATE_LIMIT_WAIT"
+)
+
+// TransportTimeouts holds the timeouts applied to registry and HuggingFace transports.
+type TransportTimeouts struct {
+	// Dial bounds the time taken to establish a TCP connection.
+	Dial time.Duration
+	// TLSHandshake bounds the time taken by the TLS handshake.
+	TLSHandshake time.Duration
+	// ResponseHeader bounds the time to wait for response headers.
+	ResponseHeader time.Duration
+	// Read bounds the time a single body read may stall without data.
+	Read time.Duration
+	// RateLimitWait bounds the total time a rate limited request waits to
+	// be retried.
+	RateLimitWait time.Duration
+}
+
+// DefaultTransportTimeouts returns the default transport timeouts.
+func DefaultTransportTimeouts() TransportTimeouts {
+	return TransportTimeouts{
+		Dial:           DefaultDialTimeout,
+		TLSHandshake:   DefaultTLSHandshakeTimeout,
+		ResponseHeader: DefaultResponseHeaderTimeout,
+		Read:           DefaultReadTimeout,
+		RateLimitWait:  DefaultRateLimitWait,
+	}
+}
+
+// TransportTimeoutsFromEnv returns the default transport timeouts overridden by
+// any of the MODEL_RUNNER_REGISTRY_*_TIMEOUT environment variables. Invalid
+// values are reported in the returned error and leave the default in place.
+func TransportTimeoutsFromEnv() (TransportTimeouts, error) {
+	timeouts := DefaultTransportTimeouts()
+	var errs []error
+	for _, setting := range []struct {
+		env   string
+		value *time.Duration
+	}{
+		{EnvDialTimeout, &timeouts.Dial},
+		{EnvTLSHandshakeTimeout, &timeouts.TLSHandshake},
+		{EnvResponseHeaderTimeout, &timeouts.ResponseHeader},
+		{EnvReadTimeout, &timeouts.Read},
+		{EnvRateLimitWait, &timeouts.RateLimitWait},
+	} {
+		raw := os.Getenv(setting.env)
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("invalid %s value %q", setting.env, raw))
+			continue
+		}
+		*setting.value = d
+	}
+	return timeouts, errors.Join(errs...)
+}
+
+// NewTimeoutTransport returns a transport based on a clone of base with the given
+// timeouts applied. If timeouts.Read is non-zero, response bodies are wrapped so
+// that a read stalling for longer than that duration fails with a ReadTimeoutError.
+// If timeouts.RateLimitWait is non-zero, rate limited requests are retried as
+// described by NewRateLimitTransport.
+func NewTimeoutTransport(base *http.Transport, timeouts TransportTimeouts) http.RoundTripper {
+	var t *http.Transport
+	if base != nil {
+		t = base.Clone()
+	} else if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+		t = dt.Clone()
+	} else {
+		t = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   timeouts.Dial,
+		KeepAlive: 30 * time.Second,
+	}
+	t.DialContext = dialer.DialContext
+	t.TLSHandshakeTimeout = timeouts.TLSHandshake
+	t.ResponseHeaderTimeout = timeouts.ResponseHeader
+
+	var transport http.RoundTripper = t
+	if timeouts.Read > 0 {
+		transport = &readTimeoutTransport{base: t, timeout: timeouts.Read}
+	}
+	if timeouts.RateLimitWait > 0 {
+		transport = NewRateLimitTransport(transport, timeouts.RateLimitWait)
+	}
+	return transport
+}
+
+// ReadTimeoutError is returned when a response body read stalls for longer than
+// the configured read timeout. It implements net.Error and reports itself as a
+// timeout so that callers treat it as a retryable network failure.
+type ReadTimeoutError struct {
+	After time.Duration
+}
+
+func (e *ReadTimeoutError) Error() string {
+	return fmt.Sprintf("registry read timeout: no data received for %s", e.After)
+}
+
+// Timeout implements net.Error.
+func (e *ReadTimeoutError) Timeout() bool { return true }
+
+// Temporary implements net.Error.
+func (e *ReadTimeoutError) Temporary() bool { return true }
+
+// readTimeoutTransport wraps response bodies with an idle read timeout.
+type readTimeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *readTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, err
+	}
+	resp.Body = newTimeoutBody(resp.Body, t.timeout)
+	return resp, nil
+}
+
+// timeoutBody closes the underlying body if a single Read blocks for longer
+// than timeout, unblocking the reader with a ReadTimeoutError.
+type timeoutBody struct {
+	body     io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+func newTimeoutBody(body io.ReadCloser, timeout time.Duration) *timeoutBody {
+	b := &timeoutBody{body: body, timeout: timeout}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.timedOut.Store(true)
+		b.body.Close()
+	})
+	b.timer.Stop()
+	return b
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	n, err := b.body.Read(p)
+	b.timer.Stop()
+	if err != nil && b.timedOut.Load() {
+		return n, &ReadTimeoutError{After: b.timeout}
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	b.timer.Stop()
+	return b.body.Close()
+}
@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransportTimeoutsFromEnv(t *testing.T) {
+	t.Setenv(EnvDialTimeout, "5s")
+	t.Setenv(EnvReadTimeout, "2m")
+	t.Setenv(EnvResponseHeaderTimeout, "bogus")
+
+	timeouts, err := TransportTimeoutsFromEnv()
+	if err == nil {
+		t.Error("Expected error for invalid response header timeout")
+	}
+
+	if timeouts.Dial != 5*time.Second {
+		t.Errorf("Expected dial timeout 5s, got %s", timeouts.Dial)
+	}
+	if timeouts.Read != 2*time.Minute {
+		t.Errorf("Expected read timeout 2m, got %s", timeouts.Read)
+	}
+	if timeouts.ResponseHeader != DefaultResponseHeaderTimeout {
+		t.Errorf("Expected default response header timeout, got %s", timeouts.ResponseHeader)
+	}
+	if timeouts.TLSHandshake != DefaultTLSHandshakeTimeout {
+		t.Errorf("Expected default TLS handshake timeout, got %s", timeouts.TLSHandshake)
+	}
+}
+
+func TestTimeoutTransportStalledRead(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		// Stall the body until the test finishes
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	timeouts := DefaultTransportTimeouts()
+	timeouts.Read = 100 * time.Millisecond
+	client := &http.Client{Transport: NewTimeoutTransport(nil, timeouts)}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if string(data) != "partial" {
+		t.Errorf("Expected to read %q before stalling, got %q", "partial", string(data))
+	}
+
+	var timeoutErr *ReadTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected ReadTimeoutError, got %v", err)
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Expected error to be a net.Error timeout")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{header: "30", want: 30 * time.Second, ok: true},
+		{header: " 0 ", want: 0, ok: true},
+		{header: "86400", want: time.Hour, ok: true},
+		{header: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second, ok: true},
+		{header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, ok: true},
+		{header: "", ok: false},
+		{header: "-5", ok: false},
+		{header: "soon", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %t, want %v, %t", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRateLimitTransportBody(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewRateLimitTransport(http.DefaultTransport, time.Minute)}
+
+	// A replayable body is sent again.
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "payload" || attempts != 2 {
+		t.Errorf("Expected the body to be replayed on a second attempt, got %q after %d attempts", body, attempts)
+	}
+
+	// A body that can't be replayed fails the request.
+	attempts = 0
+	_, err = client.Post(server.URL, "text/plain", io.MultiReader(strings.NewReader("payload")))
+	if !errors.Is(err, ErrRateLimited) || attempts != 1 {
+		t.Errorf("Expected ErrRateLimited after 1 attempt, got %v after %d", err, attempts)
+	}
+}
@@ -16,6 +16,7 @@ type Reader struct {
 	tr          *tar.Reader
 	rawManifest []byte
 	digest      oci.Hash
+	size        int64
 	done        bool
 }
 
@@ -70,6 +71,7 @@ func (r *Reader) Next() (oci.Hash, error) {
 		if len(parts) != 3 || parts[0] != "blobs" && parts[0] != "manifests" {
 			continue
 		}
+		r.size = hdr.Size
 		return oci.Hash{
 			Algorithm: parts[1],
 			Hex:       parts[2],
@@ -77,6 +79,11 @@ func (r *Reader) Next() (oci.Hash, error) {
 	}
 }
 
+// Size returns the size of the blob returned by the last call to Next.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
 func (r *Reader) Read(p []byte) (n int, err error) {
 	return r.tr.Read(p)
 }
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/docker/model-runner/pkg/distribution/oci"
 )
 
@@ -15,6 +17,15 @@ type Model interface {
 	Tags() []string
 	Descriptor() (Descriptor, error)
 	ChatTemplatePath() (string, error)
+	// LastUsed returns when the model was last used for inference, or the
+	// zero time if it never has been.
+	LastUsed() time.Time
+	// LastPulled returns when the model was last pulled or imported, or the
+	// zero time if it is unknown.
+	LastPulled() time.Time
+	// Archived reports whether the model's weights were removed from the
+	// store, so that it must be pulled again before it can be used.
+	Archived() bool
 }
 
 type ModelArtifact interface {
@@ -16,6 +16,57 @@ var ModelsPrefix = "/models"
 // to provide more granular tracking of model usage by source.
 const RequestOriginHeader = "X-Request-Origin"
 
+// ModelLoadingHeader is set to "true" on inference responses whose request had
+// to wait for the model to be loaded (a cold start).
+const ModelLoadingHeader = "X-Model-Loading"
+
+// ModelLoadTimeHeader is set on inference responses whose request waited for
+// a cold start. Its value is how long the model took to load, as a Go
+// duration string.
+const ModelLoadTimeHeader = "X-Model-Load-Time"
+
+// ContextSizeClampedHeader is set on responses to requests whose context size
+// was lowered to the server's maximum. Its value is that maximum.
+const ContextSizeClampedHeader = "X-Context-Size-Clamped"
+
+// MaxTokensHeader advertises the maximum number of tokens an inference request
+// may generate, which also applies to requests that don't set a limit.
+const MaxTokensHeader = "X-Max-Tokens"
+
+// RequestTimeoutHeader advertises the wall-clock timeout for inference
+// requests, as a Go duration string.
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// CacheHeader reports whether a deterministic completion was served from the
+// response cache ("hit") or generated and cached ("miss").
+const CacheHeader = "X-Cache"
+
+// AcceptStreamingHeader, when set to "false" on a generation request, makes
+// the server return a single JSON response even if the request asks for a
+// stream. Clients behind proxies that buffer or break server-sent events can
+// use it instead of changing the request body.
+const AcceptStreamingHeader = "X-Accept-Streaming"
+
+// CoalesceHeader, when set to "true" on a deterministic, non-streaming
+// generation request, lets it share the backend call of an identical request
+// already in flight. "false" opts out when coalescing is enabled by default.
+const CoalesceHeader = "X-Coalesce"
+
+// CoalescedHeader is set to "true" on responses shared from the backend call
+// of an identical in-flight request.
+const CoalescedHeader = "X-Coalesced"
+
+// DebugTimingHeader, when set to "true" on a streamed OpenAI chat or text
+// completion request, adds a "_timing" object to each chunk of the stream
+// with the time it was generated and the index of the token it carries, so
+// that clients can reconstruct inter-token latency. Such requests bypass the
+// response cache and request coalescing.
+const DebugTimingHeader = "X-Debug-Timing"
+
+// TenantHeader names the tenant an inference request is made for, so that
+// its token usage is accounted to it.
+const TenantHeader = "X-Tenant-ID"
+
 // Valid origin values for the RequestOriginHeader.
 const (
 	// OriginOllamaCompletion indicates the request came from the Ollama /api/chat or /api/generate endpoints
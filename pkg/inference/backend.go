@@ -113,6 +113,9 @@ type BackendConfiguration struct {
 	ContextSize  *int32                     `json:"context-size,omitempty"`
 	RuntimeFlags []string                   `json:"runtime-flags,omitempty"`
 	Speculative  *SpeculativeDecodingConfig `json:"speculative,omitempty"`
+	// Env holds environment variables set on the backend process. Only the
+	// variables in AllowedBackendEnv are accepted.
+	Env map[string]string `json:"env,omitempty"`
 
 	// Backend-specific configuration
 	VLLM     *VLLMConfig     `json:"vllm,omitempty"`
@@ -23,13 +23,16 @@ const (
 	// Name is the backend name.
 	Name         = "diffusers"
 	diffusersDir = "/opt/diffusers-env"
+	// bundleModelSubdir is the bundle subdirectory holding unpacked model
+	// files (see the distribution bundle package).
+	bundleModelSubdir = "model"
 )
 
 var (
 	ErrNotImplemented    = errors.New("not implemented")
 	ErrDiffusersNotFound = errors.New("diffusers package not installed")
 	ErrPythonNotFound    = errors.New("python3 not found in PATH")
-	ErrNoDDUFFile        = errors.New("no DDUF file found in model bundle")
+	ErrNoDDUFFile        = errors.New("no DDUF file or diffusers pipeline found in model bundle")
 )
 
 // diffusers is the diffusers-based backend implementation for image generation.
@@ -150,15 +153,20 @@ func (d *diffusers) Run(ctx context.Context, socket, model string, modelRef stri
 		return fmt.Errorf("failed to get model bundle for %s: %w", model, err)
 	}
 
-	// Get the DDUF file path from the bundle
-	ddufPath := bundle.DDUFPath()
-	if ddufPath == "" {
-		return fmt.Errorf("%w: model %s", ErrNoDDUFFile, model)
+	// Get the DDUF file path from the bundle, falling back to the unpacked
+	// pipeline directory for models packaged as individual components
+	modelPath := bundle.DDUFPath()
+	if modelPath == "" {
+		componentsDir := filepath.Join(bundle.RootDir(), bundleModelSubdir)
+		if _, err := os.Stat(filepath.Join(componentsDir, "model_index.json")); err != nil {
+			return fmt.Errorf("%w: model %s", ErrNoDDUFFile, model)
+		}
+		modelPath = componentsDir
 	}
 
-	d.log.Infof("Loading DDUF file from: %s", ddufPath)
+	d.log.Infof("Loading diffusers model from: %s", modelPath)
 
-	args, err := d.config.GetArgs(ddufPath, socket, mode, backendConfig)
+	args, err := d.config.GetArgs(modelPath, socket, mode, backendConfig)
 	if err != nil {
 		return fmt.Errorf("failed to get diffusers arguments: %w", err)
 	}
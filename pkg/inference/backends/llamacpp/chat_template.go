@@ -0,0 +1,111 @@
+package llamacpp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/distribution/types"
+	parser "github.com/gpustack/gguf-parser-go"
+)
+
+const (
+	// ChatTemplateBundled indicates a chat template packaged alongside the
+	// model as template.jinja.
+	ChatTemplateBundled = "bundled"
+	// ChatTemplateEmbedded indicates the chat template embedded in the GGUF
+	// metadata.
+	ChatTemplateEmbedded = "embedded"
+	// ChatTemplateArchitectureDefault indicates a llama.cpp built-in template
+	// chosen from the model architecture.
+	ChatTemplateArchitectureDefault = "architecture-default"
+	// ChatTemplateGeneric indicates that no template was found, leaving
+	// llama.cpp to fall back to its generic one.
+	ChatTemplateGeneric = "generic"
+	// ChatTemplateUnknown indicates that the GGUF metadata couldn't be read,
+	// leaving the choice of template to llama.cpp.
+	ChatTemplateUnknown = "unknown"
+)
+
+// DefaultChatTemplates maps GGUF architectures (general.architecture) to the
+// llama.cpp built-in chat template used when a model has neither a bundled
+// nor an embedded template. Architectures shared by models trained on
+// different formats, such as llama, are deliberately left out.
+var DefaultChatTemplates = map[string]string{
+	"bailingmoe": "bailing",
+	"chatglm":    "chatglm4",
+	"command-r":  "command-r",
+	"deepseek2":  "deepseek2",
+	"exaone":     "exaone3",
+	"gemma":      "gemma",
+	"gemma2":     "gemma",
+	"gemma3":     "gemma",
+	"granite":    "granite",
+	"internlm2":  "chatml",
+	"llama4":     "llama4",
+	"minicpm":    "minicpm",
+	"orion":      "orion",
+	"phi3":       "phi3",
+	"qwen2":      "chatml",
+	"qwen2moe":   "chatml",
+	"qwen3":      "chatml",
+	"qwen3moe":   "chatml",
+	"rwkv6":      "rwkv-world",
+}
+
+// chatTemplate describes the chat template chosen for a model.
+type chatTemplate struct {
+	// source is one of the ChatTemplate* constants.
+	source string
+	// architecture is the model architecture, if known.
+	architecture string
+	// args are the llama.cpp arguments selecting the template.
+	args []string
+	// err is the error reading the GGUF metadata, if any.
+	err error
+}
+
+// chatTemplateFor chooses the chat template for bundle. A bundled template
+// takes precedence over one embedded in the GGUF, which takes precedence over
+// the default for the model architecture. Entries in c.ChatTemplates override
+// DefaultChatTemplates, with an empty value disabling the default.
+func (c *Config) chatTemplateFor(bundle types.ModelBundle) chatTemplate {
+	if path := bundle.ChatTemplatePath(); path != "" {
+		return chatTemplate{source: ChatTemplateBundled, args: []string{"--chat-template-file", path}}
+	}
+	gguf, err := parser.ParseGGUFFile(bundle.GGUFPath())
+	if err != nil {
+		// Leave the choice to llama.cpp, which reports unreadable models.
+		return chatTemplate{source: ChatTemplateUnknown, err: err}
+	}
+	architecture := strings.TrimSpace(gguf.Metadata().Architecture)
+	if kv, ok := gguf.Header.MetadataKV.Get("tokenizer.chat_template"); ok &&
+		kv.ValueType == parser.GGUFMetadataValueTypeString && strings.TrimSpace(kv.ValueString()) != "" {
+		return chatTemplate{source: ChatTemplateEmbedded, architecture: architecture}
+	}
+	name, ok := c.ChatTemplates[architecture]
+	if !ok {
+		name = DefaultChatTemplates[architecture]
+	}
+	if name == "" {
+		return chatTemplate{source: ChatTemplateGeneric, architecture: architecture}
+	}
+	return chatTemplate{
+		source:       ChatTemplateArchitectureDefault,
+		architecture: architecture,
+		args:         []string{"--chat-template", name},
+	}
+}
+
+// String describes the template for logging.
+func (t chatTemplate) String() string {
+	if t.err != nil {
+		return fmt.Sprintf("%s (%v)", t.source, t.err)
+	}
+	if t.source == ChatTemplateArchitectureDefault {
+		return fmt.Sprintf("%s (%s for %s)", t.source, t.args[1], t.architecture)
+	}
+	if t.architecture != "" {
+		return fmt.Sprintf("%s (architecture %s)", t.source, t.architecture)
+	}
+	return t.source
+}
@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/docker/model-runner/pkg/diskusage"
 	"github.com/docker/model-runner/pkg/distribution/oci"
@@ -51,6 +52,10 @@ type llamaCpp struct {
 	config config.BackendConfig
 	// gpuSupported indicates whether the underlying llama-server is built with GPU support.
 	gpuSupported bool
+	// crashLock guards lastCrash.
+	crashLock sync.Mutex
+	// lastCrash describes the most recent unexpected exit of llama-server.
+	lastCrash string
 }
 
 // New creates a new llama.cpp-based backend.
@@ -66,6 +71,12 @@ func New(
 	if conf == nil {
 		conf = NewDefaultLlamaCppConfig()
 	}
+	if c, ok := conf.(*Config); ok && c.Log == nil {
+		// Log through a copy, leaving the caller's configuration untouched.
+		withLog := *c
+		withLog.Log = log
+		conf = &withLog
+	}
 
 	return &llamaCpp{
 		log:                       log,
@@ -172,7 +183,7 @@ func (l *llamaCpp) Run(ctx context.Context, socket, model string, _ string, mode
 		}
 	}
 
-	return backends.RunBackend(ctx, backends.RunnerConfig{
+	err = backends.RunBackend(ctx, backends.RunnerConfig{
 		BackendName:     "llama.cpp",
 		Socket:          socket,
 		BinaryPath:      filepath.Join(binPath, "com.docker.llama-server"),
@@ -182,9 +193,31 @@ func (l *llamaCpp) Run(ctx context.Context, socket, model string, _ string, mode
 		Logger:          l.log,
 		ServerLogWriter: l.serverLog.Writer(),
 	})
+	var crash *backends.CrashError
+	if errors.As(err, &crash) {
+		l.recordCrash(crash)
+	}
+	return err
+}
+
+// recordCrash records crash as the most recent llama-server crash so that it
+// can be reported in the backend status.
+func (l *llamaCpp) recordCrash(crash *backends.CrashError) {
+	reason := fmt.Sprintf("exit code %d", crash.ExitCode)
+	if output := strings.TrimSpace(crash.Output); output != "" {
+		reason += ": " + output
+	}
+	l.crashLock.Lock()
+	l.lastCrash = reason
+	l.crashLock.Unlock()
 }
 
 func (l *llamaCpp) Status() string {
+	l.crashLock.Lock()
+	defer l.crashLock.Unlock()
+	if l.lastCrash != "" {
+		return l.status + " (last crash: " + l.lastCrash + ")"
+	}
 	return l.status
 }
 
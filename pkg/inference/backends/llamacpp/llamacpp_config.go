@@ -7,6 +7,8 @@ import (
 
 	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
 )
 
 const UnlimitedContextSize = -1
@@ -15,6 +17,12 @@ const UnlimitedContextSize = -1
 type Config struct {
 	// Args are the base arguments that are always included.
 	Args []string
+	// ChatTemplates maps GGUF architectures to llama.cpp built-in chat
+	// templates, overriding DefaultChatTemplates for models without a
+	// template of their own. An empty value disables the default.
+	ChatTemplates map[string]string
+	// Log, if set, records which chat template each model uses.
+	Log logging.Logger
 }
 
 // NewDefaultLlamaCppConfig creates a new LlamaCppConfig with default values.
@@ -57,10 +65,11 @@ func (c *Config) GetArgs(bundle types.ModelBundle, socket string, mode inference
 	// Add mode-specific arguments
 	switch mode {
 	case inference.BackendModeCompletion:
-		// Add arguments for chat template file
-		if path := bundle.ChatTemplatePath(); path != "" {
-			args = append(args, "--chat-template-file", path)
+		template := c.chatTemplateFor(bundle)
+		if c.Log != nil {
+			c.Log.Infof("Using %s chat template for %s", template, utils.SanitizeForLog(modelPath, -1))
 		}
+		args = append(args, template.args...)
 	case inference.BackendModeEmbedding:
 		args = append(args, "--embeddings")
 	case inference.BackendModeReranking:
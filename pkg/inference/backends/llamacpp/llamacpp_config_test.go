@@ -1,6 +1,7 @@
 package llamacpp
 
 import (
+	"path/filepath"
 	"runtime"
 	"slices"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/inference"
+	"github.com/sirupsen/logrus"
 )
 
 func TestNewDefaultLlamaCppConfig(t *testing.T) {
@@ -382,6 +384,73 @@ func TestGetArgs(t *testing.T) {
 	}
 }
 
+func TestChatTemplateFor(t *testing.T) {
+	// dummy.gguf is a llama model without an embedded chat template.
+	ggufPath := filepath.Join("..", "..", "..", "..", "assets", "dummy.gguf")
+
+	tests := []struct {
+		name      string
+		bundle    *fakeBundle
+		templates map[string]string
+		source    string
+		args      []string
+	}{
+		{
+			name:   "bundled template",
+			bundle: &fakeBundle{ggufPath: ggufPath, templatePath: "/path/to/template.jinja"},
+			source: ChatTemplateBundled,
+			args:   []string{"--chat-template-file", "/path/to/template.jinja"},
+		},
+		{
+			name:   "no default for architecture",
+			bundle: &fakeBundle{ggufPath: ggufPath},
+			source: ChatTemplateGeneric,
+		},
+		{
+			name:      "overridden default",
+			bundle:    &fakeBundle{ggufPath: ggufPath},
+			templates: map[string]string{"llama": "llama3"},
+			source:    ChatTemplateArchitectureDefault,
+			args:      []string{"--chat-template", "llama3"},
+		},
+		{
+			name:   "unreadable model",
+			bundle: &fakeBundle{ggufPath: "/path/to/model"},
+			source: ChatTemplateUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{ChatTemplates: tt.templates}
+			template := config.chatTemplateFor(tt.bundle)
+			if template.source != tt.source {
+				t.Errorf("Expected source %s, got %s", tt.source, template.source)
+			}
+			if !slices.Equal(template.args, tt.args) {
+				t.Errorf("Expected args %v, got %v", tt.args, template.args)
+			}
+			if (template.err != nil) != (tt.source == ChatTemplateUnknown) {
+				t.Errorf("Expected an error only for an unknown source, got %v", template.err)
+			}
+		})
+	}
+}
+
+func TestNewLeavesConfigUntouched(t *testing.T) {
+	config := NewDefaultLlamaCppConfig()
+	backend, err := New(logrus.New(), nil, nil, "", "", config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Log != nil {
+		t.Error("Expected the caller's configuration to be left without a logger")
+	}
+	if backend.(*llamaCpp).config.(*Config).Log == nil {
+		t.Error("Expected the backend's configuration to log chat templates")
+	}
+}
+
 func TestContainsArg(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/docker/model-runner/pkg/internal/utils"
@@ -16,6 +18,42 @@ import (
 	"github.com/docker/model-runner/pkg/tailbuffer"
 )
 
+// ErrBackendCrashed indicates that a backend process exited while it was
+// expected to be serving.
+var ErrBackendCrashed = errors.New("backend crashed")
+
+// CrashError describes an unexpected exit of a backend process. It matches
+// ErrBackendCrashed under errors.Is.
+type CrashError struct {
+	// Backend is the display name of the backend that crashed.
+	Backend string
+	// ExitCode is the process exit code, or -1 if the process was killed by
+	// a signal or its exit status is unknown.
+	ExitCode int
+	// Output is the tail of the process's error output, after any
+	// ErrorTransformer has been applied.
+	Output string
+	// Err is the error returned when waiting for the process, if any.
+	Err error
+}
+
+func (e *CrashError) Error() string {
+	if e.Output != "" {
+		return fmt.Sprintf("%s terminated unexpectedly: %s failed: %s", e.Backend, e.Backend, e.Output)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s terminated unexpectedly: %s exit status: %v", e.Backend, e.Backend, e.Err)
+	}
+	return fmt.Sprintf("%s terminated unexpectedly with exit code %d", e.Backend, e.ExitCode)
+}
+
+func (e *CrashError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrBackendCrashed}
+	}
+	return []error{ErrBackendCrashed, e.Err}
+}
+
 // ErrorTransformer is a function that transforms raw error output
 // into a more user-friendly message. Backends can provide their own
 // implementation to customize error presentation.
@@ -51,6 +89,56 @@ type Logger interface {
 	Warnln(args ...interface{})
 }
 
+// processObserverKey is the context key for the backend process observer.
+type processObserverKey struct{}
+
+// WithProcessObserver returns a context that causes RunBackend to report the
+// PID of the backend process it starts to fn.
+func WithProcessObserver(ctx context.Context, fn func(pid int)) context.Context {
+	return context.WithValue(ctx, processObserverKey{}, fn)
+}
+
+// errorOutputKey is the context key for the backend error output writer.
+type errorOutputKey struct{}
+
+// WithErrorOutput returns a context that causes RunBackend to copy the error
+// output of the backend process it starts to w.
+func WithErrorOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, errorOutputKey{}, w)
+}
+
+// ErrorOutput returns the writer set by WithErrorOutput, or nil if there is
+// none.
+func ErrorOutput(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(errorOutputKey{}).(io.Writer)
+	return w
+}
+
+// environmentKey is the context key for the backend process environment.
+type environmentKey struct{}
+
+// WithEnvironment returns a context that causes RunBackend to set the given
+// environment variables on the backend process it starts, in addition to
+// those it inherits.
+func WithEnvironment(ctx context.Context, env map[string]string) context.Context {
+	return context.WithValue(ctx, environmentKey{}, env)
+}
+
+// processEnv returns the environment of a backend process started with ctx,
+// or nil to inherit the current environment unchanged.
+func processEnv(ctx context.Context) []string {
+	env, _ := ctx.Value(environmentKey{}).(map[string]string)
+	if len(env) == 0 {
+		return nil
+	}
+	names := slices.Sorted(maps.Keys(env))
+	result := os.Environ()
+	for _, name := range names {
+		result = append(result, name+"="+env[name])
+	}
+	return result
+}
+
 // RunBackend runs a backend process with common error handling and logging.
 // It handles:
 // - Socket cleanup
@@ -75,6 +163,9 @@ func RunBackend(ctx context.Context, config RunnerConfig) error {
 	// Create tail buffer for error output
 	tailBuf := tailbuffer.NewTailBuffer(1024)
 	out := io.MultiWriter(config.ServerLogWriter, tailBuf)
+	if w := ErrorOutput(ctx); w != nil {
+		out = io.MultiWriter(out, w)
+	}
 
 	// Create sandbox with process cancellation
 	backendSandbox, err := sandbox.Create(
@@ -89,6 +180,7 @@ func RunBackend(ctx context.Context, config RunnerConfig) error {
 			}
 			command.Stdout = config.ServerLogWriter
 			command.Stderr = out
+			command.Env = processEnv(ctx)
 		},
 		config.SandboxPath,
 		config.BinaryPath,
@@ -99,29 +191,36 @@ func RunBackend(ctx context.Context, config RunnerConfig) error {
 	}
 	defer backendSandbox.Close()
 
+	if observe, ok := ctx.Value(processObserverKey{}).(func(pid int)); ok {
+		if process := backendSandbox.Command().Process; process != nil {
+			observe(process.Pid)
+		}
+	}
+
 	// Handle backend process errors
-	backendErrors := make(chan error, 1)
+	backendErrors := make(chan *CrashError, 1)
 	go func() {
-		backendErr := backendSandbox.Command().Wait()
+		waitErr := backendSandbox.Command().Wait()
 		config.ServerLogWriter.Close()
 
+		crash := &CrashError{Backend: config.BackendName, ExitCode: -1, Err: waitErr}
+		if state := backendSandbox.Command().ProcessState; state != nil {
+			crash.ExitCode = state.ExitCode()
+		}
+
 		errOutput := new(strings.Builder)
 		if _, err := io.Copy(errOutput, tailBuf); err != nil {
 			config.Logger.Warnf("failed to read server output tail: %v", err)
 		}
-
 		if errOutput.String() != "" {
-			errorMsg := errOutput.String()
+			crash.Output = errOutput.String()
 			// Apply error transformer if provided
 			if config.ErrorTransformer != nil {
-				errorMsg = config.ErrorTransformer(errorMsg)
+				crash.Output = config.ErrorTransformer(crash.Output)
 			}
-			backendErr = fmt.Errorf("%s failed: %s", config.BackendName, errorMsg)
-		} else {
-			backendErr = fmt.Errorf("%s exit status: %w", config.BackendName, backendErr)
 		}
 
-		backendErrors <- backendErr
+		backendErrors <- crash
 		close(backendErrors)
 		if err := os.Remove(config.Socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
 			config.Logger.Warnf("failed to remove socket file %s on exit: %v\n", config.Socket, err)
@@ -135,12 +234,12 @@ func RunBackend(ctx context.Context, config RunnerConfig) error {
 	select {
 	case <-ctx.Done():
 		return nil
-	case backendErr := <-backendErrors:
+	case crash := <-backendErrors:
 		select {
 		case <-ctx.Done():
 			return nil
 		default:
 		}
-		return fmt.Errorf("%s terminated unexpectedly: %w", config.BackendName, backendErr)
+		return crash
 	}
 }
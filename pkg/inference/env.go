@@ -0,0 +1,57 @@
+package inference
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidBackendEnv indicates that a backend configuration sets an
+// environment variable that isn't allowed, or sets one to an unsafe value.
+var ErrInvalidBackendEnv = errors.New("invalid backend environment")
+
+// AllowedBackendEnv contains the environment variables that may be set on a
+// backend process through its configuration. Variables that take paths, or
+// that backends map onto command line flags, are intentionally excluded for
+// security.
+var AllowedBackendEnv = map[string]bool{
+	// Threading
+	"OMP_NUM_THREADS":      true,
+	"MKL_NUM_THREADS":      true,
+	"OPENBLAS_NUM_THREADS": true,
+
+	// Device selection
+	"CUDA_VISIBLE_DEVICES":    true,
+	"HIP_VISIBLE_DEVICES":     true,
+	"ROCR_VISIBLE_DEVICES":    true,
+	"GGML_VK_VISIBLE_DEVICES": true,
+
+	// ggml tuning
+	"GGML_CUDA_ENABLE_UNIFIED_MEMORY": true,
+	"GGML_CUDA_NO_PINNED":             true,
+	"GGML_SCHED_MAX_COPIES":           true,
+	"GGML_VK_DISABLE_F16":             true,
+
+	// PyTorch and vLLM tuning
+	"PYTORCH_CUDA_ALLOC_CONF": true,
+	"TOKENIZERS_PARALLELISM":  true,
+	"VLLM_ATTENTION_BACKEND":  true,
+	"VLLM_USE_V1":             true,
+}
+
+// ValidateBackendEnv checks that env only sets allowed variables, to values
+// that contain no paths or control characters.
+func ValidateBackendEnv(env map[string]string) error {
+	for name, value := range env {
+		if !AllowedBackendEnv[name] {
+			return fmt.Errorf("%w: environment variable %q is not allowed", ErrInvalidBackendEnv, name)
+		}
+		if strings.ContainsAny(value, "/\\") {
+			return fmt.Errorf("%w: invalid value for environment variable %q: paths are not allowed", ErrInvalidBackendEnv, name)
+		}
+		if strings.ContainsFunc(value, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+			return fmt.Errorf("%w: invalid value for environment variable %q: control characters are not allowed", ErrInvalidBackendEnv, name)
+		}
+	}
+	return nil
+}
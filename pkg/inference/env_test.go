@@ -0,0 +1,32 @@
+package inference
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateBackendEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expectError bool
+	}{
+		{"nil env", nil, false},
+		{"allowed variables", map[string]string{"OMP_NUM_THREADS": "8", "CUDA_VISIBLE_DEVICES": "0,1"}, false},
+		{"unknown variable", map[string]string{"LD_PRELOAD": "evil.so"}, true},
+		{"lowercase variable", map[string]string{"omp_num_threads": "8"}, true},
+		{"path value", map[string]string{"OMP_NUM_THREADS": "/etc/passwd"}, true},
+		{"windows path value", map[string]string{"OMP_NUM_THREADS": `C:\x`}, true},
+		{"newline value", map[string]string{"OMP_NUM_THREADS": "8\nLD_PRELOAD=x"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBackendEnv(tt.env)
+			if tt.expectError && !errors.Is(err, ErrInvalidBackendEnv) {
+				t.Errorf("Expected ErrInvalidBackendEnv, got %v", err)
+			} else if !tt.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
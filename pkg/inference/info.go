@@ -0,0 +1,48 @@
+package inference
+
+// InfoPath is the route serving the runner's effective configuration.
+var InfoPath = InferencePrefix + "/info"
+
+// RunnerInfo describes how a model runner interpreted its environment, for
+// diagnosing misconfiguration. Secrets are redacted.
+type RunnerInfo struct {
+	// ModelsPath is the root of the model store.
+	ModelsPath string `json:"models_path"`
+	// Listen is the address the runner serves its API on, either a TCP port
+	// ("tcp::12434") or a Unix socket ("unix:model-runner.sock").
+	Listen string `json:"listen"`
+	// TLSListen is the address of the TLS listener, if enabled.
+	TLSListen string `json:"tls_listen,omitempty"`
+	// AllowedOrigins are the origins allowed to make cross-origin requests.
+	AllowedOrigins []string `json:"allowed_origins"`
+	// DefaultBackend is the backend used when a request doesn't name one.
+	DefaultBackend string `json:"default_backend"`
+	// Backends describes the registered inference backends.
+	Backends []BackendInfo `json:"backends"`
+	// LlamaServerVersion is the desired llama.cpp server version.
+	LlamaServerVersion string `json:"llama_server_version"`
+	// LlamaArgs are the extra llama.cpp arguments set with LLAMA_ARGS.
+	LlamaArgs []string `json:"llama_args,omitempty"`
+	// DefaultContextSize is the server-wide default context size, if set.
+	DefaultContextSize *int32 `json:"default_context_size,omitempty"`
+	// MaxContextSize is the largest context size allowed, if capped.
+	MaxContextSize *int32 `json:"max_context_size,omitempty"`
+	// IdleTimeout is how long unused runners stay loaded.
+	IdleTimeout string `json:"idle_timeout"`
+	// BackendStartTimeout is how long backends may take to become ready.
+	BackendStartTimeout string `json:"backend_start_timeout"`
+	// Features reports which optional features are enabled.
+	Features map[string]bool `json:"features"`
+	// Environment holds the runner's configuration environment variables.
+	Environment map[string]string `json:"environment"`
+}
+
+// BackendInfo describes an inference backend.
+type BackendInfo struct {
+	// Name is the backend's name.
+	Name string `json:"name"`
+	// Path is the custom server path configured for the backend, if any.
+	Path string `json:"path,omitempty"`
+	// Status is the backend's status, including its version once installed.
+	Status string `json:"status"`
+}
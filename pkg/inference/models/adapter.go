@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/types"
 )
@@ -26,15 +27,29 @@ func ToModel(m types.Model) (*Model, error) {
 	if desc.Created != nil {
 		created = desc.Created.Unix()
 	}
+	capabilities := ModelCapabilities(m, cfg)
 
 	return &Model{
-		ID:      id,
-		Tags:    m.Tags(),
-		Created: created,
-		Config:  cfg,
+		ID:           id,
+		Tags:         m.Tags(),
+		Created:      created,
+		LastUsed:     unixOrZero(m.LastUsed()),
+		LastPulled:   unixOrZero(m.LastPulled()),
+		Archived:     m.Archived(),
+		Config:       cfg,
+		Capabilities: &capabilities,
 	}, nil
 }
 
+// unixOrZero returns t as a Unix epoch timestamp, or zero if t is the zero
+// time.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
 // ToModelFromArtifact converts a types.ModelArtifact (typically from remote registry)
 // to the API Model representation. Remote models don't have tags.
 func ToModelFromArtifact(artifact types.ModelArtifact) (*Model, error) {
@@ -58,10 +73,13 @@ func ToModelFromArtifact(artifact types.ModelArtifact) (*Model, error) {
 		created = desc.Created.Unix()
 	}
 
+	capabilities := ArtifactCapabilities(artifact, cfg)
+
 	return &Model{
-		ID:      id,
-		Tags:    nil, // Remote models don't have local tags
-		Created: created,
-		Config:  cfg,
+		ID:           id,
+		Tags:         nil, // Remote models don't have local tags
+		Created:      created,
+		Config:       cfg,
+		Capabilities: &capabilities,
 	}, nil
 }
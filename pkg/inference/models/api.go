@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/docker/model-runner/pkg/distribution/modelpack"
 	"github.com/docker/model-runner/pkg/distribution/types"
 )
 
@@ -18,6 +19,111 @@ type ModelCreateRequest struct {
 	From string `json:"from"`
 	// BearerToken is an optional bearer token for authentication.
 	BearerToken string `json:"bearer-token,omitempty"`
+	// Platform optionally selects the variant to pull, as "os/arch[/variant]",
+	// when From refers to a multi-platform image index.
+	Platform string `json:"platform,omitempty"`
+	// ExpectedDigest, if set, makes the pull fail before downloading unless
+	// the manifest From resolves to has this digest ("sha256:...").
+	ExpectedDigest string `json:"expected_digest,omitempty"`
+}
+
+// ModelAliasRequest represents a request to map an alias to a model reference.
+type ModelAliasRequest struct {
+	// Alias is the short name users type (e.g. "llama").
+	Alias string `json:"alias"`
+	// Target is the model reference the alias resolves to.
+	Target string `json:"target"`
+}
+
+// ModelDiffRequest represents a request to compare two local models.
+type ModelDiffRequest struct {
+	// A is the reference of the first model.
+	A string `json:"a"`
+	// B is the reference of the second model.
+	B string `json:"b"`
+}
+
+// ModelPruneRequest represents a request to remove the local models matching
+// a set of filters.
+type ModelPruneRequest struct {
+	// Until removes only models not used or pulled within this duration
+	// (e.g. "720h").
+	Until string `json:"until,omitempty"`
+	// Dangling removes only models that no tag references. It is implied
+	// if neither Until nor Labels is set.
+	Dangling bool `json:"dangling,omitempty"`
+	// Labels removes only models whose manifest has all of these
+	// annotations, each given as "key" or "key=value".
+	Labels []string `json:"labels,omitempty"`
+	// DryRun lists the models that would be removed without removing them.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// LayerDiff describes a layer present in only one of two compared models, or
+// whose content differs between them.
+type LayerDiff struct {
+	// Path is the layer's file path annotation, if it has one.
+	Path string `json:"path,omitempty"`
+	// MediaType is the layer media type.
+	MediaType string `json:"media_type"`
+	// DigestA is the layer digest in the first model, if present there.
+	DigestA string `json:"digest_a,omitempty"`
+	// DigestB is the layer digest in the second model, if present there.
+	DigestB string `json:"digest_b,omitempty"`
+}
+
+// ConfigFieldDiff describes a config field whose value differs between two
+// compared models. A missing value means the field is unset in that model.
+type ConfigFieldDiff struct {
+	// Field is the dotted path of the field in the config file, such as
+	// "config.context_size".
+	Field string `json:"field"`
+	// A is the field's value in the first model.
+	A json.RawMessage `json:"a,omitempty"`
+	// B is the field's value in the second model.
+	B json.RawMessage `json:"b,omitempty"`
+}
+
+// CatalogEntry describes a model available to pull from the configured
+// catalog.
+type CatalogEntry struct {
+	// Name is the reference to pull the model by.
+	Name string `json:"name"`
+	// Size is the total size of the model's layers, in bytes, if known.
+	Size int64 `json:"size,omitempty"`
+	// Description is a short description of the model, if any.
+	Description string `json:"description,omitempty"`
+}
+
+// ModelDiff is the result of comparing two local models.
+type ModelDiff struct {
+	// A is the ID of the first model.
+	A string `json:"a"`
+	// B is the ID of the second model.
+	B string `json:"b"`
+	// Added lists layers only in the second model.
+	Added []LayerDiff `json:"added"`
+	// Removed lists layers only in the first model.
+	Removed []LayerDiff `json:"removed"`
+	// Changed lists layers present in both models with different content.
+	Changed []LayerDiff `json:"changed"`
+	// Config lists config fields that differ.
+	Config []ConfigFieldDiff `json:"config"`
+}
+
+// FormatDetection is the result of detecting the format of a model file.
+type FormatDetection struct {
+	// Format is the detected model format, or empty if it isn't recognized.
+	Format types.Format `json:"format,omitempty"`
+	// DetectedBy is how the format was determined: "content" if the file's
+	// leading bytes identified it, or "extension" if only its name did.
+	DetectedBy string `json:"detected_by,omitempty"`
+	// Supported reports whether a backend on this platform serves the format.
+	Supported bool `json:"supported"`
+	// Backend is the backend that would serve the format.
+	Backend string `json:"backend,omitempty"`
+	// Warning describes a disagreement between the file's name and content.
+	Warning string `json:"warning,omitempty"`
 }
 
 // SimpleModel is a wrapper that allows creating a model with modified configuration
@@ -110,9 +216,20 @@ type Model struct {
 	Tags []string `json:"tags,omitempty"`
 	// Created is the Unix epoch timestamp corresponding to the model creation.
 	Created int64 `json:"created"`
+	// LastUsed is the Unix epoch timestamp of the model's last use for
+	// inference, or zero if it has never been used.
+	LastUsed int64 `json:"last_used,omitempty"`
+	// LastPulled is the Unix epoch timestamp of the model's last pull, or zero
+	// if it is unknown.
+	LastPulled int64 `json:"last_pulled,omitempty"`
+	// Archived indicates that the model's weights were removed to save space,
+	// so it must be pulled again before it can be used.
+	Archived bool `json:"archived,omitempty"`
 	// Config describes the model. Can be either Docker format (*types.Config)
 	// or ModelPack format (*modelpack.Model).
 	Config types.ModelConfig `json:"config"`
+	// Capabilities describes the kinds of requests the model supports.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling for Model.
@@ -146,3 +263,72 @@ func (m *Model) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// ModelConfigResponse is returned by the model config endpoint. It is encoded
+// as the model's config, with the fields of the config's own format, extended
+// with the config's media type and the model's capabilities.
+type ModelConfigResponse struct {
+	// MediaType is the media type of the model's config, which identifies
+	// its format.
+	MediaType string `json:"media_type,omitempty"`
+	// Config is the model's config: *types.Config for Docker format models
+	// or *modelpack.Model for ModelPack format ones.
+	Config types.ModelConfig `json:"-"`
+	// Capabilities describes the kinds of requests the model supports.
+	Capabilities Capabilities `json:"capabilities"`
+}
+
+// MarshalJSON implements custom JSON marshaling for ModelConfigResponse,
+// adding the media type and capabilities to the config's own fields.
+func (r ModelConfigResponse) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if r.Config != nil {
+		raw, err := json.Marshal(r.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+	}
+	if r.MediaType != "" {
+		mediaType, err := json.Marshal(r.MediaType)
+		if err != nil {
+			return nil, err
+		}
+		fields["media_type"] = mediaType
+	}
+	capabilities, err := json.Marshal(r.Capabilities)
+	if err != nil {
+		return nil, err
+	}
+	fields["capabilities"] = capabilities
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for ModelConfigResponse,
+// decoding the config according to its media type so that no format-specific
+// fields are lost. Configs without a media type are identified by their
+// fields.
+func (r *ModelConfigResponse) UnmarshalJSON(data []byte) error {
+	type Alias ModelConfigResponse
+	if err := json.Unmarshal(data, (*Alias)(r)); err != nil {
+		return err
+	}
+
+	if modelpack.IsModelPackMediaType(r.MediaType) || (r.MediaType == "" && modelpack.IsModelPackConfig(data)) {
+		var mp modelpack.Model
+		if err := json.Unmarshal(data, &mp); err != nil {
+			return fmt.Errorf("unmarshal modelpack config: %w", err)
+		}
+		r.Config = &mp
+		return nil
+	}
+
+	var cfg types.Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("unmarshal config: %w", err)
+	}
+	r.Config = &cfg
+	return nil
+}
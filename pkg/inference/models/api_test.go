@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/docker/model-runner/pkg/distribution/modelpack"
 	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -235,6 +236,51 @@ func TestModelJSONRoundTrip(t *testing.T) {
 	assert.Equal(t, *originalConfig.ContextSize, *unmarshaledConfig.ContextSize)
 }
 
+func TestModelConfigResponseJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		response ModelConfigResponse
+	}{
+		{
+			name: "docker format",
+			response: ModelConfigResponse{
+				MediaType:    string(types.MediaTypeModelConfigV01),
+				Config:       &types.Config{Format: "gguf", Architecture: "llama", GGUF: map[string]string{"llama.context_length": "4096"}},
+				Capabilities: Capabilities{SupportsTools: true},
+			},
+		},
+		{
+			name: "modelpack format",
+			response: ModelConfigResponse{
+				MediaType: modelpack.MediaTypeModelConfigV1,
+				Config: &modelpack.Model{
+					Descriptor: modelpack.ModelDescriptor{Family: "qwen3", Authors: []string{"Qwen"}},
+					Config:     modelpack.ModelConfig{Architecture: "transformer", Format: "safetensors", ParamSize: "8b", Precision: "bf16"},
+				},
+				Capabilities: Capabilities{SupportsVision: true},
+			},
+		},
+		{
+			name: "modelpack format without media type",
+			response: ModelConfigResponse{
+				Config: &modelpack.Model{
+					Config: modelpack.ModelConfig{Architecture: "transformer", ParamSize: "8b"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tt.response)
+			require.NoError(t, err)
+
+			var unmarshaled ModelConfigResponse
+			require.NoError(t, json.Unmarshal(jsonData, &unmarshaled))
+			assert.Equal(t, tt.response, unmarshaled)
+		})
+	}
+}
+
 func TestModelUnmarshalJSONNullAndMissingConfig(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -316,3 +362,67 @@ func TestModelUnmarshalJSONInvalidData(t *testing.T) {
 func int32Ptr(i int32) *int32 {
 	return &i
 }
+
+func TestDeriveCapabilities(t *testing.T) {
+	noTools := false
+	tests := []struct {
+		name         string
+		cfg          types.ModelConfig
+		hasProjector bool
+		template     string
+		expected     Capabilities
+	}{
+		{
+			name:     "template with tools",
+			cfg:      &types.Config{Architecture: "llama", GGUF: map[string]string{"tokenizer.chat_template": "{% if tools %}...{% endif %}"}},
+			expected: Capabilities{SupportsTools: true},
+		},
+		{
+			name:     "template without tools",
+			cfg:      &types.Config{Architecture: "qwen2"},
+			template: "{{ messages }}",
+		},
+		{
+			name:     "tool calling family without a template",
+			cfg:      &types.Config{Architecture: "qwen3"},
+			expected: Capabilities{SupportsTools: true},
+		},
+		{
+			name:         "multimodal projector",
+			cfg:          &types.Config{Architecture: "gemma3"},
+			hasProjector: true,
+			expected:     Capabilities{SupportsVision: true},
+		},
+		{
+			name:     "embedding architecture",
+			cfg:      &types.Config{Architecture: "nomic-bert"},
+			expected: Capabilities{SupportsEmbeddings: true},
+		},
+		{
+			name: "embedding pooling",
+			cfg: &types.Config{Architecture: "qwen3", GGUF: map[string]string{
+				"qwen3.pooling_type": "3", "tokenizer.chat_template": "{% if tools %}{% endif %}",
+			}},
+			expected: Capabilities{SupportsEmbeddings: true},
+		},
+		{
+			name: "declared capabilities",
+			cfg: &modelpack.Model{Config: modelpack.ModelConfig{Architecture: "qwen3", Capabilities: &modelpack.ModelCapabilities{
+				InputTypes: []string{"text", "image"},
+				ToolUsage:  &noTools,
+			}}},
+			expected: Capabilities{SupportsVision: true},
+		},
+		{
+			name:         "no config",
+			hasProjector: true,
+			expected:     Capabilities{SupportsVision: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, deriveCapabilities(tt.cfg, tt.hasProjector, tt.template))
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/distribution/modelpack"
+	"github.com/docker/model-runner/pkg/distribution/types"
+)
+
+// Capabilities describes the kinds of requests a model supports, as far as can
+// be told from its packaging and metadata.
+type Capabilities struct {
+	// SupportsTools indicates that the model can be sent tool definitions.
+	SupportsTools bool `json:"supports_tools"`
+	// SupportsVision indicates that the model accepts images.
+	SupportsVision bool `json:"supports_vision"`
+	// SupportsEmbeddings indicates that the model produces embeddings rather
+	// than text.
+	SupportsEmbeddings bool `json:"supports_embeddings"`
+}
+
+// embeddingArchitectures are the architectures of models that only produce
+// embeddings.
+var embeddingArchitectures = []string{
+	"bert", "jina-bert-v2", "modern-bert", "neo-bert", "nomic-bert", "nomic-bert-moe", "t5encoder",
+}
+
+// toolArchitectures are the architectures of model families trained for tool
+// calling, for models whose chat template doesn't tell.
+var toolArchitectures = []string{
+	"command-r", "cohere2", "gpt-oss", "granite", "granitemoe", "llama4", "mistral3",
+	"qwen2", "qwen2moe", "qwen3", "qwen3moe",
+}
+
+// ModelCapabilities derives the capabilities of a local model.
+func ModelCapabilities(m types.Model, cfg types.ModelConfig) Capabilities {
+	path, err := m.MMPROJPath()
+	hasProjector := err == nil && path != ""
+	var template string
+	if path, err := m.ChatTemplatePath(); err == nil && path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			template = string(data)
+		}
+	}
+	return deriveCapabilities(cfg, hasProjector, template)
+}
+
+// ArtifactCapabilities derives the capabilities of a remote model from its
+// config and layers.
+func ArtifactCapabilities(artifact types.ModelArtifact, cfg types.ModelConfig) Capabilities {
+	hasProjector := false
+	if layers, err := artifact.Layers(); err == nil {
+		for _, layer := range layers {
+			if mediaType, err := layer.MediaType(); err == nil && mediaType == types.MediaTypeMultimodalProjector {
+				hasProjector = true
+				break
+			}
+		}
+	}
+	return deriveCapabilities(cfg, hasProjector, "")
+}
+
+// deriveCapabilities derives a model's capabilities from its config, whether
+// it has a multimodal projector, and its bundled chat template, if any.
+// Capabilities a ModelPack config declares take precedence.
+func deriveCapabilities(cfg types.ModelConfig, hasProjector bool, template string) Capabilities {
+	var capabilities Capabilities
+	if cfg == nil {
+		capabilities.SupportsVision = hasProjector
+		return capabilities
+	}
+	architecture := strings.ToLower(cfg.GetArchitecture())
+
+	if c, ok := cfg.(*types.Config); ok {
+		if template == "" {
+			template = c.GGUF["tokenizer.chat_template"]
+		}
+		// Embedding models set a pooling type other than none (0).
+		if pooling := c.GGUF[architecture+".pooling_type"]; pooling != "" && pooling != "0" {
+			capabilities.SupportsEmbeddings = true
+		}
+	}
+	if slices.Contains(embeddingArchitectures, architecture) {
+		capabilities.SupportsEmbeddings = true
+	}
+	capabilities.SupportsVision = hasProjector
+	if !capabilities.SupportsEmbeddings {
+		capabilities.SupportsTools = strings.Contains(template, "tools") ||
+			(template == "" && slices.Contains(toolArchitectures, architecture))
+	}
+
+	if mp, ok := cfg.(*modelpack.Model); ok && mp.Config.Capabilities != nil {
+		declared := mp.Config.Capabilities
+		if declared.ToolUsage != nil {
+			capabilities.SupportsTools = *declared.ToolUsage
+		}
+		if slices.Contains(declared.InputTypes, "image") {
+			capabilities.SupportsVision = true
+		}
+		if slices.Contains(declared.OutputTypes, "embedding") {
+			capabilities.SupportsEmbeddings = true
+		}
+	}
+	return capabilities
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrCatalogNotConfigured is returned when the catalog is queried but no
+// catalog source is configured.
+var ErrCatalogNotConfigured = errors.New("no model catalog configured")
+
+const (
+	// catalogDescriptionAnnotation is the manifest annotation holding a
+	// model's description in registry catalogs.
+	catalogDescriptionAnnotation = "org.opencontainers.image.description"
+	// catalogConcurrency is the maximum number of manifests fetched at once
+	// when describing the repositories of a registry catalog.
+	catalogConcurrency = 8
+	// maxCatalogManifestSize is the largest JSON catalog manifest read.
+	maxCatalogManifestSize = 8 << 20
+)
+
+// Catalog returns the models available to pull from the configured catalog
+// whose name or description contains query, ignoring case. An empty query
+// matches every model.
+func (m *Manager) Catalog(ctx context.Context, query string) ([]CatalogEntry, error) {
+	if m.catalog == "" {
+		return nil, ErrCatalogNotConfigured
+	}
+	var entries []CatalogEntry
+	var err error
+	if strings.HasPrefix(m.catalog, "http://") || strings.HasPrefix(m.catalog, "https://") {
+		entries, err = m.fetchCatalogManifest(ctx)
+	} else {
+		entries, err = m.listCatalogNamespace(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	matches := []CatalogEntry{}
+	for _, entry := range entries {
+		if strings.Contains(strings.ToLower(entry.Name), query) ||
+			strings.Contains(strings.ToLower(entry.Description), query) {
+			matches = append(matches, entry)
+		}
+	}
+	return matches, nil
+}
+
+// fetchCatalogManifest reads the catalog from a JSON manifest of the form
+// {"models": [{"name": ..., "size": ..., "description": ...}]}.
+func (m *Manager) fetchCatalogManifest(ctx context.Context) ([]CatalogEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.catalog, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating catalog request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", m.registryClient.UserAgent())
+	resp, err := (&http.Client{Transport: m.registryClient.Transport()}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching catalog: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error while fetching catalog: unexpected status %s", resp.Status)
+	}
+
+	var manifest struct {
+		Models []CatalogEntry `json:"models"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxCatalogManifestSize)).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error while decoding catalog: %w", err)
+	}
+	entries := make([]CatalogEntry, 0, len(manifest.Models))
+	for _, entry := range manifest.Models {
+		if entry.Name != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// listCatalogNamespace lists the repositories of the catalog's registry
+// namespace, describing each from the manifest of its latest tag. Models
+// whose manifest can't be read are listed by name only.
+func (m *Manager) listCatalogNamespace(ctx context.Context) ([]CatalogEntry, error) {
+	repositories, err := m.registryClient.Repositories(ctx, m.catalog)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing catalog: %w", err)
+	}
+
+	entries := make([]CatalogEntry, len(repositories))
+	var g errgroup.Group
+	g.SetLimit(catalogConcurrency)
+	for i, repository := range repositories {
+		entries[i].Name = repository
+		g.Go(func() error {
+			size, description, err := m.describeRemote(ctx, repository+":latest")
+			if err != nil {
+				m.log.Warnf("Failed to describe catalog model %s: %v", utils.SanitizeForLog(repository), err)
+				return nil
+			}
+			entries[i].Size, entries[i].Description = size, description
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return entries, nil
+}
+
+// describeRemote returns the total size of a remote model's config and layers
+// and its description annotation, reading only its manifest.
+func (m *Manager) describeRemote(ctx context.Context, ref string) (int64, string, error) {
+	model, err := m.registryClient.Model(ctx, ref)
+	if err != nil {
+		return 0, "", err
+	}
+	manifest, err := model.Manifest()
+	if err != nil {
+		return 0, "", fmt.Errorf("error while reading manifest: %w", err)
+	}
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return size, manifest.Annotations[catalogDescriptionAnnotation], nil
+}
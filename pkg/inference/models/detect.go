@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/docker/model-runner/pkg/distribution/format"
+	"github.com/docker/model-runner/pkg/distribution/types"
+)
+
+// BackendSelector returns the name of the backend that would serve models of
+// the given format, and whether it is supported on this platform.
+type BackendSelector func(format types.Format) (string, bool)
+
+// detectFormat determines the format of a model file from its name and
+// leading bytes. Content takes precedence over the name; the name is only
+// used when header is too short to sniff.
+func detectFormat(filename string, header []byte) FormatDetection {
+	var byName types.Format
+	if f, err := format.DetectFromPath(filename); err == nil {
+		byName = f.Name()
+	}
+	if len(header) < format.ContentSniffLength {
+		if byName == "" {
+			return FormatDetection{}
+		}
+		return FormatDetection{Format: byName, DetectedBy: "extension"}
+	}
+
+	f, err := format.DetectFromContent(header)
+	if err != nil {
+		detection := FormatDetection{}
+		if byName != "" {
+			detection.Warning = fmt.Sprintf("file name suggests %s, but its content is not %s", byName, byName)
+		}
+		return detection
+	}
+	detection := FormatDetection{Format: f.Name(), DetectedBy: "content"}
+	if byName != "" && byName != f.Name() {
+		detection.Warning = fmt.Sprintf("file name suggests %s, but its content is %s", byName, f.Name())
+	}
+	return detection
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+	"github.com/docker/model-runner/pkg/distribution/types"
+)
+
+// storedModel is implemented by local models that expose their stored
+// manifest and config.
+type storedModel interface {
+	Manifest() (*oci.Manifest, error)
+	RawConfigFile() ([]byte, error)
+}
+
+// Diff compares two local models, reporting which layers differ, by digest
+// and file path annotation, and which config fields differ.
+func (m *Manager) Diff(a, b string) (*ModelDiff, error) {
+	idA, manifestA, configA, err := m.readStored(a)
+	if err != nil {
+		return nil, err
+	}
+	idB, manifestB, configB, err := m.readStored(b)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ModelDiff{A: idA, B: idB}
+	diff.Added, diff.Removed, diff.Changed = diffLayers(manifestA.Layers, manifestB.Layers)
+	if diff.Config, err = diffConfigs(configA, configB); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// readStored returns the ID, manifest and raw config of a local model.
+func (m *Manager) readStored(ref string) (string, *oci.Manifest, []byte, error) {
+	model, err := m.GetLocal(ref)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	id, err := model.ID()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error while getting model ID: %w", err)
+	}
+	stored, ok := model.(storedModel)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("model %s has no stored manifest", id)
+	}
+	manifest, err := stored.Manifest()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error while reading model manifest: %w", err)
+	}
+	config, err := stored.RawConfigFile()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error while reading model config: %w", err)
+	}
+	return id, manifest, config, nil
+}
+
+// layerKeys returns a key identifying each layer across models: its file
+// path annotation or, for layers without one, its media type and position
+// among unannotated layers of that type.
+func layerKeys(layers []oci.Descriptor) []string {
+	keys := make([]string, len(layers))
+	seen := make(map[oci.MediaType]int)
+	for i, layer := range layers {
+		if path := layer.Annotations[types.AnnotationFilePath]; path != "" {
+			keys[i] = path
+			continue
+		}
+		keys[i] = string(layer.MediaType) + "#" + strconv.Itoa(seen[layer.MediaType])
+		seen[layer.MediaType]++
+	}
+	return keys
+}
+
+// diffLayers matches the layers of two manifests by key, returning the layers
+// only in b, those only in a, and those in both with different digests.
+func diffLayers(a, b []oci.Descriptor) (added, removed, changed []LayerDiff) {
+	keysA, keysB := layerKeys(a), layerKeys(b)
+	indexB := make(map[string]int, len(b))
+	for i, key := range keysB {
+		indexB[key] = i
+	}
+	matched := make(map[string]bool, len(a))
+	added, removed, changed = []LayerDiff{}, []LayerDiff{}, []LayerDiff{}
+	for i, layer := range a {
+		d := LayerDiff{
+			Path:      layer.Annotations[types.AnnotationFilePath],
+			MediaType: string(layer.MediaType),
+			DigestA:   layer.Digest.String(),
+		}
+		j, ok := indexB[keysA[i]]
+		if !ok {
+			removed = append(removed, d)
+			continue
+		}
+		matched[keysA[i]] = true
+		if b[j].Digest != layer.Digest {
+			d.DigestB = b[j].Digest.String()
+			changed = append(changed, d)
+		}
+	}
+	for i, layer := range b {
+		if !matched[keysB[i]] {
+			added = append(added, LayerDiff{
+				Path:      layer.Annotations[types.AnnotationFilePath],
+				MediaType: string(layer.MediaType),
+				DigestB:   layer.Digest.String(),
+			})
+		}
+	}
+	return added, removed, changed
+}
+
+// diffConfigs compares two raw config files field by field. Objects are
+// compared recursively; other values, including arrays, as a whole. The
+// rootfs section is skipped since it mirrors the layers.
+func diffConfigs(a, b []byte) ([]ConfigFieldDiff, error) {
+	fieldsA, fieldsB := make(map[string]json.RawMessage), make(map[string]json.RawMessage)
+	if err := flattenJSON("", a, fieldsA); err != nil {
+		return nil, fmt.Errorf("error while parsing model config: %w", err)
+	}
+	if err := flattenJSON("", b, fieldsB); err != nil {
+		return nil, fmt.Errorf("error while parsing model config: %w", err)
+	}
+	var fields []string
+	for field := range fieldsA {
+		fields = append(fields, field)
+	}
+	for field := range fieldsB {
+		if _, ok := fieldsA[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+
+	diffs := []ConfigFieldDiff{}
+	for _, field := range fields {
+		valueA, valueB := fieldsA[field], fieldsB[field]
+		if !bytes.Equal(valueA, valueB) {
+			diffs = append(diffs, ConfigFieldDiff{Field: field, A: valueA, B: valueB})
+		}
+	}
+	return diffs, nil
+}
+
+// flattenJSON adds each leaf value of the JSON document raw to fields, keyed
+// by its dotted path under prefix.
+func flattenJSON(prefix string, raw json.RawMessage, fields map[string]json.RawMessage) error {
+	var object map[string]json.RawMessage
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &object); err != nil {
+			return err
+		}
+		for key, value := range object {
+			if prefix == "" && key == "rootfs" {
+				continue
+			}
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			if err := flattenJSON(path, value, fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return err
+	}
+	fields[prefix] = compact.Bytes()
+	return nil
+}
@@ -1,8 +1,13 @@
 package models
 
 import (
+	"archive/tar"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -10,10 +15,13 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/builder"
+	"github.com/docker/model-runner/pkg/distribution/oci"
 	reg "github.com/docker/model-runner/pkg/distribution/registry"
 	"github.com/docker/model-runner/pkg/distribution/registry/testregistry"
+	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/inference"
 	"github.com/sirupsen/logrus"
 )
@@ -316,3 +324,706 @@ func TestCors(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleGetModelConfig(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Create a test registry
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	// Prepare the OCI model artifact and push it
+	projectRoot := getProjectRoot(t)
+	model, err := builder.FromPath(filepath.Join(projectRoot, "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model builder: %v", err)
+	}
+	tag := uri.Host + "/ai/model:v1.0.0"
+	client := reg.NewClient(reg.WithPlainHTTP(true))
+	target, err := client.NewTarget(tag)
+	if err != nil {
+		t.Fatalf("Failed to create model target: %v", err)
+	}
+	if err := model.Build(t.Context(), target, io.Discard); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log.WithFields(logrus.Fields{"component": "model-manager"}), ClientConfig{
+		StoreRootPath: tempDir,
+		Logger:        log.WithFields(logrus.Fields{"component": "model-manager"}),
+		PlainHTTP:     true,
+	})
+	handler := NewHTTPHandler(log, manager, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/models/create", strings.NewReader(`{"from": "`+tag+`"}`))
+	if err := handler.manager.Pull(tag, "", r, httptest.NewRecorder()); err != nil {
+		t.Fatalf("Failed to pull model: %v", err)
+	}
+
+	t.Run("existing model", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, inference.ModelsPrefix+"/"+tag+"/config", http.NoBody)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), `"capabilities":{"supports_tools":`) {
+			t.Errorf("Expected capabilities in config, got %s", w.Body.String())
+		}
+		var response ModelConfigResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if response.MediaType != string(types.MediaTypeModelConfigV01) {
+			t.Errorf("Expected media type %q, got %q", types.MediaTypeModelConfigV01, response.MediaType)
+		}
+		cfg, ok := response.Config.(*types.Config)
+		if !ok {
+			t.Fatalf("Expected a Docker format config, got %T", response.Config)
+		}
+		if cfg.Format != types.FormatGGUF {
+			t.Errorf("Expected format %q, got %q", types.FormatGGUF, cfg.Format)
+		}
+		if len(cfg.GGUF) == 0 {
+			t.Errorf("Expected GGUF metadata in config")
+		}
+	})
+
+	t.Run("missing model", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, inference.ModelsPrefix+"/nonexistent:v1/config", http.NoBody)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestHandleGetModelGGUF(t *testing.T) {
+	tempDir := t.TempDir()
+
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	ggufPath := filepath.Join(getProjectRoot(t), "assets", "dummy.gguf")
+	model, err := builder.FromPath(ggufPath)
+	if err != nil {
+		t.Fatalf("Failed to create model builder: %v", err)
+	}
+	tag := uri.Host + "/ai/model:v1.0.0"
+	client := reg.NewClient(reg.WithPlainHTTP(true))
+	target, err := client.NewTarget(tag)
+	if err != nil {
+		t.Fatalf("Failed to create model target: %v", err)
+	}
+	if err := model.Build(t.Context(), target, io.Discard); err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log.WithFields(logrus.Fields{"component": "model-manager"}), ClientConfig{
+		StoreRootPath: tempDir,
+		Logger:        log.WithFields(logrus.Fields{"component": "model-manager"}),
+		PlainHTTP:     true,
+	})
+	handler := NewHTTPHandler(log, manager, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/models/create", strings.NewReader(`{"from": "`+tag+`"}`))
+	if err := handler.manager.Pull(tag, "", r, httptest.NewRecorder()); err != nil {
+		t.Fatalf("Failed to pull model: %v", err)
+	}
+
+	t.Run("existing model", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, inference.ModelsPrefix+"/"+tag+"/gguf", http.NoBody)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		tr := tar.NewReader(w.Body)
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("Failed to read tar entry: %v", err)
+		}
+		if hdr.Name != "model.gguf" {
+			t.Errorf("Expected entry name %q, got %q", "model.gguf", hdr.Name)
+		}
+		got, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read tar entry contents: %v", err)
+		}
+		want, err := os.ReadFile(ggufPath)
+		if err != nil {
+			t.Fatalf("Failed to read source GGUF file: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Extracted GGUF file does not match the source file")
+		}
+		if _, err := tr.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("Expected a single tar entry, got err=%v", err)
+		}
+	})
+
+	t.Run("missing model", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, inference.ModelsPrefix+"/nonexistent:v1/gguf", http.NoBody)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestHandlePushModelInvalidVariant(t *testing.T) {
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log, ClientConfig{
+		StoreRootPath: t.TempDir(),
+		Logger:        log,
+	})
+	handler := NewHTTPHandler(log, manager, nil)
+
+	for _, body := range []string{
+		`{"variants":[{"platform":"linux","model":"ai/model:cpu"}]}`,
+		`{"variants":[{"platform":"linux/amd64","model":""}]}`,
+	} {
+		r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/ai/model:latest/push", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func TestPullExpectedDigestFromHuggingFace(t *testing.T) {
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log, ClientConfig{
+		StoreRootPath: t.TempDir(),
+		Logger:        log,
+	})
+	handler := NewHTTPHandler(log, manager, nil)
+
+	body := `{"from": "hf.co/org/model", "expected_digest": "sha256:` + strings.Repeat("0", 64) + `"}`
+	r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/create", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+func TestPullWaitsForSlot(t *testing.T) {
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log, ClientConfig{
+		StoreRootPath:      t.TempDir(),
+		Logger:             log,
+		MaxConcurrentPulls: 1,
+	})
+
+	// Occupy the only pull slot, then cancel a pull queued behind it.
+	<-manager.pullTokens
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/create", http.NoBody).WithContext(ctx)
+	r.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	if err := manager.Pull("ai/model:latest", "", r, w); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if !strings.Contains(w.Body.String(), "Waiting for pull slot") {
+		t.Errorf("Expected a waiting message, got %q", w.Body.String())
+	}
+	if len(manager.pullTokens) != 0 {
+		t.Errorf("Expected the canceled pull not to release a slot, got %d free", len(manager.pullTokens))
+	}
+}
+
+// TestPullReportsFailureAfterWaiting tests that a pull that fails after
+// reporting that it is waiting for a pull slot, and so after its status has
+// been sent, reports the failure as a progress error.
+func TestPullReportsFailureAfterWaiting(t *testing.T) {
+	registryServer := httptest.NewServer(testregistry.New())
+	defer registryServer.Close()
+	uri, err := url.Parse(registryServer.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log, ClientConfig{
+		StoreRootPath:      t.TempDir(),
+		Logger:             log,
+		PlainHTTP:          true,
+		MaxConcurrentPulls: 1,
+	})
+	server := httptest.NewServer(NewHTTPHandler(log, manager, nil))
+	defer server.Close()
+
+	// Occupy the only pull slot, so that the pull has to wait for it.
+	<-manager.pullTokens
+	body := strings.NewReader(`{"from": "` + uri.Host + `/ai/missing:latest"}`)
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL+inference.ModelsPrefix+"/create", body)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Pull request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d once waiting, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	var waiting oci.ProgressMessage
+	if err := decoder.Decode(&waiting); err != nil {
+		t.Fatalf("Failed to decode waiting message: %v", err)
+	}
+	if waiting.Message != "Waiting for pull slot" {
+		t.Fatalf("Expected a waiting message, got %q", waiting.Message)
+	}
+	manager.pullTokens <- struct{}{}
+
+	var failure oci.ProgressMessage
+	if err := decoder.Decode(&failure); err != nil {
+		t.Fatalf("Failed to decode failure message: %v", err)
+	}
+	if failure.Type != oci.TypeError || failure.Message != "Model not found" {
+		t.Errorf("Expected a %q progress error, got %s %q", "Model not found", failure.Type, failure.Message)
+	}
+}
+
+// TestPullReportsRegistryRateLimit tests that a pull the registry keeps rate
+// limiting fails with 429 and the registry's Retry-After, or with a progress
+// error carrying them if rate limit warnings have already started the stream.
+func TestPullReportsRegistryRateLimit(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		retryAfter string
+		// streamed indicates that the registry's delay is short enough to
+		// wait for once, reporting the wait as progress.
+		streamed bool
+	}{
+		{name: "before progress", retryAfter: "3600"},
+		{name: "after progress", retryAfter: "1", streamed: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", tt.retryAfter)
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+			}))
+			defer registryServer.Close()
+			uri, err := url.Parse(registryServer.URL)
+			if err != nil {
+				t.Fatalf("Failed to parse registry URL: %v", err)
+			}
+
+			log := logrus.NewEntry(logrus.StandardLogger())
+			manager := NewManager(log, ClientConfig{
+				StoreRootPath: t.TempDir(),
+				Logger:        log,
+				PlainHTTP:     true,
+				Transport:     reg.NewTimeoutTransport(nil, reg.TransportTimeouts{RateLimitWait: 1500 * time.Millisecond}),
+			})
+			handler := NewHTTPHandler(log, manager, nil)
+
+			r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/create", strings.NewReader(`{"from": "`+uri.Host+`/ai/model:latest"}`))
+			r.Header.Set("Accept", "application/json")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if !tt.streamed {
+				if w.Code != http.StatusTooManyRequests {
+					t.Fatalf("Expected status %d, got %d: %s", http.StatusTooManyRequests, w.Code, w.Body.String())
+				}
+				if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+					t.Errorf("Expected Retry-After %q, got %q", tt.retryAfter, got)
+				}
+				return
+			}
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d once streaming, got %d", http.StatusOK, w.Code)
+			}
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			var failure oci.ProgressMessage
+			if err := json.Unmarshal([]byte(lines[len(lines)-1]), &failure); err != nil {
+				t.Fatalf("Failed to decode the final progress message: %v", err)
+			}
+			if failure.Type != oci.TypeError || failure.Status != http.StatusTooManyRequests || failure.RetryAfter != 1 {
+				t.Errorf("Expected a rate limited progress error retrying after 1s, got %+v", failure)
+			}
+		})
+	}
+}
+
+func TestHandleDiff(t *testing.T) {
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	projectRoot := getProjectRoot(t)
+	base, err := builder.FromPath(filepath.Join(projectRoot, "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model builder: %v", err)
+	}
+	licensed, err := base.WithLicense(filepath.Join(projectRoot, "assets", "license.txt"))
+	if err != nil {
+		t.Fatalf("Failed to add license: %v", err)
+	}
+	client := reg.NewClient(reg.WithPlainHTTP(true))
+	tagA, tagB := uri.Host+"/ai/model:a", uri.Host+"/ai/model:b"
+	for tag, b := range map[string]*builder.Builder{tagA: base, tagB: licensed.WithContextSize(4096)} {
+		target, err := client.NewTarget(tag)
+		if err != nil {
+			t.Fatalf("Failed to create model target: %v", err)
+		}
+		if err := b.Build(t.Context(), target, io.Discard); err != nil {
+			t.Fatalf("Failed to build model: %v", err)
+		}
+	}
+
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log, ClientConfig{
+		StoreRootPath: t.TempDir(),
+		Logger:        log,
+		PlainHTTP:     true,
+	})
+	handler := NewHTTPHandler(log, manager, nil)
+	for _, tag := range []string{tagA, tagB} {
+		r := httptest.NewRequest(http.MethodPost, "/models/create", http.NoBody)
+		if err := manager.Pull(tag, "", r, httptest.NewRecorder()); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+	}
+
+	t.Run("different models", func(t *testing.T) {
+		body := `{"a": "` + tagA + `", "b": "` + tagB + `"}`
+		r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/_diff", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var diff ModelDiff
+		if err := json.NewDecoder(w.Body).Decode(&diff); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if len(diff.Added) != 1 || diff.Added[0].MediaType != string(types.MediaTypeLicense) || diff.Added[0].Path != "license.txt" {
+			t.Errorf("Expected the license layer to be added, got %+v", diff.Added)
+		}
+		if len(diff.Removed) != 0 || len(diff.Changed) != 0 {
+			t.Errorf("Expected no removed or changed layers, got %+v and %+v", diff.Removed, diff.Changed)
+		}
+		var contextSize *ConfigFieldDiff
+		for i := range diff.Config {
+			if diff.Config[i].Field == "config.context_size" {
+				contextSize = &diff.Config[i]
+			}
+		}
+		if contextSize == nil || contextSize.A != nil || string(contextSize.B) != "4096" {
+			t.Errorf("Expected a config.context_size difference, got %+v", diff.Config)
+		}
+	})
+
+	t.Run("same model", func(t *testing.T) {
+		diff, err := manager.Diff(tagA, tagA)
+		if err != nil {
+			t.Fatalf("Failed to diff model: %v", err)
+		}
+		if len(diff.Added)+len(diff.Removed)+len(diff.Changed)+len(diff.Config) != 0 {
+			t.Errorf("Expected no differences, got %+v", diff)
+		}
+	})
+
+	t.Run("missing model", func(t *testing.T) {
+		body := `{"a": "` + tagA + `", "b": "nonexistent:v1"}`
+		r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/_diff", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestHandleTagModel(t *testing.T) {
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	projectRoot := getProjectRoot(t)
+	base, err := builder.FromPath(filepath.Join(projectRoot, "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model builder: %v", err)
+	}
+	client := reg.NewClient(reg.WithPlainHTTP(true))
+	tagA, tagB := uri.Host+"/ai/model:a", uri.Host+"/ai/model:b"
+	for tag, b := range map[string]*builder.Builder{tagA: base, tagB: base.WithContextSize(4096)} {
+		target, err := client.NewTarget(tag)
+		if err != nil {
+			t.Fatalf("Failed to create model target: %v", err)
+		}
+		if err := b.Build(t.Context(), target, io.Discard); err != nil {
+			t.Fatalf("Failed to build model: %v", err)
+		}
+	}
+
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log, ClientConfig{
+		StoreRootPath: t.TempDir(),
+		Logger:        log,
+		PlainHTTP:     true,
+	})
+	handler := NewHTTPHandler(log, manager, nil)
+	for _, tag := range []string{tagA, tagB} {
+		r := httptest.NewRequest(http.MethodPost, "/models/create", http.NoBody)
+		if err := manager.Pull(tag, "", r, httptest.NewRecorder()); err != nil {
+			t.Fatalf("Failed to pull model: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		query    string
+		expected int
+	}{
+		{name: "new tag", source: tagA, query: "repo=ai/model&tag=c", expected: http.StatusCreated},
+		{name: "same model", source: tagA, query: "repo=ai/model&tag=c", expected: http.StatusOK},
+		{name: "different model", source: tagB, query: "repo=ai/model&tag=c", expected: http.StatusConflict},
+		{name: "different model forced", source: tagB, query: "repo=ai/model&tag=c&force=true", expected: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/"+tt.source+"/tag?"+tt.query, http.NoBody)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleDetect(t *testing.T) {
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := NewManager(log, ClientConfig{
+		StoreRootPath: t.TempDir(),
+		Logger:        log,
+	})
+	handler := NewHTTPHandler(log, manager, nil)
+	handler.SetBackendSelector(func(format types.Format) (string, bool) {
+		if format == types.FormatGGUF {
+			return "llama.cpp", true
+		}
+		return "", false
+	})
+
+	gguf, err := os.ReadFile(filepath.Join(getProjectRoot(t), "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to read model file: %v", err)
+	}
+	safetensors := []byte{2, 0, 0, 0, 0, 0, 0, 0, '{', '}'}
+
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		expected FormatDetection
+	}{
+		{
+			name:     "gguf",
+			filename: "model.gguf",
+			content:  gguf,
+			expected: FormatDetection{Format: types.FormatGGUF, DetectedBy: "content", Supported: true, Backend: "llama.cpp"},
+		},
+		{
+			name:     "unsupported format",
+			filename: "model.safetensors",
+			content:  safetensors,
+			expected: FormatDetection{Format: types.FormatSafetensors, DetectedBy: "content"},
+		},
+		{
+			name:     "mismatched extension",
+			filename: "model.safetensors",
+			content:  gguf,
+			expected: FormatDetection{
+				Format: types.FormatGGUF, DetectedBy: "content", Supported: true, Backend: "llama.cpp",
+				Warning: "file name suggests safetensors, but its content is gguf",
+			},
+		},
+		{
+			name:     "unknown content",
+			filename: "model.gguf",
+			content:  []byte("not a model file"),
+			expected: FormatDetection{Warning: "file name suggests gguf, but its content is not gguf"},
+		},
+		{
+			name:     "extension only",
+			filename: "model.gguf",
+			expected: FormatDetection{Format: types.FormatGGUF, DetectedBy: "extension", Supported: true, Backend: "llama.cpp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			fw, err := mw.CreateFormFile("file", tt.filename)
+			if err != nil {
+				t.Fatalf("Failed to create form file: %v", err)
+			}
+			if _, err := fw.Write(tt.content); err != nil {
+				t.Fatalf("Failed to write form file: %v", err)
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatalf("Failed to close multipart writer: %v", err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/_detect", &body)
+			r.Header.Set("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var detection FormatDetection
+			if err := json.NewDecoder(w.Body).Decode(&detection); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if detection != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, detection)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, inference.ModelsPrefix+"/_detect", strings.NewReader("{}"))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestHandleCatalog(t *testing.T) {
+	server := httptest.NewServer(testregistry.New())
+	defer server.Close()
+	uri, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse registry URL: %v", err)
+	}
+
+	model, err := builder.FromPath(filepath.Join(getProjectRoot(t), "assets", "dummy.gguf"))
+	if err != nil {
+		t.Fatalf("Failed to create model builder: %v", err)
+	}
+	client := reg.NewClient(reg.WithPlainHTTP(true))
+	for _, repository := range []string{"ai/llama", "ai/phi", "other/model"} {
+		target, err := client.NewTarget(uri.Host + "/" + repository + ":latest")
+		if err != nil {
+			t.Fatalf("Failed to create model target: %v", err)
+		}
+		if err := model.Build(t.Context(), target, io.Discard); err != nil {
+			t.Fatalf("Failed to build model: %v", err)
+		}
+	}
+
+	manifest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"models": [
+			{"name": "ai/gemma3", "size": 2000, "description": "Google Gemma 3"},
+			{"name": "ai/qwen3", "description": "Alibaba Qwen 3"}
+		]}`)
+	}))
+	defer manifest.Close()
+
+	catalog := func(t *testing.T, source, query string) (int, []CatalogEntry) {
+		t.Helper()
+		log := logrus.NewEntry(logrus.StandardLogger())
+		manager := NewManager(log, ClientConfig{
+			StoreRootPath: t.TempDir(),
+			Logger:        log,
+			PlainHTTP:     true,
+			Catalog:       source,
+		})
+		handler := NewHTTPHandler(log, manager, nil)
+
+		r := httptest.NewRequest(http.MethodGet, inference.ModelsPrefix+"/catalog?q="+url.QueryEscape(query), http.NoBody)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		var entries []CatalogEntry
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+		}
+		return w.Code, entries
+	}
+
+	t.Run("registry namespace", func(t *testing.T) {
+		code, entries := catalog(t, uri.Host+"/ai", "")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+		}
+		if len(entries) != 2 || entries[0].Name != uri.Host+"/ai/llama" || entries[1].Name != uri.Host+"/ai/phi" {
+			t.Fatalf("Expected the two ai models, got %+v", entries)
+		}
+		for _, entry := range entries {
+			if entry.Size <= 0 {
+				t.Errorf("Expected a size for %s", entry.Name)
+			}
+		}
+	})
+
+	t.Run("registry namespace with query", func(t *testing.T) {
+		_, entries := catalog(t, uri.Host+"/ai", "PHI")
+		if len(entries) != 1 || entries[0].Name != uri.Host+"/ai/phi" {
+			t.Errorf("Expected only the phi model, got %+v", entries)
+		}
+	})
+
+	t.Run("manifest URL with query", func(t *testing.T) {
+		code, entries := catalog(t, manifest.URL, "gemma")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, code)
+		}
+		expected := CatalogEntry{Name: "ai/gemma3", Size: 2000, Description: "Google Gemma 3"}
+		if len(entries) != 1 || entries[0] != expected {
+			t.Errorf("Expected %+v, got %+v", expected, entries)
+		}
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		if code, _ := catalog(t, "", ""); code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, code)
+		}
+	})
+}
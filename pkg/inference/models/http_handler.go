@@ -1,18 +1,26 @@
 package models
 
 import (
+	"archive/tar"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
+	"io"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"os"
 	"path"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/docker/model-runner/pkg/distribution/distribution"
+	"github.com/docker/model-runner/pkg/distribution/format"
+	"github.com/docker/model-runner/pkg/distribution/oci"
 	"github.com/docker/model-runner/pkg/distribution/registry"
 	"github.com/docker/model-runner/pkg/inference"
 	"github.com/docker/model-runner/pkg/internal/utils"
@@ -34,6 +42,8 @@ type HTTPHandler struct {
 	lock sync.RWMutex
 	// manager handles business logic for model operations.
 	manager *Manager
+	// backendSelector, if set, reports which backend serves a model format.
+	backendSelector BackendSelector
 }
 
 type ClientConfig struct {
@@ -45,8 +55,43 @@ type ClientConfig struct {
 	Transport http.RoundTripper
 	// UserAgent is the user agent to use.
 	UserAgent string
+	// UserAgentSuffix is appended, in parentheses, to the user agent of
+	// registry and HuggingFace requests.
+	UserAgentSuffix string
 	// PlainHTTP enables plain HTTP connections to registries (for testing).
 	PlainHTTP bool
+	// AutoPrune removes a tag's previous model after a pull moves the tag to
+	// a new digest, if no other tag references it.
+	AutoPrune bool
+	// StoreQuotaBytes limits the total size of models in the store, evicting
+	// least recently used models to make room for new ones. Zero means no
+	// limit.
+	StoreQuotaBytes int64
+	// RegistryMirrors are registries that pulls retry against, in order,
+	// when the primary registry fails with a retryable error.
+	RegistryMirrors []string
+	// Catalog is the source of models listed as available to pull: either
+	// the http(s) URL of a JSON catalog manifest or a registry namespace,
+	// whose repositories are listed through the registry's catalog API.
+	Catalog string
+	// StoreFileMode and StoreDirMode are the permissions applied to the
+	// files and directories written to the model store. Zero keeps the
+	// default permissions.
+	StoreFileMode os.FileMode
+	StoreDirMode  os.FileMode
+	// MaxConcurrentPulls limits the number of pulls running at once; excess
+	// pulls wait for a slot. Zero means maximumConcurrentModelPulls.
+	MaxConcurrentPulls int
+	// PullRateLimit caps the aggregate download rate of pulls, in bytes per
+	// second. Zero means no limit.
+	PullRateLimit int64
+	// MaxModelBytes refuses pulls of models whose declared size exceeds it.
+	// Zero means no limit.
+	MaxModelBytes int64
+	// DefaultOrg and DefaultTag are applied to model names without an
+	// organization or a tag. Empty values keep "ai" and "latest".
+	DefaultOrg string
+	DefaultTag string
 }
 
 // NewHTTPHandler creates a new model's handler.
@@ -72,6 +117,14 @@ func NewHTTPHandler(log logging.Logger, manager *Manager, allowedOrigins []strin
 	return m
 }
 
+// SetBackendSelector sets the function used to report which backend would
+// serve a detected model format.
+func (h *HTTPHandler) SetBackendSelector(selector BackendSelector) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.backendSelector = selector
+}
+
 func (h *HTTPHandler) RebuildRoutes(allowedOrigins []string) {
 	h.lock.Lock()
 	defer h.lock.Unlock()
@@ -88,6 +141,16 @@ func (h *HTTPHandler) routeHandlers() map[string]http.HandlerFunc {
 		"DELETE " + inference.ModelsPrefix + "/{name...}":                     h.handleDeleteModel,
 		"POST " + inference.ModelsPrefix + "/{nameAndAction...}":              h.handleModelAction,
 		"DELETE " + inference.ModelsPrefix + "/purge":                         h.handlePurge,
+		"POST " + inference.ModelsPrefix + "/prune":                           h.handlePrune,
+		"POST " + inference.ModelsPrefix + "/compact":                         h.handleCompact,
+		"GET " + inference.ModelsPrefix + "/_alias":                           h.handleListAliases,
+		"POST " + inference.ModelsPrefix + "/_alias":                          h.handleSetAlias,
+		"DELETE " + inference.ModelsPrefix + "/_alias/{alias}":                h.handleRemoveAlias,
+		"POST " + inference.ModelsPrefix + "/_diff":                           h.handleDiff,
+		"POST " + inference.ModelsPrefix + "/_detect":                         h.handleDetect,
+		"GET " + inference.ModelsPrefix + "/catalog":                          h.handleCatalog,
+		"GET " + inference.ModelsPrefix + "/incomplete":                       h.handleListIncomplete,
+		"DELETE " + inference.ModelsPrefix + "/incomplete":                    h.handlePurgeIncomplete,
 		"GET " + inference.InferencePrefix + "/{backend}/v1/models":           h.handleOpenAIGetModels,
 		"GET " + inference.InferencePrefix + "/{backend}/v1/models/{name...}": h.handleOpenAIGetModel,
 		"GET " + inference.InferencePrefix + "/v1/models":                     h.handleOpenAIGetModels,
@@ -104,31 +167,80 @@ func (h *HTTPHandler) handleCreateModel(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	var pullOpts []distribution.PullOption
+	if request.Platform != "" {
+		platform, err := oci.ParsePlatform(request.Platform)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pullOpts = append(pullOpts, distribution.WithVariant(platform))
+	}
+	if request.ExpectedDigest != "" {
+		if _, err := oci.NewHash(request.ExpectedDigest); err != nil {
+			http.Error(w, fmt.Sprintf("invalid expected digest: %v", err), http.StatusBadRequest)
+			return
+		}
+		pullOpts = append(pullOpts, distribution.WithExpectedDigest(request.ExpectedDigest))
+	}
+
 	// Pull the model
-	if err := h.manager.Pull(request.From, request.BearerToken, r, w); err != nil {
+	stream := &progressStream{ResponseWriter: w, mode: oci.ModePull}
+	if err := h.manager.Pull(request.From, request.BearerToken, r, stream, pullOpts...); err != nil {
 		sanitizedFrom := utils.SanitizeForLog(request.From, -1)
+		var variantErr *distribution.ErrVariantNotFound
+		if errors.As(err, &variantErr) {
+			h.log.Warnf("Failed to pull model %q: %v", sanitizedFrom, err)
+			stream.fail(variantErr.Error(), http.StatusNotFound)
+			return
+		}
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			h.log.Infof("Request canceled/timed out while pulling model %q", sanitizedFrom)
 			return
 		}
 		if errors.Is(err, registry.ErrInvalidReference) {
 			h.log.Warnf("Invalid model reference %q: %v", sanitizedFrom, err)
-			http.Error(w, "Invalid model reference", http.StatusBadRequest)
+			stream.fail("Invalid model reference", http.StatusBadRequest)
 			return
 		}
 		if errors.Is(err, registry.ErrUnauthorized) {
 			h.log.Warnf("Unauthorized to pull model %q: %v", sanitizedFrom, err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			stream.fail("Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, registry.ErrRateLimited) {
+			h.log.Warnf("Rate limited pulling model %q: %v", sanitizedFrom, err)
+			writeRateLimited(stream, err)
 			return
 		}
 		if errors.Is(err, registry.ErrModelNotFound) {
 			h.log.Warnf("Failed to pull model %q: %v", sanitizedFrom, err)
-			http.Error(w, "Model not found", http.StatusNotFound)
+			stream.fail("Model not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, distribution.ErrQuotaExceeded) || errors.Is(err, distribution.ErrInsufficientSpace) {
+			h.log.Warnf("Failed to pull model %q: %v", sanitizedFrom, err)
+			stream.fail(err.Error(), http.StatusInsufficientStorage)
+			return
+		}
+		if errors.Is(err, distribution.ErrModelTooLarge) {
+			h.log.Warnf("Failed to pull model %q: %v", sanitizedFrom, err)
+			stream.fail(err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if errors.Is(err, distribution.ErrDigestMismatch) {
+			h.log.Warnf("Failed to pull model %q: %v", sanitizedFrom, err)
+			stream.fail(err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		if errors.Is(err, distribution.ErrDigestUnsupported) {
+			h.log.Warnf("Failed to pull model %q: %v", sanitizedFrom, err)
+			stream.fail(err.Error(), http.StatusBadRequest)
 			return
 		}
 		// Note: ErrUnsupportedFormat is no longer treated as an error - it's a warning
 		// that's sent to the client via the progress stream
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		stream.fail(err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
@@ -137,6 +249,14 @@ func (h *HTTPHandler) handleCreateModel(w http.ResponseWriter, r *http.Request)
 func (h *HTTPHandler) handleLoadModel(w http.ResponseWriter, r *http.Request) {
 	err := h.manager.Load(r.Body, w)
 	if err != nil {
+		if errors.Is(err, distribution.ErrNotAModel) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, distribution.ErrQuotaExceeded) {
+			http.Error(w, err.Error(), http.StatusInsufficientStorage)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -151,6 +271,14 @@ func (h *HTTPHandler) handleModelGetAction(w http.ResponseWriter, r *http.Reques
 		h.handleExportModel(w, r, model)
 		return
 	}
+	if action == "config" && model != "" {
+		h.handleGetModelConfig(w, r, model)
+		return
+	}
+	if action == "gguf" && model != "" {
+		h.handleGetModelGGUF(w, r, model)
+		return
+	}
 
 	h.handleGetModelByRef(w, r, nameAndAction)
 }
@@ -171,6 +299,77 @@ func (h *HTTPHandler) handleExportModel(w http.ResponseWriter, r *http.Request,
 	}
 }
 
+// handleGetModelConfig handles GET <inference-prefix>/models/{name}/config requests.
+// It returns the full parsed model config (architecture, parameters, quantization,
+// size, format, context length and format-specific metadata such as GGUF headers),
+// along with the config's media type and the model's derived capabilities.
+func (h *HTTPHandler) handleGetModelConfig(w http.ResponseWriter, r *http.Request, modelRef string) {
+	response, err := h.manager.GetConfig(modelRef)
+	if err != nil {
+		h.writeModelError(w, err)
+		return
+	}
+	raw, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(raw); err != nil {
+		h.log.Warnln("Error while writing model config response:", err)
+	}
+}
+
+// handleGetModelGGUF handles GET <inference-prefix>/models/{name}/gguf requests.
+// It streams the model's GGUF weight file(s) as a tar archive.
+func (h *HTTPHandler) handleGetModelGGUF(w http.ResponseWriter, r *http.Request, modelRef string) {
+	files, err := h.manager.GGUFFiles(modelRef)
+	if err != nil {
+		if errors.Is(err, ErrNotGGUF) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.writeModelError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		if err := writeTarFile(tw, file.Name, file.Path); err != nil {
+			h.log.Warnf("Error while streaming GGUF file for model %q: %v", utils.SanitizeForLog(modelRef, -1), err)
+			return
+		}
+	}
+	if err := tw.Close(); err != nil {
+		h.log.Warnf("Error while streaming GGUF file for model %q: %v", utils.SanitizeForLog(modelRef, -1), err)
+	}
+}
+
+// writeTarFile writes the file at path to tw under name.
+func writeTarFile(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
 // handleGetModels handles GET <inference-prefix>/models requests.
 func (h *HTTPHandler) handleGetModels(w http.ResponseWriter, r *http.Request) {
 	apiModels, err := h.manager.List()
@@ -388,7 +587,7 @@ func (h *HTTPHandler) handleOpenAIGetModel(w http.ResponseWriter, r *http.Reques
 }
 
 // handleModelAction handles POST <inference-prefix>/models/{nameAndAction} requests.
-// Actions: tag, push, repackage
+// Actions: tag, push, repackage, archive, unarchive
 func (h *HTTPHandler) handleModelAction(w http.ResponseWriter, r *http.Request) {
 	model, action := path.Split(r.PathValue("nameAndAction"))
 	model = strings.TrimRight(model, "/")
@@ -400,15 +599,59 @@ func (h *HTTPHandler) handleModelAction(w http.ResponseWriter, r *http.Request)
 		h.handlePushModel(w, r, model)
 	case "repackage":
 		h.handleRepackageModel(w, r, model)
+	case "archive":
+		h.handleArchiveModel(w, model)
+	case "unarchive":
+		h.handleUnarchiveModel(w, model)
 	default:
 		http.Error(w, fmt.Sprintf("unknown action %q", action), http.StatusNotFound)
 	}
 }
 
+// handleArchiveModel handles POST <inference-prefix>/models/{name}/archive
+// requests, removing the model's weights while keeping it listed.
+func (h *HTTPHandler) handleArchiveModel(w http.ResponseWriter, model string) {
+	response, err := h.manager.Archive(model)
+	if err != nil {
+		if errors.Is(err, distribution.ErrModelNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.log.Warnf("Failed to archive model %s: %v", utils.SanitizeForLog(model, -1), err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log.Warnln("Error while encoding archive response:", err)
+	}
+}
+
+// handleUnarchiveModel handles POST <inference-prefix>/models/{name}/unarchive
+// requests. It responds with 409 if the model's weights are still missing and
+// it must be pulled again.
+func (h *HTTPHandler) handleUnarchiveModel(w http.ResponseWriter, model string) {
+	if err := h.manager.Unarchive(model); err != nil {
+		switch {
+		case errors.Is(err, distribution.ErrModelNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, distribution.ErrModelArchived):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.log.Warnf("Failed to unarchive model %s: %v", utils.SanitizeForLog(model, -1), err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 // handleTagModel handles POST <inference-prefix>/models/{name}/tag requests.
 // The query parameters are:
 // - repo: the repository to tag the model with (required)
 // - tag: the tag to apply to the model (required)
+// - force: move the tag if it refers to a different model (optional)
 func (h *HTTPHandler) handleTagModel(w http.ResponseWriter, r *http.Request, model string) {
 	// Extract query parameters.
 	repo := r.URL.Query().Get("repo")
@@ -423,23 +666,41 @@ func (h *HTTPHandler) handleTagModel(w http.ResponseWriter, r *http.Request, mod
 	// Construct the target string.
 	target := fmt.Sprintf("%s:%s", repo, tag)
 
+	var force bool
+	if r.URL.Query().Has("force") {
+		if val, err := strconv.ParseBool(r.URL.Query().Get("force")); err != nil {
+			h.log.Warnln("Error while parsing force query parameter:", err)
+		} else {
+			force = val
+		}
+	}
+
 	// First try to tag using the provided model reference as-is
-	err := h.manager.Tag(model, target)
+	created, err := h.manager.Tag(model, target, force)
 	if err != nil {
 		if errors.Is(err, distribution.ErrModelNotFound) {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, distribution.ErrConflict) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		// If there's an error other than not found, return it
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Respond with success.
+	// Respond with success. Re-applying a tag the model already has is a
+	// no-op, reported with 200 rather than 201.
+	status, message := http.StatusCreated, fmt.Sprintf("Model tagged successfully with %q", target)
+	if !created {
+		status, message = http.StatusOK, fmt.Sprintf("Model already tagged with %q", target)
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	response := map[string]string{
-		"message": fmt.Sprintf("Model tagged successfully with %q", target),
+		"message": message,
 		"target":  target,
 	}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -447,32 +708,57 @@ func (h *HTTPHandler) handleTagModel(w http.ResponseWriter, r *http.Request, mod
 	}
 }
 
+// PushRequest is the optional body of a push request. Variants, if set, are
+// pushed as an image index under the model's tag.
+type PushRequest struct {
+	Variants []PushVariant `json:"variants,omitempty"`
+}
+
 // handlePushModel handles POST <inference-prefix>/models/{name}/push requests.
 func (h *HTTPHandler) handlePushModel(w http.ResponseWriter, r *http.Request, model string) {
-	if err := h.manager.Push(model, r, w); err != nil {
+	var req PushRequest
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	stream := &progressStream{ResponseWriter: w, mode: oci.ModePush}
+	if err := h.manager.Push(model, req.Variants, r, stream); err != nil {
+		if errors.Is(err, ErrInvalidVariant) {
+			stream.fail(err.Error(), http.StatusBadRequest)
+			return
+		}
 		if errors.Is(err, distribution.ErrInvalidReference) {
 			h.log.Warnf("Invalid model reference %q: %v", utils.SanitizeForLog(model, -1), err)
-			http.Error(w, "Invalid model reference", http.StatusBadRequest)
+			stream.fail("Invalid model reference", http.StatusBadRequest)
 			return
 		}
 		if errors.Is(err, distribution.ErrModelNotFound) {
 			h.log.Warnf("Failed to push model %q: %v", utils.SanitizeForLog(model, -1), err)
-			http.Error(w, "Model not found", http.StatusNotFound)
+			stream.fail("Model not found", http.StatusNotFound)
 			return
 		}
 		if errors.Is(err, registry.ErrUnauthorized) {
 			h.log.Warnf("Unauthorized to push model %q: %v", utils.SanitizeForLog(model, -1), err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			stream.fail("Unauthorized", http.StatusUnauthorized)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, registry.ErrRateLimited) {
+			h.log.Warnf("Rate limited pushing model %q: %v", utils.SanitizeForLog(model, -1), err)
+			writeRateLimited(stream, err)
+			return
+		}
+		stream.fail(err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
 
 type RepackageRequest struct {
-	Target      string  `json:"target"`
-	ContextSize *uint64 `json:"context_size,omitempty"`
+	Target       string  `json:"target"`
+	ContextSize  *uint64 `json:"context_size,omitempty"`
+	Quantization *string `json:"quantization,omitempty"`
 }
 
 func (h *HTTPHandler) handleRepackageModel(w http.ResponseWriter, r *http.Request, model string) {
@@ -488,18 +774,38 @@ func (h *HTTPHandler) handleRepackageModel(w http.ResponseWriter, r *http.Reques
 	}
 
 	opts := RepackageOptions{
-		ContextSize: req.ContextSize,
+		ContextSize:  req.ContextSize,
+		Quantization: req.Quantization,
+	}
+
+	// Requantization rewrites the weights and can take a while, so its
+	// progress is streamed in the same format as pulls.
+	var progressWriter io.Writer
+	if req.Quantization != nil {
+		pw, err := newProgressResponseWriter(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		progressWriter = pw
 	}
 
-	if err := h.manager.Repackage(model, req.Target, opts); err != nil {
+	if err := h.manager.Repackage(r.Context(), model, req.Target, opts, progressWriter); err != nil {
 		if errors.Is(err, distribution.ErrModelNotFound) {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, distribution.ErrRequantizationUnsupported) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.log.Warnf("Failed to repackage model %q: %v", utils.SanitizeForLog(model, -1), err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if progressWriter != nil {
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -523,6 +829,216 @@ func (h *HTTPHandler) handlePurge(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// handlePrune handles POST <inference-prefix>/models/prune requests.
+func (h *HTTPHandler) handlePrune(w http.ResponseWriter, r *http.Request) {
+	var request ModelPruneRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.manager.Prune(request)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPruneRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.log.Warnf("Failed to prune models: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.log.Warnln("Error while encoding prune response:", err)
+	}
+}
+
+// writeRateLimited responds to a request that failed with err because the
+// registry rate limited it, passing on how long the registry asked to wait.
+func writeRateLimited(w *progressStream, err error) {
+	retryAfter := 0
+	var rateLimited *registry.RateLimitError
+	if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+		retryAfter = int(math.Ceil(rateLimited.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	w.failRetryAfter(err.Error(), http.StatusTooManyRequests, retryAfter)
+}
+
+// handleCompact handles POST <inference-prefix>/models/compact requests.
+func (h *HTTPHandler) handleCompact(w http.ResponseWriter, _ *http.Request) {
+	result, err := h.manager.Compact()
+	if err != nil {
+		h.log.Warnf("Failed to compact store: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.log.Warnln("Error while encoding compact response:", err)
+	}
+}
+
+// handleListAliases handles GET <inference-prefix>/models/_alias requests.
+func (h *HTTPHandler) handleListAliases(w http.ResponseWriter, _ *http.Request) {
+	aliases, err := h.manager.ListAliases()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(aliases); err != nil {
+		h.log.Warnln("Error while encoding aliases response:", err)
+	}
+}
+
+// handleSetAlias handles POST <inference-prefix>/models/_alias requests.
+func (h *HTTPHandler) handleSetAlias(w http.ResponseWriter, r *http.Request) {
+	var request ModelAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Alias == "" || request.Target == "" {
+		http.Error(w, "invalid request: alias and target are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.manager.SetAlias(request.Alias, request.Target); err != nil {
+		h.writeModelError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleRemoveAlias handles DELETE <inference-prefix>/models/_alias/{alias} requests.
+func (h *HTTPHandler) handleRemoveAlias(w http.ResponseWriter, r *http.Request) {
+	if err := h.manager.RemoveAlias(r.PathValue("alias")); err != nil {
+		if errors.Is(err, distribution.ErrAliasNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleDiff handles POST <inference-prefix>/models/_diff requests.
+func (h *HTTPHandler) handleDiff(w http.ResponseWriter, r *http.Request) {
+	var request ModelDiffRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.A == "" || request.B == "" {
+		http.Error(w, "invalid request: a and b are required", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.manager.Diff(request.A, request.B)
+	if err != nil {
+		h.writeModelError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diff); err != nil {
+		h.log.Warnln("Error while encoding diff response:", err)
+	}
+}
+
+// handleDetect handles POST <inference-prefix>/models/_detect requests. The
+// request is a multipart form whose "file" part holds a model file, or just
+// its leading bytes, which are sniffed along with the part's file name.
+func (h *HTTPHandler) handleDetect(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "invalid request: expected a multipart form", http.StatusBadRequest)
+		return
+	}
+	var part *multipart.Part
+	for {
+		part, err = reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			http.Error(w, "invalid request: missing file", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "invalid request: malformed multipart form", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() == "file" {
+			break
+		}
+	}
+	defer part.Close()
+
+	header := make([]byte, format.ContentSniffLength)
+	n, err := io.ReadFull(part, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		http.Error(w, "failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	detection := detectFormat(part.FileName(), header[:n])
+	if detection.Format != "" && h.backendSelector != nil {
+		detection.Backend, detection.Supported = h.backendSelector(detection.Format)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(detection); err != nil {
+		h.log.Warnln("Error while encoding detect response:", err)
+	}
+}
+
+// handleCatalog handles GET <inference-prefix>/models/catalog requests,
+// listing the models available to pull from the configured catalog. The
+// optional "q" query parameter filters them by name or description.
+func (h *HTTPHandler) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.manager.Catalog(r.Context(), r.URL.Query().Get("q"))
+	if err != nil {
+		if errors.Is(err, ErrCatalogNotConfigured) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.log.Warnln("Error while listing catalog:", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		h.log.Warnln("Error while encoding catalog response:", err)
+	}
+}
+
+// handleListIncomplete handles GET <inference-prefix>/models/incomplete
+// requests, listing partially downloaded blobs.
+func (h *HTTPHandler) handleListIncomplete(w http.ResponseWriter, _ *http.Request) {
+	downloads, err := h.manager.IncompleteDownloads()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(downloads); err != nil {
+		h.log.Warnln("Error while encoding incomplete downloads response:", err)
+	}
+}
+
+// handlePurgeIncomplete handles DELETE <inference-prefix>/models/incomplete
+// requests, removing the partially downloaded blobs that aren't being
+// downloaded and listing those removed.
+func (h *HTTPHandler) handlePurgeIncomplete(w http.ResponseWriter, _ *http.Request) {
+	purged, err := h.manager.PurgeIncompleteDownloads()
+	if err != nil {
+		h.log.Warnf("Failed to purge incomplete downloads: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(purged); err != nil {
+		h.log.Warnln("Error while encoding purged downloads response:", err)
+	}
+}
+
 // ServeHTTP implement net/http.HTTPHandler.ServeHTTP.
 func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.lock.RLock()
@@ -530,6 +1046,61 @@ func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.httpHandler.ServeHTTP(w, r)
 }
 
+// progressStream is the response writer of a pull or push, which streams
+// progress. It records whether the stream has started, since the response
+// status is sent with the first progress message.
+type progressStream struct {
+	http.ResponseWriter
+	// mode is the operation whose progress is streamed.
+	mode oci.Mode
+	// started indicates that the response status has been sent.
+	started atomic.Bool
+}
+
+func (w *progressStream) WriteHeader(status int) {
+	w.started.Store(true)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *progressStream) Write(p []byte) (int, error) {
+	w.started.Store(true)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *progressStream) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// fail reports that the operation failed with message and status. Once the
+// stream has started its status can no longer change, so the failure is
+// reported as a progress error carrying the status instead.
+func (w *progressStream) fail(message string, status int) {
+	w.failRetryAfter(message, status, 0)
+}
+
+// failRetryAfter is like fail, for a failure that the client was asked to
+// retry after retryAfter seconds, if non-zero.
+func (w *progressStream) failRetryAfter(message string, status, retryAfter int) {
+	if !w.started.Load() {
+		http.Error(w, message, status)
+		return
+	}
+	data, err := json.Marshal(oci.ProgressMessage{
+		Type:       oci.TypeError,
+		Message:    message,
+		Mode:       w.mode,
+		Status:     status,
+		RetryAfter: retryAfter,
+	})
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s\n", data)
+	w.Flush()
+}
+
 // progressResponseWriter implements io.Writer to write progress updates to the HTTP response
 type progressResponseWriter struct {
 	writer  http.ResponseWriter
@@ -537,6 +1108,33 @@ type progressResponseWriter struct {
 	isJSON  bool
 }
 
+// newProgressResponseWriter sets up w for streaming progress updates, using
+// JSON if the request accepts it and plain text otherwise.
+func newProgressResponseWriter(w http.ResponseWriter, r *http.Request) (*progressResponseWriter, error) {
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Transfer-Encoding", "chunked")
+
+	isJSON := r.Header.Get("Accept") == "application/json"
+	if isJSON {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		// Defaults to text/plain
+		w.Header().Set("Content-Type", "text/plain")
+	}
+
+	// Flush each chunk so that progress is sent immediately
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported")
+	}
+	return &progressResponseWriter{
+		writer:  w,
+		flusher: flusher,
+		isJSON:  isJSON,
+	}, nil
+}
+
 func (w *progressResponseWriter) Write(p []byte) (n int, err error) {
 	var data []byte
 	if w.isJSON {
@@ -2,11 +2,13 @@ package models
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/docker/model-runner/pkg/diskusage"
 	"github.com/docker/model-runner/pkg/distribution/distribution"
@@ -17,9 +19,20 @@ import (
 	"github.com/docker/model-runner/pkg/logging"
 )
 
+// ErrNotGGUF is returned when a GGUF-only operation targets a model that has
+// no GGUF weights.
+var ErrNotGGUF = errors.New("model is not in GGUF format")
+
+// ErrInvalidVariant is returned when a push variant has a malformed platform.
+var ErrInvalidVariant = errors.New("invalid push variant")
+
+// ErrInvalidPruneRequest is returned when a prune request has a malformed
+// filter.
+var ErrInvalidPruneRequest = errors.New("invalid prune request")
+
 const (
-	// maximumConcurrentModelPulls is the maximum number of concurrent model
-	// pulls that a model manager will allow.
+	// maximumConcurrentModelPulls is the default maximum number of concurrent
+	// model pulls that a model manager will allow.
 	maximumConcurrentModelPulls = 2
 )
 
@@ -34,6 +47,8 @@ type Manager struct {
 	// pullTokens is a semaphore used to restrict the maximum number of
 	// concurrent pull requests.
 	pullTokens chan struct{}
+	// catalog is the source of models available to pull, if configured.
+	catalog string
 }
 
 // NewManager creates a new model models with the provided clients.
@@ -42,6 +57,7 @@ func NewManager(log logging.Logger, c ClientConfig) *Manager {
 	registryClient := registry.NewClient(
 		registry.WithTransport(c.Transport),
 		registry.WithUserAgent(c.UserAgent),
+		registry.WithUserAgentSuffix(c.UserAgentSuffix),
 		registry.WithPlainHTTP(c.PlainHTTP),
 	)
 
@@ -50,6 +66,14 @@ func NewManager(log logging.Logger, c ClientConfig) *Manager {
 		distribution.WithStoreRootPath(c.StoreRootPath),
 		distribution.WithLogger(c.Logger),
 		distribution.WithRegistryClient(registryClient),
+		distribution.WithAutoPrune(c.AutoPrune),
+		distribution.WithStoreQuota(c.StoreQuotaBytes),
+		distribution.WithMirrors(c.RegistryMirrors),
+		distribution.WithStoreModes(c.StoreFileMode, c.StoreDirMode),
+		distribution.WithPullRateLimit(c.PullRateLimit),
+		distribution.WithMaxModelSize(c.MaxModelBytes),
+		distribution.WithDefaultOrg(c.DefaultOrg),
+		distribution.WithDefaultTag(c.DefaultTag),
 	)
 	if err != nil {
 		log.Errorf("Failed to create distribution client: %v", err)
@@ -57,10 +81,14 @@ func NewManager(log logging.Logger, c ClientConfig) *Manager {
 		// respond to requests, but may return errors if the client is required.
 	}
 
-	tokens := make(chan struct{}, maximumConcurrentModelPulls)
+	maxPulls := c.MaxConcurrentPulls
+	if maxPulls <= 0 {
+		maxPulls = maximumConcurrentModelPulls
+	}
+	tokens := make(chan struct{}, maxPulls)
 
 	// Populate the pull concurrency semaphore.
-	for i := 0; i < maximumConcurrentModelPulls; i++ {
+	for i := 0; i < maxPulls; i++ {
 		tokens <- struct{}{}
 	}
 
@@ -69,6 +97,7 @@ func NewManager(log logging.Logger, c ClientConfig) *Manager {
 		distributionClient: distributionClient,
 		registryClient:     registryClient,
 		pullTokens:         tokens,
+		catalog:            strings.TrimSpace(c.Catalog),
 	}
 }
 
@@ -86,6 +115,87 @@ func (m *Manager) GetLocal(ref string) (types.Model, error) {
 	return model, nil
 }
 
+// MarkUsed records that a local model was just used for inference, so that
+// it is evicted last when the store quota is enforced.
+func (m *Manager) MarkUsed(ref string) {
+	if m.distributionClient == nil {
+		return
+	}
+	m.distributionClient.MarkUsed(ref)
+}
+
+// SetInUseModels sets the function reporting the IDs of the models in use,
+// such as those loaded for inference, so that the store quota never evicts
+// them.
+func (m *Manager) SetInUseModels(inUse func() []string) {
+	if m.distributionClient == nil {
+		return
+	}
+	m.distributionClient.SetInUseModels(inUse)
+}
+
+// GetConfig returns the parsed config of a local model, along with its media
+// type and the capabilities derived from it.
+func (m *Manager) GetConfig(ref string) (ModelConfigResponse, error) {
+	model, err := m.GetLocal(ref)
+	if err != nil {
+		return ModelConfigResponse{}, err
+	}
+	cfg, err := model.Config()
+	if err != nil {
+		return ModelConfigResponse{}, fmt.Errorf("error while reading model config: %w", err)
+	}
+	response := ModelConfigResponse{Config: cfg, Capabilities: ModelCapabilities(model, cfg)}
+	if stored, ok := model.(storedModel); ok {
+		manifest, err := stored.Manifest()
+		if err != nil {
+			return ModelConfigResponse{}, fmt.Errorf("error while reading model manifest: %w", err)
+		}
+		response.MediaType = string(manifest.Config.MediaType)
+	}
+	return response, nil
+}
+
+// HasBlob reports whether a blob with the given digest is in the local store.
+func (m *Manager) HasBlob(digest string) (bool, error) {
+	if m.distributionClient == nil {
+		return false, fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.HasBlob(digest)
+}
+
+// WriteBlob stores a blob under the given digest, verifying its content.
+func (m *Manager) WriteBlob(digest string, r io.Reader) error {
+	if m.distributionClient == nil {
+		return fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.WriteBlob(digest, r)
+}
+
+// SetAlias maps a short alias name to a model reference in the local store.
+func (m *Manager) SetAlias(name, target string) error {
+	if m.distributionClient == nil {
+		return fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.SetAlias(name, target)
+}
+
+// ListAliases returns the alias table.
+func (m *Manager) ListAliases() (map[string]string, error) {
+	if m.distributionClient == nil {
+		return nil, fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.ListAliases()
+}
+
+// RemoveAlias removes an alias.
+func (m *Manager) RemoveAlias(name string) error {
+	if m.distributionClient == nil {
+		return fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.RemoveAlias(name)
+}
+
 // ResolveID resolves a model reference to a model ID. If resolution fails, it returns the original ref.
 func (m *Manager) ResolveID(modelRef string) string {
 	// Sanitize modelRef to prevent log forgery
@@ -207,57 +317,41 @@ func (m *Manager) Delete(reference string, force bool) (*distribution.DeleteMode
 
 // Pull pulls a model to local storage. Any error it returns is suitable
 // for writing back to the client.
-func (m *Manager) Pull(model string, bearerToken string, r *http.Request, w http.ResponseWriter) error {
-	// Restrict model pull concurrency.
+func (m *Manager) Pull(model string, bearerToken string, r *http.Request, w http.ResponseWriter, opts ...distribution.PullOption) error {
+	progressWriter, err := newProgressResponseWriter(w, r)
+	if err != nil {
+		return err
+	}
+
+	// Restrict model pull concurrency, letting the client know if the pull
+	// has to wait for another to finish. A pull canceled while waiting never
+	// starts.
 	select {
 	case <-m.pullTokens:
-	case <-r.Context().Done():
-		return context.Canceled
+	default:
+		m.log.Infoln("Waiting for pull slot:", utils.SanitizeForLog(model, -1))
+		if err := writePullWaiting(progressWriter); err != nil {
+			m.log.Warnf("Failed to write pull progress: %v", err)
+		}
+		select {
+		case <-m.pullTokens:
+		case <-r.Context().Done():
+			return context.Canceled
+		}
 	}
 	defer func() {
 		m.pullTokens <- struct{}{}
 	}()
 
-	// Set up response headers for streaming
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Transfer-Encoding", "chunked")
-
-	// Check Accept header to determine content type
-	acceptHeader := r.Header.Get("Accept")
-	isJSON := acceptHeader == "application/json"
-
-	if isJSON {
-		w.Header().Set("Content-Type", "application/json")
-	} else {
-		// Defaults to text/plain
-		w.Header().Set("Content-Type", "text/plain")
-	}
-
-	// Create a flusher to ensure chunks are sent immediately
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		return fmt.Errorf("streaming not supported")
-	}
-
-	// Create a progress writer that writes to the response
-	progressWriter := &progressResponseWriter{
-		writer:  w,
-		flusher: flusher,
-		isJSON:  isJSON,
-	}
-
 	// Pull the model using the Docker model distribution client
 	m.log.Infoln("Pulling model:", utils.SanitizeForLog(model, -1))
 
 	// Use bearer token if provided
-	var err error
 	if bearerToken != "" {
 		m.log.Infoln("Using provided bearer token for authentication")
-		err = m.distributionClient.PullModel(r.Context(), model, progressWriter, bearerToken)
-	} else {
-		err = m.distributionClient.PullModel(r.Context(), model, progressWriter)
+		opts = append(opts, distribution.WithBearerToken(bearerToken))
 	}
+	err = m.distributionClient.PullModel(r.Context(), model, progressWriter, opts...)
 
 	if err != nil {
 		return fmt.Errorf("error while pulling model: %w", err)
@@ -266,6 +360,20 @@ func (m *Manager) Pull(model string, bearerToken string, r *http.Request, w http
 	return nil
 }
 
+// writePullWaiting reports that a pull is waiting for a pull slot.
+func writePullWaiting(w io.Writer) error {
+	data, err := json.Marshal(oci.ProgressMessage{
+		Type:    oci.TypeProgress,
+		Message: "Waiting for pull slot",
+		Mode:    oci.ModePull,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
 func (m *Manager) Load(r io.Reader, progressWriter io.Writer) error {
 	if m.distributionClient == nil {
 		return fmt.Errorf("model distribution service unavailable")
@@ -277,13 +385,13 @@ func (m *Manager) Load(r io.Reader, progressWriter io.Writer) error {
 	return nil
 }
 
-func (m *Manager) Tag(ref, target string) error {
+func (m *Manager) Tag(ref, target string, force bool) (bool, error) {
 	if m.distributionClient == nil {
-		return fmt.Errorf("model distribution service unavailable")
+		return false, fmt.Errorf("model distribution service unavailable")
 	}
 
 	// First try to tag using the provided model reference as-is
-	err := m.distributionClient.Tag(ref, target)
+	created, err := m.distributionClient.Tag(ref, target, force)
 	if err != nil && errors.Is(err, distribution.ErrModelNotFound) {
 		// Check if the model parameter is a model ID (starts with sha256:) or is a partial name
 		var foundModelRef string
@@ -294,7 +402,7 @@ func (m *Manager) Tag(ref, target string) error {
 			// Get all models and find the one matching this ID
 			models, listErr := m.distributionClient.ListModels()
 			if listErr != nil {
-				return fmt.Errorf("error listing models: %w", listErr)
+				return false, fmt.Errorf("error listing models: %w", listErr)
 			}
 
 			for _, mModel := range models {
@@ -321,7 +429,7 @@ func (m *Manager) Tag(ref, target string) error {
 		if !found {
 			models, listErr := m.distributionClient.ListModels()
 			if listErr != nil {
-				return fmt.Errorf("error listing models: %w", listErr)
+				return false, fmt.Errorf("error listing models: %w", listErr)
 			}
 
 			// Look for a model whose tags match the provided reference
@@ -354,51 +462,53 @@ func (m *Manager) Tag(ref, target string) error {
 		}
 
 		if !found {
-			return distribution.ErrModelNotFound
+			return false, distribution.ErrModelNotFound
 		}
 
 		// Now tag using the found model reference (the matching tag)
-		if tagErr := m.distributionClient.Tag(foundModelRef, target); tagErr != nil {
-			m.log.Warnf("Failed to apply tag %q to resolved model %q: %v", utils.SanitizeForLog(target, -1), utils.SanitizeForLog(foundModelRef, -1), tagErr)
-			return fmt.Errorf("error while tagging model: %w", tagErr)
+		created, err = m.distributionClient.Tag(foundModelRef, target, force)
+		if err != nil {
+			m.log.Warnf("Failed to apply tag %q to resolved model %q: %v", utils.SanitizeForLog(target, -1), utils.SanitizeForLog(foundModelRef, -1), err)
+			return false, fmt.Errorf("error while tagging model: %w", err)
 		}
 	} else if err != nil {
-		return fmt.Errorf("error while tagging model: %w", err)
+		return false, fmt.Errorf("error while tagging model: %w", err)
 	}
-	return nil
+	return created, nil
 }
 
-// Push pushes a model from the store to the registry.
-func (m *Manager) Push(model string, r *http.Request, w http.ResponseWriter) error {
-	// Set up response headers for streaming
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Transfer-Encoding", "chunked")
-
-	// Check Accept header to determine content type
-	acceptHeader := r.Header.Get("Accept")
-	isJSON := acceptHeader == "application/json"
+// PushVariant is a local model published for a platform within an image
+// index, with the platform in "os/arch[/variant]" form.
+type PushVariant struct {
+	Platform string `json:"platform"`
+	Model    string `json:"model"`
+}
 
-	if isJSON {
-		w.Header().Set("Content-Type", "application/json")
-	} else {
-		w.Header().Set("Content-Type", "text/plain")
+// Push pushes a model from the store to the registry. If variants are given,
+// they are pushed as an image index under model instead.
+func (m *Manager) Push(model string, variants []PushVariant, r *http.Request, w http.ResponseWriter) error {
+	var indexVariants []distribution.IndexVariant
+	for _, v := range variants {
+		if v.Model == "" {
+			return fmt.Errorf("%w: no model for platform %q", ErrInvalidVariant, utils.SanitizeForLog(v.Platform))
+		}
+		platform, err := oci.ParsePlatform(v.Platform)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidVariant, err)
+		}
+		indexVariants = append(indexVariants, distribution.IndexVariant{Platform: platform, Model: v.Model})
 	}
 
-	// Create a flusher to ensure chunks are sent immediately
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		return errors.New("streaming not supported")
+	progressWriter, err := newProgressResponseWriter(w, r)
+	if err != nil {
+		return err
 	}
 
-	// Create a progress writer that writes to the response
-	progressWriter := &progressResponseWriter{
-		writer:  w,
-		flusher: flusher,
-		isJSON:  isJSON,
+	if len(indexVariants) > 0 {
+		err = m.distributionClient.PushModelIndex(r.Context(), model, indexVariants, progressWriter)
+	} else {
+		err = m.distributionClient.PushModel(r.Context(), model, progressWriter)
 	}
-
-	err := m.distributionClient.PushModel(r.Context(), model, progressWriter)
 	if err != nil {
 		return fmt.Errorf("error while pushing model: %w", err)
 	}
@@ -417,6 +527,75 @@ func (m *Manager) Purge() error {
 	return nil
 }
 
+// Prune removes the local models matching the request's filters.
+func (m *Manager) Prune(request ModelPruneRequest) (*distribution.PruneResponse, error) {
+	if m.distributionClient == nil {
+		return nil, fmt.Errorf("model distribution service unavailable")
+	}
+	opts := distribution.PruneOptions{
+		Dangling: request.Dangling,
+		Labels:   request.Labels,
+		DryRun:   request.DryRun,
+	}
+	if request.Until != "" {
+		until, err := time.ParseDuration(request.Until)
+		if err != nil || until <= 0 {
+			return nil, fmt.Errorf("%w: invalid until duration %q", ErrInvalidPruneRequest, request.Until)
+		}
+		opts.Until = until
+	}
+	for _, label := range request.Labels {
+		if key, _, _ := strings.Cut(label, "="); strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%w: invalid label filter %q", ErrInvalidPruneRequest, label)
+		}
+	}
+	return m.distributionClient.Prune(opts)
+}
+
+// Compact reclaims the space taken by the store's empty directories and
+// bloated index files.
+func (m *Manager) Compact() (distribution.CompactResult, error) {
+	if m.distributionClient == nil {
+		return distribution.CompactResult{}, fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.Compact()
+}
+
+// IncompleteDownloads lists the partially downloaded blobs in the store.
+func (m *Manager) IncompleteDownloads() ([]distribution.IncompleteDownload, error) {
+	if m.distributionClient == nil {
+		return nil, fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.IncompleteDownloads()
+}
+
+// PurgeIncompleteDownloads removes the partially downloaded blobs that aren't
+// being downloaded, returning those removed.
+func (m *Manager) PurgeIncompleteDownloads() ([]distribution.IncompleteDownload, error) {
+	if m.distributionClient == nil {
+		return nil, fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.PurgeIncompleteDownloads()
+}
+
+// Archive removes the weights of a local model, keeping it listed until it
+// is pulled again.
+func (m *Manager) Archive(ref string) (*distribution.ArchiveResponse, error) {
+	if m.distributionClient == nil {
+		return nil, fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.ArchiveModel(ref)
+}
+
+// Unarchive marks an archived model as usable again once its weights are
+// back in the store.
+func (m *Manager) Unarchive(ref string) error {
+	if m.distributionClient == nil {
+		return fmt.Errorf("model distribution service unavailable")
+	}
+	return m.distributionClient.UnarchiveModel(ref)
+}
+
 func (m *Manager) Export(ref string, w io.Writer) error {
 	if m.distributionClient == nil {
 		return fmt.Errorf("model distribution service unavailable")
@@ -424,15 +603,69 @@ func (m *Manager) Export(ref string, w io.Writer) error {
 	return m.distributionClient.ExportModel(ref, w)
 }
 
+// GGUFFile is a GGUF weight file of a local model.
+type GGUFFile struct {
+	// Name is the file name the weights should be extracted as. Shards use
+	// the llama.cpp split naming so that they can be loaded as a set.
+	Name string
+	// Path is the location of the weights in the model store.
+	Path string
+}
+
+// GGUFFiles returns the GGUF weight file(s) of a local model, or ErrNotGGUF if
+// the model has none.
+func (m *Manager) GGUFFiles(ref string) ([]GGUFFile, error) {
+	model, err := m.GetLocal(ref)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := model.GGUFPaths()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting GGUF paths: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, ErrNotGGUF
+	}
+	files := make([]GGUFFile, len(paths))
+	for i, p := range paths {
+		name := "model.gguf"
+		if len(paths) > 1 {
+			name = fmt.Sprintf("model-%05d-of-%05d.gguf", i+1, len(paths))
+		}
+		files[i] = GGUFFile{Name: name, Path: p}
+	}
+	return files, nil
+}
+
 type RepackageOptions struct {
 	ContextSize *uint64 `json:"context_size,omitempty"`
+	// Quantization requests the GGUF weights be requantized to the given
+	// type (e.g. "Q4_K_M").
+	Quantization *string `json:"quantization,omitempty"`
 }
 
-func (m *Manager) Repackage(sourceRef string, targetRef string, opts RepackageOptions) error {
+// Repackage creates targetRef as a variant of sourceRef. Requantization
+// progress, if requested, is written to progressWriter.
+func (m *Manager) Repackage(ctx context.Context, sourceRef string, targetRef string, opts RepackageOptions, progressWriter io.Writer) error {
 	if m.distributionClient == nil {
 		return fmt.Errorf("model distribution service unavailable")
 	}
-	return m.distributionClient.RepackageModel(sourceRef, targetRef, distribution.RepackageOptions{
-		ContextSize: opts.ContextSize,
-	})
+	distOpts := distribution.RepackageOptions{
+		ContextSize:    opts.ContextSize,
+		ProgressWriter: progressWriter,
+	}
+	if opts.Quantization != nil {
+		quantization := strings.ToUpper(*opts.Quantization)
+		if !validQuantization.MatchString(quantization) {
+			return fmt.Errorf("%w: invalid quantization type %q", distribution.ErrRequantizationUnsupported, *opts.Quantization)
+		}
+		quantizer := findQuantizer()
+		if quantizer == nil {
+			return fmt.Errorf("%w: llama-quantize not found (install it or set %s to its path)",
+				distribution.ErrRequantizationUnsupported, quantizerEnv)
+		}
+		distOpts.Quantization = &quantization
+		distOpts.Quantizer = quantizer
+	}
+	return m.distributionClient.RepackageModel(ctx, sourceRef, targetRef, distOpts)
 }
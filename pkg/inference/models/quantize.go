@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"github.com/docker/model-runner/pkg/distribution/distribution"
+)
+
+// quantizerEnv overrides the path of the llama-quantize binary used to
+// requantize GGUF models.
+const quantizerEnv = "MODEL_RUNNER_LLAMA_QUANTIZE"
+
+// validQuantization matches llama.cpp quantization type names such as Q4_K_M.
+var validQuantization = regexp.MustCompile(`^[A-Z0-9_]+$`)
+
+// findQuantizer returns a quantizer backed by llama-quantize, or nil if the
+// binary is not available.
+func findQuantizer() distribution.Quantizer {
+	path := os.Getenv(quantizerEnv)
+	if path == "" {
+		var err error
+		if path, err = exec.LookPath("llama-quantize"); err != nil {
+			return nil
+		}
+	}
+	return func(ctx context.Context, src, dst, quantization string, log io.Writer) error {
+		cmd := exec.CommandContext(ctx, path, src, dst, quantization)
+		cmd.Stdout = log
+		cmd.Stderr = log
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("llama-quantize: %w", err)
+		}
+		return nil
+	}
+}
@@ -1,6 +1,8 @@
 package scheduling
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
@@ -13,12 +15,23 @@ const (
 	// enough to encompass any real-world request but also small enough to avoid
 	// DoS attacks.
 	maximumOpenAIInferenceRequestSize = 10 * 1024 * 1024
+	// defaultUnloadWaitTimeout is how long an unload of all runners waits for
+	// in-flight requests when no timeout is given.
+	defaultUnloadWaitTimeout = 2 * time.Minute
+	// applyTemplatePath is the llama.cpp endpoint rendering a chat template
+	// for messages without generating.
+	applyTemplatePath = "/apply-template"
 )
 
 // trimRequestPathToOpenAIRoot trims a request path to start at the first
-// instance of /v1/ to appear in the path.
+// instance of /v1/ to appear in the path. Tokenization requests are mapped to
+// the backend's unversioned /tokenize and /detokenize endpoints, and template
+// rendering requests to its /apply-template endpoint.
 func trimRequestPathToOpenAIRoot(path string) string {
-	if index := strings.Index(path, "/v1/"); index != -1 {
+	if strings.HasSuffix(path, "/v1/tokenize") || strings.HasSuffix(path, "/v1/detokenize") ||
+		strings.HasSuffix(path, applyTemplatePath) {
+		return path[strings.LastIndex(path, "/"):]
+	} else if index := strings.Index(path, "/v1/"); index != -1 {
 		return path[index:]
 	} else if index = strings.Index(path, "/rerank"); index != -1 {
 		return path[index:]
@@ -41,6 +54,10 @@ func backendModeForRequest(path string) (inference.BackendMode, bool) {
 	} else if strings.HasSuffix(path, "/v1/messages") || strings.HasSuffix(path, "/v1/messages/count_tokens") {
 		// Anthropic Messages API - treated as completion mode
 		return inference.BackendModeCompletion, true
+	} else if strings.HasSuffix(path, "/v1/tokenize") || strings.HasSuffix(path, "/v1/detokenize") ||
+		strings.HasSuffix(path, applyTemplatePath) {
+		// Tokenization and template rendering use the completion model.
+		return inference.BackendModeCompletion, true
 	} else if strings.HasSuffix(path, "/v1/images/generations") {
 		// OpenAI Images API - image generation mode
 		return inference.BackendModeImageGeneration, true
@@ -53,6 +70,22 @@ func backendModeForRequest(path string) (inference.BackendMode, bool) {
 type OpenAIInferenceRequest struct {
 	// Model is the requested model name.
 	Model string `json:"model"`
+	// ChatTemplate is a Jinja chat template overriding the model's bundled
+	// template for a chat completion request. It is only supported by the
+	// llama.cpp backend. The template runs inside the backend with access to
+	// the request's messages and tools, so servers exposed to untrusted
+	// clients should treat it like any other prompt content.
+	ChatTemplate string `json:"chat_template,omitempty"`
+	// ResponseFormat is the output format requested for a chat completion,
+	// if any.
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIResponseFormat is the response_format of an OpenAI chat completion
+// request.
+type OpenAIResponseFormat struct {
+	// Type is the requested format: "text", "json_object" or "json_schema".
+	Type string `json:"type"`
 }
 
 // OpenAIErrorResponse is used to format an OpenAI API compatible error response
@@ -85,11 +118,65 @@ type DiskUsage struct {
 	DefaultBackendDiskUsage int64 `json:"default_backend_disk_usage"`
 }
 
+// TokenUsage is the token usage of the inference requests for a model by a
+// tenant.
+type TokenUsage struct {
+	// Model is the model, as named in the requests.
+	Model string `json:"model"`
+	// Tenant is the tenant named by the requests' inference.TenantHeader, if
+	// any.
+	Tenant string `json:"tenant,omitempty"`
+	// Requests is the number of requests.
+	Requests int64 `json:"requests"`
+	// PromptTokens is the number of prompt tokens processed.
+	PromptTokens int64 `json:"prompt_tokens"`
+	// CompletionTokens is the number of tokens generated.
+	CompletionTokens int64 `json:"completion_tokens"`
+	// TotalTokens is the sum of the prompt and completion tokens.
+	TotalTokens int64 `json:"total_tokens"`
+}
+
+// UsageReport is used to return the token usage recorded since a point in
+// time, by model and tenant.
+type UsageReport struct {
+	// Since is the start of the reported period. Usage is only retained for
+	// a limited time and number of requests, so it may be later than
+	// requested.
+	Since time.Time `json:"since"`
+	// Usage holds the totals, sorted by model and tenant.
+	Usage []TokenUsage `json:"usage"`
+}
+
 // UnloadRequest is used to specify which models to unload.
 type UnloadRequest struct {
 	All     bool     `json:"all"`
 	Backend string   `json:"backend"`
 	Models  []string `json:"models"`
+	// Wait, with All, waits for in-flight requests to finish and unloads
+	// each runner once it becomes idle, rather than skipping runners in use.
+	Wait bool `json:"wait,omitempty"`
+	// WaitTimeout bounds the wait as a Go duration string. It defaults to
+	// defaultUnloadWaitTimeout.
+	WaitTimeout string `json:"wait-timeout,omitempty"`
+}
+
+// drainTimeout returns how long an unload waits for in-flight requests, or
+// zero if it doesn't wait.
+func (u UnloadRequest) drainTimeout() (time.Duration, error) {
+	if !u.All || !u.Wait {
+		return 0, nil
+	}
+	if u.WaitTimeout == "" {
+		return defaultUnloadWaitTimeout, nil
+	}
+	timeout, err := time.ParseDuration(u.WaitTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wait timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid wait timeout: %s must be positive", u.WaitTimeout)
+	}
+	return timeout, nil
 }
 
 // UnloadResponse is used to return the number of unloaded runners (backend, model).
@@ -97,14 +184,123 @@ type UnloadResponse struct {
 	UnloadedRunners int `json:"unloaded_runners"`
 }
 
+// WarmupRequest lists the models to preload.
+type WarmupRequest struct {
+	Models []string `json:"models"`
+}
+
+// Warmup statuses reported in WarmupResult.
+const (
+	// WarmupLoaded indicates that the model was loaded or already resident.
+	WarmupLoaded = "loaded"
+	// WarmupSkipped indicates that the model couldn't be loaded without
+	// waiting, for example because all runner slots are in use.
+	WarmupSkipped = "skipped"
+	// WarmupFailed indicates that loading the model failed.
+	WarmupFailed = "failed"
+)
+
+// WarmupResult reports the outcome of preloading a single model.
+type WarmupResult struct {
+	Model  string `json:"model"`
+	Status string `json:"status"`
+	// Error describes why the model was skipped or failed to load.
+	Error string `json:"error,omitempty"`
+}
+
+// WarmupResponse reports the outcome of a warmup, one result per requested
+// model in request order.
+type WarmupResponse struct {
+	Results []WarmupResult `json:"results"`
+}
+
+// ReloadBackendResponse describes the outcome of a backend reload.
+type ReloadBackendResponse struct {
+	// Backend is the name of the reloaded backend.
+	Backend string `json:"backend"`
+	// UnloadedModels are the models whose runners were unloaded.
+	UnloadedModels []string `json:"unloaded_models"`
+	// Status is the backend status after the reload.
+	Status string `json:"status"`
+}
+
 // ConfigureRequest specifies per-model runtime configuration options.
 type ConfigureRequest struct {
 	Model           string                 `json:"model"`
 	Mode            *inference.BackendMode `json:"mode,omitempty"`
 	RawRuntimeFlags string                 `json:"raw-runtime-flags,omitempty"`
+	// KeepAlive is how long the model stays loaded once idle, as a Go
+	// duration string. "0" unloads it as soon as it is idle, a negative
+	// duration keeps it loaded indefinitely and an empty string restores the
+	// server default.
+	KeepAlive *string `json:"keep-alive,omitempty"`
+	// ResetBreaker clears the model's load circuit breaker so that loads
+	// are attempted again immediately.
+	ResetBreaker bool `json:"reset-breaker,omitempty"`
+	// DefaultStop holds stop sequences merged into the stop sequences of
+	// every request for the model. An empty list removes them, so the field
+	// is only omitted when nil.
+	DefaultStop []string `json:"default-stop,omitzero"`
+	// DefaultSystemPrompt is the system prompt applied to every chat request
+	// for the model according to SystemPromptPolicy. An empty prompt removes
+	// it.
+	DefaultSystemPrompt *string `json:"default-system-prompt,omitempty"`
+	// SystemPromptPolicy selects how DefaultSystemPrompt combines with a
+	// request's own system prompt. It defaults to SystemPromptIfMissing.
+	SystemPromptPolicy SystemPromptPolicy `json:"system-prompt-policy,omitempty"`
 	inference.BackendConfiguration
 }
 
+// SystemPromptPolicy selects how a model's default system prompt combines
+// with the system prompt of a request.
+type SystemPromptPolicy string
+
+const (
+	// SystemPromptIfMissing applies the default system prompt only to
+	// requests without a system prompt.
+	SystemPromptIfMissing SystemPromptPolicy = "if-missing"
+	// SystemPromptMerge also places the default system prompt before the
+	// system prompt of requests that have one.
+	SystemPromptMerge SystemPromptPolicy = "merge"
+)
+
+// LoaderSettings describes the effective configuration of the scheduler's
+// runner loader.
+type LoaderSettings struct {
+	// IdleTimeout is how long unused runners stay loaded.
+	IdleTimeout time.Duration
+	// BackendStartTimeout is how long backends may take to become ready.
+	BackendStartTimeout time.Duration
+	// DefaultContextSize is the server-wide default context size, if set.
+	DefaultContextSize *int32
+	// MaxContextSize is the largest context size allowed, if capped.
+	MaxContextSize *int32
+}
+
+// ResetConfigResponse is used to return the number of runner configurations
+// reset to their defaults.
+type ResetConfigResponse struct {
+	ResetConfigs int `json:"reset_configs"`
+}
+
+// RenderTemplateRequest is used to render a model's chat template for
+// messages without generating.
+type RenderTemplateRequest struct {
+	// Model is the model whose chat template is rendered.
+	Model string `json:"model"`
+	// Messages are the chat messages, in the OpenAI format.
+	Messages []json.RawMessage `json:"messages"`
+	// ChatTemplate is a Jinja chat template to render instead of the model's
+	// bundled template.
+	ChatTemplate string `json:"chat_template,omitempty"`
+}
+
+// RenderTemplateResponse is used to return a rendered chat template.
+type RenderTemplateResponse struct {
+	// Prompt is the prompt the template renders, as sent to the model.
+	Prompt string `json:"prompt"`
+}
+
 // ModelConfigEntry represents a model configuration entry with its associated metadata.
 type ModelConfigEntry struct {
 	Backend string
@@ -112,4 +308,14 @@ type ModelConfigEntry struct {
 	ModelID string
 	Mode    inference.BackendMode
 	Config  inference.BackendConfiguration
+	// DefaultStop holds the stop sequences merged into the model's requests.
+	DefaultStop []string `json:",omitempty"`
+	// DefaultSystemPrompt is the system prompt applied to the model's chat
+	// requests.
+	DefaultSystemPrompt string `json:",omitempty"`
+	// SystemPromptPolicy is how DefaultSystemPrompt is applied.
+	SystemPromptPolicy SystemPromptPolicy `json:",omitempty"`
+	// KeepAlive is how long the model stays loaded once idle, as a Go
+	// duration string, if it overrides the server default.
+	KeepAlive string `json:",omitempty"`
 }
@@ -0,0 +1,330 @@
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/model-runner/pkg/inference"
+)
+
+const (
+	// maximumBatchRequestSize is the maximum batch request size that the
+	// scheduler will accept.
+	maximumBatchRequestSize = 64 * 1024 * 1024
+	// maximumBatchItems is the maximum number of requests in a single batch.
+	maximumBatchItems = 10000
+	// defaultBatchConcurrency is the number of batch items executed at once
+	// when the runner's slot count isn't configured. It matches the number of
+	// slots llama.cpp's server provides by default.
+	defaultBatchConcurrency = 4
+)
+
+// BatchRequest is the body of a batch inference request.
+type BatchRequest struct {
+	// Model is the model used for every request in the batch.
+	Model string `json:"model"`
+	// Requests are OpenAI chat completion request bodies. Their model is set
+	// to Model and streaming is disabled.
+	Requests []json.RawMessage `json:"requests"`
+	// Concurrency optionally lowers the number of requests executed at once.
+	// It is capped at the runner's slot count.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Stream enables server-sent events reporting each item as it completes.
+	Stream bool `json:"stream,omitempty"`
+}
+
+// BatchItemResult is the outcome of a single request in a batch.
+type BatchItemResult struct {
+	// Index is the position of the request in the batch.
+	Index int `json:"index"`
+	// Status is the HTTP status code of the request.
+	Status int `json:"status"`
+	// Response is the chat completion response of a successful request.
+	Response json.RawMessage `json:"response,omitempty"`
+	// Error describes why the request failed.
+	Error string `json:"error,omitempty"`
+}
+
+// BatchResponse is the result of a batch inference request.
+type BatchResponse struct {
+	// Model is the model used for the batch.
+	Model string `json:"model"`
+	// Results holds the outcome of each request, in request order.
+	Results []BatchItemResult `json:"results"`
+}
+
+// parallelSlots returns the slot count set by a --parallel (or -np) runtime
+// flag, or zero if there is none.
+func parallelSlots(flags []string) int {
+	for i, flag := range flags {
+		name, value, hasValue := strings.Cut(flag, "=")
+		if name != "--parallel" && name != "-np" {
+			continue
+		}
+		if !hasValue && i+1 < len(flags) {
+			value = flags[i+1]
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// Batch handles POST <inference-prefix>/{backend}/v1/batch requests, running
+// each chat completion in the batch against the model with concurrency up to
+// the runner's slot count.
+func (h *HTTPHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var backend inference.Backend
+	if b := r.PathValue("backend"); b == "" {
+		backend = h.scheduler.defaultBackend
+	} else {
+		backend = h.scheduler.backends[b]
+	}
+	if backend == nil {
+		http.Error(w, ErrBackendNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maximumBatchRequestSize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, "request too large", http.StatusBadRequest)
+		} else {
+			http.Error(w, "failed to read request body", http.StatusInternalServerError)
+		}
+		return
+	}
+	var request BatchRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if request.Model == "" {
+		http.Error(w, "model is required", http.StatusBadRequest)
+		return
+	}
+	if len(request.Requests) == 0 {
+		http.Error(w, "requests are required", http.StatusBadRequest)
+		return
+	}
+	if len(request.Requests) > maximumBatchItems {
+		http.Error(w, fmt.Sprintf("batch exceeds the maximum of %d requests", maximumBatchItems), http.StatusBadRequest)
+		return
+	}
+	items := make([][]byte, len(request.Requests))
+	for i, raw := range request.Requests {
+		item, err := batchItemBody(raw, request.Model)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid request at index %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		items[i] = item
+	}
+
+	var flusher http.Flusher
+	if request.Stream {
+		var ok bool
+		if flusher, ok = w.(http.Flusher); !ok {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		flusher.Flush()
+	}
+
+	concurrency := h.scheduler.loader.batchConcurrency(r.Context(), backend.Name(),
+		h.scheduler.modelManager.ResolveID(request.Model))
+	if request.Concurrency > 0 {
+		concurrency = min(concurrency, request.Concurrency)
+	}
+
+	response := BatchResponse{Model: request.Model, Results: make([]BatchItemResult, len(items))}
+	var streamLock sync.Mutex
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				result := h.runBatchItem(r, items[i])
+				result.Index = i
+				response.Results[i] = result
+				if flusher != nil {
+					data, err := json.Marshal(result)
+					if err != nil {
+						continue
+					}
+					streamLock.Lock()
+					fmt.Fprintf(w, "event: item\ndata: %s\n\n", data)
+					flusher.Flush()
+					streamLock.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range items {
+		if r.Context().Err() != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	if r.Context().Err() != nil {
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	if flusher != nil {
+		fmt.Fprintf(w, "event: done\ndata: %s\n\n", data)
+		flusher.Flush()
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// batchItemBody returns the chat completion request body for a batch item,
+// with its model set to model and streaming disabled.
+func batchItemBody(raw json.RawMessage, model string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errors.New("request must be a JSON object")
+	}
+	encodedModel, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	fields["model"] = encodedModel
+	fields["stream"] = json.RawMessage("false")
+	return json.Marshal(fields)
+}
+
+// runBatchItem schedules a single batch item through the regular inference
+// path and captures its result.
+func (h *HTTPHandler) runBatchItem(r *http.Request, body []byte) BatchItemResult {
+	path := inference.InferencePrefix + "/v1/chat/completions"
+	if backend := r.PathValue("backend"); backend != "" {
+		path = inference.InferencePrefix + "/" + backend + "/v1/chat/completions"
+	}
+	itemReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return BatchItemResult{Status: http.StatusInternalServerError, Error: err.Error()}
+	}
+	itemReq.Header = batchItemHeader(r.Header)
+	itemReq.Header.Set("Content-Type", "application/json")
+	itemReq.SetPathValue("backend", r.PathValue("backend"))
+
+	recorder := &batchItemWriter{header: make(http.Header)}
+	h.handleOpenAIInference(recorder, itemReq)
+	status := recorder.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	result := BatchItemResult{Status: status}
+	if status == http.StatusOK && json.Valid(recorder.body.Bytes()) {
+		result.Response = json.RawMessage(recorder.body.Bytes())
+	} else if status == http.StatusOK {
+		result.Status = http.StatusBadGateway
+		result.Error = "backend returned an invalid response"
+	} else {
+		result.Error = strings.TrimSpace(recorder.body.String())
+	}
+	return result
+}
+
+// hopByHopHeaders are the headers that describe a single connection, which
+// batch items don't inherit from the batch request.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// batchItemHeader returns the headers of a batch item's request: those of the
+// batch request, such as its tenant and user agent, minus the hop-by-hop
+// headers and those describing the batch request's body.
+func batchItemHeader(header http.Header) http.Header {
+	itemHeader := header.Clone()
+	if itemHeader == nil {
+		itemHeader = make(http.Header)
+	}
+	for _, connection := range header.Values("Connection") {
+		for _, name := range strings.Split(connection, ",") {
+			itemHeader.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		itemHeader.Del(name)
+	}
+	itemHeader.Del("Content-Length")
+	itemHeader.Del("Content-Encoding")
+	return itemHeader
+}
+
+// batchItemWriter buffers the response to a batch item.
+type batchItemWriter struct {
+	header http.Header
+	// status is the response status, or zero if none was written.
+	status int
+	// body holds the response body.
+	body bytes.Buffer
+}
+
+func (w *batchItemWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchItemWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchItemWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// batchConcurrency returns the number of batch items to execute at once for
+// a model, based on the slot count configured for its runner.
+func (l *loader) batchConcurrency(ctx context.Context, backendName, modelID string) int {
+	if !l.lock(ctx) {
+		return defaultBatchConcurrency
+	}
+	defer l.unlock()
+	if config, ok := l.runnerConfigs[makeConfigKey(backendName, modelID, inference.BackendModeCompletion)]; ok {
+		if slots := parallelSlots(config.RuntimeFlags); slots > 0 {
+			return slots
+		}
+	}
+	return defaultBatchConcurrency
+}
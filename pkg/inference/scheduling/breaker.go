@@ -0,0 +1,161 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// breakerFailureThreshold is the number of consecutive load failures of a
+	// model within breakerFailureWindow that opens its circuit breaker.
+	breakerFailureThreshold = 3
+	// breakerFailureWindow is the window within which consecutive load
+	// failures are counted towards opening a circuit breaker.
+	breakerFailureWindow = 10 * time.Minute
+	// breakerCooldown is how long an open circuit breaker rejects loads before
+	// allowing another attempt.
+	breakerCooldown = 2 * time.Minute
+)
+
+// ErrModelUnavailable indicates that loads of a model are being rejected
+// because it has repeatedly failed to load. If returned in conjunction with an
+// HTTP request, it should be paired with a 503 response status and a
+// Retry-After header.
+var ErrModelUnavailable = errors.New("model unavailable")
+
+// ModelUnavailableError is returned for loads rejected by an open circuit
+// breaker. It matches ErrModelUnavailable under errors.Is.
+type ModelUnavailableError struct {
+	// Model is the ID of the rejected model.
+	Model string
+	// RetryAfter is the time remaining until another load will be attempted.
+	RetryAfter time.Duration
+	// LastError is the error from the most recent failed load.
+	LastError error
+}
+
+func (e *ModelUnavailableError) Error() string {
+	return fmt.Sprintf("model %s unavailable after %d consecutive load failures, retry in %s: %v",
+		e.Model, breakerFailureThreshold, e.RetryAfter.Round(time.Second), e.LastError)
+}
+
+func (e *ModelUnavailableError) Unwrap() error {
+	return ErrModelUnavailable
+}
+
+// BreakerStatus describes the circuit breaker state of a model that has
+// recently failed to load.
+type BreakerStatus struct {
+	// Model is the model ID.
+	Model string `json:"model"`
+	// Open indicates whether loads of the model are currently rejected.
+	Open bool `json:"open"`
+	// Failures is the number of consecutive load failures.
+	Failures int `json:"failures"`
+	// LastError is the error from the most recent failed load.
+	LastError string `json:"last_error"`
+	// RetryAt is when an open breaker will next allow a load attempt.
+	RetryAt time.Time `json:"retry_at,omitempty"`
+}
+
+// breakerState tracks the load failures of a single model.
+type breakerState struct {
+	// failures is the number of consecutive load failures.
+	failures int
+	// firstFailure is the time of the first failure in the current window.
+	firstFailure time.Time
+	// openUntil is the time until which loads are rejected. It is zero if
+	// the breaker is closed.
+	openUntil time.Time
+	// lastErr is the error from the most recent failed load.
+	lastErr error
+}
+
+// loadBreaker is a per-model circuit breaker that short-circuits loads of
+// models that repeatedly fail to load. It is not safe for concurrent use; the
+// loader guards it with its lock.
+type loadBreaker struct {
+	// now returns the current time.
+	now func() time.Time
+	// states maps model IDs to their failure state.
+	states map[string]*breakerState
+}
+
+// newLoadBreaker creates a new load breaker.
+func newLoadBreaker() *loadBreaker {
+	return &loadBreaker{
+		now:    time.Now,
+		states: make(map[string]*breakerState),
+	}
+}
+
+// check returns a *ModelUnavailableError if the breaker for modelID is open.
+// Once the cooldown has elapsed, a single attempt is allowed through; if it
+// fails, the breaker reopens immediately.
+func (b *loadBreaker) check(modelID string) error {
+	state, ok := b.states[modelID]
+	if !ok || state.openUntil.IsZero() {
+		return nil
+	}
+	now := b.now()
+	if now.Before(state.openUntil) {
+		return &ModelUnavailableError{
+			Model:      modelID,
+			RetryAfter: state.openUntil.Sub(now),
+			LastError:  state.lastErr,
+		}
+	}
+	state.openUntil = time.Time{}
+	state.failures = breakerFailureThreshold - 1
+	state.firstFailure = now
+	return nil
+}
+
+// recordFailure records a failed load of modelID, opening the breaker once
+// the failure threshold is reached within the failure window.
+func (b *loadBreaker) recordFailure(modelID string, err error) {
+	now := b.now()
+	state, ok := b.states[modelID]
+	if !ok || now.Sub(state.firstFailure) > breakerFailureWindow {
+		state = &breakerState{firstFailure: now}
+		b.states[modelID] = state
+	}
+	state.failures++
+	state.lastErr = err
+	if state.failures >= breakerFailureThreshold {
+		state.openUntil = now.Add(breakerCooldown)
+	}
+}
+
+// reset closes the breaker for modelID and clears its failures. It reports
+// whether there was any state to clear.
+func (b *loadBreaker) reset(modelID string) bool {
+	_, ok := b.states[modelID]
+	delete(b.states, modelID)
+	return ok
+}
+
+// statuses returns the state of each model with recorded failures, sorted by
+// model ID.
+func (b *loadBreaker) statuses() []BreakerStatus {
+	statuses := make([]BreakerStatus, 0, len(b.states))
+	now := b.now()
+	for modelID, state := range b.states {
+		status := BreakerStatus{
+			Model:     modelID,
+			Failures:  state.failures,
+			LastError: state.lastErr.Error(),
+		}
+		if now.Before(state.openUntil) {
+			status.Open = true
+			status.RetryAt = state.openUntil
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Model < statuses[j].Model
+	})
+	return statuses
+}
@@ -0,0 +1,166 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidChatTemplate indicates that a request's chat template override
+// doesn't compile. If returned in conjunction with an HTTP request, it should
+// be paired with a 400 response status.
+var ErrInvalidChatTemplate = errors.New("invalid chat template")
+
+// chatTemplateBlocks maps the Jinja tags opening a block to the tag closing
+// it. The set tag only opens a block when it has no assignment.
+var chatTemplateBlocks = map[string]string{
+	"if":         "endif",
+	"for":        "endfor",
+	"macro":      "endmacro",
+	"call":       "endcall",
+	"filter":     "endfilter",
+	"set":        "endset",
+	"block":      "endblock",
+	"raw":        "endraw",
+	"generation": "endgeneration",
+}
+
+// chatTemplateBranches maps the Jinja tags continuing a block to the tags of
+// the blocks they may continue.
+var chatTemplateBranches = map[string][]string{
+	"elif": {"if"},
+	"else": {"if", "for"},
+}
+
+// templateBlock is a Jinja block that is open while validating a template.
+type templateBlock struct {
+	// tag is the tag that opened the block.
+	tag string
+	// line is the line on which the block opened.
+	line int
+}
+
+// validateChatTemplate checks that a Jinja chat template compiles: that its
+// tags, expressions and comments are terminated, that their brackets and
+// strings are balanced, and that its blocks are properly nested. It doesn't
+// evaluate the template.
+func validateChatTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("%w: template is empty", ErrInvalidChatTemplate)
+	}
+	var open []templateBlock
+	for pos := 0; pos < len(template); {
+		start := strings.IndexByte(template[pos:], '{')
+		if start < 0 || pos+start+1 >= len(template) {
+			break
+		}
+		start += pos
+		line := 1 + strings.Count(template[:start], "\n")
+		var closing string
+		switch template[start+1] {
+		case '{':
+			closing = "}}"
+		case '%':
+			closing = "%}"
+		case '#':
+			closing = "#}"
+		default:
+			pos = start + 1
+			continue
+		}
+
+		if closing == "#}" {
+			end := strings.Index(template[start+2:], closing)
+			if end < 0 {
+				return fmt.Errorf("%w: unterminated comment on line %d", ErrInvalidChatTemplate, line)
+			}
+			pos = start + 2 + end + 2
+			continue
+		}
+		end, err := scanTemplateExpression(template, start+2, closing)
+		if err != nil {
+			return fmt.Errorf("%w: %v on line %d", ErrInvalidChatTemplate, err, line)
+		}
+		pos = end + len(closing)
+		if closing == "}}" {
+			if strings.Trim(template[start+2:end], "-+ \t\r\n") == "" {
+				return fmt.Errorf("%w: empty expression on line %d", ErrInvalidChatTemplate, line)
+			}
+			continue
+		}
+
+		statement := strings.Fields(strings.Trim(template[start+2:end], "-+"))
+		if len(statement) == 0 {
+			return fmt.Errorf("%w: empty tag on line %d", ErrInvalidChatTemplate, line)
+		}
+		tag := statement[0]
+		switch {
+		case tag == "set" && strings.Contains(template[start+2:end], "="):
+			// An assignment, which doesn't open a block.
+		case chatTemplateBlocks[tag] != "":
+			open = append(open, templateBlock{tag: tag, line: line})
+			if tag == "raw" {
+				// Skip the raw block's content, which isn't parsed.
+				endRaw := strings.Index(template[pos:], "endraw")
+				if endRaw < 0 {
+					return fmt.Errorf("%w: unclosed raw block opened on line %d", ErrInvalidChatTemplate, line)
+				}
+				if pos = strings.LastIndex(template[:pos+endRaw], "{%"); pos < 0 {
+					return fmt.Errorf("%w: unclosed raw block opened on line %d", ErrInvalidChatTemplate, line)
+				}
+			}
+		case chatTemplateBranches[tag] != nil:
+			if len(open) == 0 || !slices.Contains(chatTemplateBranches[tag], open[len(open)-1].tag) {
+				return fmt.Errorf("%w: unexpected %q on line %d", ErrInvalidChatTemplate, tag, line)
+			}
+		case strings.HasPrefix(tag, "end"):
+			if len(open) == 0 || chatTemplateBlocks[open[len(open)-1].tag] != tag {
+				return fmt.Errorf("%w: unexpected %q on line %d", ErrInvalidChatTemplate, tag, line)
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		block := open[len(open)-1]
+		return fmt.Errorf("%w: unclosed %s block opened on line %d", ErrInvalidChatTemplate, block.tag, block.line)
+	}
+	return nil
+}
+
+// scanTemplateExpression returns the offset of the closing delimiter of the
+// Jinja tag or expression whose content starts at offset pos, checking that
+// its strings and brackets are balanced.
+func scanTemplateExpression(template string, pos int, closing string) (int, error) {
+	var brackets []byte
+	for i := pos; i < len(template); i++ {
+		if len(brackets) == 0 && strings.HasPrefix(template[i:], closing) {
+			return i, nil
+		}
+		switch c := template[i]; c {
+		case '"', '\'':
+			for i++; i < len(template) && template[i] != c; i++ {
+				if template[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(template) {
+				return 0, errors.New("unterminated string")
+			}
+		case '(', '[', '{':
+			brackets = append(brackets, c)
+		case ')', ']', '}':
+			if len(brackets) == 0 || brackets[len(brackets)-1] != matchingBracket[c] {
+				return 0, fmt.Errorf("unbalanced %q", c)
+			}
+			brackets = brackets[:len(brackets)-1]
+		}
+	}
+	if closing == "}}" {
+		return 0, errors.New("unterminated expression")
+	}
+	return 0, errors.New("unterminated tag")
+}
+
+// matchingBracket maps closing brackets to the brackets they close.
+var matchingBracket = map[byte]byte{')': '(', ']': '[', '}': '{'}
@@ -0,0 +1,88 @@
+package scheduling
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+// coalesceRequestsEnv names the environment variable that, when true, makes
+// concurrent identical deterministic requests share a single backend call
+// unless they opt out with inference.CoalesceHeader.
+const coalesceRequestsEnv = "MODEL_RUNNER_COALESCE_REQUESTS"
+
+// coalesceRequestsFromEnv reports whether request coalescing is enabled by
+// default in the environment.
+func coalesceRequestsFromEnv(log logging.Logger) bool {
+	raw := os.Getenv(coalesceRequestsEnv)
+	if raw == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Warnf("Ignoring invalid %s value %q", coalesceRequestsEnv, utils.SanitizeForLog(raw, -1))
+		return false
+	}
+	return enabled
+}
+
+// coalesces reports whether r is to be coalesced with identical in-flight
+// requests. The header takes precedence over the server default.
+func coalesces(r *http.Request, enabled bool) bool {
+	if coalesce, err := strconv.ParseBool(r.Header.Get(inference.CoalesceHeader)); err == nil {
+		return coalesce
+	}
+	return enabled
+}
+
+// coalescedCall is a backend call shared by identical in-flight requests.
+type coalescedCall struct {
+	// done is closed once the call completes.
+	done chan struct{}
+	// response is the call's response, or nil if it couldn't be shared. It
+	// is only valid once done is closed.
+	response *cachedResponse
+}
+
+// coalescer tracks in-flight deterministic requests so that identical ones
+// arriving meanwhile wait for their response rather than calling the
+// backend again.
+type coalescer struct {
+	// lock guards calls.
+	lock sync.Mutex
+	// calls maps request keys, as computed by responseCacheKey, to the calls
+	// in flight for them.
+	calls map[string]*coalescedCall
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{calls: make(map[string]*coalescedCall)}
+}
+
+// join returns the call in flight for key, creating it if there is none, in
+// which case the caller leads the call and must finish it.
+func (c *coalescer) join(key string) (*coalescedCall, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if call, ok := c.calls[key]; ok {
+		return call, false
+	}
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	return call, true
+}
+
+// finish completes the call for key with response, which is nil if it can't
+// be shared, releasing the requests waiting for it.
+func (c *coalescer) finish(key string, call *coalescedCall, response *cachedResponse) {
+	c.lock.Lock()
+	delete(c.calls, key)
+	c.lock.Unlock()
+	call.response = response
+	close(call.done)
+}
@@ -0,0 +1,145 @@
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+const (
+	// maxContextSizeEnv names the environment variable holding the maximum
+	// context size that requests may configure.
+	maxContextSizeEnv = "MODEL_RUNNER_MAX_CTX"
+	// contextSizeCapModeEnv names the environment variable selecting whether
+	// context sizes above the maximum are clamped (the default) or rejected.
+	contextSizeCapModeEnv = "MODEL_RUNNER_CTX_CAP_MODE"
+)
+
+// ErrContextSizeExceeded indicates that a requested context size exceeds the
+// configured maximum. If returned in conjunction with an HTTP request, it
+// should be paired with a 400 response status.
+var ErrContextSizeExceeded = errors.New("context size exceeds the configured maximum")
+
+// contextSizeFlags are the runtime flags, across backends, that set the
+// context size.
+var contextSizeFlags = []string{"-c", "--ctx-size", "--max-model-len"}
+
+// contextSizeCap is an operator policy limiting the context sizes that
+// requests may configure, independent of available memory.
+type contextSizeCap struct {
+	// max is the largest context size allowed.
+	max int32
+	// reject causes oversized requests to fail rather than be clamped.
+	reject bool
+}
+
+// contextSizeCapFromEnv returns the context size cap configured in the
+// environment, or nil if there is none.
+func contextSizeCapFromEnv(log logging.Logger) *contextSizeCap {
+	raw := os.Getenv(maxContextSizeEnv)
+	if raw == "" {
+		return nil
+	}
+	size, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || size <= 0 {
+		log.Warnf("Ignoring invalid %s value %q", maxContextSizeEnv, utils.SanitizeForLog(raw, -1))
+		return nil
+	}
+	c := &contextSizeCap{max: int32(size)}
+	switch mode := os.Getenv(contextSizeCapModeEnv); mode {
+	case "", "clamp":
+	case "reject":
+		c.reject = true
+	default:
+		log.Warnf("Ignoring invalid %s value %q, clamping context sizes", contextSizeCapModeEnv, utils.SanitizeForLog(mode, -1))
+	}
+	return c
+}
+
+// exceeds reports whether size is above the cap. An unlimited (-1) context
+// size always exceeds it.
+func (c *contextSizeCap) exceeds(size int32) bool {
+	return size > c.max || size < 0
+}
+
+// apply enforces the cap on the context size of config, including any set
+// through runtime flags. In clamp mode, oversized values are replaced by the
+// maximum and apply reports that it clamped them; in reject mode, it returns
+// an error wrapping ErrContextSizeExceeded. A nil cap allows any size.
+func (c *contextSizeCap) apply(config *inference.BackendConfiguration) (bool, error) {
+	if c == nil {
+		return false, nil
+	}
+	clamped := false
+	if config.ContextSize != nil && c.exceeds(*config.ContextSize) {
+		if c.reject {
+			return false, fmt.Errorf("%w: %d > %d", ErrContextSizeExceeded, *config.ContextSize, c.max)
+		}
+		maxSize := c.max
+		config.ContextSize = &maxSize
+		clamped = true
+	}
+
+	var flags []string
+	for i := 0; i < len(config.RuntimeFlags); i++ {
+		name, value, hasValue := strings.Cut(config.RuntimeFlags[i], "=")
+		if !slices.Contains(contextSizeFlags, name) {
+			continue
+		}
+		flagIndex := i
+		if !hasValue {
+			if i+1 >= len(config.RuntimeFlags) {
+				break
+			}
+			i++
+			value = config.RuntimeFlags[i]
+		}
+		// A zero context size flag asks the backend for the model's full
+		// training context, so it is capped too.
+		size, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || (size != 0 && !c.exceeds(int32(size))) {
+			continue
+		}
+		if c.reject {
+			return false, fmt.Errorf("%w: %s %d > %d", ErrContextSizeExceeded, name, size, c.max)
+		}
+		if flags == nil {
+			flags = slices.Clone(config.RuntimeFlags)
+		}
+		maxValue := strconv.Itoa(int(c.max))
+		if hasValue {
+			flags[flagIndex] = name + "=" + maxValue
+		} else {
+			flags[i] = maxValue
+		}
+		clamped = true
+	}
+	if flags != nil {
+		config.RuntimeFlags = flags
+	}
+	return clamped, nil
+}
+
+// clampNotifierKey is the context key for context size clamp notifiers.
+type clampNotifierKey struct{}
+
+// WithClampNotifier returns a context that invokes fn with the applied
+// maximum when a configuration made with it has its context size clamped.
+func WithClampNotifier(ctx context.Context, fn func(maxSize int32)) context.Context {
+	return context.WithValue(ctx, clampNotifierKey{}, fn)
+}
+
+// notifyClamped invokes the clamp notifier registered in ctx, if any.
+func notifyClamped(ctx context.Context, maxSize int32) {
+	if fn, ok := ctx.Value(clampNotifierKey{}).(func(int32)); ok {
+		fn(maxSize)
+	}
+}
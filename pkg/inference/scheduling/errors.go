@@ -8,3 +8,8 @@ import (
 // returned in conjunction with an HTTP request, it should be paired with a
 // 404 response status.
 var ErrBackendNotFound = errors.New("backend not found")
+
+// ErrFormatUnsupportedOnPlatform indicates that a model's format can't be run
+// by any backend on this platform. If returned in conjunction with an HTTP
+// request, it should be paired with a 400 response status.
+var ErrFormatUnsupportedOnPlatform = errors.New("model format is not supported on this platform")
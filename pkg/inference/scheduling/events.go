@@ -0,0 +1,133 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// eventSubscriberBuffer is the buffer size for lifecycle event subscribers.
+// Events are dropped for subscribers that fall this far behind.
+const eventSubscriberBuffer = 64
+
+// LifecycleEventType identifies a backend runner lifecycle transition.
+type LifecycleEventType string
+
+const (
+	// EventModelLoaded indicates that a runner finished loading a model.
+	EventModelLoaded LifecycleEventType = "model_loaded"
+	// EventModelUnloaded indicates that a runner was stopped on request.
+	EventModelUnloaded LifecycleEventType = "model_unloaded"
+	// EventModelEvicted indicates that a runner was stopped by the scheduler,
+	// e.g. because it was idle or its slot was needed.
+	EventModelEvicted LifecycleEventType = "model_evicted"
+	// EventLoadFailed indicates that a runner failed to start.
+	EventLoadFailed LifecycleEventType = "load_failed"
+)
+
+// Eviction reasons reported in lifecycle events.
+const (
+	evictReasonIdle        = "idle"
+	evictReasonCapacity    = "capacity"
+	evictReasonDefunct     = "defunct"
+	evictReasonUnload      = "unload"
+	evictReasonReconfigure = "reconfigure"
+	evictReasonShutdown    = "shutdown"
+	evictReasonReload      = "reload"
+)
+
+// LifecycleEvent describes a change in the state of a backend runner.
+type LifecycleEvent struct {
+	// Type is the kind of transition.
+	Type LifecycleEventType `json:"type"`
+	// Backend is the backend running the model.
+	Backend string `json:"backend"`
+	// Model is the model reference used to load the runner.
+	Model string `json:"model"`
+	// Mode is the backend operation mode.
+	Mode string `json:"mode"`
+	// Reason explains unloads, evictions, and load failures.
+	Reason string `json:"reason,omitempty"`
+	// MemoryBytes is the resident memory of the backend process, if known.
+	MemoryBytes uint64 `json:"memory_bytes,omitempty"`
+	// SlotsUsed is the number of occupied runner slots after the transition.
+	SlotsUsed int `json:"slots_used"`
+	// SlotsTotal is the total number of runner slots.
+	SlotsTotal int `json:"slots_total"`
+	// Timestamp is when the transition occurred.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// eventBroker fans lifecycle events out to subscribers. Publishing never
+// blocks, so it is safe while holding the loader lock.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan LifecycleEvent]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[chan LifecycleEvent]struct{})}
+}
+
+// subscribe registers a subscriber. The returned function unregisters it.
+func (b *eventBroker) subscribe() (<-chan LifecycleEvent, func()) {
+	ch := make(chan LifecycleEvent, eventSubscriberBuffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		b.mu.Unlock()
+	}
+}
+
+// publish delivers event to all subscribers, dropping it for any whose
+// buffer is full.
+func (b *eventBroker) publish(event LifecycleEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams lifecycle events as server-sent events until the client
+// disconnects.
+func (b *eventBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := b.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprint(w, "event: connected\ndata: {\"status\": \"connected\"}\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
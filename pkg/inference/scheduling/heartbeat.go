@@ -0,0 +1,168 @@
+package scheduling
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+const (
+	// sseHeartbeatIntervalEnv names the environment variable holding how
+	// long, as a Go duration, a server-sent event stream may go without
+	// output before a comment line is sent to keep proxies from closing it.
+	// Zero disables heartbeats.
+	sseHeartbeatIntervalEnv = "MODEL_RUNNER_SSE_HEARTBEAT_INTERVAL"
+	// defaultSSEHeartbeatInterval is the heartbeat interval used if
+	// sseHeartbeatIntervalEnv is unset.
+	defaultSSEHeartbeatInterval = 15 * time.Second
+)
+
+// sseHeartbeat is the comment written to idle event streams, which clients
+// ignore.
+var sseHeartbeat = []byte(": keepalive\n\n")
+
+// sseHeartbeatIntervalFromEnv returns the heartbeat interval configured in
+// the environment.
+func sseHeartbeatIntervalFromEnv(log logging.Logger) time.Duration {
+	raw := os.Getenv(sseHeartbeatIntervalEnv)
+	if raw == "" {
+		return defaultSSEHeartbeatInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < 0 {
+		log.Warnf("Ignoring invalid %s value %q", sseHeartbeatIntervalEnv, utils.SanitizeForLog(raw, -1))
+		return defaultSSEHeartbeatInterval
+	}
+	return interval
+}
+
+// heartbeatWriter forwards a backend response, writing a comment to an event
+// stream whenever it has produced no output for the heartbeat interval.
+// Comments are only written between events, so that they can't split one.
+// The writer must be stopped before the handler returns.
+type heartbeatWriter struct {
+	http.ResponseWriter
+	// interval is how long the stream may be idle before a heartbeat.
+	interval time.Duration
+	// mu serializes writes to the response, which come from both the
+	// backend and the heartbeat.
+	mu sync.Mutex
+	// wroteHeader indicates that the response status has been written.
+	wroteHeader bool
+	// lastWrite is when output was last written.
+	lastWrite time.Time
+	// tail holds the last bytes written, to find event boundaries.
+	tail []byte
+	// stopped is closed to stop the heartbeat.
+	stopped chan struct{}
+	// done is closed once the heartbeat has stopped writing. It is nil if
+	// no heartbeat was started.
+	done chan struct{}
+	// once guards closing stopped.
+	once sync.Once
+}
+
+func newHeartbeatWriter(w http.ResponseWriter, interval time.Duration) *heartbeatWriter {
+	return &heartbeatWriter{ResponseWriter: w, interval: interval, stopped: make(chan struct{})}
+}
+
+func (w *heartbeatWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeHeader(status)
+}
+
+// writeHeader writes the response status, starting the heartbeat if the
+// response is an event stream. It must be called with mu held.
+func (w *heartbeatWriter) writeHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.lastWrite = time.Now()
+	if w.interval > 0 && status == http.StatusOK &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.done = make(chan struct{})
+		go w.run()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *heartbeatWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeHeader(http.StatusOK)
+	n, err := w.ResponseWriter.Write(p)
+	if n > 0 {
+		w.lastWrite = time.Now()
+		w.tail = append(w.tail, p[:n]...)
+		if len(w.tail) > 4 {
+			w.tail = append(w.tail[:0], w.tail[len(w.tail)-4:]...)
+		}
+	}
+	return n, err
+}
+
+func (w *heartbeatWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *heartbeatWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// betweenEvents reports whether the output so far ends between events. It
+// must be called with mu held.
+func (w *heartbeatWriter) betweenEvents() bool {
+	return len(w.tail) == 0 || bytes.HasSuffix(w.tail, []byte("\n\n")) || bytes.HasSuffix(w.tail, []byte("\r\n\r\n"))
+}
+
+// run writes heartbeats until the writer is stopped.
+func (w *heartbeatWriter) run() {
+	defer close(w.done)
+	timer := time.NewTimer(w.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.stopped:
+			return
+		case <-timer.C:
+		}
+		w.mu.Lock()
+		if time.Since(w.lastWrite) >= w.interval && w.betweenEvents() {
+			if _, err := w.ResponseWriter.Write(sseHeartbeat); err != nil {
+				w.mu.Unlock()
+				return
+			}
+			if f, ok := w.ResponseWriter.(http.Flusher); ok {
+				f.Flush()
+			}
+			w.lastWrite = time.Now()
+		}
+		next := w.interval - time.Since(w.lastWrite)
+		w.mu.Unlock()
+		timer.Reset(max(next, w.interval/10))
+	}
+}
+
+// stop stops the heartbeat, waiting for any heartbeat being written. It may be
+// called more than once.
+func (w *heartbeatWriter) stop() {
+	w.once.Do(func() { close(w.stopped) })
+	w.mu.Lock()
+	done := w.done
+	w.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
@@ -7,23 +7,79 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/distribution"
 	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/backends/llamacpp"
+	"github.com/docker/model-runner/pkg/inference/backends/sglang"
 	"github.com/docker/model-runner/pkg/inference/backends/vllm"
+	"github.com/docker/model-runner/pkg/inference/backends/vllmmetal"
 	"github.com/docker/model-runner/pkg/inference/models"
+	"github.com/docker/model-runner/pkg/internal/utils"
 	"github.com/docker/model-runner/pkg/metrics"
 	"github.com/docker/model-runner/pkg/middleware"
+	"github.com/docker/model-runner/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type contextKey bool
+type contextKey int
 
-const preloadOnlyKey contextKey = false
+const (
+	preloadOnlyKey contextKey = iota
+	loadNotifierKey
+	noSlotWaitKey
+)
+
+// structuredOutputBackends are the backends that constrain generation to a
+// requested response_format.
+var structuredOutputBackends = map[string]bool{
+	llamacpp.Name:  true,
+	vllm.Name:      true,
+	vllmmetal.Name: true,
+	sglang.Name:    true,
+}
+
+// WithLoadNotifier returns a context that invokes fn when a request made with it
+// has to wait for a model to be loaded rather than using a resident runner.
+// Notifiers already present in ctx are invoked after fn.
+func WithLoadNotifier(ctx context.Context, fn func()) context.Context {
+	if parent, ok := ctx.Value(loadNotifierKey).(func()); ok {
+		own := fn
+		fn = func() {
+			own()
+			parent()
+		}
+	}
+	return context.WithValue(ctx, loadNotifierKey, fn)
+}
+
+// withoutSlotWait returns a context whose loads fail with errNoRunnerSlot
+// rather than waiting for a runner slot when all slots are in use.
+func withoutSlotWait(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noSlotWaitKey, true)
+}
+
+// waitsForSlot reports whether loads made with ctx wait for a runner slot.
+func waitsForSlot(ctx context.Context) bool {
+	return ctx.Value(noSlotWaitKey) == nil
+}
+
+// notifyLoading invokes the load notifier registered in ctx, if any.
+func notifyLoading(ctx context.Context) {
+	if fn, ok := ctx.Value(loadNotifierKey).(func()); ok {
+		fn()
+	}
+}
 
 // HTTPHandler handles HTTP requests for the scheduler.
 // It wraps the Scheduler to provide HTTP endpoint functionality without
@@ -35,6 +91,11 @@ type HTTPHandler struct {
 	// modelHandler is the shared model handler.
 	modelHandler *models.HTTPHandler
 	lock         sync.RWMutex
+	// inflightLock guards inflight.
+	inflightLock sync.Mutex
+	// inflight maps recorded request IDs to the cancel functions of their
+	// in-flight inference requests.
+	inflight map[string]context.CancelFunc
 }
 
 // NewHTTPHandler creates a new HTTP handler that wraps the scheduler.
@@ -44,6 +105,7 @@ func NewHTTPHandler(s *Scheduler, modelHandler *models.HTTPHandler, allowedOrigi
 		scheduler:    s,
 		modelHandler: modelHandler,
 		router:       http.NewServeMux(),
+		inflight:     make(map[string]context.CancelFunc),
 	}
 
 	// Register routes
@@ -76,6 +138,11 @@ func (h *HTTPHandler) routeHandlers() map[string]http.HandlerFunc {
 		// Image generation routes
 		"POST " + inference.InferencePrefix + "/{backend}/v1/images/generations",
 		"POST " + inference.InferencePrefix + "/v1/images/generations",
+		// Tokenization routes
+		"POST " + inference.InferencePrefix + "/{backend}/v1/tokenize",
+		"POST " + inference.InferencePrefix + "/{backend}/v1/detokenize",
+		"POST " + inference.InferencePrefix + "/v1/tokenize",
+		"POST " + inference.InferencePrefix + "/v1/detokenize",
 	}
 
 	// Anthropic Messages API routes
@@ -97,14 +164,27 @@ func (h *HTTPHandler) routeHandlers() map[string]http.HandlerFunc {
 	m["GET "+inference.InferencePrefix+"/v1/models"] = h.handleModels
 	m["GET "+inference.InferencePrefix+"/v1/models/{name...}"] = h.handleModels
 
+	m["POST "+inference.InferencePrefix+"/{backend}/v1/batch"] = h.Batch
+	m["POST "+inference.InferencePrefix+"/v1/batch"] = h.Batch
+
 	m["GET "+inference.InferencePrefix+"/status"] = h.GetBackendStatus
 	m["GET "+inference.InferencePrefix+"/ps"] = h.GetRunningBackends
+	m["GET "+inference.InferencePrefix+"/breakers"] = h.GetBreakers
 	m["GET "+inference.InferencePrefix+"/df"] = h.GetDiskUsage
+	m["GET "+inference.InferencePrefix+"/usage"] = h.GetUsage
 	m["POST "+inference.InferencePrefix+"/unload"] = h.Unload
+	m["POST "+inference.InferencePrefix+"/warmup"] = h.Warmup
+	m["POST "+inference.InferencePrefix+"/{backend}/_render-template"] = h.RenderTemplate
+	m["POST "+inference.InferencePrefix+"/_render-template"] = h.RenderTemplate
+	m["POST "+inference.InferencePrefix+"/{backend}/_reload-backend"] = h.ReloadBackend
+	m["POST "+inference.InferencePrefix+"/_reload-backend"] = h.ReloadBackend
 	m["POST "+inference.InferencePrefix+"/{backend}/_configure"] = h.Configure
 	m["POST "+inference.InferencePrefix+"/_configure"] = h.Configure
 	m["GET "+inference.InferencePrefix+"/_configure"] = h.GetModelConfigs
+	m["DELETE "+inference.InferencePrefix+"/_configure"] = h.ResetModelConfigs
 	m["GET "+inference.InferencePrefix+"/requests"] = h.scheduler.openAIRecorder.GetRecordsHandler()
+	m["DELETE "+inference.InferencePrefix+"/requests/{id}"] = h.CancelRequest
+	m["GET "+inference.InferencePrefix+"/events"] = h.scheduler.loader.events.ServeHTTP
 	return m
 }
 
@@ -113,9 +193,11 @@ func (h *HTTPHandler) routeHandlers() map[string]http.HandlerFunc {
 // - POST <inference-prefix>/{backend}/v1/chat/completions
 // - POST <inference-prefix>/{backend}/v1/completions
 // - POST <inference-prefix>/{backend}/v1/embeddings
-// and 2 extras:
+// and some extras:
 // - POST <inference-prefix>/{backend}/rerank
 // - POST <inference-prefix>/{backend}/score
+// - POST <inference-prefix>/{backend}/v1/tokenize
+// - POST <inference-prefix>/{backend}/v1/detokenize
 func (h *HTTPHandler) handleOpenAIInference(w http.ResponseWriter, r *http.Request) {
 	// Determine the requested backend and ensure that it's valid.
 	var backend inference.Backend
@@ -165,6 +247,40 @@ func (h *HTTPHandler) handleOpenAIInference(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "model is required", http.StatusBadRequest)
 		return
 	}
+	tenant := r.Header.Get(inference.TenantHeader)
+	if err := validateTenant(tenant); err != nil {
+		http.Error(w, fmt.Sprintf("invalid %s header: %v", inference.TenantHeader, err), http.StatusBadRequest)
+		return
+	}
+
+	// Check that any chat template override compiles before it reaches the
+	// backend.
+	if request.ChatTemplate != "" {
+		if !strings.HasSuffix(r.URL.Path, "/v1/chat/completions") && !strings.HasSuffix(r.URL.Path, applyTemplatePath) {
+			http.Error(w, "chat_template is only supported for chat completions", http.StatusBadRequest)
+			return
+		}
+		if err := validateChatTemplate(request.ChatTemplate); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Enforce the server's token limit on text generation.
+	if isGenerationPath(r.URL.Path) {
+		if body, err = h.scheduler.limits.applyMaxTokens(body); err != nil {
+			http.Error(w, "failed to apply token limit", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Return a single response to clients that can't handle event streams.
+	if isGenerationPath(r.URL.Path) && refusesStreaming(r) {
+		if body, err = disableStreaming(body); err != nil {
+			http.Error(w, "failed to disable streaming", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	// Check if the shared model manager has the requested model available.
 	if !backend.UsesExternalModelManagement() {
@@ -177,6 +293,10 @@ func (h *HTTPHandler) handleOpenAIInference(w http.ResponseWriter, r *http.Reque
 			}
 			return
 		}
+		if model.Archived() {
+			http.Error(w, fmt.Errorf("%w: pull %s to restore it", distribution.ErrModelArchived, request.Model).Error(), http.StatusConflict)
+			return
+		}
 		// Determine the action for tracking
 		action := "inference/" + backendMode.String()
 		// Check if there's a request origin header to provide more specific tracking
@@ -194,7 +314,27 @@ func (h *HTTPHandler) handleOpenAIInference(w http.ResponseWriter, r *http.Reque
 		h.scheduler.tracker.TrackModel(model, r.UserAgent(), action)
 
 		// Automatically identify models for vLLM.
-		backend = h.scheduler.selectBackendForModel(model, backend, request.Model)
+		if backend, err = h.scheduler.selectBackendForModel(model, backend, request.Model); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Only llama.cpp applies chat templates at request time.
+	if request.ChatTemplate != "" && backend.Name() != llamacpp.Name {
+		http.Error(w, fmt.Sprintf("chat_template is not supported by the %s backend", backend.Name()), http.StatusBadRequest)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, applyTemplatePath) && backend.Name() != llamacpp.Name {
+		http.Error(w, fmt.Sprintf("rendering chat templates is not supported by the %s backend", backend.Name()), http.StatusBadRequest)
+		return
+	}
+
+	// Backends that can't constrain generation would silently ignore a
+	// structured response format, so refuse it up front.
+	if request.ResponseFormat != nil && request.ResponseFormat.Type != "text" && !structuredOutputBackends[backend.Name()] {
+		http.Error(w, fmt.Sprintf("response_format is not supported by the %s backend", backend.Name()), http.StatusNotImplemented)
+		return
 	}
 
 	// Wait for the corresponding backend installation to complete or fail. We
@@ -221,13 +361,109 @@ func (h *HTTPHandler) handleOpenAIInference(w http.ResponseWriter, r *http.Reque
 
 	modelID := h.scheduler.modelManager.ResolveID(request.Model)
 
+	// Merge the stop sequences and system prompt configured for the model.
+	if isGenerationPath(r.URL.Path) {
+		stops := h.scheduler.loader.defaultStop(backend.Name(), modelID, backendMode)
+		if body, err = mergeStops(body, r.URL.Path, stops); err != nil {
+			http.Error(w, "failed to apply default stop sequences", http.StatusInternalServerError)
+			return
+		}
+		prompt := h.scheduler.loader.defaultSystemPrompt(backend.Name(), modelID, backendMode)
+		if body, err = applySystemPrompt(body, r.URL.Path, prompt); err != nil {
+			http.Error(w, "failed to apply default system prompt", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Serve repeated deterministic completions from the response cache.
+	// Timed requests are always generated, so that their timings are real.
+	var cacheKey string
+	requestKey, deterministic := "", false
+	timed := timesTokens(r)
+	if isGenerationPath(r.URL.Path) && !timed {
+		requestKey, deterministic = responseCacheKey(backend.Name(), modelID, r.URL.Path, body)
+	}
+	if h.scheduler.responseCache != nil && deterministic {
+		if cached, hit := h.scheduler.responseCache.get(requestKey); hit {
+			w.Header().Set(inference.CacheHeader, "hit")
+			writeSharedResponse(w, cached)
+			return
+		}
+		cacheKey = requestKey
+		w.Header().Set(inference.CacheHeader, "miss")
+	}
+
+	// Share the backend call of an identical request already in flight. If
+	// that call's response can't be shared, the request is served itself.
+	var coalesceKey string
+	var shared *cachedResponse
+	if deterministic && coalesces(r, h.scheduler.coalesceRequests) {
+		call, leader := h.scheduler.coalescer.join(requestKey)
+		if leader {
+			coalesceKey = requestKey
+			defer func() { h.scheduler.coalescer.finish(coalesceKey, call, shared) }()
+		} else {
+			// Wait no longer than the server's timeout allows the request.
+			wait := r.Context()
+			if timeout := h.scheduler.limits.timeout; timeout > 0 {
+				var cancelWait context.CancelFunc
+				wait, cancelWait = context.WithTimeout(wait, timeout)
+				defer cancelWait()
+			}
+			select {
+			case <-call.done:
+			case <-wait.Done():
+				if r.Context().Err() == nil {
+					http.Error(w, fmt.Sprintf("request timed out after %s", h.scheduler.limits.timeout), http.StatusGatewayTimeout)
+				}
+				return
+			}
+			if call.response != nil {
+				w.Header().Set(inference.CoalescedHeader, "true")
+				writeSharedResponse(w, call.response)
+				return
+			}
+		}
+	}
+
+	ctx, span := tracing.Tracer().Start(r.Context(), "inference", trace.WithAttributes(
+		attribute.String("backend", backend.Name()),
+		attribute.String("model", request.Model),
+		attribute.String("url.path", r.URL.Path),
+	))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	// Signal cold starts to the client so it can show progress instead of
+	// appearing hung while the model loads.
+	var loadStarted time.Time
+	loadCtx := WithLoadNotifier(r.Context(), func() {
+		loadStarted = time.Now()
+		w.Header().Set(inference.ModelLoadingHeader, "true")
+	})
+
 	// Request a runner to execute the request and defer its release.
-	runner, err := h.scheduler.loader.load(r.Context(), backend.Name(), modelID, request.Model, backendMode)
+	runner, err := h.scheduler.loader.load(loadCtx, backend.Name(), modelID, request.Model, backendMode)
 	if err != nil {
+		tracing.RecordError(span, err)
+		var unavailable *ModelUnavailableError
+		if errors.As(err, &unavailable) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(unavailable.RetryAfter.Seconds()))))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, errNoRunnerSlot) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		http.Error(w, fmt.Errorf("unable to load runner: %w", err).Error(), http.StatusInternalServerError)
 		return
 	}
 	defer h.scheduler.loader.release(runner)
+	h.scheduler.modelManager.MarkUsed(modelID)
+	if !loadStarted.IsZero() {
+		w.Header().Set(inference.ModelLoadTimeHeader, time.Since(loadStarted).Round(time.Millisecond).String())
+	}
 
 	// If this is a preload-only request, return here without running inference.
 	// Can be triggered via context (internal) or X-Preload-Only header (external).
@@ -243,12 +479,139 @@ func (h *HTTPHandler) handleOpenAIInference(w http.ResponseWriter, r *http.Reque
 		h.scheduler.openAIRecorder.RecordResponse(recordID, request.Model, w)
 	}()
 
+	// Make the request cancellable by its record ID while it is in flight.
+	ctx, cancel := context.WithCancel(r.Context())
+	h.trackInflight(recordID, cancel)
+	defer h.untrackInflight(recordID)
+
+	// Bound the request by the server's timeout, if any.
+	timeout := h.scheduler.limits.timeout
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		defer cancelTimeout()
+	}
+
+	// Pin chat requests resending a system prompt to the slot whose KV cache
+	// holds it, so the backend skips re-processing the prefix.
+	if runner.promptSlots != nil && strings.HasSuffix(r.URL.Path, "/v1/chat/completions") {
+		if key, ok := promptPrefixKey(body); ok {
+			if slot, release, ok := runner.promptSlots.assign(ctx, runner.client, key); ok {
+				defer release()
+				var pinned bool
+				if body, pinned = pinPromptSlot(body, slot); pinned {
+					ctx = withPromptSlot(ctx)
+				}
+			}
+		}
+	}
+
 	// Create a request with the body replaced for forwarding upstream.
-	upstreamRequest := r.Clone(r.Context())
+	upstreamRequest := r.Clone(ctx)
 	upstreamRequest.Body = io.NopCloser(bytes.NewReader(body))
+	// The body may have been rewritten, so its length may have changed.
+	upstreamRequest.ContentLength = int64(len(body))
+	upstreamRequest.Header.Del("Content-Length")
+	// Propagate the trace context to the backend.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(upstreamRequest.Header))
+
+	// Keep idle event streams alive through proxies. Heartbeats are
+	// written beneath the cache capture, so that they aren't cached.
+	var downstream http.ResponseWriter = w
+	if timed {
+		timing := newTimingWriter(w)
+		defer timing.finish()
+		downstream = timing
+	}
+	heartbeat := newHeartbeatWriter(downstream, h.scheduler.sseHeartbeatInterval)
+	defer heartbeat.stop()
+
+	// Read the token usage the backend reports, for usage accounting.
+	var upstreamWriter http.ResponseWriter = heartbeat
+	var usage *usageWriter
+	if h.scheduler.usage != nil {
+		usage = newUsageWriter(upstreamWriter)
+		upstreamWriter = usage
+	}
+
+	// Capture the response for the cache, if it is cacheable, and for the
+	// requests coalesced with this one.
+	var capture *cachingWriter
+	if cacheKey != "" || coalesceKey != "" {
+		capture = newCachingWriter(upstreamWriter)
+		upstreamWriter = capture
+	}
 
 	// Perform the request.
-	runner.ServeHTTP(w, upstreamRequest)
+	if timeout > 0 {
+		serveWithTimeout(runner, newTimeoutWriter(ctx, upstreamWriter), upstreamRequest, timeout)
+	} else {
+		runner.ServeHTTP(upstreamWriter, upstreamRequest)
+	}
+
+	if usage != nil {
+		if tokens, ok := usage.tokens(); ok {
+			h.scheduler.usage.record(usageRecord{
+				Time:             time.Now(),
+				Model:            request.Model,
+				Tenant:           tenant,
+				PromptTokens:     tokens.prompt,
+				CompletionTokens: tokens.completion,
+			})
+		}
+	}
+
+	// Cache and share the response unless the request was cut short.
+	if capture != nil && ctx.Err() == nil {
+		if response, ok := capture.response(requestKey, modelID); ok {
+			if cacheKey != "" {
+				h.scheduler.responseCache.put(response)
+			}
+			shared = response
+		}
+	}
+}
+
+// writeSharedResponse writes a response generated for another request.
+func writeSharedResponse(w http.ResponseWriter, response *cachedResponse) {
+	if response.contentType != "" {
+		w.Header().Set("Content-Type", response.contentType)
+	}
+	_, _ = w.Write(response.body)
+}
+
+// trackInflight registers the cancel function for an in-flight request.
+func (h *HTTPHandler) trackInflight(id string, cancel context.CancelFunc) {
+	h.inflightLock.Lock()
+	defer h.inflightLock.Unlock()
+	h.inflight[id] = cancel
+}
+
+// untrackInflight removes an in-flight request and releases its context.
+func (h *HTTPHandler) untrackInflight(id string) {
+	h.inflightLock.Lock()
+	cancel, ok := h.inflight[id]
+	delete(h.inflight, id)
+	h.inflightLock.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// CancelRequest handles DELETE <inference-prefix>/requests/{id} requests by
+// cancelling the identified in-flight inference request.
+func (h *HTTPHandler) CancelRequest(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.inflightLock.Lock()
+	cancel, ok := h.inflight[id]
+	h.inflightLock.Unlock()
+	if !ok {
+		http.Error(w, "request not found", http.StatusNotFound)
+		return
+	}
+	h.scheduler.log.Infof("Cancelling in-flight request %s", utils.SanitizeForLog(id))
+	cancel()
+	w.WriteHeader(http.StatusOK)
 }
 
 // handleModels handles GET /engines/{backend}/v1/models* requests
@@ -270,10 +633,37 @@ func (h *HTTPHandler) GetBackendStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.scheduler.limits.setHeaders(w.Header())
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(data)
 }
 
+// GetBreakers returns the load circuit breaker state of models that have
+// recently failed to load.
+func (h *HTTPHandler) GetBreakers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.scheduler.loader.breakerStatuses(r.Context())); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetUsage handles GET <inference-prefix>/usage requests, reporting the token
+// usage recorded since the time given by the since parameter (an RFC 3339
+// time or a duration before now) by model and tenant.
+func (h *HTTPHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	since, err := parseUsageSince(r.URL.Query().Get("since"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.scheduler.usage.report(since)); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
 // GetRunningBackends returns information about all running backends
 func (h *HTTPHandler) GetRunningBackends(w http.ResponseWriter, r *http.Request) {
 	runningBackends := h.scheduler.getLoaderStatus(r.Context())
@@ -327,6 +717,10 @@ func (h *HTTPHandler) Unload(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid request", http.StatusBadRequest)
 		return
 	}
+	if _, err := unloadRequest.drainTimeout(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	unloadedRunners := UnloadResponse{h.scheduler.loader.Unload(r.Context(), unloadRequest)}
 	w.Header().Set("Content-Type", "application/json")
@@ -336,6 +730,85 @@ func (h *HTTPHandler) Unload(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ReloadBackend handles POST <inference-prefix>/{backend}/_reload-backend
+// requests, unloading the backend's runners and installing it again so that
+// subsequent loads pick up an updated backend binary.
+func (h *HTTPHandler) ReloadBackend(w http.ResponseWriter, r *http.Request) {
+	var backend inference.Backend
+	if b := r.PathValue("backend"); b == "" {
+		backend = h.scheduler.defaultBackend
+	} else {
+		backend = h.scheduler.backends[b]
+	}
+	if backend == nil {
+		http.Error(w, ErrBackendNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	unloaded, err := h.scheduler.ReloadBackend(r.Context(), backend)
+	if err != nil {
+		if errors.Is(err, ErrReinstallInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else if errors.Is(err, errInstallerNotStarted) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		} else {
+			http.Error(w, fmt.Sprintf("failed to reload backend: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := ReloadBackendResponse{
+		Backend:        backend.Name(),
+		UnloadedModels: unloaded,
+		Status:         backend.Status(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RenderTemplate handles POST <inference-prefix>/{backend}/_render-template
+// requests, returning the prompt that the model's chat template, or the one
+// supplied, renders for the given messages. The model is loaded, but nothing
+// is generated.
+func (h *HTTPHandler) RenderTemplate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maximumOpenAIInferenceRequestSize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, "request too large", http.StatusBadRequest)
+		} else {
+			http.Error(w, "failed to read request body", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var request RenderTemplateRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if len(request.Messages) == 0 {
+		http.Error(w, "messages are required", http.StatusBadRequest)
+		return
+	}
+	upstreamBody, err := json.Marshal(request)
+	if err != nil {
+		http.Error(w, "failed to encode request", http.StatusInternalServerError)
+		return
+	}
+
+	// Schedule the request like any other to the backend's template endpoint.
+	upstreamRequest := r.Clone(r.Context())
+	upstreamRequest.URL.Path = strings.TrimSuffix(r.URL.Path, "/_render-template") + applyTemplatePath
+	upstreamRequest.URL.RawPath = ""
+	upstreamRequest.Body = io.NopCloser(bytes.NewReader(upstreamBody))
+	upstreamRequest.ContentLength = int64(len(upstreamBody))
+	h.handleOpenAIInference(w, upstreamRequest)
+}
+
 // Configure handles POST <inference-prefix>/{backend}/_configure requests.
 func (h *HTTPHandler) Configure(w http.ResponseWriter, r *http.Request) {
 	// Determine the requested backend and ensure that it's valid.
@@ -369,10 +842,16 @@ func (h *HTTPHandler) Configure(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	backend, err = h.scheduler.ConfigureRunner(r.Context(), backend, configureRequest, r.UserAgent())
+	ctx := WithClampNotifier(r.Context(), func(maxSize int32) {
+		w.Header().Set(inference.ContextSizeClampedHeader, strconv.Itoa(int(maxSize)))
+	})
+	backend, err = h.scheduler.ConfigureRunner(ctx, backend, configureRequest, r.UserAgent())
 	if err != nil {
 		if errors.Is(err, errRunnerAlreadyActive) {
 			http.Error(w, err.Error(), http.StatusConflict)
+		} else if errors.Is(err, ErrContextSizeExceeded) || errors.Is(err, ErrFormatUnsupportedOnPlatform) ||
+			errors.Is(err, inference.ErrInvalidBackendEnv) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 		} else {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
@@ -382,55 +861,134 @@ func (h *HTTPHandler) Configure(w http.ResponseWriter, r *http.Request) {
 	// Preload the model in the background by calling handleOpenAIInference with preload-only context.
 	// This makes Compose preload the model as well as it calls `configure` by default.
 	go func() {
-		preloadBody, err := json.Marshal(OpenAIInferenceRequest{Model: configureRequest.Model})
-		if err != nil {
-			h.scheduler.log.Warnf("failed to marshal preload request body: %v", err)
-			return
-		}
 		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 		defer cancel()
-		preloadReq, err := http.NewRequestWithContext(
-			context.WithValue(ctx, preloadOnlyKey, true),
-			http.MethodPost,
-			inference.InferencePrefix+"/v1/chat/completions",
-			bytes.NewReader(preloadBody),
-		)
-		if err != nil {
-			h.scheduler.log.Warnf("failed to create preload request: %v", err)
-			return
-		}
-		preloadReq.Header.Set("User-Agent", r.UserAgent())
-		if backend != nil {
-			preloadReq.SetPathValue("backend", backend.Name())
-		}
-		recorder := httptest.NewRecorder()
-		h.handleOpenAIInference(recorder, preloadReq)
-		if recorder.Code != http.StatusOK {
-			h.scheduler.log.Warnf("background model preload failed with status %d: %s", recorder.Code, recorder.Body.String())
+		if err := h.preloadModel(ctx, backend, configureRequest.Model, r.UserAgent()); err != nil {
+			h.scheduler.log.Warnf("background model preload failed: %v", err)
 		}
 	}()
 
 	w.WriteHeader(http.StatusAccepted)
 }
 
-// GetModelConfigs returns model configurations. If a model is specified in the query parameter,
-// returns only configs for that model; otherwise returns all configs.
-func (h *HTTPHandler) GetModelConfigs(w http.ResponseWriter, r *http.Request) {
-	model := r.URL.Query().Get("model")
+// preloadModel loads model into a runner without running inference by issuing
+// a preload-only request through handleOpenAIInference, so the same model
+// resolution, memory estimation, and slot limits apply as for real requests.
+// A nil backend selects the default backend.
+func (h *HTTPHandler) preloadModel(ctx context.Context, backend inference.Backend, model, userAgent string) error {
+	preloadBody, err := json.Marshal(OpenAIInferenceRequest{Model: model})
+	if err != nil {
+		return fmt.Errorf("marshaling preload request body: %w", err)
+	}
+	preloadReq, err := http.NewRequestWithContext(
+		context.WithValue(ctx, preloadOnlyKey, true),
+		http.MethodPost,
+		inference.InferencePrefix+"/v1/chat/completions",
+		bytes.NewReader(preloadBody),
+	)
+	if err != nil {
+		return fmt.Errorf("creating preload request: %w", err)
+	}
+	preloadReq.Header.Set("User-Agent", userAgent)
+	if backend != nil {
+		preloadReq.SetPathValue("backend", backend.Name())
+	}
+	recorder := httptest.NewRecorder()
+	h.handleOpenAIInference(recorder, preloadReq)
+	if recorder.Code != http.StatusOK {
+		return &preloadError{StatusCode: recorder.Code, Message: strings.TrimSpace(recorder.Body.String())}
+	}
+	return nil
+}
+
+// preloadError is returned by preloadModel when the preload request fails.
+type preloadError struct {
+	// StatusCode is the HTTP status of the failed preload request.
+	StatusCode int
+	// Message is the body of the failed preload request.
+	Message string
+}
 
-	configs := h.scheduler.loader.getAllRunnerConfigs(r.Context())
+func (e *preloadError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
 
-	if model != "" {
-		modelID := h.scheduler.modelManager.ResolveID(model)
-		filtered := configs[:0]
-		for _, entry := range configs {
-			if entry.ModelID == modelID {
-				filtered = append(filtered, entry)
+// Warmup handles POST <inference-prefix>/warmup requests, preloading each of
+// the requested models in turn and reporting the outcome of each. Models that
+// can't be loaded without waiting, because all runner slots are in use or the
+// model's circuit breaker is open, are reported as skipped rather than failed.
+func (h *HTTPHandler) Warmup(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maximumOpenAIInferenceRequestSize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, "request too large", http.StatusBadRequest)
+		} else {
+			http.Error(w, "failed to read request body", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	var warmupRequest WarmupRequest
+	if err := json.Unmarshal(body, &warmupRequest); err != nil || len(warmupRequest.Models) == 0 {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	ctx := withoutSlotWait(r.Context())
+	response := WarmupResponse{Results: make([]WarmupResult, 0, len(warmupRequest.Models))}
+	for _, model := range warmupRequest.Models {
+		result := WarmupResult{Model: model, Status: WarmupLoaded}
+		if err := h.preloadModel(ctx, nil, model, r.UserAgent()); err != nil {
+			result.Status = WarmupFailed
+			result.Error = err.Error()
+			var preloadErr *preloadError
+			if errors.As(err, &preloadErr) {
+				result.Error = preloadErr.Message
+				if preloadErr.StatusCode == http.StatusServiceUnavailable {
+					result.Status = WarmupSkipped
+				}
 			}
 		}
-		configs = filtered
+		response.Results = append(response.Results, result)
+		if r.Context().Err() != nil {
+			return
+		}
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// PreloadModels loads each of models in turn once the scheduler is running,
+// logging the outcome of each. Models that can't be loaded, for example
+// because they don't fit in memory or all runner slots are taken, are skipped.
+// It is intended to be run in the background at startup.
+func (h *HTTPHandler) PreloadModels(ctx context.Context, models []string) {
+	if err := h.scheduler.waitUntilRunning(ctx); err != nil {
+		return
+	}
+	for _, model := range models {
+		if err := h.preloadModel(ctx, nil, model, "model-runner-preload"); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			h.scheduler.log.Warnf("Failed to preload model %s: %v", utils.SanitizeForLog(model), err)
+			continue
+		}
+		h.scheduler.log.Infof("Preloaded model %s", utils.SanitizeForLog(model))
+	}
+}
+
+// GetModelConfigs returns model configurations. If a model is specified in the query parameter,
+// returns only configs for that model; otherwise returns all configs.
+func (h *HTTPHandler) GetModelConfigs(w http.ResponseWriter, r *http.Request) {
+	configs := h.scheduler.RunnerConfigs(r.Context(), r.URL.Query().Get("model"))
+
+	h.scheduler.limits.setHeaders(w.Header())
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(configs); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
@@ -438,6 +996,26 @@ func (h *HTTPHandler) GetModelConfigs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ResetModelConfigs handles DELETE <inference-prefix>/_configure requests,
+// restoring the default configuration of the model given by the model query
+// parameter, or of every model if all=true.
+func (h *HTTPHandler) ResetModelConfigs(w http.ResponseWriter, r *http.Request) {
+	model := r.URL.Query().Get("model")
+	all := r.URL.Query().Get("all") == "true"
+	if (model == "") == !all {
+		http.Error(w, "exactly one of model or all=true must be specified", http.StatusBadRequest)
+		return
+	}
+
+	reset := h.scheduler.ResetRunnerConfigs(r.Context(), model)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ResetConfigResponse{ResetConfigs: reset}); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
 // ServeHTTP implements net/http.Handler.ServeHTTP.
 func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.lock.RLock()
@@ -464,3 +1042,9 @@ func (h *HTTPHandler) GetLlamaCppSocket() (string, error) {
 func (h *HTTPHandler) GetAllActiveRunners() []metrics.ActiveRunner {
 	return h.scheduler.GetAllActiveRunners()
 }
+
+// GetBackendCrashes delegates to the scheduler's business logic.
+// Required by metrics.SchedulerInterface.
+func (h *HTTPHandler) GetBackendCrashes() map[string]uint64 {
+	return h.scheduler.GetBackendCrashes()
+}
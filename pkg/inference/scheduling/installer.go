@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"sync"
 	"sync/atomic"
 
 	"github.com/docker/model-runner/pkg/inference"
@@ -17,6 +18,10 @@ var (
 	// errInstallerShuttingDown indicates that the installer's run loop has been
 	// terminated and the installer is shutting down.
 	errInstallerShuttingDown = errors.New("backend installer shutting down")
+	// ErrReinstallInProgress indicates that a backend reinstallation was
+	// requested while another one is running. If returned in conjunction with
+	// an HTTP request, it should be paired with a 409 response status.
+	ErrReinstallInProgress = errors.New("backend reinstallation already in progress")
 )
 
 // installStatus tracks the installation status of a backend.
@@ -42,8 +47,14 @@ type installer struct {
 	httpClient *http.Client
 	// started tracks whether or not the installer has been started.
 	started atomic.Bool
+	// statusesLock guards statuses, whose entries are replaced when a backend
+	// is reinstalled.
+	statusesLock sync.Mutex
 	// statuses maps backend names to their installation statuses.
 	statuses map[string]*installStatus
+	// reinstalling serializes backend reinstallations. It stays held until
+	// the installation completes, even if the caller stops waiting for it.
+	reinstalling sync.Mutex
 }
 
 // newInstaller creates a new backend installer.
@@ -84,7 +95,7 @@ func (i *installer) run(ctx context.Context) {
 	// ubiquitous backend and mlx as a relatively lightweight backend (on macOS
 	// only), this granularity is probably less of a concern.
 	for name, backend := range i.backends {
-		status := i.statuses[name]
+		status, _ := i.status(name)
 
 		var installedClosed bool
 		select {
@@ -116,7 +127,7 @@ func (i *installer) run(ctx context.Context) {
 // wait waits for installation of the specified backend to complete or fail.
 func (i *installer) wait(ctx context.Context, backend string) error {
 	// Grab the backend status.
-	status, ok := i.statuses[backend]
+	status, ok := i.status(backend)
 	if !ok {
 		return ErrBackendNotFound
 	}
@@ -138,3 +149,81 @@ func (i *installer) wait(ctx context.Context, backend string) error {
 		return status.err
 	}
 }
+
+// status returns the installation status of the named backend.
+func (i *installer) status(name string) (*installStatus, bool) {
+	i.statusesLock.Lock()
+	defer i.statusesLock.Unlock()
+	status, ok := i.statuses[name]
+	return status, ok
+}
+
+// reinstall installs the named backend again, for example to pick up an
+// updated binary, once its previous installation has completed. Requests for
+// the backend wait for the reinstallation as they do for the initial one.
+// prepare is called once those requests are held back and before the backend
+// is installed; if it fails, the outcome of the previous installation stands
+// and prepare's error is returned. Once started, the installation runs to
+// completion even if ctx is cancelled, in which case reinstall stops waiting
+// for it and returns ctx's error.
+func (i *installer) reinstall(ctx context.Context, name string, prepare func() error) error {
+	if !i.reinstalling.TryLock() {
+		return ErrReinstallInProgress
+	}
+	installing := false
+	defer func() {
+		if !installing {
+			i.reinstalling.Unlock()
+		}
+	}()
+
+	err := i.wait(ctx, name)
+	if errors.Is(err, ErrBackendNotFound) || errors.Is(err, errInstallerNotStarted) || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	i.statusesLock.Lock()
+	previous := i.statuses[name]
+	status := &installStatus{
+		installed: make(chan struct{}),
+		failed:    make(chan struct{}),
+	}
+	i.statuses[name] = status
+	i.statusesLock.Unlock()
+
+	if err := prepare(); err != nil {
+		status.err = previous.err
+		if status.err != nil {
+			close(status.failed)
+		} else {
+			close(status.installed)
+		}
+		return err
+	}
+
+	// Install detached from ctx, so that a client giving up on the request
+	// doesn't leave the backend failed with a context error. The caller
+	// waits for the outcome, but can't cancel it.
+	installCtx := context.WithoutCancel(ctx)
+	done := make(chan error, 1)
+	installing = true
+	go func() {
+		defer i.reinstalling.Unlock()
+		err := i.backends[name].Install(installCtx, i.httpClient)
+		if err != nil {
+			i.log.Warnf("Backend reinstallation failed for %s: %v", name, err)
+			status.err = err
+			close(status.failed)
+		} else {
+			close(status.installed)
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,274 @@
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+const (
+	// maxTokensEnv names the environment variable holding the maximum number
+	// of tokens a request may generate, which is also applied to requests that
+	// don't set a limit.
+	maxTokensEnv = "MODEL_RUNNER_MAX_TOKENS"
+	// requestTimeoutEnv names the environment variable holding the wall-clock
+	// timeout for inference requests, as a Go duration string.
+	requestTimeoutEnv = "MODEL_RUNNER_REQUEST_TIMEOUT"
+)
+
+// maxTokensFields are the request fields, across APIs, that limit the number
+// of generated tokens.
+var maxTokensFields = []string{"max_tokens", "max_completion_tokens"}
+
+// requestLimits are server-wide limits on inference requests. Zero values
+// disable the corresponding limit.
+type requestLimits struct {
+	// maxTokens is the maximum number of tokens a request may generate.
+	maxTokens int64
+	// timeout is the wall-clock timeout for a request once it is running.
+	timeout time.Duration
+}
+
+// requestLimitsFromEnv returns the request limits configured in the
+// environment.
+func requestLimitsFromEnv(log logging.Logger) requestLimits {
+	var limits requestLimits
+	if raw := os.Getenv(maxTokensEnv); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 32); err != nil || n <= 0 {
+			log.Warnf("Ignoring invalid %s value %q", maxTokensEnv, utils.SanitizeForLog(raw, -1))
+		} else {
+			limits.maxTokens = n
+		}
+	}
+	if raw := os.Getenv(requestTimeoutEnv); raw != "" {
+		if timeout, err := time.ParseDuration(raw); err != nil || timeout <= 0 {
+			log.Warnf("Ignoring invalid %s value %q", requestTimeoutEnv, utils.SanitizeForLog(raw, -1))
+		} else {
+			limits.timeout = timeout
+		}
+	}
+	return limits
+}
+
+// setHeaders advertises the limits in response headers.
+func (l requestLimits) setHeaders(header http.Header) {
+	if l.maxTokens > 0 {
+		header.Set(inference.MaxTokensHeader, strconv.FormatInt(l.maxTokens, 10))
+	}
+	if l.timeout > 0 {
+		header.Set(inference.RequestTimeoutHeader, l.timeout.String())
+	}
+}
+
+// isGenerationPath reports whether path is that of a text generation request,
+// to which the token limit applies.
+func isGenerationPath(path string) bool {
+	return strings.HasSuffix(path, "/v1/chat/completions") ||
+		strings.HasSuffix(path, "/v1/completions") ||
+		strings.HasSuffix(path, "/v1/messages")
+}
+
+// applyMaxTokens returns body with its token limits lowered to the maximum,
+// or set to it if the request has none. Negative limits, which some backends
+// treat as unlimited, are lowered as well. Bodies that aren't JSON objects are
+// returned unchanged for the backend to reject.
+func (l requestLimits) applyMaxTokens(body []byte) ([]byte, error) {
+	if l.maxTokens <= 0 {
+		return body, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body, nil
+	}
+	limit := json.RawMessage(strconv.FormatInt(l.maxTokens, 10))
+	changed, limited := false, false
+	for _, name := range maxTokensFields {
+		raw, ok := fields[name]
+		if !ok || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+		var n int64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			continue
+		}
+		limited = true
+		if n < 0 || n > l.maxTokens {
+			fields[name] = limit
+			changed = true
+		}
+	}
+	if !limited {
+		fields["max_tokens"] = limit
+		changed = true
+	}
+	if !changed {
+		return body, nil
+	}
+	return json.Marshal(fields)
+}
+
+// streamChunk holds the identifying fields of an OpenAI streaming chunk.
+type streamChunk struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+}
+
+// timeoutWriter forwards a backend response while tracking enough of it to
+// terminate the response cleanly if the request times out.
+type timeoutWriter struct {
+	http.ResponseWriter
+	// ctx is the context whose deadline enforces the timeout.
+	ctx context.Context
+	// wroteHeader indicates that the response status has been written.
+	wroteHeader bool
+	// suppressed indicates that the backend's error response for the
+	// timed-out request is being discarded.
+	suppressed bool
+	// stream indicates that the response is a server-sent event stream.
+	stream bool
+	// partial holds the incomplete trailing line of streamed output.
+	partial []byte
+	// last is the most recent chunk streamed.
+	last streamChunk
+	// done indicates that the stream has already been terminated.
+	done bool
+}
+
+func newTimeoutWriter(ctx context.Context, w http.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{ResponseWriter: w, ctx: ctx}
+}
+
+// timedOut reports whether the request's deadline has passed.
+func (w *timeoutWriter) timedOut() bool {
+	return errors.Is(w.ctx.Err(), context.DeadlineExceeded)
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if w.wroteHeader || w.suppressed {
+		return
+	}
+	if w.timedOut() {
+		w.suppressed = true
+		return
+	}
+	w.wroteHeader = true
+	w.stream = strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.suppressed {
+		return len(p), nil
+	}
+	if w.stream {
+		w.track(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// track records the most recent chunk in streamed output p.
+func (w *timeoutWriter) track(p []byte) {
+	w.partial = append(w.partial, p...)
+	for {
+		line, rest, found := bytes.Cut(w.partial, []byte("\n"))
+		if !found {
+			break
+		}
+		if data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:")); ok {
+			data = bytes.TrimSpace(data)
+			var chunk streamChunk
+			if bytes.Equal(data, []byte("[DONE]")) {
+				w.done = true
+			} else if json.Unmarshal(data, &chunk) == nil && chunk.Object != "" {
+				w.last = chunk
+			}
+		}
+		w.partial = rest
+	}
+	w.partial = bytes.Clone(w.partial)
+}
+
+func (w *timeoutWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.suppressed {
+		f.Flush()
+	}
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish terminates the response to a timed-out request. A stream is ended
+// with a final chunk whose finish reason is "timeout"; if nothing has been
+// written yet, a 504 response is sent instead.
+func (w *timeoutWriter) finish(timeout time.Duration) {
+	if !w.wroteHeader {
+		http.Error(w.ResponseWriter, fmt.Sprintf("request timed out after %s", timeout), http.StatusGatewayTimeout)
+		return
+	}
+	if !w.stream || w.done {
+		return
+	}
+	choice := map[string]any{"index": 0, "finish_reason": "timeout"}
+	switch w.last.Object {
+	case "chat.completion.chunk":
+		choice["delta"] = map[string]any{}
+	case "text_completion":
+		choice["text"] = ""
+	default:
+		// Streams in other formats are left as they stand.
+		return
+	}
+	data, err := json.Marshal(map[string]any{
+		"id":      w.last.ID,
+		"object":  w.last.Object,
+		"created": w.last.Created,
+		"model":   w.last.Model,
+		"choices": []any{choice},
+	})
+	if err != nil {
+		return
+	}
+	if len(w.partial) > 0 {
+		_, _ = w.ResponseWriter.Write([]byte("\n"))
+	}
+	_, _ = fmt.Fprintf(w.ResponseWriter, "\ndata: %s\n\ndata: [DONE]\n\n", data)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// serveWithTimeout serves req through runner, writing to w. If the request
+// times out, the backend request is cancelled and the response terminated
+// cleanly rather than aborted.
+func serveWithTimeout(runner *runner, w *timeoutWriter, req *http.Request, timeout time.Duration) {
+	defer func() {
+		if v := recover(); v != nil {
+			// The proxy aborts responses whose upstream body fails mid-copy,
+			// which is how a timed-out stream ends.
+			if v != http.ErrAbortHandler || !w.timedOut() {
+				panic(v)
+			}
+		}
+		if w.timedOut() {
+			w.finish(timeout)
+		}
+	}()
+	runner.ServeHTTP(w, req)
+}
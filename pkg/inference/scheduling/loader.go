@@ -7,14 +7,24 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/docker/go-units"
+	"github.com/docker/model-runner/pkg/distribution/distribution"
+	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/environment"
 	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/backends"
 	"github.com/docker/model-runner/pkg/inference/models"
 	"github.com/docker/model-runner/pkg/internal/utils"
 	"github.com/docker/model-runner/pkg/logging"
 	"github.com/docker/model-runner/pkg/metrics"
+	"github.com/docker/model-runner/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -26,6 +36,10 @@ const (
 	// defaultRunnerIdleTimeout is the default maximum amount of time that a
 	// runner can sit idle (i.e. without any requests) before being terminated.
 	defaultRunnerIdleTimeout = 5 * time.Minute
+	// defaultContextSizeEnv names the environment variable holding the
+	// server-wide default context size applied when neither the request, the
+	// runner configuration, nor the model specifies one.
+	defaultContextSizeEnv = "MODEL_RUNNER_DEFAULT_CTX"
 )
 
 var (
@@ -34,6 +48,9 @@ var (
 	// errRunnerAlreadyActive indicates that a given runner is already active
 	// and therefore can't be reconfigured for example
 	errRunnerAlreadyActive = errors.New("runner already active")
+	// errNoRunnerSlot indicates that a load that can't wait for a runner slot
+	// found all slots in use.
+	errNoRunnerSlot = errors.New("no runner slot available")
 )
 
 // runnerKey is used to index runners.
@@ -80,6 +97,15 @@ type runnerInfo struct {
 	modelRef string
 }
 
+// pendingLoad tracks a runner that is starting up. Concurrent requests for
+// the same runner wait on done rather than starting a duplicate backend.
+type pendingLoad struct {
+	// done is closed once the load has completed.
+	done chan struct{}
+	// err is the load's error, if any. It is only valid once done is closed.
+	err error
+}
+
 // loader manages the loading and unloading of backend runners. It regulates
 // active backends in a manner that avoids exhausting system resources. Loaders
 // assume that all of their backends have been installed, so no load requests
@@ -94,6 +120,14 @@ type loader struct {
 	modelManager *models.Manager
 	// runnerIdleTimeout is the loader-specific default runner idle timeout.
 	runnerIdleTimeout time.Duration
+	// defaultContextSize is the server-wide default context size, if any.
+	defaultContextSize *int32
+	// backendStartTimeout is the maximum amount of time that a backend may
+	// take to become ready.
+	backendStartTimeout time.Duration
+	// contextSizeCap limits the context sizes that requests may configure. It
+	// is nil if there is no limit.
+	contextSizeCap *contextSizeCap
 	// idleCheck is used to signal the run loop when timestamps have updated.
 	idleCheck chan struct{}
 	// guard is a sempahore controlling access to all subsequent fields. It is
@@ -109,6 +143,9 @@ type loader struct {
 	waiters map[chan<- struct{}]bool
 	// runners maps runner keys to their slot index.
 	runners map[runnerKey]runnerInfo
+	// loading maps runner keys to runners that are still starting up. Their
+	// slots are reserved but they are not yet present in runners.
+	loading map[runnerKey]*pendingLoad
 	// slots maps slot indices to associated runners. A slot is considered free
 	// if the runner value in it is nil.
 	slots []*runner
@@ -119,8 +156,27 @@ type loader struct {
 	timestamps []time.Time
 	// runnerConfigs maps model names to runner configurations
 	runnerConfigs map[runnerKey]inference.BackendConfiguration
+	// keepAlives maps configuration keys to per-model idle timeouts that
+	// override runnerIdleTimeout.
+	keepAlives map[runnerKey]time.Duration
+	// defaultsLock guards defaultStops and systemPrompts, which are read by
+	// every request, so that requests don't contend for the loader lock to
+	// read them.
+	defaultsLock sync.RWMutex
+	// defaultStops maps configuration keys to stop sequences merged into
+	// every request for the model.
+	defaultStops map[runnerKey][]string
+	// systemPrompts maps configuration keys to system prompts applied to
+	// chat requests for the model.
+	systemPrompts map[runnerKey]defaultSystemPrompt
+	// crashes maps backend names to the number of backend process crashes.
+	crashes map[string]uint64
+	// breaker short-circuits loads of models that repeatedly fail to load.
+	breaker *loadBreaker
 	// openAIRecorder is used to record OpenAI API inference requests and responses.
 	openAIRecorder *metrics.OpenAIRecorder
+	// events publishes runner lifecycle events.
+	events *eventBroker
 }
 
 // newLoader creates a new loader.
@@ -149,21 +205,60 @@ func newLoader(
 		runnerIdleTimeout = 8 * time.Hour
 	}
 
+	// Determine the server-wide default context size.
+	var defaultContextSize *int32
+	if raw := os.Getenv(defaultContextSizeEnv); raw != "" {
+		if size, err := strconv.ParseInt(raw, 10, 32); err != nil || (size <= 0 && size != -1) {
+			log.Warnf("Ignoring invalid %s value %q", defaultContextSizeEnv, utils.SanitizeForLog(raw, -1))
+		} else {
+			ctxSize := int32(size)
+			defaultContextSize = &ctxSize
+		}
+	}
+
+	// Determine how long backends may take to become ready.
+	backendStartTimeout := defaultBackendStartTimeout
+	if raw := os.Getenv(backendStartTimeoutEnv); raw != "" {
+		if timeout, err := time.ParseDuration(raw); err != nil || timeout <= 0 {
+			log.Warnf("Ignoring invalid %s value %q", backendStartTimeoutEnv, utils.SanitizeForLog(raw, -1))
+		} else {
+			backendStartTimeout = timeout
+		}
+	}
+
+	// Determine the maximum context size, which also bounds the default.
+	contextSizeCap := contextSizeCapFromEnv(log)
+	if contextSizeCap != nil && defaultContextSize != nil && contextSizeCap.exceeds(*defaultContextSize) {
+		log.Warnf("Lowering %s to the %s value of %d", defaultContextSizeEnv, maxContextSizeEnv, contextSizeCap.max)
+		maxSize := contextSizeCap.max
+		defaultContextSize = &maxSize
+	}
+
 	// Create the loader.
 	l := &loader{
-		log:               log,
-		backends:          backends,
-		modelManager:      modelManager,
-		runnerIdleTimeout: runnerIdleTimeout,
-		idleCheck:         make(chan struct{}, 1),
-		guard:             make(chan struct{}, 1),
-		waiters:           make(map[chan<- struct{}]bool),
-		runners:           make(map[runnerKey]runnerInfo, nSlots),
-		slots:             make([]*runner, nSlots),
-		references:        make([]uint, nSlots),
-		timestamps:        make([]time.Time, nSlots),
-		runnerConfigs:     make(map[runnerKey]inference.BackendConfiguration),
-		openAIRecorder:    openAIRecorder,
+		log:                 log,
+		backends:            backends,
+		modelManager:        modelManager,
+		runnerIdleTimeout:   runnerIdleTimeout,
+		defaultContextSize:  defaultContextSize,
+		backendStartTimeout: backendStartTimeout,
+		contextSizeCap:      contextSizeCap,
+		idleCheck:           make(chan struct{}, 1),
+		guard:               make(chan struct{}, 1),
+		waiters:             make(map[chan<- struct{}]bool),
+		runners:             make(map[runnerKey]runnerInfo, nSlots),
+		loading:             make(map[runnerKey]*pendingLoad),
+		slots:               make([]*runner, nSlots),
+		references:          make([]uint, nSlots),
+		timestamps:          make([]time.Time, nSlots),
+		runnerConfigs:       make(map[runnerKey]inference.BackendConfiguration),
+		keepAlives:          make(map[runnerKey]time.Duration),
+		defaultStops:        make(map[runnerKey][]string),
+		systemPrompts:       make(map[runnerKey]defaultSystemPrompt),
+		crashes:             make(map[string]uint64),
+		breaker:             newLoadBreaker(),
+		openAIRecorder:      openAIRecorder,
+		events:              newEventBroker(),
 	}
 	l.guard <- struct{}{}
 	return l
@@ -185,6 +280,21 @@ func (l *loader) unlock() {
 	l.guard <- struct{}{}
 }
 
+// runnerPID returns the backend process ID of the runner in the given slot,
+// or 0 if it is not known. Callers must hold the loader lock.
+func (l *loader) runnerPID(slot int) int {
+	if r := l.slots[slot]; r != nil {
+		return int(r.pid.Load())
+	}
+	return 0
+}
+
+// occupiedSlots returns the number of slots holding a loaded or loading
+// runner. Callers must hold the loader lock.
+func (l *loader) occupiedSlots() int {
+	return len(l.runners) + len(l.loading)
+}
+
 // broadcast signals all waiters. Callers must hold the loader lock.
 func (l *loader) broadcast() {
 	for waiter := range l.waiters {
@@ -195,13 +305,129 @@ func (l *loader) broadcast() {
 	}
 }
 
-// freeRunnerSlot frees a runner slot.
-// The caller must hold the loader lock.
-func (l *loader) freeRunnerSlot(slot int, key runnerKey) {
-	l.slots[slot].terminate()
+// publishEvent publishes a lifecycle event for the runner identified by key,
+// whose backend process uses memoryBytes of resident memory, if known. The
+// caller must hold the loader lock.
+func (l *loader) publishEvent(eventType LifecycleEventType, key runnerKey, modelRef, reason string, memoryBytes uint64) {
+	l.events.publish(LifecycleEvent{
+		Type:        eventType,
+		Backend:     key.backend,
+		Model:       modelRef,
+		Mode:        key.mode.String(),
+		Reason:      reason,
+		MemoryBytes: memoryBytes,
+		SlotsUsed:   l.occupiedSlots(),
+		SlotsTotal:  len(l.slots),
+		Timestamp:   time.Now(),
+	})
+}
+
+// countCrash records a crash of backend if the terminated runner's backend
+// process exited unexpectedly. The caller must hold the loader lock.
+func (l *loader) countCrash(backend string, r *runner) {
+	if errors.Is(r.err, backends.ErrBackendCrashed) {
+		l.crashes[backend]++
+	}
+}
+
+// logStartTimeout logs the size and context size of a model whose backend
+// didn't become ready in time, to help correlate slow starts with large models.
+func (l *loader) logStartTimeout(err *BackendStartTimeoutError, runnerConfig *inference.BackendConfiguration) {
+	contextSize := "default"
+	if runnerConfig != nil && runnerConfig.ContextSize != nil {
+		contextSize = strconv.FormatInt(int64(*runnerConfig.ContextSize), 10)
+	}
+	size := "unknown"
+	if bytes, ok := l.modelSize(err.Model); ok {
+		size = units.HumanSize(float64(bytes))
+	}
+	l.log.Warnf("%s backend gave up waiting for model %s (size %s, context size %s) after %s; set %s to allow more time",
+		err.Backend, err.Model, size, contextSize, err.Waited.Round(time.Second), backendStartTimeoutEnv,
+	)
+}
+
+// modelSize returns the total size of a model's weight files, or false if it
+// can't be determined.
+func (l *loader) modelSize(modelID string) (int64, bool) {
+	if l.modelManager == nil {
+		return 0, false
+	}
+	model, err := l.modelManager.GetLocal(modelID)
+	if err != nil {
+		return 0, false
+	}
+	var paths []string
+	for _, list := range []func() ([]string, error){model.GGUFPaths, model.SafetensorsPaths, model.DDUFPaths} {
+		if found, err := list(); err == nil {
+			paths = append(paths, found...)
+		}
+	}
+	var size int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, false
+		}
+		size += info.Size()
+	}
+	return size, len(paths) > 0
+}
+
+// crashCounts returns the number of backend process crashes observed for each
+// backend.
+func (l *loader) crashCounts(ctx context.Context) map[string]uint64 {
+	if !l.lock(ctx) {
+		return nil
+	}
+	defer l.unlock()
+	counts := make(map[string]uint64, len(l.crashes))
+	for backend, count := range l.crashes {
+		counts[backend] = count
+	}
+	return counts
+}
+
+// breakerStatuses returns the circuit breaker state of models that have
+// recently failed to load.
+func (l *loader) breakerStatuses(ctx context.Context) []BreakerStatus {
+	if !l.lock(ctx) {
+		return nil
+	}
+	defer l.unlock()
+	return l.breaker.statuses()
+}
+
+// resetBreaker closes the circuit breaker for modelID, allowing loads to be
+// attempted again immediately.
+func (l *loader) resetBreaker(ctx context.Context, modelID string) {
+	if !l.lock(ctx) {
+		return
+	}
+	defer l.unlock()
+	if l.breaker.reset(modelID) {
+		l.log.Infof("Reset load circuit breaker for model %s", utils.SanitizeForLog(modelID))
+	}
+}
+
+// freeRunnerSlot frees a runner slot, reporting the eviction with reason and
+// the runner's memory as last sampled, since the process is gone once
+// terminated. The caller must hold the loader lock.
+func (l *loader) freeRunnerSlot(slot int, key runnerKey, reason string) {
+	modelRef := l.runners[key].modelRef
+	runner := l.slots[slot]
+	memoryBytes := runner.rss.Load()
+	runner.terminate()
+	l.countCrash(key.backend, runner)
 	l.slots[slot] = nil
 	l.timestamps[slot] = time.Time{}
 	delete(l.runners, key)
+
+	eventType := EventModelEvicted
+	switch reason {
+	case evictReasonUnload, evictReasonReconfigure, evictReasonShutdown, evictReasonReload:
+		eventType = EventModelUnloaded
+	}
+	l.publishEvent(eventType, key, modelRef, reason, memoryBytes)
 }
 
 // evict evicts all unused runners from the loader. If idleOnly is true, then
@@ -209,12 +435,13 @@ func (l *loader) freeRunnerSlot(slot int, key runnerKey) {
 // on usage timestamp) are evicted. Defunct (e.g. crashed) runners will be evicted
 // regardless of whether they are considered "idle". The caller must hold the loader
 // lock. It returns the number of remaining runners.
-func (l *loader) evict(idleOnly bool) int {
+func (l *loader) evict(idleOnly bool, reason string) int {
 	now := time.Now()
 	evictedCount := 0
 	for r, runnerInfo := range l.runners {
 		unused := l.references[runnerInfo.slot] == 0
-		idle := unused && now.Sub(l.timestamps[runnerInfo.slot]) > l.runnerIdleTimeout
+		timeout := l.idleTimeout(r)
+		idle := unused && timeout >= 0 && now.Sub(l.timestamps[runnerInfo.slot]) > timeout
 		defunct := false
 		select {
 		case <-l.slots[runnerInfo.slot].done:
@@ -225,7 +452,11 @@ func (l *loader) evict(idleOnly bool) int {
 			l.log.Infof("Evicting %s backend runner with model %s (%s) in %s mode",
 				r.backend, r.modelID, runnerInfo.modelRef, r.mode,
 			)
-			l.freeRunnerSlot(runnerInfo.slot, r)
+			evictReason := reason
+			if defunct {
+				evictReason = evictReasonDefunct
+			}
+			l.freeRunnerSlot(runnerInfo.slot, r, evictReason)
 			evictedCount++
 		} else if unused {
 			l.log.Debugf("Runner %s (%s) is unused but not evictable: idleOnly=%v, idle=%v, defunct=%v",
@@ -243,7 +474,7 @@ func (l *loader) evict(idleOnly bool) int {
 
 // evictRunner evicts a specific runner. The caller must hold the loader lock.
 // It returns the number of remaining runners.
-func (l *loader) evictRunner(backend, model string, mode inference.BackendMode) int {
+func (l *loader) evictRunner(backend, model string, mode inference.BackendMode, reason string) int {
 	allBackends := backend == ""
 	found := false
 	for r, runnerInfo := range l.runners {
@@ -252,7 +483,7 @@ func (l *loader) evictRunner(backend, model string, mode inference.BackendMode)
 			l.log.Infof("Evicting %s backend runner with model %s (%s) in %s mode",
 				r.backend, r.modelID, runnerInfo.modelRef, r.mode,
 			)
-			l.freeRunnerSlot(runnerInfo.slot, r)
+			l.freeRunnerSlot(runnerInfo.slot, r, reason)
 			found = true
 		}
 	}
@@ -270,29 +501,123 @@ func (l *loader) Unload(ctx context.Context, unload UnloadRequest) int {
 	}
 	defer l.unlock()
 
-	return len(l.runners) - func() int {
-		if unload.All {
-			l.runnerConfigs = make(map[runnerKey]inference.BackendConfiguration)
-			return l.evict(false)
-		} else {
-			for _, model := range unload.Models {
-				modelID := l.modelManager.ResolveID(model)
-				// Delete all runner configs for this model (including with different draft models)
-				for key := range l.runnerConfigs {
-					if key.backend == unload.Backend && key.modelID == modelID {
-						delete(l.runnerConfigs, key)
-					}
-				}
-				// Evict all mode types. We should consider
-				// accepting a mode parameter in unload requests.
-				l.evictRunner(unload.Backend, modelID, inference.BackendModeCompletion)
-				l.evictRunner(unload.Backend, modelID, inference.BackendModeEmbedding)
-				l.evictRunner(unload.Backend, modelID, inference.BackendModeReranking)
-				l.evictRunner(unload.Backend, modelID, inference.BackendModeImageGeneration)
+	if unload.All {
+		// The request has been validated by the handler.
+		drain, _ := unload.drainTimeout()
+		return l.unloadAll(ctx, drain)
+	}
+
+	before := len(l.runners)
+	for _, model := range unload.Models {
+		modelID := l.modelManager.ResolveID(model)
+		// Delete all runner configs for this model (including with different draft models)
+		for key := range l.runnerConfigs {
+			if key.backend == unload.Backend && key.modelID == modelID {
+				delete(l.runnerConfigs, key)
 			}
-			return len(l.runners)
 		}
-	}()
+		// Evict all mode types. We should consider
+		// accepting a mode parameter in unload requests.
+		l.evictRunner(unload.Backend, modelID, inference.BackendModeCompletion, evictReasonUnload)
+		l.evictRunner(unload.Backend, modelID, inference.BackendModeEmbedding, evictReasonUnload)
+		l.evictRunner(unload.Backend, modelID, inference.BackendModeReranking, evictReasonUnload)
+		l.evictRunner(unload.Backend, modelID, inference.BackendModeImageGeneration, evictReasonUnload)
+	}
+	return before - len(l.runners)
+}
+
+// unloadAll evicts all unused runners and clears their configurations. If
+// drain is positive, it then waits up to drain for in-flight requests to
+// finish, evicting each remaining runner as it becomes unused. The caller
+// must hold the loader lock. It returns the number of runners evicted.
+func (l *loader) unloadAll(ctx context.Context, drain time.Duration) int {
+	l.runnerConfigs = make(map[runnerKey]inference.BackendConfiguration)
+	unloaded := len(l.runners) - l.evict(false, evictReasonUnload)
+	if drain <= 0 || len(l.runners) == 0 {
+		return unloaded
+	}
+
+	l.log.Infof("Waiting up to %s for %d runner(s) in use to become idle", drain, len(l.runners))
+	timer := time.NewTimer(drain)
+	defer timer.Stop()
+	poll := make(chan struct{}, 1)
+	l.waiters[poll] = true
+	defer delete(l.waiters, poll)
+	for len(l.runners) > 0 {
+		// Note that we always re-lock with context.Background() because we
+		// need to ensure we hold the lock by the time we return.
+		l.unlock()
+		select {
+		case <-ctx.Done():
+			l.lock(context.Background())
+			return unloaded
+		case <-timer.C:
+			l.lock(context.Background())
+			l.log.Warnf("Timed out waiting for %d runner(s) in use to become idle", len(l.runners))
+			return unloaded
+		case <-poll:
+			l.lock(context.Background())
+		}
+		unloaded += len(l.runners) - l.evict(false, evictReasonUnload)
+	}
+	return unloaded
+}
+
+// drainBackend evicts the runners of backend, waiting up to drain for
+// in-flight requests and pending loads to finish. Runner configurations are
+// kept. It returns the references of the unloaded models, along with an error
+// if runners were still in use once drain elapsed.
+func (l *loader) drainBackend(ctx context.Context, backend string, drain time.Duration) ([]string, error) {
+	if !l.lock(ctx) {
+		return nil, context.Canceled
+	}
+	defer l.unlock()
+
+	timer := time.NewTimer(drain)
+	defer timer.Stop()
+	poll := make(chan struct{}, 1)
+	l.waiters[poll] = true
+	defer delete(l.waiters, poll)
+	unloaded := []string{}
+	for {
+		remaining := 0
+		for r, runnerInfo := range l.runners {
+			if r.backend != backend {
+				continue
+			}
+			if l.references[runnerInfo.slot] > 0 {
+				remaining++
+				continue
+			}
+			l.log.Infof("Evicting %s backend runner with model %s (%s) in %s mode",
+				r.backend, r.modelID, runnerInfo.modelRef, r.mode,
+			)
+			l.freeRunnerSlot(runnerInfo.slot, r, evictReasonReload)
+			unloaded = append(unloaded, runnerInfo.modelRef)
+		}
+		for key := range l.loading {
+			if key.backend == backend {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			return unloaded, nil
+		}
+
+		// Note that we always re-lock with context.Background() because we
+		// need to ensure we hold the lock by the time we return.
+		l.unlock()
+		select {
+		case <-ctx.Done():
+			l.lock(context.Background())
+			return unloaded, context.Canceled
+		case <-timer.C:
+			l.lock(context.Background())
+			return unloaded, fmt.Errorf("timed out waiting for %d %s runner(s) in use to become idle", remaining, backend)
+		case <-poll:
+			l.lock(context.Background())
+		}
+	}
 }
 
 // stopAndDrainTimer stops and drains a timer without knowing if it was running.
@@ -310,9 +635,9 @@ func stopAndDrainTimer(timer *time.Timer) {
 // 0 seconds is returned. Otherwise a time in the future at which eviction
 // should occur is returned.
 func (l *loader) idleCheckDuration() time.Duration {
-	// Compute the oldest usage time for any idle runner.
-	var oldest time.Time
-	for _, runnerInfo := range l.runners {
+	// Compute the earliest expiration time for any unused runner.
+	var earliest time.Time
+	for r, runnerInfo := range l.runners {
 		select {
 		case <-l.slots[runnerInfo.slot].done:
 			// Check immediately if a runner is defunct
@@ -320,28 +645,74 @@ func (l *loader) idleCheckDuration() time.Duration {
 		default:
 		}
 		if l.references[runnerInfo.slot] == 0 {
-			timestamp := l.timestamps[runnerInfo.slot]
-			if oldest.IsZero() || timestamp.Before(oldest) {
-				oldest = timestamp
+			timeout := l.idleTimeout(r)
+			if timeout < 0 {
+				continue
+			}
+			expiration := l.timestamps[runnerInfo.slot].Add(timeout)
+			if earliest.IsZero() || expiration.Before(earliest) {
+				earliest = expiration
 			}
 		}
 	}
 
 	// If there are no unused runners, then don't schedule a check.
-	if oldest.IsZero() {
+	if earliest.IsZero() {
 		return -1 * time.Second
 	}
 
 	// Compute the remaining duration. If negative, check immediately, otherwise
 	// wait until 100 milliseconds after expiration time (to avoid checking
 	// right on the expiration boundary).
-	if remaining := l.runnerIdleTimeout - time.Since(oldest); remaining < 0 {
+	if remaining := time.Until(earliest); remaining < 0 {
 		return 0
 	} else {
 		return remaining + 100*time.Millisecond
 	}
 }
 
+// idleTimeout returns the idle timeout for the runner identified by key: its
+// configured keep-alive if any, otherwise the loader default. A negative
+// timeout means the runner is never evicted for being idle. The caller must
+// hold the loader lock.
+func (l *loader) idleTimeout(key runnerKey) time.Duration {
+	if keepAlive, ok := l.keepAlives[makeConfigKey(key.backend, key.modelID, key.mode)]; ok {
+		return keepAlive
+	}
+	return l.runnerIdleTimeout
+}
+
+// setKeepAlive sets how long runners for the model stay loaded once idle,
+// overriding the loader default. A negative duration keeps them loaded until
+// they are explicitly unloaded or evicted for capacity.
+func (l *loader) setKeepAlive(ctx context.Context, backendName, modelID string, mode inference.BackendMode, keepAlive time.Duration) {
+	if !l.lock(ctx) {
+		return
+	}
+	defer l.unlock()
+	l.keepAlives[makeConfigKey(backendName, modelID, mode)] = keepAlive
+	// Reschedule the idle check for the new timeout.
+	select {
+	case l.idleCheck <- struct{}{}:
+	default:
+	}
+}
+
+// clearKeepAlive removes the model's keep-alive, so that its runners use the
+// loader default again.
+func (l *loader) clearKeepAlive(ctx context.Context, backendName, modelID string, mode inference.BackendMode) {
+	if !l.lock(ctx) {
+		return
+	}
+	defer l.unlock()
+	delete(l.keepAlives, makeConfigKey(backendName, modelID, mode))
+	// Reschedule the idle check for the default timeout.
+	select {
+	case l.idleCheck <- struct{}{}:
+	default:
+	}
+}
+
 // run is the run loop for the loader. It drives idle runner eviction. By the
 // time run returns, all runners will have been evicted.
 func (l *loader) run(ctx context.Context) {
@@ -364,7 +735,7 @@ func (l *loader) run(ctx context.Context) {
 		l.unlock()
 		for range poll {
 			l.lock(context.Background())
-			if l.evict(false) == 0 {
+			if l.evict(false, evictReasonShutdown) == 0 && len(l.loading) == 0 {
 				delete(l.waiters, poll)
 				l.unlock()
 				break
@@ -389,7 +760,7 @@ func (l *loader) run(ctx context.Context) {
 		case <-idleTimer.C:
 			// Perform eviction.
 			if l.lock(ctx) {
-				l.evict(true)
+				l.evict(true, evictReasonIdle)
 				if nextCheck := l.idleCheckDuration(); nextCheck >= 0 {
 					idleTimer.Reset(nextCheck)
 				}
@@ -411,7 +782,17 @@ func (l *loader) run(ctx context.Context) {
 // load allocates a runner using the specified backend and modelID. If allocated,
 // it should be released by the caller using the release mechanism (once the
 // runner is no longer needed).
-func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string, mode inference.BackendMode) (*runner, error) {
+func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string, mode inference.BackendMode) (loaded *runner, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "scheduler.load", trace.WithAttributes(
+		attribute.String("backend", backendName),
+		attribute.String("model", modelRef),
+		attribute.String("mode", mode.String()),
+	))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	// Grab the backend.
 	backend, ok := l.backends[backendName]
 	if !ok {
@@ -436,13 +817,29 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 		}
 	}
 
+	// Get the model's bundle before acquiring the loader lock, since getting
+	// it verifies, and may unpack, the model's files.
+	var bundle types.ModelBundle
+	var bundleErr error
+	if l.modelManager != nil && (runnerConfig == nil || !backend.UsesExternalModelManagement()) {
+		bundle, bundleErr = l.modelManager.GetBundle(modelID)
+	}
+
+	// Refuse to launch a backend on a model whose files are incomplete or
+	// archived, which would otherwise fail with an opaque error.
+	var unloadableErr error
+	if !backend.UsesExternalModelManagement() &&
+		(errors.Is(bundleErr, distribution.ErrIncompleteModel) || errors.Is(bundleErr, distribution.ErrModelArchived)) {
+		unloadableErr = bundleErr
+	}
+
 	// If no explicit config exists, create a default one with the model's context size
 	// so that the OpenAI recorder can report the actual configuration being used.
 	if runnerConfig == nil {
 		defaultConfig := inference.BackendConfiguration{}
 		if l.modelManager != nil {
-			if bundle, err := l.modelManager.GetBundle(modelID); err != nil {
-				l.log.Warnf("Failed to get bundle for model %s to determine default context size: %v", modelID, err)
+			if bundleErr != nil {
+				l.log.Warnf("Failed to get bundle for model %s to determine default context size: %v", modelID, bundleErr)
 			} else if runtimeConfig := bundle.RuntimeConfig(); runtimeConfig != nil {
 				if ctxSize := runtimeConfig.GetContextSize(); ctxSize != nil {
 					defaultConfig.ContextSize = ctxSize
@@ -452,6 +849,14 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 		runnerConfig = &defaultConfig
 	}
 
+	// Fall back to the server-wide default context size. Backends still give
+	// precedence to a context size set in the model's own configuration.
+	if runnerConfig.ContextSize == nil && l.defaultContextSize != nil {
+		withDefault := *runnerConfig
+		withDefault.ContextSize = l.defaultContextSize
+		runnerConfig = &withDefault
+	}
+
 	l.log.Infof("Loading %s backend runner with model %s in %s mode", backendName, modelID, mode)
 
 	// Acquire the loader lock and defer its release.
@@ -469,6 +874,7 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 	}()
 
 	// Loop until we can satisfy the request or an error occurs.
+	key := makeRunnerKey(backendName, modelID, draftModelID, mode)
 	for {
 		slot := -1
 
@@ -477,14 +883,32 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 			return nil, errLoadsDisabled
 		}
 
+		// If the runner is already being started by another request, then
+		// share that load instead of starting a duplicate backend. A load
+		// cancelled by its requester is retried on our own behalf.
+		if pending, ok := l.loading[key]; ok {
+			l.unlock()
+			select {
+			case <-ctx.Done():
+				l.lock(context.Background())
+				return nil, context.Canceled
+			case <-pending.done:
+				l.lock(context.Background())
+			}
+			if pending.err != nil && !errors.Is(pending.err, context.Canceled) {
+				return nil, fmt.Errorf("error waiting for runner to be ready: %w", pending.err)
+			}
+			continue
+		}
+
 		// See if we can satisfy the request with an existing runner.
-		existing, ok := l.runners[makeRunnerKey(backendName, modelID, draftModelID, mode)]
+		existing, ok := l.runners[key]
 		if ok {
 			select {
 			case <-l.slots[existing.slot].done:
 				l.log.Warnf("%s runner for %s is defunct. Waiting for it to be evicted.", backendName, existing.modelRef)
 				if l.references[existing.slot] == 0 {
-					l.evictRunner(backendName, modelID, mode)
+					l.evictRunner(backendName, modelID, mode, evictReasonDefunct)
 					// Continue the loop to retry loading after evicting the defunct runner
 					continue
 				} else {
@@ -498,11 +922,11 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 		}
 
 		// If all slots are full, try evicting unused runners.
-		if len(l.runners) == len(l.slots) {
+		if l.occupiedSlots() == len(l.slots) {
 			l.log.Infof("Evicting to make room: %d/%d slots used",
-				len(l.runners), len(l.slots))
+				l.occupiedSlots(), len(l.slots))
 			runnerCountAtLoopStart := len(l.runners)
-			remainingRunners := l.evict(false)
+			remainingRunners := l.evict(false, evictReasonCapacity)
 			// Restart the loop if eviction happened
 			if remainingRunners < runnerCountAtLoopStart {
 				continue
@@ -510,7 +934,7 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 		}
 
 		// If there's a free slot, then find the slot.
-		if len(l.runners) < len(l.slots) {
+		if l.occupiedSlots() < len(l.slots) {
 			for s, runner := range l.slots {
 				if runner == nil {
 					slot = s
@@ -520,39 +944,88 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 		}
 
 		if slot < 0 {
+			if !waitsForSlot(ctx) {
+				return nil, errNoRunnerSlot
+			}
 			l.log.Debugf("Cannot load model yet: %d/%d slots used",
-				len(l.runners), len(l.slots))
+				l.occupiedSlots(), len(l.slots))
 		}
 
 		// If we've identified a slot, then we're ready to start a runner.
 		if slot >= 0 {
+			span.SetAttributes(attribute.Bool("cold_start", true))
+
+			// Refuse to start runners for models that keep failing to load.
+			if err := l.breaker.check(modelID); err != nil {
+				return nil, err
+			}
+
+			// Refuse to start runners for models whose files can't be used.
+			if unloadableErr != nil {
+				l.log.Warnf("Not loading incomplete model %s: %v", modelID, unloadableErr)
+				l.publishEvent(EventLoadFailed, key, modelRef, unloadableErr.Error(), 0)
+				return nil, unloadableErr
+			}
+
 			// Create the runner.
 			runner, err := run(l.log, backend, modelID, modelRef, mode, slot, runnerConfig, l.openAIRecorder)
 			if err != nil {
 				l.log.Warnf("Unable to start %s backend runner with model %s in %s mode: %v",
 					backendName, modelID, mode, err,
 				)
+				l.publishEvent(EventLoadFailed, key, modelRef, err.Error(), 0)
+				l.breaker.recordFailure(modelID, err)
 				return nil, fmt.Errorf("unable to start runner: %w", err)
 			}
 
-			// Wait for the runner to be ready. In theory it's a little
-			// inefficient to block all other loaders (including those that
-			// might not want this runner), but in reality they would probably
-			// be blocked by the underlying loading anyway (in terms of disk and
-			// GPU performance). We have to retain a lock here though to enforce
-			// deduplication of runners and keep slot / memory reservations.
-			if err := runner.wait(ctx); err != nil {
+			// Reserve the slot and wait for the runner to be ready without
+			// holding the lock, so that loads of other models can proceed.
+			// Requests for this runner wait on the pending load, which
+			// enforces deduplication of runners.
+			pending := &pendingLoad{done: make(chan struct{})}
+			l.loading[key] = pending
+			l.slots[slot] = runner
+			l.unlock()
+			// Let the caller know that it's waiting on a cold load. Notifiers
+			// are caller code, so they're only run without the lock held.
+			notifyLoading(ctx)
+			err = runner.wait(ctx, l.backendStartTimeout)
+			if err == nil {
+				runner.sampleRSS()
+			}
+			l.lock(context.Background())
+			delete(l.loading, key)
+			if err == nil && !l.loadsEnabled {
+				err = errLoadsDisabled
+			}
+			if err != nil {
 				runner.terminate()
+				l.countCrash(backendName, runner)
+				l.slots[slot] = nil
+				pending.err = err
+				close(pending.done)
+				l.broadcast()
 				l.log.Warnf("Initialization for %s backend runner with model %s in %s mode failed: %v",
 					backendName, modelID, mode, err,
 				)
+				var timeoutErr *BackendStartTimeoutError
+				if errors.As(err, &timeoutErr) {
+					l.logStartTimeout(timeoutErr, runnerConfig)
+				}
+				l.publishEvent(EventLoadFailed, key, modelRef, err.Error(), 0)
+				if !errors.Is(err, context.Canceled) && !errors.Is(err, errLoadsDisabled) {
+					l.breaker.recordFailure(modelID, err)
+				}
 				return nil, fmt.Errorf("error waiting for runner to be ready: %w", err)
 			}
 
 			// Perform registration and return the runner.
-			l.runners[makeRunnerKey(backendName, modelID, draftModelID, mode)] = runnerInfo{slot, modelRef}
-			l.slots[slot] = runner
+			l.runners[key] = runnerInfo{slot, modelRef}
 			l.references[slot] = 1
+			close(pending.done)
+			l.broadcast()
+			l.publishEvent(EventModelLoaded, key, modelRef, "", runner.rss.Load())
+			l.breaker.reset(modelID)
 			return runner, nil
 		}
 
@@ -573,6 +1046,15 @@ func (l *loader) load(ctx context.Context, backendName, modelID, modelRef string
 
 // release releases a runner, which internally decrements its reference count.
 func (l *loader) release(runner *runner) {
+	// Sample the memory of a runner left idle once the lock is released, so
+	// that it can be reported if the runner is evicted.
+	idle := false
+	defer func() {
+		if idle {
+			go runner.sampleRSS()
+		}
+	}()
+
 	// Acquire the loader lock and defer its release.
 	l.lock(context.Background())
 	defer l.unlock()
@@ -595,9 +1077,10 @@ func (l *loader) release(runner *runner) {
 	if l.references[slotInfo.slot] == 0 {
 		select {
 		case <-runner.done:
-			l.evictRunner(runner.backend.Name(), runner.model, runner.mode)
+			l.evictRunner(runner.backend.Name(), runner.model, runner.mode, evictReasonDefunct)
 		default:
 			l.timestamps[slotInfo.slot] = time.Now()
+			idle = true
 			select {
 			case l.idleCheck <- struct{}{}:
 			default:
@@ -632,7 +1115,7 @@ func (l *loader) setRunnerConfig(ctx context.Context, backendName, modelID strin
 	// If there's an active runner whose configuration we want to override, then
 	// try evicting it (because it may not be in use).
 	if _, ok := l.runners[rKey]; ok {
-		l.evictRunner(backendName, modelID, mode)
+		l.evictRunner(backendName, modelID, mode, evictReasonReconfigure)
 	}
 
 	// If there's still then active runner, then we can't (or at least
@@ -640,34 +1123,153 @@ func (l *loader) setRunnerConfig(ctx context.Context, backendName, modelID strin
 	if _, ok := l.runners[rKey]; ok {
 		return errRunnerAlreadyActive
 	}
+	if _, ok := l.loading[rKey]; ok {
+		return errRunnerAlreadyActive
+	}
 
 	l.log.Infof("Configuring %s runner for %s", backendName, modelID)
 	l.runnerConfigs[configKey] = runnerConfig
 	return nil
 }
 
+// resetRunnerConfigs removes the runner configurations and keep-alives set for
+// the model with modelID, or for every model if modelID is empty, so that its
+// next load uses the defaults. Unused runners loaded with a removed
+// configuration are evicted. It returns the keys of the removed
+// configurations.
+func (l *loader) resetRunnerConfigs(ctx context.Context, modelID string) []runnerKey {
+	if !l.lock(ctx) {
+		return nil
+	}
+	defer l.unlock()
+
+	var removed []runnerKey
+	for key := range l.runnerConfigs {
+		if modelID == "" || key.modelID == modelID {
+			delete(l.runnerConfigs, key)
+			removed = append(removed, key)
+		}
+	}
+	for key := range l.keepAlives {
+		if modelID == "" || key.modelID == modelID {
+			delete(l.keepAlives, key)
+		}
+	}
+	l.defaultsLock.Lock()
+	for key := range l.defaultStops {
+		if modelID == "" || key.modelID == modelID {
+			delete(l.defaultStops, key)
+		}
+	}
+	for key := range l.systemPrompts {
+		if modelID == "" || key.modelID == modelID {
+			delete(l.systemPrompts, key)
+		}
+	}
+	l.defaultsLock.Unlock()
+	for _, key := range removed {
+		l.evictRunner(key.backend, key.modelID, key.mode, evictReasonReconfigure)
+	}
+	return removed
+}
+
+// setDefaultStop sets the stop sequences merged into every request for the
+// model. An empty list removes them.
+func (l *loader) setDefaultStop(backendName, modelID string, mode inference.BackendMode, stops []string) {
+	l.defaultsLock.Lock()
+	defer l.defaultsLock.Unlock()
+	configKey := makeConfigKey(backendName, modelID, mode)
+	if len(stops) == 0 {
+		delete(l.defaultStops, configKey)
+		return
+	}
+	l.defaultStops[configKey] = slices.Clone(stops)
+}
+
+// defaultStop returns the stop sequences configured for the model, if any.
+func (l *loader) defaultStop(backendName, modelID string, mode inference.BackendMode) []string {
+	l.defaultsLock.RLock()
+	defer l.defaultsLock.RUnlock()
+	return l.defaultStops[makeConfigKey(backendName, modelID, mode)]
+}
+
+// setDefaultSystemPrompt sets the system prompt applied to every chat request
+// for the model. An empty prompt removes it.
+func (l *loader) setDefaultSystemPrompt(backendName, modelID string, mode inference.BackendMode, prompt defaultSystemPrompt) {
+	l.defaultsLock.Lock()
+	defer l.defaultsLock.Unlock()
+	configKey := makeConfigKey(backendName, modelID, mode)
+	if prompt.prompt == "" {
+		delete(l.systemPrompts, configKey)
+		return
+	}
+	l.systemPrompts[configKey] = prompt
+}
+
+// defaultSystemPrompt returns the system prompt configured for the model, if
+// any.
+func (l *loader) defaultSystemPrompt(backendName, modelID string, mode inference.BackendMode) defaultSystemPrompt {
+	l.defaultsLock.RLock()
+	defer l.defaultsLock.RUnlock()
+	return l.systemPrompts[makeConfigKey(backendName, modelID, mode)]
+}
+
 // getAllRunnerConfigs retrieves all runner configurations.
 func (l *loader) getAllRunnerConfigs(ctx context.Context) []ModelConfigEntry {
 	if !l.lock(ctx) {
 		return nil
 	}
 	defer l.unlock()
+	l.defaultsLock.RLock()
+	defer l.defaultsLock.RUnlock()
 
-	entries := make([]ModelConfigEntry, 0, len(l.runnerConfigs))
-	for key, config := range l.runnerConfigs {
+	keys := make([]runnerKey, 0, len(l.runnerConfigs)+len(l.defaultStops)+len(l.systemPrompts)+len(l.keepAlives))
+	for key := range l.runnerConfigs {
+		keys = append(keys, key)
+	}
+	for key := range l.defaultStops {
+		if _, ok := l.runnerConfigs[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range l.systemPrompts {
+		_, configured := l.runnerConfigs[key]
+		_, stops := l.defaultStops[key]
+		if !configured && !stops {
+			keys = append(keys, key)
+		}
+	}
+	for key := range l.keepAlives {
+		_, configured := l.runnerConfigs[key]
+		_, stops := l.defaultStops[key]
+		_, prompt := l.systemPrompts[key]
+		if !configured && !stops && !prompt {
+			keys = append(keys, key)
+		}
+	}
+
+	entries := make([]ModelConfigEntry, 0, len(keys))
+	for _, key := range keys {
 		model, err := l.modelManager.GetLocal(key.modelID)
 		if err == nil {
 			modelName := ""
 			if len(model.Tags()) > 0 {
 				modelName = model.Tags()[0]
 			}
-			entries = append(entries, ModelConfigEntry{
-				Backend: key.backend,
-				Model:   modelName,
-				ModelID: key.modelID,
-				Mode:    key.mode,
-				Config:  config,
-			})
+			entry := ModelConfigEntry{
+				Backend:             key.backend,
+				Model:               modelName,
+				ModelID:             key.modelID,
+				Mode:                key.mode,
+				Config:              l.runnerConfigs[key],
+				DefaultStop:         l.defaultStops[key],
+				DefaultSystemPrompt: l.systemPrompts[key].prompt,
+				SystemPromptPolicy:  l.systemPrompts[key].policy,
+			}
+			if keepAlive, ok := l.keepAlives[key]; ok {
+				entry.KeepAlive = keepAlive.String()
+			}
+			entries = append(entries, entry)
 		}
 	}
 	return entries
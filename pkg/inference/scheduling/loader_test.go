@@ -3,12 +3,21 @@ package scheduling
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/backends"
+	"github.com/docker/model-runner/pkg/metrics"
 	"github.com/sirupsen/logrus"
 )
 
@@ -322,3 +331,677 @@ func TestUnusedRunnerEvictionTriggersRetry(t *testing.T) {
 		t.Error("Unexpected success; acceptable but unusual with fastFail backend")
 	}
 }
+
+// TestLoadNotifiesColdLoad tests that load() invokes the context's load
+// notifiers, innermost first and without the loader lock held, when it has to
+// start a new runner.
+func TestLoadNotifiesColdLoad(t *testing.T) {
+	log := createTestLogger()
+
+	backend := &fastFailBackend{mockBackend: mockBackend{name: "test-backend"}}
+	backends := map[string]inference.Backend{"test-backend": backend}
+	loader := newLoader(log, backends, nil, nil)
+
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	loader.unlock()
+
+	var calls []string
+	var locked bool
+	ctx := WithLoadNotifier(t.Context(), func() { calls = append(calls, "outer") })
+	ctx = WithLoadNotifier(ctx, func() {
+		calls = append(calls, "inner")
+		// Notifiers run without the loader lock held.
+		lockCtx, cancel := context.WithTimeout(t.Context(), time.Second)
+		defer cancel()
+		if locked = loader.lock(lockCtx); locked {
+			loader.unlock()
+		}
+	})
+
+	// The fastFail backend errors out after the notification
+	_, _ = loader.load(ctx, "test-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+
+	if len(calls) != 2 || calls[0] != "inner" || calls[1] != "outer" {
+		t.Errorf("Expected notifiers to be called inner then outer, got %v", calls)
+	}
+	if !locked {
+		t.Error("Expected notifiers to be called without the loader lock held")
+	}
+}
+
+// TestLoadWithoutSlotWait tests that a load that can't wait for a runner slot
+// fails with errNoRunnerSlot when all slots hold runners in use.
+func TestLoadWithoutSlotWait(t *testing.T) {
+	log := createTestLogger()
+
+	backend := &fastFailBackend{mockBackend: mockBackend{name: "test-backend"}}
+	backends := map[string]inference.Backend{"test-backend": backend}
+	loader := newLoader(log, backends, nil, nil)
+
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock")
+	}
+	loader.loadsEnabled = true
+	for slot := range loader.slots {
+		loader.slots[slot] = createAliveTerminableMockRunner(t.Context(), log, backend)
+		loader.runners[makeRunnerKey("test-backend", fmt.Sprintf("busy%d", slot), "", inference.BackendModeCompletion)] = runnerInfo{
+			slot:     slot,
+			modelRef: fmt.Sprintf("busy%d:latest", slot),
+		}
+		loader.references[slot] = 1
+	}
+	loader.unlock()
+
+	ctx, cancel := context.WithTimeout(withoutSlotWait(t.Context()), 5*time.Second)
+	defer cancel()
+	_, err := loader.load(ctx, "test-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+	if !errors.Is(err, errNoRunnerSlot) {
+		t.Errorf("Expected errNoRunnerSlot, got %v", err)
+	}
+}
+
+// contextSizeRecordingBackend records the context size it was launched with
+// and then fails fast.
+type contextSizeRecordingBackend struct {
+	mockBackend
+	contextSize chan *int32
+}
+
+func (b *contextSizeRecordingBackend) Run(ctx context.Context, socket, model string, modelRef string, mode inference.BackendMode, config *inference.BackendConfiguration) error {
+	b.contextSize <- config.ContextSize
+	return errors.New("boom")
+}
+
+// TestLoadAppliesDefaultContextSize tests that the server-wide default context
+// size is applied unless the runner configuration sets one.
+func TestLoadAppliesDefaultContextSize(t *testing.T) {
+	t.Setenv(defaultContextSizeEnv, "8192")
+	log := createTestLogger()
+
+	backend := &contextSizeRecordingBackend{
+		mockBackend: mockBackend{name: "test-backend"},
+		contextSize: make(chan *int32, 1),
+	}
+	backends := map[string]inference.Backend{"test-backend": backend}
+	loader := newLoader(log, backends, nil, nil)
+
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	explicit := int32(2048)
+	loader.runnerConfigs[makeConfigKey("test-backend", "model2", inference.BackendModeCompletion)] = inference.BackendConfiguration{
+		ContextSize: &explicit,
+	}
+	loader.unlock()
+
+	for model, expected := range map[string]int32{"model1": 8192, "model2": 2048} {
+		_, _ = loader.load(t.Context(), "test-backend", model, model+":latest", inference.BackendModeCompletion)
+		got := <-backend.contextSize
+		if got == nil || *got != expected {
+			t.Errorf("Expected context size %d for %s, got %v", expected, model, got)
+		}
+	}
+}
+
+func TestContextSizeCapApply(t *testing.T) {
+	size := func(n int32) *int32 { return &n }
+	tests := []struct {
+		name        string
+		reject      bool
+		config      inference.BackendConfiguration
+		wantSize    *int32
+		wantFlags   []string
+		wantClamped bool
+		wantErr     bool
+	}{
+		{name: "within cap", config: inference.BackendConfiguration{ContextSize: size(4096), RuntimeFlags: []string{"--ctx-size", "2048"}}, wantSize: size(4096), wantFlags: []string{"--ctx-size", "2048"}},
+		{name: "clamp size", config: inference.BackendConfiguration{ContextSize: size(65536)}, wantSize: size(8192), wantClamped: true},
+		{name: "clamp unlimited", config: inference.BackendConfiguration{ContextSize: size(-1)}, wantSize: size(8192), wantClamped: true},
+		{name: "clamp flags", config: inference.BackendConfiguration{RuntimeFlags: []string{"-c", "0", "--threads", "4", "--max-model-len=32768"}}, wantFlags: []string{"-c", "8192", "--threads", "4", "--max-model-len=8192"}, wantClamped: true},
+		{name: "reject size", reject: true, config: inference.BackendConfiguration{ContextSize: size(65536)}, wantErr: true},
+		{name: "reject flag", reject: true, config: inference.BackendConfiguration{RuntimeFlags: []string{"--ctx-size=65536"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &contextSizeCap{max: 8192, reject: tt.reject}
+			clamped, err := c.apply(&tt.config)
+			if tt.wantErr {
+				if !errors.Is(err, ErrContextSizeExceeded) {
+					t.Fatalf("Expected ErrContextSizeExceeded, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if clamped != tt.wantClamped {
+				t.Errorf("Expected clamped=%v, got %v", tt.wantClamped, clamped)
+			}
+			if (tt.wantSize == nil) != (tt.config.ContextSize == nil) || (tt.wantSize != nil && *tt.wantSize != *tt.config.ContextSize) {
+				t.Errorf("Expected context size %v, got %v", tt.wantSize, tt.config.ContextSize)
+			}
+			if !slices.Equal(tt.config.RuntimeFlags, tt.wantFlags) {
+				t.Errorf("Expected runtime flags %v, got %v", tt.wantFlags, tt.config.RuntimeFlags)
+			}
+		})
+	}
+}
+
+func TestLoaderCapsDefaultContextSize(t *testing.T) {
+	t.Setenv(defaultContextSizeEnv, "65536")
+	t.Setenv(maxContextSizeEnv, "8192")
+	t.Setenv(contextSizeCapModeEnv, "reject")
+	loader := newLoader(createTestLogger(), map[string]inference.Backend{}, nil, nil)
+	if loader.contextSizeCap == nil || loader.contextSizeCap.max != 8192 || !loader.contextSizeCap.reject {
+		t.Fatalf("Expected a rejecting cap of 8192, got %+v", loader.contextSizeCap)
+	}
+	if loader.defaultContextSize == nil || *loader.defaultContextSize != 8192 {
+		t.Errorf("Expected the default context size to be capped to 8192, got %v", loader.defaultContextSize)
+	}
+}
+
+// healthyBackend serves a healthy /health endpoint on the runner socket and
+// counts how many times it has been started.
+type healthyBackend struct {
+	mockBackend
+	starts atomic.Int32
+}
+
+func (b *healthyBackend) Run(ctx context.Context, socket, model string, modelRef string, mode inference.BackendMode, config *inference.BackendConfiguration) error {
+	b.starts.Add(1)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	go func() { _ = server.Serve(listener) }()
+	<-ctx.Done()
+	return server.Close()
+}
+
+// TestConcurrentLoadsShareRunner tests that concurrent requests for a cold
+// model share a single backend launch.
+func TestConcurrentLoadsShareRunner(t *testing.T) {
+	socketDir := t.TempDir()
+	originalSocketPath := RunnerSocketPath
+	RunnerSocketPath = func(slot int) (string, error) {
+		return filepath.Join(socketDir, fmt.Sprintf("runner-%d.sock", slot)), nil
+	}
+	t.Cleanup(func() { RunnerSocketPath = originalSocketPath })
+
+	log := createTestLogger()
+	backend := &healthyBackend{mockBackend: mockBackend{name: "test-backend"}}
+	loader := newLoader(log, map[string]inference.Backend{"test-backend": backend}, nil, nil)
+
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	loader.unlock()
+
+	const n = 8
+	runners := make([]*runner, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runners[i], errs[i] = loader.load(t.Context(), "test-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+		}()
+	}
+	wg.Wait()
+
+	for i := range n {
+		if errs[i] != nil {
+			t.Fatalf("Load %d failed: %v", i, errs[i])
+		}
+		if runners[i] != runners[0] {
+			t.Errorf("Load %d returned a different runner", i)
+		}
+	}
+	if starts := backend.starts.Load(); starts != 1 {
+		t.Errorf("Expected exactly one backend launch, got %d", starts)
+	}
+
+	for _, r := range runners {
+		loader.release(r)
+	}
+	loader.lock(context.Background())
+	if remaining := loader.evict(false, evictReasonUnload); remaining != 0 {
+		t.Errorf("Expected all runners to be evicted, %d remain", remaining)
+	}
+	loader.unlock()
+}
+
+// stuckBackend writes to its error output but never becomes ready.
+type stuckBackend struct{ mockBackend }
+
+func (b *stuckBackend) Run(ctx context.Context, socket, model string, modelRef string, mode inference.BackendMode, config *inference.BackendConfiguration) error {
+	if w := backends.ErrorOutput(ctx); w != nil {
+		_, _ = io.WriteString(w, "load_tensors: loading model tensors\n")
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// TestLoadBackendStartTimeout tests that a backend that doesn't become ready
+// within the configured timeout is stopped and reported with its output.
+func TestLoadBackendStartTimeout(t *testing.T) {
+	socketDir := t.TempDir()
+	originalSocketPath := RunnerSocketPath
+	RunnerSocketPath = func(slot int) (string, error) {
+		return filepath.Join(socketDir, fmt.Sprintf("runner-%d.sock", slot)), nil
+	}
+	t.Cleanup(func() { RunnerSocketPath = originalSocketPath })
+	t.Setenv(backendStartTimeoutEnv, "1s")
+
+	log := createTestLogger()
+	backend := &stuckBackend{mockBackend: mockBackend{name: "test-backend"}}
+	loader := newLoader(log, map[string]inference.Backend{"test-backend": backend}, nil, nil)
+	if loader.backendStartTimeout != time.Second {
+		t.Fatalf("Expected a backend start timeout of 1s, got %s", loader.backendStartTimeout)
+	}
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	loader.unlock()
+
+	_, err := loader.load(t.Context(), "test-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+	if !errors.Is(err, ErrBackendStartTimeout) {
+		t.Fatalf("Expected ErrBackendStartTimeout, got %v", err)
+	}
+	var timeoutErr *BackendStartTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected a BackendStartTimeoutError, got %T", err)
+	}
+	if timeoutErr.Waited < time.Second {
+		t.Errorf("Expected to wait for the timeout, waited only %s", timeoutErr.Waited)
+	}
+	if timeoutErr.Output != "load_tensors: loading model tensors" {
+		t.Errorf("Expected the backend output in the error, got %q", timeoutErr.Output)
+	}
+
+	loader.lock(context.Background())
+	defer loader.unlock()
+	for slot, r := range loader.slots {
+		if r != nil {
+			t.Errorf("Expected slot %d to be freed", slot)
+		}
+	}
+}
+
+// TestLoaderPublishesLifecycleEvents tests that loads, unloads, and load
+// failures are published to event subscribers.
+func TestLoaderPublishesLifecycleEvents(t *testing.T) {
+	socketDir := t.TempDir()
+	originalSocketPath := RunnerSocketPath
+	RunnerSocketPath = func(slot int) (string, error) {
+		return filepath.Join(socketDir, fmt.Sprintf("runner-%d.sock", slot)), nil
+	}
+	t.Cleanup(func() { RunnerSocketPath = originalSocketPath })
+
+	log := createTestLogger()
+	backends := map[string]inference.Backend{
+		"test-backend": &healthyBackend{mockBackend: mockBackend{name: "test-backend"}},
+		"fail-backend": &fastFailBackend{mockBackend: mockBackend{name: "fail-backend"}},
+	}
+	loader := newLoader(log, backends, nil, nil)
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	loader.unlock()
+
+	events, unsubscribe := loader.events.subscribe()
+	defer unsubscribe()
+
+	r, err := loader.load(t.Context(), "test-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	loader.release(r)
+	loader.lock(context.Background())
+	loader.evictRunner("test-backend", "model1", inference.BackendModeCompletion, evictReasonUnload)
+	loader.unlock()
+	_, _ = loader.load(t.Context(), "fail-backend", "model2", "model2:latest", inference.BackendModeCompletion)
+
+	expected := []struct {
+		eventType LifecycleEventType
+		model     string
+	}{
+		{EventModelLoaded, "model1:latest"},
+		{EventModelUnloaded, "model1:latest"},
+		{EventLoadFailed, "model2:latest"},
+	}
+	for _, want := range expected {
+		select {
+		case event := <-events:
+			if event.Type != want.eventType || event.Model != want.model {
+				t.Errorf("Expected %s for %s, got %s for %s", want.eventType, want.model, event.Type, event.Model)
+			}
+			if event.SlotsTotal != len(loader.slots) {
+				t.Errorf("Expected %d total slots, got %d", len(loader.slots), event.SlotsTotal)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s event", want.eventType)
+		}
+	}
+}
+
+// TestLoaderReportsEvictedRunnerMemory tests that an eviction reports the
+// memory sampled while the runner was idle, rather than sampling the process
+// after it has been terminated.
+func TestLoaderReportsEvictedRunnerMemory(t *testing.T) {
+	if metrics.ProcessRSS(os.Getpid()) == 0 {
+		t.Skip("Process memory is not available on this platform")
+	}
+	socketDir := t.TempDir()
+	originalSocketPath := RunnerSocketPath
+	RunnerSocketPath = func(slot int) (string, error) {
+		return filepath.Join(socketDir, fmt.Sprintf("runner-%d.sock", slot)), nil
+	}
+	t.Cleanup(func() { RunnerSocketPath = originalSocketPath })
+
+	loader := newLoader(createTestLogger(), map[string]inference.Backend{
+		"test-backend": &healthyBackend{mockBackend: mockBackend{name: "test-backend"}},
+	}, nil, nil)
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	loader.unlock()
+
+	events, unsubscribe := loader.events.subscribe()
+	defer unsubscribe()
+
+	r, err := loader.load(t.Context(), "test-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	<-events
+
+	// Stand in for the backend process with this one, which stays alive.
+	r.pid.Store(int64(os.Getpid()))
+	loader.release(r)
+	deadline := time.Now().Add(5 * time.Second)
+	for r.rss.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the idle runner's memory to be sampled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	sampled := r.rss.Load()
+
+	loader.lock(context.Background())
+	loader.evictRunner("test-backend", "model1", inference.BackendModeCompletion, evictReasonUnload)
+	loader.unlock()
+
+	select {
+	case event := <-events:
+		if event.Type != EventModelUnloaded {
+			t.Fatalf("Expected %s, got %s", EventModelUnloaded, event.Type)
+		}
+		if event.MemoryBytes != sampled {
+			t.Errorf("Expected %d memory bytes, got %d", sampled, event.MemoryBytes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the unloaded event")
+	}
+}
+
+// crashingBackend is a backend whose process exits unexpectedly on Run.
+type crashingBackend struct{ mockBackend }
+
+func (b *crashingBackend) Run(ctx context.Context, socket, model string, modelRef string, mode inference.BackendMode, config *inference.BackendConfiguration) error {
+	return &backends.CrashError{Backend: b.name, ExitCode: 139, Output: "segmentation fault"}
+}
+
+// TestLoaderCountsBackendCrashes tests that crashed backend processes are
+// counted per backend, while ordinary failures are not.
+func TestLoaderCountsBackendCrashes(t *testing.T) {
+	log := createTestLogger()
+	loader := newLoader(log, map[string]inference.Backend{
+		"crash-backend": &crashingBackend{mockBackend: mockBackend{name: "crash-backend"}},
+		"fail-backend":  &fastFailBackend{mockBackend: mockBackend{name: "fail-backend"}},
+	}, nil, nil)
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	loader.unlock()
+
+	for i := 0; i < 2; i++ {
+		_, err := loader.load(t.Context(), "crash-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+		if !errors.Is(err, backends.ErrBackendCrashed) {
+			t.Fatalf("Expected ErrBackendCrashed, got %v", err)
+		}
+	}
+	if _, err := loader.load(t.Context(), "fail-backend", "model2", "model2:latest", inference.BackendModeCompletion); err == nil {
+		t.Fatal("Expected load to fail")
+	}
+
+	crashes := loader.crashCounts(t.Context())
+	if crashes["crash-backend"] != 2 {
+		t.Errorf("Expected 2 crashes for crash-backend, got %d", crashes["crash-backend"])
+	}
+	if _, ok := crashes["fail-backend"]; ok {
+		t.Errorf("Expected no crashes for fail-backend, got %d", crashes["fail-backend"])
+	}
+}
+
+// TestLoaderBreakerShortCircuitsFailingModel tests that repeated load failures
+// open the model's circuit breaker until its cooldown elapses or it is reset.
+func TestLoaderBreakerShortCircuitsFailingModel(t *testing.T) {
+	log := createTestLogger()
+	backend := &fastFailBackend{mockBackend: mockBackend{name: "fail-backend"}}
+	loader := newLoader(log, map[string]inference.Backend{"fail-backend": backend}, nil, nil)
+	now := time.Now()
+	loader.breaker.now = func() time.Time { return now }
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	loader.loadsEnabled = true
+	loader.unlock()
+
+	load := func() error {
+		_, err := loader.load(t.Context(), "fail-backend", "model1", "model1:latest", inference.BackendModeCompletion)
+		return err
+	}
+	for i := 0; i < breakerFailureThreshold; i++ {
+		if err := load(); err == nil || errors.Is(err, ErrModelUnavailable) {
+			t.Fatalf("Load %d: expected a load failure, got %v", i, err)
+		}
+	}
+
+	var unavailable *ModelUnavailableError
+	if err := load(); !errors.As(err, &unavailable) {
+		t.Fatalf("Expected ModelUnavailableError, got %v", err)
+	}
+	if unavailable.RetryAfter != breakerCooldown {
+		t.Errorf("Expected retry after %s, got %s", breakerCooldown, unavailable.RetryAfter)
+	}
+	statuses := loader.breakerStatuses(t.Context())
+	if len(statuses) != 1 || !statuses[0].Open || statuses[0].Failures != breakerFailureThreshold {
+		t.Errorf("Unexpected breaker statuses: %+v", statuses)
+	}
+
+	// After the cooldown a single attempt is allowed, and its failure reopens
+	// the breaker.
+	now = now.Add(breakerCooldown)
+	if err := load(); err == nil || errors.Is(err, ErrModelUnavailable) {
+		t.Fatalf("Expected a load attempt after cooldown, got %v", err)
+	}
+	if err := load(); !errors.Is(err, ErrModelUnavailable) {
+		t.Fatalf("Expected breaker to reopen, got %v", err)
+	}
+
+	loader.resetBreaker(t.Context(), "model1")
+	if err := load(); err == nil || errors.Is(err, ErrModelUnavailable) {
+		t.Fatalf("Expected a load attempt after reset, got %v", err)
+	}
+}
+
+// TestLoaderKeepAliveOverridesIdleTimeout tests that a per-model keep-alive
+// overrides the default idle timeout when evicting idle runners.
+func TestLoaderKeepAliveOverridesIdleTimeout(t *testing.T) {
+	log := createTestLogger()
+	loader := newLoader(log, map[string]inference.Backend{}, nil, nil)
+	loader.runnerIdleTimeout = time.Hour
+	loader.slots = make([]*runner, 3)
+	loader.references = make([]uint, 3)
+	loader.timestamps = make([]time.Time, 3)
+	for slot, modelID := range []string{"model1", "model2", "model3"} {
+		key := makeRunnerKey("test-backend", modelID, "", inference.BackendModeCompletion)
+		loader.runners[key] = runnerInfo{slot: slot, modelRef: modelID}
+		done := make(chan struct{})
+		loader.slots[slot] = &runner{
+			log:       log,
+			done:      done,
+			cancel:    func() { close(done) },
+			client:    &http.Client{},
+			transport: &http.Transport{},
+			proxyLog:  io.NopCloser(nil),
+		}
+		loader.timestamps[slot] = time.Now().Add(-time.Minute)
+	}
+	loader.setKeepAlive(t.Context(), "test-backend", "model1", inference.BackendModeCompletion, 0)
+	loader.setKeepAlive(t.Context(), "test-backend", "model3", inference.BackendModeCompletion, -1)
+
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock")
+	}
+	defer loader.unlock()
+	if d := loader.idleCheckDuration(); d != 0 {
+		t.Errorf("Expected an immediate idle check, got %s", d)
+	}
+	if remaining := loader.evict(true, evictReasonIdle); remaining != 2 {
+		t.Errorf("Expected 2 remaining runners, got %d", remaining)
+	}
+	if _, ok := loader.runners[makeRunnerKey("test-backend", "model1", "", inference.BackendModeCompletion)]; ok {
+		t.Error("Expected runner with zero keep-alive to be evicted")
+	}
+	loader.unlock()
+
+	loader.clearKeepAlive(t.Context(), "test-backend", "model3", inference.BackendModeCompletion)
+	if !loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock")
+	}
+	if timeout := loader.idleTimeout(makeRunnerKey("test-backend", "model3", "", inference.BackendModeCompletion)); timeout != time.Hour {
+		t.Errorf("Expected the cleared keep-alive to restore the default timeout, got %s", timeout)
+	}
+}
+
+// TestLoaderResetRunnerConfigs tests that resetting a model's configuration
+// removes only that model's configurations and keep-alives.
+func TestLoaderResetRunnerConfigs(t *testing.T) {
+	log := createTestLogger()
+	loader := newLoader(log, map[string]inference.Backend{}, nil, nil)
+	for _, modelID := range []string{"model1", "model2"} {
+		for _, mode := range []inference.BackendMode{inference.BackendModeCompletion, inference.BackendModeEmbedding} {
+			loader.runnerConfigs[makeConfigKey("test-backend", modelID, mode)] = inference.BackendConfiguration{}
+		}
+		loader.setKeepAlive(t.Context(), "test-backend", modelID, inference.BackendModeCompletion, time.Minute)
+		loader.setDefaultStop("test-backend", modelID, inference.BackendModeCompletion, []string{"</s>"})
+		loader.setDefaultSystemPrompt("test-backend", modelID, inference.BackendModeCompletion, defaultSystemPrompt{prompt: "Be brief."})
+	}
+
+	if removed := loader.resetRunnerConfigs(t.Context(), "model1"); len(removed) != 2 {
+		t.Errorf("Expected 2 removed configurations, got %d", len(removed))
+	}
+	for key := range loader.runnerConfigs {
+		if key.modelID == "model1" {
+			t.Errorf("Expected model1's configurations to be removed, found %v", key)
+		}
+	}
+	if _, ok := loader.keepAlives[makeConfigKey("test-backend", "model1", inference.BackendModeCompletion)]; ok {
+		t.Error("Expected model1's keep-alive to be removed")
+	}
+	if len(loader.keepAlives) != 1 {
+		t.Errorf("Expected model2's keep-alive to remain, got %d keep-alives", len(loader.keepAlives))
+	}
+	if stops := loader.defaultStop("test-backend", "model1", inference.BackendModeCompletion); stops != nil {
+		t.Errorf("Expected model1's default stop sequences to be removed, got %v", stops)
+	}
+	if stops := loader.defaultStop("test-backend", "model2", inference.BackendModeCompletion); len(stops) != 1 {
+		t.Errorf("Expected model2's default stop sequences to remain, got %v", stops)
+	}
+	if len(loader.systemPrompts) != 1 {
+		t.Errorf("Expected only model2's system prompt to remain, got %d system prompts", len(loader.systemPrompts))
+	}
+
+	if removed := loader.resetRunnerConfigs(t.Context(), ""); len(removed) != 2 {
+		t.Errorf("Expected 2 removed configurations, got %d", len(removed))
+	}
+	if len(loader.runnerConfigs) != 0 || len(loader.keepAlives) != 0 {
+		t.Errorf("Expected no configurations, got %d and %d keep-alives", len(loader.runnerConfigs), len(loader.keepAlives))
+	}
+}
+
+// TestLoaderUnloadAllWaitsForInFlight tests that unloading all runners with
+// Wait unloads runners in use once their requests finish.
+func TestLoaderUnloadAllWaitsForInFlight(t *testing.T) {
+	newTestLoader := func() *loader {
+		log := createTestLogger()
+		loader := newLoader(log, map[string]inference.Backend{}, nil, nil)
+		loader.slots = make([]*runner, 2)
+		loader.references = make([]uint, 2)
+		loader.timestamps = make([]time.Time, 2)
+		for slot, modelID := range []string{"model1", "model2"} {
+			key := makeRunnerKey("test-backend", modelID, "", inference.BackendModeCompletion)
+			loader.runners[key] = runnerInfo{slot: slot, modelRef: modelID}
+			done := make(chan struct{})
+			loader.slots[slot] = &runner{
+				log:       log,
+				done:      done,
+				cancel:    func() { close(done) },
+				client:    &http.Client{},
+				transport: &http.Transport{},
+				proxyLog:  io.NopCloser(nil),
+			}
+		}
+		// model2 has a request in flight.
+		loader.references[1] = 1
+		return loader
+	}
+
+	t.Run("without wait", func(t *testing.T) {
+		loader := newTestLoader()
+		if unloaded := loader.Unload(t.Context(), UnloadRequest{All: true}); unloaded != 1 {
+			t.Errorf("Expected 1 unloaded runner, got %d", unloaded)
+		}
+	})
+
+	t.Run("with wait", func(t *testing.T) {
+		loader := newTestLoader()
+		result := make(chan int, 1)
+		go func() {
+			result <- loader.Unload(t.Context(), UnloadRequest{All: true, Wait: true, WaitTimeout: "10s"})
+		}()
+		time.Sleep(50 * time.Millisecond)
+		loader.lock(context.Background())
+		loader.references[1] = 0
+		loader.broadcast()
+		loader.unlock()
+		if unloaded := <-result; unloaded != 2 {
+			t.Errorf("Expected 2 unloaded runners, got %d", unloaded)
+		}
+	})
+
+	t.Run("wait timeout", func(t *testing.T) {
+		loader := newTestLoader()
+		if unloaded := loader.Unload(t.Context(), UnloadRequest{All: true, Wait: true, WaitTimeout: "10ms"}); unloaded != 1 {
+			t.Errorf("Expected 1 unloaded runner, got %d", unloaded)
+		}
+		if len(loader.runners) != 1 {
+			t.Errorf("Expected the runner in use to remain loaded, got %d runners", len(loader.runners))
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// propsTimeout bounds the request reading a runner's slot count.
+	propsTimeout = 5 * time.Second
+	// maxRewrittenResponseSize is the largest response body whose usage is
+	// annotated with cached prompt tokens.
+	maxRewrittenResponseSize = 4 << 20
+)
+
+// promptSlots pins requests sharing a prompt prefix to the same llama.cpp
+// slot, so that the slot's KV cache already holds the prefix when they run.
+type promptSlots struct {
+	// lock guards the fields below.
+	lock sync.Mutex
+	// probed indicates that the runner's slot count has been read.
+	probed bool
+	// probing indicates that a request is reading the runner's slot count.
+	probing bool
+	// owners holds the prefix key that each slot's KV cache was last used
+	// for.
+	owners []string
+	// used holds the time each slot was last assigned.
+	used []time.Time
+	// busy holds the number of in-flight requests pinned to each slot.
+	busy []int
+}
+
+// assign returns the slot for requests with prefix key, along with a function
+// releasing it once the request completes. It reports false if the request
+// should not be pinned: the slot count is unknown or still being read, or the
+// prefix's slot is busy and the backend should pick a free one.
+func (p *promptSlots) assign(ctx context.Context, client *http.Client, key string) (int, func(), bool) {
+	p.lock.Lock()
+	if !p.probed && !p.probing {
+		// Read the slot count without holding the lock, so that concurrent
+		// requests aren't held up by the probe. They go unpinned meanwhile.
+		p.probing = true
+		p.lock.Unlock()
+		n := totalSlots(ctx, client)
+		p.lock.Lock()
+		p.probing = false
+		// A probe cut short by the request's cancellation is left to a later
+		// request.
+		if n > 0 || ctx.Err() == nil {
+			p.probed = true
+		}
+		if n > 0 && len(p.owners) == 0 {
+			p.owners, p.used, p.busy = make([]string, n), make([]time.Time, n), make([]int, n)
+		}
+	}
+	defer p.lock.Unlock()
+	if len(p.owners) == 0 {
+		return 0, nil, false
+	}
+
+	slot := -1
+	for i, owner := range p.owners {
+		if owner == key {
+			slot = i
+			break
+		}
+	}
+	if slot >= 0 && p.busy[slot] > 0 {
+		return 0, nil, false
+	}
+	if slot < 0 {
+		// Claim the idle slot that was least recently assigned.
+		for i := range p.owners {
+			if p.busy[i] == 0 && (slot < 0 || p.used[i].Before(p.used[slot])) {
+				slot = i
+			}
+		}
+		if slot < 0 {
+			return 0, nil, false
+		}
+		p.owners[slot] = key
+	}
+	p.used[slot] = time.Now()
+	p.busy[slot]++
+	return slot, func() {
+		p.lock.Lock()
+		p.busy[slot]--
+		p.lock.Unlock()
+	}, true
+}
+
+// totalSlots reads a llama.cpp server's slot count from its properties, or
+// returns zero if it can't be read.
+func totalSlots(ctx context.Context, client *http.Client) int {
+	ctx, cancel := context.WithTimeout(ctx, propsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/props", http.NoBody)
+	if err != nil {
+		return 0
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+	var props struct {
+		TotalSlots int `json:"total_slots"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&props); err != nil {
+		return 0
+	}
+	return props.TotalSlots
+}
+
+// promptPrefixKey returns a key identifying the leading system and developer
+// messages of a chat completion request, which chat apps resend unchanged
+// every turn, or false if the request has none.
+func promptPrefixKey(body []byte) (string, bool) {
+	var request struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return "", false
+	}
+	hash := sha256.New()
+	prefixed := false
+	for _, raw := range request.Messages {
+		var message map[string]any
+		if err := json.Unmarshal(raw, &message); err != nil {
+			return "", false
+		}
+		if role, _ := message["role"].(string); role != "system" && role != "developer" {
+			break
+		}
+		// Marshaling a map orders its keys, normalizing the message.
+		normalized, err := json.Marshal(message)
+		if err != nil {
+			return "", false
+		}
+		hash.Write(normalized)
+		hash.Write([]byte{0})
+		prefixed = true
+	}
+	if !prefixed {
+		return "", false
+	}
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// pinPromptSlot returns body with the request pinned to a llama.cpp slot and
+// prompt caching enabled. Requests that already select a slot are returned
+// unchanged.
+func pinPromptSlot(body []byte, slot int) ([]byte, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body, false
+	}
+	if _, ok := fields["id_slot"]; ok {
+		return body, false
+	}
+	fields["id_slot"] = json.RawMessage(strconv.Itoa(slot))
+	fields["cache_prompt"] = json.RawMessage("true")
+	pinned, err := json.Marshal(fields)
+	if err != nil {
+		return body, false
+	}
+	return pinned, true
+}
+
+// promptSlotKey is the context key marking requests pinned to a prompt slot.
+type promptSlotKey struct{}
+
+// withPromptSlot marks a request context as pinned to a prompt slot, so that
+// its response usage is annotated with the prompt tokens served from cache.
+func withPromptSlot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, promptSlotKey{}, true)
+}
+
+// annotateCachedTokens adds the number of prompt tokens that llama.cpp served
+// from its KV cache, reported in the response's timings, to the usage of a
+// non-streaming completion response of a pinned request, as OpenAI's
+// usage.prompt_tokens_details.cached_tokens.
+func annotateCachedTokens(resp *http.Response) error {
+	if resp.Request == nil || resp.Request.Context().Value(promptSlotKey{}) == nil ||
+		resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Encoding") != "" ||
+		!strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRewrittenResponseSize+1))
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > maxRewrittenResponseSize {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+	var timings struct {
+		CacheN *int64 `json:"cache_n"`
+	}
+	var usage map[string]json.RawMessage
+	if json.Unmarshal(fields["timings"], &timings) != nil || timings.CacheN == nil ||
+		json.Unmarshal(fields["usage"], &usage) != nil || usage == nil {
+		return nil
+	}
+	var details map[string]json.RawMessage
+	if raw, ok := usage["prompt_tokens_details"]; ok {
+		if json.Unmarshal(raw, &details) != nil {
+			return nil
+		}
+	}
+	if details == nil {
+		details = make(map[string]json.RawMessage)
+	}
+	if _, ok := details["cached_tokens"]; ok {
+		return nil
+	}
+	details["cached_tokens"] = json.RawMessage(strconv.FormatInt(*timings.CacheN, 10))
+
+	if usage["prompt_tokens_details"], err = json.Marshal(details); err != nil {
+		return nil
+	}
+	if fields["usage"], err = json.Marshal(usage); err != nil {
+		return nil
+	}
+	rewritten, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(rewritten))
+	resp.ContentLength = int64(len(rewritten))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(rewritten)))
+	return nil
+}
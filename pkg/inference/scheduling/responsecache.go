@@ -0,0 +1,244 @@
+package scheduling
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+const (
+	// responseCacheSizeEnv names the environment variable holding the number
+	// of responses the deterministic response cache holds. Caching is
+	// disabled if it is unset or zero.
+	responseCacheSizeEnv = "MODEL_RUNNER_RESPONSE_CACHE_SIZE"
+	// maxCachedResponseSize is the largest response body that is cached.
+	maxCachedResponseSize = 4 << 20
+)
+
+// cachedResponse is a completed response held by the response cache.
+type cachedResponse struct {
+	// key is the cache key of the request.
+	key string
+	// modelID is the ID of the model that generated the response.
+	modelID string
+	// contentType is the response's content type.
+	contentType string
+	// body is the response body.
+	body []byte
+}
+
+// responseCache is an LRU cache of responses to deterministic, non-streaming
+// completion requests.
+type responseCache struct {
+	// size is the maximum number of cached responses.
+	size int
+	// lock guards the fields below.
+	lock sync.Mutex
+	// order holds the cached responses, most recently used first.
+	order *list.List
+	// entries indexes the elements of order by cache key.
+	entries map[string]*list.Element
+}
+
+// newResponseCache creates a response cache holding up to size responses.
+func newResponseCache(size int) *responseCache {
+	return &responseCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// responseCacheFromEnv returns the response cache configured in the
+// environment, or nil if caching is disabled.
+func responseCacheFromEnv(log logging.Logger) *responseCache {
+	raw := os.Getenv(responseCacheSizeEnv)
+	if raw == "" {
+		return nil
+	}
+	size, err := strconv.Atoi(raw)
+	if err != nil || size < 0 {
+		log.Warnf("Ignoring invalid %s value %q", responseCacheSizeEnv, utils.SanitizeForLog(raw, -1))
+		return nil
+	}
+	if size == 0 {
+		return nil
+	}
+	return newResponseCache(size)
+}
+
+// get returns the cached response for key, if any.
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cachedResponse), true
+}
+
+// put caches a response, evicting the least recently used response if the
+// cache is full.
+func (c *responseCache) put(response *cachedResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.entries[response.key]; ok {
+		element.Value = response
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[response.key] = c.order.PushFront(response)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// purgeModel removes the cached responses generated by a model, whose
+// configuration may have changed.
+func (c *responseCache) purgeModel(modelID string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if response := element.Value.(*cachedResponse); response.modelID == modelID {
+			c.order.Remove(element)
+			delete(c.entries, response.key)
+		}
+		element = next
+	}
+}
+
+// responseCacheKey returns the cache key for a request to path on backend
+// for modelID, or false if the response to the request can't be cached. Only
+// non-streaming requests that sample deterministically, with a temperature
+// of zero or a fixed seed, and use no tools or images are cached. The key
+// hashes the request body with its fields in canonical order.
+func responseCacheKey(backend, modelID, path string, body []byte) (string, bool) {
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return "", false
+	}
+	if stream, _ := fields["stream"].(bool); stream {
+		return "", false
+	}
+	temperature, hasTemperature := fields["temperature"].(float64)
+	_, hasSeed := fields["seed"].(float64)
+	if !(hasTemperature && temperature == 0) && !hasSeed {
+		return "", false
+	}
+	for _, name := range []string{"tools", "functions"} {
+		if tools, ok := fields[name].([]any); ok && len(tools) > 0 {
+			return "", false
+		}
+	}
+	if hasImages(fields["messages"]) {
+		return "", false
+	}
+
+	// Marshaling a map orders its keys, normalizing the request body.
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return "", false
+	}
+	hash := sha256.New()
+	for _, part := range []string{backend, modelID, path} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(normalized)
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// hasImages reports whether any of the messages of a chat request has image
+// content, in either the OpenAI or the Anthropic format.
+func hasImages(messages any) bool {
+	list, _ := messages.([]any)
+	for _, message := range list {
+		message, _ := message.(map[string]any)
+		parts, _ := message["content"].([]any)
+		for _, part := range parts {
+			part, _ := part.(map[string]any)
+			switch part["type"] {
+			case "image_url", "input_image", "image":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cachingWriter forwards a backend response while capturing it for the
+// response cache.
+type cachingWriter struct {
+	http.ResponseWriter
+	// status is the response status.
+	status int
+	// body holds the response body written so far.
+	body []byte
+	// overflowed indicates that the body exceeded maxCachedResponseSize.
+	overflowed bool
+}
+
+func newCachingWriter(w http.ResponseWriter) *cachingWriter {
+	return &cachingWriter{ResponseWriter: w}
+}
+
+func (w *cachingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cachingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.overflowed {
+		if len(w.body)+len(p) > maxCachedResponseSize {
+			w.overflowed, w.body = true, nil
+		} else {
+			w.body = append(w.body, p...)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *cachingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *cachingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// response returns the captured response for caching under key, or false if
+// it isn't a complete, successful response.
+func (w *cachingWriter) response(key, modelID string) (*cachedResponse, bool) {
+	if w.status != http.StatusOK || w.overflowed || len(w.body) == 0 {
+		return nil, false
+	}
+	return &cachedResponse{
+		key:         key,
+		modelID:     modelID,
+		contentType: w.Header().Get("Content-Type"),
+		body:        w.body,
+	}, true
+}
@@ -12,18 +12,29 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/backends"
+	"github.com/docker/model-runner/pkg/inference/backends/llamacpp"
 	"github.com/docker/model-runner/pkg/internal/utils"
 	"github.com/docker/model-runner/pkg/logging"
 	"github.com/docker/model-runner/pkg/metrics"
+	"github.com/docker/model-runner/pkg/tailbuffer"
 )
 
 const (
-	// maximumReadinessPings is the maximum number of retries that a runner will
-	// perform when pinging a backend for readiness.
-	maximumReadinessPings = 600
+	// defaultBackendStartTimeout is the default maximum amount of time that a
+	// runner waits for its backend to become ready.
+	defaultBackendStartTimeout = 5 * time.Minute
+	// backendStartTimeoutEnv names the environment variable overriding
+	// defaultBackendStartTimeout.
+	backendStartTimeoutEnv = "MODEL_RUNNER_BACKEND_START_TIMEOUT"
+	// startupOutputSize is the number of bytes of a backend's error output
+	// retained while it starts.
+	startupOutputSize = 4096
 	// readinessRetryInterval is the interval at which a runner will retry
 	// readiness checks for a backend.
 	readinessRetryInterval = 500 * time.Millisecond
@@ -33,9 +44,34 @@ const (
 	tcpBackendBasePort = 30000
 )
 
-// errBackendNotReadyInTime indicates that an inference backend took too
-// long to initialize and respond to a readiness request.
-var errBackendNotReadyInTime = errors.New("inference backend took too long to initialize")
+// ErrBackendStartTimeout indicates that an inference backend took too long to
+// initialize and respond to a readiness request.
+var ErrBackendStartTimeout = errors.New("inference backend took too long to initialize")
+
+// BackendStartTimeoutError describes a backend that didn't become ready in
+// time. It matches ErrBackendStartTimeout under errors.Is.
+type BackendStartTimeoutError struct {
+	// Backend is the name of the backend.
+	Backend string
+	// Model is the ID of the model being loaded.
+	Model string
+	// Waited is how long the runner waited for the backend.
+	Waited time.Duration
+	// Output is the tail of the backend's error output, if any.
+	Output string
+}
+
+func (e *BackendStartTimeoutError) Error() string {
+	msg := fmt.Sprintf("%s backend did not become ready within %s", e.Backend, e.Waited.Round(time.Second))
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *BackendStartTimeoutError) Unwrap() error {
+	return ErrBackendStartTimeout
+}
 
 // errBackendQuitUnexpectedly indicates that an inference backend terminated
 // unexpectedly
@@ -74,6 +110,16 @@ type runner struct {
 	openAIRecorder *metrics.OpenAIRecorder
 	// err is the error returned by the runner's backend, only valid after done is closed.
 	err error
+	// pid is the backend process ID, or 0 if it is not known.
+	pid atomic.Int64
+	// rss is the backend process's resident memory in bytes when it was last
+	// sampled, or 0 if it hasn't been.
+	rss atomic.Uint64
+	// promptSlots pins requests sharing a prompt prefix to a slot. It is nil
+	// for backends without KV cache slots.
+	promptSlots *promptSlots
+	// output holds the tail of the backend's error output.
+	output io.ReadWriter
 }
 
 // run creates a new runner instance.
@@ -137,7 +183,7 @@ func run(
 		// CORS headers are set by the CorsMiddleware from pkg/inference/cors.go,
 		// so we remove them here to avoid duplication and potential misconfiguration.
 		resp.Header.Del("Access-Control-Allow-Origin")
-		return nil
+		return annotateCachedTokens(resp)
 	}
 	proxy.Transport = transport
 	proxyLog := log.Writer()
@@ -160,31 +206,41 @@ func run(
 		proxy:          proxy,
 		proxyLog:       proxyLog,
 		openAIRecorder: openAIRecorder,
+		output:         tailbuffer.NewTailBuffer(startupOutputSize),
+	}
+	if backend.Name() == llamacpp.Name && mode == inference.BackendModeCompletion {
+		r.promptSlots = &promptSlots{}
 	}
 
 	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
 		// If the error is EOF, the underlying runner likely bailed, and closed its socket
 		// unexpectedly. Wait for the runner process to complete, but time out in case
-		// the runner process only killed its comms and is stuck.
+		// the runner process only killed its comms and is stuck. For other errors, only
+		// report a crash if the runner process has already exited.
+		wait := time.Duration(0)
 		if errors.Is(err, io.EOF) {
+			wait = 30 * time.Second
+		}
+		if r.waitExited(wait) && r.err != nil {
+			res := OpenAIErrorResponse{
+				Type:           "error",
+				Code:           nil,
+				Message:        r.err.Error(),
+				Param:          nil,
+				SequenceNumber: 1,
+			}
+			errJson, err := json.Marshal(&res)
+			if err == nil {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			}
 			w.WriteHeader(http.StatusInternalServerError)
-			select {
-			case <-r.done:
-				res := OpenAIErrorResponse{
-					Type:           "error",
-					Code:           nil,
-					Message:        r.err.Error(),
-					Param:          nil,
-					SequenceNumber: 1,
-				}
-				errJson, err := json.Marshal(&res)
-				if err == nil {
-					w.Header().Set("Content-Type", "application/json; charset=utf-8")
-					_, _ = w.Write(errJson)
-				}
-				return
-			case <-time.After(30 * time.Second):
+			if err == nil {
+				_, _ = w.Write(errJson)
 			}
+			return
+		}
+		if errors.Is(err, io.EOF) {
+			w.WriteHeader(http.StatusInternalServerError)
 		} else {
 			w.WriteHeader(http.StatusBadGateway)
 		}
@@ -197,7 +253,12 @@ func run(
 
 	// Start the backend run loop.
 	go func() {
-		if err := backend.Run(runCtx, socket, modelID, modelRef, mode, runnerConfig); err != nil {
+		observedCtx := backends.WithProcessObserver(runCtx, func(pid int) {
+			r.pid.Store(int64(pid))
+		})
+		observedCtx = backends.WithErrorOutput(observedCtx, r.output)
+		observedCtx = backends.WithEnvironment(observedCtx, runnerConfig.Env)
+		if err := backend.Run(observedCtx, socket, modelID, modelRef, mode, runnerConfig); err != nil {
 			log.Warnf("Backend %s running model %s exited with error: %v",
 				backend.Name(), utils.SanitizeForLog(modelRef), err,
 			)
@@ -210,10 +271,16 @@ func run(
 	return r, nil
 }
 
-// wait waits for the runner to be ready.
-func (r *runner) wait(ctx context.Context) error {
+// wait waits up to timeout for the runner to be ready.
+func (r *runner) wait(ctx context.Context, timeout time.Duration) error {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	// Bound readiness requests by the deadline, in case the backend accepts
+	// connections but never responds.
+	requestCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
 	// Loop and poll for readiness.
-	for p := 0; p < maximumReadinessPings; p++ {
+	for {
 		select {
 		case <-r.done:
 			if r.err == nil {
@@ -224,34 +291,69 @@ func (r *runner) wait(ctx context.Context) error {
 		}
 		// Create and execute a request targeting the health endpoint.
 		// Note: /health returns 503 during model loading, 200 when ready.
-		readyRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/health", http.NoBody)
+		readyRequest, err := http.NewRequestWithContext(requestCtx, http.MethodGet, "http://localhost/health", http.NoBody)
 		if err != nil {
 			return fmt.Errorf("readiness request creation failed: %w", err)
 		}
 		response, err := r.client.Do(readyRequest)
 		if err == nil {
 			response.Body.Close()
+			if response.StatusCode == http.StatusOK {
+				// The backend responded successfully.
+				return nil
+			}
 		}
 
-		// If the request failed, then wait (if appropriate) and try again.
-		if err != nil || response.StatusCode != http.StatusOK {
-			if p < (maximumReadinessPings - 1) {
-				select {
-				case <-time.After(readinessRetryInterval):
-					continue
-				case <-ctx.Done():
-					return context.Canceled
-				}
-			}
+		// The backend isn't ready yet, so wait (if appropriate) and try again.
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
 			break
 		}
-
-		// The backend responded successfully.
-		return nil
+		select {
+		case <-time.After(min(readinessRetryInterval, remaining)):
+		case <-ctx.Done():
+			return context.Canceled
+		}
 	}
 
 	// The backend did not initialize and respond in time.
-	return errBackendNotReadyInTime
+	return &BackendStartTimeoutError{
+		Backend: r.backend.Name(),
+		Model:   r.model,
+		Waited:  time.Since(start),
+		Output:  r.outputTail(),
+	}
+}
+
+// outputTail returns the retained tail of the backend's error output.
+func (r *runner) outputTail() string {
+	output, err := io.ReadAll(r.output)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// waitExited waits up to timeout for the runner's backend run loop to exit
+// and reports whether it has.
+func (r *runner) waitExited(timeout time.Duration) bool {
+	if timeout <= 0 {
+		select {
+		case <-r.done:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case <-r.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
 }
 
 // terminate stops the runner instance and waits for it to unload from memory.
@@ -276,6 +378,21 @@ func (r *runner) terminate() {
 	}
 }
 
+// sampleRSS records the backend process's resident memory, keeping the last
+// sample if the process can't be read. Sampling may run a subprocess, so it
+// must not be called with the loader lock held.
+func (r *runner) sampleRSS() {
+	select {
+	case <-r.done:
+		// The process has exited and its ID may have been reused.
+		return
+	default:
+	}
+	if rss := metrics.ProcessRSS(int(r.pid.Load())); rss > 0 {
+		r.rss.Store(rss)
+	}
+}
+
 // ServeHTTP implements net/http.Handler.ServeHTTP. It forwards requests to the
 // backend's HTTP server.
 func (r *runner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -5,17 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
+	"runtime"
 	"slices"
 	"time"
 
 	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/backends/diffusers"
 	"github.com/docker/model-runner/pkg/inference/backends/llamacpp"
 	"github.com/docker/model-runner/pkg/inference/backends/mlx"
 	"github.com/docker/model-runner/pkg/inference/backends/sglang"
 	"github.com/docker/model-runner/pkg/inference/backends/vllm"
 	"github.com/docker/model-runner/pkg/inference/backends/vllmmetal"
 	"github.com/docker/model-runner/pkg/inference/models"
+	"github.com/docker/model-runner/pkg/inference/platform"
 	"github.com/docker/model-runner/pkg/internal/utils"
 	"github.com/docker/model-runner/pkg/logging"
 	"github.com/docker/model-runner/pkg/metrics"
@@ -42,6 +46,22 @@ type Scheduler struct {
 	tracker *metrics.Tracker
 	// openAIRecorder is used to record OpenAI API inference requests and responses.
 	openAIRecorder *metrics.OpenAIRecorder
+	// limits are the server-wide limits on inference requests.
+	limits requestLimits
+	// responseCache caches responses to deterministic completions. It is nil
+	// if response caching is disabled.
+	responseCache *responseCache
+	// sseHeartbeatInterval is how long an event stream may be idle before a
+	// heartbeat comment is sent. Zero disables heartbeats.
+	sseHeartbeatInterval time.Duration
+	// usage records the token usage of inference requests. It is nil if
+	// usage accounting is disabled.
+	usage *usageLedger
+	// coalescer shares backend calls between identical in-flight requests.
+	coalescer *coalescer
+	// coalesceRequests indicates that requests are coalesced unless they opt
+	// out.
+	coalesceRequests bool
 }
 
 // NewScheduler creates a new inference scheduler.
@@ -65,6 +85,13 @@ func NewScheduler(
 		loader:         newLoader(log, backends, modelManager, openAIRecorder),
 		tracker:        tracker,
 		openAIRecorder: openAIRecorder,
+		limits:         requestLimitsFromEnv(log),
+		responseCache:  responseCacheFromEnv(log),
+
+		sseHeartbeatInterval: sseHeartbeatIntervalFromEnv(log),
+		usage:                usageLedgerFromEnv(log),
+		coalescer:            newCoalescer(),
+		coalesceRequests:     coalesceRequestsFromEnv(log),
 	}
 
 	// Scheduler successfully initialized.
@@ -90,43 +117,136 @@ func (s *Scheduler) Run(ctx context.Context) error {
 	})
 
 	// Wait for all workers to exit.
-	return workers.Wait()
+	err := workers.Wait()
+	s.usage.close()
+	return err
 }
 
-// selectBackendForModel selects the appropriate backend for a model based on its format.
-// If the model is in safetensors format, it will prefer the best available backend:
+// waitUntilRunning blocks until the installer and loader have started and
+// loads are enabled, or until ctx is cancelled.
+func (s *Scheduler) waitUntilRunning(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if s.installer.started.Load() && s.loader.lock(ctx) {
+			enabled := s.loader.loadsEnabled
+			s.loader.unlock()
+			if enabled {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// safetensorsBackend is a backend that serves safetensors models.
+type safetensorsBackend struct {
+	// name is the backend's name.
+	name string
+	// supported reports whether the backend is supported on this platform.
+	supported func() bool
+}
+
+// safetensorsBackends lists the backends that serve safetensors models, in
+// order of preference, along with whether each is supported on this platform:
 // - On macOS: vllm-metal > MLX
 // - On Linux: vLLM > SGLang
-func (s *Scheduler) selectBackendForModel(model types.Model, backend inference.Backend, modelRef string) inference.Backend {
+var safetensorsBackends = []safetensorsBackend{
+	{vllmmetal.Name, platform.SupportsVLLMMetal},
+	{mlx.Name, platform.SupportsMLX},
+	{vllm.Name, platform.SupportsVLLM},
+	{sglang.Name, platform.SupportsSGLang},
+}
+
+// selectBackendForModel selects the appropriate backend for a model based on its format.
+// If the model is in safetensors format, it will prefer the best available
+// backend listed in safetensorsBackends that is supported on this platform.
+// It returns an error wrapping ErrFormatUnsupportedOnPlatform if no backend
+// can run the model's format on this platform.
+func (s *Scheduler) selectBackendForModel(model types.Model, backend inference.Backend, modelRef string) (inference.Backend, error) {
 	config, err := model.Config()
 	if err != nil {
 		s.log.Warnln("failed to fetch model config:", err)
-		return backend
+		return backend, nil
 	}
 
-	if config.GetFormat() == types.FormatSafetensors {
-		// Prefer vllm-metal for safetensors models on macOS (most feature-rich for Metal)
-		if vllmMetalBackend, ok := s.backends[vllmmetal.Name]; ok && vllmMetalBackend != nil {
-			return vllmMetalBackend
-		}
-		// Fall back to MLX on macOS
-		if mlxBackend, ok := s.backends[mlx.Name]; ok && mlxBackend != nil {
-			return mlxBackend
-		}
-		// Prefer vLLM for safetensors models on Linux
-		if vllmBackend, ok := s.backends[vllm.Name]; ok && vllmBackend != nil {
-			return vllmBackend
-		}
-		// Fall back to SGLang if vLLM is not available
-		if sglangBackend, ok := s.backends[sglang.Name]; ok && sglangBackend != nil {
-			return sglangBackend
+	format := config.GetFormat()
+	if err := checkFormatSupported(format); err != nil {
+		return nil, err
+	}
+	if format == types.FormatSafetensors {
+		for _, candidate := range safetensorsBackends {
+			if b, ok := s.backends[candidate.name]; ok && b != nil && candidate.supported() {
+				return b, nil
+			}
 		}
 		s.log.Warnf("Model %s is in safetensors format but no compatible backend is available. "+
 			"Backend %s may not support this format and could fail at runtime.",
 			utils.SanitizeForLog(modelRef), backend.Name())
 	}
 
-	return backend
+	return backend, nil
+}
+
+// checkFormatSupported returns an error wrapping
+// ErrFormatUnsupportedOnPlatform if no backend can run models of format on
+// this platform.
+func checkFormatSupported(format types.Format) error {
+	supported := true
+	switch format {
+	case types.FormatSafetensors:
+		supported = slices.ContainsFunc(safetensorsBackends, func(candidate safetensorsBackend) bool {
+			return candidate.supported()
+		})
+	case types.FormatDiffusers:
+		supported = platform.SupportsDiffusers()
+	}
+	if !supported {
+		return fmt.Errorf("%w: %s models can't be run on %s/%s", ErrFormatUnsupportedOnPlatform, format, runtime.GOOS, runtime.GOARCH)
+	}
+	return nil
+}
+
+// CheckModelSupported returns an error wrapping
+// ErrFormatUnsupportedOnPlatform if the local model can't be run on this
+// platform. Models that aren't available locally aren't checked.
+func (s *Scheduler) CheckModelSupported(modelRef string) error {
+	model, err := s.modelManager.GetLocal(modelRef)
+	if err != nil {
+		return nil
+	}
+	config, err := model.Config()
+	if err != nil {
+		return nil
+	}
+	return checkFormatSupported(config.GetFormat())
+}
+
+// BackendForFormat returns the name of the backend that would serve models
+// of the given format and whether that backend is supported on this
+// platform. The name is empty if no configured backend serves the format.
+func (s *Scheduler) BackendForFormat(format types.Format) (string, bool) {
+	switch format {
+	case types.FormatGGUF:
+		if s.defaultBackend != nil {
+			return s.defaultBackend.Name(), true
+		}
+	case types.FormatSafetensors:
+		for _, candidate := range safetensorsBackends {
+			if b, ok := s.backends[candidate.name]; ok && b != nil && candidate.supported() {
+				return b.Name(), true
+			}
+		}
+	case types.FormatDiffusers:
+		if b, ok := s.backends[diffusers.Name]; ok && b != nil {
+			return b.Name(), platform.SupportsDiffusers()
+		}
+	}
+	return "", false
 }
 
 // ResetInstaller resets the backend installer with a new HTTP client.
@@ -139,6 +259,24 @@ func (s *Scheduler) GetRunningBackendsInfo(ctx context.Context) []BackendStatus
 	return s.getLoaderStatus(ctx)
 }
 
+// LoadedModels returns the IDs of the models loaded by runners, including
+// their draft models.
+func (s *Scheduler) LoadedModels() []string {
+	if !s.loader.lock(context.Background()) {
+		return nil
+	}
+	defer s.loader.unlock()
+
+	var loaded []string
+	for key := range s.loader.runners {
+		loaded = append(loaded, key.modelID)
+		if key.draftModelID != "" {
+			loaded = append(loaded, key.draftModelID)
+		}
+	}
+	return loaded
+}
+
 // getLoaderStatus returns information about all running backends managed by the loader
 func (s *Scheduler) getLoaderStatus(ctx context.Context) []BackendStatus {
 	if !s.loader.lock(ctx) {
@@ -169,6 +307,12 @@ func (s *Scheduler) getLoaderStatus(ctx context.Context) []BackendStatus {
 	return result
 }
 
+// GetBackendCrashes returns the number of backend process crashes observed for
+// each backend.
+func (s *Scheduler) GetBackendCrashes() map[string]uint64 {
+	return s.loader.crashCounts(context.Background())
+}
+
 // GetAllActiveRunners returns information about all active runners
 func (s *Scheduler) GetAllActiveRunners() []metrics.ActiveRunner {
 	runningBackends := s.getLoaderStatus(context.Background())
@@ -199,6 +343,7 @@ func (s *Scheduler) GetAllActiveRunners() []metrics.ActiveRunner {
 					ModelName:   backend.ModelName,
 					Mode:        backend.Mode,
 					Socket:      socket,
+					PID:         s.loader.runnerPID(runnerInfo.slot),
 				})
 				break // Found the runner, no need to continue iterating
 			}
@@ -238,6 +383,24 @@ func (s *Scheduler) GetLlamaCppSocket() (string, error) {
 	return "", errors.New("no active llama.cpp backend found")
 }
 
+// ReloadBackend unloads the runners of backend, once their in-flight requests
+// finish, and installs the backend again so that subsequent loads use any
+// updated binary. Requests for the backend wait for the reload to complete.
+// It returns the references of the unloaded models.
+func (s *Scheduler) ReloadBackend(ctx context.Context, backend inference.Backend) ([]string, error) {
+	var unloaded []string
+	err := s.installer.reinstall(ctx, backend.Name(), func() error {
+		var err error
+		unloaded, err = s.loader.drainBackend(ctx, backend.Name(), defaultUnloadWaitTimeout)
+		return err
+	})
+	if err != nil {
+		return unloaded, err
+	}
+	s.log.Infof("Reloaded %s backend, unloading %d runner(s)", backend.Name(), len(unloaded))
+	return unloaded, nil
+}
+
 // ConfigureRunner configures a runner for a specific model and backend.
 // It handles all the business logic of configuration including parsing flags,
 // determining mode, selecting backend, and setting runner configuration.
@@ -246,6 +409,26 @@ func (s *Scheduler) ConfigureRunner(ctx context.Context, backend inference.Backe
 		backend = s.defaultBackend
 	}
 
+	var keepAlive time.Duration
+	if req.KeepAlive != nil && *req.KeepAlive != "" {
+		var err error
+		keepAlive, err = time.ParseDuration(*req.KeepAlive)
+		if err != nil {
+			return nil, fmt.Errorf("invalid keep-alive: %w", err)
+		}
+	}
+
+	if slices.Contains(req.DefaultStop, "") {
+		return nil, errors.New("invalid default stop: empty stop sequence")
+	}
+	if !validSystemPromptPolicy(req.SystemPromptPolicy) {
+		return nil, fmt.Errorf("invalid system prompt policy %q: must be %s or %s",
+			utils.SanitizeForLog(string(req.SystemPromptPolicy), -1), SystemPromptIfMissing, SystemPromptMerge)
+	}
+	if req.SystemPromptPolicy != "" && req.DefaultSystemPrompt == nil {
+		return nil, errors.New("a system prompt policy requires a default system prompt")
+	}
+
 	// Parse runtime flags from either array or raw string
 	var runtimeFlags []string
 	if len(req.RuntimeFlags) > 0 {
@@ -263,11 +446,27 @@ func (s *Scheduler) ConfigureRunner(ctx context.Context, backend inference.Backe
 		return nil, err
 	}
 
+	// Validate the backend environment against the allowlist
+	if err := inference.ValidateBackendEnv(req.Env); err != nil {
+		return nil, err
+	}
+
 	// Build runner configuration with shared settings
 	var runnerConfig inference.BackendConfiguration
 	runnerConfig.ContextSize = req.ContextSize
 	runnerConfig.Speculative = req.Speculative
 	runnerConfig.RuntimeFlags = runtimeFlags
+	runnerConfig.Env = req.Env
+
+	// Enforce the operator's maximum context size.
+	clamped, err := s.loader.contextSizeCap.apply(&runnerConfig)
+	if err != nil {
+		return nil, err
+	}
+	if clamped {
+		s.log.Warnf("Clamping context size configured for %s to %d", utils.SanitizeForLog(req.Model, -1), s.loader.contextSizeCap.max)
+		notifyClamped(ctx, s.loader.contextSizeCap.max)
+	}
 
 	// Set vLLM-specific configuration if provided
 	if req.VLLM != nil {
@@ -304,17 +503,101 @@ func (s *Scheduler) ConfigureRunner(ctx context.Context, backend inference.Backe
 		s.tracker.TrackModel(model, userAgent, "configure/"+mode.String())
 
 		// Automatically identify models for vLLM
-		backend = s.selectBackendForModel(model, backend, req.Model)
+		if backend, err = s.selectBackendForModel(model, backend, req.Model); err != nil {
+			return nil, err
+		}
 	}
 
 	// Resolve model ID
 	modelID := s.modelManager.ResolveID(req.Model)
+	if req.ResetBreaker {
+		s.loader.resetBreaker(ctx, modelID)
+	}
+
+	// Keep-alives, default stop sequences and system prompts apply to the
+	// model's runners without reconfiguring them, so a request that only sets
+	// them leaves any loaded runner in place.
+	if req.KeepAlive != nil && *req.KeepAlive == "" {
+		s.loader.clearKeepAlive(ctx, backend.Name(), modelID, mode)
+	} else if req.KeepAlive != nil {
+		s.loader.setKeepAlive(ctx, backend.Name(), modelID, mode, keepAlive)
+	}
+	if req.DefaultStop != nil {
+		s.loader.setDefaultStop(backend.Name(), modelID, mode, req.DefaultStop)
+		// Responses generated with the previous stop sequences may differ.
+		s.responseCache.purgeModel(modelID)
+	}
+	if req.DefaultSystemPrompt != nil {
+		policy := req.SystemPromptPolicy
+		if policy == "" {
+			policy = SystemPromptIfMissing
+		}
+		s.loader.setDefaultSystemPrompt(backend.Name(), modelID, mode, defaultSystemPrompt{
+			prompt: *req.DefaultSystemPrompt,
+			policy: policy,
+		})
+		s.responseCache.purgeModel(modelID)
+	}
+	if (req.KeepAlive != nil || req.DefaultStop != nil || req.DefaultSystemPrompt != nil) &&
+		req.RawRuntimeFlags == "" && reflect.DeepEqual(req.BackendConfiguration, inference.BackendConfiguration{}) {
+		return backend, nil
+	}
 
 	// Set the runner configuration
 	if err := s.loader.setRunnerConfig(ctx, backend.Name(), modelID, mode, runnerConfig); err != nil {
 		s.log.Warnf("Failed to configure %s runner for %s (%s): %s", backend.Name(), utils.SanitizeForLog(req.Model, -1), modelID, err)
 		return nil, err
 	}
+	// Responses generated under the previous configuration may differ.
+	s.responseCache.purgeModel(modelID)
 
 	return backend, nil
 }
+
+// LoaderSettings returns the effective configuration of the runner loader.
+func (s *Scheduler) LoaderSettings() LoaderSettings {
+	settings := LoaderSettings{
+		IdleTimeout:         s.loader.runnerIdleTimeout,
+		BackendStartTimeout: s.loader.backendStartTimeout,
+		DefaultContextSize:  s.loader.defaultContextSize,
+	}
+	if s.loader.contextSizeCap != nil {
+		maxSize := s.loader.contextSizeCap.max
+		settings.MaxContextSize = &maxSize
+	}
+	return settings
+}
+
+// RunnerConfigs returns the runner configurations set for model, or for every
+// model if model is empty.
+func (s *Scheduler) RunnerConfigs(ctx context.Context, model string) []ModelConfigEntry {
+	configs := s.loader.getAllRunnerConfigs(ctx)
+	if model == "" {
+		return configs
+	}
+	modelID := s.modelManager.ResolveID(model)
+	filtered := configs[:0]
+	for _, entry := range configs {
+		if entry.ModelID == modelID {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// ResetRunnerConfigs restores the default configuration of model, or of every
+// model if model is empty, returning the number of configurations removed.
+func (s *Scheduler) ResetRunnerConfigs(ctx context.Context, model string) int {
+	modelID := ""
+	if model != "" {
+		modelID = s.modelManager.ResolveID(model)
+	}
+	removed := s.loader.resetRunnerConfigs(ctx, modelID)
+	for _, key := range removed {
+		s.responseCache.purgeModel(key.modelID)
+	}
+	if len(removed) > 0 {
+		s.log.Infof("Reset %d runner configuration(s)", len(removed))
+	}
+	return len(removed)
+}
@@ -1,11 +1,28 @@
 package scheduling
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/docker/model-runner/pkg/distribution/types"
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/backends/llamacpp"
+	"github.com/docker/model-runner/pkg/inference/backends/mlx"
+	"github.com/docker/model-runner/pkg/inference/backends/vllm"
+	"github.com/docker/model-runner/pkg/inference/models"
+	"github.com/docker/model-runner/pkg/inference/platform"
 	"github.com/sirupsen/logrus"
 )
 
@@ -46,3 +63,1309 @@ func TestCors(t *testing.T) {
 		})
 	}
 }
+
+func TestCancelRequest(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	s := NewScheduler(log, nil, nil, nil, nil, nil)
+	httpHandler := NewHTTPHandler(s, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	httpHandler.trackInflight("model_1", cancel)
+
+	cancelReq := func(id string) int {
+		req := httptest.NewRequest(http.MethodDelete, "http://model-runner.docker.internal/engines/requests/"+id, http.NoBody)
+		w := httptest.NewRecorder()
+		httpHandler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := cancelReq("unknown"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown request, got %d", code)
+	}
+	if code := cancelReq("model_1"); code != http.StatusOK {
+		t.Fatalf("Expected 200 for in-flight request, got %d", code)
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected in-flight request context to be cancelled")
+	}
+
+	httpHandler.untrackInflight("model_1")
+	if code := cancelReq("model_1"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 after request completed, got %d", code)
+	}
+}
+
+func TestBatchRejectsInvalidRequests(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	backend := &mockBackend{name: "mock"}
+	s := NewScheduler(log, map[string]inference.Backend{"mock": backend}, backend, nil, nil, nil)
+	httpHandler := NewHTTPHandler(s, nil, nil)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"unknown backend", "/engines/missing/v1/batch", `{"model":"m","requests":[{}]}`, http.StatusNotFound},
+		{"invalid body", "/engines/v1/batch", `[`, http.StatusBadRequest},
+		{"missing model", "/engines/v1/batch", `{"requests":[{}]}`, http.StatusBadRequest},
+		{"no requests", "/engines/v1/batch", `{"model":"m","requests":[]}`, http.StatusBadRequest},
+		{"non-object request", "/engines/mock/v1/batch", `{"model":"m","requests":[{},"hi"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal"+tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			httpHandler.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWarmupRejectsInvalidRequests(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	backend := &mockBackend{name: "mock"}
+	s := NewScheduler(log, map[string]inference.Backend{"mock": backend}, backend, nil, nil, nil)
+	httpHandler := NewHTTPHandler(s, nil, nil)
+
+	for name, body := range map[string]string{
+		"invalid body": `[`,
+		"no models":    `{"models":[]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal/engines/warmup", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			httpHandler.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestConfigureRejectsInvalidEnv(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	backend := &mockBackend{name: "mock"}
+	s := NewScheduler(log, map[string]inference.Backend{"mock": backend}, backend, nil, nil, nil)
+	httpHandler := NewHTTPHandler(s, nil, nil)
+
+	for name, env := range map[string]string{
+		"unknown variable": `{"LD_PRELOAD":"evil.so"}`,
+		"path value":       `{"OMP_NUM_THREADS":"/etc/passwd"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			body := `{"model":"ai/model","env":` + env + `}`
+			req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal/engines/_configure", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			httpHandler.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), "environment variable") {
+				t.Errorf("Expected the validation error in the response, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenderTemplateRejectsInvalidRequests(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	backend := &mockBackend{name: "mock"}
+	s := NewScheduler(log, map[string]inference.Backend{"mock": backend}, backend, nil, nil, nil)
+	httpHandler := NewHTTPHandler(s, nil, nil)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"invalid body", "/engines/_render-template", `[`},
+		{"no messages", "/engines/_render-template", `{"model":"m"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal"+tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			httpHandler.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHeartbeatWriter(t *testing.T) {
+	serve := func(contentType string) string {
+		rec := httptest.NewRecorder()
+		w := newHeartbeatWriter(rec, 5*time.Millisecond)
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("data: {\"a\":1}\n\n"))
+		time.Sleep(30 * time.Millisecond)
+		// No heartbeat may split an event being written.
+		_, _ = w.Write([]byte("data: {\"b\":"))
+		time.Sleep(30 * time.Millisecond)
+		_, _ = w.Write([]byte("2}\n\n"))
+		w.stop()
+		return rec.Body.String()
+	}
+
+	body := serve("text/event-stream")
+	prefix := "data: {\"a\":1}\n\n: keepalive\n\n"
+	if !strings.HasPrefix(body, prefix) {
+		t.Fatalf("Expected a heartbeat after the first event, got %q", body)
+	}
+	if events := strings.ReplaceAll(body, ": keepalive\n\n", ""); events != "data: {\"a\":1}\n\ndata: {\"b\":2}\n\n" {
+		t.Errorf("Expected the events intact, got %q", body)
+	}
+
+	if body := serve("application/json"); strings.Contains(body, "keepalive") {
+		t.Errorf("Expected no heartbeats outside event streams, got %q", body)
+	}
+}
+
+func TestUsageWriter(t *testing.T) {
+	serve := func(contentType string, chunks ...string) (tokenCounts, bool) {
+		w := newUsageWriter(httptest.NewRecorder())
+		w.Header().Set("Content-Type", contentType)
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte(chunk))
+		}
+		return w.tokens()
+	}
+
+	if tokens, ok := serve("application/json", `{"choices":[],"usage":{"prompt_tokens":12,`, `"completion_tokens":5}}`); !ok || tokens != (tokenCounts{12, 5}) {
+		t.Errorf("Expected 12 prompt and 5 completion tokens, got %+v, %t", tokens, ok)
+	}
+	if tokens, ok := serve("text/event-stream", "data: {\"choices\":[]}\n\n: keepalive\n\ndata: {\"usage\":{\"prompt_tokens\":3,",
+		"\"completion_tokens\":9}}\n\ndata: [DONE]\n\n"); !ok || tokens != (tokenCounts{3, 9}) {
+		t.Errorf("Expected 3 prompt and 9 completion tokens, got %+v, %t", tokens, ok)
+	}
+	if tokens, ok := serve("text/event-stream", "event: message_start\ndata: {\"message\":{\"usage\":{\"input_tokens\":7}}}\n\n",
+		"event: message_delta\ndata: {\"usage\":{\"output_tokens\":4}}\n\n"); !ok || tokens != (tokenCounts{7, 4}) {
+		t.Errorf("Expected 7 input and 4 output tokens, got %+v, %t", tokens, ok)
+	}
+	if _, ok := serve("text/event-stream", "data: {\"choices\":[]}\n\n"); ok {
+		t.Error("Expected no usage for a stream that doesn't report it")
+	}
+}
+
+func TestUsageLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.log")
+	ledger := newUsageLedger(logrus.New(), path, time.Hour)
+	now := time.Now()
+	ledger.record(usageRecord{Time: now.Add(-2 * time.Hour), Model: "ai/old", PromptTokens: 100})
+	ledger.record(usageRecord{Time: now.Add(-30 * time.Minute), Model: "ai/smollm2", Tenant: "b", PromptTokens: 10, CompletionTokens: 2})
+	ledger.record(usageRecord{Time: now.Add(-time.Minute), Model: "ai/smollm2", Tenant: "a", PromptTokens: 5, CompletionTokens: 1})
+	ledger.record(usageRecord{Time: now, Model: "ai/smollm2", Tenant: "a", PromptTokens: 3, CompletionTokens: 4})
+	ledger.close()
+
+	want := []TokenUsage{
+		{Model: "ai/smollm2", Tenant: "a", Requests: 2, PromptTokens: 8, CompletionTokens: 5, TotalTokens: 13},
+		{Model: "ai/smollm2", Tenant: "b", Requests: 1, PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+	}
+	// Reloading the log drops the expired record.
+	for _, l := range []*usageLedger{ledger, newUsageLedger(logrus.New(), path, time.Hour)} {
+		report := l.report(time.Time{})
+		if len(report.Usage) != len(want) || report.Usage[0] != want[0] || report.Usage[1] != want[1] {
+			t.Errorf("Expected %+v, got %+v", want, report.Usage)
+		}
+		if report.Since.Before(now.Add(-time.Hour)) {
+			t.Errorf("Expected the report to start within retention, got %v", report.Since)
+		}
+	}
+	if report := ledger.report(now.Add(-10 * time.Minute)); len(report.Usage) != 1 || report.Usage[0].Requests != 2 {
+		t.Errorf("Expected only tenant a's recent requests, got %+v", report.Usage)
+	}
+}
+
+// TestUsageLedgerReportsTruncation tests that a report covering records
+// discarded to stay within maxUsageRecords starts at the oldest one kept.
+func TestUsageLedgerReportsTruncation(t *testing.T) {
+	ledger := newUsageLedger(logrus.New(), "", time.Hour)
+	now := time.Now()
+	ledger.record(usageRecord{Time: now.Add(-30 * time.Minute), Model: "ai/smollm2", PromptTokens: 1})
+	oldestKept := now.Add(-10 * time.Minute)
+	for range maxUsageRecords {
+		ledger.record(usageRecord{Time: oldestKept, Model: "ai/smollm2", PromptTokens: 1})
+	}
+
+	report := ledger.report(now.Add(-time.Hour))
+	if !report.Since.Equal(oldestKept) {
+		t.Errorf("Expected the report to start at %v, got %v", oldestKept, report.Since)
+	}
+	if len(report.Usage) != 1 || report.Usage[0].Requests != maxUsageRecords {
+		t.Errorf("Expected %d requests, got %+v", maxUsageRecords, report.Usage)
+	}
+	if report := ledger.report(now.Add(-time.Minute)); !report.Since.Equal(now.Add(-time.Minute)) {
+		t.Errorf("Expected a report since after truncation to start when requested, got %v", report.Since)
+	}
+}
+
+func TestParseUsageSince(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for raw, want := range map[string]time.Time{
+		"":                     {},
+		"2025-05-31T00:00:00Z": time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		"24h":                  now.Add(-24 * time.Hour),
+	} {
+		if since, err := parseUsageSince(raw, now); err != nil || !since.Equal(want) {
+			t.Errorf("parseUsageSince(%q) = %v, %v, want %v", raw, since, err, want)
+		}
+	}
+	for _, raw := range []string{"yesterday", "-1h"} {
+		if _, err := parseUsageSince(raw, now); err == nil {
+			t.Errorf("Expected an error for %q", raw)
+		}
+	}
+}
+
+func TestBatchItemBody(t *testing.T) {
+	body, err := batchItemBody([]byte(`{"model":"other","stream":true,"messages":[{"role":"user","content":"hi"}]}`), "ai/smollm2")
+	if err != nil {
+		t.Fatalf("batchItemBody failed: %v", err)
+	}
+	want := `{"messages":[{"role":"user","content":"hi"}],"model":"ai/smollm2","stream":false}`
+	if string(body) != want {
+		t.Errorf("Expected %s, got %s", want, body)
+	}
+	if _, err := batchItemBody([]byte(`null`), "ai/smollm2"); err == nil {
+		t.Error("Expected an error for a null request")
+	}
+}
+
+func TestBatchItemHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set(inference.TenantHeader, "team-a")
+	header.Set("User-Agent", "batch-client")
+	header.Set("Connection", "keep-alive, X-Hop")
+	header.Set("X-Hop", "1")
+	header.Set("Keep-Alive", "timeout=5")
+	header.Set("Transfer-Encoding", "chunked")
+	header.Set("Content-Length", "1234")
+
+	itemHeader := batchItemHeader(header)
+	if got := itemHeader.Get(inference.TenantHeader); got != "team-a" {
+		t.Errorf("Expected the tenant header to be copied, got %q", got)
+	}
+	if got := itemHeader.Get("User-Agent"); got != "batch-client" {
+		t.Errorf("Expected the user agent to be copied, got %q", got)
+	}
+	for _, name := range []string{"Connection", "X-Hop", "Keep-Alive", "Transfer-Encoding", "Content-Length"} {
+		if itemHeader.Get(name) != "" {
+			t.Errorf("Expected %s not to be copied", name)
+		}
+	}
+	if header.Get("Connection") == "" {
+		t.Error("Expected the batch request's headers to be left intact")
+	}
+}
+
+func TestParallelSlots(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  int
+	}{
+		{nil, 0},
+		{[]string{"--threads", "8"}, 0},
+		{[]string{"--parallel", "8"}, 8},
+		{[]string{"-np", "2"}, 2},
+		{[]string{"--parallel=6"}, 6},
+		{[]string{"--parallel", "auto"}, 0},
+	}
+	for _, tt := range tests {
+		if got := parallelSlots(tt.flags); got != tt.want {
+			t.Errorf("parallelSlots(%v) = %d, want %d", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestBackendForFormat(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	llama := &mockBackend{name: llamacpp.Name}
+	backends := map[string]inference.Backend{
+		llamacpp.Name: llama,
+		vllm.Name:     &mockBackend{name: vllm.Name},
+		mlx.Name:      &mockBackend{name: mlx.Name},
+	}
+	s := NewScheduler(log, backends, llama, nil, nil, nil)
+
+	if name, supported := s.BackendForFormat(types.FormatGGUF); name != llamacpp.Name || !supported {
+		t.Errorf("Expected gguf to be served by %s, got %q (supported=%v)", llamacpp.Name, name, supported)
+	}
+	wantSafetensors := ""
+	switch {
+	case platform.SupportsMLX():
+		wantSafetensors = mlx.Name
+	case platform.SupportsVLLM():
+		wantSafetensors = vllm.Name
+	}
+	if name, supported := s.BackendForFormat(types.FormatSafetensors); name != wantSafetensors || supported != (wantSafetensors != "") {
+		t.Errorf("Expected safetensors to be served by %q, got %q (supported=%v)", wantSafetensors, name, supported)
+	}
+	if name, supported := s.BackendForFormat(types.FormatDiffusers); name != "" || supported {
+		t.Errorf("Expected no backend for diffusers, got %q (supported=%v)", name, supported)
+	}
+}
+
+func TestCheckFormatSupported(t *testing.T) {
+	if err := checkFormatSupported(types.FormatGGUF); err != nil {
+		t.Errorf("Expected gguf to be supported, got %v", err)
+	}
+	safetensorsSupported := platform.SupportsVLLM() || platform.SupportsMLX()
+	err := checkFormatSupported(types.FormatSafetensors)
+	if safetensorsSupported != (err == nil) {
+		t.Errorf("Expected safetensors support to be %v, got %v", safetensorsSupported, err)
+	}
+	err = checkFormatSupported(types.FormatDiffusers)
+	if platform.SupportsDiffusers() != (err == nil) {
+		t.Errorf("Expected diffusers support to be %v, got %v", platform.SupportsDiffusers(), err)
+	}
+	if err != nil && (!errors.Is(err, ErrFormatUnsupportedOnPlatform) || !strings.Contains(err.Error(), runtime.GOOS)) {
+		t.Errorf("Expected an error naming the platform, got %v", err)
+	}
+}
+
+// installCountingBackend counts how many times it has been installed.
+type installCountingBackend struct {
+	mockBackend
+	installs atomic.Int32
+}
+
+func (b *installCountingBackend) Install(ctx context.Context, httpClient *http.Client) error {
+	b.installs.Add(1)
+	return nil
+}
+
+func TestReloadBackend(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	backend := &installCountingBackend{mockBackend: mockBackend{name: "mock"}}
+	other := &mockBackend{name: "other"}
+	s := NewScheduler(log, map[string]inference.Backend{"mock": backend, "other": other}, backend, nil, nil, nil)
+	s.installer.run(t.Context())
+	s.loader.slots = make([]*runner, 2)
+	s.loader.references = make([]uint, 2)
+	s.loader.timestamps = make([]time.Time, 2)
+
+	for slot, key := range []runnerKey{
+		makeRunnerKey("mock", "model1", "", inference.BackendModeCompletion),
+		makeRunnerKey("other", "model2", "", inference.BackendModeCompletion),
+	} {
+		s.loader.runners[key] = runnerInfo{slot: slot, modelRef: key.modelID + ":latest"}
+		s.loader.slots[slot] = createAliveTerminableMockRunner(t.Context(), log, s.backends[key.backend])
+	}
+
+	unloaded, err := s.ReloadBackend(t.Context(), backend)
+	if err != nil {
+		t.Fatalf("ReloadBackend failed: %v", err)
+	}
+	if len(unloaded) != 1 || unloaded[0] != "model1:latest" {
+		t.Errorf("Expected model1:latest to be unloaded, got %v", unloaded)
+	}
+	if len(s.loader.runners) != 1 {
+		t.Errorf("Expected the other backend's runner to remain loaded, got %d runners", len(s.loader.runners))
+	}
+	if installs := backend.installs.Load(); installs != 2 {
+		t.Errorf("Expected the backend to be installed twice, got %d", installs)
+	}
+	if err := s.installer.wait(t.Context(), "mock"); err != nil {
+		t.Errorf("Expected the reinstalled backend to be ready, got %v", err)
+	}
+}
+
+// blockingInstallBackend installs once released, failing with its context's
+// error if that is cancelled first.
+type blockingInstallBackend struct {
+	mockBackend
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingInstallBackend) Install(ctx context.Context, httpClient *http.Client) error {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestReloadBackendOutlivesRequest(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	backend := &blockingInstallBackend{
+		mockBackend: mockBackend{name: "mock"},
+		started:     make(chan struct{}, 1),
+		release:     make(chan struct{}),
+	}
+	s := NewScheduler(log, map[string]inference.Backend{"mock": backend}, backend, nil, nil, nil)
+	close(backend.release)
+	s.installer.run(t.Context())
+	<-backend.started
+	backend.release = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.ReloadBackend(ctx, backend)
+		errs <- err
+	}()
+	<-backend.started
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected ReloadBackend to stop waiting once cancelled, got %v", err)
+	}
+	if _, err := s.ReloadBackend(t.Context(), backend); !errors.Is(err, ErrReinstallInProgress) {
+		t.Errorf("Expected the abandoned reinstallation to still be in progress, got %v", err)
+	}
+
+	close(backend.release)
+	if err := s.installer.wait(t.Context(), "mock"); err != nil {
+		t.Errorf("Expected the reinstallation to complete despite the cancelled request, got %v", err)
+	}
+}
+
+// logprobsBackend serves chat completions that return token log-probabilities
+// when the request asks for them, recording the request bodies it receives.
+type logprobsBackend struct {
+	mockBackend
+	bodies chan []byte
+}
+
+func (b *logprobsBackend) Run(ctx context.Context, socket, model string, modelRef string, mode inference.BackendMode, config *inference.BackendConfiguration) error {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		b.bodies <- body
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},`+
+			`"logprobs":{"content":[{"token":"Hi","logprob":-0.25,"bytes":[72,105],`+
+			`"top_logprobs":[{"token":"Hi","logprob":-0.25,"bytes":[72,105]},{"token":"Hello","logprob":-1.5,"bytes":[72,101,108,108,111]}]}]},`+
+			`"finish_reason":"stop"}]}`)
+	})}
+	go func() { _ = server.Serve(listener) }()
+	<-ctx.Done()
+	return server.Close()
+}
+
+// newProxyTestHandler returns a scheduler with loads enabled for backend,
+// whose runners are served on sockets in a temporary directory, along with
+// its HTTP handler.
+func newProxyTestHandler(t *testing.T, backend inference.Backend) (*Scheduler, *HTTPHandler) {
+	t.Helper()
+	socketDir := t.TempDir()
+	originalSocketPath := RunnerSocketPath
+	RunnerSocketPath = func(slot int) (string, error) {
+		return filepath.Join(socketDir, fmt.Sprintf("runner-%d.sock", slot)), nil
+	}
+	t.Cleanup(func() { RunnerSocketPath = originalSocketPath })
+
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	log := logrus.NewEntry(discard)
+	manager := models.NewManager(log, models.ClientConfig{StoreRootPath: t.TempDir(), Logger: log})
+	s := NewScheduler(log, map[string]inference.Backend{backend.Name(): backend}, backend, manager, nil, nil)
+	s.installer.run(t.Context())
+	if !s.loader.lock(t.Context()) {
+		t.Fatal("Failed to acquire loader lock to enable loads")
+	}
+	s.loader.loadsEnabled = true
+	s.loader.unlock()
+	t.Cleanup(func() {
+		s.loader.lock(context.Background())
+		s.loader.evict(false, evictReasonShutdown)
+		s.loader.unlock()
+	})
+	return s, NewHTTPHandler(s, nil, nil)
+}
+
+// recordingBackend serves completions with an empty response, recording the
+// request bodies it receives. It reports slots as its llama.cpp slot count,
+// if set.
+type recordingBackend struct {
+	mockBackend
+	bodies chan []byte
+	slots  int
+}
+
+func (b *recordingBackend) Run(ctx context.Context, socket, model string, modelRef string, mode inference.BackendMode, config *inference.BackendConfiguration) error {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/props":
+			if b.slots == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]int{"total_slots": b.slots})
+		case "/v1/chat/completions", "/v1/completions", "/v1/messages":
+			body, _ := io.ReadAll(r.Body)
+			b.bodies <- body
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"choices":[]}`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	})}
+	go func() { _ = server.Serve(listener) }()
+	<-ctx.Done()
+	return server.Close()
+}
+
+// forwardedBody sends body to path through the handler, checks that the
+// request succeeds, and returns the body the backend received.
+func forwardedBody(t *testing.T, handler http.Handler, backend *recordingBackend, path, body string, header http.Header) map[string]json.RawMessage {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal"+path, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var forwarded map[string]json.RawMessage
+	if err := json.Unmarshal(<-backend.bodies, &forwarded); err != nil {
+		t.Fatalf("Failed to decode the forwarded request: %v", err)
+	}
+	return forwarded
+}
+
+// TestInferenceForwardsRewrittenBody tests that a request whose body the
+// server rewrites reaches the backend through the runner's proxy.
+func TestInferenceForwardsRewrittenBody(t *testing.T) {
+	backend := &recordingBackend{
+		mockBackend: mockBackend{name: "mock", usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	s, httpHandler := newProxyTestHandler(t, backend)
+	s.limits.maxTokens = 8
+
+	forwarded := forwardedBody(t, httpHandler, backend, "/engines/v1/chat/completions",
+		`{"model":"ai/model","messages":[{"role":"user","content":"Hi"}],"max_tokens":4096}`, nil)
+	if got := string(forwarded["max_tokens"]); got != "8" {
+		t.Errorf("Expected max_tokens to be lowered to 8, got %s", got)
+	}
+}
+
+// TestInferencePinsPromptSlot tests that a chat request with a system
+// prompt reaches a llama.cpp backend through the runner's proxy pinned to a
+// slot.
+func TestInferencePinsPromptSlot(t *testing.T) {
+	backend := &recordingBackend{
+		mockBackend: mockBackend{name: llamacpp.Name, usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+		slots:       2,
+	}
+	_, httpHandler := newProxyTestHandler(t, backend)
+
+	forwarded := forwardedBody(t, httpHandler, backend, "/engines/v1/chat/completions",
+		`{"model":"ai/model","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hi"}]}`, nil)
+	if _, ok := forwarded["id_slot"]; !ok {
+		t.Errorf("Expected the request to be pinned to a slot, got %v", forwarded)
+	}
+	if got := string(forwarded["cache_prompt"]); got != "true" {
+		t.Errorf("Expected prompt caching to be enabled, got %s", got)
+	}
+}
+
+// TestInferenceDisablesStreaming tests that a streaming request from a client
+// refusing event streams reaches the backend through the runner's proxy with
+// streaming turned off.
+func TestInferenceDisablesStreaming(t *testing.T) {
+	backend := &recordingBackend{
+		mockBackend: mockBackend{name: "mock", usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	_, httpHandler := newProxyTestHandler(t, backend)
+
+	header := http.Header{inference.AcceptStreamingHeader: []string{"false"}}
+	forwarded := forwardedBody(t, httpHandler, backend, "/engines/v1/chat/completions",
+		`{"model":"ai/model","messages":[{"role":"user","content":"Hi"}],"stream":true,"stream_options":{"include_usage":true}}`, header)
+	if got := string(forwarded["stream"]); got != "false" {
+		t.Errorf("Expected streaming to be disabled, got %s", got)
+	}
+	if _, ok := forwarded["stream_options"]; ok {
+		t.Error("Expected the stream options to be dropped")
+	}
+}
+
+// TestInferenceMergesDefaultStops tests that a request merged with the
+// model's default stop sequences reaches the backend through the runner's
+// proxy.
+func TestInferenceMergesDefaultStops(t *testing.T) {
+	backend := &recordingBackend{
+		mockBackend: mockBackend{name: "mock", usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	s, httpHandler := newProxyTestHandler(t, backend)
+	s.loader.setDefaultStop("mock", s.modelManager.ResolveID("ai/model"), inference.BackendModeCompletion, []string{"</s>"})
+
+	forwarded := forwardedBody(t, httpHandler, backend, "/engines/v1/chat/completions",
+		`{"model":"ai/model","messages":[{"role":"user","content":"Hi"}],"stop":"END"}`, nil)
+	if got := string(forwarded["stop"]); got != `["END","</s>"]` {
+		t.Errorf("Expected the default stop sequences to be merged, got %s", got)
+	}
+}
+
+// TestInferenceAppliesSystemPrompt tests that a chat request given the
+// model's default system prompt reaches the backend through the runner's
+// proxy.
+func TestInferenceAppliesSystemPrompt(t *testing.T) {
+	backend := &recordingBackend{
+		mockBackend: mockBackend{name: "mock", usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	s, httpHandler := newProxyTestHandler(t, backend)
+	s.loader.setDefaultSystemPrompt("mock", s.modelManager.ResolveID("ai/model"), inference.BackendModeCompletion,
+		defaultSystemPrompt{prompt: "Be brief.", policy: SystemPromptIfMissing})
+
+	forwarded := forwardedBody(t, httpHandler, backend, "/engines/v1/chat/completions",
+		`{"model":"ai/model","messages":[{"role":"user","content":"Hi"}]}`, nil)
+	if got := string(forwarded["messages"]); got != `[{"content":"Be brief.","role":"system"},{"content":"Hi","role":"user"}]` {
+		t.Errorf("Expected the default system prompt to be applied, got %s", got)
+	}
+}
+
+// TestInferenceChecksResponseFormat tests that a structured response format
+// is refused for backends that would ignore it and forwarded to those that
+// constrain generation to it.
+func TestInferenceChecksResponseFormat(t *testing.T) {
+	body := `{"model":"ai/model","messages":[{"role":"user","content":"Hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"r","schema":{"type":"object"}}}}`
+
+	unsupported := &recordingBackend{
+		mockBackend: mockBackend{name: mlx.Name, usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	_, httpHandler := newProxyTestHandler(t, unsupported)
+	req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal/engines/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	httpHandler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 for the %s backend, got %d: %s", mlx.Name, w.Code, w.Body.String())
+	}
+
+	supported := &recordingBackend{
+		mockBackend: mockBackend{name: llamacpp.Name, usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	_, httpHandler = newProxyTestHandler(t, supported)
+	forwarded := forwardedBody(t, httpHandler, supported, "/engines/v1/chat/completions", body, nil)
+	if _, ok := forwarded["response_format"]; !ok {
+		t.Errorf("Expected the response format to be forwarded, got %v", forwarded)
+	}
+}
+
+// TestLogprobsPassthrough tests that logprobs and top_logprobs reach the
+// backend unchanged and that the logprobs in its response are returned.
+func TestLogprobsPassthrough(t *testing.T) {
+	backend := &logprobsBackend{
+		mockBackend: mockBackend{name: "mock", usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	_, httpHandler := newProxyTestHandler(t, backend)
+
+	body := `{"model":"ai/model","messages":[{"role":"user","content":"Hi"}],"logprobs":true,"top_logprobs":2}`
+	req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal/engines/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	httpHandler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var forwarded struct {
+		Logprobs    bool `json:"logprobs"`
+		TopLogprobs int  `json:"top_logprobs"`
+	}
+	if err := json.Unmarshal(<-backend.bodies, &forwarded); err != nil {
+		t.Fatalf("Failed to decode the forwarded request: %v", err)
+	}
+	if !forwarded.Logprobs || forwarded.TopLogprobs != 2 {
+		t.Errorf("Expected logprobs and top_logprobs to be forwarded, got %+v", forwarded)
+	}
+
+	var response struct {
+		Choices []struct {
+			Logprobs *struct {
+				Content []struct {
+					Token       string  `json:"token"`
+					Logprob     float64 `json:"logprob"`
+					TopLogprobs []struct {
+						Token string `json:"token"`
+					} `json:"top_logprobs"`
+				} `json:"content"`
+			} `json:"logprobs"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode the response: %v", err)
+	}
+	if len(response.Choices) != 1 || response.Choices[0].Logprobs == nil || len(response.Choices[0].Logprobs.Content) != 1 {
+		t.Fatalf("Expected the response to contain logprobs, got %s", w.Body.String())
+	}
+	if content := response.Choices[0].Logprobs.Content[0]; content.Token != "Hi" || content.Logprob != -0.25 || len(content.TopLogprobs) != 2 {
+		t.Errorf("Expected the logprobs to be returned unmodified, got %+v", content)
+	}
+}
+
+func TestApplyMaxTokens(t *testing.T) {
+	limits := requestLimits{maxTokens: 256}
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "unset", body: `{"model":"m"}`, want: `{"max_tokens":256,"model":"m"}`},
+		{name: "null", body: `{"max_tokens":null,"model":"m"}`, want: `{"max_tokens":256,"model":"m"}`},
+		{name: "within limit", body: `{"max_tokens":128,"model":"m"}`, want: `{"max_tokens":128,"model":"m"}`},
+		{name: "above limit", body: `{"max_completion_tokens":4096,"model":"m"}`, want: `{"max_completion_tokens":256,"model":"m"}`},
+		{name: "unlimited", body: `{"max_tokens":-1,"model":"m"}`, want: `{"max_tokens":256,"model":"m"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := limits.applyMaxTokens([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("applyMaxTokens failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if got, _ := (requestLimits{}).applyMaxTokens([]byte(`{"model":"m"}`)); string(got) != `{"model":"m"}` {
+		t.Errorf("Expected the body to be unchanged without a limit, got %s", got)
+	}
+}
+
+func TestDisableStreaming(t *testing.T) {
+	body, err := disableStreaming([]byte(`{"model":"ai/smollm2","stream":true,"stream_options":{"include_usage":true}}`))
+	if err != nil {
+		t.Fatalf("disableStreaming failed: %v", err)
+	}
+	if want := `{"model":"ai/smollm2","stream":false}`; string(body) != want {
+		t.Errorf("Expected %s, got %s", want, body)
+	}
+	for _, unchanged := range []string{`{"model":"ai/smollm2"}`, `{"stream":false,"stream_options":{}}`, `[]`} {
+		if body, err := disableStreaming([]byte(unchanged)); err != nil || string(body) != unchanged {
+			t.Errorf("Expected %s unchanged, got %s, %v", unchanged, body, err)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/engines/v1/chat/completions", http.NoBody)
+	if refusesStreaming(r) {
+		t.Error("Expected streaming to be accepted without the header")
+	}
+	r.Header.Set(inference.AcceptStreamingHeader, "false")
+	if !refusesStreaming(r) {
+		t.Error("Expected streaming to be refused")
+	}
+}
+
+func TestMergeStops(t *testing.T) {
+	stops := []string{"<|im_end|>", "</s>"}
+	tests := []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{name: "unset", path: "/engines/v1/chat/completions", body: `{"model":"m"}`, want: `{"model":"m","stop":["<|im_end|>","</s>"]}`},
+		{name: "null", path: "/engines/v1/completions", body: `{"model":"m","stop":null}`, want: `{"model":"m","stop":["<|im_end|>","</s>"]}`},
+		{name: "string", path: "/engines/v1/chat/completions", body: `{"model":"m","stop":"END"}`, want: `{"model":"m","stop":["END","<|im_end|>","</s>"]}`},
+		{name: "deduplicated", path: "/engines/v1/chat/completions", body: `{"model":"m","stop":["</s>"]}`, want: `{"model":"m","stop":["</s>","<|im_end|>"]}`},
+		{name: "unchanged", path: "/engines/v1/chat/completions", body: `{"model":"m","stop":["</s>","<|im_end|>"]}`, want: `{"model":"m","stop":["</s>","<|im_end|>"]}`},
+		{name: "anthropic", path: "/engines/v1/messages", body: `{"model":"m"}`, want: `{"model":"m","stop_sequences":["<|im_end|>","</s>"]}`},
+		{name: "invalid", path: "/engines/v1/chat/completions", body: `{"model":"m","stop":42}`, want: `{"model":"m","stop":42}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeStops([]byte(tt.body), tt.path, stops)
+			if err != nil {
+				t.Fatalf("mergeStops failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplySystemPrompt(t *testing.T) {
+	ifMissing := defaultSystemPrompt{prompt: "Be <brief>.", policy: SystemPromptIfMissing}
+	merge := defaultSystemPrompt{prompt: "Be <brief>.", policy: SystemPromptMerge}
+	tests := []struct {
+		name   string
+		path   string
+		prompt defaultSystemPrompt
+		body   string
+		want   string
+	}{
+		{name: "none", path: "/engines/v1/chat/completions", prompt: ifMissing, body: `{"messages":[{"role":"user","content":"hi"}]}`, want: `{"messages":[{"content":"Be <brief>.","role":"system"},{"content":"hi","role":"user"}]}`},
+		{name: "present", path: "/engines/v1/chat/completions", prompt: ifMissing, body: `{"messages":[{"role":"system","content":"Be loud."}]}`, want: `{"messages":[{"role":"system","content":"Be loud."}]}`},
+		{name: "merged", path: "/engines/v1/chat/completions", prompt: merge, body: `{"messages":[{"role":"developer","content":"Be loud."}]}`, want: `{"messages":[{"content":"Be <brief>.\n\nBe loud.","role":"developer"}]}`},
+		{name: "merged parts", path: "/engines/v1/chat/completions", prompt: merge, body: `{"messages":[{"role":"system","content":[{"type":"text","text":"Be loud."}]}]}`, want: `{"messages":[{"content":[{"text":"Be <brief>.","type":"text"},{"type":"text","text":"Be loud."}],"role":"system"}]}`},
+		{name: "anthropic", path: "/engines/v1/messages", prompt: ifMissing, body: `{"messages":[]}`, want: `{"messages":[],"system":"Be <brief>."}`},
+		{name: "anthropic present", path: "/engines/v1/messages", prompt: ifMissing, body: `{"system":"Be loud."}`, want: `{"system":"Be loud."}`},
+		{name: "anthropic merged", path: "/engines/v1/messages", prompt: merge, body: `{"system":"Be loud."}`, want: `{"system":"Be <brief>.\n\nBe loud."}`},
+		{name: "completions", path: "/engines/v1/completions", prompt: merge, body: `{"prompt":"hi"}`, want: `{"prompt":"hi"}`},
+		{name: "unset", path: "/engines/v1/chat/completions", body: `{"messages":[]}`, want: `{"messages":[]}`},
+		{name: "invalid", path: "/engines/v1/chat/completions", prompt: ifMissing, body: `{"messages":42}`, want: `{"messages":42}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applySystemPrompt([]byte(tt.body), tt.path, tt.prompt)
+			if err != nil {
+				t.Fatalf("applySystemPrompt failed: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeoutWriterFinish(t *testing.T) {
+	t.Run("stream", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(t.Context(), time.Now().Add(time.Hour))
+		defer cancel()
+		recorder := httptest.NewRecorder()
+		w := newTimeoutWriter(ctx, recorder)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n\n"))
+		w.finish(time.Second)
+		body := recorder.Body.String()
+		if !strings.Contains(body, `"finish_reason":"timeout"`) || !strings.Contains(body, `"id":"chatcmpl-1"`) || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+			t.Errorf("Expected the stream to end with a timeout chunk, got %q", body)
+		}
+	})
+
+	t.Run("before response", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(t.Context(), time.Now())
+		defer cancel()
+		recorder := httptest.NewRecorder()
+		w := newTimeoutWriter(ctx, recorder)
+		// The proxy's error response for the cancelled request is discarded.
+		w.WriteHeader(http.StatusBadGateway)
+		w.finish(time.Second)
+		if recorder.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected status %d, got %d", http.StatusGatewayTimeout, recorder.Code)
+		}
+	})
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	base, ok := responseCacheKey("llama.cpp", "sha256:a", "/v1/chat/completions", []byte(`{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	if !ok {
+		t.Fatal("Expected a deterministic request to be cacheable")
+	}
+	reordered, _ := responseCacheKey("llama.cpp", "sha256:a", "/v1/chat/completions", []byte(`{"messages":[{"content":"hi","role":"user"}], "temperature":0.0,"model":"m"}`))
+	if reordered != base {
+		t.Error("Expected reordered fields to yield the same key")
+	}
+	if other, _ := responseCacheKey("llama.cpp", "sha256:b", "/v1/chat/completions", []byte(`{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}]}`)); other == base {
+		t.Error("Expected a different model to yield a different key")
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		cacheable bool
+	}{
+		{name: "fixed seed", body: `{"model":"m","seed":42,"temperature":0.8}`, cacheable: true},
+		{name: "sampled", body: `{"model":"m","temperature":0.7}`},
+		{name: "default temperature", body: `{"model":"m"}`},
+		{name: "streaming", body: `{"model":"m","temperature":0,"stream":true}`},
+		{name: "tools", body: `{"model":"m","temperature":0,"tools":[{"type":"function"}]}`},
+		{name: "images", body: `{"model":"m","temperature":0,"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:"}}]}]}`},
+		{name: "invalid", body: `not json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := responseCacheKey("llama.cpp", "sha256:a", "/v1/chat/completions", []byte(tt.body)); ok != tt.cacheable {
+				t.Errorf("Expected cacheable to be %v", tt.cacheable)
+			}
+		})
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	cache := newResponseCache(2)
+	cache.put(&cachedResponse{key: "a", modelID: "m1", body: []byte("a")})
+	cache.put(&cachedResponse{key: "b", modelID: "m2", body: []byte("b")})
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("Expected a to be cached")
+	}
+	cache.put(&cachedResponse{key: "c", modelID: "m1", body: []byte("c")})
+	if _, ok := cache.get("b"); ok {
+		t.Error("Expected the least recently used response to be evicted")
+	}
+
+	cache.purgeModel("m1")
+	if _, ok := cache.get("a"); ok {
+		t.Error("Expected responses of the purged model to be removed")
+	}
+	if len(cache.entries) != 0 || cache.order.Len() != 0 {
+		t.Errorf("Expected an empty cache, got %d entries", len(cache.entries))
+	}
+}
+
+func TestCoalescer(t *testing.T) {
+	c := newCoalescer()
+	call, leader := c.join("a")
+	if !leader {
+		t.Fatal("Expected the first request to lead the call")
+	}
+	follower, leader := c.join("a")
+	if leader || follower != call {
+		t.Fatal("Expected an identical request to join the call in flight")
+	}
+	if _, leader := c.join("b"); !leader {
+		t.Error("Expected a different request to lead its own call")
+	}
+
+	response := &cachedResponse{key: "a", body: []byte("shared")}
+	c.finish("a", call, response)
+	select {
+	case <-follower.done:
+	default:
+		t.Fatal("Expected finishing the call to release its followers")
+	}
+	if follower.response != response {
+		t.Errorf("Expected the follower to receive the shared response, got %v", follower.response)
+	}
+	if _, leader := c.join("a"); !leader {
+		t.Error("Expected a request after the call finished to lead a new call")
+	}
+}
+
+// TestCoalescedRequestTimesOut tests that a request waiting for an identical
+// one in flight gives up once the server's timeout passes.
+func TestCoalescedRequestTimesOut(t *testing.T) {
+	backend := &recordingBackend{
+		mockBackend: mockBackend{name: "mock", usesExternalModelMgmt: true},
+		bodies:      make(chan []byte, 1),
+	}
+	s, httpHandler := newProxyTestHandler(t, backend)
+	s.coalesceRequests = true
+	s.limits.timeout = 50 * time.Millisecond
+
+	const path = "/engines/v1/chat/completions"
+	body := `{"model":"ai/model","messages":[{"role":"user","content":"Hi"}],"temperature":0}`
+	key, ok := responseCacheKey("mock", s.modelManager.ResolveID("ai/model"), path, []byte(body))
+	if !ok {
+		t.Fatal("Expected the request to be deterministic")
+	}
+	// Hold a call in flight that never finishes.
+	if _, leader := s.coalescer.join(key); !leader {
+		t.Fatal("Expected to lead the call")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://model-runner.docker.internal"+path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		httpHandler.ServeHTTP(w, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the coalesced request to time out")
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status 504, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(backend.bodies) != 0 {
+		t.Error("Expected the request not to reach the backend")
+	}
+}
+
+func TestCoalesces(t *testing.T) {
+	tests := []struct {
+		header  string
+		enabled bool
+		want    bool
+	}{
+		{header: "", enabled: false, want: false},
+		{header: "", enabled: true, want: true},
+		{header: "true", enabled: false, want: true},
+		{header: "false", enabled: true, want: false},
+		{header: "invalid", enabled: false, want: false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/engines/v1/chat/completions", http.NoBody)
+		if tt.header != "" {
+			r.Header.Set(inference.CoalesceHeader, tt.header)
+		}
+		if got := coalesces(r, tt.enabled); got != tt.want {
+			t.Errorf("coalesces(%q, %t) = %t, want %t", tt.header, tt.enabled, got, tt.want)
+		}
+	}
+}
+
+func TestTimingWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := newTimingWriter(recorder)
+	w.Header().Set("Content-Type", "text/event-stream")
+	stream := `data: {"choices":[{"index":0,"delta":{"role":"assistant"}}]}` + "\n\n" +
+		`data: {"choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" +
+		": keepalive\n\n" +
+		`data: {"choices":[{"index":0,"delta":{"content":"lo"}}]}` + "\r\n\r\n" +
+		"data: [DONE]\n\n"
+	// Split writes mid-line, as a backend may.
+	for _, part := range []string{stream[:30], stream[30:90], stream[90:]} {
+		if _, err := w.Write([]byte(part)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	w.finish()
+
+	var indexes []int
+	var last float64
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(strings.TrimRight(line, "\r"), "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk struct {
+			Choices []json.RawMessage `json:"choices"`
+			Timing  *chunkTiming      `json:"_timing"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil || chunk.Timing == nil || len(chunk.Choices) != 1 {
+			t.Fatalf("Expected a chunk with its timing, got %q (%v)", data, err)
+		}
+		if chunk.Timing.ElapsedMs < last || chunk.Timing.Timestamp.IsZero() {
+			t.Errorf("Unexpected timing %+v after %v ms", chunk.Timing, last)
+		}
+		last = chunk.Timing.ElapsedMs
+		if chunk.Timing.TokenIndex != nil {
+			indexes = append(indexes, *chunk.Timing.TokenIndex)
+		}
+	}
+	if fmt.Sprint(indexes) != "[0 1]" {
+		t.Errorf("Expected token indexes [0 1], got %v", indexes)
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, ": keepalive\n\n") || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Errorf("Expected other stream lines to be unchanged, got %q", body)
+	}
+
+	plain := httptest.NewRecorder()
+	w = newTimingWriter(plain)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"choices":[]}`))
+	w.finish()
+	if plain.Body.String() != `{"choices":[]}` {
+		t.Errorf("Expected a JSON response to be unchanged, got %q", plain.Body.String())
+	}
+}
+
+func TestTimesTokens(t *testing.T) {
+	tests := []struct {
+		path   string
+		header string
+		want   bool
+	}{
+		{path: "/engines/v1/chat/completions", header: "true", want: true},
+		{path: "/engines/llama.cpp/v1/completions", header: "1", want: true},
+		{path: "/engines/v1/chat/completions", header: "", want: false},
+		{path: "/engines/v1/chat/completions", header: "false", want: false},
+		{path: "/engines/v1/messages", header: "true", want: false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.path, http.NoBody)
+		if tt.header != "" {
+			r.Header.Set(inference.DebugTimingHeader, tt.header)
+		}
+		if got := timesTokens(r); got != tt.want {
+			t.Errorf("timesTokens(%s, %q) = %t, want %t", tt.path, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestPromptSlots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/props" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, `{"total_slots":2}`)
+	}))
+	defer server.Close()
+	target := server.Listener.Addr().String()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, target)
+		},
+	}}
+
+	slots := &promptSlots{}
+	a, releaseA, ok := slots.assign(t.Context(), client, "a")
+	if !ok {
+		t.Fatal("Expected the request to be pinned")
+	}
+	if _, _, ok := slots.assign(t.Context(), client, "a"); ok {
+		t.Error("Expected a request for a busy prefix slot not to be pinned")
+	}
+	b, releaseB, ok := slots.assign(t.Context(), client, "b")
+	if !ok || b == a {
+		t.Fatalf("Expected a new prefix to claim the other slot, got %d", b)
+	}
+	releaseA()
+	releaseB()
+	if again, release, ok := slots.assign(t.Context(), client, "a"); !ok || again != a {
+		t.Errorf("Expected the prefix to return to slot %d, got %d", a, again)
+	} else {
+		release()
+	}
+	if c, release, ok := slots.assign(t.Context(), client, "c"); !ok || c != b {
+		t.Errorf("Expected a new prefix to claim the least recently used slot %d, got %d", b, c)
+	} else {
+		release()
+	}
+}
+
+func TestPromptSlotsProbeDoesNotBlock(t *testing.T) {
+	probed := make(chan struct{})
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(probed)
+		<-unblock
+		_, _ = io.WriteString(w, `{"total_slots":2}`)
+	}))
+	defer server.Close()
+	defer close(unblock)
+	target := server.Listener.Addr().String()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, target)
+		},
+	}}
+
+	slots := &promptSlots{}
+	go slots.assign(t.Context(), client, "a")
+	<-probed
+
+	// Requests arriving during the probe go unpinned rather than waiting.
+	done := make(chan bool)
+	go func() {
+		_, _, ok := slots.assign(t.Context(), client, "b")
+		done <- ok
+	}()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("Expected a request during the probe not to be pinned")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a request during the probe not to wait for it")
+	}
+}
+
+func TestPromptPrefixKey(t *testing.T) {
+	first, ok := promptPrefixKey([]byte(`{"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"hi"}]}`))
+	if !ok {
+		t.Fatal("Expected a key for a request with a system prompt")
+	}
+	next, _ := promptPrefixKey([]byte(`{"messages":[{"content":"Be brief.","role":"system"},{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"bye"}]}`))
+	if next != first {
+		t.Error("Expected later turns to share the system prompt's key")
+	}
+	if _, ok := promptPrefixKey([]byte(`{"messages":[{"role":"user","content":"hi"}]}`)); ok {
+		t.Error("Expected no key without a system prompt")
+	}
+}
+
+func TestAnnotateCachedTokens(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", http.NoBody)
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"usage":{"prompt_tokens":120},"timings":{"cache_n":100}}`)),
+		Request:    req.WithContext(withPromptSlot(req.Context())),
+	}
+	if err := annotateCachedTokens(resp); err != nil {
+		t.Fatalf("annotateCachedTokens failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"prompt_tokens_details":{"cached_tokens":100}`) {
+		t.Errorf("Expected the cached tokens in the usage, got %s", body)
+	}
+	if resp.ContentLength != int64(len(body)) {
+		t.Errorf("Expected content length %d, got %d", len(body), resp.ContentLength)
+	}
+}
+
+func TestValidateChatTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		valid    bool
+	}{
+		{
+			name: "chatml",
+			template: "{%- for message in messages %}\n" +
+				"{{- '<|im_start|>' + message['role'] + '\\n' + message['content'] + '<|im_end|>\\n' }}\n" +
+				"{%- endfor %}\n" +
+				"{%- if add_generation_prompt %}{{ '<|im_start|>assistant\\n' }}{% endif %}",
+			valid: true,
+		},
+		{name: "delimiters in strings", template: "{{ '}}' + \"%}\" }}{# {{ #}", valid: true},
+		{name: "escaped quote", template: "{{ 'it\\'s' }}", valid: true},
+		{name: "set and raw", template: "{% set x = {'a': [1, 2]} %}{% raw %}{% if %}{% endraw %}{{ x.a[0] }}", valid: true},
+		{name: "else in for", template: "{% for m in messages %}{{ m }}{% else %}none{% endfor %}", valid: true},
+		{name: "empty", template: "  "},
+		{name: "unclosed block", template: "{% if x %}yes"},
+		{name: "mismatched end", template: "{% for m in messages %}{% endif %}"},
+		{name: "stray elif", template: "{% for m in messages %}{% elif %}{% endfor %}"},
+		{name: "unterminated expression", template: "{{ message['content'] "},
+		{name: "unterminated string", template: "{{ 'hello }}"},
+		{name: "unbalanced brackets", template: "{{ message['content' }}"},
+		{name: "unterminated comment", template: "{# note"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateChatTemplate(tt.template)
+			if tt.valid && err != nil {
+				t.Errorf("Expected the template to be valid, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidChatTemplate) {
+				t.Errorf("Expected ErrInvalidChatTemplate, got %v", err)
+			}
+		})
+	}
+}
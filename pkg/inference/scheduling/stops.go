@@ -0,0 +1,70 @@
+package scheduling
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// stopField returns the name of the request field holding the stop sequences
+// of a generation request to path.
+func stopField(path string) string {
+	if strings.HasSuffix(path, "/v1/messages") {
+		return "stop_sequences"
+	}
+	return "stop"
+}
+
+// mergeStops returns body with stops appended to the stop sequences of a
+// generation request to path, skipping those the request already has. The
+// OpenAI APIs accept either a single stop string or a list. Bodies that aren't
+// JSON objects, or whose stop sequences aren't strings, are returned unchanged
+// for the backend to handle.
+func mergeStops(body []byte, path string, stops []string) ([]byte, error) {
+	if len(stops) == 0 {
+		return body, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body, nil
+	}
+	name := stopField(path)
+	var merged []string
+	if raw, ok := fields[name]; ok && !bytes.Equal(raw, []byte("null")) {
+		var single string
+		if err := json.Unmarshal(raw, &single); err == nil {
+			merged = []string{single}
+		} else if err := json.Unmarshal(raw, &merged); err != nil {
+			return body, nil
+		}
+	}
+	changed := false
+	for _, stop := range stops {
+		if !slices.Contains(merged, stop) {
+			merged = append(merged, stop)
+			changed = true
+		}
+	}
+	if !changed {
+		return body, nil
+	}
+	raw, err := marshalUnescaped(merged)
+	if err != nil {
+		return nil, err
+	}
+	fields[name] = raw
+	return marshalUnescaped(fields)
+}
+
+// marshalUnescaped marshals v without escaping HTML characters, which stop
+// sequences such as "</s>" commonly contain.
+func marshalUnescaped(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
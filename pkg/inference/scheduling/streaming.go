@@ -0,0 +1,34 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/docker/model-runner/pkg/inference"
+)
+
+// refusesStreaming reports whether the client asked, through
+// inference.AcceptStreamingHeader, for a single JSON response instead of an
+// event stream.
+func refusesStreaming(r *http.Request) bool {
+	accept, err := strconv.ParseBool(r.Header.Get(inference.AcceptStreamingHeader))
+	return err == nil && !accept
+}
+
+// disableStreaming returns body with streaming turned off, dropping the
+// stream options that only apply to streams. Bodies that don't request
+// streaming, or that aren't JSON objects, are returned unchanged.
+func disableStreaming(body []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body, nil
+	}
+	var stream bool
+	if json.Unmarshal(fields["stream"], &stream) != nil || !stream {
+		return body, nil
+	}
+	fields["stream"] = json.RawMessage("false")
+	delete(fields, "stream_options")
+	return json.Marshal(fields)
+}
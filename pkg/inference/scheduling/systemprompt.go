@@ -0,0 +1,113 @@
+package scheduling
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// defaultSystemPrompt is a system prompt configured for a model.
+type defaultSystemPrompt struct {
+	// prompt is the system prompt.
+	prompt string
+	// policy is how the prompt combines with a request's own.
+	policy SystemPromptPolicy
+}
+
+// validSystemPromptPolicy reports whether policy is a known policy, or empty
+// for the default.
+func validSystemPromptPolicy(policy SystemPromptPolicy) bool {
+	return policy == "" || policy == SystemPromptIfMissing || policy == SystemPromptMerge
+}
+
+// applySystemPrompt returns body with the default system prompt applied to a
+// chat request to path. OpenAI chat requests carry their system prompt as a
+// system or developer message and Anthropic ones in the system field, either
+// of which may be a string or a list of text blocks. Legacy completions have
+// no system prompt. Bodies that aren't JSON objects, or whose system prompt
+// has an unexpected shape, are returned unchanged for the backend to handle.
+func applySystemPrompt(body []byte, path string, prompt defaultSystemPrompt) ([]byte, error) {
+	if prompt.prompt == "" || strings.HasSuffix(path, "/v1/completions") {
+		return body, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body, nil
+	}
+
+	if strings.HasSuffix(path, "/v1/messages") {
+		system, ok := mergeSystemContent(fields["system"], prompt)
+		if !ok {
+			return body, nil
+		}
+		fields["system"] = system
+		return marshalUnescaped(fields)
+	}
+
+	var messages []map[string]json.RawMessage
+	if err := json.Unmarshal(fields["messages"], &messages); err != nil {
+		return body, nil
+	}
+	found := -1
+	for i, message := range messages {
+		var role string
+		if err := json.Unmarshal(message["role"], &role); err == nil && (role == "system" || role == "developer") {
+			found = i
+			break
+		}
+	}
+	if found == -1 {
+		content, err := marshalUnescaped(prompt.prompt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append([]map[string]json.RawMessage{{"role": json.RawMessage(`"system"`), "content": content}}, messages...)
+	} else {
+		content, ok := mergeSystemContent(messages[found]["content"], prompt)
+		if !ok {
+			return body, nil
+		}
+		messages[found]["content"] = content
+	}
+	raw, err := marshalUnescaped(messages)
+	if err != nil {
+		return nil, err
+	}
+	fields["messages"] = raw
+	return marshalUnescaped(fields)
+}
+
+// mergeSystemContent returns the system prompt content raw with the default
+// prompt applied, reporting false if it is left unchanged. Content that is
+// neither a string nor a list of blocks isn't changed.
+func mergeSystemContent(raw json.RawMessage, prompt defaultSystemPrompt) (json.RawMessage, bool) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		content, err := marshalUnescaped(prompt.prompt)
+		return content, err == nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if text == "" {
+			content, err := marshalUnescaped(prompt.prompt)
+			return content, err == nil
+		}
+		if prompt.policy != SystemPromptMerge {
+			return nil, false
+		}
+		content, err := marshalUnescaped(prompt.prompt + "\n\n" + text)
+		return content, err == nil
+	}
+	var blocks []json.RawMessage
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, false
+	}
+	if len(blocks) > 0 && prompt.policy != SystemPromptMerge {
+		return nil, false
+	}
+	block, err := marshalUnescaped(map[string]string{"type": "text", "text": prompt.prompt})
+	if err != nil {
+		return nil, false
+	}
+	content, err := marshalUnescaped(append([]json.RawMessage{block}, blocks...))
+	return content, err == nil
+}
@@ -0,0 +1,173 @@
+package scheduling
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/model-runner/pkg/inference"
+)
+
+// timesTokens reports whether r asked, through inference.DebugTimingHeader,
+// for the chunks of an OpenAI event stream to be annotated with timings.
+func timesTokens(r *http.Request) bool {
+	timing, err := strconv.ParseBool(r.Header.Get(inference.DebugTimingHeader))
+	return err == nil && timing &&
+		(strings.HasSuffix(r.URL.Path, "/v1/chat/completions") || strings.HasSuffix(r.URL.Path, "/v1/completions"))
+}
+
+// chunkTiming is the timing added to a streamed chunk under its "_timing"
+// key.
+type chunkTiming struct {
+	// TokenIndex is the index of the token the chunk carries, counting from
+	// zero. It is omitted for chunks that carry no generated text, such as
+	// the final usage chunk.
+	TokenIndex *int `json:"token_index,omitempty"`
+	// Timestamp is when the chunk was received from the backend.
+	Timestamp time.Time `json:"timestamp"`
+	// ElapsedMs is the time since the request was sent to the backend, in
+	// milliseconds.
+	ElapsedMs float64 `json:"elapsed_ms"`
+}
+
+// streamedChunk holds the parts of an OpenAI streamed chunk that carry
+// generated text.
+type streamedChunk struct {
+	Choices []struct {
+		Text  string `json:"text"`
+		Delta struct {
+			Content          string          `json:"content"`
+			ReasoningContent string          `json:"reasoning_content"`
+			ToolCalls        json.RawMessage `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// carriesToken reports whether the chunk carries generated text.
+func (c streamedChunk) carriesToken() bool {
+	for _, choice := range c.Choices {
+		if choice.Text != "" || choice.Delta.Content != "" || choice.Delta.ReasoningContent != "" ||
+			(len(choice.Delta.ToolCalls) > 0 && !bytes.Equal(choice.Delta.ToolCalls, []byte("null"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// timingWriter forwards a backend response, adding a chunkTiming to each JSON
+// chunk of an event stream so that clients can work out inter-token latency.
+// Other responses, and stream lines that aren't JSON chunks, are forwarded
+// unchanged. The writer must be finished before the handler returns.
+type timingWriter struct {
+	http.ResponseWriter
+	// start is when the request was sent to the backend.
+	start time.Time
+	// status is the response status.
+	status int
+	// streaming indicates that the response is an event stream.
+	streaming bool
+	// buf holds the incomplete line of an event stream.
+	buf []byte
+	// tokens is the number of chunks carrying a token seen so far.
+	tokens int
+}
+
+func newTimingWriter(w http.ResponseWriter) *timingWriter {
+	return &timingWriter{ResponseWriter: w, start: time.Now()}
+}
+
+func (w *timingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.streaming = strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+		if w.streaming {
+			// Annotating chunks changes the length of the body.
+			w.Header().Del("Content-Length")
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *timingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.streaming {
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	var out []byte
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		out = append(out, w.annotate(w.buf[:i+1])...)
+		w.buf = w.buf[i+1:]
+	}
+	if len(out) > 0 {
+		if _, err := w.ResponseWriter.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *timingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish forwards the remainder of a stream that didn't end with a newline.
+func (w *timingWriter) finish() {
+	if len(w.buf) > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// annotate returns the stream line, including its line ending, with a timing
+// added if it is the data line of a JSON chunk.
+func (w *timingWriter) annotate(line []byte) []byte {
+	content := bytes.TrimRight(line, "\r\n")
+	data, ok := bytes.CutPrefix(content, []byte("data: "))
+	if !ok {
+		return line
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[0] != '{' || !json.Valid(data) {
+		return line
+	}
+	now := time.Now()
+	timing := chunkTiming{
+		Timestamp: now,
+		ElapsedMs: float64(now.Sub(w.start).Microseconds()) / 1000,
+	}
+	var chunk streamedChunk
+	if json.Unmarshal(data, &chunk) == nil && chunk.carriesToken() {
+		index := w.tokens
+		timing.TokenIndex = &index
+		w.tokens++
+	}
+	encoded, err := json.Marshal(timing)
+	if err != nil {
+		return line
+	}
+	// Splice the timing into the object, leaving the backend's encoding of
+	// the chunk untouched.
+	body := bytes.TrimSpace(data[1 : len(data)-1])
+	separator := ","
+	if len(body) == 0 {
+		separator = ""
+	}
+	return fmt.Appendf(nil, "data: {%s%s\"_timing\":%s}%s", body, separator, encoded, line[len(content):])
+}
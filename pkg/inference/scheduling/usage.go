@@ -0,0 +1,400 @@
+package scheduling
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+const (
+	// usageRetentionEnv names the environment variable holding how long, as
+	// a Go duration, per-request token usage is kept for reports. Zero
+	// disables usage accounting.
+	usageRetentionEnv = "MODEL_RUNNER_USAGE_RETENTION"
+	// usageLogEnv names the environment variable holding the path of a file
+	// to which usage records are appended, one JSON object per line, and
+	// from which they are reloaded at startup.
+	usageLogEnv = "MODEL_RUNNER_USAGE_LOG"
+	// defaultUsageRetention is the retention used if usageRetentionEnv is
+	// unset.
+	defaultUsageRetention = 7 * 24 * time.Hour
+	// maxUsageRecords bounds the number of usage records kept in memory.
+	maxUsageRecords = 100000
+	// maxUsageLogSize is the size beyond which the usage log is rotated,
+	// keeping a single previous generation with a ".1" suffix.
+	maxUsageLogSize = 64 << 20
+	// maxTenantLength is the longest tenant identifier accepted.
+	maxTenantLength = 128
+)
+
+// errInvalidTenant indicates a malformed inference.TenantHeader.
+var errInvalidTenant = errors.New("tenant must be at most 128 printable characters")
+
+// usageRecord is the token usage of a single inference request.
+type usageRecord struct {
+	Time             time.Time `json:"time"`
+	Model            string    `json:"model"`
+	Tenant           string    `json:"tenant,omitempty"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+}
+
+// usageLedger records the token usage of inference requests for reports. It
+// is safe for concurrent use.
+type usageLedger struct {
+	// log is the associated logger.
+	log logging.Logger
+	// retention is how long records are kept.
+	retention time.Duration
+	// path is the usage log, or empty if records aren't persisted.
+	path string
+	// mu guards the fields below.
+	mu sync.Mutex
+	// records holds the retained records, oldest first.
+	records []usageRecord
+	// retainedSince is the time of the oldest record kept when records
+	// within retention were last discarded to stay within maxUsageRecords,
+	// as usage before it is incomplete, or zero if none have been.
+	retainedSince time.Time
+	// file is the open usage log, if any.
+	file *os.File
+	// size is the size of the usage log.
+	size int64
+}
+
+// usageLedgerFromEnv returns the usage ledger configured in the environment,
+// or nil if usage accounting is disabled.
+func usageLedgerFromEnv(log logging.Logger) *usageLedger {
+	retention := defaultUsageRetention
+	if raw := os.Getenv(usageRetentionEnv); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			log.Warnf("Ignoring invalid %s value %q", usageRetentionEnv, utils.SanitizeForLog(raw, -1))
+		} else {
+			retention = parsed
+		}
+	}
+	if retention == 0 {
+		return nil
+	}
+	return newUsageLedger(log, os.Getenv(usageLogEnv), retention)
+}
+
+// newUsageLedger returns a ledger keeping records for retention, persisted to
+// path unless it is empty. Records still within retention are reloaded from
+// path.
+func newUsageLedger(log logging.Logger, path string, retention time.Duration) *usageLedger {
+	l := &usageLedger{log: log, retention: retention, path: path}
+	if path == "" {
+		return l
+	}
+	cutoff := time.Now().Add(-retention)
+	for _, name := range []string{path + ".1", path} {
+		if err := l.load(name, cutoff); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warnf("Failed to load usage log %s: %v", name, err)
+		}
+	}
+	if len(l.records) > maxUsageRecords {
+		l.records = l.records[len(l.records)-maxUsageRecords:]
+		l.retainedSince = l.records[0].Time
+	}
+	return l
+}
+
+// load appends the records in the usage log name made after cutoff.
+func (l *usageLedger) load(name string, cutoff time.Time) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record usageRecord
+		if json.Unmarshal(scanner.Bytes(), &record) != nil || record.Time.Before(cutoff) {
+			continue
+		}
+		l.records = append(l.records, record)
+	}
+	return scanner.Err()
+}
+
+// record adds the usage of a request, discarding records that have expired.
+func (l *usageLedger) record(record usageRecord) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+	cutoff := record.Time.Add(-l.retention)
+	expired, _ := slices.BinarySearchFunc(l.records, cutoff, func(r usageRecord, t time.Time) int {
+		return r.Time.Compare(t)
+	})
+	if excess := len(l.records) - maxUsageRecords; excess > expired {
+		l.records = l.records[excess:]
+		l.retainedSince = l.records[0].Time
+	} else if expired > 0 {
+		l.records = l.records[expired:]
+	}
+	if l.path != "" {
+		if err := l.persist(record); err != nil {
+			l.log.Warnf("Failed to write usage log: %v", err)
+		}
+	}
+}
+
+// persist appends record to the usage log, rotating it once it grows too
+// large. It must be called with mu held.
+func (l *usageLedger) persist(record usageRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if l.file != nil && l.size+int64(len(line)) > maxUsageLogSize {
+		l.file.Close()
+		l.file = nil
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return fmt.Errorf("rotating usage log: %w", err)
+		}
+	}
+	if l.file == nil {
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("opening usage log: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("opening usage log: %w", err)
+		}
+		l.file, l.size = f, info.Size()
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// report totals the usage recorded since the given time by model and tenant.
+func (l *usageLedger) report(since time.Time) UsageReport {
+	report := UsageReport{Since: since, Usage: []TokenUsage{}}
+	if l == nil {
+		return report
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if cutoff := time.Now().Add(-l.retention); report.Since.Before(cutoff) {
+		report.Since = cutoff
+	}
+	if report.Since.Before(l.retainedSince) {
+		report.Since = l.retainedSince
+	}
+	type key struct{ model, tenant string }
+	totals := make(map[key]*TokenUsage)
+	for _, record := range l.records {
+		if record.Time.Before(since) {
+			continue
+		}
+		k := key{record.Model, record.Tenant}
+		total := totals[k]
+		if total == nil {
+			total = &TokenUsage{Model: record.Model, Tenant: record.Tenant}
+			totals[k] = total
+		}
+		total.Requests++
+		total.PromptTokens += record.PromptTokens
+		total.CompletionTokens += record.CompletionTokens
+		total.TotalTokens += record.PromptTokens + record.CompletionTokens
+	}
+	for _, total := range totals {
+		report.Usage = append(report.Usage, *total)
+	}
+	slices.SortFunc(report.Usage, func(a, b TokenUsage) int {
+		if c := strings.Compare(a.Model, b.Model); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tenant, b.Tenant)
+	})
+	return report
+}
+
+// close closes the usage log.
+func (l *usageLedger) close() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// validateTenant checks a tenant identifier from inference.TenantHeader.
+func validateTenant(tenant string) error {
+	if len(tenant) > maxTenantLength || strings.ContainsFunc(tenant, func(r rune) bool { return !unicode.IsPrint(r) }) {
+		return errInvalidTenant
+	}
+	return nil
+}
+
+// parseUsageSince parses the since parameter of a usage report, which is
+// either an RFC 3339 time or a Go duration before now. Empty means all
+// retained usage.
+func parseUsageSince(raw string, now time.Time) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if since, err := time.Parse(time.RFC3339, raw); err == nil {
+		return since, nil
+	}
+	if ago, err := time.ParseDuration(raw); err == nil && ago >= 0 {
+		return now.Add(-ago), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since value %q: expected an RFC 3339 time or a duration", raw)
+}
+
+// tokenCounts holds the token counts reported in a response's usage.
+type tokenCounts struct {
+	prompt, completion int64
+}
+
+// responseUsage is the part of a response, or of a streamed event, reporting
+// token usage. Anthropic responses name the counts differently and, when
+// streaming, report the prompt tokens in the message_start event's message.
+type responseUsage struct {
+	Usage *struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+		InputTokens      int64 `json:"input_tokens"`
+		OutputTokens     int64 `json:"output_tokens"`
+	} `json:"usage"`
+	Message *responseUsage `json:"message"`
+}
+
+// counts returns the token counts reported, if any.
+func (u *responseUsage) counts() (tokenCounts, bool) {
+	if u.Usage != nil {
+		return tokenCounts{
+			prompt:     max(u.Usage.PromptTokens, u.Usage.InputTokens),
+			completion: max(u.Usage.CompletionTokens, u.Usage.OutputTokens),
+		}, true
+	}
+	if u.Message != nil {
+		return u.Message.counts()
+	}
+	return tokenCounts{}, false
+}
+
+// usageWriter forwards a backend response while reading the token usage it
+// reports, either in a JSON body or in the events of a stream. Streams only
+// report usage if the backend includes it, typically in the final event.
+type usageWriter struct {
+	http.ResponseWriter
+	// status is the response status.
+	status int
+	// streaming indicates that the response is an event stream.
+	streaming bool
+	// buf holds a JSON body, or the incomplete line of an event stream.
+	buf []byte
+	// overflowed indicates that a JSON body exceeded
+	// maxRewrittenResponseSize.
+	overflowed bool
+	// usage holds the largest counts reported so far.
+	usage tokenCounts
+	// reported indicates that usage was reported.
+	reported bool
+}
+
+func newUsageWriter(w http.ResponseWriter) *usageWriter {
+	return &usageWriter{ResponseWriter: w}
+}
+
+func (w *usageWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.streaming = strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *usageWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.streaming {
+		w.buf = append(w.buf, p...)
+		for {
+			i := bytes.IndexByte(w.buf, '\n')
+			if i < 0 {
+				break
+			}
+			if data, ok := bytes.CutPrefix(bytes.TrimRight(w.buf[:i], "\r"), []byte("data: ")); ok {
+				w.observe(data)
+			}
+			w.buf = w.buf[i+1:]
+		}
+		if len(w.buf) > maxRewrittenResponseSize {
+			w.buf = nil
+		}
+	} else if !w.overflowed {
+		if len(w.buf)+len(p) > maxRewrittenResponseSize {
+			w.overflowed, w.buf = true, nil
+		} else {
+			w.buf = append(w.buf, p...)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *usageWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *usageWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// observe records the usage reported in a JSON object, if any.
+func (w *usageWriter) observe(data []byte) {
+	if !bytes.Contains(data, []byte(`"usage"`)) {
+		return
+	}
+	var response responseUsage
+	if json.Unmarshal(data, &response) != nil {
+		return
+	}
+	if counts, ok := response.counts(); ok {
+		w.usage.prompt = max(w.usage.prompt, counts.prompt)
+		w.usage.completion = max(w.usage.completion, counts.completion)
+		w.reported = true
+	}
+}
+
+// tokens returns the token usage of a successful response, or false if none
+// was reported.
+func (w *usageWriter) tokens() (tokenCounts, bool) {
+	if w.status != http.StatusOK {
+		return tokenCounts{}, false
+	}
+	if !w.streaming && !w.overflowed {
+		w.observe(w.buf)
+	}
+	return w.usage, w.reported
+}
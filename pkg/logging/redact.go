@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redacted replaces sensitive values in log output.
+const redacted = "[REDACTED]"
+
+// secretPatterns match sensitive values embedded in log messages. The first
+// capture group, if any, is preserved and the remainder is redacted.
+var secretPatterns = []*regexp.Regexp{
+	// Authorization headers, e.g. "Authorization: Bearer xyz" or "authorization=Basic xyz".
+	regexp.MustCompile(`(?i)(authorization["']?\s*[:=]\s*["']?(?:bearer\s+|basic\s+)?)[^\s"',}]+`),
+	// Bare bearer tokens.
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+	// Token and key assignments, e.g. HF_TOKEN=xyz or "BearerToken":"xyz".
+	regexp.MustCompile(`(?i)((?:hf_token|bearer_?token|access_?token|api_?key)["']?\s*[:=]\s*["']?)[^\s"',}]+`),
+	// Secret command-line flags, e.g. "--api-key xyz" in LLAMA_ARGS.
+	regexp.MustCompile(`(?i)(--(?:api-key|hf-token)(?:=|\s+))[^\s"']+`),
+	// HuggingFace access tokens.
+	regexp.MustCompile(`()\bhf_[A-Za-z0-9]{20,}\b`),
+}
+
+// secretEnvVars are environment variables whose values are always redacted.
+var secretEnvVars = []string{"HF_TOKEN"}
+
+// sensitiveFieldNames are the words of structured log field names whose values
+// are redacted. Whole words are matched so that, for example, "token" doesn't
+// redact "max_tokens".
+var sensitiveFieldNames = []string{"authorization", "token", "apikey", "password", "secret"}
+
+// RedactSecrets scrubs known-sensitive values from s.
+func RedactSecrets(s string) string {
+	for _, env := range secretEnvVars {
+		if v := os.Getenv(env); v != "" {
+			s = strings.ReplaceAll(s, v, redacted)
+		}
+	}
+	for _, p := range secretPatterns {
+		s = p.ReplaceAllString(s, "${1}"+redacted)
+	}
+	return s
+}
+
+// RedactEnv returns the value of the environment variable name with secrets
+// scrubbed. Values of variables whose name suggests a secret, and URLs with
+// credentials, are redacted entirely.
+func RedactEnv(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if slices.Contains(secretEnvVars, name) || isSensitiveField(name) {
+		return redacted
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return redacted
+	}
+	return RedactSecrets(value)
+}
+
+// RedactHook is a logrus hook that scrubs secrets from log messages and
+// fields at every level.
+type RedactHook struct{}
+
+// NewRedactHook creates a new RedactHook.
+func NewRedactHook() *RedactHook {
+	return &RedactHook{}
+}
+
+// Levels implements logrus.Hook.
+func (h *RedactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (h *RedactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = RedactSecrets(entry.Message)
+	for key, value := range entry.Data {
+		if isSensitiveField(key) {
+			entry.Data[key] = redacted
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = RedactSecrets(v)
+		case error:
+			if msg := v.Error(); RedactSecrets(msg) != msg {
+				entry.Data[key] = RedactSecrets(msg)
+			}
+		case fmt.Stringer:
+			if msg := v.String(); RedactSecrets(msg) != msg {
+				entry.Data[key] = RedactSecrets(msg)
+			}
+		}
+	}
+	return nil
+}
+
+// isSensitiveField reports whether a structured field name holds a secret.
+func isSensitiveField(key string) bool {
+	words := fieldWords(key)
+	for i, word := range words {
+		if slices.Contains(sensitiveFieldNames, word) {
+			return true
+		}
+		if word == "api" && i+1 < len(words) && words[i+1] == "key" {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldWords splits a field name into lower-case words at underscores,
+// hyphens, dots and camel case boundaries, so that "X-Api-Key", "api_key"
+// and "APIKey" all yield "api" and "key".
+func fieldWords(key string) []string {
+	runes := []rune(key)
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for i, r := range runes {
+		if r == '_' || r == '-' || r == '.' || unicode.IsSpace(r) {
+			flush()
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			flush()
+		}
+		word.WriteRune(unicode.ToLower(r))
+	}
+	flush()
+	return words
+}
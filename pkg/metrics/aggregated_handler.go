@@ -38,7 +38,8 @@ func (h *AggregatedMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Requ
 	}
 
 	runners := h.scheduler.GetAllActiveRunners()
-	if len(runners) == 0 {
+	crashes := crashMetricFamily(h.scheduler.GetBackendCrashes())
+	if len(runners) == 0 && crashes == nil {
 		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "# No active runners\n")
@@ -47,6 +48,12 @@ func (h *AggregatedMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Requ
 
 	// Collect and aggregate metrics from all runners
 	allFamilies := h.collectAndAggregateMetrics(r.Context(), runners)
+	for name, family := range h.processMetricFamilies(runners) {
+		allFamilies[name] = family
+	}
+	if crashes != nil {
+		allFamilies[backendCrashesMetric] = crashes
+	}
 
 	// Write aggregated response using Prometheus encoder
 	h.writeAggregatedMetrics(w, allFamilies)
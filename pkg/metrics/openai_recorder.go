@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -405,15 +406,50 @@ func (r *OpenAIRecorder) RecordResponse(id, model string, rw http.ResponseWriter
 	}
 }
 
+// streamedChoice accumulates the deltas of a single choice of a streaming
+// chat completion.
+type streamedChoice struct {
+	// last is the most recently streamed version of the choice.
+	last map[string]interface{}
+	// content accumulates the streamed content.
+	content strings.Builder
+	// reasoning accumulates the streamed reasoning content.
+	reasoning strings.Builder
+}
+
+// message returns the choice in non-streaming form, with its deltas replaced
+// by the accumulated message.
+func (c *streamedChoice) message() map[string]interface{} {
+	choice := c.last
+	if choice == nil {
+		choice = make(map[string]interface{})
+	}
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": c.content.String(),
+	}
+	if c.reasoning.Len() > 0 {
+		message["reasoning_content"] = c.reasoning.String()
+	}
+	choice["message"] = message
+	delete(choice, "delta")
+
+	if _, ok := choice["finish_reason"]; !ok {
+		choice["finish_reason"] = "stop"
+	}
+	return choice
+}
+
 // convertStreamingResponse converts a streaming response body into a standard JSON response.
 // It handles both successful streaming completions and streaming errors.
 // If a streaming error is detected, it returns the original streaming body and the error.
 // If successful, it reconstructs the final response in standard JSON format.
 func (r *OpenAIRecorder) convertStreamingResponse(streamingBody string) (string, error) {
 	lines := strings.Split(streamingBody, "\n")
-	var contentBuilder strings.Builder
-	var reasoningContentBuilder strings.Builder
-	var lastChoice, lastChunk map[string]interface{}
+	// Deltas are accumulated per choice index so that responses to requests
+	// with n > 1 keep all of their choices.
+	choices := make(map[int]*streamedChoice)
+	var lastChunk map[string]interface{}
 
 	for _, line := range lines {
 		// Check for error lines in the streaming format
@@ -469,16 +505,28 @@ func (r *OpenAIRecorder) convertStreamingResponse(streamingBody string) (string,
 
 			lastChunk = chunk
 
-			if choices, ok := chunk["choices"].([]interface{}); ok && len(choices) > 0 {
-				if choice, ok := choices[0].(map[string]interface{}); ok {
-					lastChoice = choice
-					if delta, ok := choice["delta"].(map[string]interface{}); ok {
-						if content, ok := delta["content"].(string); ok {
-							contentBuilder.WriteString(content)
-						}
-						if content, ok := delta["reasoning_content"].(string); ok {
-							reasoningContentBuilder.WriteString(content)
-						}
+			chunkChoices, _ := chunk["choices"].([]interface{})
+			for i, c := range chunkChoices {
+				choice, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				index := i
+				if idx, ok := choice["index"].(float64); ok {
+					index = int(idx)
+				}
+				accumulated, ok := choices[index]
+				if !ok {
+					accumulated = &streamedChoice{}
+					choices[index] = accumulated
+				}
+				accumulated.last = choice
+				if delta, ok := choice["delta"].(map[string]interface{}); ok {
+					if content, ok := delta["content"].(string); ok {
+						accumulated.content.WriteString(content)
+					}
+					if content, ok := delta["reasoning_content"].(string); ok {
+						accumulated.reasoning.WriteString(content)
 					}
 				}
 			}
@@ -489,9 +537,9 @@ func (r *OpenAIRecorder) convertStreamingResponse(streamingBody string) (string,
 		return streamingBody, nil
 	}
 
-	// Initialize lastChoice if it's nil to prevent nil map panics
-	if lastChoice == nil {
-		lastChoice = make(map[string]interface{})
+	// Ensure there is at least one choice to report the response in.
+	if len(choices) == 0 {
+		choices[0] = &streamedChoice{}
 	}
 
 	finalResponse := make(map[string]interface{})
@@ -499,25 +547,17 @@ func (r *OpenAIRecorder) convertStreamingResponse(streamingBody string) (string,
 	for key, value := range lastChunk {
 		finalResponse[key] = value
 	}
-	finalResponse["choices"] = []interface{}{lastChoice}
-
-	if choices, ok := finalResponse["choices"].([]interface{}); ok && len(choices) > 0 {
-		if choice, ok := choices[0].(map[string]interface{}); ok {
-			message := map[string]interface{}{
-				"role":    "assistant",
-				"content": contentBuilder.String(),
-			}
-			if reasoningContentBuilder.Len() > 0 {
-				message["reasoning_content"] = reasoningContentBuilder.String()
-			}
-			choice["message"] = message
-			delete(choice, "delta")
 
-			if _, ok := choice["finish_reason"]; !ok {
-				choice["finish_reason"] = "stop"
-			}
-		}
+	indices := make([]int, 0, len(choices))
+	for index := range choices {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	finalChoices := make([]interface{}, 0, len(indices))
+	for _, index := range indices {
+		finalChoices = append(finalChoices, choices[index].message())
 	}
+	finalResponse["choices"] = finalChoices
 
 	finalResponse["object"] = "chat.completion"
 
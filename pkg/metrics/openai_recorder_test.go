@@ -213,3 +213,52 @@ func generateLongString(length int) string {
 	}
 	return string(result)
 }
+
+func TestConvertStreamingResponseMultipleChoices(t *testing.T) {
+	recorder := NewOpenAIRecorder(logrus.New(), &models.Manager{})
+
+	body := `data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"},"finish_reason":null}]}
+data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":1,"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}
+data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+data: {"id":"1","object":"chat.completion.chunk","choices":[{"index":1,"delta":{"content":" there"},"finish_reason":"length"}]}
+data: [DONE]
+`
+	converted, err := recorder.convertStreamingResponse(body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var response struct {
+		Object  string `json:"object"`
+		Choices []struct {
+			Index   int `json:"index"`
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal([]byte(converted), &response); err != nil {
+		t.Fatalf("Failed to decode converted response %q: %v", converted, err)
+	}
+	if response.Object != "chat.completion" {
+		t.Errorf("Expected chat.completion object, got %q", response.Object)
+	}
+	if len(response.Choices) != 2 {
+		t.Fatalf("Expected 2 choices, got %d: %s", len(response.Choices), converted)
+	}
+	expected := []struct {
+		content      string
+		finishReason string
+	}{
+		{"Hello", "stop"},
+		{"Hi there", "length"},
+	}
+	for i, want := range expected {
+		choice := response.Choices[i]
+		if choice.Index != i || choice.Message.Content != want.content || choice.FinishReason != want.finishReason {
+			t.Errorf("Choice %d: expected %q (%s), got index %d %q (%s)",
+				i, want.content, want.finishReason, choice.Index, choice.Message.Content, choice.FinishReason)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"errors"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	// backendCPUMetric is the cumulative CPU time consumed by a backend process.
+	backendCPUMetric = "dmr_backend_cpu_seconds_total"
+	// backendRSSMetric is the resident memory of a backend process.
+	backendRSSMetric = "dmr_backend_rss_bytes"
+	// backendCrashesMetric is the number of unexpected backend process exits.
+	backendCrashesMetric = "dmr_backend_crashes_total"
+)
+
+// errProcessStatsUnsupported indicates that process sampling is not
+// implemented on the current platform.
+var errProcessStatsUnsupported = errors.New("process stats are not supported on this platform")
+
+// processStats is a point-in-time sample of a process's resource usage.
+type processStats struct {
+	// cpuSeconds is the total user and system CPU time consumed.
+	cpuSeconds float64
+	// rssBytes is the resident set size.
+	rssBytes uint64
+}
+
+// ProcessRSS returns the resident memory of the process with the given pid,
+// or 0 if it cannot be determined.
+func ProcessRSS(pid int) uint64 {
+	if pid <= 0 {
+		return 0
+	}
+	stats, err := sampleProcess(pid)
+	if err != nil {
+		return 0
+	}
+	return stats.rssBytes
+}
+
+// processMetricFamilies samples each runner's backend process and returns
+// CPU and RSS metric families labelled like the aggregated runner metrics.
+func (h *AggregatedMetricsHandler) processMetricFamilies(runners []ActiveRunner) map[string]*dto.MetricFamily {
+	cpu := &dto.MetricFamily{
+		Name: strPtr(backendCPUMetric),
+		Help: strPtr("Total CPU time consumed by the backend process in seconds"),
+		Type: dto.MetricType_COUNTER.Enum(),
+	}
+	rss := &dto.MetricFamily{
+		Name: strPtr(backendRSSMetric),
+		Help: strPtr("Resident memory of the backend process in bytes"),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+
+	for _, runner := range runners {
+		if runner.PID <= 0 {
+			continue
+		}
+		stats, err := sampleProcess(runner.PID)
+		if err != nil {
+			if !errors.Is(err, errProcessStatsUnsupported) {
+				h.log.Debugf("Failed to sample backend process %d for %s: %v", runner.PID, runner.ModelName, err)
+			}
+			continue
+		}
+		labels := []*dto.LabelPair{
+			{Name: strPtr("backend"), Value: strPtr(runner.BackendName)},
+			{Name: strPtr("model"), Value: strPtr(runner.ModelName)},
+			{Name: strPtr("mode"), Value: strPtr(runner.Mode)},
+		}
+		cpu.Metric = append(cpu.Metric, &dto.Metric{
+			Label:   labels,
+			Counter: &dto.Counter{Value: float64Ptr(stats.cpuSeconds)},
+		})
+		rss.Metric = append(rss.Metric, &dto.Metric{
+			Label: labels,
+			Gauge: &dto.Gauge{Value: float64Ptr(float64(stats.rssBytes))},
+		})
+	}
+
+	families := make(map[string]*dto.MetricFamily)
+	if len(cpu.Metric) > 0 {
+		families[backendCPUMetric] = cpu
+		families[backendRSSMetric] = rss
+	}
+	return families
+}
+
+// crashMetricFamily returns a counter family of backend process crashes
+// labelled by backend, or nil if no crashes have been observed.
+func crashMetricFamily(crashes map[string]uint64) *dto.MetricFamily {
+	if len(crashes) == 0 {
+		return nil
+	}
+	family := &dto.MetricFamily{
+		Name: strPtr(backendCrashesMetric),
+		Help: strPtr("Total number of unexpected backend process exits"),
+		Type: dto.MetricType_COUNTER.Enum(),
+	}
+	backends := make([]string, 0, len(crashes))
+	for backend := range crashes {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+	for _, backend := range backends {
+		family.Metric = append(family.Metric, &dto.Metric{
+			Label:   []*dto.LabelPair{{Name: strPtr("backend"), Value: strPtr(backend)}},
+			Counter: &dto.Counter{Value: float64Ptr(float64(crashes[backend]))},
+		})
+	}
+	return family
+}
+
+func strPtr(s string) *string { return &s }
+
+func float64Ptr(f float64) *float64 { return &f }
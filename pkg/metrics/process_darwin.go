@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// sampleProcess reads CPU time and RSS for pid using ps(1), which reports the
+// same task_info data without requiring cgo.
+func sampleProcess(pid int) (processStats, error) {
+	out, err := exec.Command("ps", "-o", "rss=,time=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return processStats{}, fmt.Errorf("running ps: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return processStats{}, fmt.Errorf("unexpected ps output %q", strings.TrimSpace(string(out)))
+	}
+	rssKiB, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return processStats{}, fmt.Errorf("parsing rss: %w", err)
+	}
+	cpuSeconds, err := parseCPUTime(fields[1])
+	if err != nil {
+		return processStats{}, err
+	}
+	return processStats{cpuSeconds: cpuSeconds, rssBytes: rssKiB * 1024}, nil
+}
+
+// parseCPUTime parses a ps time value of the form [[dd-]hh:]mm:ss[.ff].
+func parseCPUTime(s string) (float64, error) {
+	var days float64
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		v, err := strconv.ParseFloat(d, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing cpu time %q: %w", s, err)
+		}
+		days, s = v, rest
+	}
+	var seconds float64
+	for _, part := range strings.Split(s, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing cpu time %q: %w", s, err)
+		}
+		seconds = seconds*60 + v
+	}
+	return days*86400 + seconds, nil
+}
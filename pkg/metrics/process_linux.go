@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// clockTicksPerSecond is the kernel USER_HZ value used by /proc/<pid>/stat.
+// It is 100 on all mainstream Linux architectures.
+const clockTicksPerSecond = 100
+
+// sampleProcess reads CPU time and RSS for pid from /proc.
+func sampleProcess(pid int) (processStats, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return processStats{}, fmt.Errorf("reading process stat: %w", err)
+	}
+	// The command name is parenthesized and may contain spaces, so parse
+	// the fields following the last closing parenthesis.
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return processStats{}, fmt.Errorf("malformed process stat")
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	// utime and stime are fields 14 and 15 of the full line; fields here
+	// start at field 3 (state).
+	if len(fields) < 13 {
+		return processStats{}, fmt.Errorf("malformed process stat")
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return processStats{}, fmt.Errorf("parsing utime: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return processStats{}, fmt.Errorf("parsing stime: %w", err)
+	}
+
+	statm, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return processStats{}, fmt.Errorf("reading process statm: %w", err)
+	}
+	statmFields := strings.Fields(string(statm))
+	if len(statmFields) < 2 {
+		return processStats{}, fmt.Errorf("malformed process statm")
+	}
+	residentPages, err := strconv.ParseUint(statmFields[1], 10, 64)
+	if err != nil {
+		return processStats{}, fmt.Errorf("parsing resident pages: %w", err)
+	}
+
+	return processStats{
+		cpuSeconds: float64(utime+stime) / clockTicksPerSecond,
+		rssBytes:   residentPages * uint64(os.Getpagesize()),
+	}, nil
+}
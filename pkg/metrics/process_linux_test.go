@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestProcessMetricFamilies(t *testing.T) {
+	discard := logrus.New()
+	discard.SetOutput(io.Discard)
+	h := NewAggregatedMetricsHandler(logrus.NewEntry(discard), nil)
+
+	families := h.processMetricFamilies([]ActiveRunner{
+		{BackendName: "llama.cpp", ModelName: "ai/smollm2", Mode: "completion", PID: os.Getpid()},
+		{BackendName: "llama.cpp", ModelName: "ai/unknown-pid", Mode: "completion"},
+	})
+
+	rss, ok := families[backendRSSMetric]
+	if !ok || len(rss.GetMetric()) != 1 {
+		t.Fatalf("Expected one %s sample, got %v", backendRSSMetric, families)
+	}
+	if rss.GetMetric()[0].GetGauge().GetValue() <= 0 {
+		t.Errorf("Expected positive RSS for the current process")
+	}
+	cpu, ok := families[backendCPUMetric]
+	if !ok || len(cpu.GetMetric()) != 1 {
+		t.Fatalf("Expected one %s sample, got %v", backendCPUMetric, families)
+	}
+	var model string
+	for _, l := range cpu.GetMetric()[0].GetLabel() {
+		if l.GetName() == "model" {
+			model = l.GetValue()
+		}
+	}
+	if model != "ai/smollm2" {
+		t.Errorf("Expected model label ai/smollm2, got %q", model)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package metrics
+
+// sampleProcess is not implemented on this platform.
+func sampleProcess(int) (processStats, error) {
+	return processStats{}, errProcessStatsUnsupported
+}
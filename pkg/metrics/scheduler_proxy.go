@@ -20,6 +20,7 @@ type SchedulerInterface interface {
 	GetRunningBackends(w http.ResponseWriter, r *http.Request)
 	GetLlamaCppSocket() (string, error)
 	GetAllActiveRunners() []ActiveRunner
+	GetBackendCrashes() map[string]uint64
 }
 
 // ActiveRunner contains information about an active runner
@@ -28,6 +29,8 @@ type ActiveRunner struct {
 	ModelName   string
 	Mode        string
 	Socket      string
+	// PID is the backend process ID, or 0 if it is not known.
+	PID int
 }
 
 // ServeHTTP implements http.Handler for metrics proxying via scheduler
@@ -3,18 +3,46 @@ package middleware
 import (
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 )
 
+const (
+	// defaultPreflightMaxAge is how long, in seconds, browsers may cache a
+	// preflight response unless DMR_CORS_MAX_AGE overrides it.
+	defaultPreflightMaxAge = 300
+	// preflightAllowedMethods are the methods used by the inference, model
+	// management and Ollama routes.
+	preflightAllowedMethods = "GET, HEAD, POST, DELETE"
+	// preflightAllowedHeaders are the request headers allowed when a preflight
+	// doesn't list the headers it needs. A wildcard can't be used here, since
+	// browsers treat it literally for requests with credentials.
+	preflightAllowedHeaders = "Accept, Authorization, Content-Type, Range, X-Preload-Only, X-Request-Origin"
+)
+
 // CorsMiddleware handles CORS and OPTIONS preflight requests with optional allowedOrigins.
 // If allowedOrigins is nil or empty, it falls back to getAllowedOrigins().
 // This middleware intercepts OPTIONS requests only if the Origin header is present and valid,
 // otherwise passing the request to the router (allowing 405/404 responses as appropriate).
+// Cross-origin requests can be further restricted to the methods in DMR_CORS_METHODS and
+// the headers in DMR_CORS_HEADERS; both default to permitting everything the API uses.
 func CorsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
 	if len(allowedOrigins) == 0 {
 		allowedOrigins = getAllowedOrigins()
 	}
 
+	maxAge := strconv.Itoa(getPreflightMaxAge())
+	allowedMethods := getAllowedMethods()
+	methodsHeader := preflightAllowedMethods
+	var methodSet map[string]struct{}
+	if len(allowedMethods) > 0 {
+		methodsHeader = strings.Join(allowedMethods, ", ")
+		methodSet = make(map[string]struct{}, len(allowedMethods))
+		for _, m := range allowedMethods {
+			methodSet[m] = struct{}{}
+		}
+	}
+	configuredHeaders := strings.Join(corsListFromEnv("DMR_CORS_HEADERS"), ", ")
 	allowAll := len(allowedOrigins) == 1 && allowedOrigins[0] == "*"
 	allowedSet := make(map[string]struct{}, len(allowedOrigins))
 	for _, o := range allowedOrigins {
@@ -36,6 +64,17 @@ func CorsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 
+		// Reject cross-origin requests using methods outside the configured
+		// set. Browsers send simple GET and POST requests without a preflight,
+		// so this can't be left to the preflight response alone.
+		if origin != "" && methodSet != nil && r.Method != http.MethodOptions {
+			if _, ok := methodSet[r.Method]; !ok {
+				w.Header().Set("Allow", methodsHeader)
+				http.Error(w, "Method not allowed for cross-origin requests", http.StatusMethodNotAllowed)
+				return
+			}
+		}
+
 		// Handle OPTIONS requests with origin validation.
 		// Only intercept OPTIONS if the origin is valid to prevent unauthorized preflight requests.
 		if r.Method == http.MethodOptions {
@@ -46,10 +85,21 @@ func CorsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
 				return
 			}
 
-			// Valid origin - handle OPTIONS with CORS headers
+			// Valid origin - handle OPTIONS with CORS headers. Unless headers
+			// are configured, allow the headers the browser asks for, since API
+			// clients send their own (e.g. OpenAI and Anthropic SDK headers).
+			allowedHeaders := configuredHeaders
+			if allowedHeaders == "" {
+				allowedHeaders = r.Header.Get("Access-Control-Request-Headers")
+			}
+			if allowedHeaders == "" {
+				allowedHeaders = preflightAllowedHeaders
+			}
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
-			w.Header().Set("Access-Control-Allow-Headers", "*")
+			w.Header().Set("Access-Control-Allow-Methods", methodsHeader)
+			w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+			w.Header().Add("Vary", "Origin, Access-Control-Request-Headers")
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
@@ -65,21 +115,39 @@ func originAllowed(origin string, allowedSet map[string]struct{}) bool {
 
 // getAllowedOrigins retrieves allowed origins from the DMR_ORIGINS environment variable.
 // If the variable is not set it returns nil, indicating no origins are allowed.
-func getAllowedOrigins() (origins []string) {
-	dmrOrigins := os.Getenv("DMR_ORIGINS")
-	if dmrOrigins == "" {
-		return nil
+func getAllowedOrigins() []string {
+	return corsListFromEnv("DMR_ORIGINS")
+}
+
+// getAllowedMethods retrieves the methods permitted for cross-origin requests
+// from the DMR_CORS_METHODS environment variable, in upper case. If the
+// variable is not set it returns nil, indicating the default set applies.
+func getAllowedMethods() []string {
+	methods := corsListFromEnv("DMR_CORS_METHODS")
+	for i, m := range methods {
+		methods[i] = strings.ToUpper(m)
 	}
+	return methods
+}
 
-	for _, o := range strings.Split(dmrOrigins, ",") {
-		if trimmed := strings.TrimSpace(o); trimmed != "" {
-			origins = append(origins, trimmed)
+// corsListFromEnv parses a comma-separated list from the named environment
+// variable, returning nil if it is unset or has no entries.
+func corsListFromEnv(name string) (values []string) {
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if trimmed := strings.TrimSpace(v); trimmed != "" {
+			values = append(values, trimmed)
 		}
 	}
+	return values
+}
 
-	if len(origins) == 0 {
-		return nil
+// getPreflightMaxAge returns the preflight cache duration in seconds from the
+// DMR_CORS_MAX_AGE environment variable, or defaultPreflightMaxAge if it is
+// unset or invalid.
+func getPreflightMaxAge() int {
+	maxAge, err := strconv.Atoi(os.Getenv("DMR_CORS_MAX_AGE"))
+	if err != nil || maxAge < 0 {
+		return defaultPreflightMaxAge
 	}
-
-	return origins
+	return maxAge
 }
@@ -49,8 +49,9 @@ func TestCorsMiddleware(t *testing.T) {
 			wantStatus:     http.StatusNoContent,
 			wantHeaders: map[string]string{
 				"Access-Control-Allow-Credentials": "true",
-				"Access-Control-Allow-Methods":     "GET, POST, DELETE",
-				"Access-Control-Allow-Headers":     "*",
+				"Access-Control-Allow-Methods":     "GET, HEAD, POST, DELETE",
+				"Access-Control-Allow-Headers":     preflightAllowedHeaders,
+				"Access-Control-Max-Age":           "300",
 			},
 		},
 		{
@@ -104,6 +105,84 @@ func TestCorsMiddleware(t *testing.T) {
 	}
 }
 
+func TestCorsMiddlewarePreflight(t *testing.T) {
+	t.Setenv("DMR_CORS_MAX_AGE", "600")
+	handler := CorsMiddleware([]string{"http://localhost:3000"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("preflight should not reach the router")
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/engines/v1/chat/completions", http.NoBody)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type, authorization, x-stainless-os")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rec.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":  "http://localhost:3000",
+		"Access-Control-Allow-Methods": "GET, HEAD, POST, DELETE",
+		"Access-Control-Allow-Headers": "content-type, authorization, x-stainless-os",
+		"Access-Control-Max-Age":       "600",
+	}
+	for k, v := range want {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("expected %s to be %q, got %q", k, v, got)
+		}
+	}
+}
+
+func TestCorsMiddlewareRestrictsMethodsAndHeaders(t *testing.T) {
+	t.Setenv("DMR_CORS_METHODS", "post")
+	t.Setenv("DMR_CORS_HEADERS", "Content-Type")
+	handler := CorsMiddleware([]string{"http://embed.example"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/engines/v1/embeddings", http.NoBody)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		req.Header.Set("Access-Control-Request-Headers", "content-type, authorization")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	preflight := serve(http.MethodOptions, "http://embed.example")
+	if preflight.Code != http.StatusNoContent {
+		t.Fatalf("expected preflight status %d, got %d", http.StatusNoContent, preflight.Code)
+	}
+	if got := preflight.Header().Get("Access-Control-Allow-Methods"); got != "POST" {
+		t.Errorf("expected only POST to be allowed, got %q", got)
+	}
+	if got := preflight.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+		t.Errorf("expected only Content-Type to be allowed, got %q", got)
+	}
+
+	if rec := serve(http.MethodPost, "http://embed.example"); rec.Code != http.StatusOK {
+		t.Errorf("expected cross-origin POST to be allowed, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodDelete, "http://embed.example"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected cross-origin DELETE to be rejected, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodDelete, ""); rec.Code != http.StatusOK {
+		t.Errorf("expected same-origin DELETE to be unaffected, got %d", rec.Code)
+	}
+}
+
+func TestGetPreflightMaxAge(t *testing.T) {
+	for value, want := range map[string]int{"": 300, "0": 0, "7200": 7200, "-1": 300, "5m": 300} {
+		t.Setenv("DMR_CORS_MAX_AGE", value)
+		if got := getPreflightMaxAge(); got != want {
+			t.Errorf("DMR_CORS_MAX_AGE=%q: expected %d, got %d", value, want, got)
+		}
+	}
+}
+
 func TestOriginAllowed(t *testing.T) {
 	t.Parallel()
 	set := map[string]struct{}{
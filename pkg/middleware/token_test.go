@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenAuthHandler(t *testing.T) {
+	t.Parallel()
+
+	h := &TokenAuthHandler{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		Token: "s3cret",
+	}
+
+	tests := []struct {
+		name       string
+		auth       string
+		network    string
+		wantStatus int
+	}{
+		{name: "missing token over tcp", network: "tcp", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token over tcp", auth: "Bearer nope", network: "tcp", wantStatus: http.StatusUnauthorized},
+		{name: "valid token over tcp", auth: "Bearer s3cret", network: "tcp", wantStatus: http.StatusOK},
+		{name: "no token over unix socket", network: "unix", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var addr net.Addr = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 12434}
+			if tt.network == "unix" {
+				addr = &net.UnixAddr{Name: "model-runner.sock", Net: "unix"}
+			}
+			ctx := context.WithValue(context.Background(), http.LocalAddrContextKey, addr)
+			req := httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody).WithContext(ctx)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/docker/model-runner/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries a caller-supplied request identifier. It is echoed
+// back in the response and recorded on the request span; if absent, the trace
+// ID is returned in its place so callers can find the trace.
+const RequestIDHeader = "X-Request-ID"
+
+// TracingHandler extracts W3C trace context from incoming requests and wraps
+// each request in a server span.
+type TracingHandler struct {
+	Handler http.Handler
+}
+
+func (h *TracingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		),
+	)
+	defer span.End()
+
+	if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+		span.SetAttributes(attribute.String("request.id", requestID))
+		w.Header().Set(RequestIDHeader, requestID)
+	} else if sc := span.SpanContext(); sc.HasTraceID() {
+		w.Header().Set(RequestIDHeader, sc.TraceID().String())
+	}
+
+	h.Handler.ServeHTTP(w, r.WithContext(ctx))
+}
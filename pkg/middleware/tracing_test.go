@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingHandlerPropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	var seen trace.SpanContext
+	h := &TracingHandler{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = trace.SpanContextFromContext(r.Context())
+	})}
+
+	req := httptest.NewRequest(http.MethodPost, "/engines/v1/chat/completions", http.NoBody)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if seen.TraceID().String() != traceID {
+		t.Errorf("Expected trace ID %s in request context, got %s", traceID, seen.TraceID())
+	}
+	if got := w.Header().Get(RequestIDHeader); got != traceID {
+		t.Errorf("Expected %s header %s, got %q", RequestIDHeader, traceID, got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/models", http.NoBody)
+	req.Header.Set(RequestIDHeader, "req-123")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if got := w.Header().Get(RequestIDHeader); got != "req-123" {
+		t.Errorf("Expected caller request ID to be echoed, got %q", got)
+	}
+}
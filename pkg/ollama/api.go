@@ -1,6 +1,9 @@
 package ollama
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 const (
 	// APIPrefix Ollama API prefix
@@ -45,6 +48,9 @@ type ShowResponse struct {
 	Parameters string       `json:"parameters,omitempty"`
 	Template   string       `json:"template,omitempty"`
 	Details    ModelDetails `json:"details,omitempty"`
+	// Capabilities lists what the model supports: "completion" or
+	// "embedding", and "tools" and "vision".
+	Capabilities []string `json:"capabilities,omitempty"`
 }
 
 // ChatRequest is the request for /api/chat
@@ -56,7 +62,12 @@ type ChatRequest struct {
 	Stream    *bool                  `json:"stream,omitempty"`
 	Think     interface{}            `json:"think,omitempty"`      // Can be bool or string ("high", "medium", "low") for reasoning/thinking models
 	KeepAlive string                 `json:"keep_alive,omitempty"` // Duration like "5m" or "0s" to unload immediately
+	Format    json.RawMessage        `json:"format,omitempty"`     // "json" or a JSON schema object for structured output
 	Options   map[string]interface{} `json:"options,omitempty"`
+	// Logprobs requests the log-probability of each generated token, and
+	// TopLogprobs the number of most likely alternatives returned with it.
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs int  `json:"top_logprobs,omitempty"`
 }
 
 // Message represents a chat message
@@ -103,6 +114,21 @@ type ChatResponse struct {
 	Message    Message   `json:"message,omitempty"`
 	Done       bool      `json:"done"`
 	DoneReason string    `json:"done_reason,omitempty"`
+	Logprobs   []Logprob `json:"logprobs,omitempty"`
+}
+
+// TokenLogprob is the log-probability of a token.
+type TokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
+
+// Logprob is the log-probability of a generated token, along with the most
+// likely alternatives if requested.
+type Logprob struct {
+	TokenLogprob
+	TopLogprobs []TokenLogprob `json:"top_logprobs,omitempty"`
 }
 
 // GenerateRequest is the request for /api/generate
@@ -114,6 +140,9 @@ type GenerateRequest struct {
 	Think     interface{}            `json:"think,omitempty"`      // Can be bool or string ("high", "medium", "low") for reasoning/thinking models
 	KeepAlive string                 `json:"keep_alive,omitempty"` // Duration like "5m" or "0s" to unload immediately
 	Options   map[string]interface{} `json:"options,omitempty"`
+	// Logprobs and TopLogprobs are as for ChatRequest.
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs int  `json:"top_logprobs,omitempty"`
 }
 
 // GenerateResponse is the response for /api/generate
@@ -123,6 +152,7 @@ type GenerateResponse struct {
 	Response  string    `json:"response,omitempty"`
 	Thinking  string    `json:"thinking,omitempty"` // The model's generated thinking output
 	Done      bool      `json:"done"`
+	Logprobs  []Logprob `json:"logprobs,omitempty"`
 }
 
 // DeleteRequest is the request for DELETE /api/delete
@@ -149,6 +179,15 @@ type PSModel struct {
 	SizeVram  int64     `json:"size_vram,omitempty"`
 }
 
+// streamStatus is an informational line streamed before generation begins,
+// e.g. while the model is being loaded
+type streamStatus struct {
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	Done      bool      `json:"done"`
+}
+
 // ollamaPullStatus represents the Ollama pull status response format
 type ollamaPullStatus struct {
 	Status    string `json:"status,omitempty"`
@@ -166,20 +205,41 @@ type openAIChatResponse struct {
 			ReasoningContent string     `json:"reasoning_content,omitempty"`
 			ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
 		} `json:"message"`
+		Logprobs *openAILogprobs `json:"logprobs,omitempty"`
 	} `json:"choices"`
 }
 
+// openAILogprobs represents the token log-probabilities of an OpenAI chat
+// completion choice, which share Ollama's format.
+type openAILogprobs struct {
+	Content []Logprob `json:"content"`
+}
+
 // openAIChatStreamChunk represents a chunk from OpenAI chat completion stream
 type openAIChatStreamChunk struct {
 	Choices []struct {
 		Delta struct {
-			Content          string     `json:"content"`
-			ReasoningContent string     `json:"reasoning_content,omitempty"`
-			ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
+			Content          string                `json:"content"`
+			ReasoningContent string                `json:"reasoning_content,omitempty"`
+			ToolCalls        []openAIToolCallDelta `json:"tool_calls,omitempty"`
 		} `json:"delta"`
+		Logprobs     *openAILogprobs `json:"logprobs,omitempty"`
+		FinishReason string          `json:"finish_reason,omitempty"`
 	} `json:"choices"`
 }
 
+// openAIToolCallDelta represents a fragment of a tool call in an OpenAI chat
+// completion stream. Fragments sharing an index belong to the same tool call.
+type openAIToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
 // openAIErrorResponse represents the OpenAI error response format
 type openAIErrorResponse struct {
 	Error struct {
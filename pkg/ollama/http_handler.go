@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/docker/model-runner/pkg/distribution/distribution"
+	"github.com/docker/model-runner/pkg/distribution/modelpack"
 	"github.com/docker/model-runner/pkg/distribution/oci"
+	"github.com/docker/model-runner/pkg/distribution/types"
 	"github.com/docker/model-runner/pkg/inference"
 	"github.com/docker/model-runner/pkg/inference/models"
 	"github.com/docker/model-runner/pkg/inference/scheduling"
@@ -35,6 +42,9 @@ type HTTPHandler struct {
 	modelManager  *models.Manager
 	scheduler     *scheduling.Scheduler
 	schedulerHTTP http.Handler
+	// loadKeepAliveInterval is the interval at which streaming responses
+	// send empty chunks while their model loads, or zero to send none.
+	loadKeepAliveInterval time.Duration
 }
 
 // NewHTTPHandler creates a new Ollama API handler
@@ -45,12 +55,16 @@ func NewHTTPHandler(log logging.Logger, scheduler *scheduling.Scheduler, schedul
 		scheduler:     scheduler,
 		schedulerHTTP: schedulerHTTP,
 		modelManager:  modelManager,
+		// Keep cold-start streams warm so clients don't time out.
+		loadKeepAliveInterval: loadKeepAliveIntervalFromEnv(log),
 	}
 
 	// Register routes
 	for route, handler := range h.routeHandlers() {
 		h.router.HandleFunc(route, handler)
 	}
+	// Catch-all for unregistered endpoints, returns an Ollama-style JSON error
+	h.router.HandleFunc("/", h.handleNotFound)
 
 	// Apply CORS middleware
 	h.httpHandler = middleware.CorsMiddleware(allowedOrigins, h.router)
@@ -69,14 +83,36 @@ func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // routeHandlers returns the mapping of routes to their handlers
 func (h *HTTPHandler) routeHandlers() map[string]http.HandlerFunc {
 	return map[string]http.HandlerFunc{
-		"GET " + APIPrefix + "/version":   h.handleVersion,
-		"GET " + APIPrefix + "/tags":      h.handleListModels,
-		"GET " + APIPrefix + "/ps":        h.handlePS,
-		"POST " + APIPrefix + "/show":     h.handleShowModel,
-		"POST " + APIPrefix + "/chat":     h.handleChat,
-		"POST " + APIPrefix + "/generate": h.handleGenerate,
-		"POST " + APIPrefix + "/pull":     h.handlePull,
-		"DELETE " + APIPrefix + "/delete": h.handleDelete,
+		"GET " + APIPrefix + "/version":         h.handleVersion,
+		"GET " + APIPrefix + "/tags":            h.handleListModels,
+		"GET " + APIPrefix + "/ps":              h.handlePS,
+		"POST " + APIPrefix + "/show":           h.handleShowModel,
+		"POST " + APIPrefix + "/chat":           h.handleChat,
+		"POST " + APIPrefix + "/generate":       h.handleGenerate,
+		"POST " + APIPrefix + "/pull":           h.handlePull,
+		"DELETE " + APIPrefix + "/delete":       h.handleDelete,
+		"HEAD " + APIPrefix + "/blobs/{digest}": h.handleBlobExists,
+		"POST " + APIPrefix + "/blobs/{digest}": h.handleCreateBlob,
+	}
+}
+
+// handleNotFound handles requests to endpoints that are not implemented
+func (h *HTTPHandler) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	h.log.Warnf("Ollama API endpoint not found: %s %s",
+		utils.SanitizeForLog(r.Method, -1), utils.SanitizeForLog(r.URL.Path, -1))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": "endpoint not found"}); err != nil {
+		h.log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// writeError writes an error response in Ollama's format.
+func (h *HTTPHandler) writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		h.log.Errorf("Failed to encode response: %v", err)
 	}
 }
 
@@ -333,8 +369,16 @@ func (h *HTTPHandler) handleShowModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	template := readChatTemplate(model)
+	parameters := showParameters(config, h.runnerConfig(r.Context(), modelName))
+	license := modelLicense(model, config)
+
 	// Build response
 	response := ShowResponse{
+		License:    license,
+		Modelfile:  buildModelfile(modelName, template, parameters, license),
+		Parameters: parameters,
+		Template:   template,
 		Details: ModelDetails{
 			Format:            "gguf",
 			Family:            config.GetArchitecture(),
@@ -342,6 +386,7 @@ func (h *HTTPHandler) handleShowModel(w http.ResponseWriter, r *http.Request) {
 			ParameterSize:     config.GetParameters(),
 			QuantizationLevel: config.GetQuantization(),
 		},
+		Capabilities: showCapabilities(models.ModelCapabilities(model, config)),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -350,6 +395,208 @@ func (h *HTTPHandler) handleShowModel(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// showCapabilities converts model capabilities to Ollama's capability names.
+func showCapabilities(capabilities models.Capabilities) []string {
+	if capabilities.SupportsEmbeddings {
+		return []string{"embedding"}
+	}
+	names := []string{"completion"}
+	if capabilities.SupportsTools {
+		names = append(names, "tools")
+	}
+	if capabilities.SupportsVision {
+		names = append(names, "vision")
+	}
+	return names
+}
+
+// ggufSamplingKeys maps GGUF sampling metadata keys to Ollama parameter names.
+var ggufSamplingKeys = []struct {
+	key   string
+	param string
+}{
+	{"general.sampling.temp", "temperature"},
+	{"general.sampling.top_k", "top_k"},
+	{"general.sampling.top_p", "top_p"},
+	{"general.sampling.min_p", "min_p"},
+	{"general.sampling.penalty_repeat", "repeat_penalty"},
+}
+
+// runtimeFlagParams maps backend runtime flags to Ollama parameter names.
+var runtimeFlagParams = map[string]string{
+	"-c":               "num_ctx",
+	"--ctx-size":       "num_ctx",
+	"--max-model-len":  "num_ctx",
+	"--temp":           "temperature",
+	"--top-k":          "top_k",
+	"--top-p":          "top_p",
+	"--min-p":          "min_p",
+	"--repeat-penalty": "repeat_penalty",
+	"-s":               "seed",
+	"--seed":           "seed",
+	"-n":               "num_predict",
+	"--n-predict":      "num_predict",
+}
+
+// runnerConfig returns the runner configuration set for model with configure,
+// preferring the completion mode one, or nil if there is none.
+func (h *HTTPHandler) runnerConfig(ctx context.Context, model string) *scheduling.ModelConfigEntry {
+	if h.scheduler == nil {
+		return nil
+	}
+	var config *scheduling.ModelConfigEntry
+	for _, entry := range h.scheduler.RunnerConfigs(ctx, model) {
+		if config == nil || entry.Mode == inference.BackendModeCompletion {
+			config = &entry
+		}
+	}
+	return config
+}
+
+// showParameters builds the Ollama parameters string ("name value" per line)
+// from the model's context size and any sampling defaults in its metadata,
+// overridden by the runner configuration set for the model, if any.
+func showParameters(config types.ModelConfig, runner *scheduling.ModelConfigEntry) string {
+	var names []string
+	values := make(map[string]string)
+	set := func(name, value string) {
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = value
+	}
+
+	if ctxSize := config.GetContextSize(); ctxSize != nil {
+		set("num_ctx", strconv.FormatInt(int64(*ctxSize), 10))
+	}
+	if cfg, ok := config.(*types.Config); ok {
+		for _, k := range ggufSamplingKeys {
+			if v, ok := cfg.GGUF[k.key]; ok && v != "" {
+				set(k.param, v)
+			}
+		}
+	}
+	if runner != nil {
+		flags := runner.Config.RuntimeFlags
+		for i := 0; i < len(flags); i++ {
+			flag, value, hasValue := strings.Cut(flags[i], "=")
+			name, ok := runtimeFlagParams[flag]
+			if !ok {
+				continue
+			}
+			if !hasValue {
+				if i+1 == len(flags) {
+					break
+				}
+				i++
+				value = flags[i]
+			}
+			set(name, value)
+		}
+		if ctxSize := runner.Config.ContextSize; ctxSize != nil {
+			set("num_ctx", strconv.FormatInt(int64(*ctxSize), 10))
+		}
+	}
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%-30s %s", name, values[name]))
+	}
+	if runner != nil {
+		for _, stop := range runner.DefaultStop {
+			lines = append(lines, fmt.Sprintf("%-30s %s", "stop", strconv.Quote(stop)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readChatTemplate returns the model's chat template, or an empty string if
+// the model does not ship one.
+func readChatTemplate(model types.Model) string {
+	path, err := model.ChatTemplatePath()
+	if err != nil || path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// modelLicense returns the model's license from its ModelPack descriptor or
+// the OCI licenses manifest annotation, if present.
+func modelLicense(model types.Model, config types.ModelConfig) string {
+	if mp, ok := config.(*modelpack.Model); ok && len(mp.Descriptor.Licenses) > 0 {
+		return strings.Join(mp.Descriptor.Licenses, ", ")
+	}
+	if m, ok := model.(interface{ Manifest() (*oci.Manifest, error) }); ok {
+		if manifest, err := m.Manifest(); err == nil && manifest != nil {
+			return manifest.Annotations[annotationLicenses]
+		}
+	}
+	return ""
+}
+
+// annotationLicenses is the OCI annotation key holding the license expression.
+const annotationLicenses = "org.opencontainers.image.licenses"
+
+// buildModelfile builds a minimal synthetic Modelfile for /api/show clients
+// that parse it.
+func buildModelfile(modelName, template, parameters, license string) string {
+	var sb strings.Builder
+	sb.WriteString("# Modelfile generated by Docker Model Runner\n")
+	fmt.Fprintf(&sb, "FROM %s\n", modelName)
+	if template != "" {
+		fmt.Fprintf(&sb, "TEMPLATE \"\"\"%s\"\"\"\n", template)
+	}
+	for _, line := range strings.Split(parameters, "\n") {
+		if name, value, ok := strings.Cut(line, " "); ok {
+			fmt.Fprintf(&sb, "PARAMETER %s %s\n", name, strings.TrimSpace(value))
+		}
+	}
+	if license != "" {
+		fmt.Fprintf(&sb, "LICENSE \"\"\"%s\"\"\"\n", license)
+	}
+	return sb.String()
+}
+
+// handleBlobExists handles HEAD /api/blobs/{digest}
+func (h *HTTPHandler) handleBlobExists(w http.ResponseWriter, r *http.Request) {
+	digest := r.PathValue("digest")
+	exists, err := h.modelManager.HasBlob(digest)
+	if err != nil {
+		h.log.Warnf("Failed to check blob %s: %v", utils.SanitizeForLog(digest, -1), err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleCreateBlob handles POST /api/blobs/{digest}
+func (h *HTTPHandler) handleCreateBlob(w http.ResponseWriter, r *http.Request) {
+	digest := r.PathValue("digest")
+	if _, err := oci.NewHash(digest); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid digest: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.modelManager.WriteBlob(digest, r.Body); err != nil {
+		h.log.Errorf("Failed to write blob %s: %v", utils.SanitizeForLog(digest, -1), err)
+		if errors.Is(err, distribution.ErrBlobDigestMismatch) {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to write blob: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
 // handleChat handles POST /api/chat
 func (h *HTTPHandler) handleChat(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -366,8 +613,21 @@ func (h *HTTPHandler) handleChat(w http.ResponseWriter, r *http.Request) {
 		modelName = req.Model
 	}
 
+	if err := validateOllamaOptions(req.Options); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.scheduler.CheckModelSupported(modelName); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Configure model
-	h.configureModel(ctx, modelName, req.Options, req.Think, r.UserAgent()+" (Ollama API)")
+	if err := h.configureModel(clampNotifierContext(ctx, w), modelName, req.Options, req.Think, r.UserAgent()+" (Ollama API)"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Convert to OpenAI format chat completion request
 	openAIReq := map[string]interface{}{
@@ -381,6 +641,16 @@ func (h *HTTPHandler) handleChat(w http.ResponseWriter, r *http.Request) {
 		openAIReq["tools"] = req.Tools
 	}
 
+	// Map Ollama think to the per-request reasoning toggle
+	applyThinkToRequest(req.Think, openAIReq)
+
+	// Map Ollama structured-output format to OpenAI response_format
+	if responseFormat := convertFormatToResponseFormat(req.Format); responseFormat != nil {
+		openAIReq["response_format"] = responseFormat
+	}
+
+	applyLogprobsToRequest(req.Logprobs, req.TopLogprobs, openAIReq)
+
 	// Map Ollama options to OpenAI format
 	if req.Options != nil {
 		h.mapOllamaOptionsToOpenAI(req.Options, openAIReq)
@@ -390,9 +660,19 @@ func (h *HTTPHandler) handleChat(w http.ResponseWriter, r *http.Request) {
 	h.proxyToChatCompletions(ctx, w, r, openAIReq, modelName, req.Stream == nil || *req.Stream)
 }
 
+// clampNotifierContext returns ctx set up to report a clamped context size to
+// the client through w.
+func clampNotifierContext(ctx context.Context, w http.ResponseWriter) context.Context {
+	return scheduling.WithClampNotifier(ctx, func(maxSize int32) {
+		w.Header().Set(inference.ContextSizeClampedHeader, strconv.Itoa(int(maxSize)))
+	})
+}
+
 // configureModel extracts and applies model configuration options.
 // Handles num_ctx from options and think parameter for reasoning budget.
-func (h *HTTPHandler) configureModel(ctx context.Context, modelName string, options map[string]interface{}, think interface{}, userAgent string) {
+// Configuration failures are logged and ignored, except for a context size
+// above the server's maximum, which is returned.
+func (h *HTTPHandler) configureModel(ctx context.Context, modelName string, options map[string]interface{}, think interface{}, userAgent string) error {
 	var contextSize int32
 	var hasContextSize bool
 
@@ -425,11 +705,14 @@ func (h *HTTPHandler) configureModel(ctx context.Context, modelName string, opti
 			}
 		}
 		_, err := h.scheduler.ConfigureRunner(ctx, nil, configureRequest, userAgent) // TODO add backend selection?
-		if err != nil {
+		if errors.Is(err, scheduling.ErrContextSizeExceeded) {
+			return err
+		} else if err != nil {
 			// Log the error but continue with the request
 			h.log.Warnf("configureModel: failed to configure model %s: %v", sanitizedModelName, err)
 		}
 	}
+	return nil
 }
 
 // isZeroKeepAlive checks if the keep-alive duration string represents zero duration.
@@ -455,13 +738,26 @@ func (h *HTTPHandler) handleGenerate(w http.ResponseWriter, r *http.Request) {
 		modelName = req.Model
 	}
 
+	if err := validateOllamaOptions(req.Options); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.scheduler.CheckModelSupported(modelName); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	if req.Prompt == "" && isZeroKeepAlive(req.KeepAlive) {
 		h.unloadModel(ctx, w, modelName)
 		return
 	}
 
 	// Configure model
-	h.configureModel(ctx, modelName, req.Options, req.Think, r.UserAgent()+" (Ollama API)")
+	if err := h.configureModel(clampNotifierContext(ctx, w), modelName, req.Options, req.Think, r.UserAgent()+" (Ollama API)"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if req.Prompt == "" {
 		// Empty prompt - preload the model (already configured above)
@@ -481,6 +777,11 @@ func (h *HTTPHandler) handleGenerate(w http.ResponseWriter, r *http.Request) {
 		"stream": req.Stream == nil || *req.Stream,
 	}
 
+	// Map Ollama think to the per-request reasoning toggle
+	applyThinkToRequest(req.Think, openAIReq)
+
+	applyLogprobsToRequest(req.Logprobs, req.TopLogprobs, openAIReq)
+
 	// Map Ollama options to OpenAI format
 	if req.Options != nil {
 		h.mapOllamaOptionsToOpenAI(req.Options, openAIReq)
@@ -676,6 +977,16 @@ func (h *HTTPHandler) handlePull(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// validateOllamaOptions rejects options that can't be honoured through the
+// Ollama API. Ollama responses carry a single message, so multiple completions
+// (n > 1) are refused rather than silently dropping all but the first.
+func validateOllamaOptions(ollamaOpts map[string]interface{}) error {
+	if val, ok := ollamaOpts["n"]; ok && convertToInt32(val) > 1 {
+		return errors.New("multiple completions (n > 1) are not supported by the Ollama API; use the OpenAI API instead")
+	}
+	return nil
+}
+
 // mapOllamaOptionsToOpenAI maps Ollama API options to OpenAI-compatible format
 // This function handles all standard Ollama options and maps them to their OpenAI equivalents
 func (h *HTTPHandler) mapOllamaOptionsToOpenAI(ollamaOpts map[string]interface{}, openAIReq map[string]interface{}) {
@@ -816,6 +1127,37 @@ func convertMessages(messages []Message) []map[string]interface{} {
 	return result
 }
 
+// convertFormatToResponseFormat converts an Ollama "format" value to an OpenAI
+// response_format. "json" maps to a json_object response and a schema object
+// maps to a json_schema response. Whether the output is actually constrained
+// depends on the backend; backends without constrained decoding ignore it.
+// Returns nil if format is empty or not recognized.
+func convertFormatToResponseFormat(format json.RawMessage) map[string]interface{} {
+	if len(format) == 0 {
+		return nil
+	}
+
+	var formatStr string
+	if err := json.Unmarshal(format, &formatStr); err == nil {
+		if formatStr == "json" {
+			return map[string]interface{}{"type": "json_object"}
+		}
+		return nil
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(format, &schema); err != nil || schema == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   "response",
+			"schema": schema,
+		},
+	}
+}
+
 // convertThinkToReasoningBudget converts the Ollama 'think' parameter to llama.cpp's 'reasoning_budget'.
 // The think parameter must be a boolean:
 // - true: unlimited reasoning (-1)
@@ -841,6 +1183,46 @@ func convertThinkToReasoningBudget(think interface{}) *int32 {
 	return nil
 }
 
+// applyThinkToRequest maps the Ollama 'think' parameter onto the OpenAI request.
+// A boolean toggles thinking through chat_template_kwargs.enable_thinking, which
+// llama.cpp and vLLM honor per request. A level ("high", "medium", "low") enables
+// thinking and is forwarded as reasoning_effort. The request is left unchanged if
+// think is absent or invalid. Reasoning output is returned by the backend as
+// reasoning_content and surfaced in the Ollama 'thinking' field.
+func applyThinkToRequest(think interface{}, openAIReq map[string]interface{}) {
+	switch v := think.(type) {
+	case bool:
+		openAIReq["chat_template_kwargs"] = map[string]interface{}{"enable_thinking": v}
+	case string:
+		switch v {
+		case "high", "medium", "low":
+			openAIReq["chat_template_kwargs"] = map[string]interface{}{"enable_thinking": true}
+			openAIReq["reasoning_effort"] = v
+		}
+	}
+}
+
+// applyLogprobsToRequest maps the Ollama logprobs and top_logprobs parameters,
+// which share the OpenAI names, onto the OpenAI request.
+func applyLogprobsToRequest(logprobs bool, topLogprobs int, openAIReq map[string]interface{}) {
+	if !logprobs {
+		return
+	}
+	openAIReq["logprobs"] = true
+	if topLogprobs > 0 {
+		openAIReq["top_logprobs"] = topLogprobs
+	}
+}
+
+// choiceLogprobs returns the token log-probabilities of an OpenAI choice, if
+// any.
+func choiceLogprobs(logprobs *openAILogprobs) []Logprob {
+	if logprobs == nil {
+		return nil
+	}
+	return logprobs.Content
+}
+
 // convertToInt32 converts various numeric types to int32
 func convertToInt32(v interface{}) int32 {
 	switch val := v.(type) {
@@ -888,8 +1270,14 @@ func (h *HTTPHandler) proxyToChatCompletions(ctx context.Context, w http.Respons
 			modelName: modelName,
 			log:       h.log,
 		}
+		// Tell the client the model is loading so a cold start doesn't look hung
+		newReq = newReq.WithContext(scheduling.WithLoadNotifier(newReq.Context(), func() {
+			streamWriter.writeStatus(statusLoadingModel)
+			streamWriter.startKeepAlive(h.loadKeepAliveInterval)
+		}))
 		// Forward to scheduler HTTP handler with streaming writer
 		h.schedulerHTTP.ServeHTTP(streamWriter, newReq)
+		streamWriter.finish()
 		return
 	}
 
@@ -931,8 +1319,14 @@ func (h *HTTPHandler) proxyToCompletions(ctx context.Context, w http.ResponseWri
 			modelName: modelName,
 			log:       h.log,
 		}
+		// Tell the client the model is loading so a cold start doesn't look hung
+		newReq = newReq.WithContext(scheduling.WithLoadNotifier(newReq.Context(), func() {
+			streamWriter.writeStatus(statusLoadingModel)
+			streamWriter.startKeepAlive(h.loadKeepAliveInterval)
+		}))
 		// Forward to scheduler HTTP handler with streaming writer
 		h.schedulerHTTP.ServeHTTP(streamWriter, newReq)
+		streamWriter.finish()
 		return
 	}
 
@@ -950,6 +1344,50 @@ func (h *HTTPHandler) proxyToCompletions(ctx context.Context, w http.ResponseWri
 	h.convertGenerateResponse(w, respRecorder, modelName)
 }
 
+// statusLoadingModel is streamed while the scheduler loads a model that isn't resident
+const statusLoadingModel = "loading model"
+
+// writeStreamStatus writes an Ollama status line and flushes it to the client
+func writeStreamStatus(w http.ResponseWriter, modelName, status string) {
+	writeStreamChunk(w, streamStatus{
+		Model:     modelName,
+		CreatedAt: time.Now(),
+		Status:    status,
+	})
+}
+
+// writeStreamChunk writes an Ollama stream line and flushes it to the client
+func writeStreamChunk(w http.ResponseWriter, chunk interface{}) {
+	jsonData, err := json.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	_, _ = w.Write(jsonData)
+	_, _ = w.Write([]byte("\n"))
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// streamFailure collects the error response of a failed streaming request,
+// e.g. a model that fails to load, to report it in Ollama's format
+type streamFailure struct {
+	status int
+	body   strings.Builder
+}
+
+// message returns the error message of the failed request
+func (f *streamFailure) message() string {
+	var openAIErr openAIErrorResponse
+	if err := json.Unmarshal([]byte(f.body.String()), &openAIErr); err == nil && openAIErr.Error.Message != "" {
+		return openAIErr.Error.Message
+	}
+	if message := strings.TrimSpace(f.body.String()); message != "" {
+		return message
+	}
+	return http.StatusText(f.status)
+}
+
 // responseRecorder is a custom ResponseWriter that records the response
 type responseRecorder struct {
 	statusCode int
@@ -976,16 +1414,97 @@ type streamingChatResponseWriter struct {
 	log         logging.Logger
 	buffer      strings.Builder
 	headersSent bool
+	// toolCalls accumulates streamed tool call fragments keyed by index until
+	// the tool calls are complete.
+	toolCalls map[int]*pendingToolCall
+	// keepAlive sends empty chunks while the model loads, if started.
+	keepAlive *loadKeepAlive
+	// failure holds the upstream error response, if the request failed.
+	failure *streamFailure
+}
+
+// pendingToolCall is a tool call being assembled from stream fragments.
+type pendingToolCall struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+// addToolCallDeltas buffers streamed tool call fragments.
+func (s *streamingChatResponseWriter) addToolCallDeltas(deltas []openAIToolCallDelta) {
+	if s.toolCalls == nil {
+		s.toolCalls = make(map[int]*pendingToolCall)
+	}
+	for _, d := range deltas {
+		p, ok := s.toolCalls[d.Index]
+		if !ok {
+			p = &pendingToolCall{}
+			s.toolCalls[d.Index] = p
+		}
+		if d.ID != "" {
+			p.id = d.ID
+		}
+		if d.Function.Name != "" {
+			p.name = d.Function.Name
+		}
+		p.arguments.WriteString(d.Function.Arguments)
+	}
+}
+
+// flushToolCalls emits the buffered tool calls, in index order, as a single
+// Ollama chunk.
+func (s *streamingChatResponseWriter) flushToolCalls() {
+	if len(s.toolCalls) == 0 {
+		return
+	}
+	indices := make([]int, 0, len(s.toolCalls))
+	for i := range s.toolCalls {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	toolCalls := make([]ToolCall, 0, len(indices))
+	for _, i := range indices {
+		p := s.toolCalls[i]
+		toolCalls = append(toolCalls, ToolCall{
+			ID:       p.id,
+			Function: FunctionCall{Name: p.name, Arguments: p.arguments.String()},
+		})
+	}
+	s.toolCalls = nil
+
+	ollamaChunk := ChatResponse{
+		Model:     s.modelName,
+		CreatedAt: time.Now(),
+		Message: Message{
+			Role:      "assistant",
+			ToolCalls: convertToolCallsToOllamaFormat(toolCalls),
+		},
+	}
+	if jsonData, err := json.Marshal(ollamaChunk); err == nil {
+		_, _ = s.w.Write(jsonData)
+		_, _ = s.w.Write([]byte("\n"))
+	}
 }
 
 func (s *streamingChatResponseWriter) Header() http.Header {
+	s.keepAlive.stop()
 	return s.w.Header()
 }
 
 func (s *streamingChatResponseWriter) WriteHeader(statusCode int) {
+	s.keepAlive.stop()
+	if statusCode != http.StatusOK {
+		// Collect the error response to report it once the request ends
+		s.failure = &streamFailure{status: statusCode}
+	}
+	if s.headersSent {
+		// Headers already went out with a status line
+		return
+	}
 	s.headersSent = true
 	if statusCode != http.StatusOK {
 		// Pass through non-success status codes
+		s.w.Header().Set("Content-Type", "application/json")
 		s.w.WriteHeader(statusCode)
 		return
 	}
@@ -995,10 +1514,44 @@ func (s *streamingChatResponseWriter) WriteHeader(statusCode int) {
 	s.w.WriteHeader(statusCode)
 }
 
+// finish stops the keepalive and reports a failed request as an Ollama error
+// line, since its status may already have gone out with a status line
+func (s *streamingChatResponseWriter) finish() {
+	s.keepAlive.stop()
+	if s.failure != nil {
+		writeStreamChunk(s.w, map[string]string{"error": s.failure.message()})
+	}
+}
+
+// writeStatus streams an informational status line to the client
+func (s *streamingChatResponseWriter) writeStatus(status string) {
+	if !s.headersSent {
+		s.WriteHeader(http.StatusOK)
+	}
+	writeStreamStatus(s.w, s.modelName, status)
+}
+
+// startKeepAlive sends an empty chunk every interval until the backend
+// responds.
+func (s *streamingChatResponseWriter) startKeepAlive(interval time.Duration) {
+	s.keepAlive = startLoadKeepAlive(interval, func() {
+		writeStreamChunk(s.w, ChatResponse{
+			Model:     s.modelName,
+			CreatedAt: time.Now(),
+			Message:   Message{Role: "assistant"},
+		})
+	})
+}
+
 func (s *streamingChatResponseWriter) Write(data []byte) (int, error) {
+	s.keepAlive.stop()
 	if !s.headersSent {
 		s.WriteHeader(http.StatusOK)
 	}
+	if s.failure != nil {
+		s.failure.body.Write(data)
+		return len(data), nil
+	}
 
 	// Add data to buffer
 	s.buffer.Write(data)
@@ -1025,6 +1578,8 @@ func (s *streamingChatResponseWriter) Write(data []byte) (int, error) {
 
 		dataStr := strings.TrimPrefix(line, "data: ")
 		if dataStr == "[DONE]" {
+			// Emit any tool calls that were not terminated by a finish reason
+			s.flushToolCalls()
 			// Send final done message
 			finalResp := ChatResponse{
 				Model:     s.modelName,
@@ -1045,27 +1600,35 @@ func (s *streamingChatResponseWriter) Write(data []byte) (int, error) {
 			continue
 		}
 
-		// Extract content, tool calls, and thinking from structured response
+		// Extract content and thinking from structured response. Tool call
+		// arguments arrive in fragments, so buffer them until complete.
 		var content string
 		var thinking string
-		var toolCalls []ToolCall
+		var finishReason string
+		var logprobs []Logprob
+		hasToolCalls := false
 		if len(chunk.Choices) > 0 {
 			content = chunk.Choices[0].Delta.Content
 			thinking = chunk.Choices[0].Delta.ReasoningContent
+			finishReason = chunk.Choices[0].FinishReason
+			logprobs = choiceLogprobs(chunk.Choices[0].Logprobs)
 			if len(chunk.Choices[0].Delta.ToolCalls) > 0 {
-				// Convert tool calls to Ollama format
-				toolCalls = convertToolCallsToOllamaFormat(chunk.Choices[0].Delta.ToolCalls)
+				hasToolCalls = true
+				s.addToolCallDeltas(chunk.Choices[0].Delta.ToolCalls)
 			}
 		}
+		if finishReason != "" {
+			s.flushToolCalls()
+		}
+		if hasToolCalls && content == "" && thinking == "" {
+			continue
+		}
 
 		// Build Ollama chunk
 		message := Message{
 			Role:    "assistant",
 			Content: content,
 		}
-		if len(toolCalls) > 0 {
-			message.ToolCalls = toolCalls
-		}
 		if thinking != "" {
 			message.Thinking = thinking
 		}
@@ -1075,6 +1638,7 @@ func (s *streamingChatResponseWriter) Write(data []byte) (int, error) {
 			CreatedAt: time.Now(),
 			Message:   message,
 			Done:      false,
+			Logprobs:  logprobs,
 		}
 
 		if jsonData, err := json.Marshal(ollamaChunk); err == nil {
@@ -1098,16 +1662,31 @@ type streamingGenerateResponseWriter struct {
 	log         logging.Logger
 	buffer      strings.Builder
 	headersSent bool
+	// keepAlive sends empty chunks while the model loads, if started.
+	keepAlive *loadKeepAlive
+	// failure holds the upstream error response, if the request failed.
+	failure *streamFailure
 }
 
 func (s *streamingGenerateResponseWriter) Header() http.Header {
+	s.keepAlive.stop()
 	return s.w.Header()
 }
 
 func (s *streamingGenerateResponseWriter) WriteHeader(statusCode int) {
+	s.keepAlive.stop()
+	if statusCode != http.StatusOK {
+		// Collect the error response to report it once the request ends
+		s.failure = &streamFailure{status: statusCode}
+	}
+	if s.headersSent {
+		// Headers already went out with a status line
+		return
+	}
 	s.headersSent = true
 	if statusCode != http.StatusOK {
 		// Pass through non-success status codes
+		s.w.Header().Set("Content-Type", "application/json")
 		s.w.WriteHeader(statusCode)
 		return
 	}
@@ -1117,10 +1696,43 @@ func (s *streamingGenerateResponseWriter) WriteHeader(statusCode int) {
 	s.w.WriteHeader(statusCode)
 }
 
+// finish stops the keepalive and reports a failed request as an Ollama error
+// line, since its status may already have gone out with a status line
+func (s *streamingGenerateResponseWriter) finish() {
+	s.keepAlive.stop()
+	if s.failure != nil {
+		writeStreamChunk(s.w, map[string]string{"error": s.failure.message()})
+	}
+}
+
+// writeStatus streams an informational status line to the client
+func (s *streamingGenerateResponseWriter) writeStatus(status string) {
+	if !s.headersSent {
+		s.WriteHeader(http.StatusOK)
+	}
+	writeStreamStatus(s.w, s.modelName, status)
+}
+
+// startKeepAlive sends an empty chunk every interval until the backend
+// responds.
+func (s *streamingGenerateResponseWriter) startKeepAlive(interval time.Duration) {
+	s.keepAlive = startLoadKeepAlive(interval, func() {
+		writeStreamChunk(s.w, GenerateResponse{
+			Model:     s.modelName,
+			CreatedAt: time.Now(),
+		})
+	})
+}
+
 func (s *streamingGenerateResponseWriter) Write(data []byte) (int, error) {
+	s.keepAlive.stop()
 	if !s.headersSent {
 		s.WriteHeader(http.StatusOK)
 	}
+	if s.failure != nil {
+		s.failure.body.Write(data)
+		return len(data), nil
+	}
 
 	// Add data to buffer
 	s.buffer.Write(data)
@@ -1170,9 +1782,11 @@ func (s *streamingGenerateResponseWriter) Write(data []byte) (int, error) {
 		// Extract content and reasoning_content from structured response
 		var content string
 		var thinking string
+		var logprobs []Logprob
 		if len(chunk.Choices) > 0 {
 			content = chunk.Choices[0].Delta.Content
 			thinking = chunk.Choices[0].Delta.ReasoningContent
+			logprobs = choiceLogprobs(chunk.Choices[0].Logprobs)
 		}
 
 		// Build Ollama generate chunk
@@ -1182,6 +1796,7 @@ func (s *streamingGenerateResponseWriter) Write(data []byte) (int, error) {
 			Response:  content,
 			Thinking:  thinking,
 			Done:      false,
+			Logprobs:  logprobs,
 		}
 
 		if jsonData, err := json.Marshal(ollamaChunk); err == nil {
@@ -1229,7 +1844,9 @@ func (h *HTTPHandler) convertChatResponse(w http.ResponseWriter, respRecorder *r
 
 	// Extract the message from structured response
 	var message Message
+	var logprobs []Logprob
 	if len(openAIResp.Choices) > 0 {
+		logprobs = choiceLogprobs(openAIResp.Choices[0].Logprobs)
 		message.Role = "assistant"
 		message.Content = openAIResp.Choices[0].Message.Content
 		// Include tool calls if present
@@ -1248,6 +1865,7 @@ func (h *HTTPHandler) convertChatResponse(w http.ResponseWriter, respRecorder *r
 		CreatedAt: time.Now(),
 		Message:   message,
 		Done:      true,
+		Logprobs:  logprobs,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1317,9 +1935,11 @@ func (h *HTTPHandler) convertGenerateResponse(w http.ResponseWriter, respRecorde
 	// Extract the message content and reasoning content from structured response
 	var content string
 	var thinking string
+	var logprobs []Logprob
 	if len(openAIResp.Choices) > 0 {
 		content = openAIResp.Choices[0].Message.Content
 		thinking = openAIResp.Choices[0].Message.ReasoningContent
+		logprobs = choiceLogprobs(openAIResp.Choices[0].Logprobs)
 	}
 
 	// Build Ollama generate response
@@ -1329,6 +1949,7 @@ func (h *HTTPHandler) convertGenerateResponse(w http.ResponseWriter, respRecorde
 		Response:  content,
 		Thinking:  thinking,
 		Done:      true,
+		Logprobs:  logprobs,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1,8 +1,20 @@
 package ollama
 
 import (
+	"bytes"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/docker/model-runner/pkg/distribution/oci"
+	"github.com/docker/model-runner/pkg/distribution/types"
+	"github.com/docker/model-runner/pkg/inference"
+	"github.com/docker/model-runner/pkg/inference/models"
+	"github.com/docker/model-runner/pkg/inference/scheduling"
+	"github.com/sirupsen/logrus"
 )
 
 func TestConvertMessages_Multimodal(t *testing.T) {
@@ -232,3 +244,474 @@ func TestConvertMessages_PreservesOrder(t *testing.T) {
 		t.Errorf("Last message should have 2 content parts (text + image), got %d", len(content))
 	}
 }
+
+func TestShowParametersAndModelfile(t *testing.T) {
+	ctxSize := int32(4096)
+	config := &types.Config{
+		ContextSize: &ctxSize,
+		GGUF: map[string]string{
+			"general.sampling.temp":  "0.7",
+			"general.sampling.top_k": "40",
+		},
+	}
+
+	parameters := showParameters(config, nil)
+	for _, want := range []string{"num_ctx", "4096", "temperature", "0.7", "top_k", "40"} {
+		if !strings.Contains(parameters, want) {
+			t.Errorf("Expected parameters to contain %q, got %q", want, parameters)
+		}
+	}
+
+	modelfile := buildModelfile("ai/smollm2", "{{ .Prompt }}", parameters, "MIT")
+	for _, want := range []string{
+		"FROM ai/smollm2\n",
+		"TEMPLATE \"\"\"{{ .Prompt }}\"\"\"\n",
+		"PARAMETER num_ctx 4096\n",
+		"PARAMETER temperature 0.7\n",
+		"PARAMETER top_k 40\n",
+		"LICENSE \"\"\"MIT\"\"\"\n",
+	} {
+		if !strings.Contains(modelfile, want) {
+			t.Errorf("Expected modelfile to contain %q, got:\n%s", want, modelfile)
+		}
+	}
+
+	if got := showParameters(&types.Config{}, nil); got != "" {
+		t.Errorf("Expected empty parameters for bare config, got %q", got)
+	}
+
+	// Settings applied with configure override the model's own.
+	configured := int32(16384)
+	runner := &scheduling.ModelConfigEntry{
+		Config: inference.BackendConfiguration{
+			ContextSize:  &configured,
+			RuntimeFlags: []string{"--temp", "0.2", "--top-p=0.9", "--no-mmap"},
+		},
+		DefaultStop: []string{"<|end|>"},
+	}
+	parameters = showParameters(config, runner)
+	modelfile = buildModelfile("ai/smollm2", "", parameters, "")
+	for _, want := range []string{
+		"PARAMETER num_ctx 16384\n",
+		"PARAMETER temperature 0.2\n",
+		"PARAMETER top_k 40\n",
+		"PARAMETER top_p 0.9\n",
+		"PARAMETER stop \"<|end|>\"\n",
+	} {
+		if !strings.Contains(modelfile, want) {
+			t.Errorf("Expected modelfile to contain %q, got:\n%s", want, modelfile)
+		}
+	}
+	if strings.Contains(parameters, "4096") || strings.Contains(parameters, "0.7") {
+		t.Errorf("Expected configured settings to replace the model's, got %q", parameters)
+	}
+}
+
+func TestConvertFormatToResponseFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{name: "empty", format: "", expected: "null"},
+		{name: "json", format: `"json"`, expected: `{"type":"json_object"}`},
+		{name: "unknown string", format: `"yaml"`, expected: "null"},
+		{
+			name:     "schema",
+			format:   `{"type":"object","properties":{"age":{"type":"integer"}}}`,
+			expected: `{"json_schema":{"name":"response","schema":{"properties":{"age":{"type":"integer"}},"type":"object"}},"type":"json_schema"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := convertFormatToResponseFormat(json.RawMessage(tt.format))
+			got, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("Failed to marshal result: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplyThinkToRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		think    interface{}
+		expected string
+	}{
+		{name: "absent", think: nil, expected: `{}`},
+		{name: "true", think: true, expected: `{"chat_template_kwargs":{"enable_thinking":true}}`},
+		{name: "false", think: false, expected: `{"chat_template_kwargs":{"enable_thinking":false}}`},
+		{name: "level", think: "high", expected: `{"chat_template_kwargs":{"enable_thinking":true},"reasoning_effort":"high"}`},
+		{name: "invalid", think: "extreme", expected: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openAIReq := map[string]interface{}{}
+			applyThinkToRequest(tt.think, openAIReq)
+			got, err := json.Marshal(openAIReq)
+			if err != nil {
+				t.Fatalf("Failed to marshal request: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHandleNotFound(t *testing.T) {
+	h := NewHTTPHandler(logrus.NewEntry(logrus.StandardLogger()), nil, nil, nil, nil)
+
+	r := httptest.NewRequest(http.MethodPost, APIPrefix+"/unknown", http.NoBody)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	if body["error"] != "endpoint not found" {
+		t.Errorf("Expected endpoint not found error, got %q", body["error"])
+	}
+}
+
+func TestBlobEndpoints(t *testing.T) {
+	log := logrus.NewEntry(logrus.StandardLogger())
+	manager := models.NewManager(log, models.ClientConfig{
+		StoreRootPath: t.TempDir(),
+		Logger:        log,
+	})
+	h := NewHTTPHandler(log, nil, nil, nil, manager)
+
+	content := "blob-content"
+	hash, _, err := oci.SHA256(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to compute digest: %v", err)
+	}
+	blobPath := APIPrefix + "/blobs/" + hash.String()
+
+	serve := func(method, path, body string) int {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve(http.MethodHead, blobPath, ""); code != http.StatusNotFound {
+		t.Errorf("Expected HEAD of missing blob to return %d, got %d", http.StatusNotFound, code)
+	}
+	if code := serve(http.MethodPost, blobPath, "wrong-content"); code != http.StatusBadRequest {
+		t.Errorf("Expected POST with mismatched content to return %d, got %d", http.StatusBadRequest, code)
+	}
+	if code := serve(http.MethodPost, APIPrefix+"/blobs/not-a-digest", content); code != http.StatusBadRequest {
+		t.Errorf("Expected POST with invalid digest to return %d, got %d", http.StatusBadRequest, code)
+	}
+	if code := serve(http.MethodPost, blobPath, content); code != http.StatusCreated {
+		t.Errorf("Expected POST to return %d, got %d", http.StatusCreated, code)
+	}
+	if code := serve(http.MethodHead, blobPath, ""); code != http.StatusOK {
+		t.Errorf("Expected HEAD of stored blob to return %d, got %d", http.StatusOK, code)
+	}
+}
+
+func TestStreamingWriterLoadingStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := &streamingChatResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	s.writeStatus(statusLoadingModel)
+	// A late WriteHeader from the upstream proxy must not override the stream
+	s.WriteHeader(http.StatusOK)
+	_, _ = s.Write([]byte("data: [DONE]\n"))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), rec.Body.String())
+	}
+	var status streamStatus
+	if err := json.Unmarshal([]byte(lines[0]), &status); err != nil {
+		t.Fatalf("Failed to decode status line: %v", err)
+	}
+	if status.Status != statusLoadingModel || status.Done {
+		t.Errorf("Unexpected status line: %+v", status)
+	}
+	var final ChatResponse
+	if err := json.Unmarshal([]byte(lines[1]), &final); err != nil {
+		t.Fatalf("Failed to decode final line: %v", err)
+	}
+	if !final.Done {
+		t.Errorf("Expected final line to be done")
+	}
+}
+
+func TestStreamingWriterReportsFailedLoad(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := &streamingChatResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	s.writeStatus(statusLoadingModel)
+	http.Error(s, "unable to load runner: boom", http.StatusInternalServerError)
+	s.finish()
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a status and an error line, got %q", rec.Body.String())
+	}
+	var failure struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failure); err != nil {
+		t.Fatalf("Failed to decode error line: %v", err)
+	}
+	if failure.Error != "unable to load runner: boom" {
+		t.Errorf("Expected the load error, got %q", failure.Error)
+	}
+
+	// Without a status line, the error keeps its status code.
+	rec = httptest.NewRecorder()
+	s = &streamingChatResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+	http.Error(s, `{"error":{"message":"model not found"}}`, http.StatusNotFound)
+	s.finish()
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"error":"model not found"}` {
+		t.Errorf("Expected an Ollama error, got %s", got)
+	}
+}
+
+func TestStreamingWriterIgnoresHeartbeats(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := &streamingChatResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	_, _ = s.Write([]byte(": keepalive\n\n"))
+	_, _ = s.Write([]byte("data: " + `{"choices":[{"delta":{"content":"Hi"}}]}` + "\n\n: keepalive\n\n"))
+	_, _ = s.Write([]byte("data: [DONE]\n"))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a token and a final line, got %q", rec.Body.String())
+	}
+	var token ChatResponse
+	if err := json.Unmarshal([]byte(lines[0]), &token); err != nil {
+		t.Fatalf("Failed to decode token line: %v", err)
+	}
+	if token.Message.Content != "Hi" {
+		t.Errorf("Expected the token, got %+v", token)
+	}
+}
+
+func TestStreamingWriterLoadKeepAlive(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := &streamingChatResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	s.writeStatus(statusLoadingModel)
+	s.startKeepAlive(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	// The first backend output stops the keepalive.
+	_, _ = s.Write([]byte("data: " + `{"choices":[{"delta":{"content":"Hi"}}]}` + "\n"))
+	time.Sleep(5 * time.Millisecond)
+	_, _ = s.Write([]byte("data: [DONE]\n"))
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) < 4 {
+		t.Fatalf("Expected a status, keepalives, a token and a final line, got %q", rec.Body.String())
+	}
+	for _, line := range lines[1 : len(lines)-2] {
+		var chunk ChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			t.Fatalf("Failed to decode keepalive line: %v", err)
+		}
+		if chunk.Done || chunk.Message.Content != "" {
+			t.Errorf("Expected an empty keepalive chunk, got %+v", chunk)
+		}
+	}
+	var token ChatResponse
+	if err := json.Unmarshal([]byte(lines[len(lines)-2]), &token); err != nil {
+		t.Fatalf("Failed to decode token line: %v", err)
+	}
+	if token.Message.Content != "Hi" {
+		t.Errorf("Expected no keepalive after the first token, got %+v", token)
+	}
+}
+
+func TestStreamingWriterLoadKeepAliveFailedLoad(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := &streamingGenerateResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	s.writeStatus(statusLoadingModel)
+	s.startKeepAlive(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	http.Error(s, "unable to load runner: boom", http.StatusInternalServerError)
+	s.finish()
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected a status, keepalives and an error line, got %q", rec.Body.String())
+	}
+	var failure struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &failure); err != nil {
+		t.Fatalf("Failed to decode error line: %v", err)
+	}
+	if failure.Error != "unable to load runner: boom" {
+		t.Errorf("Expected the stream to end with the load error, got %q", lines[len(lines)-1])
+	}
+}
+
+func TestStreamingWriterAssemblesToolCalls(t *testing.T) {
+	rec := httptest.NewRecorder()
+	s := &streamingChatResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+
+	chunks := []string{
+		`{"choices":[{"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+	}
+	for _, c := range chunks {
+		_, _ = s.Write([]byte("data: " + c + "\n\n"))
+	}
+	_, _ = s.Write([]byte("data: [DONE]\n"))
+
+	var toolCalls []ToolCall
+	for _, line := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n") {
+		var resp ChatResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("Failed to decode line %q: %v", line, err)
+		}
+		toolCalls = append(toolCalls, resp.Message.ToolCalls...)
+	}
+
+	if len(toolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d: %q", len(toolCalls), rec.Body.String())
+	}
+	tc := toolCalls[0]
+	if tc.ID != "call_1" || tc.Function.Name != "get_weather" {
+		t.Errorf("Unexpected tool call: %+v", tc)
+	}
+	args, ok := tc.Function.Arguments.(map[string]interface{})
+	if !ok || args["city"] != "Paris" {
+		t.Errorf("Expected parsed arguments with city=Paris, got %#v", tc.Function.Arguments)
+	}
+}
+
+func TestStreamingWritersEmitThinking(t *testing.T) {
+	chunk := "data: " + `{"choices":[{"delta":{"reasoning_content":"Let me think","content":""}}]}` + "\n\n"
+
+	chatRec := httptest.NewRecorder()
+	chat := &streamingChatResponseWriter{
+		w:         chatRec,
+		modelName: "ai/qwen3",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+	_, _ = chat.Write([]byte(chunk))
+	var chatResp ChatResponse
+	if err := json.Unmarshal(bytes.TrimSpace(chatRec.Body.Bytes()), &chatResp); err != nil {
+		t.Fatalf("Failed to decode chat chunk: %v", err)
+	}
+	if chatResp.Message.Thinking != "Let me think" || chatResp.Message.Content != "" {
+		t.Errorf("Expected thinking to be split from content, got %+v", chatResp.Message)
+	}
+
+	genRec := httptest.NewRecorder()
+	gen := &streamingGenerateResponseWriter{
+		w:         genRec,
+		modelName: "ai/qwen3",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+	_, _ = gen.Write([]byte(chunk))
+	var genResp GenerateResponse
+	if err := json.Unmarshal(bytes.TrimSpace(genRec.Body.Bytes()), &genResp); err != nil {
+		t.Fatalf("Failed to decode generate chunk: %v", err)
+	}
+	if genResp.Thinking != "Let me think" || genResp.Response != "" {
+		t.Errorf("Expected thinking to be split from response, got %+v", genResp)
+	}
+}
+
+func TestLogprobs(t *testing.T) {
+	openAIReq := map[string]interface{}{}
+	applyLogprobsToRequest(true, 3, openAIReq)
+	if openAIReq["logprobs"] != true || openAIReq["top_logprobs"] != 3 {
+		t.Errorf("Expected logprobs and top_logprobs to be mapped, got %v", openAIReq)
+	}
+	openAIReq = map[string]interface{}{}
+	applyLogprobsToRequest(false, 3, openAIReq)
+	if len(openAIReq) != 0 {
+		t.Errorf("Expected no logprobs without logprobs, got %v", openAIReq)
+	}
+
+	chunk := "data: " + `{"choices":[{"delta":{"content":"Hi"},"logprobs":{"content":[` +
+		`{"token":"Hi","logprob":-0.25,"bytes":[72,105],"top_logprobs":[{"token":"Hi","logprob":-0.25}]}]}}]}` + "\n\n"
+	rec := httptest.NewRecorder()
+	chat := &streamingChatResponseWriter{
+		w:         rec,
+		modelName: "ai/smollm2",
+		log:       logrus.NewEntry(logrus.StandardLogger()),
+	}
+	_, _ = chat.Write([]byte(chunk))
+	var resp ChatResponse
+	if err := json.Unmarshal(bytes.TrimSpace(rec.Body.Bytes()), &resp); err != nil {
+		t.Fatalf("Failed to decode chat chunk: %v", err)
+	}
+	if len(resp.Logprobs) != 1 || resp.Logprobs[0].Token != "Hi" || resp.Logprobs[0].Logprob != -0.25 ||
+		len(resp.Logprobs[0].TopLogprobs) != 1 {
+		t.Errorf("Expected the chunk's logprobs, got %+v", resp.Logprobs)
+	}
+}
+
+func TestHandleChatRejectsMultipleCompletions(t *testing.T) {
+	h := NewHTTPHandler(logrus.NewEntry(logrus.StandardLogger()), nil, nil, nil, nil)
+
+	body := `{"model":"ai/smollm2","messages":[{"role":"user","content":"hi"}],"options":{"n":2}}`
+	r := httptest.NewRequest(http.MethodPost, APIPrefix+"/chat", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "n > 1") {
+		t.Errorf("Expected multiple completions error, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,85 @@
+package ollama
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/model-runner/pkg/internal/utils"
+	"github.com/docker/model-runner/pkg/logging"
+)
+
+const (
+	// loadKeepAliveIntervalEnv names the environment variable holding the
+	// interval, as a Go duration, at which streaming chat and generate
+	// responses send empty chunks while their model loads. Zero disables
+	// them.
+	loadKeepAliveIntervalEnv = "MODEL_RUNNER_OLLAMA_LOAD_KEEPALIVE_INTERVAL"
+	// defaultLoadKeepAliveInterval is the keepalive interval used if
+	// loadKeepAliveIntervalEnv is unset.
+	defaultLoadKeepAliveInterval = 5 * time.Second
+)
+
+// loadKeepAliveIntervalFromEnv returns the keepalive interval configured in
+// the environment.
+func loadKeepAliveIntervalFromEnv(log logging.Logger) time.Duration {
+	raw := os.Getenv(loadKeepAliveIntervalEnv)
+	if raw == "" {
+		return defaultLoadKeepAliveInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < 0 {
+		log.Warnf("Ignoring invalid %s value %q", loadKeepAliveIntervalEnv, utils.SanitizeForLog(raw, -1))
+		return defaultLoadKeepAliveInterval
+	}
+	return interval
+}
+
+// loadKeepAlive periodically writes a chunk to a streaming response while its
+// model loads, so that clients don't give up on a cold start before the first
+// token arrives.
+type loadKeepAlive struct {
+	// stopped is closed to stop the keepalive.
+	stopped chan struct{}
+	// done is closed once the keepalive has stopped writing.
+	done chan struct{}
+	// once guards closing stopped.
+	once sync.Once
+}
+
+// startLoadKeepAlive calls write every interval until the returned keepalive
+// is stopped. It returns nil if interval isn't positive.
+func startLoadKeepAlive(interval time.Duration, write func()) *loadKeepAlive {
+	if interval <= 0 {
+		return nil
+	}
+	k := &loadKeepAlive{
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(k.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-k.stopped:
+				return
+			case <-ticker.C:
+				write()
+			}
+		}
+	}()
+	return k
+}
+
+// stop stops the keepalive, waiting for any chunk being written, so that the
+// response can be written to again. It may be called on a nil keepalive and
+// more than once.
+func (k *loadKeepAlive) stop() {
+	if k == nil {
+		return
+	}
+	k.once.Do(func() { close(k.stopped) })
+	<-k.done
+}